@@ -0,0 +1,92 @@
+package migrate
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/oarkflow/json"
+)
+
+// AuditEventBackup records a table dump taken before a destructive operation.
+const AuditEventBackup = "backup"
+
+// AuditEventApply records a migration applied by ApplyMigration.
+const AuditEventApply = "apply"
+
+// AuditEntry is one line of the audit log.
+type AuditEntry struct {
+	Time      time.Time `json:"time"`
+	Event     string    `json:"event"`
+	Migration string    `json:"migration,omitempty"`
+	// Seed is the seed file whose truncation caused a backup.
+	Seed string `json:"seed,omitempty"`
+	// Direction is "up" or "down".
+	Direction string `json:"direction,omitempty"`
+	Table     string `json:"table,omitempty"`
+	// Path is the file written by the event, such as a table dump.
+	Path string `json:"path,omitempty"`
+	// Author, Ticket and ReviewedBy are copied from the migration block.
+	Author     string `json:"author,omitempty"`
+	Ticket     string `json:"ticket,omitempty"`
+	ReviewedBy string `json:"reviewed_by,omitempty"`
+	// DownSQL is the Down of an applied migration as generated at apply
+	// time; rollbacks use it once the file has been modified.
+	DownSQL []string `json:"down_sql,omitempty"`
+}
+
+// WithAuditLog appends audit entries as JSON lines to path.
+func WithAuditLog(path string) ManagerOption {
+	return func(m *Manager) {
+		m.auditLog = path
+	}
+}
+
+// recordAudit appends e to the audit log, if one is configured.
+func (d *Manager) recordAudit(e AuditEntry) error {
+	if d.auditLog == "" {
+		return nil
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	if dir := filepath.Dir(d.auditLog); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create audit log directory: %w", err)
+		}
+	}
+	f, err := os.OpenFile(d.auditLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// ReadAuditLog returns the entries of the audit log at path, oldest first.
+func ReadAuditLog(path string) ([]AuditEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("invalid audit log entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
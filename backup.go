@@ -0,0 +1,190 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TableDumper writes a dump of a single table to path.
+type TableDumper interface {
+	DumpTable(ctx context.Context, table, path string) error
+}
+
+// CommandDumper dumps tables with pg_dump (PostgreSQL) or mysqldump (MySQL).
+type CommandDumper struct {
+	Database DatabaseConfig
+	// Command overrides the dump tool; by default pg_dump or mysqldump is
+	// looked up in PATH.
+	Command string
+}
+
+// NewCommandDumper returns a CommandDumper for the database described by db.
+func NewCommandDumper(db DatabaseConfig, command string) (*CommandDumper, error) {
+	driver, err := NormalizeDriver(db.Driver)
+	if err != nil {
+		return nil, err
+	}
+	if driver != DialectPostgres && driver != DialectMySQL {
+		return nil, fmt.Errorf("table backups are not supported for %s", driver)
+	}
+	db.Driver = driver
+	return &CommandDumper{Database: db, Command: command}, nil
+}
+
+// DumpTable runs the dump tool for table.
+func (c *CommandDumper) DumpTable(ctx context.Context, table, path string) error {
+	db := c.Database
+	switch db.Driver {
+	case DialectPostgres:
+		return runDumpTool(ctx, db, "pg_dump", c.Command, nil,
+			postgresToolArgs(db, "--dbname="+db.Database, "--table="+table, "--file="+path)...)
+	case DialectMySQL:
+		return runDumpTool(ctx, db, "mysqldump", c.Command, nil,
+			mysqlToolArgs(db, "--single-transaction", "--result-file="+path, db.Database, table)...)
+	}
+	return fmt.Errorf("table backups are not supported for %s", db.Driver)
+}
+
+// postgresToolArgs returns the connection arguments of the PostgreSQL
+// client tools for db, followed by args.
+func postgresToolArgs(db DatabaseConfig, args ...string) []string {
+	return append([]string{"--host=" + db.Host, "--port=" + strconv.Itoa(db.Port), "--username=" + db.Username}, args...)
+}
+
+// mysqlToolArgs returns the connection arguments of the MySQL client tools
+// for db, followed by args.
+func mysqlToolArgs(db DatabaseConfig, args ...string) []string {
+	return append([]string{"--host=" + db.Host, "--port=" + strconv.Itoa(db.Port), "--user=" + db.Username}, args...)
+}
+
+// runDumpTool runs the client tool name of db, or command when set, with
+// the password passed through the environment so it does not show up in
+// the process list.
+func runDumpTool(ctx context.Context, db DatabaseConfig, name, command string, stdin io.Reader, args ...string) error {
+	var env []string
+	switch db.Driver {
+	case DialectPostgres:
+		env = append(env, "PGPASSWORD="+db.Password)
+		if db.SSLMode != "" {
+			env = append(env, "PGSSLMODE="+db.SSLMode)
+		}
+	case DialectMySQL:
+		env = append(env, "MYSQL_PWD="+db.Password)
+	}
+	if command != "" {
+		name = command
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = stdin
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// WithBackups dumps every table that a migration is about to drop, or that
+// seeding with truncation is about to empty, into dir before its statements
+// run.
+func WithBackups(dir string, dumper TableDumper) ManagerOption {
+	return func(m *Manager) {
+		m.backupDir = dir
+		m.dumper = dumper
+	}
+}
+
+// destructiveTables lists the tables op drops. DeleteData always carries a
+// Where clause, so it never empties a whole table.
+func destructiveTables(op Operation) []string {
+	var tables []string
+	for _, dt := range op.DropTable {
+		tables = append(tables, dt.Name)
+	}
+	return tables
+}
+
+// backupTables dumps the tables that the Up (or Down) block of m destroys
+// and records each dump in the audit log. Tables that do not exist are
+// skipped. A failed dump aborts the migration.
+func (d *Manager) backupTables(m Migration, up bool) error {
+	if d.dumper == nil {
+		return nil
+	}
+	op, direction := m.Down, "down"
+	if up {
+		op, direction = m.Up, "up"
+	}
+	tables := destructiveTables(op)
+	if len(tables) == 0 {
+		return nil
+	}
+	if m.Connection != "" {
+		logger.Warn().Msgf("Migration %s uses its own connection; skipping table backups", m.Name)
+		return nil
+	}
+	return d.dumpTables(tables, m.Name, AuditEntry{
+		Migration:  m.Name,
+		Direction:  direction,
+		Author:     m.Author,
+		Ticket:     m.Ticket,
+		ReviewedBy: m.ReviewedBy,
+	})
+}
+
+// backupTruncatedTables dumps the tables that seeding seedFile with
+// --truncate is about to empty. A failed dump aborts the truncation.
+func (d *Manager) backupTruncatedTables(seedFile string, tables []string) error {
+	if d.dumper == nil || len(tables) == 0 {
+		return nil
+	}
+	base := filepath.Base(seedFile)
+	if isEncryptedSeed(base) {
+		base = strings.TrimSuffix(base, EncryptedSeedExt)
+	}
+	return d.dumpTables(tables, strings.TrimSuffix(base, filepath.Ext(base)), AuditEntry{Seed: seedFile})
+}
+
+// dumpTables dumps each existing table of tables into the backup directory,
+// in files named after label, and records each dump in the audit log with
+// the details of entry. Tables that do not exist are skipped.
+func (d *Manager) dumpTables(tables []string, label string, entry AuditEntry) error {
+	if err := os.MkdirAll(d.backupDir, 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	seen := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		if seen[table] {
+			continue
+		}
+		seen[table] = true
+		exists, err := d.TableExists(table)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		now := time.Now().UTC()
+		path := filepath.Join(d.backupDir, fmt.Sprintf("%s_%s_%s.sql", now.Format("20060102T150405Z"), label, table))
+		if err := d.dumper.DumpTable(context.Background(), table, path); err != nil {
+			return fmt.Errorf("failed to back up table %s: %w", table, err)
+		}
+		logger.Info().Msgf("Backed up table %s to %s", table, path)
+		audit := entry
+		audit.Time, audit.Event, audit.Table, audit.Path = now, AuditEventBackup, table, path
+		if err := d.recordAudit(audit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -7,6 +7,9 @@ import (
 )
 
 type bclDocument struct {
+	// Atomic = false runs every statement of the file on its own instead of
+	// in one transaction.
+	Atomic     *bool          `bcl:"atomic"`
 	Migrations []bclMigration `bcl:"Migration,block"`
 	Seeds      []bclSeed      `bcl:"Seed,block"`
 }
@@ -21,10 +24,26 @@ type bclMigration struct {
 	Down        []bclOperation   `bcl:"Down,block"`
 	Transaction []bclTransaction `bcl:"Transaction,block"`
 	Validate    []bclValidation  `bcl:"Validate,block"`
-	Disable     bool             `bcl:"Disable"`
+	Disabled    bool             `bcl:"Disabled"`
+	// Disable is the former name of Disabled.
+	Disable        bool     `bcl:"Disable"`
+	Tags           []string `bcl:"Tags"`
+	Repeatable     bool     `bcl:"Repeatable"`
+	Phase          string   `bcl:"Phase"`
+	RunAfter       string   `bcl:"RunAfter"`
+	RequiresWindow bool     `bcl:"RequiresWindow"`
+	Author         string   `bcl:"Author"`
+	Ticket         string   `bcl:"Ticket"`
+	ReviewedBy     string   `bcl:"ReviewedBy"`
+	DSLVersion     int      `bcl:"DSLVersion"`
+	ToolVersion    string   `bcl:"ToolVersion"`
 }
 
 type bclOperation struct {
+	// Step optionally labels the block, as in Up "backfill" { ... }.
+	Step string `bcl:",id"`
+	// Order is OperationOrderGrouped (the default) or OperationOrderDeclared.
+	Order                string                    `bcl:"order"`
 	AlterTable           []bclAlterTable           `bcl:"AlterTable,block"`
 	CreateTable          []bclCreateTable          `bcl:"CreateTable,block"`
 	DeleteData           []bclDeleteData           `bcl:"DeleteData,block"`
@@ -46,25 +65,48 @@ type bclOperation struct {
 	CreateTrigger        []bclCreateTrigger        `bcl:"CreateTrigger,block"`
 	DropTrigger          []bclDropTrigger          `bcl:"DropTrigger,block"`
 	RenameTrigger        []bclRenameTrigger        `bcl:"RenameTrigger,block"`
+	RebuildIndex         []bclRebuildIndex         `bcl:"RebuildIndex,block"`
+	ReindexTable         []bclReindexTable         `bcl:"ReindexTable,block"`
+	CreatePublication    []bclCreatePublication    `bcl:"CreatePublication,block"`
+	AlterPublication     []bclAlterPublication     `bcl:"AlterPublication,block"`
+	DropPublication      []bclDropPublication      `bcl:"DropPublication,block"`
+	CreateRole           []bclCreateRole           `bcl:"CreateRole,block"`
+	AlterRole            []bclAlterRole            `bcl:"AlterRole,block"`
+	DropRole             []bclDropRole             `bcl:"DropRole,block"`
+	CreateDatabase       []bclCreateDatabase       `bcl:"CreateDatabase,block"`
+	DropDatabase         []bclDropDatabase         `bcl:"DropDatabase,block"`
+	Vacuum               []bclVacuum               `bcl:"Vacuum,block"`
+	Analyze              []bclAnalyze              `bcl:"Analyze,block"`
 }
 
 type bclAlterTable struct {
-	Name         string           `bcl:",id"`
-	AddFields    []bclAddField    `bcl:"AddField,block"`
-	DropFields   []bclDropField   `bcl:"DropField,block"`
-	RenameFields []bclRenameField `bcl:"RenameField,block"`
+	Name            string           `bcl:",id"`
+	AddFields       []bclAddField    `bcl:"AddField,block"`
+	DropFields      []bclDropField   `bcl:"DropField,block"`
+	RenameFields    []bclRenameField `bcl:"RenameField,block"`
+	DropPrimaryKey  []bclPrimaryKey  `bcl:"DropPrimaryKey,block"`
+	AddPrimaryKey   []bclPrimaryKey  `bcl:"AddPrimaryKey,block"`
+	ContinueOnError bool             `bcl:"continue_on_error"`
+}
+
+type bclPrimaryKey struct {
+	ID     string   `bcl:",id"`
+	Name   string   `bcl:"name"`
+	Fields []string `bcl:"fields"`
 }
 
 type bclCreateTable struct {
-	Name       string        `bcl:",id"`
-	AddFields  []bclAddField `bcl:"Field,block"`
-	PrimaryKey []string      `bcl:"PrimaryKey"`
+	Name            string        `bcl:",id"`
+	AddFields       []bclAddField `bcl:"Field,block"`
+	PrimaryKey      []string      `bcl:"PrimaryKey"`
+	ContinueOnError bool          `bcl:"continue_on_error"`
 }
 
 type bclAddField struct {
 	ID            string      `bcl:",id"`
 	Name          string      `bcl:"name"`
 	Type          string      `bcl:"type"`
+	RawType       string      `bcl:"raw_type"`
 	Nullable      bool        `bcl:"nullable"`
 	Default       any         `bcl:"default"`
 	Check         string      `bcl:"check"`
@@ -75,6 +117,7 @@ type bclAddField struct {
 	Unique        bool        `bcl:"unique"`
 	Index         bool        `bcl:"index"`
 	ForeignKey    *ForeignKey `bcl:"foreign_key"`
+	Backfill      any         `bcl:"backfill"`
 }
 
 type bclDropField struct {
@@ -90,113 +133,218 @@ type bclRenameField struct {
 }
 
 type bclRenameTable struct {
-	Name    string `bcl:",id"`
-	OldName string `bcl:"old_name"`
-	NewName string `bcl:"new_name"`
+	Name            string `bcl:",id"`
+	OldName         string `bcl:"old_name"`
+	NewName         string `bcl:"new_name"`
+	ContinueOnError bool   `bcl:"continue_on_error"`
 }
 
 type bclDeleteData struct {
-	Name  string `bcl:",id"`
-	Where string `bcl:"Where"`
+	Name            string `bcl:",id"`
+	Where           string `bcl:"Where"`
+	ContinueOnError bool   `bcl:"continue_on_error"`
 }
 
 type bclDropEnumType struct {
-	Name     string `bcl:",id"`
-	IfExists bool   `bcl:"IfExists"`
+	Name            string `bcl:",id"`
+	IfExists        bool   `bcl:"IfExists"`
+	ContinueOnError bool   `bcl:"continue_on_error"`
 }
 
 type bclDropRowPolicy struct {
-	Name     string `bcl:",id"`
-	Table    string `bcl:"Table"`
-	IfExists bool   `bcl:"if_exists"`
+	Name            string `bcl:",id"`
+	Table           string `bcl:"Table"`
+	IfExists        bool   `bcl:"if_exists"`
+	ContinueOnError bool   `bcl:"continue_on_error"`
 }
 
 type bclDropMaterializedView struct {
-	Name     string `bcl:",id"`
-	IfExists bool   `bcl:"if_exists"`
+	Name            string `bcl:",id"`
+	IfExists        bool   `bcl:"if_exists"`
+	ContinueOnError bool   `bcl:"continue_on_error"`
 }
 
 type bclDropTable struct {
-	Name    string `bcl:",id"`
-	Cascade bool   `bcl:"Cascade"`
+	Name            string `bcl:",id"`
+	Cascade         bool   `bcl:"Cascade"`
+	ContinueOnError bool   `bcl:"continue_on_error"`
 }
 
 type bclDropSchema struct {
-	Name     string `bcl:",id"`
-	Cascade  bool   `bcl:"cascade"`
-	IfExists bool   `bcl:"if_exists"`
+	Name            string `bcl:",id"`
+	Cascade         bool   `bcl:"cascade"`
+	IfExists        bool   `bcl:"if_exists"`
+	ContinueOnError bool   `bcl:"continue_on_error"`
 }
 
 type bclCreateView struct {
-	Name       string `bcl:",id"`
-	Definition string `bcl:"definition"`
-	OrReplace  bool   `bcl:"or_replace"`
+	Name            string `bcl:",id"`
+	Definition      string `bcl:"definition"`
+	OrReplace       bool   `bcl:"or_replace"`
+	ContinueOnError bool   `bcl:"continue_on_error"`
 }
 
 type bclDropView struct {
-	Name     string `bcl:",id"`
-	Cascade  bool   `bcl:"cascade"`
-	IfExists bool   `bcl:"if_exists"`
+	Name            string `bcl:",id"`
+	Cascade         bool   `bcl:"cascade"`
+	IfExists        bool   `bcl:"if_exists"`
+	ContinueOnError bool   `bcl:"continue_on_error"`
 }
 
 type bclRenameView struct {
-	Name    string `bcl:",id"`
-	OldName string `bcl:"old_name"`
-	NewName string `bcl:"new_name"`
+	Name            string `bcl:",id"`
+	OldName         string `bcl:"old_name"`
+	NewName         string `bcl:"new_name"`
+	ContinueOnError bool   `bcl:"continue_on_error"`
 }
 
 type bclCreateFunction struct {
-	Name       string `bcl:",id"`
-	Definition string `bcl:"definition"`
-	OrReplace  bool   `bcl:"or_replace"`
+	Name            string `bcl:",id"`
+	Definition      string `bcl:"definition"`
+	OrReplace       bool   `bcl:"or_replace"`
+	ContinueOnError bool   `bcl:"continue_on_error"`
 }
 
 type bclDropFunction struct {
-	Name     string `bcl:",id"`
-	Cascade  bool   `bcl:"cascade"`
-	IfExists bool   `bcl:"if_exists"`
+	Name            string `bcl:",id"`
+	Cascade         bool   `bcl:"cascade"`
+	IfExists        bool   `bcl:"if_exists"`
+	ContinueOnError bool   `bcl:"continue_on_error"`
 }
 
 type bclRenameFunction struct {
-	Name    string `bcl:",id"`
-	OldName string `bcl:"old_name"`
-	NewName string `bcl:"new_name"`
+	Name            string `bcl:",id"`
+	OldName         string `bcl:"old_name"`
+	NewName         string `bcl:"new_name"`
+	ContinueOnError bool   `bcl:"continue_on_error"`
 }
 
 type bclCreateProcedure struct {
-	Name       string `bcl:",id"`
-	Definition string `bcl:"definition"`
-	OrReplace  bool   `bcl:"or_replace"`
+	Name            string `bcl:",id"`
+	Definition      string `bcl:"definition"`
+	OrReplace       bool   `bcl:"or_replace"`
+	ContinueOnError bool   `bcl:"continue_on_error"`
 }
 
 type bclDropProcedure struct {
-	Name     string `bcl:",id"`
-	Cascade  bool   `bcl:"cascade"`
-	IfExists bool   `bcl:"if_exists"`
+	Name            string `bcl:",id"`
+	Cascade         bool   `bcl:"cascade"`
+	IfExists        bool   `bcl:"if_exists"`
+	ContinueOnError bool   `bcl:"continue_on_error"`
 }
 
 type bclRenameProcedure struct {
-	Name    string `bcl:",id"`
-	OldName string `bcl:"old_name"`
-	NewName string `bcl:"new_name"`
+	Name            string `bcl:",id"`
+	OldName         string `bcl:"old_name"`
+	NewName         string `bcl:"new_name"`
+	ContinueOnError bool   `bcl:"continue_on_error"`
 }
 
 type bclCreateTrigger struct {
-	Name       string `bcl:",id"`
-	Definition string `bcl:"definition"`
-	OrReplace  bool   `bcl:"or_replace"`
+	Name            string `bcl:",id"`
+	Definition      string `bcl:"definition"`
+	OrReplace       bool   `bcl:"or_replace"`
+	ContinueOnError bool   `bcl:"continue_on_error"`
 }
 
 type bclDropTrigger struct {
-	Name     string `bcl:",id"`
-	Cascade  bool   `bcl:"cascade"`
-	IfExists bool   `bcl:"if_exists"`
+	Name            string `bcl:",id"`
+	Cascade         bool   `bcl:"cascade"`
+	IfExists        bool   `bcl:"if_exists"`
+	ContinueOnError bool   `bcl:"continue_on_error"`
 }
 
 type bclRenameTrigger struct {
-	Name    string `bcl:",id"`
-	OldName string `bcl:"old_name"`
-	NewName string `bcl:"new_name"`
+	Name            string `bcl:",id"`
+	OldName         string `bcl:"old_name"`
+	NewName         string `bcl:"new_name"`
+	ContinueOnError bool   `bcl:"continue_on_error"`
+}
+
+type bclRebuildIndex struct {
+	Name            string `bcl:",id"`
+	Table           string `bcl:"table"`
+	ContinueOnError bool   `bcl:"continue_on_error"`
+}
+
+type bclReindexTable struct {
+	Name            string `bcl:",id"`
+	ContinueOnError bool   `bcl:"continue_on_error"`
+}
+
+type bclCreatePublication struct {
+	Name            string   `bcl:",id"`
+	Tables          []string `bcl:"tables"`
+	AllTables       bool     `bcl:"all_tables"`
+	Publish         string   `bcl:"publish"`
+	ContinueOnError bool     `bcl:"continue_on_error"`
+}
+
+type bclAlterPublication struct {
+	Name            string   `bcl:",id"`
+	AddTables       []string `bcl:"add_tables"`
+	DropTables      []string `bcl:"drop_tables"`
+	SetTables       []string `bcl:"set_tables"`
+	Publish         string   `bcl:"publish"`
+	ContinueOnError bool     `bcl:"continue_on_error"`
+}
+
+type bclDropPublication struct {
+	Name            string `bcl:",id"`
+	IfExists        bool   `bcl:"if_exists"`
+	ContinueOnError bool   `bcl:"continue_on_error"`
+}
+
+type bclCreateRole struct {
+	Name            string   `bcl:",id"`
+	Login           bool     `bcl:"login"`
+	Password        string   `bcl:"password"`
+	InRoles         []string `bcl:"in_roles"`
+	Options         []string `bcl:"options"`
+	ContinueOnError bool     `bcl:"continue_on_error"`
+}
+
+type bclAlterRole struct {
+	Name            string   `bcl:",id"`
+	Password        string   `bcl:"password"`
+	RenameTo        string   `bcl:"rename_to"`
+	Options         []string `bcl:"options"`
+	ContinueOnError bool     `bcl:"continue_on_error"`
+}
+
+type bclDropRole struct {
+	Name            string `bcl:",id"`
+	IfExists        bool   `bcl:"if_exists"`
+	ContinueOnError bool   `bcl:"continue_on_error"`
+}
+
+type bclCreateDatabase struct {
+	Name            string `bcl:",id"`
+	IfNotExists     bool   `bcl:"if_not_exists"`
+	Owner           string `bcl:"owner"`
+	Template        string `bcl:"template"`
+	Encoding        string `bcl:"encoding"`
+	Collation       string `bcl:"collation"`
+	ContinueOnError bool   `bcl:"continue_on_error"`
+}
+
+type bclDropDatabase struct {
+	Name            string `bcl:",id"`
+	IfExists        bool   `bcl:"if_exists"`
+	Force           bool   `bcl:"force"`
+	ContinueOnError bool   `bcl:"continue_on_error"`
+}
+
+type bclVacuum struct {
+	Name            string `bcl:",id"`
+	Full            bool   `bcl:"full"`
+	Analyze         bool   `bcl:"analyze"`
+	ContinueOnError bool   `bcl:"continue_on_error"`
+}
+
+type bclAnalyze struct {
+	Name            string `bcl:",id"`
+	ContinueOnError bool   `bcl:"continue_on_error"`
 }
 
 type bclTransaction struct {
@@ -218,6 +366,7 @@ type bclSeed struct {
 	Combine   []string       `bcl:"combine"`
 	Condition string         `bcl:"condition"`
 	Rows      int            `bcl:"rows"`
+	Profile   string         `bcl:"profile"`
 }
 
 type bclSeedField struct {
@@ -232,12 +381,17 @@ type bclSeedField struct {
 func ParseMigrationsBCL(data []byte) ([]Migration, error) {
 	var doc bclDocument
 	if err := bcl.Unmarshal(data, &doc); err != nil {
-		return nil, err
+		return nil, newParseError(data, err)
 	}
 	migrations := make([]Migration, 0, len(doc.Migrations))
 	seen := make(map[string]struct{}, len(doc.Migrations))
+	orders := declaredOperationOrders(data)
 	for i, item := range doc.Migrations {
 		migration := item.toMigration()
+		migration.Atomic = doc.Atomic
+		if err := applyDeclaredOrder(&migration, item, orders); err != nil {
+			return nil, err
+		}
 		if migration.Name == "" {
 			return nil, fmt.Errorf("migration block %d is missing a name", i+1)
 		}
@@ -277,12 +431,13 @@ func FindMigrationBCL(data []byte, name string) (Migration, error) {
 func ParseSeedsBCL(data []byte) ([]SeedDefinition, error) {
 	var doc bclDocument
 	if err := bcl.Unmarshal(data, &doc); err != nil {
-		return nil, err
+		return nil, newParseError(data, err)
 	}
 	seeds := make([]SeedDefinition, 0, len(doc.Seeds))
 	seen := make(map[string]struct{}, len(doc.Seeds))
 	for i, item := range doc.Seeds {
 		seed := item.toSeedDefinition()
+		seed.Atomic = doc.Atomic
 		if seed.Name == "" {
 			return nil, fmt.Errorf("seed block %d is missing a name", i+1)
 		}
@@ -308,17 +463,42 @@ func ParseSeedBCL(data []byte) (SeedDefinition, error) {
 
 func (m bclMigration) toMigration() Migration {
 	return Migration{
-		Name:        m.Name,
-		Version:     m.Version,
-		Description: m.Description,
-		Connection:  m.Connection,
-		Driver:      m.Driver,
-		Up:          mergeBCLOperations(m.Up),
-		Down:        mergeBCLOperations(m.Down),
-		Transaction: mapSlice(m.Transaction, func(v bclTransaction) Transaction { return v.toTransaction() }),
-		Validate:    mapSlice(m.Validate, func(v bclValidation) Validation { return v.toValidation() }),
-		Disable:     m.Disable,
+		Name:           m.Name,
+		Version:        m.Version,
+		Description:    m.Description,
+		Connection:     m.Connection,
+		Driver:         m.Driver,
+		Up:             mergeBCLOperations(m.Up),
+		Down:           mergeBCLOperations(m.Down),
+		UpSteps:        bclOperationSteps(m.Up),
+		DownSteps:      bclOperationSteps(m.Down),
+		Transaction:    mapSlice(m.Transaction, func(v bclTransaction) Transaction { return v.toTransaction() }),
+		Validate:       mapSlice(m.Validate, func(v bclValidation) Validation { return v.toValidation() }),
+		Disabled:       m.Disabled || m.Disable,
+		Tags:           m.Tags,
+		Repeatable:     m.Repeatable,
+		Phase:          m.Phase,
+		RunAfter:       m.RunAfter,
+		RequiresWindow: m.RequiresWindow,
+		Author:         m.Author,
+		Ticket:         m.Ticket,
+		ReviewedBy:     m.ReviewedBy,
+		DSLVersion:     m.DSLVersion,
+		ToolVersion:    m.ToolVersion,
+	}
+}
+
+// bclOperationSteps keeps several Up or Down blocks as ordered steps. A
+// single block needs no steps.
+func bclOperationSteps(items []bclOperation) []OperationStep {
+	if len(items) < 2 {
+		return nil
+	}
+	steps := make([]OperationStep, 0, len(items))
+	for _, item := range items {
+		steps = append(steps, OperationStep{Name: item.Step, Operation: mergeBCLOperations([]bclOperation{item})})
 	}
+	return steps
 }
 
 func mergeBCLOperations(items []bclOperation) Operation {
@@ -346,6 +526,18 @@ func mergeBCLOperations(items []bclOperation) Operation {
 		out.CreateTrigger = append(out.CreateTrigger, op.CreateTrigger...)
 		out.DropTrigger = append(out.DropTrigger, op.DropTrigger...)
 		out.RenameTrigger = append(out.RenameTrigger, op.RenameTrigger...)
+		out.RebuildIndex = append(out.RebuildIndex, op.RebuildIndex...)
+		out.ReindexTable = append(out.ReindexTable, op.ReindexTable...)
+		out.CreatePublication = append(out.CreatePublication, op.CreatePublication...)
+		out.AlterPublication = append(out.AlterPublication, op.AlterPublication...)
+		out.DropPublication = append(out.DropPublication, op.DropPublication...)
+		out.CreateRole = append(out.CreateRole, op.CreateRole...)
+		out.AlterRole = append(out.AlterRole, op.AlterRole...)
+		out.DropRole = append(out.DropRole, op.DropRole...)
+		out.CreateDatabase = append(out.CreateDatabase, op.CreateDatabase...)
+		out.DropDatabase = append(out.DropDatabase, op.DropDatabase...)
+		out.Vacuum = append(out.Vacuum, op.Vacuum...)
+		out.Analyze = append(out.Analyze, op.Analyze...)
 	}
 	return out
 }
@@ -373,6 +565,18 @@ func (op bclOperation) toOperation() Operation {
 		CreateTrigger:        mapSlice(op.CreateTrigger, func(v bclCreateTrigger) CreateTrigger { return v.toCreateTrigger() }),
 		DropTrigger:          mapSlice(op.DropTrigger, func(v bclDropTrigger) DropTrigger { return v.toDropTrigger() }),
 		RenameTrigger:        mapSlice(op.RenameTrigger, func(v bclRenameTrigger) RenameTrigger { return v.toRenameTrigger() }),
+		RebuildIndex:         mapSlice(op.RebuildIndex, func(v bclRebuildIndex) RebuildIndex { return v.toRebuildIndex() }),
+		ReindexTable:         mapSlice(op.ReindexTable, func(v bclReindexTable) ReindexTable { return v.toReindexTable() }),
+		CreatePublication:    mapSlice(op.CreatePublication, func(v bclCreatePublication) CreatePublication { return v.toCreatePublication() }),
+		AlterPublication:     mapSlice(op.AlterPublication, func(v bclAlterPublication) AlterPublication { return v.toAlterPublication() }),
+		DropPublication:      mapSlice(op.DropPublication, func(v bclDropPublication) DropPublication { return v.toDropPublication() }),
+		CreateRole:           mapSlice(op.CreateRole, func(v bclCreateRole) CreateRole { return v.toCreateRole() }),
+		AlterRole:            mapSlice(op.AlterRole, func(v bclAlterRole) AlterRole { return v.toAlterRole() }),
+		DropRole:             mapSlice(op.DropRole, func(v bclDropRole) DropRole { return v.toDropRole() }),
+		CreateDatabase:       mapSlice(op.CreateDatabase, func(v bclCreateDatabase) CreateDatabase { return v.toCreateDatabase() }),
+		DropDatabase:         mapSlice(op.DropDatabase, func(v bclDropDatabase) DropDatabase { return v.toDropDatabase() }),
+		Vacuum:               mapSlice(op.Vacuum, func(v bclVacuum) Vacuum { return v.toVacuum() }),
+		Analyze:              mapSlice(op.Analyze, func(v bclAnalyze) Analyze { return v.toAnalyze() }),
 	}
 }
 
@@ -382,6 +586,13 @@ func (at bclAlterTable) toAlterTable() AlterTable {
 		AddFields:    mapSlice(at.AddFields, func(v bclAddField) AddField { return v.toAddField() }),
 		DropFields:   mapSlice(at.DropFields, func(v bclDropField) DropField { return v.toDropField() }),
 		RenameFields: mapSlice(at.RenameFields, func(v bclRenameField) RenameField { return v.toRenameField() }),
+		DropPrimaryKey: mapSlice(at.DropPrimaryKey, func(v bclPrimaryKey) DropPrimaryKey {
+			return DropPrimaryKey{Name: firstNonEmpty(v.ID, v.Name)}
+		}),
+		AddPrimaryKey: mapSlice(at.AddPrimaryKey, func(v bclPrimaryKey) AddPrimaryKey {
+			return AddPrimaryKey{Name: firstNonEmpty(v.ID, v.Name), Fields: v.Fields}
+		}),
+		Optional: Optional{ContinueOnError: at.ContinueOnError},
 	}
 }
 
@@ -390,6 +601,7 @@ func (ct bclCreateTable) toCreateTable() CreateTable {
 		Name:       ct.Name,
 		AddFields:  mapSlice(ct.AddFields, func(v bclAddField) AddField { return v.toAddField() }),
 		PrimaryKey: ct.PrimaryKey,
+		Optional:   Optional{ContinueOnError: ct.ContinueOnError},
 	}
 }
 
@@ -397,6 +609,7 @@ func (f bclAddField) toAddField() AddField {
 	return AddField{
 		Name:          firstNonEmpty(f.ID, f.Name),
 		Type:          f.Type,
+		RawType:       f.RawType,
 		Nullable:      f.Nullable,
 		Default:       f.Default,
 		Check:         f.Check,
@@ -407,6 +620,7 @@ func (f bclAddField) toAddField() AddField {
 		Unique:        f.Unique,
 		Index:         f.Index,
 		ForeignKey:    f.ForeignKey,
+		Backfill:      f.Backfill,
 	}
 }
 
@@ -419,79 +633,127 @@ func (f bclRenameField) toRenameField() RenameField {
 }
 
 func (rt bclRenameTable) toRenameTable() RenameTable {
-	return RenameTable{OldName: firstNonEmpty(rt.OldName, rt.Name), NewName: rt.NewName}
+	return RenameTable{OldName: firstNonEmpty(rt.OldName, rt.Name), NewName: rt.NewName, Optional: Optional{ContinueOnError: rt.ContinueOnError}}
 }
 
 func (d bclDeleteData) toDeleteData() DeleteData {
-	return DeleteData{Name: d.Name, Where: d.Where}
+	return DeleteData{Name: d.Name, Where: d.Where, Optional: Optional{ContinueOnError: d.ContinueOnError}}
 }
 
 func (d bclDropEnumType) toDropEnumType() DropEnumType {
-	return DropEnumType{Name: d.Name, IfExists: d.IfExists}
+	return DropEnumType{Name: d.Name, IfExists: d.IfExists, Optional: Optional{ContinueOnError: d.ContinueOnError}}
 }
 
 func (d bclDropRowPolicy) toDropRowPolicy() DropRowPolicy {
-	return DropRowPolicy{Name: d.Name, Table: d.Table, IfExists: d.IfExists}
+	return DropRowPolicy{Name: d.Name, Table: d.Table, IfExists: d.IfExists, Optional: Optional{ContinueOnError: d.ContinueOnError}}
 }
 
 func (d bclDropMaterializedView) toDropMaterializedView() DropMaterializedView {
-	return DropMaterializedView{Name: d.Name, IfExists: d.IfExists}
+	return DropMaterializedView{Name: d.Name, IfExists: d.IfExists, Optional: Optional{ContinueOnError: d.ContinueOnError}}
 }
 
 func (d bclDropTable) toDropTable() DropTable {
-	return DropTable{Name: d.Name, Cascade: d.Cascade}
+	return DropTable{Name: d.Name, Cascade: d.Cascade, Optional: Optional{ContinueOnError: d.ContinueOnError}}
 }
 
 func (d bclDropSchema) toDropSchema() DropSchema {
-	return DropSchema{Name: d.Name, Cascade: d.Cascade, IfExists: d.IfExists}
+	return DropSchema{Name: d.Name, Cascade: d.Cascade, IfExists: d.IfExists, Optional: Optional{ContinueOnError: d.ContinueOnError}}
 }
 
 func (v bclCreateView) toCreateView() CreateView {
-	return CreateView{Name: v.Name, Definition: v.Definition, OrReplace: v.OrReplace}
+	return CreateView{Name: v.Name, Definition: v.Definition, OrReplace: v.OrReplace, Optional: Optional{ContinueOnError: v.ContinueOnError}}
 }
 
 func (v bclDropView) toDropView() DropView {
-	return DropView{Name: v.Name, Cascade: v.Cascade, IfExists: v.IfExists}
+	return DropView{Name: v.Name, Cascade: v.Cascade, IfExists: v.IfExists, Optional: Optional{ContinueOnError: v.ContinueOnError}}
 }
 
 func (v bclRenameView) toRenameView() RenameView {
-	return RenameView{OldName: firstNonEmpty(v.OldName, v.Name), NewName: v.NewName}
+	return RenameView{OldName: firstNonEmpty(v.OldName, v.Name), NewName: v.NewName, Optional: Optional{ContinueOnError: v.ContinueOnError}}
 }
 
 func (f bclCreateFunction) toCreateFunction() CreateFunction {
-	return CreateFunction{Name: f.Name, Definition: f.Definition, OrReplace: f.OrReplace}
+	return CreateFunction{Name: f.Name, Definition: f.Definition, OrReplace: f.OrReplace, Optional: Optional{ContinueOnError: f.ContinueOnError}}
 }
 
 func (f bclDropFunction) toDropFunction() DropFunction {
-	return DropFunction{Name: f.Name, Cascade: f.Cascade, IfExists: f.IfExists}
+	return DropFunction{Name: f.Name, Cascade: f.Cascade, IfExists: f.IfExists, Optional: Optional{ContinueOnError: f.ContinueOnError}}
 }
 
 func (f bclRenameFunction) toRenameFunction() RenameFunction {
-	return RenameFunction{OldName: firstNonEmpty(f.OldName, f.Name), NewName: f.NewName}
+	return RenameFunction{OldName: firstNonEmpty(f.OldName, f.Name), NewName: f.NewName, Optional: Optional{ContinueOnError: f.ContinueOnError}}
 }
 
 func (p bclCreateProcedure) toCreateProcedure() CreateProcedure {
-	return CreateProcedure{Name: p.Name, Definition: p.Definition, OrReplace: p.OrReplace}
+	return CreateProcedure{Name: p.Name, Definition: p.Definition, OrReplace: p.OrReplace, Optional: Optional{ContinueOnError: p.ContinueOnError}}
 }
 
 func (p bclDropProcedure) toDropProcedure() DropProcedure {
-	return DropProcedure{Name: p.Name, Cascade: p.Cascade, IfExists: p.IfExists}
+	return DropProcedure{Name: p.Name, Cascade: p.Cascade, IfExists: p.IfExists, Optional: Optional{ContinueOnError: p.ContinueOnError}}
 }
 
 func (p bclRenameProcedure) toRenameProcedure() RenameProcedure {
-	return RenameProcedure{OldName: firstNonEmpty(p.OldName, p.Name), NewName: p.NewName}
+	return RenameProcedure{OldName: firstNonEmpty(p.OldName, p.Name), NewName: p.NewName, Optional: Optional{ContinueOnError: p.ContinueOnError}}
 }
 
 func (t bclCreateTrigger) toCreateTrigger() CreateTrigger {
-	return CreateTrigger{Name: t.Name, Definition: t.Definition, OrReplace: t.OrReplace}
+	return CreateTrigger{Name: t.Name, Definition: t.Definition, OrReplace: t.OrReplace, Optional: Optional{ContinueOnError: t.ContinueOnError}}
 }
 
 func (t bclDropTrigger) toDropTrigger() DropTrigger {
-	return DropTrigger{Name: t.Name, Cascade: t.Cascade, IfExists: t.IfExists}
+	return DropTrigger{Name: t.Name, Cascade: t.Cascade, IfExists: t.IfExists, Optional: Optional{ContinueOnError: t.ContinueOnError}}
 }
 
 func (t bclRenameTrigger) toRenameTrigger() RenameTrigger {
-	return RenameTrigger{OldName: firstNonEmpty(t.OldName, t.Name), NewName: t.NewName}
+	return RenameTrigger{OldName: firstNonEmpty(t.OldName, t.Name), NewName: t.NewName, Optional: Optional{ContinueOnError: t.ContinueOnError}}
+}
+
+func (r bclRebuildIndex) toRebuildIndex() RebuildIndex {
+	return RebuildIndex{Name: r.Name, Table: r.Table, Optional: Optional{ContinueOnError: r.ContinueOnError}}
+}
+
+func (r bclReindexTable) toReindexTable() ReindexTable {
+	return ReindexTable{Name: r.Name, Optional: Optional{ContinueOnError: r.ContinueOnError}}
+}
+
+func (p bclCreatePublication) toCreatePublication() CreatePublication {
+	return CreatePublication{Name: p.Name, Tables: p.Tables, AllTables: p.AllTables, Publish: p.Publish, Optional: Optional{ContinueOnError: p.ContinueOnError}}
+}
+
+func (p bclAlterPublication) toAlterPublication() AlterPublication {
+	return AlterPublication{Name: p.Name, AddTables: p.AddTables, DropTables: p.DropTables, SetTables: p.SetTables, Publish: p.Publish, Optional: Optional{ContinueOnError: p.ContinueOnError}}
+}
+
+func (p bclDropPublication) toDropPublication() DropPublication {
+	return DropPublication{Name: p.Name, IfExists: p.IfExists, Optional: Optional{ContinueOnError: p.ContinueOnError}}
+}
+
+func (r bclCreateRole) toCreateRole() CreateRole {
+	return CreateRole{Name: r.Name, Login: r.Login, Password: r.Password, InRoles: r.InRoles, Options: r.Options, Optional: Optional{ContinueOnError: r.ContinueOnError}}
+}
+
+func (r bclAlterRole) toAlterRole() AlterRole {
+	return AlterRole{Name: r.Name, Password: r.Password, RenameTo: r.RenameTo, Options: r.Options, Optional: Optional{ContinueOnError: r.ContinueOnError}}
+}
+
+func (r bclDropRole) toDropRole() DropRole {
+	return DropRole{Name: r.Name, IfExists: r.IfExists, Optional: Optional{ContinueOnError: r.ContinueOnError}}
+}
+
+func (d bclCreateDatabase) toCreateDatabase() CreateDatabase {
+	return CreateDatabase{Name: d.Name, IfNotExists: d.IfNotExists, Owner: d.Owner, Template: d.Template, Encoding: d.Encoding, Collation: d.Collation, Optional: Optional{ContinueOnError: d.ContinueOnError}}
+}
+
+func (d bclDropDatabase) toDropDatabase() DropDatabase {
+	return DropDatabase{Name: d.Name, IfExists: d.IfExists, Force: d.Force, Optional: Optional{ContinueOnError: d.ContinueOnError}}
+}
+
+func (v bclVacuum) toVacuum() Vacuum {
+	return Vacuum{Name: v.Name, Full: v.Full, Analyze: v.Analyze, Optional: Optional{ContinueOnError: v.ContinueOnError}}
+}
+
+func (a bclAnalyze) toAnalyze() Analyze {
+	return Analyze{Name: a.Name, Optional: Optional{ContinueOnError: a.ContinueOnError}}
 }
 
 func (t bclTransaction) toTransaction() Transaction {
@@ -510,6 +772,7 @@ func (s bclSeed) toSeedDefinition() SeedDefinition {
 		Combine:   s.Combine,
 		Condition: s.Condition,
 		Rows:      s.Rows,
+		Profile:   s.Profile,
 	}
 }
 
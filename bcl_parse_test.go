@@ -1,6 +1,7 @@
 package migrate
 
 import (
+	"errors"
 	"strings"
 	"testing"
 )
@@ -158,3 +159,282 @@ func TestSeedDefinitionUsesLocalSeedFunctionRegistry(t *testing.T) {
 		t.Fatalf("seed arg = %v, want registered-value", got)
 	}
 }
+
+func TestMaintenanceIndexOperationsPlanOutsideTransaction(t *testing.T) {
+	migration, err := ParseMigrationBCL([]byte(`
+Migration "003_reindex" {
+  Version = "1.0.0"
+  Description = "Rebuild bloated indexes."
+  Up {
+    RebuildIndex "idx_orders_sku" {
+      table = "orders"
+    }
+    ReindexTable "orders" {}
+  }
+}
+`))
+	if err != nil {
+		t.Fatalf("ParseMigrationBCL: %v", err)
+	}
+	want := map[string][]string{
+		DialectPostgres: {`REINDEX INDEX CONCURRENTLY "idx_orders_sku";`, `REINDEX TABLE CONCURRENTLY "orders";`},
+		DialectMySQL:    {"OPTIMIZE TABLE `orders`;", "OPTIMIZE TABLE `orders`;"},
+		DialectSQLite:   {`REINDEX "idx_orders_sku";`, `REINDEX "orders";`},
+	}
+	for dialect, queries := range want {
+		planned, err := migration.Plan(dialect, true)
+		if err != nil {
+			t.Fatalf("%s: Plan: %v", dialect, err)
+		}
+		if len(planned) != len(queries) {
+			t.Fatalf("%s: planned %d statements, want %d", dialect, len(planned), len(queries))
+		}
+		for i, st := range planned {
+			if st.SQL != queries[i] {
+				t.Fatalf("%s: statement %d = %q, want %q", dialect, i, st.SQL, queries[i])
+			}
+			if st.Options.NoTransaction != (dialect != DialectSQLite) {
+				t.Fatalf("%s: statement %d NoTransaction = %t", dialect, i, st.Options.NoTransaction)
+			}
+		}
+	}
+	if _, err := (RebuildIndex{Name: "idx_orders_sku"}).ToSQL(DialectMySQL); err == nil {
+		t.Fatalf("MySQL RebuildIndex without table should fail")
+	}
+}
+
+func TestPublicationOperationsPlanForPostgres(t *testing.T) {
+	migration, err := ParseMigrationBCL([]byte(`
+Migration "004_publish_orders" {
+  Version = "1.0.0"
+  Description = "Publish new tables."
+  Up {
+    CreateTable "orders" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+    }
+    CreatePublication "analytics" {
+      tables = ["customers"]
+      publish = "insert, update"
+    }
+    AlterPublication "cdc" {
+      add_tables = ["orders"]
+    }
+  }
+  Down {
+    DropPublication "analytics" {
+      if_exists = true
+    }
+  }
+}
+`))
+	if err != nil {
+		t.Fatalf("ParseMigrationBCL: %v", err)
+	}
+	up, err := migration.ToSQL(DialectPostgres, true)
+	if err != nil {
+		t.Fatalf("ToSQL up: %v", err)
+	}
+	if len(up) != 3 || !strings.HasPrefix(up[0], "CREATE TABLE") {
+		t.Fatalf("up = %q, want CREATE TABLE followed by the publication statements", up)
+	}
+	if up[1] != `CREATE PUBLICATION "analytics" FOR TABLE "customers" WITH (publish = 'insert, update');` {
+		t.Fatalf("create publication = %q", up[1])
+	}
+	if up[2] != `ALTER PUBLICATION "cdc" ADD TABLE "orders";` {
+		t.Fatalf("alter publication = %q", up[2])
+	}
+	down, err := migration.ToSQL(DialectPostgres, false)
+	if err != nil || len(down) != 1 || down[0] != `DROP PUBLICATION IF EXISTS "analytics";` {
+		t.Fatalf("down = %q, %v", down, err)
+	}
+	if _, err := migration.ToSQL(DialectMySQL, true); err == nil {
+		t.Fatalf("publications should not be supported on MySQL")
+	}
+}
+
+func TestRoleOperationsResolvePasswordSecrets(t *testing.T) {
+	t.Setenv("BILLING_DB_PASSWORD", "it's-secret")
+	migration, err := ParseMigrationBCL([]byte(`
+Migration "005_billing_role" {
+  Version = "1.0.0"
+  Description = "Provision the billing application role."
+  Up {
+    CreateRole "billing" {
+      login = true
+      password = "env:BILLING_DB_PASSWORD"
+      in_roles = ["readers"]
+    }
+  }
+  Down {
+    DropRole "billing" {
+      if_exists = true
+    }
+  }
+}
+`))
+	if err != nil {
+		t.Fatalf("ParseMigrationBCL: %v", err)
+	}
+	up, err := migration.ToSQL(DialectPostgres, true)
+	if err != nil {
+		t.Fatalf("ToSQL up: %v", err)
+	}
+	want := `CREATE ROLE "billing" WITH LOGIN PASSWORD 'it''s-secret' IN ROLE "readers";`
+	if len(up) != 1 || up[0] != want {
+		t.Fatalf("up = %q, want %q", up, want)
+	}
+	if got := RedactPasswords(up[0]); strings.Contains(got, "secret") {
+		t.Fatalf("RedactPasswords left the password in %q", got)
+	}
+	mysql, err := migration.ToSQL(DialectMySQL, true)
+	if err != nil {
+		t.Fatalf("ToSQL mysql: %v", err)
+	}
+	if len(mysql) != 1 || mysql[0] != `CREATE USER 'billing'@'%' IDENTIFIED BY 'it''s-secret'; GRANT 'readers' TO 'billing'@'%';` {
+		t.Fatalf("mysql = %q", mysql)
+	}
+	down, err := migration.ToSQL(DialectPostgres, false)
+	if err != nil || len(down) != 1 || down[0] != `DROP ROLE IF EXISTS "billing";` {
+		t.Fatalf("down = %q, %v", down, err)
+	}
+
+	if _, err := (CreateRole{Name: "billing", Login: true, Password: "hunter2"}).ToSQL(DialectPostgres); err == nil {
+		t.Fatal("expected a plain password to be rejected")
+	}
+	if _, err := (AlterRole{Name: "billing", Password: "env:MISSING_BILLING_PASSWORD"}).ToSQL(DialectPostgres); err == nil {
+		t.Fatal("expected an unset environment variable to fail")
+	}
+}
+
+func TestDatabaseOperationsPlanOutsideTransaction(t *testing.T) {
+	migration, err := ParseMigrationBCL([]byte(`
+Migration "000_bootstrap" {
+  Version = "1.0.0"
+  Description = "Create the reporting database."
+  Up {
+    CreateTable "tenants" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+    }
+    CreateDatabase "reporting" {
+      owner = "reporter"
+      encoding = "UTF8"
+    }
+  }
+  Down {
+    DropDatabase "reporting" {
+      if_exists = true
+      force = true
+    }
+  }
+}
+`))
+	if err != nil {
+		t.Fatalf("ParseMigrationBCL: %v", err)
+	}
+	up, err := migration.Up.Plan(DialectPostgres)
+	if err != nil {
+		t.Fatalf("Plan up: %v", err)
+	}
+	if len(up) != 2 || up[0].SQL != `CREATE DATABASE "reporting" WITH OWNER "reporter" ENCODING 'UTF8';` {
+		t.Fatalf("up = %+v, want CREATE DATABASE first", up)
+	}
+	if !up[0].Options.NoTransaction || up[1].Options.NoTransaction {
+		t.Fatalf("only CREATE DATABASE should run outside the transaction: %+v", up)
+	}
+	down, err := migration.Down.Plan(DialectPostgres)
+	if err != nil || len(down) != 1 || down[0].SQL != `DROP DATABASE IF EXISTS "reporting" WITH (FORCE);` || !down[0].Options.NoTransaction {
+		t.Fatalf("down = %+v, %v", down, err)
+	}
+	mysql, err := (CreateDatabase{Name: "reporting", IfNotExists: true, Encoding: "utf8mb4"}).ToSQL(DialectMySQL)
+	if err != nil || mysql != "CREATE DATABASE IF NOT EXISTS `reporting` CHARACTER SET utf8mb4;" {
+		t.Fatalf("mysql = %q, %v", mysql, err)
+	}
+	if _, err := migration.Up.Plan(DialectSQLite); err == nil {
+		t.Fatal("expected database operations to be rejected on SQLite")
+	}
+}
+
+func TestGetDialectRejectsUnknownNames(t *testing.T) {
+	dial, err := GetDialect("mariadb")
+	if err != nil {
+		t.Fatalf("GetDialect(mariadb): %v", err)
+	}
+	if _, ok := dial.(*MySQLDialect); !ok {
+		t.Fatalf("GetDialect(mariadb) = %T, want *MySQLDialect", dial)
+	}
+	if _, err := GetDialect("oracle"); !errors.Is(err, ErrUnknownDialect) {
+		t.Fatalf("GetDialect(oracle) error = %v, want ErrUnknownDialect", err)
+	}
+	op := Operation{DropTable: []DropTable{{Name: "orders"}}}
+	if _, err := op.ToSQL("oracle"); !errors.Is(err, ErrUnknownDialect) {
+		t.Fatalf("ToSQL(oracle) error = %v, want ErrUnknownDialect", err)
+	}
+}
+
+func TestDeclaredOperationOrderPreservesBlockOrder(t *testing.T) {
+	source := func(order string) string {
+		return `
+Migration "001_rebuild_orders" {
+  Up {
+    ` + order + `
+    DropView "order_totals" {}
+    CreateTable "orders_v2" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+    }
+    CreateView "order_totals" {
+      definition = "SELECT id FROM orders_v2"
+    }
+  }
+}
+`
+	}
+	firstStatement := func(order string) string {
+		t.Helper()
+		migration, err := ParseMigrationBCL([]byte(source(order)))
+		if err != nil {
+			t.Fatalf("ParseMigrationBCL(%q): %v", order, err)
+		}
+		up, err := migration.ToSQL(DialectSQLite, true)
+		if err != nil || len(up) != 3 {
+			t.Fatalf("ToSQL(%q) = %v, %v", order, up, err)
+		}
+		return up[0]
+	}
+	if got := firstStatement(""); !strings.HasPrefix(got, "CREATE TABLE") {
+		t.Fatalf("grouped order starts with %q, want CREATE TABLE", got)
+	}
+	if got := firstStatement(`order = "declared"`); !strings.HasPrefix(got, "DROP VIEW") {
+		t.Fatalf("declared order starts with %q, want DROP VIEW", got)
+	}
+	if _, err := ParseMigrationBCL([]byte(source(`order = "random"`))); err == nil {
+		t.Fatal("expected an unknown order to be rejected")
+	}
+}
+
+func TestParseMigrationsBCLReportsLocatedParseError(t *testing.T) {
+	data := []byte("Migration \"001_broken\" {\n  Version = \"1.0.0\n  Up {}\n}\n")
+	_, err := ParseMigrationsBCL(data)
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("err = %T %v, want *ParseError", err, err)
+	}
+	if parseErr.Line != 2 || parseErr.Column != 13 {
+		t.Fatalf("location = %d:%d, want 2:13", parseErr.Line, parseErr.Column)
+	}
+	want := "1 | Migration \"001_broken\" {\n2 |   Version = \"1.0.0\n  |             ^\n3 |   Up {}"
+	if parseErr.Snippet != want {
+		t.Fatalf("snippet =\n%s\nwant\n%s", parseErr.Snippet, want)
+	}
+	if !strings.HasPrefix(err.Error(), "line 2, column 13: unterminated string") {
+		t.Fatalf("Error() = %q", err.Error())
+	}
+}
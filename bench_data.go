@@ -0,0 +1,219 @@
+package migrate
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	// benchRowsPerScale is the number of rows db:bench-data generates per
+	// table for each unit of scale.
+	benchRowsPerScale = 100
+	// benchChunkRows is the number of rows a worker generates and inserts
+	// in one batch.
+	benchChunkRows = 500
+)
+
+// BenchData describes the dataset GenerateBenchData loads for load tests.
+type BenchData struct {
+	// Schema is the migration set whose tables are filled: a directory of
+	// the migration directory, empty meaning every migration.
+	Schema string
+	// Scale multiplies the rows of every table, benchRowsPerScale each;
+	// 0 means 1.
+	Scale int
+	// Workers is the number of chunks generated at once; 0 means the
+	// number of CPUs.
+	Workers int
+}
+
+// GenerateBenchData fills every table of bench.Schema with generated rows,
+// referenced tables first so foreign keys point at existing rows. Each
+// table is generated by the seed engine in chunks on parallel workers, and
+// unique fields stay unique across them.
+func (d *Manager) GenerateBenchData(bench BenchData) error {
+	if d.dbDriver == nil {
+		return fmt.Errorf("no database driver configured")
+	}
+	scale := max(bench.Scale, 1)
+	workers := bench.Workers
+	if workers <= 0 {
+		workers = d.parseJobs()
+	}
+	tables, _, err := d.migrationSchema(bench.Schema)
+	if err != nil {
+		return err
+	}
+	if len(tables) == 0 {
+		return fmt.Errorf("no tables are created by the migrations of %q", bench.Schema)
+	}
+	lookup := d.newSeedLookup(false)
+	// SQLite allows a single writer, so there the chunks are generated in
+	// parallel but inserted one at a time.
+	var writeMu sync.Mutex
+	for _, table := range benchOrder(tables) {
+		seed, err := benchSeed(table, d.dialect)
+		if err != nil {
+			return err
+		}
+		rows := scale * benchRowsPerScale
+		logger.Info().Msgf("Generating %d row(s) for table: %s", rows, table.name)
+		chunks := make(chan int)
+		errs := make(chan error, workers)
+		var wg sync.WaitGroup
+		for range min(workers, (rows+benchChunkRows-1)/benchChunkRows) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for n := range chunks {
+					chunk := seed
+					chunk.Rows = n
+					queries, err := chunk.toSQL(d.dialect, lookup)
+					if err != nil {
+						errs <- fmt.Errorf("failed to generate rows for %s: %w", table.name, err)
+						return
+					}
+					batch := make([]Statement, len(queries))
+					for i, q := range queries {
+						batch[i] = Statement{SQL: q.SQL, Args: q.Args}
+					}
+					if d.dialect == DialectSQLite {
+						writeMu.Lock()
+					}
+					err = d.dbDriver.ApplyBatch(batch)
+					if d.dialect == DialectSQLite {
+						writeMu.Unlock()
+					}
+					if err != nil {
+						errs <- fmt.Errorf("failed to insert rows into %s: %w", table.name, err)
+						return
+					}
+				}
+			}()
+		}
+		// A failed worker stops taking chunks, so feeding stops at the
+		// first error.
+	feed:
+		for left := rows; left > 0; left -= benchChunkRows {
+			select {
+			case chunks <- min(left, benchChunkRows):
+			case err := <-errs:
+				errs <- err
+				break feed
+			}
+		}
+		close(chunks)
+		wg.Wait()
+		close(errs)
+		if err := <-errs; err != nil {
+			return err
+		}
+		d.emitSeedRows("bench-data", table.name, int64(rows))
+	}
+	return nil
+}
+
+// benchOrder orders tables so that every table comes after the tables its
+// foreign keys reference. Self references and tables in a reference cycle
+// keep their order.
+func benchOrder(tables []migrationTable) []migrationTable {
+	references := func(t migrationTable, other string) bool {
+		return other != t.name && slices.ContainsFunc(t.fields, func(f AddField) bool {
+			return f.ForeignKey != nil && f.ForeignKey.ReferenceTable == other
+		})
+	}
+	var order []migrationTable
+	remaining := slices.Clone(tables)
+	for len(remaining) > 0 {
+		next := 0
+		for i, t := range remaining {
+			if !slices.ContainsFunc(remaining, func(o migrationTable) bool { return references(t, o.name) }) {
+				next = i
+				break
+			}
+		}
+		order = append(order, remaining[next])
+		remaining = slices.Delete(remaining, next, next+1)
+	}
+	return order
+}
+
+// benchSeed returns the seed generating the rows of table: foreign keys
+// pick referenced rows, fields named after a fake function use it, and
+// other fields get a fake value of their type. Fields the database assigns
+// are left out.
+func benchSeed(table migrationTable, dialect string) (SeedDefinition, error) {
+	seed := SeedDefinition{Name: "bench_" + table.name, Table: table.name}
+	for _, f := range table.fields {
+		typ := strings.ToLower(strings.TrimSpace(f.Type))
+		if i := strings.Index(typ, "("); i >= 0 {
+			typ = typ[:i]
+		}
+		if benchAssigned(f, typ, dialect) {
+			continue
+		}
+		field := FieldDefinition{Name: f.Name, Unique: f.Unique || f.PrimaryKey}
+		token := "fake_" + strings.ToLower(f.Name)
+		_, named := lookupSeedFunction(token)
+		switch {
+		case f.ForeignKey != nil && f.ForeignKey.ReferenceTable == table.name:
+			// A self reference has no row to point at before the table is
+			// filled.
+			if !f.Nullable {
+				return SeedDefinition{}, fmt.Errorf("cannot generate %s.%s: it references its own table", table.name, f.Name)
+			}
+			continue
+		case f.ForeignKey != nil:
+			field.Value = fmt.Sprintf("ref:%s.%s", f.ForeignKey.ReferenceTable, f.ForeignKey.ReferenceField)
+		case f.RawType != "":
+			if !f.Nullable && f.Default == nil {
+				return SeedDefinition{}, fmt.Errorf("cannot generate %s.%s: raw type %s", table.name, f.Name, f.RawType)
+			}
+			continue
+		case slices.Contains([]string{"int", "integer", "number", "bigint", "mediumint"}, typ):
+			field.Value = "fake_int32"
+			field.DataType = "int"
+		case slices.Contains([]string{"smallint", "tinyint"}, typ):
+			field.Value = "fake_int"
+			field.DataType = "int"
+		case slices.Contains([]string{"float", "double", "decimal", "numeric", "real"}, typ):
+			field.Value = "fake_float32"
+		case typ == "boolean" || typ == "bool":
+			field.Value = "fake_bool"
+			field.DataType = "boolean"
+		case slices.Contains([]string{"date", "datetime", "timestamp", "timestamptz", "time"}, typ):
+			field.Value = "fake_date"
+			field.DataType = typ
+		case typ == "json" || typ == "jsonb":
+			field.Value = "{}"
+		case typ == "uuid":
+			field.Value = "fake_uuid"
+		case named:
+			field.Value = token
+		case field.Unique:
+			field.Value = "fake_uuid"
+		default:
+			field.Value = "fake_string"
+		}
+		seed.Fields = append(seed.Fields, field)
+	}
+	if len(seed.Fields) == 0 {
+		return SeedDefinition{}, fmt.Errorf("cannot generate %s: every field is assigned by the database", table.name)
+	}
+	return seed, nil
+}
+
+// benchAssigned reports whether the database assigns the field when it is
+// left out: auto increments, serial types, and on SQLite an integer
+// primary key, which aliases the rowid.
+func benchAssigned(f AddField, typ, dialect string) bool {
+	switch {
+	case f.AutoIncrement, strings.HasSuffix(typ, "serial"):
+		return true
+	case dialect == DialectSQLite && f.PrimaryKey && (typ == "integer" || typ == "int"):
+		return true
+	}
+	return false
+}
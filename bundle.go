@@ -0,0 +1,285 @@
+package migrate
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oarkflow/json"
+)
+
+// BundleFormatVersion is the manifest format written by WriteBundle.
+const BundleFormatVersion = 1
+
+// Names of the bundle entries that are not migration files.
+const (
+	bundleManifestName  = "manifest.json"
+	bundleSignatureName = "manifest.sig"
+	bundleFilesDir      = "migrations"
+)
+
+// BundleManifest describes the migration files of a bundle. Every file is
+// pinned by its SHA-256 checksum, so a bundle cannot gain, lose or change a
+// file without failing verification.
+type BundleManifest struct {
+	FormatVersion int       `json:"format_version"`
+	ToolVersion   string    `json:"tool_version"`
+	CreatedAt     time.Time `json:"created_at"`
+	// Signed reports whether manifest.sig holds an ed25519 signature of the
+	// manifest.
+	Signed bool         `json:"signed"`
+	Files  []BundleFile `json:"files"`
+}
+
+// BundleFile is one migration file of a bundle.
+type BundleFile struct {
+	// Path is relative to the migration directory, with forward slashes.
+	Path       string   `json:"path"`
+	Checksum   string   `json:"checksum"`
+	Migrations []string `json:"migrations"`
+}
+
+// Bundle is a verified bundle read into memory.
+type Bundle struct {
+	Manifest BundleManifest
+	// Verified reports whether the signature was checked against a key.
+	Verified bool
+	files    map[string][]byte
+}
+
+// WriteBundle writes every migration file with a manifest to w as a gzipped
+// tar. When signKey is not nil the manifest is signed with it.
+func (d *Manager) WriteBundle(w io.Writer, signKey ed25519.PrivateKey) (BundleManifest, error) {
+	manifest := BundleManifest{
+		FormatVersion: BundleFormatVersion,
+		ToolVersion:   Version,
+		CreatedAt:     time.Now().UTC(),
+		Signed:        signKey != nil,
+	}
+	migrationMap, err := d.ListMigrationMap()
+	if err != nil {
+		return manifest, fmt.Errorf("failed to list migrations: %w", err)
+	}
+	names := make(map[string][]string)
+	for name, p := range migrationMap {
+		names[p] = append(names[p], name)
+	}
+	paths, _, err := d.migrationFilePaths()
+	if err != nil {
+		return manifest, fmt.Errorf("failed to list migration files: %w", err)
+	}
+	contents := make(map[string][]byte, len(paths))
+	for _, p := range paths {
+		data, err := d.readFile(p)
+		if err != nil {
+			return manifest, err
+		}
+		rel, err := filepath.Rel(d.migrationDir, p)
+		if err != nil {
+			return manifest, err
+		}
+		rel = filepath.ToSlash(rel)
+		sort.Strings(names[p])
+		manifest.Files = append(manifest.Files, BundleFile{Path: rel, Checksum: computeChecksum(data), Migrations: names[p]})
+		contents[rel] = data
+	}
+	sort.Slice(manifest.Files, func(i, j int) bool { return manifest.Files[i].Path < manifest.Files[j].Path })
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return manifest, fmt.Errorf("failed to encode bundle manifest: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	add := func(name string, data []byte) error {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: manifest.CreatedAt, Typeflag: tar.TypeReg}); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+	if err := add(bundleManifestName, manifestData); err != nil {
+		return manifest, err
+	}
+	if signKey != nil {
+		if err := add(bundleSignatureName, ed25519.Sign(signKey, manifestData)); err != nil {
+			return manifest, err
+		}
+	}
+	for _, f := range manifest.Files {
+		if err := add(path.Join(bundleFilesDir, f.Path), contents[f.Path]); err != nil {
+			return manifest, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return manifest, err
+	}
+	return manifest, gz.Close()
+}
+
+// OpenBundle reads the bundle at file and verifies that it holds exactly the
+// files of its manifest with the listed checksums. When verifyKey is not nil
+// the bundle must also be signed by the matching private key.
+func OpenBundle(file string, verifyKey ed25519.PublicKey) (*Bundle, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a migration bundle: %w", file, err)
+	}
+	var manifestData, signature []byte
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle %s: %w", file, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("bundle entry %s is not a regular file", header.Name)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle entry %s: %w", header.Name, err)
+		}
+		switch {
+		case header.Name == bundleManifestName:
+			manifestData = data
+		case header.Name == bundleSignatureName:
+			signature = data
+		case strings.HasPrefix(header.Name, bundleFilesDir+"/"):
+			name := strings.TrimPrefix(header.Name, bundleFilesDir+"/")
+			if !fs.ValidPath(name) {
+				return nil, fmt.Errorf("bundle entry %s has an invalid path", header.Name)
+			}
+			if _, dup := files[name]; dup {
+				return nil, fmt.Errorf("bundle entry %s appears twice", header.Name)
+			}
+			files[name] = data
+		default:
+			return nil, fmt.Errorf("unexpected bundle entry %s", header.Name)
+		}
+	}
+	if manifestData == nil {
+		return nil, fmt.Errorf("bundle %s has no %s", file, bundleManifestName)
+	}
+	bundle := &Bundle{files: files}
+	if err := json.Unmarshal(manifestData, &bundle.Manifest); err != nil {
+		return nil, fmt.Errorf("invalid bundle manifest: %w", err)
+	}
+	if bundle.Manifest.FormatVersion != BundleFormatVersion {
+		return nil, fmt.Errorf("unsupported bundle format version %d", bundle.Manifest.FormatVersion)
+	}
+	if bundle.Manifest.Signed != (signature != nil) {
+		return nil, errors.New("bundle signature does not match the manifest")
+	}
+	if verifyKey != nil {
+		if signature == nil {
+			return nil, errors.New("bundle is not signed")
+		}
+		if !ed25519.Verify(verifyKey, manifestData, signature) {
+			return nil, errors.New("bundle signature is invalid")
+		}
+		bundle.Verified = true
+	}
+	listed := make(map[string]bool, len(bundle.Manifest.Files))
+	for _, bf := range bundle.Manifest.Files {
+		data, ok := files[bf.Path]
+		if !ok {
+			return nil, fmt.Errorf("bundle is missing %s", bf.Path)
+		}
+		if sum := computeChecksum(data); sum != bf.Checksum {
+			return nil, fmt.Errorf("checksum mismatch for %s: manifest has %s, file has %s", bf.Path, bf.Checksum, sum)
+		}
+		listed[bf.Path] = true
+	}
+	for name := range files {
+		if !listed[name] {
+			return nil, fmt.Errorf("bundle file %s is not in the manifest", name)
+		}
+	}
+	return bundle, nil
+}
+
+// Extract writes the migration files of b under dir.
+func (b *Bundle) Extract(dir string) error {
+	for _, bf := range b.Manifest.Files {
+		target := filepath.Join(dir, filepath.FromSlash(bf.Path))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, b.files[bf.Path], 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GenerateBundleKey returns a new ed25519 key pair for signing bundles,
+// base64 encoded.
+func GenerateBundleKey() (private, public string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.StdEncoding.EncodeToString(priv.Seed()), base64.StdEncoding.EncodeToString(pub), nil
+}
+
+// ResolveBundleSigningKey resolves the secret reference ref to an ed25519
+// private key, given as a base64 seed or full private key.
+func ResolveBundleSigningKey(ref string) (ed25519.PrivateKey, error) {
+	key, err := resolveBase64Secret(ref, "bundle signing key")
+	if err != nil {
+		return nil, err
+	}
+	switch len(key) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(key), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(key), nil
+	}
+	return nil, fmt.Errorf("bundle signing key from %s is %d bytes; want %d or %d", ref, len(key), ed25519.SeedSize, ed25519.PrivateKeySize)
+}
+
+// ResolveBundleVerifyKey resolves the secret reference ref to an ed25519
+// public key given in base64.
+func ResolveBundleVerifyKey(ref string) (ed25519.PublicKey, error) {
+	key, err := resolveBase64Secret(ref, "bundle verify key")
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("bundle verify key from %s is %d bytes; want %d", ref, len(key), ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+func resolveBase64Secret(ref, what string) ([]byte, error) {
+	secret, err := ResolveSecret(ref)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", what, err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(secret))
+	if err != nil {
+		return nil, fmt.Errorf("%s from %s is not base64", what, ref)
+	}
+	return key, nil
+}
@@ -0,0 +1,146 @@
+package migrate
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/oarkflow/json"
+)
+
+// Checksum modes select what the checksum recorded in the migration history
+// covers.
+const (
+	// ChecksumRaw hashes the migration file as is, so any edit, including
+	// whitespace and comments, is reported as a modification.
+	ChecksumRaw = "raw"
+	// ChecksumNormalized hashes the parsed migration for BCL files and the
+	// SQL without comments and redundant whitespace for .sql files, so
+	// formatting-only edits keep the checksum.
+	ChecksumNormalized = "normalized"
+)
+
+// normalizedChecksumPrefix marks normalized checksums in the history so they
+// are never compared with raw file checksums.
+const normalizedChecksumPrefix = "normalized:"
+
+// WithChecksumMode sets the checksum recorded for newly applied migrations
+// to ChecksumRaw or ChecksumNormalized. Existing raw checksums keep
+// validating in either mode.
+func WithChecksumMode(mode string) ManagerOption {
+	return func(m *Manager) {
+		m.checksumMode = mode
+	}
+}
+
+// normalizedMigrationChecksum hashes the parsed form of m.
+func normalizedMigrationChecksum(m Migration) (string, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode migration %s: %w", m.Name, err)
+	}
+	return normalizedChecksumPrefix + computeChecksum(data), nil
+}
+
+// normalizedSQLChecksum hashes the up and down sections of a .sql migration
+// with comment lines dropped and whitespace collapsed.
+func normalizedSQLChecksum(data []byte) string {
+	up, down := parseSQLMigration(data)
+	return normalizedChecksumPrefix + computeChecksum([]byte(normalizeSQL(up)+"\n"+normalizeSQL(down)))
+}
+
+func normalizeSQL(sql string) string {
+	var kept []string
+	for _, line := range strings.Split(sql, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(strings.Fields(strings.Join(kept, "\n")), " ")
+}
+
+// checksumMatches reports whether recorded, a checksum from the history,
+// matches a migration with the given raw and normalized checksums.
+func checksumMatches(recorded, raw, normalized string) bool {
+	if strings.HasPrefix(recorded, normalizedChecksumPrefix) {
+		return recorded == normalized
+	}
+	return recorded == raw
+}
+
+// recordedChecksum picks the checksum to store for a newly applied migration.
+func (d *Manager) recordedChecksum(raw, normalized string) string {
+	if d.checksumMode == ChecksumNormalized {
+		return normalized
+	}
+	return raw
+}
+
+// migrationChecksums returns the raw and normalized checksums of migration
+// name in the file at path. The file is read directly so edits made since
+// parsing are seen.
+func (d *Manager) migrationChecksums(path, name string) (raw, normalized string, err error) {
+	if strings.EqualFold(filepath.Ext(path), ".sql") {
+		data, err := d.readFile(path)
+		if err != nil {
+			return "", "", err
+		}
+		return computeChecksum(data), normalizedSQLChecksum(data), nil
+	}
+	cached, err := d.readMigrationsBCL(path)
+	if err != nil {
+		return "", "", err
+	}
+	migration, ok := findMigrationByName(cached.written, name)
+	if !ok {
+		return "", "", fmt.Errorf("migration %q not found in %s", name, path)
+	}
+	normalized, err = normalizedMigrationChecksum(migration)
+	if err != nil {
+		return "", "", err
+	}
+	return cached.checksum, normalized, nil
+}
+
+// UpgradeChecksums rewrites the raw checksums in the history as normalized
+// checksums, for switching an existing project to ChecksumNormalized.
+// Entries whose file was modified since it was applied, or is missing, are
+// left alone and returned as skipped.
+func (d *Manager) UpgradeChecksums() (upgraded int, skipped []string, err error) {
+	migrationMap, err := d.ListMigrationMap()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+	histories, err := d.historyDriver.Load()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to load migration history: %w", err)
+	}
+	for i, h := range histories {
+		if strings.HasPrefix(h.Checksum, normalizedChecksumPrefix) {
+			continue
+		}
+		path, ok := migrationMap[h.Name]
+		if !ok {
+			skipped = append(skipped, h.Name)
+			continue
+		}
+		raw, normalized, err := d.migrationChecksums(path, h.Name)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to checksum %s: %w", path, err)
+		}
+		if raw != h.Checksum {
+			skipped = append(skipped, h.Name)
+			continue
+		}
+		histories[i].Checksum = normalized
+		upgraded++
+	}
+	if upgraded == 0 {
+		return 0, skipped, nil
+	}
+	if err := d.historyDriver.Rollback(histories...); err != nil {
+		return 0, nil, fmt.Errorf("failed to update migration history: %w", err)
+	}
+	return upgraded, skipped, nil
+}
@@ -48,6 +48,57 @@ func extractConfigFromArgs(args []string) (string, []string) {
 	return cfg, out
 }
 
+// extractWaitFromArgs reports whether --wait-for-db was passed and returns the
+// accompanying --timeout value. The flags are left in args for the command.
+func extractWaitFromArgs(args []string) (bool, string) {
+	var wait bool
+	var timeout string
+	for i := 1; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--wait-for-db" || a == "--wait-for-db=true" || a == "--wait-for-db=1":
+			wait = true
+		case a == "--timeout" && i+1 < len(args):
+			timeout = args[i+1]
+			i++
+		case strings.HasPrefix(a, "--timeout="):
+			timeout = strings.TrimPrefix(a, "--timeout=")
+		}
+	}
+	return wait, timeout
+}
+
+// offlineRequested reports whether --offline was passed. The flag is left in
+// args for the command.
+func offlineRequested(args []string) bool {
+	for _, a := range args[1:] {
+		if a == "--offline" || a == "--offline=true" || a == "--offline=1" {
+			return true
+		}
+	}
+	return false
+}
+
+// newManagerFromConfig builds the manager from a config file. When
+// --wait-for-db is on the command line it first waits for the database so a
+// cold database does not fail manager construction. With --offline it does
+// not connect to the database at all.
+func newManagerFromConfig(path string) (*migrate.Manager, error) {
+	if offlineRequested(os.Args) {
+		return migrate.NewManagerFromConfig(path, migrate.WithOffline())
+	}
+	if wait, timeoutValue := extractWaitFromArgs(os.Args); wait {
+		timeout, err := migrate.ParseWaitTimeout(timeoutValue)
+		if err != nil {
+			return nil, err
+		}
+		if err := migrate.WaitForDatabaseFromConfig(path, timeout); err != nil {
+			return nil, err
+		}
+	}
+	return migrate.NewManagerFromConfig(path)
+}
+
 func Run(dialect string, cfg ...Config) error {
 	var config Config
 	if len(cfg) > 0 {
@@ -59,7 +110,7 @@ func Run(dialect string, cfg ...Config) error {
 		if _, err := os.Stat(config.ConfigFile); err != nil {
 			return err
 		}
-		manager, err := migrate.NewManagerFromConfig(config.ConfigFile)
+		manager, err := newManagerFromConfig(config.ConfigFile)
 		if err != nil {
 			return err
 		}
@@ -76,7 +127,7 @@ func Run(dialect string, cfg ...Config) error {
 		if _, err := os.Stat(cfgPath); err != nil {
 			return err
 		}
-		manager, err := migrate.NewManagerFromConfig(cfgPath)
+		manager, err := newManagerFromConfig(cfgPath)
 		if err != nil {
 			return err
 		}
@@ -92,7 +143,7 @@ func Run(dialect string, cfg ...Config) error {
 	// If no explicit config is provided, auto-load default migrate.json when present.
 	// This keeps plain `migrator ... migrate` behavior intuitive.
 	if _, err := os.Stat("migrate.json"); err == nil {
-		manager, err := migrate.NewManagerFromConfig("migrate.json")
+		manager, err := newManagerFromConfig("migrate.json")
 		if err != nil {
 			return err
 		}
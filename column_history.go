@@ -0,0 +1,268 @@
+package migrate
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Kinds of column changes.
+const (
+	ColumnAdded      = "Added"
+	ColumnDropped    = "Dropped"
+	ColumnRenamed    = "Renamed"
+	ColumnType       = "Type"
+	ColumnDefault    = "Default"
+	ColumnNullable   = "Nullability"
+	ColumnUnique     = "Unique"
+	ColumnPrimaryKey = "Primary key"
+	ColumnForeignKey = "Foreign key"
+	ColumnCheck      = "Check"
+)
+
+// ColumnChange is a change of a column by a migration. From and To are the
+// values before and after the change; To of ColumnAdded is the definition
+// of the column.
+type ColumnChange struct {
+	MigrationName string
+	Date          time.Time
+	Kind          string
+	From          string
+	To            string
+}
+
+// Describe returns the change as a sentence, such as "Type changed from int
+// to bigint".
+func (c ColumnChange) Describe() string {
+	switch c.Kind {
+	case ColumnAdded:
+		return "Added as " + c.To
+	case ColumnDropped:
+		return "Dropped"
+	case ColumnRenamed:
+		return fmt.Sprintf("Renamed from %s to %s", c.From, c.To)
+	}
+	return fmt.Sprintf("%s changed from %s to %s", c.Kind, c.From, c.To)
+}
+
+// ColumnHistory is the timeline of a column of a table, under its latest
+// name. A column dropped and added again keeps one timeline.
+type ColumnHistory struct {
+	Name    string
+	Dropped bool
+	Changes []ColumnChange
+}
+
+// columnHistories returns the timeline of each column of a table from the
+// changes of the table, in the order the columns were added. Type and
+// default changes show where a column is renamed with a new type, dropped
+// and added again, or where the table is created again.
+func columnHistories(changes []MigrationChange) []ColumnHistory {
+	var columns []*ColumnHistory
+	live := map[string]*ColumnHistory{}
+	dropped := map[string]*ColumnHistory{}
+	defs := map[*ColumnHistory]AddField{}
+	record := func(col *ColumnHistory, ch MigrationChange, kind, from, to string) {
+		col.Changes = append(col.Changes, ColumnChange{MigrationName: ch.MigrationName, Date: ch.Date, Kind: kind, From: from, To: to})
+	}
+	add := func(ch MigrationChange, f AddField) {
+		col, ok := live[f.Name]
+		switch {
+		case ok:
+			// The table is created again with the column.
+			columnDiff(col, ch, defs[col], f, record)
+		case dropped[f.Name] != nil:
+			col = dropped[f.Name]
+			delete(dropped, f.Name)
+			col.Dropped = false
+			record(col, ch, ColumnAdded, "", columnDefinition(f))
+			columnDiff(col, ch, defs[col], f, record)
+		default:
+			col = &ColumnHistory{Name: f.Name}
+			columns = append(columns, col)
+			record(col, ch, ColumnAdded, "", columnDefinition(f))
+		}
+		live[f.Name] = col
+		defs[col] = f
+	}
+	drop := func(ch MigrationChange, name string) {
+		col, ok := live[name]
+		if !ok {
+			return
+		}
+		record(col, ch, ColumnDropped, "", "")
+		col.Dropped = true
+		delete(live, name)
+		dropped[name] = col
+	}
+	setPrimaryKey := func(ch MigrationChange, fields []string) {
+		for name, col := range live {
+			def := defs[col]
+			if pk := slices.Contains(fields, name); pk != def.PrimaryKey {
+				record(col, ch, ColumnPrimaryKey, yesNo(def.PrimaryKey), yesNo(pk))
+				def.PrimaryKey = pk
+				defs[col] = def
+			}
+		}
+	}
+	for _, ch := range changes {
+		switch ch.Operation {
+		case "CreateTable":
+			if ch.CreateTable == nil {
+				continue
+			}
+			fields := make([]AddField, 0, len(ch.CreateTable.AddFields))
+			for _, f := range ch.CreateTable.AddFields {
+				if slices.Contains(ch.CreateTable.PrimaryKey, f.Name) {
+					f.PrimaryKey = true
+				}
+				fields = append(fields, f)
+			}
+			for name := range live {
+				if !slices.ContainsFunc(fields, func(f AddField) bool { return f.Name == name }) {
+					drop(ch, name)
+				}
+			}
+			for _, f := range fields {
+				add(ch, f)
+			}
+		case "AddField":
+			if ch.Field != nil {
+				add(ch, *ch.Field)
+			}
+		case "DropField":
+			if ch.DropField != nil {
+				drop(ch, ch.DropField.Name)
+			}
+		case "RenameField":
+			if ch.RenameField == nil {
+				continue
+			}
+			col, ok := live[ch.RenameField.From]
+			if !ok {
+				continue
+			}
+			record(col, ch, ColumnRenamed, ch.RenameField.From, ch.RenameField.To)
+			delete(live, ch.RenameField.From)
+			live[ch.RenameField.To] = col
+			col.Name = ch.RenameField.To
+			def := defs[col]
+			def.Name = ch.RenameField.To
+			if ch.RenameField.Type != "" && !strings.EqualFold(ch.RenameField.Type, migrationFieldType(def)) {
+				record(col, ch, ColumnType, migrationFieldType(def), ch.RenameField.Type)
+				def.Type, def.RawType, def.Size, def.Scale = ch.RenameField.Type, "", 0, 0
+			}
+			defs[col] = def
+		case "AddPrimaryKey":
+			setPrimaryKey(ch, strings.Split(ch.Details, ", "))
+		case "DropPrimaryKey":
+			setPrimaryKey(ch, nil)
+		case "DropTable":
+			for name := range live {
+				drop(ch, name)
+			}
+		}
+	}
+	histories := make([]ColumnHistory, len(columns))
+	for i, col := range columns {
+		histories[i] = *col
+	}
+	return histories
+}
+
+// columnDiff records the changes between the definitions from and to of a
+// column.
+func columnDiff(col *ColumnHistory, ch MigrationChange, from, to AddField, record func(*ColumnHistory, MigrationChange, string, string, string)) {
+	if a, b := migrationFieldType(from), migrationFieldType(to); !strings.EqualFold(a, b) {
+		record(col, ch, ColumnType, a, b)
+	}
+	if a, b := columnDefault(from), columnDefault(to); a != b {
+		record(col, ch, ColumnDefault, a, b)
+	}
+	if from.Nullable != to.Nullable {
+		record(col, ch, ColumnNullable, nullability(from.Nullable), nullability(to.Nullable))
+	}
+	if from.Unique != to.Unique {
+		record(col, ch, ColumnUnique, yesNo(from.Unique), yesNo(to.Unique))
+	}
+	if from.PrimaryKey != to.PrimaryKey {
+		record(col, ch, ColumnPrimaryKey, yesNo(from.PrimaryKey), yesNo(to.PrimaryKey))
+	}
+	if a, b := columnReference(from), columnReference(to); a != b {
+		record(col, ch, ColumnForeignKey, a, b)
+	}
+	if from.Check != to.Check {
+		record(col, ch, ColumnCheck, noneIfEmpty(from.Check), noneIfEmpty(to.Check))
+	}
+}
+
+// migrationFieldType returns the SQL type of f as written in the migration.
+func migrationFieldType(f AddField) string {
+	switch {
+	case f.RawType != "":
+		return f.RawType
+	case f.Size > 0 && f.Scale > 0:
+		return fmt.Sprintf("%s(%d,%d)", f.Type, f.Size, f.Scale)
+	case f.Size > 0:
+		return fmt.Sprintf("%s(%d)", f.Type, f.Size)
+	}
+	return f.Type
+}
+
+// columnDefinition returns the type and constraints of f, such as
+// "varchar(100) NOT NULL DEFAULT 'x'".
+func columnDefinition(f AddField) string {
+	parts := []string{migrationFieldType(f), nullability(f.Nullable)}
+	if hasDefault(f) {
+		parts = append(parts, "DEFAULT "+columnDefault(f))
+	}
+	if f.PrimaryKey {
+		parts = append(parts, "PRIMARY KEY")
+	}
+	if f.Unique {
+		parts = append(parts, "UNIQUE")
+	}
+	if f.ForeignKey != nil {
+		parts = append(parts, "REFERENCES "+columnReference(f))
+	}
+	if f.Check != "" {
+		parts = append(parts, "CHECK "+f.Check)
+	}
+	return strings.Join(parts, " ")
+}
+
+func columnDefault(f AddField) string {
+	if !hasDefault(f) {
+		return "none"
+	}
+	return fmt.Sprint(f.Default)
+}
+
+func columnReference(f AddField) string {
+	if f.ForeignKey == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%s(%s)", f.ForeignKey.ReferenceTable, f.ForeignKey.ReferenceField)
+}
+
+func nullability(nullable bool) string {
+	if nullable {
+		return "NULL"
+	}
+	return "NOT NULL"
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func noneIfEmpty(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
@@ -0,0 +1,65 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/oarkflow/cli/contracts"
+)
+
+// BenchDataCommand fills the tables of a migration set with generated rows
+// for load tests.
+type BenchDataCommand struct {
+	Driver IManager
+}
+
+func (c *BenchDataCommand) Signature() string {
+	return "db:bench-data"
+}
+
+func (c *BenchDataCommand) Description() string {
+	return "Generates a dataset of configurable scale across the tables of a migration set."
+}
+
+func (c *BenchDataCommand) Extend() contracts.Extend {
+	return contracts.Extend{
+		Flags: []contracts.Flag{
+			{
+				Name:  "schema",
+				Usage: "Directory of the migration directory whose tables are filled (default: every migration)",
+				Value: "",
+			},
+			{
+				Name:  "scale",
+				Usage: fmt.Sprintf("Multiplier of the rows generated per table, %d each", benchRowsPerScale),
+				Value: "1",
+			},
+			{
+				Name:  "workers",
+				Usage: "Number of row chunks generated concurrently (default: number of CPUs)",
+				Value: "",
+			},
+		},
+	}
+}
+
+func (c *BenchDataCommand) Handle(ctx contracts.Context) error {
+	mgr, ok := c.Driver.(*Manager)
+	if !ok {
+		return errors.New("db:bench-data requires *Manager driver")
+	}
+	bench := BenchData{Schema: ctx.Option("schema")}
+	for name, target := range map[string]*int{"scale": &bench.Scale, "workers": &bench.Workers} {
+		value := ctx.Option(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid --%s value: %s (expected a positive integer)", name, value)
+		}
+		*target = n
+	}
+	return mgr.GenerateBenchData(bench)
+}
@@ -0,0 +1,169 @@
+package migrate
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/oarkflow/cli/contracts"
+)
+
+// BundleCommand packs the migration files into one checksum-pinned,
+// optionally signed archive for apply-bundle.
+type BundleCommand struct {
+	Driver IManager
+}
+
+func (c *BundleCommand) Signature() string {
+	return "bundle"
+}
+
+func (c *BundleCommand) Description() string {
+	return "Write the migration files and a manifest of their names and checksums to a .tgz bundle, signed with --sign-key. --generate-key=true prints a new key pair instead."
+}
+
+func (c *BundleCommand) Extend() contracts.Extend {
+	return contracts.Extend{
+		Flags: []contracts.Flag{
+			{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "Bundle file to write",
+				Value:   "migrations.tgz",
+			},
+			{
+				Name:  "sign-key",
+				Usage: "Secret reference holding the base64 ed25519 private key, such as env:MIGRATE_BUNDLE_KEY; unsigned when empty",
+				Value: "",
+			},
+			{
+				Name:  "generate-key",
+				Usage: "Print a new signing key pair and exit",
+				Value: "false",
+			},
+		},
+	}
+}
+
+func (c *BundleCommand) Handle(ctx contracts.Context) error {
+	if optionEnabled(ctx, "generate-key") {
+		private, public, err := GenerateBundleKey()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Signing key (keep secret): %s\nVerify key:                %s\n", private, public)
+		return nil
+	}
+	mgr, ok := c.Driver.(*Manager)
+	if !ok {
+		return errors.New("bundle requires *Manager driver")
+	}
+	var signKey ed25519.PrivateKey
+	if ref := ctx.Option("sign-key"); ref != "" {
+		var err error
+		if signKey, err = ResolveBundleSigningKey(ref); err != nil {
+			return err
+		}
+	}
+	output := ctx.Option("output")
+	if output == "" {
+		output = "migrations.tgz"
+	}
+	var buf bytes.Buffer
+	manifest, err := mgr.WriteBundle(&buf, signKey)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(output, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	state := "unsigned"
+	if manifest.Signed {
+		state = "signed"
+	}
+	fmt.Printf("Wrote %s bundle %s with %d file(s)\n", state, output, len(manifest.Files))
+	return nil
+}
+
+// ApplyBundleCommand applies the migrations of a bundle written by the bundle
+// command, and nothing else. It accepts the flags of migrate except --seed
+// and --rows, since a bundle carries no seed files.
+type ApplyBundleCommand struct {
+	MigrateCommand
+}
+
+func (c *ApplyBundleCommand) Signature() string {
+	return "apply-bundle"
+}
+
+func (c *ApplyBundleCommand) Description() string {
+	return "Verify a migration bundle against its manifest checksums and signature, then apply its pending migrations."
+}
+
+func (c *ApplyBundleCommand) Extend() contracts.Extend {
+	extend := c.MigrateCommand.Extend()
+	flags := extend.Flags[:0:0]
+	for _, flag := range extend.Flags {
+		if flag.Name != "seed" && flag.Name != "rows" {
+			flags = append(flags, flag)
+		}
+	}
+	extend.Flags = append(flags,
+		contracts.Flag{
+			Name:  "verify-key",
+			Usage: "Secret reference holding the base64 ed25519 verify key; the bundle must carry a valid signature. Required unless --allow-unsigned=true",
+			Value: "",
+		},
+		contracts.Flag{
+			Name:  "allow-unsigned",
+			Usage: "Apply the bundle without --verify-key, without checking any signature",
+			Value: "false",
+		},
+	)
+	return extend
+}
+
+func (c *ApplyBundleCommand) Handle(ctx contracts.Context) error {
+	mgr, ok := c.Driver.(*Manager)
+	if !ok {
+		return errors.New("apply-bundle requires *Manager driver")
+	}
+	file := ctx.Argument(0)
+	if file == "" {
+		return errors.New("bundle file is required")
+	}
+	if optionEnabled(ctx, "seed") {
+		return errors.New("apply-bundle does not seed: a bundle carries no seed files; run db:seed separately")
+	}
+	var verifyKey ed25519.PublicKey
+	if ref := ctx.Option("verify-key"); ref != "" {
+		var err error
+		if verifyKey, err = ResolveBundleVerifyKey(ref); err != nil {
+			return err
+		}
+	} else if !optionEnabled(ctx, "allow-unsigned") {
+		return errors.New("apply-bundle requires --verify-key to check the bundle signature; pass --allow-unsigned=true to apply it unverified")
+	}
+	bundle, err := OpenBundle(file, verifyKey)
+	if err != nil {
+		return fmt.Errorf("bundle %s failed verification: %w", file, err)
+	}
+	if !bundle.Verified {
+		logger.Warn().Msgf("Applying bundle %s without checking its signature (--allow-unsigned)", file)
+	}
+	dir, err := os.MkdirTemp("", "migrate-bundle-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	if err := bundle.Extract(dir); err != nil {
+		return fmt.Errorf("failed to extract bundle %s: %w", file, err)
+	}
+	logger.Info().Msgf("Applying bundle %s (%d file(s), built by migrate %s at %s)", file, len(bundle.Manifest.Files), bundle.Manifest.ToolVersion, bundle.Manifest.CreatedAt.Format("2006-01-02 15:04:05"))
+	migrationDir, assets := mgr.migrationDir, mgr.assets
+	mgr.migrationDir, mgr.assets = dir, nil
+	defer func() { mgr.migrationDir, mgr.assets = migrationDir, assets }()
+	return c.MigrateCommand.Handle(ctx)
+}
@@ -0,0 +1,45 @@
+package migrate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/oarkflow/cli/contracts"
+)
+
+// ChecksumUpgradeCommand rewrites the raw checksums in the migration history
+// as normalized checksums.
+type ChecksumUpgradeCommand struct {
+	Driver IManager
+}
+
+func (c *ChecksumUpgradeCommand) Signature() string {
+	return "checksum:upgrade"
+}
+
+func (c *ChecksumUpgradeCommand) Description() string {
+	return "Rewrite raw history checksums as normalized checksums for migrations that are unchanged since they were applied."
+}
+
+func (c *ChecksumUpgradeCommand) Extend() contracts.Extend {
+	return contracts.Extend{}
+}
+
+func (c *ChecksumUpgradeCommand) Handle(ctx contracts.Context) error {
+	mgr, ok := c.Driver.(*Manager)
+	if !ok {
+		return fmt.Errorf("checksum:upgrade requires *Manager driver")
+	}
+	upgraded, skipped, err := mgr.UpgradeChecksums()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Upgraded %d checksum(s) to normalized.\n", upgraded)
+	if len(skipped) > 0 {
+		fmt.Printf("Skipped modified or missing migrations: %s\n", strings.Join(skipped, ", "))
+	}
+	if mgr.checksumMode != ChecksumNormalized {
+		fmt.Println(`Set migration.checksum = "normalized" so new migrations are recorded the same way.`)
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/oarkflow/cli/contracts"
+)
+
+// CompletionCommand prints shell completion scripts and a command reference
+// generated from the registered commands.
+type CompletionCommand struct {
+	Driver IManager
+}
+
+func (c *CompletionCommand) Signature() string {
+	return "completion"
+}
+
+func (c *CompletionCommand) Description() string {
+	return "Generate shell completion (bash|zsh|fish) or a markdown command reference."
+}
+
+func (c *CompletionCommand) Extend() contracts.Extend {
+	return contracts.Extend{
+		Flags: []contracts.Flag{
+			{
+				Name:    "name",
+				Aliases: []string{"n"},
+				Usage:   "Program name to complete (default: current executable name)",
+				Value:   "",
+			},
+		},
+	}
+}
+
+func (c *CompletionCommand) Handle(ctx contracts.Context) error {
+	shell := ctx.Argument(0)
+	if shell == "" {
+		return fmt.Errorf("shell is required: bash, zsh, fish or markdown")
+	}
+	mgr, ok := c.Driver.(*Manager)
+	if !ok {
+		return fmt.Errorf("completion requires *Manager driver")
+	}
+	prog := ctx.Option("name")
+	if prog == "" {
+		prog = filepath.Base(os.Args[0])
+	}
+	script, err := GenerateCompletion(shell, prog, mgr.registeredCommands())
+	if err != nil {
+		return err
+	}
+	fmt.Print(script)
+	return nil
+}
+
+// GenerateCompletion renders a completion script for shell covering commands.
+// Commands are invoked as "<prog> cli <command>", matching the CLI runner.
+func GenerateCompletion(shell, prog string, commands []contracts.Command) (string, error) {
+	switch strings.ToLower(shell) {
+	case "bash":
+		return bashCompletion(prog, commands), nil
+	case "zsh":
+		return zshCompletion(prog, commands), nil
+	case "fish":
+		return fishCompletion(prog, commands), nil
+	case "markdown", "md":
+		return markdownReference(prog, commands), nil
+	default:
+		return "", fmt.Errorf("unsupported shell: %s (expected bash, zsh, fish or markdown)", shell)
+	}
+}
+
+var completionFuncPattern = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+func completionFuncName(prog string) string {
+	return "_" + completionFuncPattern.ReplaceAllString(prog, "_") + "_completion"
+}
+
+func flagWords(flag contracts.Flag) []string {
+	words := []string{"--" + flag.Name}
+	for _, alias := range flag.Aliases {
+		if len(alias) == 1 {
+			words = append(words, "-"+alias)
+		} else {
+			words = append(words, "--"+alias)
+		}
+	}
+	return words
+}
+
+func commandNames(commands []contracts.Command) []string {
+	names := make([]string, 0, len(commands))
+	for _, cmd := range commands {
+		names = append(names, cmd.Signature())
+	}
+	return names
+}
+
+func bashCompletion(prog string, commands []contracts.Command) string {
+	fn := completionFuncName(prog)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# bash completion for %s\n", prog)
+	fmt.Fprintf(&sb, "%s() {\n", fn)
+	sb.WriteString("    local cur words cword\n")
+	sb.WriteString("    if declare -F _get_comp_words_by_ref >/dev/null; then\n")
+	sb.WriteString("        _get_comp_words_by_ref -n : cur words cword\n")
+	sb.WriteString("    else\n")
+	sb.WriteString("        cur=\"${COMP_WORDS[COMP_CWORD]}\"; words=(\"${COMP_WORDS[@]}\"); cword=$COMP_CWORD\n")
+	sb.WriteString("    fi\n")
+	sb.WriteString("    local i cmd=\"\" seen_cli=\"\"\n")
+	sb.WriteString("    for ((i = 1; i < cword; i++)); do\n")
+	sb.WriteString("        case \"${words[i]}\" in\n")
+	sb.WriteString("            cli) seen_cli=1 ;;\n")
+	sb.WriteString("            -*) ;;\n")
+	sb.WriteString("            *) if [[ -n \"$seen_cli\" ]]; then cmd=\"${words[i]}\"; break; fi ;;\n")
+	sb.WriteString("        esac\n")
+	sb.WriteString("    done\n")
+	sb.WriteString("    local opts=\"\"\n")
+	sb.WriteString("    case \"$cmd\" in\n")
+	for _, cmd := range commands {
+		var words []string
+		for _, flag := range cmd.Extend().Flags {
+			words = append(words, flagWords(flag)...)
+		}
+		words = append(words, "--help")
+		fmt.Fprintf(&sb, "        %s) opts=\"%s\" ;;\n", cmd.Signature(), strings.Join(words, " "))
+	}
+	fmt.Fprintf(&sb, "        \"\") if [[ -n \"$seen_cli\" ]]; then opts=\"%s\"; else opts=\"cli\"; fi ;;\n", strings.Join(commandNames(commands), " "))
+	sb.WriteString("    esac\n")
+	sb.WriteString("    COMPREPLY=($(compgen -W \"$opts\" -- \"$cur\"))\n")
+	sb.WriteString("    if declare -F __ltrim_colon_completions >/dev/null; then\n")
+	sb.WriteString("        __ltrim_colon_completions \"$cur\"\n")
+	sb.WriteString("    fi\n")
+	sb.WriteString("}\n")
+	fmt.Fprintf(&sb, "complete -F %s %s\n", fn, prog)
+	return sb.String()
+}
+
+// zshEscape escapes text for use inside single quoted zsh completion specs.
+func zshEscape(s string) string {
+	r := strings.NewReplacer("'", "'\\''", "[", "\\[", "]", "\\]", ":", "\\:")
+	return r.Replace(s)
+}
+
+func zshCompletion(prog string, commands []contracts.Command) string {
+	fn := completionFuncName(prog)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "#compdef %s\n\n", prog)
+	fmt.Fprintf(&sb, "%s() {\n", fn)
+	sb.WriteString("    local -a commands flags\n")
+	sb.WriteString("    commands=(\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(&sb, "        '%s:%s'\n", zshEscape(cmd.Signature()), zshEscape(cmd.Description()))
+	}
+	sb.WriteString("    )\n")
+	sb.WriteString("    local i cmd=\"\" seen_cli=\"\"\n")
+	sb.WriteString("    for ((i = 2; i < CURRENT; i++)); do\n")
+	sb.WriteString("        case \"${words[i]}\" in\n")
+	sb.WriteString("            cli) seen_cli=1 ;;\n")
+	sb.WriteString("            -*) ;;\n")
+	sb.WriteString("            *) if [[ -n \"$seen_cli\" ]]; then cmd=\"${words[i]}\"; break; fi ;;\n")
+	sb.WriteString("        esac\n")
+	sb.WriteString("    done\n")
+	sb.WriteString("    if [[ -z \"$seen_cli\" ]]; then\n")
+	sb.WriteString("        compadd cli\n")
+	sb.WriteString("        return\n")
+	sb.WriteString("    fi\n")
+	sb.WriteString("    if [[ -z \"$cmd\" ]]; then\n")
+	sb.WriteString("        _describe -t commands 'command' commands\n")
+	sb.WriteString("        return\n")
+	sb.WriteString("    fi\n")
+	sb.WriteString("    case \"$cmd\" in\n")
+	for _, cmd := range commands {
+		var specs []string
+		for _, flag := range cmd.Extend().Flags {
+			for _, word := range flagWords(flag) {
+				specs = append(specs, fmt.Sprintf("'%s=[%s]:value:'", word, zshEscape(flag.Usage)))
+			}
+		}
+		fmt.Fprintf(&sb, "        %s) flags=(%s) ;;\n", cmd.Signature(), strings.Join(specs, " "))
+	}
+	sb.WriteString("    esac\n")
+	sb.WriteString("    _arguments -s $flags '*::argument:'\n")
+	sb.WriteString("}\n\n")
+	fmt.Fprintf(&sb, "compdef %s %s\n", fn, prog)
+	return sb.String()
+}
+
+// fishEscape escapes text for use inside single quoted fish arguments.
+func fishEscape(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "'", "\\'").Replace(s)
+}
+
+func fishCompletion(prog string, commands []contracts.Command) string {
+	var sb strings.Builder
+	names := strings.Join(commandNames(commands), " ")
+	fmt.Fprintf(&sb, "# fish completion for %s\n", prog)
+	fmt.Fprintf(&sb, "complete -c %s -f\n", prog)
+	fmt.Fprintf(&sb, "complete -c %s -n '__fish_use_subcommand' -a 'cli' -d 'Run a command'\n", prog)
+	for _, cmd := range commands {
+		fmt.Fprintf(&sb, "complete -c %s -n '__fish_seen_subcommand_from cli; and not __fish_seen_subcommand_from %s' -a '%s' -d '%s'\n",
+			prog, names, cmd.Signature(), fishEscape(cmd.Description()))
+	}
+	for _, cmd := range commands {
+		for _, flag := range cmd.Extend().Flags {
+			line := fmt.Sprintf("complete -c %s -n '__fish_seen_subcommand_from %s' -l %s", prog, cmd.Signature(), flag.Name)
+			for _, alias := range flag.Aliases {
+				if len(alias) == 1 {
+					line += " -s " + alias
+				} else {
+					line += " -l " + alias
+				}
+			}
+			fmt.Fprintf(&sb, "%s -r -d '%s'\n", line, fishEscape(flag.Usage))
+		}
+	}
+	return sb.String()
+}
+
+func markdownReference(prog string, commands []contracts.Command) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s command reference\n", prog)
+	for _, cmd := range commands {
+		fmt.Fprintf(&sb, "\n## `%s`\n\n%s\n\n```\n%s cli %s [options] [arguments...]\n```\n", cmd.Signature(), cmd.Description(), prog, cmd.Signature())
+		flags := cmd.Extend().Flags
+		if len(flags) == 0 {
+			continue
+		}
+		sb.WriteString("\n| Flag | Default | Description |\n|------|---------|-------------|\n")
+		for _, flag := range flags {
+			fmt.Fprintf(&sb, "| `%s` | `%s` | %s |\n", strings.Join(flagWords(flag), "`, `"), flag.Value, strings.ReplaceAll(flag.Usage, "|", "\\|"))
+		}
+	}
+	return sb.String()
+}
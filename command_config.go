@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/oarkflow/cli/contracts"
 	"github.com/oarkflow/json"
@@ -29,7 +30,7 @@ func (c *ConfigCommand) Extend() contracts.Extend {
 }
 
 func (c *ConfigCommand) Handle(ctx contracts.Context) error {
-	return fmt.Errorf("please use config:init, config:validate, or config:show commands")
+	return fmt.Errorf("please use config:init, config:validate, config:show, config:get or config:set commands")
 }
 
 // ConfigInitCommand initializes a new configuration file
@@ -42,7 +43,7 @@ func (c *ConfigInitCommand) Signature() string {
 }
 
 func (c *ConfigInitCommand) Description() string {
-	return "Initialize a new configuration file"
+	return "Initialize a new configuration file. On a terminal it asks for the database settings and tests the connection; otherwise, or with --no-input=true, it writes a sample file to edit"
 }
 
 func (c *ConfigInitCommand) Extend() contracts.Extend {
@@ -51,9 +52,10 @@ func (c *ConfigInitCommand) Extend() contracts.Extend {
 			{
 				Name:    "path",
 				Aliases: []string{"p"},
-				Usage:   "Path to configuration file",
-				Value:   "migrate.json",
+				Usage:   "Path to configuration file (default: the --config file or migrate.json)",
+				Value:   "",
 			},
+			configFlagDefinition(),
 			{
 				Name:    "force",
 				Aliases: []string{"f"},
@@ -65,10 +67,7 @@ func (c *ConfigInitCommand) Extend() contracts.Extend {
 }
 
 func (c *ConfigInitCommand) Handle(ctx contracts.Context) error {
-	configPath := ctx.Option("path")
-	if configPath == "" {
-		configPath = "migrate.json"
-	}
+	configPath := configFileOption(ctx, c.Driver)
 
 	force := ctx.Option("force") == "true"
 
@@ -77,6 +76,18 @@ func (c *ConfigInitCommand) Handle(ctx contracts.Context) error {
 		return fmt.Errorf("configuration file already exists at %s (use --force to overwrite)", configPath)
 	}
 
+	if inputAllowed(ctx) {
+		config, err := runConfigWizard(os.Stdin, os.Stdout, testConfigConnection)
+		if err != nil {
+			return fmt.Errorf("configuration wizard: %w", err)
+		}
+		if err := config.SaveConfig(configPath); err != nil {
+			return fmt.Errorf("failed to create configuration file: %w", err)
+		}
+		logger.Info().Msgf("Configuration file created: %s", configPath)
+		return nil
+	}
+
 	// Create sample configuration
 	if err := CreateSampleConfig(configPath); err != nil {
 		return fmt.Errorf("failed to create configuration file: %w", err)
@@ -107,18 +118,16 @@ func (c *ConfigValidateCommand) Extend() contracts.Extend {
 			{
 				Name:    "path",
 				Aliases: []string{"p"},
-				Usage:   "Path to configuration file",
-				Value:   "migrate.json",
+				Usage:   "Path to configuration file (default: the --config file or migrate.json)",
+				Value:   "",
 			},
+			configFlagDefinition(),
 		},
 	}
 }
 
 func (c *ConfigValidateCommand) Handle(ctx contracts.Context) error {
-	configPath := ctx.Option("path")
-	if configPath == "" {
-		configPath = "migrate.json"
-	}
+	configPath := configFileOption(ctx, c.Driver)
 
 	// Load and validate configuration
 	config, err := LoadConfig(configPath)
@@ -153,9 +162,10 @@ func (c *ConfigShowCommand) Extend() contracts.Extend {
 			{
 				Name:    "path",
 				Aliases: []string{"p"},
-				Usage:   "Path to configuration file",
-				Value:   "migrate.json",
+				Usage:   "Path to configuration file (default: the --config file or migrate.json)",
+				Value:   "",
 			},
+			configFlagDefinition(),
 			{
 				Name:    "format",
 				Aliases: []string{"f"},
@@ -167,10 +177,7 @@ func (c *ConfigShowCommand) Extend() contracts.Extend {
 }
 
 func (c *ConfigShowCommand) Handle(ctx contracts.Context) error {
-	configPath := ctx.Option("path")
-	if configPath == "" {
-		configPath = "migrate.json"
-	}
+	configPath := configFileOption(ctx, c.Driver)
 
 	format := ctx.Option("format")
 	if format == "" {
@@ -227,6 +234,7 @@ func (c *ConfigShowCommand) showTable(config *MigrateConfig) error {
 	fmt.Printf("  Auto Rollback:   %t\n", config.Migration.AutoRollback)
 	fmt.Printf("  Dry Run:         %t\n", config.Migration.DryRun)
 	fmt.Printf("  Skip Validation: %t\n", config.Migration.SkipValidation)
+	fmt.Printf("  Checksum:        %s\n", config.Migration.Checksum)
 	fmt.Println()
 
 	fmt.Println("Seed:")
@@ -234,6 +242,9 @@ func (c *ConfigShowCommand) showTable(config *MigrateConfig) error {
 	fmt.Printf("  Default Rows:    %d\n", config.Seed.DefaultRows)
 	fmt.Printf("  Truncate First:  %t\n", config.Seed.TruncateFirst)
 	fmt.Printf("  Batch Size:      %d\n", config.Seed.BatchSize)
+	if config.Seed.EncryptionKey != "" {
+		fmt.Printf("  Encryption Key:  %s\n", config.Seed.EncryptionKey)
+	}
 	fmt.Println()
 
 	fmt.Println("Logging:")
@@ -241,6 +252,7 @@ func (c *ConfigShowCommand) showTable(config *MigrateConfig) error {
 	fmt.Printf("  Format:  %s\n", config.Logging.Format)
 	fmt.Printf("  Output:  %s\n", config.Logging.Output)
 	fmt.Printf("  Verbose: %t\n", config.Logging.Verbose)
+	fmt.Printf("  Error Hints: %t\n", config.Logging.ErrorHints)
 	if config.Logging.LogFile != "" {
 		fmt.Printf("  Log File: %s\n", config.Logging.LogFile)
 	}
@@ -251,6 +263,10 @@ func (c *ConfigShowCommand) showTable(config *MigrateConfig) error {
 	fmt.Printf("  Strict Mode:           %t\n", config.Validation.StrictMode)
 	fmt.Printf("  Max Identifier Length: %d\n", config.Validation.MaxIdentifierLen)
 	fmt.Printf("  Require Description:   %t\n", config.Validation.RequireDescription)
+	fmt.Printf("  Require Author:        %t\n", config.Validation.RequireAuthor)
+	fmt.Printf("  Require Ticket:        %t\n", config.Validation.RequireTicket)
+	fmt.Printf("  Require Review:        %t\n", config.Validation.RequireReview)
+	fmt.Printf("  Large Table Rows:      %d\n", config.Validation.LargeTableRows)
 	if len(config.Validation.ForbiddenNames) > 0 {
 		fmt.Printf("  Forbidden Names:       %v\n", config.Validation.ForbiddenNames)
 	}
@@ -283,12 +299,16 @@ func (c *StatusCommand) Extend() contracts.Extend {
 				Usage:   "Show detailed status",
 				Value:   "false",
 			},
+			jobsFlagDefinition(),
 		},
 	}
 }
 
 func (c *StatusCommand) Handle(ctx contracts.Context) error {
 	verbose := ctx.Option("verbose") == "true"
+	if err := applyJobsOption(ctx, c.Driver); err != nil {
+		return err
+	}
 
 	// Get migration files
 	files, err := os.ReadDir(c.Driver.MigrationDir())
@@ -321,5 +341,132 @@ func (c *StatusCommand) Handle(ctx contracts.Context) error {
 		}
 	}
 
+	if mgr, ok := c.Driver.(*Manager); ok {
+		statuses, err := mgr.MigrationStatuses()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\nMigrations:\n")
+		for _, status := range statuses {
+			line := fmt.Sprintf("  %-8s %s", status.State(), status.Name)
+			if status.Phase == PhasePostDeploy {
+				line += " (post-deploy)"
+			}
+			if len(status.Tags) > 0 {
+				line += fmt.Sprintf(" [%s]", strings.Join(status.Tags, ", "))
+			}
+			if ownership := status.Ownership(); ownership != "" {
+				line += " (" + ownership + ")"
+			}
+			fmt.Println(line)
+		}
+	}
+
+	return nil
+}
+
+// ConfigGetCommand prints configuration values by key.
+type ConfigGetCommand struct {
+	Driver IManager
+}
+
+func (c *ConfigGetCommand) Signature() string {
+	return "config:get"
+}
+
+func (c *ConfigGetCommand) Description() string {
+	return "Print a configuration value, e.g. config:get seed.batch_size; without a key, print every key and value"
+}
+
+func (c *ConfigGetCommand) Extend() contracts.Extend {
+	return contracts.Extend{
+		Flags: []contracts.Flag{
+			{
+				Name:    "path",
+				Aliases: []string{"p"},
+				Usage:   "Path to configuration file (default: the --config file or migrate.json)",
+				Value:   "",
+			},
+			configFlagDefinition(),
+		},
+	}
+}
+
+func (c *ConfigGetCommand) Handle(ctx contracts.Context) error {
+	configPath := configFileOption(ctx, c.Driver)
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	config.ApplyEnvironmentOverrides()
+	if key := ctx.Argument(0); key != "" {
+		value, err := GetConfigValue(config, key)
+		if err != nil {
+			return err
+		}
+		fmt.Println(value)
+		return nil
+	}
+	for _, key := range ConfigKeys() {
+		value, err := GetConfigValue(config, key)
+		if err != nil {
+			return err
+		}
+		if key == "database.password" && value != "" {
+			value = "********"
+		}
+		fmt.Printf("%s=%s\n", key, value)
+	}
+	return nil
+}
+
+// ConfigSetCommand changes configuration values in the configuration file.
+type ConfigSetCommand struct {
+	Driver IManager
+}
+
+func (c *ConfigSetCommand) Signature() string {
+	return "config:set"
+}
+
+func (c *ConfigSetCommand) Description() string {
+	return "Set configuration values, e.g. config:set database.host=db.internal database.port=5433; the file is only written when the result is valid"
+}
+
+func (c *ConfigSetCommand) Extend() contracts.Extend {
+	return contracts.Extend{
+		Flags: []contracts.Flag{
+			{
+				Name:    "path",
+				Aliases: []string{"p"},
+				Usage:   "Path to configuration file (default: the --config file or migrate.json)",
+				Value:   "",
+			},
+			configFlagDefinition(),
+		},
+	}
+}
+
+func (c *ConfigSetCommand) Handle(ctx contracts.Context) error {
+	configPath := configFileOption(ctx, c.Driver)
+	args := ctx.Arguments()
+	if len(args) == 0 {
+		return fmt.Errorf("usage: config:set key=value [key=value ...]")
+	}
+	values := make(map[string]string, len(args))
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("invalid setting %q: expected key=value", arg)
+		}
+		values[key] = value
+	}
+	if err := SetConfigValues(configPath, values); err != nil {
+		return err
+	}
+	for _, arg := range args {
+		key, _, _ := strings.Cut(arg, "=")
+		logger.Info().Msgf("Set %s in %s", key, configPath)
+	}
 	return nil
 }
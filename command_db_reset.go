@@ -1,7 +1,6 @@
 package migrate
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"strings"
@@ -25,16 +24,11 @@ func (c *ResetDatabaseCommand) Description() string {
 func (c *ResetDatabaseCommand) Extend() contracts.Extend {
 	return contracts.Extend{
 		Flags: []contracts.Flag{
-			{
-				Name:    "config",
-				Aliases: []string{"c"},
-				Usage:   "Path to configuration file",
-				Value:   "",
-			},
+			configFlagDefinition(),
 			{
 				Name:    "force",
 				Aliases: []string{"f"},
-				Usage:   "Skip confirmation prompt",
+				Usage:   "Skip confirmation prompt (same as --yes)",
 				Value:   "false",
 			},
 			{
@@ -43,13 +37,14 @@ func (c *ResetDatabaseCommand) Extend() contracts.Extend {
 				Usage:   "Enable verbose output",
 				Value:   "false",
 			},
+			yesProductionFlagDefinition(),
 		},
 	}
 }
 
 func (c *ResetDatabaseCommand) Handle(ctx contracts.Context) error {
 	configPath := ctx.Option("config")
-	force := ctx.Option("force") == "true" || ctx.Option("force") == "1"
+	force := optionEnabled(ctx, "force") || optionEnabled(ctx, "yes")
 	verbose := ctx.Option("verbose") == "true" || ctx.Option("verbose") == "1"
 
 	// If no config path from CLI, use the one from Manager (set via --config at startup)
@@ -69,6 +64,10 @@ func (c *ResetDatabaseCommand) Handle(ctx contracts.Context) error {
 		return fmt.Errorf("invalid configuration: %w", err)
 	}
 
+	if err := confirmProtectedEnvironment(ctx, cfg.Environment, "Database reset"); err != nil {
+		return err
+	}
+
 	if verbose {
 		if mgr, ok := c.Driver.(*Manager); ok {
 			mgr.Verbose = true
@@ -84,11 +83,11 @@ func (c *ResetDatabaseCommand) Handle(ctx contracts.Context) error {
 	}
 
 	if !force {
-		fmt.Printf("Type 'yes' to continue: ")
-		r := bufio.NewReader(os.Stdin)
-		resp, _ := r.ReadString('\n')
-		resp = strings.TrimSpace(resp)
-		if strings.ToLower(resp) != "yes" {
+		ok, err := promptConfirmation(ctx, "Type 'yes' to continue: ", "yes", "pass --yes to reset without prompting")
+		if err != nil {
+			return err
+		}
+		if !ok {
 			logger.Info().Msg("Aborted.")
 			return nil
 		}
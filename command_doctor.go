@@ -0,0 +1,313 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/oarkflow/cli/contracts"
+	"github.com/oarkflow/json"
+)
+
+// Doctor finding statuses.
+const (
+	DoctorOK   = "ok"
+	DoctorWarn = "warn"
+	DoctorFail = "fail"
+)
+
+// doctorProbeTable is created and dropped to verify DDL permissions.
+const doctorProbeTable = "migrate_doctor_probe"
+
+// DoctorFinding is the result of a single diagnostic check.
+type DoctorFinding struct {
+	Check   string `json:"check"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// DoctorCommand diagnoses common environment problems.
+type DoctorCommand struct {
+	Driver IManager
+}
+
+func (c *DoctorCommand) Signature() string {
+	return "doctor"
+}
+
+func (c *DoctorCommand) Description() string {
+	return "Diagnose connectivity, permissions, history, lock and checksum problems."
+}
+
+func (c *DoctorCommand) Extend() contracts.Extend {
+	return contracts.Extend{
+		Flags: []contracts.Flag{
+			{
+				Name:  "format",
+				Usage: "Output format (text|json)",
+				Value: "text",
+			},
+			jobsFlagDefinition(),
+		},
+	}
+}
+
+func (c *DoctorCommand) Handle(ctx contracts.Context) error {
+	mgr, ok := c.Driver.(*Manager)
+	if !ok {
+		return fmt.Errorf("doctor requires *Manager driver")
+	}
+	if err := applyJobsOption(ctx, c.Driver); err != nil {
+		return err
+	}
+	findings := mgr.Diagnose()
+	failures, drift := 0, false
+	for _, f := range findings {
+		if f.Status == DoctorFail {
+			failures++
+			drift = drift || f.Check == "checksums"
+		}
+	}
+	if ctx.Option("format") == "json" {
+		data, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal findings: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		for _, f := range findings {
+			fmt.Printf("[%-4s] %-20s %s\n", strings.ToUpper(f.Status), f.Check, f.Message)
+			if f.Hint != "" {
+				fmt.Printf("       %-20s -> %s\n", "", f.Hint)
+			}
+		}
+	}
+	if drift {
+		return fmt.Errorf("doctor found %d problem(s): %w", failures, ErrChecksumMismatch)
+	}
+	if failures > 0 {
+		return fmt.Errorf("doctor found %d problem(s)", failures)
+	}
+	return nil
+}
+
+// Diagnose runs every doctor check and returns the findings in order.
+func (d *Manager) Diagnose() []DoctorFinding {
+	var findings []DoctorFinding
+	findings = append(findings, d.diagnoseMigrationDir())
+	connection := d.diagnoseConnection()
+	findings = append(findings, connection)
+	if connection.Status == DoctorOK {
+		findings = append(findings, d.diagnosePermissions())
+	}
+	findings = append(findings, d.diagnoseHistory()...)
+	findings = append(findings, diagnoseLock())
+	findings = append(findings, d.diagnoseChecksums()...)
+	return findings
+}
+
+func (d *Manager) diagnoseMigrationDir() DoctorFinding {
+	finding := DoctorFinding{Check: "migration directory"}
+	var err error
+	if d.assets != nil {
+		_, err = fs.ReadDir(d.assets, d.migrationDir)
+	} else {
+		_, err = os.ReadDir(d.migrationDir)
+	}
+	if err != nil {
+		finding.Status = DoctorFail
+		finding.Message = fmt.Sprintf("cannot read %s: %v", d.migrationDir, err)
+		finding.Hint = "check migration.directory in the config and the directory permissions"
+		return finding
+	}
+	finding.Status = DoctorOK
+	finding.Message = fmt.Sprintf("%s is readable", d.migrationDir)
+	return finding
+}
+
+func (d *Manager) diagnoseConnection() DoctorFinding {
+	finding := DoctorFinding{Check: "database connection"}
+	if d.dbDriver == nil {
+		finding.Status = DoctorFail
+		finding.Message = "no database driver configured"
+		finding.Hint = "set the database section in migrate.json or pass --config"
+		return finding
+	}
+	if err := d.dbDriver.Ping(context.Background()); err != nil {
+		finding.Status = DoctorFail
+		finding.Message = fmt.Sprintf("cannot reach %s database: %v", d.dialect, err)
+		finding.Hint = "verify host, port, credentials and that the database server is running"
+		return finding
+	}
+	finding.Status = DoctorOK
+	finding.Message = fmt.Sprintf("%s database is reachable", d.dialect)
+	return finding
+}
+
+func (d *Manager) diagnosePermissions() DoctorFinding {
+	finding := DoctorFinding{Check: "ddl permissions"}
+	dial, err := GetDialect(d.dialect)
+	if err != nil {
+		finding.Status = DoctorFail
+		finding.Message = err.Error()
+		return finding
+	}
+	create, err := dial.CreateTableSQL(CreateTable{
+		Name:      doctorProbeTable,
+		AddFields: []AddField{{Name: "id", Type: "integer"}},
+	}, true)
+	if err == nil {
+		err = execDialectSQL(d.dbDriver.DB(), dial, create)
+	}
+	if err != nil {
+		finding.Status = DoctorFail
+		finding.Message = fmt.Sprintf("cannot create tables: %v", err)
+		finding.Hint = "grant CREATE privileges to the migration user"
+		return finding
+	}
+	drop, err := dial.DropTableSQL(DropTable{Name: doctorProbeTable})
+	if err == nil {
+		err = execDialectSQL(d.dbDriver.DB(), dial, drop)
+	}
+	if err != nil {
+		finding.Status = DoctorWarn
+		finding.Message = fmt.Sprintf("created probe table but cannot drop it: %v", err)
+		finding.Hint = fmt.Sprintf("grant DROP privileges and remove the %s table manually", doctorProbeTable)
+		return finding
+	}
+	finding.Status = DoctorOK
+	finding.Message = "can create and drop tables"
+	return finding
+}
+
+func (d *Manager) diagnoseHistory() []DoctorFinding {
+	finding := DoctorFinding{Check: "history storage"}
+	if d.historyDriver == nil {
+		finding.Status = DoctorFail
+		finding.Message = "no history driver configured"
+		return []DoctorFinding{finding}
+	}
+	histories, err := d.historyDriver.Load()
+	if err != nil {
+		finding.Status = DoctorFail
+		finding.Message = fmt.Sprintf("cannot load migration history: %v", err)
+		finding.Hint = "run migrate once to create the history storage, or check migration.table_name"
+		return []DoctorFinding{finding}
+	}
+	finding.Status = DoctorOK
+	finding.Message = fmt.Sprintf("%d applied migration(s) recorded", len(histories))
+	findings := []DoctorFinding{finding}
+	if dbHistory, ok := databaseHistory(d.historyDriver); ok {
+		version := DoctorFinding{Check: "history schema"}
+		v, err := dbHistory.SchemaVersion()
+		switch {
+		case err != nil:
+			version.Status = DoctorWarn
+			version.Message = fmt.Sprintf("cannot read schema version: %v", err)
+			version.Hint = "run migrate to upgrade the history table"
+		case v < HistorySchemaVersion:
+			version.Status = DoctorWarn
+			version.Message = fmt.Sprintf("schema version %d is older than %d", v, HistorySchemaVersion)
+			version.Hint = "run migrate to upgrade the history table"
+		default:
+			version.Status = DoctorOK
+			version.Message = fmt.Sprintf("schema version %d", v)
+		}
+		findings = append(findings, version)
+	}
+	return findings
+}
+
+func diagnoseLock() DoctorFinding {
+	finding := DoctorFinding{Check: "migration lock"}
+	info, err := ReadLockStatus()
+	if err != nil {
+		finding.Status = DoctorWarn
+		finding.Message = fmt.Sprintf("cannot inspect %s: %v", lockFileName, err)
+		return finding
+	}
+	if info == nil {
+		finding.Status = DoctorOK
+		finding.Message = "no lock held"
+		return finding
+	}
+	finding.Status = DoctorWarn
+	if info.Stale(time.Now()) {
+		finding.Message = fmt.Sprintf("stale lock from %s (pid %d), last renewed %s", info.Holder, info.PID, info.RenewedAt.Format(time.DateTime))
+		finding.Hint = "the next migrate run takes the lock over; delete " + lockFileName + " to clear it now"
+		return finding
+	}
+	finding.Message = fmt.Sprintf("held by %s (pid %d) for %s", info.Holder, info.PID, time.Since(info.AcquiredAt).Round(time.Second))
+	finding.Hint = "a migration is running; use lock:status to follow it"
+	return finding
+}
+
+func (d *Manager) diagnoseChecksums() []DoctorFinding {
+	if d.historyDriver == nil {
+		return nil
+	}
+	migrationMap, err := d.ListMigrationMap()
+	if err != nil {
+		return []DoctorFinding{{
+			Check:   "checksums",
+			Status:  DoctorFail,
+			Message: fmt.Sprintf("cannot list migrations: %v", err),
+		}}
+	}
+	histories, err := d.historyDriver.Load()
+	if err != nil {
+		return nil
+	}
+	var findings []DoctorFinding
+	for _, h := range histories {
+		path, ok := migrationMap[h.Name]
+		if !ok {
+			findings = append(findings, DoctorFinding{
+				Check:   "checksums",
+				Status:  DoctorWarn,
+				Message: fmt.Sprintf("applied migration %s has no file", h.Name),
+				Hint:    "restore the migration file or remove the stale history record",
+			})
+			continue
+		}
+		raw, normalized, err := d.migrationChecksums(path, h.Name)
+		if err != nil {
+			findings = append(findings, DoctorFinding{
+				Check:   "checksums",
+				Status:  DoctorFail,
+				Message: fmt.Sprintf("cannot read %s: %v", path, err),
+			})
+			continue
+		}
+		if !checksumMatches(h.Checksum, raw, normalized) {
+			if d.isRepeatable(path, h.Name) {
+				findings = append(findings, DoctorFinding{
+					Check:   "checksums",
+					Status:  DoctorWarn,
+					Message: fmt.Sprintf("repeatable migration %s changed since it was applied", h.Name),
+					Hint:    "run migrate to apply the new definition",
+				})
+				continue
+			}
+			findings = append(findings, DoctorFinding{
+				Check:   "checksums",
+				Status:  DoctorFail,
+				Message: fmt.Sprintf("%s was modified after being applied", h.Name),
+				Hint:    fmt.Sprintf("revert %s or add a new migration with the change", filepath.Base(path)),
+			})
+		}
+	}
+	if len(findings) == 0 {
+		findings = append(findings, DoctorFinding{
+			Check:   "checksums",
+			Status:  DoctorOK,
+			Message: fmt.Sprintf("%d applied migration(s) match their files", len(histories)),
+		})
+	}
+	return findings
+}
@@ -0,0 +1,77 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/oarkflow/cli/contracts"
+)
+
+// GenerateModelsCommand writes Go structs for the tables defined by the
+// migrations.
+type GenerateModelsCommand struct {
+	Driver IManager
+}
+
+func (c *GenerateModelsCommand) Signature() string {
+	return "generate:models"
+}
+
+func (c *GenerateModelsCommand) Description() string {
+	return "Generates Go structs with db and json tags from the tables defined by the migrations."
+}
+
+func (c *GenerateModelsCommand) Extend() contracts.Extend {
+	return contracts.Extend{
+		Flags: []contracts.Flag{
+			{
+				Name:  "package",
+				Usage: "Package name of the generated file",
+				Value: "models",
+			},
+			{
+				Name:  "schema",
+				Usage: "Directory of the migration directory whose tables are generated (default: every migration)",
+				Value: "",
+			},
+			{
+				Name:  "output",
+				Usage: "File to write the models to, or - for stdout (default: <package>/models.go)",
+				Value: "",
+			},
+		},
+	}
+}
+
+func (c *GenerateModelsCommand) Handle(ctx contracts.Context) error {
+	mgr, ok := c.Driver.(*Manager)
+	if !ok {
+		return errors.New("generate:models requires *Manager driver")
+	}
+	pkg := ctx.Option("package")
+	if pkg == "" {
+		pkg = "models"
+	}
+	src, err := mgr.GenerateModels(pkg, ctx.Option("schema"))
+	if err != nil {
+		return err
+	}
+	output := ctx.Option("output")
+	if output == "-" {
+		_, err := os.Stdout.Write(src)
+		return err
+	}
+	if output == "" {
+		output = filepath.Join(pkg, "models.go")
+	}
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		return fmt.Errorf("failed to create model directory: %w", err)
+	}
+	if err := os.WriteFile(output, src, 0644); err != nil {
+		return fmt.Errorf("failed to write models: %w", err)
+	}
+	logger.Info().Msgf("Models written to %s", output)
+	return nil
+}
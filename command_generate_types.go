@@ -0,0 +1,77 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/oarkflow/cli/contracts"
+)
+
+// GenerateTypesCommand writes frontend type declarations for the tables
+// defined by the migrations.
+type GenerateTypesCommand struct {
+	Driver IManager
+}
+
+func (c *GenerateTypesCommand) Signature() string {
+	return "generate:types"
+}
+
+func (c *GenerateTypesCommand) Description() string {
+	return "Generates TypeScript interfaces from the tables defined by the migrations."
+}
+
+func (c *GenerateTypesCommand) Extend() contracts.Extend {
+	return contracts.Extend{
+		Flags: []contracts.Flag{
+			{
+				Name:  "lang",
+				Usage: "Language of the declarations: ts",
+				Value: TypesTypeScript,
+			},
+			{
+				Name:  "schema",
+				Usage: "Directory of the migration directory whose tables are generated (default: every migration)",
+				Value: "",
+			},
+			{
+				Name:  "output",
+				Usage: "File to write the declarations to, or - for stdout",
+				Value: "schema.ts",
+			},
+		},
+	}
+}
+
+func (c *GenerateTypesCommand) Handle(ctx contracts.Context) error {
+	mgr, ok := c.Driver.(*Manager)
+	if !ok {
+		return errors.New("generate:types requires *Manager driver")
+	}
+	lang := ctx.Option("lang")
+	if lang == "" {
+		lang = TypesTypeScript
+	}
+	src, err := mgr.GenerateTypes(lang, ctx.Option("schema"))
+	if err != nil {
+		return err
+	}
+	output := ctx.Option("output")
+	if output == "-" {
+		_, err := os.Stdout.Write(src)
+		return err
+	}
+	if output == "" {
+		output = "schema.ts"
+	}
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := os.WriteFile(output, src, 0644); err != nil {
+		return fmt.Errorf("failed to write types: %w", err)
+	}
+	logger.Info().Msgf("Types written to %s", output)
+	return nil
+}
@@ -0,0 +1,73 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/oarkflow/cli/contracts"
+)
+
+// GrepCommand searches the text of the migration files, to find the
+// migration that introduced a column, an index or a SQL snippet.
+type GrepCommand struct {
+	Driver IManager
+}
+
+func (c *GrepCommand) Signature() string {
+	return "grep"
+}
+
+func (c *GrepCommand) Description() string {
+	return "Search the migration files for a regular expression and print the matching lines with their migration, oldest first."
+}
+
+func (c *GrepCommand) Extend() contracts.Extend {
+	return contracts.Extend{
+		Flags: []contracts.Flag{
+			{
+				Name:    "ignore-case",
+				Aliases: []string{"i"},
+				Usage:   "Match the pattern case-insensitively",
+				Value:   "false",
+			},
+			{
+				Name:    "list",
+				Aliases: []string{"l"},
+				Usage:   "Print only the names of the matching migrations",
+				Value:   "false",
+			},
+		},
+	}
+}
+
+func (c *GrepCommand) Handle(ctx contracts.Context) error {
+	mgr, ok := c.Driver.(*Manager)
+	if !ok {
+		return errors.New("grep requires *Manager driver")
+	}
+	pattern := ctx.Argument(0)
+	if pattern == "" {
+		return errors.New("grep requires a pattern, e.g. migrate grep 'email'")
+	}
+	matches, err := mgr.SearchMigrations(pattern, optionEnabled(ctx, "ignore-case"))
+	if err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		fmt.Printf("No migration matches %q\n", pattern)
+		return nil
+	}
+	list := optionEnabled(ctx, "list")
+	last := ""
+	for _, m := range matches {
+		if list {
+			if m.Migration != last {
+				fmt.Println(m.Migration)
+			}
+		} else {
+			fmt.Printf("%s:%d: %s: %s\n", m.File, m.Line, m.Migration, m.Text)
+		}
+		last = m.Migration
+	}
+	return nil
+}
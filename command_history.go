@@ -42,6 +42,12 @@ func (c *HistoryCommand) Extend() contracts.Extend {
 				Usage:   "Serve the HTML report at a local HTTP endpoint instead of writing to a file",
 				Value:   "false",
 			},
+			{
+				Name:  "with-down",
+				Usage: "Add the Down SQL each applied migration would run if rolled back now, flagging irreversible operations",
+				Value: "false",
+			},
+			jobsFlagDefinition(),
 		},
 	}
 }
@@ -65,6 +71,8 @@ type MigrationChange struct {
 type MigrationGroup struct {
 	Date           time.Time
 	MigrationName  string
+	Ownership      string // Author, Ticket and ReviewedBy of the migration
+	Description    string // Description of the migration
 	Actions        []MigrationChange
 	StructureAfter string // HTML snapshot after this migration
 }
@@ -77,6 +85,9 @@ type objectInfo struct {
 func (c *HistoryCommand) Handle(ctx contracts.Context) error {
 	objectName := ctx.Option("object")
 	serveFlag := ctx.Option("serve") == "true"
+	if err := applyJobsOption(ctx, c.Driver); err != nil {
+		return err
+	}
 
 	// Recursively find all .bcl migration files except those in SeedDir.
 	var filePaths []string
@@ -128,41 +139,28 @@ func (c *HistoryCommand) Handle(ctx contracts.Context) error {
 			return fmt.Errorf("failed to walk migration directory: %w", err)
 		}
 		readFile = os.ReadFile
+		// The report reads every file once per object; parse each only once.
+		parsed := make(map[string][]Migration)
 		readMigrations = func(path string) ([]Migration, error) {
+			if migrations, ok := parsed[path]; ok {
+				return migrations, nil
+			}
 			data, err := readFile(path)
 			if err != nil {
 				return nil, err
 			}
-			return ParseMigrationsBCL(data)
+			migrations, err := ParseMigrationsBCL(data)
+			if err != nil {
+				return nil, withParseErrorFile(err, path)
+			}
+			parsed[path] = migrations
+			return migrations, nil
 		}
 	}
 	// Sort by filename (timestamp prefix)
 	sort.Strings(filePaths)
 
-	objectSet := make(map[string]string)
-	for _, path := range filePaths {
-		migrations, err := readMigrations(path)
-		if err != nil {
-			continue
-		}
-		for _, m := range migrations {
-			for _, ct := range m.Up.CreateTable {
-				objectSet[strings.ToLower(ct.Name)] = "table"
-			}
-			for _, cv := range m.Up.CreateView {
-				objectSet[strings.ToLower(cv.Name)] = "view"
-			}
-			for _, cf := range m.Up.CreateFunction {
-				objectSet[strings.ToLower(cf.Name)] = "function"
-			}
-			for _, cp := range m.Up.CreateProcedure {
-				objectSet[strings.ToLower(cp.Name)] = "procedure"
-			}
-			for _, ct := range m.Up.CreateTrigger {
-				objectSet[strings.ToLower(ct.Name)] = "trigger"
-			}
-		}
-	}
+	objectSet := historyObjects(filePaths, readMigrations)
 
 	var allObjects []objectInfo
 	if objectName == "" {
@@ -183,6 +181,26 @@ func (c *HistoryCommand) Handle(ctx contracts.Context) error {
 	if err != nil {
 		return err
 	}
+	if sources, err := migrationSources(filePaths, readFile, readMigrations); err != nil {
+		logger.Warn().Msgf("Searching migration contents is unavailable in the report: %v", err)
+	} else {
+		report = strings.Replace(report, "</body>", migrationSearchScript(sources)+"</body>", 1)
+	}
+	if optionEnabled(ctx, "with-down") {
+		mgr, ok := c.Driver.(*Manager)
+		if !ok {
+			return fmt.Errorf("history --with-down requires *Manager driver")
+		}
+		previews, err := mgr.RollbackPreviews()
+		if err != nil {
+			return err
+		}
+		section, err := rollbackPreviewHTML(previews)
+		if err != nil {
+			return err
+		}
+		report = strings.Replace(report, "</body>", section+"</body>", 1)
+	}
 
 	if serveFlag {
 		// Serve the HTML report at http://localhost:8080/history
@@ -207,6 +225,36 @@ func (c *HistoryCommand) Handle(ctx contracts.Context) error {
 	return nil
 }
 
+// historyObjects returns the type of each object created by the migrations
+// of filePaths, keyed by lowercased object name.
+func historyObjects(filePaths []string, readMigrations func(string) ([]Migration, error)) map[string]string {
+	objectSet := make(map[string]string)
+	for _, path := range filePaths {
+		migrations, err := readMigrations(path)
+		if err != nil {
+			continue
+		}
+		for _, m := range migrations {
+			for _, ct := range m.Up.CreateTable {
+				objectSet[strings.ToLower(ct.Name)] = "table"
+			}
+			for _, cv := range m.Up.CreateView {
+				objectSet[strings.ToLower(cv.Name)] = "view"
+			}
+			for _, cf := range m.Up.CreateFunction {
+				objectSet[strings.ToLower(cf.Name)] = "function"
+			}
+			for _, cp := range m.Up.CreateProcedure {
+				objectSet[strings.ToLower(cp.Name)] = "procedure"
+			}
+			for _, ct := range m.Up.CreateTrigger {
+				objectSet[strings.ToLower(ct.Name)] = "trigger"
+			}
+		}
+	}
+	return objectSet
+}
+
 func extractTimeFromFilename(fname string) time.Time {
 	parts := strings.Split(fname, "_")
 	if len(parts) > 0 {
@@ -314,6 +362,8 @@ type ObjectReport struct {
 	FinalProcedure *CreateProcedure
 	FinalTrigger   *CreateTrigger
 	Dropped        bool
+	// Columns is the timeline of each column of a table.
+	Columns []ColumnHistory
 }
 
 type HistoryReportTemplateData struct {
@@ -330,6 +380,57 @@ func generateHTMLReportAllObjectsTemplate(
 	migrationDir string,
 	readMigrations func(string) ([]Migration, error),
 ) (string, error) {
+	reports := objectHistoryReports(allObjects, filePaths, readMigrations)
+
+	// Calculate TotalMigrations and LastUpdated for template
+	totalMigrations := len(filePaths)
+	var lastUpdated string
+	if totalMigrations > 0 {
+		lastUpdated = extractTimeFromFilename(filepath.Base(filePaths[len(filePaths)-1])).Format("2006-01-02 15:04:05")
+	} else {
+		lastUpdated = "N/A"
+	}
+
+	// Load and execute template
+	tmplPath := filepath.Join("examples", "templates", "history.html")
+
+	// Check if template file exists
+	if _, err := os.Stat(tmplPath); os.IsNotExist(err) {
+		return generateFallbackHTMLReport(allObjects, reports)
+	}
+
+	tmpl, err := template.New("history.html").
+		Funcs(template.FuncMap{
+			"safeHTML": func(s string) template.HTML { return template.HTML(s) },
+			"join":     strings.Join,
+			"sub":      func(a, b int) int { return a - b }, // For "What's New" section
+		}).
+		ParseFiles(tmplPath)
+	if err != nil {
+		return generateFallbackHTMLReport(allObjects, reports)
+	}
+
+	data := HistoryReportTemplateData{
+		AllObjects:      allObjects,
+		Reports:         reports,
+		TotalMigrations: totalMigrations,
+		LastUpdated:     lastUpdated,
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "history.html", data); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// objectHistoryReports replays the migrations of filePaths, in order, for
+// each object of allObjects and returns their history keyed by object name.
+func objectHistoryReports(
+	allObjects []objectInfo,
+	filePaths []string,
+	readMigrations func(string) ([]Migration, error),
+) map[string]ObjectReport {
 	reports := make(map[string]ObjectReport)
 	for _, obj := range allObjects {
 		var changes []MigrationChange
@@ -342,6 +443,8 @@ func generateHTMLReportAllObjectsTemplate(
 
 		// For structure snapshots after each migration
 		var migrationGroups []MigrationGroup
+		ownership := make(map[string]string)
+		descriptions := make(map[string]string)
 
 		for _, path := range filePaths {
 			migrations, err := readMigrations(path)
@@ -350,6 +453,8 @@ func generateHTMLReportAllObjectsTemplate(
 			}
 			createdAt := extractTimeFromFilename(filepath.Base(path))
 			for _, m := range migrations {
+				ownership[m.Name] = m.Ownership()
+				descriptions[m.Name] = m.Description
 
 				// TABLES
 				for _, ct := range m.Up.CreateTable {
@@ -413,6 +518,24 @@ func generateHTMLReportAllObjectsTemplate(
 							}
 							finalTable.AddFields = sortFieldsPriority(finalTable.AddFields)
 						}
+						for _, pk := range at.DropPrimaryKey {
+							changes = append(changes, MigrationChange{
+								MigrationName: m.Name,
+								Date:          createdAt,
+								Operation:     "DropPrimaryKey",
+								Details:       pk.Name,
+							})
+							finalTable.changePrimaryKey(AlterTable{Name: at.Name, DropPrimaryKey: []DropPrimaryKey{pk}})
+						}
+						for _, pk := range at.AddPrimaryKey {
+							changes = append(changes, MigrationChange{
+								MigrationName: m.Name,
+								Date:          createdAt,
+								Operation:     "AddPrimaryKey",
+								Details:       strings.Join(pk.Fields, ", "),
+							})
+							finalTable.changePrimaryKey(AlterTable{Name: at.Name, AddPrimaryKey: []AddPrimaryKey{pk}})
+						}
 					}
 				}
 				for _, dt := range m.Up.DropTable {
@@ -601,6 +724,8 @@ func generateHTMLReportAllObjectsTemplate(
 				migrationMap[key] = &MigrationGroup{
 					Date:          ch.Date,
 					MigrationName: ch.MigrationName,
+					Ownership:     ownership[ch.MigrationName],
+					Description:   descriptions[ch.MigrationName],
 				}
 				migrationOrder = append(migrationOrder, key)
 			}
@@ -746,48 +871,13 @@ func generateHTMLReportAllObjectsTemplate(
 			FinalTrigger:   finalTrigger,
 			Dropped:        dropped,
 		}
+		if obj.Type == "table" {
+			report := reports[obj.Name]
+			report.Columns = columnHistories(changes)
+			reports[obj.Name] = report
+		}
 	}
-
-	// Calculate TotalMigrations and LastUpdated for template
-	totalMigrations := len(filePaths)
-	var lastUpdated string
-	if totalMigrations > 0 {
-		lastUpdated = extractTimeFromFilename(filepath.Base(filePaths[len(filePaths)-1])).Format("2006-01-02 15:04:05")
-	} else {
-		lastUpdated = "N/A"
-	}
-
-	// Load and execute template
-	tmplPath := filepath.Join("examples", "templates", "history.html")
-
-	// Check if template file exists
-	if _, err := os.Stat(tmplPath); os.IsNotExist(err) {
-		return generateFallbackHTMLReport(allObjects, reports)
-	}
-
-	tmpl, err := template.New("history.html").
-		Funcs(template.FuncMap{
-			"safeHTML": func(s string) template.HTML { return template.HTML(s) },
-			"join":     strings.Join,
-			"sub":      func(a, b int) int { return a - b }, // For "What's New" section
-		}).
-		ParseFiles(tmplPath)
-	if err != nil {
-		return generateFallbackHTMLReport(allObjects, reports)
-	}
-
-	data := HistoryReportTemplateData{
-		AllObjects:      allObjects,
-		Reports:         reports,
-		TotalMigrations: totalMigrations,
-		LastUpdated:     lastUpdated,
-	}
-
-	var buf bytes.Buffer
-	if err := tmpl.ExecuteTemplate(&buf, "history.html", data); err != nil {
-		return "", fmt.Errorf("failed to execute template: %w", err)
-	}
-	return buf.String(), nil
+	return reports
 }
 
 // generateFallbackHTMLReport creates a basic HTML report when template file is not available
@@ -874,8 +964,9 @@ func generateFallbackHTMLReport(allObjects []objectInfo, reports map[string]Obje
 			<nav class="mt-2">
 				<h2 class="px-4 text-xs font-semibold uppercase tracking-wider text-blue-200 mb-1">Objects</h2>
 				<div class="px-4 mb-2">
-					<input id="object-search" type="text" placeholder="Search objects..." class="w-full px-2 py-1 rounded bg-blue-800 text-gray-100 border border-blue-600 focus:outline-none focus:ring-2 focus:ring-blue-400 text-sm" />
+					<input id="object-search" type="text" placeholder="Search objects and migrations..." class="w-full px-2 py-1 rounded bg-blue-800 text-gray-100 border border-blue-600 focus:outline-none focus:ring-2 focus:ring-blue-400 text-sm" />
 				</div>
+				<ul id="search-results" class="px-4 mb-2 text-xs text-gray-100"></ul>
 				<ul id="object-list" class="mt-1">
 `)
 	for _, obj := range allObjects {
@@ -895,11 +986,13 @@ func generateFallbackHTMLReport(allObjects []objectInfo, reports map[string]Obje
 						<div class="flex space-x-2" id="tab-buttons">
 							<button id="tab-structure-btn" class="px-4 py-2 text-sm font-medium text-blue-700 bg-blue-50 rounded-t border border-b-0 border-gray-200 focus:outline-none transition-colors duration-150 hover:bg-blue-100" data-tab="structure" type="button">Final Structure</button>
 							<button id="tab-history-btn" class="px-4 py-2 text-sm font-medium text-gray-700 bg-white rounded-t border border-b-0 border-gray-200 focus:outline-none transition-colors duration-150 hover:bg-blue-100" data-tab="history" type="button">History</button>
+							<button id="tab-columns-btn" class="px-4 py-2 text-sm font-medium text-gray-700 bg-white rounded-t border border-b-0 border-gray-200 focus:outline-none transition-colors duration-150 hover:bg-blue-100" data-tab="columns" type="button">Columns</button>
 						</div>
 					</div>
 					<div>
 						<div id="structure-panel" class="tab-panel"></div>
 						<div id="history-panel" class="tab-panel hidden"></div>
+						<div id="columns-panel" class="tab-panel hidden"></div>
 					</div>
 				</section>
 			</div>
@@ -908,8 +1001,9 @@ func generateFallbackHTMLReport(allObjects []objectInfo, reports map[string]Obje
 				<h2 class="text-md font-bold mb-1 flex items-center">Tips & Usage</h2>
 				<ul class="list-disc ml-6 text-sm text-gray-700">
 					<li>Click objects in the sidebar to view their structure and history.</li>
-					<li>Use the search box to quickly find objects.</li>
+					<li>Use the search box to quickly find objects, or the migrations whose text contains a column, index or SQL snippet.</li>
 					<li>Switch between <b>Final Structure</b> and <b>History</b> tabs for details.</li>
+					<li>The <b>Columns</b> tab shows every change of each column of a table as a timeline.</li>
 					<li>Copy migration details or SQL definitions using the copy button.</li>
 					<li>Toggle dark/light mode for your comfort.</li>
 					<li>Use the feedback button to share your thoughts!</li>
@@ -921,12 +1015,42 @@ func generateFallbackHTMLReport(allObjects []objectInfo, reports map[string]Obje
 	<button id="back-to-top" onclick="window.scrollTo({top:0,behavior:'smooth'})">↑ Top</button>
 	<!-- End main content -->
 	<script>
-		// Sidebar filter
+		// Search across migration contents: lists the first matching line of
+		// each migration and returns the names of the matching migrations.
+		function searchMigrations(val) {
+			const matched = {};
+			const results = document.getElementById('search-results');
+			results.innerHTML = '';
+			if (val === '' || typeof migrationSources === 'undefined') return matched;
+			migrationSources.forEach(src => {
+				const lines = src.text.split('\n');
+				const i = lines.findIndex(l => l.toLowerCase().includes(val));
+				if (i < 0) return;
+				matched[src.name] = true;
+				const li = document.createElement('li');
+				li.className = 'py-1 border-b border-blue-600';
+				const name = document.createElement('div');
+				name.className = 'font-semibold';
+				name.textContent = src.name;
+				const where = document.createElement('div');
+				where.className = 'text-blue-200';
+				where.textContent = src.file + ':' + (src.line + i);
+				const code = document.createElement('code');
+				code.textContent = lines[i].trim();
+				li.append(name, where, code);
+				results.appendChild(li);
+			});
+			return matched;
+		}
+		// Sidebar filter: objects whose name matches or that a matching
+		// migration changes.
 		document.getElementById('object-search').addEventListener('input', function () {
 			const val = this.value.toLowerCase();
+			const matched = searchMigrations(val);
 			document.querySelectorAll('#object-list li').forEach(li => {
-				const name = li.getAttribute('data-obj').toLowerCase();
-				li.style.display = name.includes(val) ? '' : 'none';
+				const obj = li.getAttribute('data-obj');
+				const inBody = (reports[obj].migrations || []).some(m => matched[m]);
+				li.style.display = obj.toLowerCase().includes(val) || inBody ? '' : 'none';
 			});
 		});
 		// Tabs
@@ -939,11 +1063,17 @@ func generateFallbackHTMLReport(allObjects []objectInfo, reports map[string]Obje
 			document.getElementById('tab-history-btn').classList.toggle('text-blue-700', tab === 'history');
 			document.getElementById('tab-history-btn').classList.toggle('bg-white', tab !== 'history');
 			document.getElementById('tab-history-btn').classList.toggle('text-gray-700', tab !== 'history');
+			document.getElementById('tab-columns-btn').classList.toggle('bg-blue-50', tab === 'columns');
+			document.getElementById('tab-columns-btn').classList.toggle('text-blue-700', tab === 'columns');
+			document.getElementById('tab-columns-btn').classList.toggle('bg-white', tab !== 'columns');
+			document.getElementById('tab-columns-btn').classList.toggle('text-gray-700', tab !== 'columns');
 			document.getElementById('structure-panel').classList.toggle('hidden', tab !== 'structure');
 			document.getElementById('history-panel').classList.toggle('hidden', tab !== 'history');
+			document.getElementById('columns-panel').classList.toggle('hidden', tab !== 'columns');
 		}
 		document.getElementById('tab-structure-btn').addEventListener('click', function () { showTab('structure'); });
 		document.getElementById('tab-history-btn').addEventListener('click', function () { showTab('history'); });
+		document.getElementById('tab-columns-btn').addEventListener('click', function () { showTab('columns'); });
 		// Object selection
 		var reports = {};
 `)
@@ -982,7 +1112,11 @@ func generateFallbackHTMLReport(allObjects []objectInfo, reports map[string]Obje
 		}
 		// History panel: show actions and structure after each migration
 		for _, group := range report.History {
-			history += `<div class="border border-gray-200 rounded shadow-sm mb-2"><div class="px-3 py-2 bg-gray-100"><span class="text-base font-semibold text-gray-800">` + group.MigrationName + `</span> <span class="text-xs text-gray-500 ml-2">` + group.Date.Format("2006-01-02 15:04:05") + `</span></div><div class="px-3 py-2 bg-white text-sm">`
+			history += `<div class="border border-gray-200 rounded shadow-sm mb-2"><div class="px-3 py-2 bg-gray-100"><span class="text-base font-semibold text-gray-800">` + group.MigrationName + `</span> <span class="text-xs text-gray-500 ml-2">` + group.Date.Format("2006-01-02 15:04:05") + `</span>`
+			if group.Ownership != "" {
+				history += ` <span class="text-xs text-gray-500 ml-2">` + template.HTMLEscapeString(group.Ownership) + `</span>`
+			}
+			history += `</div><div class="px-3 py-2 bg-white text-sm">`
 			for _, action := range group.Actions {
 				history += `<div class="mb-2"><span class="font-medium text-blue-600">` + action.Operation + `</span> `
 				switch action.Operation {
@@ -1011,7 +1145,12 @@ func generateFallbackHTMLReport(allObjects []objectInfo, reports map[string]Obje
 			history += `<div class="mt-2 border-t pt-2"><b>Structure after migration:</b><br>` + group.StructureAfter + `</div>`
 			history += `</div></div>`
 		}
-		html.WriteString(`reports["` + obj.Name + `"] = {structure: ` + "`" + structure + "`" + `, history: ` + "`" + history + "`" + `};`)
+		columns := generateColumnTimelineHTML(report.Columns)
+		migrations := make([]string, len(report.History))
+		for i, group := range report.History {
+			migrations[i] = `"` + template.JSEscapeString(group.MigrationName) + `"`
+		}
+		html.WriteString(`reports["` + obj.Name + `"] = {structure: ` + "`" + structure + "`" + `, history: ` + "`" + history + "`" + `, columns: ` + "`" + columns + "`" + `, migrations: [` + strings.Join(migrations, ", ") + `]};`)
 	}
 	html.WriteString(`
 		document.querySelectorAll('#object-list li').forEach(li => {
@@ -1020,6 +1159,7 @@ func generateFallbackHTMLReport(allObjects []objectInfo, reports map[string]Obje
 				li.classList.add('bg-blue-600', 'text-white');
 				document.getElementById('structure-panel').innerHTML = reports[li.getAttribute('data-obj')].structure;
 				document.getElementById('history-panel').innerHTML = reports[li.getAttribute('data-obj')].history;
+				document.getElementById('columns-panel').innerHTML = reports[li.getAttribute('data-obj')].columns;
 			});
 		});
 		// Auto-select first object and default to "Final Structure" tab
@@ -1046,6 +1186,41 @@ func generateFallbackHTMLReport(allObjects []objectInfo, reports map[string]Obje
 	return html.String(), nil
 }
 
+// migrationSearchScript returns the script declaring the source text of
+// each migration, which the search box of the report searches.
+func migrationSearchScript(sources []migrationSource) string {
+	var b strings.Builder
+	b.WriteString("<script>\nvar migrationSources = [\n")
+	for _, src := range sources {
+		fmt.Fprintf(&b, "{name: \"%s\", file: \"%s\", line: %d, text: \"%s\"},\n",
+			template.JSEscapeString(src.Name), template.JSEscapeString(src.File), src.Line, template.JSEscapeString(src.Text))
+	}
+	b.WriteString("];\n</script>\n")
+	return b.String()
+}
+
+// generateColumnTimelineHTML renders the timeline of each column of a table.
+func generateColumnTimelineHTML(columns []ColumnHistory) string {
+	if len(columns) == 0 {
+		return `<b>No column history.</b>`
+	}
+	// The HTML is embedded in a JavaScript template literal.
+	escape := strings.NewReplacer("`", "&#96;", "$", "&#36;").Replace
+	var b strings.Builder
+	for _, col := range columns {
+		b.WriteString(`<div class="mb-4"><div class="text-base font-semibold text-gray-800">` + escape(template.HTMLEscapeString(col.Name)))
+		if col.Dropped {
+			b.WriteString(` <span class="bg-red-600 text-white px-2 py-0.5 rounded text-xs">Dropped</span>`)
+		}
+		b.WriteString(`</div><ol class="border-l-2 border-blue-200 ml-2 mt-1">`)
+		for _, change := range col.Changes {
+			b.WriteString(`<li class="ml-4 mb-1 text-sm"><span class="text-xs text-gray-500">` + change.Date.Format("2006-01-02 15:04:05") + `</span> <span class="text-xs text-gray-500 ml-1">` + escape(template.HTMLEscapeString(change.MigrationName)) + `</span> <span class="font-medium text-blue-600 ml-1">` + change.Kind + `</span> ` + escape(template.HTMLEscapeString(change.Describe())) + `</li>`)
+		}
+		b.WriteString(`</ol></div>`)
+	}
+	return b.String()
+}
+
 // Helper for fallback stats
 func countTotalMigrations(reports map[string]ObjectReport) int {
 	m := make(map[string]struct{})
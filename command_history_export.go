@@ -0,0 +1,59 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/oarkflow/cli/contracts"
+)
+
+// HistoryExportCommand writes the migration history to a JSON file that
+// history:import can load into another history driver.
+type HistoryExportCommand struct {
+	Driver IManager
+}
+
+func (c *HistoryExportCommand) Signature() string {
+	return "history:export"
+}
+
+func (c *HistoryExportCommand) Description() string {
+	return "Export the migration history as JSON to move it to another history driver or database."
+}
+
+func (c *HistoryExportCommand) Extend() contracts.Extend {
+	return contracts.Extend{
+		Flags: []contracts.Flag{
+			{
+				Name:  "out",
+				Usage: "File to write the history to (stdout when empty)",
+				Value: "",
+			},
+		},
+	}
+}
+
+func (c *HistoryExportCommand) Handle(ctx contracts.Context) error {
+	mgr, ok := c.Driver.(*Manager)
+	if !ok {
+		return fmt.Errorf("history:export requires *Manager driver")
+	}
+	out := ctx.Option("out")
+	if out == "" {
+		_, err := mgr.ExportHistory(os.Stdout)
+		return err
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", out, err)
+	}
+	n, err := mgr.ExportHistory(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Exported %d history entry(ies) to %s.\n", n, out)
+	return nil
+}
@@ -0,0 +1,68 @@
+package migrate
+
+import (
+	"fmt"
+
+	"github.com/oarkflow/cli/contracts"
+)
+
+// HistoryImportCommand loads a history written by history:export, or a
+// file history, into the configured history driver.
+type HistoryImportCommand struct {
+	Driver IManager
+}
+
+func (c *HistoryImportCommand) Signature() string {
+	return "history:import"
+}
+
+func (c *HistoryImportCommand) Description() string {
+	return "Import a migration history exported by history:export, or a migration_history.txt file, into the configured history."
+}
+
+func (c *HistoryImportCommand) Extend() contracts.Extend {
+	return contracts.Extend{
+		Flags: []contracts.Flag{
+			{
+				Name:  "in",
+				Usage: "History file to import",
+				Value: "history.json",
+			},
+			{
+				Name:  "replace",
+				Usage: "Replace the history with the imported entries instead of adding the missing ones",
+				Value: "false",
+			},
+		},
+	}
+}
+
+func (c *HistoryImportCommand) Handle(ctx contracts.Context) error {
+	mgr, ok := c.Driver.(*Manager)
+	if !ok {
+		return fmt.Errorf("history:import requires *Manager driver")
+	}
+	in := ctx.Option("in")
+	if in == "" {
+		in = "history.json"
+	}
+	histories, err := ReadHistoryExport(in)
+	if err != nil {
+		return err
+	}
+	lock, err := acquireCommandLock(c.Driver)
+	if err != nil {
+		return fmt.Errorf("cannot import history: %w", err)
+	}
+	defer func() {
+		if err := lock.Release(); err != nil {
+			logger.Printf("Warning releasing lock: %v", err)
+		}
+	}()
+	n, err := mgr.ImportHistory(histories, optionEnabled(ctx, "replace"))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d history entry(ies) from %s.\n", n, in)
+	return nil
+}
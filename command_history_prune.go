@@ -0,0 +1,91 @@
+package migrate
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/oarkflow/cli/contracts"
+)
+
+// HistoryPruneCommand moves old entries of the database history to its
+// archive table so the history table does not grow unbounded.
+type HistoryPruneCommand struct {
+	Driver IManager
+}
+
+func (c *HistoryPruneCommand) Signature() string {
+	return "history:prune"
+}
+
+func (c *HistoryPruneCommand) Description() string {
+	return "Archive old migration history entries to the history archive table, keeping the latest ones."
+}
+
+func (c *HistoryPruneCommand) Extend() contracts.Extend {
+	return contracts.Extend{
+		Flags: []contracts.Flag{
+			{
+				Name:  "keep",
+				Usage: "Number of latest history entries to keep",
+				Value: "",
+			},
+			{
+				Name:  "older-than",
+				Usage: "Only archive entries applied longer ago than this age (e.g. 720h, 90d)",
+				Value: "",
+			},
+			{
+				Name:  "out",
+				Usage: "Also append the archived entries to this JSON file",
+				Value: "",
+			},
+		},
+	}
+}
+
+func (c *HistoryPruneCommand) Handle(ctx contracts.Context) error {
+	mgr, ok := c.Driver.(*Manager)
+	if !ok {
+		return fmt.Errorf("history:prune requires *Manager driver")
+	}
+	keep := -1
+	if v := ctx.Option("keep"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid keep value: %s", v)
+		}
+		keep = n
+	}
+	var olderThan time.Duration
+	if v := ctx.Option("older-than"); v != "" {
+		age, err := parseHistoryAge(v)
+		if err != nil {
+			return err
+		}
+		olderThan = age
+	}
+	if keep < 0 && olderThan == 0 {
+		return fmt.Errorf("history:prune requires --keep or --older-than")
+	}
+	lock, err := acquireCommandLock(c.Driver)
+	if err != nil {
+		return fmt.Errorf("cannot prune history: %w", err)
+	}
+	defer func() {
+		if err := lock.Release(); err != nil {
+			logger.Printf("Warning releasing lock: %v", err)
+		}
+	}()
+	pruned, err := mgr.PruneHistory(keep, olderThan)
+	if err != nil {
+		return err
+	}
+	if out := ctx.Option("out"); out != "" && len(pruned) > 0 {
+		if err := appendHistoryArchive(out, pruned); err != nil {
+			return fmt.Errorf("history entries were archived but writing %s failed: %w", out, err)
+		}
+	}
+	fmt.Printf("Archived %d history entry(ies).\n", len(pruned))
+	return nil
+}
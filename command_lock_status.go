@@ -0,0 +1,72 @@
+package migrate
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/oarkflow/cli/contracts"
+	"github.com/oarkflow/json"
+)
+
+// LockStatusCommand shows who holds the migration lock and whether the lease is live.
+type LockStatusCommand struct {
+	Driver IManager
+}
+
+func (c *LockStatusCommand) Signature() string {
+	return "lock:status"
+}
+
+func (c *LockStatusCommand) Description() string {
+	return "Show the migration lock holder and whether its lease is still being renewed."
+}
+
+func (c *LockStatusCommand) Extend() contracts.Extend {
+	return contracts.Extend{
+		Flags: []contracts.Flag{
+			{
+				Name:  "format",
+				Usage: "Output format (text|json)",
+				Value: "text",
+			},
+		},
+	}
+}
+
+func (c *LockStatusCommand) Handle(ctx contracts.Context) error {
+	info, err := ReadLockStatus()
+	if err != nil {
+		return err
+	}
+	state := "free"
+	if info != nil {
+		state = "active"
+		if info.Stale(time.Now()) {
+			state = "stale"
+		}
+	}
+	if ctx.Option("format") == "json" {
+		data, err := json.Marshal(map[string]any{"state": state, "lock": info})
+		if err != nil {
+			return fmt.Errorf("failed to marshal lock status: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	if info == nil {
+		fmt.Println("Migration lock is free.")
+		return nil
+	}
+	fmt.Printf("State:       %s\n", state)
+	fmt.Printf("Holder:      %s (pid %d)\n", info.Holder, info.PID)
+	if info.Command != "" {
+		fmt.Printf("Command:     %s\n", info.Command)
+	}
+	fmt.Printf("Acquired:    %s\n", info.AcquiredAt.Format(time.DateTime))
+	fmt.Printf("Renewed:     %s\n", info.RenewedAt.Format(time.DateTime))
+	fmt.Printf("Expires:     %s\n", info.ExpiresAt().Format(time.DateTime))
+	if state == "stale" {
+		fmt.Printf("\nThe holder stopped renewing its lease; the next migrate run will take over the lock.\n")
+	}
+	return nil
+}
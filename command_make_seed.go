@@ -2,6 +2,9 @@ package migrate
 
 import (
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/oarkflow/cli/contracts"
 )
@@ -33,11 +36,47 @@ func (c *MakeSeedCommand) Extend() contracts.Extend {
 				Usage:   "Create raw SQL seed file",
 				Value:   "false",
 			},
+			{
+				Name:  "from-db",
+				Usage: "Create the seed file from rows sampled from this table",
+				Value: "",
+			},
+			{
+				Name:  "limit",
+				Usage: "Rows sampled with --from-db",
+				Value: "100",
+			},
+			{
+				Name:  "mask",
+				Usage: "Columns masked with --from-db (comma-separated), besides those matching logging.redact",
+				Value: "",
+			},
 		},
 	}
 }
 
 func (c *MakeSeedCommand) Handle(ctx contracts.Context) error {
+	if table := ctx.Option("from-db"); table != "" {
+		mgr, ok := c.Driver.(*Manager)
+		if !ok {
+			return errors.New("make:seed --from-db requires *Manager driver")
+		}
+		sample := SeedSample{Table: table}
+		if value := ctx.Option("limit"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid --limit value: %s (expected a positive integer)", value)
+			}
+			sample.Limit = n
+		}
+		for _, column := range strings.Split(ctx.Option("mask"), ",") {
+			if column = strings.TrimSpace(column); column != "" {
+				sample.Mask = append(sample.Mask, column)
+			}
+		}
+		_, err := mgr.CreateSeedFromTable(sample)
+		return err
+	}
 	name := ctx.Argument(0)
 	if name == "" {
 		return errors.New("seed name is required")
@@ -1,6 +1,7 @@
 package migrate
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -57,16 +58,64 @@ func (c *MigrateCommand) Extend() contracts.Extend {
 				Usage:   "Include raw .sql migrations and raw .sql seed files",
 				Value:   "false",
 			},
+			{
+				Name:  "check",
+				Usage: "Report pending migrations without applying them; fails when any are pending",
+				Value: "false",
+			},
+			{
+				Name:  "include-scheduled",
+				Usage: "Apply migrations held back by RunAfter or RequiresWindow",
+				Value: "false",
+			},
+			{
+				Name:  "tags",
+				Usage: "Only apply migrations carrying one of these comma-separated tags",
+				Value: "",
+			},
+			{
+				Name:  "phase",
+				Usage: "Only apply migrations of this phase: pre-deploy or post-deploy (default: both)",
+				Value: "",
+			},
+			{
+				Name:  "cascade-dependencies",
+				Usage: "Drop views that depend on a table before dropping it",
+				Value: "false",
+			},
+			{
+				Name:  "keep-going",
+				Usage: "Attempt every pending migration even after failures, then summarize the results",
+				Value: "false",
+			},
+			{
+				Name:  "wait-for-db",
+				Usage: "Retry connecting until the database is available before migrating",
+				Value: "false",
+			},
+			{
+				Name:  "timeout",
+				Usage: "Maximum time to wait for the database (e.g. 120s, 2m)",
+				Value: "60s",
+			},
+			{
+				Name:  "format",
+				Usage: "Output format for the summary and errors (text|json)",
+				Value: "text",
+			},
+			jobsFlagDefinition(),
 		},
 	}
 }
 
-func (c *MigrateCommand) Handle(ctx contracts.Context) error {
+func (c *MigrateCommand) Handle(ctx contracts.Context) (err error) {
+	summary := newMigrateSummary()
 	// Set verbose flag on Manager if -v is passed
 	verbose := ctx.Option("v") != "" && ctx.Option("v") != "false"
 	forceFlag := ctx.Option("f") != "" && ctx.Option("f") != "false"
 	if mgr, ok := c.Driver.(*Manager); ok {
 		mgr.Verbose = verbose
+		mgr.CascadeDependencies = optionEnabled(ctx, "cascade-dependencies")
 		if forceFlag {
 			mgr.Force = true
 			if mgr.dbDriver != nil {
@@ -74,19 +123,52 @@ func (c *MigrateCommand) Handle(ctx contracts.Context) error {
 			}
 		}
 	}
+	if err := applyJobsOption(ctx, c.Driver); err != nil {
+		return err
+	}
+	if optionEnabled(ctx, "wait-for-db") {
+		timeout, err := ParseWaitTimeout(ctx.Option("timeout"))
+		if err != nil {
+			return err
+		}
+		mgr, ok := c.Driver.(*Manager)
+		if !ok {
+			return fmt.Errorf("--wait-for-db requires *Manager driver")
+		}
+		if err := mgr.waitForDatabase(timeout); err != nil {
+			return err
+		}
+	}
+	tags := parseTags(ctx.Option("tags"))
+	phase, err := parsePhase(ctx.Option("phase"))
+	if err != nil {
+		return err
+	}
+	if check := ctx.Option("check"); check == "true" || check == "1" {
+		return c.check(tags, phase)
+	}
 	if err := c.Driver.ValidateHistoryStorage(); err != nil {
 		logger.Error().Err(err).Msg("History storage validation failed")
 		return fmt.Errorf("history storage validation failed: %w", err)
 	}
-	if err := acquireLock(); err != nil {
+	lock, err := acquireCommandLock(c.Driver)
+	if err != nil {
 		logger.Error().Err(err).Msg("Cannot start migration (failed to acquire lock)")
 		return fmt.Errorf("cannot start migration: %w", err)
 	}
 	defer func() {
-		if err := releaseLock(); err != nil {
-			logger.Printf("Warning releasing lock: %v", err)
+		if releaseErr := lock.Release(); releaseErr != nil {
+			if errors.Is(releaseErr, errLockLost) {
+				err = errors.Join(err, releaseErr)
+				return
+			}
+			logger.Printf("Warning releasing lock: %v", releaseErr)
 		}
 	}()
+	mgr, collecting := c.Driver.(*Manager)
+	if collecting {
+		defer mgr.collectSummary(summary)()
+	}
 	if err := c.Driver.ValidateMigrations(); err != nil {
 		logger.Printf("Validation warning: %v", err)
 	}
@@ -147,7 +229,8 @@ func (c *MigrateCommand) Handle(ctx contracts.Context) error {
 			if err != nil {
 				return nil, err
 			}
-			return ParseMigrationsBCL(data)
+			migrations, err := ParseMigrationsBCL(data)
+			return migrations, withParseErrorFile(err, path)
 		}
 	}
 
@@ -161,6 +244,10 @@ func (c *MigrateCommand) Handle(ctx contracts.Context) error {
 	includeRawOption := ctx.Option("include-raw")
 	includeRaw := includeRawOption == "true" || includeRawOption == "1"
 	shouldSeed := seedFlag == "true" || seedFlag == "1"
+	keepGoing := optionEnabled(ctx, "keep-going")
+	includeScheduled := optionEnabled(ctx, "include-scheduled")
+	applied := c.appliedMigrationNames()
+	var results migrateResults
 
 	// Ensure migrations are applied in deterministic order by filename (timestamp prefix)
 	sort.SliceStable(migrationFiles, func(i, j int) bool {
@@ -168,11 +255,22 @@ func (c *MigrateCommand) Handle(ctx contracts.Context) error {
 	})
 
 	for _, path := range migrationFiles {
+		if err := lock.Err(); err != nil {
+			return fmt.Errorf("migration stopped: %w", err)
+		}
 		base := filepath.Base(path)
 		ext := strings.ToLower(filepath.Ext(base))
 		name := strings.TrimSuffix(base, ext)
 		// Handle raw .sql migrations
 		if ext == ".sql" {
+			if len(tags) > 0 {
+				logger.Info().Msgf("Skipping raw SQL migration %s: raw migrations have no tags", path)
+				continue
+			}
+			if phase == PhasePostDeploy {
+				logger.Info().Msgf("Skipping raw SQL migration %s: raw migrations run pre-deploy", path)
+				continue
+			}
 			if !includeRaw {
 				logger.Info().Msgf("Skipping raw SQL migration (enable with --include-raw=true): %s", path)
 				continue
@@ -182,8 +280,14 @@ func (c *MigrateCommand) Handle(ctx contracts.Context) error {
 				if forceFlag {
 					continue
 				}
-				return fmt.Errorf("failed to apply raw SQL migration %s: %w", name, err)
+				err = fmt.Errorf("failed to apply raw SQL migration %s: %w", name, err)
+				if keepGoing {
+					results.fail(name, err)
+					continue
+				}
+				return err
 			}
+			results.succeeded++
 			continue
 		}
 
@@ -191,15 +295,70 @@ func (c *MigrateCommand) Handle(ctx contracts.Context) error {
 		migrations, err := readMigrations(path)
 		if err != nil {
 			logger.Error().Err(err).Msgf("Failed to parse migration file %s", name)
-			return fmt.Errorf("failed to parse migration file %s: %w", name, err)
+			err = fmt.Errorf("failed to parse migration file %s: %w", name, err)
+			if keepGoing {
+				results.fail(name, err)
+				continue
+			}
+			return err
 		}
 		if len(migrations) == 0 {
-			return fmt.Errorf("migration file %s contains no Migration blocks", name)
+			err := fmt.Errorf("migration file %s contains no Migration blocks", name)
+			if keepGoing {
+				results.fail(name, err)
+				continue
+			}
+			return err
 		}
 		for _, migration := range migrations {
+			if !migration.MatchesTags(tags) {
+				logger.Info().Msgf("Skipping migration %s: not tagged %s", migration.Name, strings.Join(tags, " or "))
+				continue
+			}
+			migrationPhase, err := migration.DeployPhase()
+			if err != nil {
+				if keepGoing {
+					results.fail(migration.Name, err)
+					continue
+				}
+				return err
+			}
+			if phase != "" && migrationPhase != phase {
+				logger.Info().Msgf("Skipping %s migration %s (apply with --phase=%s)", migrationPhase, migration.Name, migrationPhase)
+				continue
+			}
+			// Holds only delay pending migrations; applied ones go on to the
+			// usual history and checksum checks.
+			if !includeScheduled && !applied[migration.Name] {
+				hold, err := migration.ScheduleHold(time.Now())
+				if err != nil {
+					if keepGoing {
+						results.fail(migration.Name, err)
+						continue
+					}
+					return err
+				}
+				if hold != "" {
+					logger.Info().Msgf("Skipping migration %s: %s (apply now with --include-scheduled=true)", migration.Name, hold)
+					continue
+				}
+			}
+			if err := lock.Err(); err != nil {
+				return fmt.Errorf("migration stopped: %w", err)
+			}
 			if err := c.applyParsedMigration(migration, name, shouldSeed, seedRows, forceFlag); err != nil {
+				if keepGoing {
+					results.fail(migration.Name, err)
+					continue
+				}
 				return err
 			}
+			results.succeeded++
+		}
+	}
+	if keepGoing {
+		if err := results.summarize(); err != nil {
+			return err
 		}
 	}
 	if shouldSeed {
@@ -208,9 +367,108 @@ func (c *MigrateCommand) Handle(ctx contracts.Context) error {
 			return err
 		}
 	}
+	if collecting {
+		return summary.write(os.Stdout, ctx.Option("format"))
+	}
 	return nil
 }
 
+// appliedMigrationNames returns the migrations recorded in the history, or
+// nil when it cannot be read here; ApplyMigration consults it again anyway.
+func (c *MigrateCommand) appliedMigrationNames() map[string]bool {
+	mgr, ok := c.Driver.(*Manager)
+	if !ok || mgr.historyDriver == nil {
+		return nil
+	}
+	histories, err := mgr.historyDriver.Load()
+	if err != nil {
+		return nil
+	}
+	applied := make(map[string]bool, len(histories))
+	for _, h := range histories {
+		applied[h.Name] = true
+	}
+	return applied
+}
+
+// migrateResults collects the outcome of migrate --keep-going.
+type migrateResults struct {
+	succeeded int
+	failed    []string
+	errs      []error
+}
+
+func (r *migrateResults) fail(name string, err error) {
+	r.failed = append(r.failed, name)
+	r.errs = append(r.errs, err)
+}
+
+// summarize logs the outcome and returns the joined failures, if any, so
+// the exit code still reflects errors such as checksum mismatches.
+func (r *migrateResults) summarize() error {
+	logger.Info().Msgf("Migration summary: %d succeeded or already applied, %d failed", r.succeeded, len(r.failed))
+	for i, name := range r.failed {
+		logger.Error().Err(r.errs[i]).Msgf("Failed migration: %s", name)
+	}
+	if len(r.failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d migration(s) failed (%s): %w", len(r.failed), strings.Join(r.failed, ", "), errors.Join(r.errs...))
+}
+
+// UpCommand is an alias of MigrateCommand suited to one-shot deployment jobs:
+// `up --wait-for-db --timeout=120s` waits for the database, applies pending
+// migrations and exits with the standard exit codes.
+type UpCommand struct {
+	MigrateCommand
+}
+
+func (c *UpCommand) Signature() string {
+	return "up"
+}
+
+func (c *UpCommand) Description() string {
+	return "Apply pending migrations (alias of migrate); use --wait-for-db in Jobs and initContainers."
+}
+
+// check reports pending migrations without touching the database schema.
+// Disabled migrations and, when tags or a phase are given, migrations that
+// do not match them are not counted as pending.
+func (c *MigrateCommand) check(tags []string, phase string) error {
+	mgr, ok := c.Driver.(*Manager)
+	if !ok {
+		return fmt.Errorf("migrate --check requires *Manager driver")
+	}
+	statuses, err := mgr.MigrationStatuses()
+	if err != nil {
+		return err
+	}
+	var pending []string
+	for _, status := range statuses {
+		if (status.Applied && !status.Changed) || !tagsMatch(status.Tags, tags) || (phase != "" && status.Phase != phase) {
+			continue
+		}
+		if status.Disabled {
+			logger.Info().Msgf("Disabled migration: %s", status.Name)
+			continue
+		}
+		pending = append(pending, status.Name)
+		if len(status.Tags) > 0 {
+			logger.Info().Msgf("Pending migration: %s [%s]", status.Name, strings.Join(status.Tags, ", "))
+		} else {
+			logger.Info().Msgf("Pending migration: %s", status.Name)
+		}
+	}
+	if len(pending) == 0 {
+		logger.Info().Msg("Migrations are up to date.")
+		return nil
+	}
+	if migrationMap, err := mgr.ListMigrationMap(); err == nil {
+		mgr.warnLargeTables(migrationMap, pending)
+	}
+	return fmt.Errorf("%w: %d pending migration(s)", ErrPendingMigrations, len(pending))
+}
+
 func (c *MigrateCommand) runSeedFilesAfterMigration(includeRaw bool) error {
 	seedDir := c.Driver.SeedDir()
 	if seedDir == "" {
@@ -270,16 +528,11 @@ func (c *MigrateCommand) applyParsedMigration(migration Migration, fileName stri
 		logger.Error().Err(err).Msgf("Migration %s failed required field check", fileName)
 		return fmt.Errorf("MigrateCommand.Handle: %w", err)
 	}
-	if migration.Disable {
+	if migration.isDisabled() {
 		logger.Warn().Msgf("Migration '%s' is disabled. To enable it, set Disabled: false or remove the Disabled field.", migration.Name)
 		return nil
 	}
-	for _, val := range migration.Validate {
-		if err := runPreUpChecks(val.PreUpChecks); err != nil {
-			logger.Error().Err(err).Msgf("Pre-up validation failed for migration %s", migration.Name)
-			return fmt.Errorf("pre-up validation failed for migration %s: %w", migration.Name, err)
-		}
-	}
+	// ApplyMigration runs the PreUpChecks and PostUpChecks of migration.
 	if err := c.Driver.ApplyMigration(migration); err != nil {
 		logger.Error().Msgf("Failed to apply migration %s: %v", migration.Name, err)
 		if forceFlag {
@@ -287,12 +540,6 @@ func (c *MigrateCommand) applyParsedMigration(migration Migration, fileName stri
 		}
 		return fmt.Errorf("failed to apply migration %s: %w", migration.Name, err)
 	}
-	for _, val := range migration.Validate {
-		if err := runPostUpChecks(val.PostUpChecks); err != nil {
-			logger.Error().Err(err).Msgf("Post-up validation failed for migration %s", migration.Name)
-			return fmt.Errorf("post-up validation failed for migration %s: %w", migration.Name, err)
-		}
-	}
 	if shouldSeed {
 		return c.autoSeedCreatedTables(migration, fileName, seedRows)
 	}
@@ -371,12 +618,13 @@ func (c *MigrateCommand) autoSeedCreatedTables(migration Migration, fileName str
 		}
 		logger.Info().Msgf("Seeding table: %s", ct.Name)
 		for _, q := range queries {
-			logger.Info().Msgf("Seed SQL: %s", q.SQL)
+			mgr.logStatement(q.SQL, q.Args)
 			if err := mgr.dbDriver.ApplySQL([]string{q.SQL}, q.Args); err != nil {
 				logger.Error().Err(err).Msgf("Failed to apply seed SQL for table %s: %s", ct.Name, q.SQL)
 				return fmt.Errorf("failed to apply seed for table %s: %w", ct.Name, err)
 			}
 		}
+		mgr.emitSeedRows("", ct.Name, int64(len(queries)))
 	}
 	return nil
 }
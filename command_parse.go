@@ -0,0 +1,63 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/oarkflow/cli/contracts"
+)
+
+// ParseCommand checks that every migration and seed file parses and
+// generates SQL, without touching the database.
+type ParseCommand struct {
+	Driver IManager
+}
+
+func (c *ParseCommand) Signature() string {
+	return "migration:parse"
+}
+
+func (c *ParseCommand) Description() string {
+	return "Check that every migration and seed file parses and generates SQL for the configured dialect, without touching the database."
+}
+
+func (c *ParseCommand) Extend() contracts.Extend {
+	return contracts.Extend{}
+}
+
+func (c *ParseCommand) Handle(ctx contracts.Context) error {
+	mgr, ok := c.Driver.(*Manager)
+	if !ok {
+		return errors.New("migration:parse requires *Manager driver")
+	}
+	checks, err := mgr.CheckFiles()
+	failed := writeFileChecks(os.Stdout, checks)
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d file(s) failed to parse", failed, len(checks))
+	}
+	return nil
+}
+
+// writeFileChecks prints one line per file, followed by its errors, and
+// returns how many files failed.
+func writeFileChecks(w io.Writer, checks []FileCheck) int {
+	failed := 0
+	for _, check := range checks {
+		if len(check.Errs) == 0 {
+			fmt.Fprintf(w, "  ok   %s\n", check.Path)
+			continue
+		}
+		failed++
+		fmt.Fprintf(w, "  FAIL %s\n", check.Path)
+		for _, err := range check.Errs {
+			fmt.Fprintf(w, "       %s\n", strings.ReplaceAll(err.Error(), "\n", "\n       "))
+		}
+	}
+	return failed
+}
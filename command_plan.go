@@ -0,0 +1,70 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/oarkflow/cli/contracts"
+)
+
+// PlanCommand lists the pending migrations with review findings:
+// destructive operations and lint violations.
+type PlanCommand struct {
+	Driver IManager
+}
+
+func (c *PlanCommand) Signature() string {
+	return "plan"
+}
+
+func (c *PlanCommand) Description() string {
+	return "List pending migrations with warnings for destructive operations and errors for lint violations. --format=github or --format=gitlab emits them as pull request annotations."
+}
+
+func (c *PlanCommand) Extend() contracts.Extend {
+	return contracts.Extend{
+		Flags: []contracts.Flag{
+			{
+				Name:  "format",
+				Usage: "Output format (text|github|gitlab); json reports failures as JSON like other commands",
+				Value: "text",
+			},
+			jobsFlagDefinition(),
+		},
+	}
+}
+
+func (c *PlanCommand) Handle(ctx contracts.Context) error {
+	mgr, ok := c.Driver.(*Manager)
+	if !ok {
+		return errors.New("plan requires *Manager driver")
+	}
+	format := ctx.Option("format")
+	switch format {
+	case "", "text", "json", "github", "gitlab":
+	default:
+		return fmt.Errorf("invalid --format %q: must be text, github, gitlab or json", format)
+	}
+	if err := applyJobsOption(ctx, c.Driver); err != nil {
+		return err
+	}
+	plan, err := mgr.Plan()
+	if err != nil {
+		return err
+	}
+	switch format {
+	case "github":
+		WritePlanGitHub(os.Stdout, plan)
+	case "gitlab":
+		if err := WritePlanGitLab(os.Stdout, plan); err != nil {
+			return err
+		}
+	default:
+		WritePlan(os.Stdout, plan)
+	}
+	if n := plan.ErrorCount(); n > 0 {
+		return fmt.Errorf("plan has %d error(s)", n)
+	}
+	return nil
+}
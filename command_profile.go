@@ -0,0 +1,56 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/oarkflow/cli/contracts"
+)
+
+// ProfileCommand writes the column statistics of a table to a profile that
+// seeds draw values from.
+type ProfileCommand struct {
+	Driver IManager
+}
+
+func (c *ProfileCommand) Signature() string {
+	return "db:profile"
+}
+
+func (c *ProfileCommand) Description() string {
+	return "Captures the column statistics of a table into a profile for seeding."
+}
+
+func (c *ProfileCommand) Extend() contracts.Extend {
+	return contracts.Extend{
+		Flags: []contracts.Flag{
+			{
+				Name:  "sample",
+				Usage: "Rows read to compute the statistics",
+				Value: strconv.Itoa(defaultProfileSample),
+			},
+		},
+	}
+}
+
+func (c *ProfileCommand) Handle(ctx contracts.Context) error {
+	mgr, ok := c.Driver.(*Manager)
+	if !ok {
+		return errors.New("db:profile requires *Manager driver")
+	}
+	table := ctx.Argument(0)
+	if table == "" {
+		return errors.New("table name is required")
+	}
+	sample := 0
+	if value := ctx.Option("sample"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid --sample value: %s (expected a positive integer)", value)
+		}
+		sample = n
+	}
+	_, err := mgr.ProfileTable(table, sample)
+	return err
+}
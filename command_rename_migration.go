@@ -0,0 +1,36 @@
+package migrate
+
+import (
+	"errors"
+
+	"github.com/oarkflow/cli/contracts"
+)
+
+// RenameMigrationCommand renames a migration, its file and its history entry.
+type RenameMigrationCommand struct {
+	Driver IManager
+}
+
+func (c *RenameMigrationCommand) Signature() string {
+	return "migration:rename"
+}
+
+func (c *RenameMigrationCommand) Description() string {
+	return "Rename a migration <old> <new>: the Migration block, the file when it carries the old name, and the history entry if already applied."
+}
+
+func (c *RenameMigrationCommand) Extend() contracts.Extend {
+	return contracts.Extend{}
+}
+
+func (c *RenameMigrationCommand) Handle(ctx contracts.Context) error {
+	mgr, ok := c.Driver.(*Manager)
+	if !ok {
+		return errors.New("migration:rename requires *Manager driver")
+	}
+	oldName, newName := ctx.Argument(0), ctx.Argument(1)
+	if oldName == "" || newName == "" {
+		return errors.New("usage: migration:rename <old> <new>")
+	}
+	return mgr.RenameMigration(oldName, newName)
+}
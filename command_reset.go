@@ -31,15 +31,23 @@ func (c *ResetCommand) Extend() contracts.Extend {
 				Usage:   "Force reset ignoring rollback statement errors",
 				Value:   "false",
 			},
+			allowModifiedFlagDefinition(),
+			fromHistoryFlagDefinition(),
+			yesProductionFlagDefinition(),
 		},
 	}
 }
 
 func (c *ResetCommand) Handle(ctx contracts.Context) error {
+	if err := confirmProtectedEnvironment(ctx, managerEnvironment(c.Driver), "Migration reset"); err != nil {
+		return err
+	}
 	verbose := ctx.Option("v") != "" && ctx.Option("v") != "false"
 	forceFlag := ctx.Option("f") != "" && ctx.Option("f") != "false"
 	if mgr, ok := c.Driver.(*Manager); ok {
 		mgr.Verbose = verbose
+		mgr.AllowModified = optionEnabled(ctx, "allow-modified")
+		mgr.FromHistory = optionEnabled(ctx, "from-history")
 		if forceFlag {
 			mgr.Force = true
 			if mgr.dbDriver != nil {
@@ -40,15 +40,23 @@ func (c *RollbackCommand) Extend() contracts.Extend {
 				Usage:   "Number of migrations to rollback (default: 1)",
 				Value:   "1",
 			},
+			allowModifiedFlagDefinition(),
+			fromHistoryFlagDefinition(),
+			yesProductionFlagDefinition(),
 		},
 	}
 }
 
 func (c *RollbackCommand) Handle(ctx contracts.Context) error {
+	if err := confirmProtectedEnvironment(ctx, managerEnvironment(c.Driver), "Rollback"); err != nil {
+		return err
+	}
 	verbose := ctx.Option("v") != "" && ctx.Option("v") != "false"
 	forceFlag := ctx.Option("f") != "" && ctx.Option("f") != "false"
 	if mgr, ok := c.Driver.(*Manager); ok {
 		mgr.Verbose = verbose
+		mgr.AllowModified = optionEnabled(ctx, "allow-modified")
+		mgr.FromHistory = optionEnabled(ctx, "from-history")
 		if forceFlag {
 			mgr.Force = true
 			if mgr.dbDriver != nil {
@@ -0,0 +1,82 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/oarkflow/cli/contracts"
+)
+
+// SchemaDocsCommand writes a static documentation site of the schema defined
+// by the migrations.
+type SchemaDocsCommand struct {
+	Driver IManager
+}
+
+func (c *SchemaDocsCommand) Signature() string {
+	return "schema:docs"
+}
+
+func (c *SchemaDocsCommand) Description() string {
+	return "Generates a static HTML or Markdown site documenting the tables, relationships and change history of the schema."
+}
+
+func (c *SchemaDocsCommand) Extend() contracts.Extend {
+	return contracts.Extend{
+		Flags: []contracts.Flag{
+			{
+				Name:  "format",
+				Usage: "Format of the site: html or markdown",
+				Value: SchemaDocsHTML,
+			},
+			{
+				Name:  "schema",
+				Usage: "Directory of the migration directory whose objects are documented (default: every migration)",
+				Value: "",
+			},
+			{
+				Name:  "output",
+				Usage: "Directory to write the site to",
+				Value: "docs/schema",
+			},
+		},
+	}
+}
+
+func (c *SchemaDocsCommand) Handle(ctx contracts.Context) error {
+	mgr, ok := c.Driver.(*Manager)
+	if !ok {
+		return errors.New("schema:docs requires *Manager driver")
+	}
+	format := ctx.Option("format")
+	if format == "" {
+		format = SchemaDocsHTML
+	}
+	pages, err := mgr.SchemaDocs(format, ctx.Option("schema"))
+	if err != nil {
+		return err
+	}
+	output := ctx.Option("output")
+	if output == "" {
+		output = "docs/schema"
+	}
+	names := make([]string, 0, len(pages))
+	for name := range pages {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		path := filepath.Join(output, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+		if err := os.WriteFile(path, pages[name], 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	logger.Info().Msgf("Schema docs written to %s (%d pages)", output, len(pages))
+	return nil
+}
@@ -0,0 +1,70 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/oarkflow/cli/contracts"
+)
+
+// SchemaExportCommand writes the schema left by the migrations as JSON
+// Schema or OpenAPI components.
+type SchemaExportCommand struct {
+	Driver IManager
+}
+
+func (c *SchemaExportCommand) Signature() string {
+	return "schema:export"
+}
+
+func (c *SchemaExportCommand) Description() string {
+	return "Exports the tables defined by the migrations as JSON Schema or OpenAPI components."
+}
+
+func (c *SchemaExportCommand) Extend() contracts.Extend {
+	return contracts.Extend{
+		Flags: []contracts.Flag{
+			{
+				Name:  "format",
+				Usage: "Output format: jsonschema or openapi",
+				Value: SchemaFormatJSONSchema,
+			},
+			{
+				Name:  "schema",
+				Usage: "Directory of the migration directory whose tables are exported (default: every migration)",
+				Value: "",
+			},
+			{
+				Name:  "output",
+				Usage: "File to write the schema to (default: stdout)",
+				Value: "",
+			},
+		},
+	}
+}
+
+func (c *SchemaExportCommand) Handle(ctx contracts.Context) error {
+	mgr, ok := c.Driver.(*Manager)
+	if !ok {
+		return errors.New("schema:export requires *Manager driver")
+	}
+	format := ctx.Option("format")
+	if format == "" {
+		format = SchemaFormatJSONSchema
+	}
+	data, err := mgr.ExportSchema(format, ctx.Option("schema"))
+	if err != nil {
+		return err
+	}
+	output := ctx.Option("output")
+	if output == "" || output == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		return fmt.Errorf("failed to write schema: %w", err)
+	}
+	logger.Info().Msgf("Schema written to %s", output)
+	return nil
+}
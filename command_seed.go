@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/oarkflow/cli/contracts"
@@ -68,9 +69,45 @@ func (c *SeedCommand) Extend() contracts.Extend {
 			{
 				Name:    "include-raw",
 				Aliases: []string{"r"},
-				Usage:   "Include raw .sql seed files",
+				Usage:   "Include raw .sql and .csv seed files",
 				Value:   "false",
 			},
+			{
+				Name:  "table",
+				Usage: "Run only the seeds of these tables (comma-separated)",
+				Value: "",
+			},
+			{
+				Name:  "rows",
+				Usage: "Rows generated by each selected seed, overriding its rows setting",
+				Value: "",
+			},
+			{
+				Name:    jobsFlag,
+				Aliases: []string{"j"},
+				Usage:   "Number of seed files run concurrently; files seeding related tables keep their order",
+				Value:   "",
+			},
+			{
+				Name:  "keep-going",
+				Usage: "Run the other seed files when one fails, then print a summary of every file",
+				Value: "false",
+			},
+			{
+				Name:  "batch-size",
+				Usage: "Rows or statements committed per transaction when streaming raw seeds",
+				Value: "1000",
+			},
+			{
+				Name:  "resume",
+				Usage: "Resume streamed seed files from the offset recorded by an interrupted run",
+				Value: "false",
+			},
+			{
+				Name:  "stream",
+				Usage: "Stream raw .sql seeds statement by statement regardless of size",
+				Value: "false",
+			},
 			{
 				Name:    "verbose",
 				Aliases: []string{"v"},
@@ -95,9 +132,50 @@ func (c *SeedCommand) Handle(ctx contracts.Context) error {
 			mgr.Verbose = true
 		}
 	}
+	if mgr, ok := c.Driver.(*Manager); ok {
+		if value := ctx.Option(jobsFlag); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid --jobs value: %s (expected a positive integer)", value)
+			}
+			mgr.seedJobs = n
+		}
+		if optionEnabled(ctx, "keep-going") {
+			mgr.seedKeepGoing = true
+		}
+		if value := ctx.Option("batch-size"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid --batch-size value: %s (expected a positive integer)", value)
+			}
+			mgr.seedStream.BatchSize = n
+		}
+		if optionEnabled(ctx, "resume") {
+			mgr.seedStream.Resume = true
+		}
+		if optionEnabled(ctx, "stream") {
+			mgr.seedStream.Always = true
+		}
+		var filter SeedFilter
+		for _, table := range strings.Split(ctx.Option("table"), ",") {
+			if table = strings.TrimSpace(table); table != "" {
+				filter.Tables = append(filter.Tables, table)
+			}
+		}
+		if value := ctx.Option("rows"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid --rows value: %s (expected a positive integer)", value)
+			}
+			filter.Rows = n
+		}
+		if len(filter.Tables) > 0 || filter.Rows > 0 {
+			mgr.seedFilter = filter
+		}
+	}
 	if seedFile != "" {
-		ext := strings.ToLower(filepath.Ext(seedFile))
-		if ext == ".sql" && !includeRaw {
+		ext := seedFileExt(seedFile)
+		if (ext == ".sql" || ext == ".csv") && !includeRaw {
 			logger.Printf("Raw seed file specified but --include-raw not set: %s", seedFile)
 			return fmt.Errorf("raw seed file specified but --include-raw not set: %s", seedFile)
 		}
@@ -117,11 +195,11 @@ func (c *SeedCommand) Handle(ctx contracts.Context) error {
 				if file.IsDir() {
 					continue
 				}
-				ext := strings.ToLower(filepath.Ext(file.Name()))
+				ext := seedFileExt(file.Name())
 				switch ext {
 				case ".bcl":
 					files = append(files, filepath.Join(c.Driver.SeedDir(), file.Name()))
-				case ".sql":
+				case ".sql", ".csv":
 					if includeRaw {
 						files = append(files, filepath.Join(c.Driver.SeedDir(), file.Name()))
 					}
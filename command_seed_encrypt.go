@@ -0,0 +1,142 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/oarkflow/cli/contracts"
+)
+
+// SeedEncryptCommand encrypts a seed file so it can be committed without
+// its plaintext. db:seed decrypts it in memory when it runs.
+type SeedEncryptCommand struct {
+	Driver IManager
+}
+
+func (c *SeedEncryptCommand) Signature() string {
+	return "seed:encrypt"
+}
+
+func (c *SeedEncryptCommand) Description() string {
+	return "Encrypt a seed file with AES-GCM, writing <file>.enc. --generate-key=true prints a new key instead."
+}
+
+func (c *SeedEncryptCommand) Extend() contracts.Extend {
+	return contracts.Extend{
+		Flags: []contracts.Flag{
+			seedKeyFlag(),
+			{
+				Name:  "remove",
+				Usage: "Delete the plaintext file after encrypting it",
+				Value: "false",
+			},
+			{
+				Name:  "generate-key",
+				Usage: "Print a new random key and exit",
+				Value: "false",
+			},
+		},
+	}
+}
+
+func (c *SeedEncryptCommand) Handle(ctx contracts.Context) error {
+	if optionEnabled(ctx, "generate-key") {
+		key, err := GenerateSeedKey()
+		if err != nil {
+			return err
+		}
+		fmt.Println(key)
+		return nil
+	}
+	path := ctx.Argument(0)
+	if path == "" {
+		return errors.New("seed file is required")
+	}
+	if isEncryptedSeed(path) {
+		return fmt.Errorf("%s is already encrypted", path)
+	}
+	key, err := ResolveSeedKey(seedKeyOption(ctx, c.Driver))
+	if err != nil {
+		return err
+	}
+	plaintext, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	data, err := EncryptSeed(plaintext, key)
+	if err != nil {
+		return err
+	}
+	target := path + EncryptedSeedExt
+	if err := os.WriteFile(target, data, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Encrypted %s to %s\n", path, target)
+	if optionEnabled(ctx, "remove") {
+		return os.Remove(path)
+	}
+	return nil
+}
+
+// SeedDecryptCommand prints the plaintext of an encrypted seed file.
+type SeedDecryptCommand struct {
+	Driver IManager
+}
+
+func (c *SeedDecryptCommand) Signature() string {
+	return "seed:decrypt"
+}
+
+func (c *SeedDecryptCommand) Description() string {
+	return "Print the decrypted contents of an encrypted seed file to stdout."
+}
+
+func (c *SeedDecryptCommand) Extend() contracts.Extend {
+	return contracts.Extend{
+		Flags: []contracts.Flag{
+			seedKeyFlag(),
+		},
+	}
+}
+
+func (c *SeedDecryptCommand) Handle(ctx contracts.Context) error {
+	path := ctx.Argument(0)
+	if path == "" {
+		return errors.New("encrypted seed file is required")
+	}
+	key, err := ResolveSeedKey(seedKeyOption(ctx, c.Driver))
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	plaintext, err := DecryptSeed(data, key)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	_, err = os.Stdout.Write(plaintext)
+	return err
+}
+
+func seedKeyFlag() contracts.Flag {
+	return contracts.Flag{
+		Name:  "key",
+		Usage: "Secret reference holding the key, such as env:MIGRATE_SEED_KEY or file:/run/secrets/seed-key (default: seed.encryption_key)",
+		Value: "",
+	}
+}
+
+// seedKeyOption returns the --key option, falling back to the configured
+// seed encryption key.
+func seedKeyOption(ctx contracts.Context, driver IManager) string {
+	if ref := ctx.Option("key"); ref != "" {
+		return ref
+	}
+	if mgr, ok := driver.(*Manager); ok {
+		return mgr.seedKey
+	}
+	return ""
+}
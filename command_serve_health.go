@@ -0,0 +1,143 @@
+package migrate
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/oarkflow/cli/contracts"
+	"github.com/oarkflow/json"
+)
+
+// HealthStatus is the response body of the /healthz endpoint.
+type HealthStatus struct {
+	Status  string `json:"status"`
+	Dialect string `json:"dialect"`
+	Error   string `json:"error,omitempty"`
+}
+
+// MigrationsStatus is the response body of the /migrations endpoint.
+type MigrationsStatus struct {
+	Status      string     `json:"status"`
+	Pending     int        `json:"pending"`
+	PendingList []string   `json:"pending_migrations"`
+	Applied     int        `json:"applied"`
+	LastApplied string     `json:"last_applied,omitempty"`
+	AppliedAt   *time.Time `json:"applied_at,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// ServeHealthCommand exposes health and migration readiness over HTTP.
+type ServeHealthCommand struct {
+	Driver IManager
+}
+
+func (c *ServeHealthCommand) Signature() string {
+	return "serve-health"
+}
+
+func (c *ServeHealthCommand) Description() string {
+	return "Serve /healthz (database reachable) and /migrations (pending count, last applied) over HTTP."
+}
+
+func (c *ServeHealthCommand) Extend() contracts.Extend {
+	return contracts.Extend{
+		Flags: []contracts.Flag{
+			{
+				Name:    "addr",
+				Aliases: []string{"a"},
+				Usage:   "Address to listen on",
+				Value:   ":9090",
+			},
+		},
+	}
+}
+
+func (c *ServeHealthCommand) Handle(ctx contracts.Context) error {
+	mgr, ok := c.Driver.(*Manager)
+	if !ok {
+		return fmt.Errorf("serve-health requires *Manager driver")
+	}
+	addr := ctx.Option("addr")
+	if addr == "" {
+		addr = ":9090"
+	}
+	logger.Info().Msgf("Serving health endpoints on %s (/healthz, /migrations)", addr)
+	return http.ListenAndServe(addr, mgr.HealthHandler())
+}
+
+// HealthHandler returns an http.Handler serving /healthz and /migrations.
+// /healthz answers 503 when the database is unreachable; /migrations answers
+// 503 while migrations are pending so deployment gates can wait on it.
+func (d *Manager) HealthHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		status := HealthStatus{Status: "ok", Dialect: d.dialect}
+		code := http.StatusOK
+		if d.dbDriver == nil {
+			status.Status = "error"
+			status.Error = "no database driver configured"
+			code = http.StatusServiceUnavailable
+		} else if err := d.dbDriver.Ping(r.Context()); err != nil {
+			status.Status = "error"
+			status.Error = err.Error()
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, status)
+	})
+	mux.HandleFunc("/migrations", func(w http.ResponseWriter, r *http.Request) {
+		status, err := d.migrationsStatus()
+		if err != nil {
+			writeJSON(w, http.StatusServiceUnavailable, MigrationsStatus{Status: "error", Error: err.Error()})
+			return
+		}
+		code := http.StatusOK
+		if status.Pending > 0 {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, status)
+	})
+	return mux
+}
+
+func (d *Manager) migrationsStatus() (MigrationsStatus, error) {
+	pending, err := d.PendingMigrations()
+	if err != nil {
+		return MigrationsStatus{}, err
+	}
+	histories, err := d.historyDriver.Load()
+	if err != nil {
+		return MigrationsStatus{}, fmt.Errorf("failed to load migration history: %w", err)
+	}
+	status := MigrationsStatus{
+		Status:      "current",
+		Pending:     len(pending),
+		PendingList: pending,
+		Applied:     len(histories),
+	}
+	if status.PendingList == nil {
+		status.PendingList = []string{}
+	}
+	if len(pending) > 0 {
+		status.Status = "pending"
+	}
+	for _, h := range histories {
+		if status.AppliedAt == nil || !h.AppliedAt.Before(*status.AppliedAt) {
+			appliedAt := h.AppliedAt
+			status.LastApplied = h.Name
+			status.AppliedAt = &appliedAt
+		}
+	}
+	return status, nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(data)
+}
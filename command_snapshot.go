@@ -0,0 +1,83 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/oarkflow/cli/contracts"
+)
+
+// SnapshotCommand saves and restores snapshots of the development database,
+// a faster way to switch between data states than re-running migrations and
+// seeds.
+type SnapshotCommand struct {
+	Driver IManager
+}
+
+func (c *SnapshotCommand) Signature() string {
+	return "db:snapshot"
+}
+
+func (c *SnapshotCommand) Description() string {
+	return "Saves, restores or lists database snapshots: db:snapshot save|restore <name>, db:snapshot list."
+}
+
+func (c *SnapshotCommand) Extend() contracts.Extend {
+	return contracts.Extend{
+		Flags: []contracts.Flag{
+			yesProductionFlagDefinition(),
+		},
+	}
+}
+
+func (c *SnapshotCommand) Handle(ctx contracts.Context) error {
+	mgr, ok := c.Driver.(*Manager)
+	if !ok {
+		return errors.New("db:snapshot requires *Manager driver")
+	}
+	action, name := ctx.Argument(0), ctx.Argument(1)
+	if action != "list" && name == "" {
+		return fmt.Errorf("usage: db:snapshot save|restore <name>, or db:snapshot list")
+	}
+	switch action {
+	case "save":
+		_, err := mgr.SaveSnapshot(name)
+		return err
+	case "restore":
+		if err := confirmProtectedEnvironment(ctx, mgr.Environment(), "Snapshot restore"); err != nil {
+			return err
+		}
+		if !optionEnabled(ctx, "yes") {
+			logger.Warn().Msgf("WARNING: This will replace the data of the database with snapshot %s.", name)
+			ok, err := promptConfirmation(ctx, "Type 'yes' to continue: ", "yes", "pass --yes to restore without prompting")
+			if err != nil {
+				return err
+			}
+			if !ok {
+				logger.Info().Msg("Aborted.")
+				return nil
+			}
+		}
+		return mgr.RestoreSnapshot(name)
+	case "list":
+		snapshots, err := mgr.ListSnapshots()
+		if err != nil {
+			return err
+		}
+		if len(snapshots) == 0 {
+			fmt.Printf("No snapshots in %s\n", mgr.snapshotDir)
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Name\tDialect\tCreated\tFile")
+		for _, s := range snapshots {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Name, s.Dialect, s.CreatedAt.Local().Format(time.DateTime), s.File)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown db:snapshot action %q (expected save, restore or list)", action)
+	}
+}
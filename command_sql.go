@@ -0,0 +1,279 @@
+package migrate
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/oarkflow/cli/contracts"
+)
+
+// SQLCommand prints the SQL of migrations without connecting to a database,
+// for review in environments that cannot reach it.
+type SQLCommand struct {
+	Driver IManager
+}
+
+func (c *SQLCommand) Signature() string {
+	return "sql"
+}
+
+func (c *SQLCommand) Description() string {
+	return "Print the SQL of pending migrations (or of [name]) without a database connection."
+}
+
+func (c *SQLCommand) Extend() contracts.Extend {
+	return contracts.Extend{
+		Flags: []contracts.Flag{
+			{
+				Name:  "dialect",
+				Usage: "SQL dialect to generate (postgres|mysql|sqlite); defaults to the configured one",
+				Value: "",
+			},
+			{
+				Name:  "up",
+				Usage: "Print the Up SQL (default)",
+				Value: "false",
+			},
+			{
+				Name:  "down",
+				Usage: "Print the Down SQL; without [name] covers applied migrations, newest first",
+				Value: "false",
+			},
+			{
+				Name:  "all",
+				Usage: "Print every migration instead of consulting the history",
+				Value: "false",
+			},
+		},
+	}
+}
+
+func (c *SQLCommand) Handle(ctx contracts.Context) error {
+	mgr, ok := c.Driver.(*Manager)
+	if !ok {
+		return fmt.Errorf("sql requires *Manager driver")
+	}
+	if optionEnabled(ctx, "up") && optionEnabled(ctx, "down") {
+		return fmt.Errorf("--up and --down are mutually exclusive")
+	}
+	opts := OfflineSQLOptions{
+		Dialect: ctx.Option("dialect"),
+		Down:    optionEnabled(ctx, "down"),
+		All:     optionEnabled(ctx, "all"),
+	}
+	if name := ctx.Argument(0); name != "" {
+		opts.Names = []string{name}
+	}
+	return mgr.WriteMigrationSQL(os.Stdout, opts)
+}
+
+// OfflineSQLOptions selects the migrations and direction written by
+// WriteMigrationSQL.
+type OfflineSQLOptions struct {
+	// Dialect overrides the manager's dialect.
+	Dialect string
+	// Down writes the Down SQL instead of the Up SQL.
+	Down bool
+	// Names limits output to these migrations, in the given order.
+	Names []string
+	// All ignores the history: every migration is written, in apply order
+	// (reversed for Down).
+	All bool
+}
+
+// WriteMigrationSQL writes the SQL of migrations to w without touching the
+// database. Unless names are given, Up covers pending migrations and Down
+// covers applied ones, newest first. When the history cannot be read (for
+// example because the database is unreachable) every migration is written.
+func (d *Manager) WriteMigrationSQL(w io.Writer, opts OfflineSQLOptions) error {
+	dialect := d.dialect
+	if opts.Dialect != "" {
+		normalized, err := NormalizeDriver(opts.Dialect)
+		if err != nil {
+			return fmt.Errorf("invalid dialect: %w", err)
+		}
+		dialect = normalized
+	}
+	migrationMap, err := d.ListMigrationMap()
+	if err != nil {
+		return fmt.Errorf("failed to list migrations: %w", err)
+	}
+	names, err := d.offlineSQLNames(migrationMap, opts)
+	if err != nil {
+		return err
+	}
+	direction := "up"
+	if opts.Down {
+		direction = "down"
+	}
+	for _, name := range names {
+		path, ok := migrationMap[name]
+		if !ok {
+			return fmt.Errorf("migration %q not found in '%s'", name, d.migrationDir)
+		}
+		steps, err := d.offlineMigrationSteps(name, path, dialect, !opts.Down)
+		if err != nil {
+			return fmt.Errorf("failed to generate SQL for %s: %w", name, err)
+		}
+		fmt.Fprintf(w, "-- Migration: %s (%s)\n", name, direction)
+		for i, step := range steps {
+			if len(steps) > 1 {
+				fmt.Fprintf(w, "-- Step %d: %s\n", i+1, step.Name)
+			}
+			for _, st := range step.Statements {
+				if st.Options.ContinueOnError {
+					fmt.Fprintln(w, "-- continue_on_error")
+				}
+				q := strings.TrimSpace(st.SQL)
+				if !strings.HasSuffix(q, ";") {
+					q += ";"
+				}
+				fmt.Fprintln(w, q)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// offlineSQLNames resolves the migrations written by WriteMigrationSQL.
+func (d *Manager) offlineSQLNames(migrationMap map[string]string, opts OfflineSQLOptions) ([]string, error) {
+	if len(opts.Names) > 0 {
+		return opts.Names, nil
+	}
+	ordered, err := d.orderedMigrationNames(migrationMap)
+	if err != nil {
+		return nil, err
+	}
+	var histories []MigrationHistory
+	if !opts.All {
+		if d.historyDriver == nil {
+			opts.All = true
+		} else if histories, err = d.historyDriver.Load(); err != nil {
+			logger.Warn().Msgf("Migration history unavailable (%v); printing every migration", err)
+			opts.All = true
+		}
+	}
+	if opts.All {
+		if opts.Down {
+			slices.Reverse(ordered)
+		}
+		return ordered, nil
+	}
+	if opts.Down {
+		var applied []string
+		for i := len(histories) - 1; i >= 0; i-- {
+			if _, ok := migrationMap[histories[i].Name]; ok {
+				applied = append(applied, histories[i].Name)
+			}
+		}
+		return applied, nil
+	}
+	applied := make(map[string]bool, len(histories))
+	for _, h := range histories {
+		applied[h.Name] = true
+	}
+	var pending []string
+	for _, name := range ordered {
+		if !applied[name] {
+			pending = append(pending, name)
+		}
+	}
+	return pending, nil
+}
+
+// orderedMigrationNames lists migrations in the order migrate applies them:
+// by file name, then by position inside a multi-migration BCL file.
+func (d *Manager) orderedMigrationNames(migrationMap map[string]string) ([]string, error) {
+	seen := make(map[string]bool, len(migrationMap))
+	var paths []string
+	for _, path := range migrationMap {
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	sort.SliceStable(paths, func(i, j int) bool {
+		return filepath.Base(paths[i]) < filepath.Base(paths[j])
+	})
+	var names []string
+	for _, path := range paths {
+		ext := strings.ToLower(filepath.Ext(path))
+		if ext == ".sql" {
+			names = append(names, strings.TrimSuffix(filepath.Base(path), ext))
+			continue
+		}
+		cached, err := d.readMigrationsBCL(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse migration file %s: %w", path, err)
+		}
+		for _, migration := range cached.migrations {
+			names = append(names, migration.Name)
+		}
+	}
+	return names, nil
+}
+
+// offlineMigrationSQL generates the statements of one migration. Raw .sql
+// migrations are split into their Up and Down sections.
+func (d *Manager) offlineMigrationSQL(name, path, dialect string, up bool) ([]Statement, error) {
+	steps, err := d.offlineMigrationSteps(name, path, dialect, up)
+	if err != nil {
+		return nil, err
+	}
+	var statements []Statement
+	for _, step := range steps {
+		statements = append(statements, step.Statements...)
+	}
+	return statements, nil
+}
+
+// offlineMigrationSteps generates the statements of one migration grouped
+// by Up or Down block. Raw .sql migrations and migrations with a single
+// block yield one step.
+func (d *Manager) offlineMigrationSteps(name, path, dialect string, up bool) ([]PlannedStep, error) {
+	if strings.EqualFold(filepath.Ext(path), ".sql") {
+		data, err := d.readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		upSQL, downSQL := parseSQLMigration(data)
+		q := downSQL
+		if up {
+			q = upSQL
+		}
+		if strings.TrimSpace(q) == "" {
+			return nil, nil
+		}
+		return []PlannedStep{{Statements: []Statement{{SQL: q}}}}, nil
+	}
+	cached, err := d.readMigrationsBCL(path)
+	if err != nil {
+		return nil, err
+	}
+	migration, ok := findMigrationByName(cached.migrations, name)
+	if !ok {
+		return nil, fmt.Errorf("migration %q not found in BCL document", name)
+	}
+	if migration.Driver != "" {
+		if dialect, err = NormalizeDriver(migration.Driver); err != nil {
+			return nil, fmt.Errorf("invalid driver in migration %s: %w", name, err)
+		}
+	}
+	// Role passwords are shown as their secret reference. Such a migration
+	// bypasses the generated SQL cache, which holds the resolved statements.
+	migration, secrets := migration.withSecretReferences()
+	if secrets || (up && len(migration.UpSteps) > 0) || (!up && len(migration.DownSteps) > 0) {
+		return migration.PlanSteps(dialect, up)
+	}
+	statements, err := d.migrationSQL(cached.checksum, migration, dialect, up)
+	if err != nil {
+		return nil, err
+	}
+	return []PlannedStep{{Statements: statements}}, nil
+}
@@ -0,0 +1,89 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/oarkflow/cli/contracts"
+)
+
+// TestReversibilityCommand applies each pending migration, rolls it back and
+// reports the migrations whose Down does not restore the schema.
+type TestReversibilityCommand struct {
+	Driver IManager
+}
+
+func (c *TestReversibilityCommand) Signature() string {
+	return "migration:test-reversibility"
+}
+
+func (c *TestReversibilityCommand) Description() string {
+	return "Apply each pending migration, roll it back and compare schema snapshots to find migrations that are not cleanly reversible. Run it against a scratch database: pending migrations are left applied."
+}
+
+func (c *TestReversibilityCommand) Extend() contracts.Extend {
+	return contracts.Extend{
+		Flags: []contracts.Flag{
+			yesProductionFlagDefinition(),
+		},
+	}
+}
+
+func (c *TestReversibilityCommand) Handle(ctx contracts.Context) error {
+	mgr, ok := c.Driver.(*Manager)
+	if !ok {
+		return errors.New("migration:test-reversibility requires *Manager driver")
+	}
+	if err := confirmProtectedEnvironment(ctx, mgr.Environment(), "Reversibility testing"); err != nil {
+		return err
+	}
+	if err := mgr.ValidateHistoryStorage(); err != nil {
+		return fmt.Errorf("history storage validation failed: %w", err)
+	}
+	lock, err := acquireLock()
+	if err != nil {
+		return fmt.Errorf("cannot start reversibility test: %w", err)
+	}
+	defer func() {
+		if err := lock.Release(); err != nil {
+			logger.Printf("Warning releasing lock: %v", err)
+		}
+	}()
+	results, err := mgr.CheckReversibility()
+	failed := writeReversibilityReport(os.Stdout, results)
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d migration(s) are not cleanly reversible", failed, len(results))
+	}
+	return nil
+}
+
+// writeReversibilityReport prints one line per result, followed by its
+// differences, and returns how many migrations are not reversible.
+func writeReversibilityReport(w io.Writer, results []ReversibilityResult) int {
+	if len(results) == 0 {
+		fmt.Fprintln(w, "No pending migrations to test.")
+		return 0
+	}
+	failed := 0
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			failed++
+			fmt.Fprintf(w, "  FAIL %s: %v\n", r.Migration, r.Err)
+		case len(r.Differences) > 0:
+			failed++
+			fmt.Fprintf(w, "  FAIL %s: Down does not restore the schema\n", r.Migration)
+			for _, diff := range r.Differences {
+				fmt.Fprintf(w, "         %s\n", diff)
+			}
+		default:
+			fmt.Fprintf(w, "  ok   %s\n", r.Migration)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,42 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/oarkflow/cli/contracts"
+)
+
+// TUICommand opens an interactive session for browsing migrations, their
+// SQL and the history, and applying or rolling back selected migrations.
+type TUICommand struct {
+	Driver IManager
+}
+
+func (c *TUICommand) Signature() string {
+	return "tui"
+}
+
+func (c *TUICommand) Description() string {
+	return "Browse migrations interactively: list their status, view generated SQL, apply or roll back selected migrations, and inspect history."
+}
+
+func (c *TUICommand) Extend() contracts.Extend {
+	return contracts.Extend{}
+}
+
+func (c *TUICommand) Handle(ctx contracts.Context) error {
+	mgr, ok := c.Driver.(*Manager)
+	if !ok {
+		return errors.New("tui requires *Manager driver")
+	}
+	if !inputAllowed(ctx) {
+		return fmt.Errorf("tui: %w", ErrNonInteractive)
+	}
+	if err := mgr.ValidateHistoryStorage(); err != nil {
+		return fmt.Errorf("history storage validation failed: %w", err)
+	}
+	fmt.Fprintln(os.Stdout, "Type help for commands.")
+	return newMigrationBrowser(mgr, os.Stdin, os.Stdout).run()
+}
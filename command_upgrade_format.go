@@ -0,0 +1,64 @@
+package migrate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/oarkflow/cli/contracts"
+)
+
+// UpgradeFormatCommand rewrites migration files written for an older DSL
+// version in the current format.
+type UpgradeFormatCommand struct {
+	Driver IManager
+}
+
+func (c *UpgradeFormatCommand) Signature() string {
+	return "migration:upgrade-format"
+}
+
+func (c *UpgradeFormatCommand) Description() string {
+	return fmt.Sprintf("Rewrite migration files written for an older DSL in the current format (DSL version %d), updating the history checksums of applied migrations.", DSLVersion)
+}
+
+func (c *UpgradeFormatCommand) Extend() contracts.Extend {
+	return contracts.Extend{
+		Flags: []contracts.Flag{
+			{
+				Name:  "dry-run",
+				Usage: "List the files that would be rewritten without changing them",
+				Value: "false",
+			},
+		},
+	}
+}
+
+func (c *UpgradeFormatCommand) Handle(ctx contracts.Context) error {
+	mgr, ok := c.Driver.(*Manager)
+	if !ok {
+		return fmt.Errorf("migration:upgrade-format requires *Manager driver")
+	}
+	if err := mgr.ValidateHistoryStorage(); err != nil {
+		return fmt.Errorf("history storage validation failed: %w", err)
+	}
+	dryRun := optionEnabled(ctx, "dry-run")
+	upgrades, err := mgr.UpgradeFormat(dryRun)
+	if err != nil {
+		return err
+	}
+	if len(upgrades) == 0 {
+		fmt.Printf("Every migration file is at DSL version %d.\n", DSLVersion)
+		return nil
+	}
+	verb, updated := "Rewrote", "updated"
+	if dryRun {
+		verb, updated = "Would rewrite", "to update"
+	}
+	for _, u := range upgrades {
+		fmt.Printf("%s %s (%s)\n", verb, u.Path, strings.Join(u.Migrations, ", "))
+		if len(u.Rechecksummed) > 0 {
+			fmt.Printf("  history checksum %s: %s\n", updated, strings.Join(u.Rechecksummed, ", "))
+		}
+	}
+	return nil
+}
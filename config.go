@@ -25,6 +25,15 @@ type MigrateConfig struct {
 
 	// Validation settings
 	Validation ValidationConfig `json:"validation"`
+
+	// Environment settings
+	Environment EnvironmentConfig `json:"environment"`
+
+	// Backup settings
+	Backup BackupConfig `json:"backup"`
+
+	// Snapshot settings
+	Snapshot SnapshotConfig `json:"snapshot"`
 }
 
 // DatabaseConfig holds database connection settings
@@ -38,6 +47,9 @@ type DatabaseConfig struct {
 	SSLMode  string `json:"ssl_mode,omitempty"`
 	Charset  string `json:"charset,omitempty"`
 	Timeout  int    `json:"timeout,omitempty"`
+	// Session lists statements run on every connection before migrations
+	// and seeds are applied, e.g. "SET lock_timeout = '5s'".
+	Session []string `json:"session,omitempty"`
 }
 
 // MigrationConfig holds migration-specific settings
@@ -49,6 +61,33 @@ type MigrationConfig struct {
 	AutoRollback   bool   `json:"auto_rollback"`
 	DryRun         bool   `json:"dry_run"`
 	SkipValidation bool   `json:"skip_validation"`
+	// Checksum is "raw" (file bytes) or "normalized" (parsed migration), see
+	// ChecksumRaw and ChecksumNormalized.
+	Checksum string `json:"checksum"`
+	// HistoryFallback is what happens when the history table cannot be
+	// reached: "fail" (default) or "file", see HistoryFallbackFail and
+	// HistoryFallbackFile.
+	HistoryFallback string `json:"history_fallback,omitempty"`
+	// HistoryFile is the file keeping the history without a database, or
+	// with the "file" fallback. Empty means migration_history.txt in the
+	// migration directory, and "state" the user's state directory, see
+	// HistoryFileState.
+	HistoryFile string `json:"history_file,omitempty"`
+	// HistoryMirror is "file" to also write the database history to the
+	// history file, see HistoryMirrorFile. The history is still read from
+	// the database.
+	HistoryMirror string `json:"history_mirror,omitempty"`
+	// IdentifierPrefix prefixes the tables of BCL migrations and seeds,
+	// e.g. "app1_", for databases shared by several applications, see
+	// WithIdentifierPrefix.
+	IdentifierPrefix string `json:"identifier_prefix,omitempty"`
+	// NotNullStrategy handles NOT NULL fields without a default added to
+	// existing tables: "allow" (default), "error" or "backfill", see
+	// WithNotNullStrategy.
+	NotNullStrategy string `json:"not_null_strategy,omitempty"`
+	// Naming holds the templates of generated index and constraint names,
+	// see NamingConvention.
+	Naming NamingConvention `json:"naming,omitempty"`
 }
 
 // SeedingConfig holds seeding-specific settings
@@ -57,6 +96,12 @@ type SeedingConfig struct {
 	DefaultRows   int    `json:"default_rows"`
 	TruncateFirst bool   `json:"truncate_first"`
 	BatchSize     int    `json:"batch_size"`
+	// EncryptionKey is a secret reference such as "env:MIGRATE_SEED_KEY" for
+	// the key of encrypted seed files. Empty means DefaultSeedKey.
+	EncryptionKey string `json:"encryption_key,omitempty"`
+	// LookupTables lists the tables the sql("SELECT ...") helper of seed
+	// expressions may read; sql() is disabled when it is empty.
+	LookupTables []string `json:"lookup_tables,omitempty"`
 }
 
 // LoggingConfig holds logging settings
@@ -66,16 +111,63 @@ type LoggingConfig struct {
 	Output  string `json:"output"`
 	Verbose bool   `json:"verbose"`
 	LogFile string `json:"log_file,omitempty"`
+	// Redact lists column name patterns whose bound values are masked in
+	// verbose statement logs. When unset DefaultRedactPatterns apply.
+	Redact []string `json:"redact,omitempty"`
+	// AuditLog is a JSON lines file recording events such as table backups.
+	AuditLog string `json:"audit_log,omitempty"`
+	// ErrorHints prints recovery suggestions for common database errors.
+	ErrorHints bool `json:"error_hints"`
 }
 
 // ValidationConfig holds validation settings
 type ValidationConfig struct {
-	Enabled            bool     `json:"enabled"`
-	StrictMode         bool     `json:"strict_mode"`
-	AllowedDataTypes   []string `json:"allowed_data_types,omitempty"`
-	ForbiddenNames     []string `json:"forbidden_names,omitempty"`
-	MaxIdentifierLen   int      `json:"max_identifier_length"`
-	RequireDescription bool     `json:"require_description"`
+	Enabled bool `json:"enabled"`
+	// StrictMode refuses reserved SQL keywords as identifiers instead of
+	// warning about them.
+	StrictMode       bool     `json:"strict_mode"`
+	AllowedDataTypes []string `json:"allowed_data_types,omitempty"`
+	ForbiddenNames   []string `json:"forbidden_names,omitempty"`
+	// MaxIdentifierLen lowers the identifier length limit of the rules
+	// below.
+	MaxIdentifierLen   int  `json:"max_identifier_length"`
+	RequireDescription bool `json:"require_description"`
+	// Identifiers replaces the identifier rules of the database driver,
+	// e.g. to allow dollar signs for Oracle compatible names.
+	Identifiers *IdentifierRules `json:"identifiers,omitempty"`
+	// RequireAuthor, RequireTicket and RequireReview refuse to apply
+	// migrations that do not declare Author, Ticket or ReviewedBy.
+	RequireAuthor bool `json:"require_author,omitempty"`
+	RequireTicket bool `json:"require_ticket,omitempty"`
+	RequireReview bool `json:"require_review,omitempty"`
+	// LargeTableRows is the estimated row count above which plan and lint
+	// warn about ALTERs that may run for a long time; 0 disables the check.
+	LargeTableRows int64 `json:"large_table_rows"`
+}
+
+// BackupConfig holds settings for dumping tables before they are dropped or
+// truncated. Backups use pg_dump or mysqldump and are not available for SQLite.
+type BackupConfig struct {
+	Enabled   bool   `json:"enabled"`
+	Directory string `json:"directory,omitempty"`
+	// Command overrides the path of the dump tool.
+	Command string `json:"command,omitempty"`
+}
+
+// SnapshotConfig holds settings for db:snapshot. Snapshots of PostgreSQL and
+// MySQL use pg_dump/pg_restore and mysqldump/mysql; SQLite snapshots copy the
+// database file.
+type SnapshotConfig struct {
+	Directory string `json:"directory,omitempty"`
+	// DumpCommand and RestoreCommand override the paths of the tools.
+	DumpCommand    string `json:"dump_command,omitempty"`
+	RestoreCommand string `json:"restore_command,omitempty"`
+}
+
+// EnvironmentConfig describes the environment the configuration targets
+type EnvironmentConfig struct {
+	Name      string `json:"name,omitempty"`
+	Protected bool   `json:"protected"`
 }
 
 // DefaultConfig returns a default configuration
@@ -95,6 +187,7 @@ func DefaultConfig() *MigrateConfig {
 			AutoRollback:   false,
 			DryRun:         false,
 			SkipValidation: false,
+			Checksum:       ChecksumRaw,
 		},
 		Seed: SeedingConfig{
 			Directory:     "migrations/seeds",
@@ -103,16 +196,24 @@ func DefaultConfig() *MigrateConfig {
 			BatchSize:     1000,
 		},
 		Logging: LoggingConfig{
-			Level:   "info",
-			Format:  "text",
-			Output:  "console",
-			Verbose: false,
+			Level:      "info",
+			Format:     "text",
+			Output:     "console",
+			Verbose:    false,
+			ErrorHints: true,
 		},
 		Validation: ValidationConfig{
 			Enabled:            true,
 			StrictMode:         false,
 			MaxIdentifierLen:   64,
 			RequireDescription: true,
+			LargeTableRows:     DefaultLargeTableRows,
+		},
+		Backup: BackupConfig{
+			Directory: "backups",
+		},
+		Snapshot: SnapshotConfig{
+			Directory: "snapshots",
 		},
 	}
 }
@@ -155,6 +256,11 @@ func LoadConfig(configPath string) (*MigrateConfig, error) {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
+	// Expand ${VAR} placeholders from the environment
+	if err := ExpandEnvPlaceholders(config); err != nil {
+		return nil, err
+	}
+
 	// Validate configuration
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
@@ -192,7 +298,13 @@ func (c *MigrateConfig) SaveConfig(configPath string) error {
 
 // Validate validates the configuration
 func (c *MigrateConfig) Validate() error {
+	return c.validate().Error()
+}
+
+// validate checks c and returns the validator holding the violations.
+func (c *MigrateConfig) validate() *Validator {
 	validator := NewValidator()
+	validator.Strict = c.Validation.StrictMode
 
 	// Validate database config
 	if c.Database.Driver == "" {
@@ -241,6 +353,30 @@ func (c *MigrateConfig) Validate() error {
 	if c.Migration.BatchSize <= 0 {
 		validator.AddError("migration.batch_size", fmt.Sprintf("%d", c.Migration.BatchSize), "batch size must be positive")
 	}
+	switch c.Migration.Checksum {
+	case "", ChecksumRaw, ChecksumNormalized:
+	default:
+		validator.AddError("migration.checksum", c.Migration.Checksum, "checksum must be raw or normalized")
+	}
+	switch c.Migration.HistoryFallback {
+	case "", HistoryFallbackFail, HistoryFallbackFile:
+	default:
+		validator.AddError("migration.history_fallback", c.Migration.HistoryFallback, "history fallback must be fail or file")
+	}
+	switch c.Migration.HistoryMirror {
+	case "", HistoryMirrorFile:
+	default:
+		validator.AddError("migration.history_mirror", c.Migration.HistoryMirror, "history mirror must be empty or file")
+	}
+	if err := validIdentifierPrefix(c.Migration.IdentifierPrefix); err != nil {
+		validator.AddError("migration.identifier_prefix", c.Migration.IdentifierPrefix, err.Error())
+	}
+	if err := validNotNullStrategy(c.Migration.NotNullStrategy); err != nil {
+		validator.AddError("migration.not_null_strategy", c.Migration.NotNullStrategy, err.Error())
+	}
+	if err := c.Migration.Naming.Validate(); err != nil {
+		validator.AddError("migration.naming", fmt.Sprintf("%+v", c.Migration.Naming), err.Error())
+	}
 
 	// Validate seed config
 	if c.Seed.Directory == "" {
@@ -292,10 +428,20 @@ func (c *MigrateConfig) Validate() error {
 		validator.AddError("logging.output", c.Logging.Output, "invalid log output")
 	}
 
+	if err := ValidateRedactPatterns(c.Logging.Redact); err != nil {
+		validator.AddError("logging.redact", strings.Join(c.Logging.Redact, ","), err.Error())
+	}
+
 	// Validate validation config
 	if c.Validation.MaxIdentifierLen <= 0 {
 		validator.AddError("validation.max_identifier_length", fmt.Sprintf("%d", c.Validation.MaxIdentifierLen), "max identifier length must be positive")
 	}
+	if c.Validation.Identifiers != nil && c.Validation.Identifiers.MaxLength < 0 {
+		validator.AddError("validation.identifiers.max_length", fmt.Sprintf("%d", c.Validation.Identifiers.MaxLength), "max length cannot be negative")
+	}
+	if c.Validation.LargeTableRows < 0 {
+		validator.AddError("validation.large_table_rows", fmt.Sprintf("%d", c.Validation.LargeTableRows), "large table rows cannot be negative")
+	}
 
 	// Validate forbidden names
 	for i, name := range c.Validation.ForbiddenNames {
@@ -304,7 +450,17 @@ func (c *MigrateConfig) Validate() error {
 		}
 	}
 
-	return validator.Error()
+	// Validate backup config
+	if c.Backup.Enabled {
+		if c.Backup.Directory == "" {
+			validator.AddError("backup.directory", c.Backup.Directory, "backup directory cannot be empty when backups are enabled")
+		}
+		if drv, err := NormalizeDriver(c.Database.Driver); err == nil && drv == DialectSQLite {
+			validator.AddError("backup.enabled", "true", "table backups require postgres or mysql")
+		}
+	}
+
+	return validator
 }
 
 // GetDSN returns the database connection string
@@ -390,6 +546,14 @@ func (c *MigrateConfig) ApplyEnvironmentOverrides() {
 	if verbose := os.Getenv("MIGRATE_VERBOSE"); verbose == "true" || verbose == "1" {
 		c.Logging.Verbose = true
 	}
+
+	if environment := os.Getenv("MIGRATE_ENVIRONMENT"); environment != "" {
+		c.Environment.Name = environment
+	}
+
+	if protected := os.Getenv("MIGRATE_PROTECTED"); protected == "true" || protected == "1" {
+		c.Environment.Protected = true
+	}
 }
 
 // parsePort parses a port string to integer
@@ -438,6 +602,7 @@ func CreateSampleConfig(configPath string) error {
 			"auto_rollback":   config.Migration.AutoRollback,
 			"dry_run":         config.Migration.DryRun,
 			"skip_validation": config.Migration.SkipValidation,
+			"checksum":        config.Migration.Checksum,
 		},
 		"seed": map[string]interface{}{
 			"_comment":       "Seed settings",
@@ -445,14 +610,16 @@ func CreateSampleConfig(configPath string) error {
 			"default_rows":   config.Seed.DefaultRows,
 			"truncate_first": config.Seed.TruncateFirst,
 			"batch_size":     config.Seed.BatchSize,
+			"encryption_key": DefaultSeedKey,
 		},
 		"logging": map[string]interface{}{
-			"_comment": "Logging settings",
-			"level":    config.Logging.Level,
-			"format":   config.Logging.Format,
-			"output":   config.Logging.Output,
-			"verbose":  config.Logging.Verbose,
-			"log_file": "/path/to/migrate.log",
+			"_comment":    "Logging settings",
+			"level":       config.Logging.Level,
+			"format":      config.Logging.Format,
+			"output":      config.Logging.Output,
+			"verbose":     config.Logging.Verbose,
+			"log_file":    "/path/to/migrate.log",
+			"error_hints": config.Logging.ErrorHints,
 		},
 		"validation": map[string]interface{}{
 			"_comment":              "Validation settings",
@@ -460,8 +627,17 @@ func CreateSampleConfig(configPath string) error {
 			"strict_mode":           config.Validation.StrictMode,
 			"max_identifier_length": config.Validation.MaxIdentifierLen,
 			"require_description":   config.Validation.RequireDescription,
+			"require_author":        config.Validation.RequireAuthor,
+			"require_ticket":        config.Validation.RequireTicket,
+			"require_review":        config.Validation.RequireReview,
+			"large_table_rows":      config.Validation.LargeTableRows,
 			"forbidden_names":       []string{"temp", "tmp", "test"},
 		},
+		"environment": map[string]interface{}{
+			"_comment":  "Protected environments require --yes-production for destructive commands",
+			"name":      "development",
+			"protected": config.Environment.Protected,
+		},
 	}
 
 	// Create directory if it doesn't exist
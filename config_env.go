@@ -0,0 +1,95 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// envPlaceholderPattern matches ${NAME} and ${NAME:-default} in config
+// values, and $${ which escapes a literal "${".
+var envPlaceholderPattern = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// ExpandEnvPlaceholders replaces ${NAME} placeholders in every string and
+// string list value of config with the environment variable NAME.
+// ${NAME:-default} uses default when NAME is unset or empty, and $${ is a
+// literal "${". A placeholder without default whose variable is unset is an
+// error naming the setting, so a missing credential fails at load time.
+func ExpandEnvPlaceholders(config *MigrateConfig) error {
+	return expandEnvPlaceholders(reflect.ValueOf(config).Elem(), "", nil)
+}
+
+// expandEnvPlaceholders expands the placeholders of the struct v. When
+// unset is not nil, a value referencing an unset variable is not an error:
+// it is left as written and its key is added to unset.
+func expandEnvPlaceholders(v reflect.Value, prefix string, unset map[string]bool) error {
+	for i := 0; i < v.NumField(); i++ {
+		name := jsonFieldName(v.Type().Field(i))
+		if name == "" {
+			continue
+		}
+		key := prefix + name
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.Struct:
+			if err := expandEnvPlaceholders(field, key+".", unset); err != nil {
+				return err
+			}
+		case reflect.String:
+			expanded, err := expandEnvValue(field.String(), key)
+			if err != nil {
+				if unset == nil {
+					return err
+				}
+				unset[key] = true
+				continue
+			}
+			field.SetString(expanded)
+		case reflect.Slice:
+			if field.Type().Elem().Kind() != reflect.String {
+				continue
+			}
+			for j := 0; j < field.Len(); j++ {
+				expanded, err := expandEnvValue(field.Index(j).String(), key)
+				if err != nil {
+					if unset == nil {
+						return err
+					}
+					unset[key] = true
+					continue
+				}
+				field.Index(j).SetString(expanded)
+			}
+		}
+	}
+	return nil
+}
+
+func expandEnvValue(value, key string) (string, error) {
+	if !strings.Contains(value, "${") {
+		return value, nil
+	}
+	var missing []string
+	expanded := envPlaceholderPattern.ReplaceAllStringFunc(value, func(match string) string {
+		if match == "$${" {
+			return "${"
+		}
+		groups := envPlaceholderPattern.FindStringSubmatch(match)
+		if env := os.Getenv(groups[1]); env != "" {
+			return env
+		}
+		if strings.Contains(match, ":-") {
+			return groups[2]
+		}
+		if _, ok := os.LookupEnv(groups[1]); !ok {
+			missing = append(missing, groups[1])
+		}
+		return ""
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("config value %s references unset environment variable %s", key, strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
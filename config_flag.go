@@ -0,0 +1,94 @@
+package migrate
+
+import (
+	"fmt"
+
+	"github.com/oarkflow/cli/contracts"
+)
+
+// configFlagCommand wraps a command with a --config/-c flag that
+// reconfigures the manager from that file before the command runs, so the
+// flag behaves the same whether the manager was built from a file, from
+// options, or from the defaults. It also adds --offline, which keeps the
+// manager from connecting to the database of that file, and the global
+// --yes and --no-input confirmation flags.
+type configFlagCommand struct {
+	contracts.Command
+	manager *Manager
+	// ownsConfig is set for commands that declare --config themselves, such
+	// as db:reset and the config commands, and read the file on their own.
+	ownsConfig bool
+}
+
+// withConfigFlag adds --config, --offline, --yes and --no-input to every
+// command.
+func withConfigFlag(m *Manager, commands []contracts.Command) []contracts.Command {
+	wrapped := make([]contracts.Command, 0, len(commands))
+	for _, cmd := range commands {
+		wrapped = append(wrapped, &configFlagCommand{Command: cmd, manager: m, ownsConfig: hasFlag(cmd.Extend(), "config")})
+	}
+	return wrapped
+}
+
+func (c *configFlagCommand) Extend() contracts.Extend {
+	extend := c.Command.Extend()
+	if !c.ownsConfig {
+		extend.Flags = append(extend.Flags, configFlagDefinition())
+	}
+	if !hasFlag(extend, "offline") {
+		extend.Flags = append(extend.Flags, offlineFlagDefinition())
+	}
+	if !hasFlag(extend, "yes") {
+		extend.Flags = append(extend.Flags, yesFlagDefinition())
+	}
+	if !hasFlag(extend, "no-input") {
+		extend.Flags = append(extend.Flags, noInputFlagDefinition())
+	}
+	return extend
+}
+
+func (c *configFlagCommand) Handle(ctx contracts.Context) error {
+	if optionEnabled(ctx, "offline") {
+		c.manager.offline = true
+	}
+	if path := ctx.Option("config"); path != "" && !c.ownsConfig {
+		if err := c.manager.UseConfigFile(path); err != nil {
+			return fmt.Errorf("--config %s: %w", path, err)
+		}
+	}
+	return c.Command.Handle(ctx)
+}
+
+func configFlagDefinition() contracts.Flag {
+	return contracts.Flag{
+		Name:    "config",
+		Aliases: []string{"c"},
+		Usage:   "Path to configuration file",
+		Value:   "",
+	}
+}
+
+func hasFlag(extend contracts.Extend, name string) bool {
+	for _, flag := range extend.Flags {
+		if flag.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// configFileOption returns the configuration file a config command works
+// on: --path, then --config, then the file the manager was built from, then
+// migrate.json.
+func configFileOption(ctx contracts.Context, driver IManager) string {
+	if path := ctx.Option("path"); path != "" {
+		return path
+	}
+	if path := ctx.Option("config"); path != "" {
+		return path
+	}
+	if mgr, ok := driver.(*Manager); ok && mgr.ConfigPath() != "" {
+		return mgr.ConfigPath()
+	}
+	return "migrate.json"
+}
@@ -0,0 +1,189 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/oarkflow/json"
+)
+
+// ConfigKeys lists every configuration key as a dotted path of JSON names,
+// such as "seed.batch_size", in declaration order.
+func ConfigKeys() []string {
+	var keys []string
+	var walk func(t reflect.Type, prefix string)
+	walk = func(t reflect.Type, prefix string) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := jsonFieldName(field)
+			if name == "" {
+				continue
+			}
+			if field.Type.Kind() == reflect.Struct {
+				walk(field.Type, prefix+name+".")
+				continue
+			}
+			keys = append(keys, prefix+name)
+		}
+	}
+	walk(reflect.TypeOf(MigrateConfig{}), "")
+	return keys
+}
+
+// GetConfigValue returns the value of key in config formatted as config:set
+// accepts it. Lists are JSON arrays, since items such as session statements
+// may contain commas.
+func GetConfigValue(config *MigrateConfig, key string) (string, error) {
+	field, err := configField(config, key)
+	if err != nil {
+		return "", err
+	}
+	if field.Kind() == reflect.Slice {
+		items := field.Interface().([]string)
+		if items == nil {
+			items = []string{}
+		}
+		data, err := json.Marshal(items)
+		return string(data), err
+	}
+	return fmt.Sprint(field.Interface()), nil
+}
+
+// SetConfigValues sets each key of values to its value in the JSON
+// configuration file at configPath. Values are converted to the type of
+// their setting and the resulting configuration must validate; otherwise the
+// file is left untouched. Settings holding a ${NAME} placeholder whose
+// variable is unset here are not validated, since they are only expanded
+// where migrate runs. Settings not named, including unknown ones, are kept.
+func SetConfigValues(configPath string, values map[string]string) error {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse config file: %w", err)
+	}
+	config := DefaultConfig()
+	if err := json.Unmarshal(data, config); err != nil {
+		return fmt.Errorf("failed to parse config file: %w", err)
+	}
+	for key, value := range values {
+		field, err := configField(config, key)
+		if err != nil {
+			return err
+		}
+		parsed, err := parseConfigValue(field.Type(), value)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+		field.Set(reflect.ValueOf(parsed).Convert(field.Type()))
+
+		parts := strings.Split(key, ".")
+		section := doc
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := section[part].(map[string]any)
+			if !ok {
+				next = make(map[string]any)
+				section[part] = next
+			}
+			section = next
+		}
+		section[parts[len(parts)-1]] = parsed
+	}
+	// The file keeps its placeholders; they are only expanded to validate.
+	unset := make(map[string]bool)
+	if err := expandEnvPlaceholders(reflect.ValueOf(config).Elem(), "", unset); err != nil {
+		return err
+	}
+	validator := config.validate()
+	checked := NewValidator()
+	for _, verr := range validator.Errors() {
+		field, _, _ := strings.Cut(verr.Field, "[")
+		if !unset[field] {
+			checked.AddError(verr.Field, verr.Value, verr.Message)
+		}
+	}
+	if err := checked.Error(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(configPath); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.WriteFile(configPath, out, mode); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// configField returns the settable field of config named by key.
+func configField(config *MigrateConfig, key string) (reflect.Value, error) {
+	v := reflect.ValueOf(config).Elem()
+	for _, part := range strings.Split(key, ".") {
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("unknown configuration key %q", key)
+		}
+		found := false
+		for i := 0; i < v.NumField(); i++ {
+			if jsonFieldName(v.Type().Field(i)) == part {
+				v, found = v.Field(i), true
+				break
+			}
+		}
+		if !found {
+			return reflect.Value{}, fmt.Errorf("unknown configuration key %q", key)
+		}
+	}
+	if v.Kind() == reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("%s is a section; name one of its settings, such as %s.%s", key, key, jsonFieldName(v.Type().Field(0)))
+	}
+	return v, nil
+}
+
+// parseConfigValue converts value to a setting of type t. Lists are given
+// comma-separated or as a JSON array.
+func parseConfigValue(t reflect.Type, value string) (any, error) {
+	switch t.Kind() {
+	case reflect.String:
+		return value, nil
+	case reflect.Bool:
+		return strconv.ParseBool(value)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", value)
+		}
+		return n, nil
+	case reflect.Slice:
+		items := []string{}
+		if strings.HasPrefix(strings.TrimSpace(value), "[") {
+			if err := json.Unmarshal([]byte(value), &items); err != nil {
+				return nil, fmt.Errorf("%q is not a JSON array of strings", value)
+			}
+			return items, nil
+		}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unsupported setting type %s", t)
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
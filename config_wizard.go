@@ -0,0 +1,205 @@
+package migrate
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// configWizard asks for the settings of a new configuration file.
+type configWizard struct {
+	in  *bufio.Reader
+	out io.Writer
+	// testConnection reports whether the configured database is reachable.
+	testConnection func(config *MigrateConfig) error
+}
+
+// runConfigWizard prompts on out for the database connection and the
+// migration and seed directories, reading answers from in. Empty answers keep
+// the default shown in brackets. When the user agrees the connection is
+// tested with testConnection, and a failed test offers to change the database
+// settings. The returned configuration is valid.
+func runConfigWizard(in io.Reader, out io.Writer, testConnection func(config *MigrateConfig) error) (*MigrateConfig, error) {
+	w := &configWizard{in: bufio.NewReader(in), out: out, testConnection: testConnection}
+	config := DefaultConfig()
+	fmt.Fprintln(out, "This wizard writes a migration configuration file. Press Enter to keep the value in brackets.")
+	for {
+		if err := w.askDatabase(&config.Database); err != nil {
+			return nil, err
+		}
+		test, err := w.confirm("Test the database connection now?", true)
+		if err != nil {
+			return nil, err
+		}
+		if !test {
+			break
+		}
+		if err = w.test(config); err == nil {
+			fmt.Fprintln(out, "Connection succeeded.")
+			break
+		}
+		fmt.Fprintf(out, "Connection failed: %v\n", err)
+		retry, err := w.confirm("Change the database settings?", true)
+		if err != nil {
+			return nil, err
+		}
+		if !retry {
+			break
+		}
+	}
+	var err error
+	if config.Migration.Directory, err = w.askRequired("Migration directory", config.Migration.Directory); err != nil {
+		return nil, err
+	}
+	if config.Seed.Directory, err = w.askRequired("Seed directory", config.Seed.Directory); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return config, nil
+}
+
+func (w *configWizard) askDatabase(db *DatabaseConfig) error {
+	for {
+		answer, err := w.askRequired("Database driver (postgres, mysql, sqlite)", db.Driver)
+		if err != nil {
+			return err
+		}
+		driver, err := NormalizeDriver(answer)
+		if err != nil {
+			fmt.Fprintf(w.out, "  %v\n", err)
+			continue
+		}
+		if driver != db.Driver {
+			db.Driver = driver
+			db.Port = defaultDriverPort(driver)
+		}
+		break
+	}
+	var err error
+	if db.Driver == "sqlite" {
+		db.Host, db.Port, db.Username, db.Password = "", 0, "", ""
+		if db.Database == "" {
+			db.Database = "migrate.db"
+		}
+		db.Database, err = w.askRequired("Database file", db.Database)
+		return err
+	}
+	if db.Host == "" {
+		db.Host = "localhost"
+	}
+	if db.Host, err = w.askRequired("Host", db.Host); err != nil {
+		return err
+	}
+	for {
+		answer, err := w.askRequired("Port", strconv.Itoa(db.Port))
+		if err != nil {
+			return err
+		}
+		port, err := strconv.Atoi(answer)
+		if err != nil || port <= 0 || port > 65535 {
+			fmt.Fprintf(w.out, "  %q is not a valid port\n", answer)
+			continue
+		}
+		db.Port = port
+		break
+	}
+	if db.Username, err = w.ask("Username", db.Username); err != nil {
+		return err
+	}
+	if db.Password, err = w.ask("Password (${NAME} reads it from the environment)", db.Password); err != nil {
+		return err
+	}
+	db.Database, err = w.askRequired("Database name", db.Database)
+	return err
+}
+
+// test runs testConnection against config with its environment placeholders
+// expanded, leaving config itself unchanged.
+func (w *configWizard) test(config *MigrateConfig) error {
+	expanded := *config
+	if err := ExpandEnvPlaceholders(&expanded); err != nil {
+		return err
+	}
+	return w.testConnection(&expanded)
+}
+
+// ask prints label with def and returns the answer, or def when the answer
+// is empty.
+func (w *configWizard) ask(label, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(w.out, "%s [%s]: ", label, def)
+	} else {
+		fmt.Fprintf(w.out, "%s: ", label)
+	}
+	line, err := w.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		if err == io.EOF {
+			return "", errors.New("input ended before the configuration was complete")
+		}
+		return "", err
+	}
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer, nil
+	}
+	return def, nil
+}
+
+// askRequired is ask, repeated until the answer is not empty.
+func (w *configWizard) askRequired(label, def string) (string, error) {
+	for {
+		answer, err := w.ask(label, def)
+		if err != nil || answer != "" {
+			return answer, err
+		}
+		fmt.Fprintln(w.out, "  a value is required")
+	}
+}
+
+func (w *configWizard) confirm(question string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	for {
+		answer, err := w.ask(question+" ("+hint+")", "")
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		fmt.Fprintln(w.out, "  please answer y or n")
+	}
+}
+
+// defaultDriverPort returns the usual server port of driver, or 0 for
+// SQLite.
+func defaultDriverPort(driver string) int {
+	switch driver {
+	case "postgres":
+		return 5432
+	case "mysql":
+		return 3306
+	}
+	return 0
+}
+
+// testConfigConnection opens and closes a connection to the database of
+// config.
+func testConfigConnection(config *MigrateConfig) error {
+	driver, err := NewDriver(config.Database.Driver, config.GetDSN())
+	if err != nil {
+		return err
+	}
+	return driver.DB().Close()
+}
@@ -0,0 +1,183 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrDependentObjects is returned when a table is dropped while views or
+// foreign keys still depend on it.
+var ErrDependentObjects = errors.New("table has dependent objects")
+
+// DependentObject is a database object that depends on a table.
+type DependentObject struct {
+	// Kind is "view", "foreign_key" or "trigger".
+	Kind string `db:"kind"`
+	// Name is empty for SQLite foreign keys, which are unnamed.
+	Name string `db:"name"`
+	// Table is the table holding a foreign key or trigger.
+	Table      string `db:"tbl"`
+	Definition string `db:"definition"`
+}
+
+func (o DependentObject) String() string {
+	switch o.Kind {
+	case "foreign_key":
+		if o.Name == "" {
+			return "foreign key from " + o.Table
+		}
+		return fmt.Sprintf("foreign key %s from %s", o.Name, o.Table)
+	default:
+		return strings.ReplaceAll(o.Kind, "_", " ") + " " + o.Name
+	}
+}
+
+// DependentObjects queries the catalog for the views, foreign keys and
+// triggers that depend on table.
+func (d *Manager) DependentObjects(table string) ([]DependentObject, error) {
+	if d.dbDriver == nil {
+		return nil, fmt.Errorf("no database driver configured")
+	}
+	dial, err := GetDialect(d.dialect)
+	if err != nil {
+		return nil, err
+	}
+	var objects []DependentObject
+	if err := d.dbDriver.Query(context.Background(), &objects, dial.DependentObjectsSQL(table)); err != nil {
+		return nil, fmt.Errorf("failed to list objects depending on %s: %w", table, err)
+	}
+	// Views found by their definition text must name the table as a whole word.
+	word := regexp.MustCompile(`(?i)(^|[^a-z0-9_])` + regexp.QuoteMeta(table) + `($|[^a-z0-9_])`)
+	kept := objects[:0]
+	for _, o := range objects {
+		if o.Kind == "view" && o.Definition != "" && !word.MatchString(o.Definition) {
+			continue
+		}
+		kept = append(kept, o)
+	}
+	return kept, nil
+}
+
+// checkDependencies looks up the objects depending on every table that the
+// Up (or Down) block of m renames or drops, skipping objects the block drops
+// itself. Renames and cascading drops are reported as warnings. A plain
+// DropTable with dependent views or foreign keys fails with
+// ErrDependentObjects, unless Force is set; with CascadeDependencies the
+// dependent views are dropped first by the returned statements instead.
+func (d *Manager) checkDependencies(m Migration, dialect string, up bool) ([]Statement, error) {
+	if d.dbDriver == nil || m.Connection != "" {
+		return nil, nil
+	}
+	op := m.Down
+	if up {
+		op = m.Up
+	}
+	if len(op.DropTable) == 0 && len(op.RenameTable) == 0 {
+		return nil, nil
+	}
+	droppedTables := make(map[string]bool, len(op.DropTable))
+	for _, dt := range op.DropTable {
+		droppedTables[dt.Name] = true
+	}
+	droppedViews := make(map[string]bool, len(op.DropView))
+	for _, dv := range op.DropView {
+		droppedViews[dv.Name] = true
+	}
+	// outside returns the views and foreign keys that survive op.
+	outside := func(table string) ([]DependentObject, error) {
+		objects, err := d.DependentObjects(table)
+		if err != nil {
+			return nil, err
+		}
+		var deps []DependentObject
+		for _, o := range objects {
+			switch {
+			case o.Kind == "view" && droppedViews[o.Name]:
+			case o.Kind == "foreign_key" && droppedTables[o.Table]:
+			default:
+				deps = append(deps, o)
+			}
+		}
+		return deps, nil
+	}
+	for _, rt := range op.RenameTable {
+		deps, err := outside(rt.OldName)
+		if err != nil {
+			return nil, err
+		}
+		if len(deps) > 0 {
+			logger.Warn().Msgf("Renaming table %s to %s: check that these still resolve: %s", rt.OldName, rt.NewName, joinObjects(deps))
+		}
+	}
+	var cascade []Statement
+	for _, dt := range op.DropTable {
+		deps, err := outside(dt.Name)
+		if err != nil {
+			return nil, err
+		}
+		var views, blocking []DependentObject
+		for _, o := range deps {
+			if o.Kind == "trigger" {
+				continue // dropped together with the table
+			}
+			blocking = append(blocking, o)
+			if o.Kind == "view" {
+				views = append(views, o)
+			}
+		}
+		if len(blocking) == 0 {
+			continue
+		}
+		if dt.Cascade {
+			logger.Warn().Msgf("Dropping table %s with cascade also drops: %s", dt.Name, joinObjects(blocking))
+			continue
+		}
+		if d.CascadeDependencies && len(views) == len(blocking) {
+			for _, v := range views {
+				q, err := DropView{Name: v.Name, IfExists: true}.ToSQL(dialect)
+				if err != nil {
+					return nil, err
+				}
+				cascade = append(cascade, Statement{SQL: q})
+			}
+			logger.Warn().Msgf("Dropping table %s: dropping dependent %s first", dt.Name, joinObjects(views))
+			continue
+		}
+		err = fmt.Errorf("%w: %s is used by %s; %s", ErrDependentObjects, dt.Name, joinObjects(blocking), cascadeHint(views, len(views) < len(blocking)))
+		if !d.Force {
+			return nil, err
+		}
+		logger.Warn().Msgf("[force] %v", err)
+	}
+	return cascade, nil
+}
+
+func joinObjects(objects []DependentObject) string {
+	names := make([]string, len(objects))
+	for i, o := range objects {
+		names[i] = o.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// cascadeHint suggests the operations that resolve the dependencies.
+func cascadeHint(views []DependentObject, foreignKeys bool) string {
+	var steps []string
+	for _, v := range views {
+		steps = append(steps, fmt.Sprintf(`DropView "%s" {}`, v.Name))
+	}
+	hint := "set Cascade = true"
+	if len(steps) > 0 {
+		hint = fmt.Sprintf("add %s before the DropTable, %s", strings.Join(steps, ", "), hint)
+		if !foreignKeys {
+			hint += " or run with --cascade-dependencies=true"
+		}
+	}
+	if foreignKeys {
+		hint += ", or drop the referencing tables or foreign keys first"
+	}
+	return hint
+}
@@ -1,5 +1,11 @@
 package migrate
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
 type Dialect interface {
 	CreateTableSQL(ct CreateTable, up bool) (string, error)
 	RenameTableSQL(rt RenameTable) (string, error)
@@ -12,6 +18,9 @@ type Dialect interface {
 	AddFieldSQL(ac AddField, tableName string) ([]string, error)
 	DropFieldSQL(dc DropField, tableName string) (string, error)
 	RenameFieldSQL(rc RenameField, tableName string) (string, error)
+	SetNotNullSQL(ac AddField, tableName string) (string, error)
+	AddPrimaryKeySQL(pk AddPrimaryKey, tableName string) (string, error)
+	DropPrimaryKeySQL(pk DropPrimaryKey, tableName string) (string, error)
 	MapDataType(genericType string, size, scale int, autoIncrement bool) string
 	CreateViewSQL(cv CreateView) (string, error)
 	DropViewSQL(dv DropView) (string, error)
@@ -25,13 +34,39 @@ type Dialect interface {
 	CreateTriggerSQL(ct CreateTrigger) (string, error)
 	DropTriggerSQL(dt DropTrigger) (string, error)
 	RenameTriggerSQL(rt RenameTrigger) (string, error)
+	RebuildIndexSQL(ri RebuildIndex) (string, error)
+	ReindexTableSQL(rt ReindexTable) (string, error)
+	CreatePublicationSQL(cp CreatePublication) (string, error)
+	AlterPublicationSQL(ap AlterPublication) (string, error)
+	DropPublicationSQL(dp DropPublication) (string, error)
+	CreateRoleSQL(cr CreateRole) (string, error)
+	AlterRoleSQL(ar AlterRole) (string, error)
+	DropRoleSQL(dr DropRole) (string, error)
+	CreateDatabaseSQL(cd CreateDatabase) (string, error)
+	DropDatabaseSQL(dd DropDatabase) (string, error)
+	VacuumSQL(v Vacuum) (string, error)
+	AnalyzeSQL(a Analyze) (string, error)
 	WrapInTransaction(queries []string) []string
 	WrapInTransactionWithConfig(queries []string, trans Transaction) []string
 	InsertSQL(table string, fields []string, values []any) (string, map[string]any, error)
 	TableExistsSQL(table string) string
+	ColumnExistsSQL(table, column string) string
+	IndexExistsSQL(table, index string) string
+	TableRowsEstimateSQL(table string) string
+	DependentObjectsSQL(table string) string
+	SchemaSnapshotSQL() string
 	EOS() string
 }
 
+// columnType returns the SQL type of f on d: its RawType as written, or its
+// Type mapped by d.
+func columnType(d Dialect, f AddField) string {
+	if f.RawType != "" {
+		return f.RawType
+	}
+	return d.MapDataType(f.Type, f.Size, f.Scale, f.AutoIncrement)
+}
+
 var dialectRegistry = map[string]Dialect{}
 
 func init() {
@@ -44,9 +79,35 @@ func AddDialect(name string, dialect Dialect) {
 	dialectRegistry[name] = dialect
 }
 
-func GetDialect(name string) Dialect {
+// ErrUnknownDialect is returned by GetDialect for names that are neither
+// registered nor an alias of a built-in dialect.
+var ErrUnknownDialect = errors.New("unknown dialect")
+
+// GetDialect returns the dialect registered under name. Driver aliases such
+// as "mariadb" or "postgresql" resolve to the built-in dialect they share.
+func GetDialect(name string) (Dialect, error) {
 	if d, ok := dialectRegistry[name]; ok {
-		return d
+		return d, nil
+	}
+	if normalized, err := NormalizeDriver(name); err == nil {
+		if d, ok := dialectRegistry[normalized]; ok {
+			return d, nil
+		}
 	}
-	return dialectRegistry[DialectPostgres]
+	return nil, fmt.Errorf("%w: %q", ErrUnknownDialect, name)
+}
+
+// MustGetDialect is like GetDialect but panics when name is unknown. It is
+// meant for dialect names fixed at compile time.
+func MustGetDialect(name string) Dialect {
+	d, err := GetDialect(name)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// quoteStringLiteral quotes s as an SQL string literal.
+func quoteStringLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
 }
@@ -8,14 +8,76 @@ import (
 
 type MySQLDialect struct{}
 
+// quoteIdentifier quotes id, doubling the backticks it contains.
 func (m *MySQLDialect) quoteIdentifier(id string) string {
-	return fmt.Sprintf("`%s`", id)
+	return "`" + strings.ReplaceAll(id, "`", "``") + "`"
 }
 
 func (m *MySQLDialect) TableExistsSQL(table string) string {
 	return fmt.Sprintf(`SELECT COUNT(*) > 0 FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = '%s'`, table)
 }
 
+func (m *MySQLDialect) ColumnExistsSQL(table, column string) string {
+	return fmt.Sprintf(`SELECT COUNT(*) > 0 FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = '%s' AND column_name = '%s'`, table, column)
+}
+
+func (m *MySQLDialect) IndexExistsSQL(table, index string) string {
+	return fmt.Sprintf(`SELECT COUNT(*) > 0 FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = '%s' AND index_name = '%s'`, table, index)
+}
+
+// TableRowsEstimateSQL reads the InnoDB row estimate kept in the table
+// statistics.
+func (m *MySQLDialect) TableRowsEstimateSQL(table string) string {
+	return fmt.Sprintf(`SELECT CAST(COALESCE(SUM(table_rows), 0) AS SIGNED) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = '%s'`, table)
+}
+
+// DependentObjectsSQL lists the views, foreign keys and triggers that
+// depend on table. Views are matched on their definition text.
+func (m *MySQLDialect) DependentObjectsSQL(table string) string {
+	return fmt.Sprintf(`SELECT 'view' AS kind, table_name AS name, '' AS tbl, view_definition AS definition
+FROM information_schema.views
+WHERE table_schema = DATABASE() AND view_definition LIKE '%%%[1]s%%'
+UNION ALL
+SELECT 'foreign_key', constraint_name, table_name, ''
+FROM information_schema.referential_constraints
+WHERE constraint_schema = DATABASE() AND referenced_table_name = '%[1]s' AND table_name <> '%[1]s'
+UNION ALL
+SELECT 'trigger', trigger_name, event_object_table, ''
+FROM information_schema.triggers
+WHERE trigger_schema = DATABASE() AND event_object_table = '%[1]s'`, table)
+}
+
+// SchemaSnapshotSQL lists the columns, indexes, foreign keys, views, triggers
+// and routines of the current database.
+func (m *MySQLDialect) SchemaSnapshotSQL() string {
+	return `SELECT 'column' AS kind, CONCAT(table_name, '.', column_name) AS name, table_name AS tbl,
+	CONCAT(column_type, IF(is_nullable = 'NO', ' NOT NULL', ''), IFNULL(CONCAT(' DEFAULT ', column_default), '')) AS definition
+FROM information_schema.columns
+WHERE table_schema = DATABASE()
+UNION ALL
+SELECT 'index', CONCAT(table_name, '.', index_name), table_name, GROUP_CONCAT(column_name ORDER BY seq_in_index)
+FROM information_schema.statistics
+WHERE table_schema = DATABASE()
+GROUP BY table_name, index_name
+UNION ALL
+SELECT 'foreign_key', constraint_name, table_name, referenced_table_name
+FROM information_schema.referential_constraints
+WHERE constraint_schema = DATABASE()
+UNION ALL
+SELECT 'view', table_name, table_name, view_definition
+FROM information_schema.views
+WHERE table_schema = DATABASE()
+UNION ALL
+SELECT 'trigger', trigger_name, event_object_table, action_statement
+FROM information_schema.triggers
+WHERE trigger_schema = DATABASE()
+UNION ALL
+SELECT 'routine', routine_name, '', routine_type
+FROM information_schema.routines
+WHERE routine_schema = DATABASE()
+ORDER BY 1, 2`
+}
+
 func (m *MySQLDialect) CreateTableSQL(ct CreateTable, up bool) (string, error) {
 	if err := requireFields(ct.Name); err != nil {
 		return "", fmt.Errorf("MySQLDialect.CreateTableSQL: %w", err)
@@ -26,7 +88,7 @@ func (m *MySQLDialect) CreateTableSQL(ct CreateTable, up bool) (string, error) {
 		var cols []string
 		var pkCols []string
 		for _, col := range ct.AddFields {
-			colDef := fmt.Sprintf("%s %s", m.quoteIdentifier(col.Name), m.MapDataType(col.Type, col.Size, col.Scale, col.AutoIncrement))
+			colDef := fmt.Sprintf("%s %s", m.quoteIdentifier(col.Name), columnType(m, col))
 			if col.AutoIncrement {
 				colDef += " AUTO_INCREMENT"
 			}
@@ -65,9 +127,9 @@ func (m *MySQLDialect) CreateTableSQL(ct CreateTable, up bool) (string, error) {
 		var extra []string
 		for _, col := range ct.AddFields {
 			if col.Unique {
-				extra = append(extra, fmt.Sprintf("CREATE UNIQUE INDEX uniq_%s_%s ON %s (%s);", ct.Name, col.Name, m.quoteIdentifier(ct.Name), m.quoteIdentifier(col.Name)))
+				extra = append(extra, fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s (%s);", m.quoteIdentifier(uniqueIndexName(DialectMySQL, ct.Name, col)), m.quoteIdentifier(ct.Name), m.quoteIdentifier(col.Name)))
 			} else if col.Index {
-				extra = append(extra, fmt.Sprintf("CREATE INDEX idx_%s_%s ON %s (%s);", ct.Name, col.Name, m.quoteIdentifier(ct.Name), m.quoteIdentifier(col.Name)))
+				extra = append(extra, fmt.Sprintf("CREATE INDEX %s ON %s (%s);", m.quoteIdentifier(indexName(DialectMySQL, ct.Name, col)), m.quoteIdentifier(ct.Name), m.quoteIdentifier(col.Name)))
 			}
 		}
 		if len(extra) > 0 {
@@ -116,7 +178,7 @@ func (m *MySQLDialect) AddFieldSQL(ac AddField, tableName string) ([]string, err
 	var queries []string
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s ", m.quoteIdentifier(tableName), m.quoteIdentifier(ac.Name)))
-	sb.WriteString(m.MapDataType(ac.Type, ac.Size, ac.Scale, ac.AutoIncrement))
+	sb.WriteString(columnType(m, ac))
 	if ac.AutoIncrement {
 		sb.WriteString(" AUTO_INCREMENT")
 	}
@@ -139,14 +201,16 @@ func (m *MySQLDialect) AddFieldSQL(ac AddField, tableName string) ([]string, err
 	sb.WriteString(";")
 	queries = append(queries, sb.String())
 	if ac.Unique {
-		queries = append(queries, fmt.Sprintf("CREATE UNIQUE INDEX uniq_%s_%s ON %s (%s);", tableName, ac.Name, tableName, ac.Name))
+		queries = append(queries, fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s (%s);", m.quoteIdentifier(uniqueIndexName(DialectMySQL, tableName, ac)), m.quoteIdentifier(tableName), m.quoteIdentifier(ac.Name)))
 	}
 	if ac.Index {
-		queries = append(queries, fmt.Sprintf("CREATE INDEX idx_%s_%s ON %s (%s);", tableName, ac.Name, tableName, ac.Name))
+		queries = append(queries, fmt.Sprintf("CREATE INDEX %s ON %s (%s);", m.quoteIdentifier(indexName(DialectMySQL, tableName, ac)), m.quoteIdentifier(tableName), m.quoteIdentifier(ac.Name)))
 	}
 	if ac.ForeignKey != nil {
 		fk := ac.ForeignKey
-		sql := fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT fk_%s FOREIGN KEY (%s) REFERENCES %s(%s)", tableName, ac.Name, ac.Name, fk.ReferenceTable, fk.ReferenceField)
+		sql := fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
+			m.quoteIdentifier(tableName), m.quoteIdentifier(foreignKeyName(DialectMySQL, tableName, ac)), m.quoteIdentifier(ac.Name),
+			m.quoteIdentifier(fk.ReferenceTable), m.quoteIdentifier(fk.ReferenceField))
 		if fk.OnDelete != "" {
 			sql += fmt.Sprintf(" ON DELETE %s", fk.OnDelete)
 		}
@@ -158,6 +222,26 @@ func (m *MySQLDialect) AddFieldSQL(ac AddField, tableName string) ([]string, err
 	return queries, nil
 }
 
+func (m *MySQLDialect) SetNotNullSQL(ac AddField, tableName string) (string, error) {
+	if err := requireFields(ac.Name, tableName); err != nil {
+		return "", fmt.Errorf("MySQLDialect.SetNotNullSQL: %w", err)
+	}
+	return fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s NOT NULL;", m.quoteIdentifier(tableName), m.quoteIdentifier(ac.Name), columnType(m, ac)), nil
+}
+
+// AddPrimaryKeySQL ignores pk.Name: MySQL always names primary keys PRIMARY.
+func (m *MySQLDialect) AddPrimaryKeySQL(pk AddPrimaryKey, tableName string) (string, error) {
+	cols := make([]string, len(pk.Fields))
+	for i, f := range pk.Fields {
+		cols[i] = m.quoteIdentifier(f)
+	}
+	return fmt.Sprintf("ALTER TABLE %s ADD PRIMARY KEY (%s);", m.quoteIdentifier(tableName), strings.Join(cols, ", ")), nil
+}
+
+func (m *MySQLDialect) DropPrimaryKeySQL(pk DropPrimaryKey, tableName string) (string, error) {
+	return fmt.Sprintf("ALTER TABLE %s DROP PRIMARY KEY;", m.quoteIdentifier(tableName)), nil
+}
+
 func (m *MySQLDialect) DropFieldSQL(dc DropField, tableName string) (string, error) {
 	if err := requireFields(dc.Name, tableName); err != nil {
 		return "", fmt.Errorf("MySQLDialect.DropFieldSQL: %w", err)
@@ -182,7 +266,133 @@ func (m *MySQLDialect) RenameFieldSQL(rc RenameField, tableName string) (string,
 	if rc.To == "" {
 		return "", errors.New("MySQL requires new field name for renaming field")
 	}
-	return fmt.Sprintf("ALTER TABLE %s CHANGE %s %s %s;", m.quoteIdentifier(tableName), m.quoteIdentifier(from), m.quoteIdentifier(rc.To), rc.Type), nil
+	return fmt.Sprintf("ALTER TABLE %s CHANGE %s %s %s;", m.quoteIdentifier(tableName), m.quoteIdentifier(from), m.quoteIdentifier(rc.To), m.MapDataType(rc.Type, 0, 0, false)), nil
+}
+
+// RebuildIndexSQL rebuilds the table holding the index; InnoDB cannot
+// rebuild a single index in place.
+func (m *MySQLDialect) RebuildIndexSQL(ri RebuildIndex) (string, error) {
+	if ri.Table == "" {
+		return "", fmt.Errorf("MySQL rebuilds index %s with OPTIMIZE TABLE; set table", ri.Name)
+	}
+	return fmt.Sprintf("OPTIMIZE TABLE %s;", m.quoteIdentifier(ri.Table)), nil
+}
+
+func (m *MySQLDialect) ReindexTableSQL(rt ReindexTable) (string, error) {
+	return fmt.Sprintf("OPTIMIZE TABLE %s;", m.quoteIdentifier(rt.Name)), nil
+}
+
+func (m *MySQLDialect) CreatePublicationSQL(cp CreatePublication) (string, error) {
+	return "", errors.New("publications are not supported in MySQL")
+}
+
+func (m *MySQLDialect) AlterPublicationSQL(ap AlterPublication) (string, error) {
+	return "", errors.New("publications are not supported in MySQL")
+}
+
+func (m *MySQLDialect) DropPublicationSQL(dp DropPublication) (string, error) {
+	return "", errors.New("publications are not supported in MySQL")
+}
+
+// mysqlString quotes s as a string literal, escaping backslashes as well.
+func mysqlString(s string) string {
+	return quoteStringLiteral(strings.ReplaceAll(s, `\`, `\\`))
+}
+
+// mysqlAccount quotes name as an account that may connect from any host.
+func mysqlAccount(name string) string {
+	return mysqlString(name) + "@'%'"
+}
+
+// CreateRoleSQL creates a user when Login is set and a role otherwise.
+// Options are not supported.
+func (m *MySQLDialect) CreateRoleSQL(cr CreateRole) (string, error) {
+	if len(cr.Options) > 0 {
+		return "", errors.New("role options are not supported in MySQL")
+	}
+	var q string
+	if cr.Login {
+		q = "CREATE USER " + mysqlAccount(cr.Name)
+		if cr.Password != "" {
+			q += " IDENTIFIED BY " + mysqlString(cr.Password)
+		}
+		q += ";"
+	} else {
+		if cr.Password != "" {
+			return "", errors.New("a password requires login in MySQL")
+		}
+		q = fmt.Sprintf("CREATE ROLE %s;", mysqlString(cr.Name))
+	}
+	for _, r := range cr.InRoles {
+		q += fmt.Sprintf(" GRANT %s TO %s;", mysqlString(r), mysqlAccount(cr.Name))
+	}
+	return q, nil
+}
+
+func (m *MySQLDialect) AlterRoleSQL(ar AlterRole) (string, error) {
+	if len(ar.Options) > 0 {
+		return "", errors.New("role options are not supported in MySQL")
+	}
+	var stmts []string
+	if ar.Password != "" {
+		stmts = append(stmts, fmt.Sprintf("ALTER USER %s IDENTIFIED BY %s;", mysqlAccount(ar.Name), mysqlString(ar.Password)))
+	}
+	if ar.RenameTo != "" {
+		stmts = append(stmts, fmt.Sprintf("RENAME USER %s TO %s;", mysqlAccount(ar.Name), mysqlAccount(ar.RenameTo)))
+	}
+	return strings.Join(stmts, " "), nil
+}
+
+// DropRoleSQL uses DROP USER, which drops roles as well.
+func (m *MySQLDialect) DropRoleSQL(dr DropRole) (string, error) {
+	if dr.IfExists {
+		return fmt.Sprintf("DROP USER IF EXISTS %s;", mysqlAccount(dr.Name)), nil
+	}
+	return fmt.Sprintf("DROP USER %s;", mysqlAccount(dr.Name)), nil
+}
+
+func (m *MySQLDialect) CreateDatabaseSQL(cd CreateDatabase) (string, error) {
+	if cd.Owner != "" || cd.Template != "" {
+		return "", errors.New("database owner and template are not supported in MySQL")
+	}
+	q := "CREATE DATABASE "
+	if cd.IfNotExists {
+		q += "IF NOT EXISTS "
+	}
+	q += m.quoteIdentifier(cd.Name)
+	if cd.Encoding != "" {
+		q += " CHARACTER SET " + cd.Encoding
+	}
+	if cd.Collation != "" {
+		q += " COLLATE " + cd.Collation
+	}
+	return q + ";", nil
+}
+
+func (m *MySQLDialect) DropDatabaseSQL(dd DropDatabase) (string, error) {
+	if dd.Force {
+		return "", errors.New("DROP DATABASE WITH (FORCE) is not supported in MySQL")
+	}
+	if dd.IfExists {
+		return fmt.Sprintf("DROP DATABASE IF EXISTS %s;", m.quoteIdentifier(dd.Name)), nil
+	}
+	return fmt.Sprintf("DROP DATABASE %s;", m.quoteIdentifier(dd.Name)), nil
+}
+
+// VacuumSQL uses OPTIMIZE TABLE, which reclaims space and refreshes the
+// statistics of InnoDB tables.
+func (m *MySQLDialect) VacuumSQL(v Vacuum) (string, error) {
+	if v.Name == "" {
+		return "", errors.New("MySQL requires a table name for Vacuum")
+	}
+	return fmt.Sprintf("OPTIMIZE TABLE %s;", m.quoteIdentifier(v.Name)), nil
+}
+
+func (m *MySQLDialect) AnalyzeSQL(a Analyze) (string, error) {
+	if a.Name == "" {
+		return "", errors.New("MySQL requires a table name for Analyze")
+	}
+	return fmt.Sprintf("ANALYZE TABLE %s;", m.quoteIdentifier(a.Name)), nil
 }
 
 func (m *MySQLDialect) MapDataType(genericType string, size, scale int, autoIncrement bool) string {
@@ -9,14 +9,81 @@ import (
 
 type PostgresDialect struct{}
 
+// quoteIdentifier quotes id, doubling the double quotes it contains.
 func (p *PostgresDialect) quoteIdentifier(id string) string {
-	return fmt.Sprintf("\"%s\"", id)
+	return `"` + strings.ReplaceAll(id, `"`, `""`) + `"`
 }
 
 func (p *PostgresDialect) TableExistsSQL(table string) string {
 	return fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM pg_catalog.pg_tables WHERE schemaname = 'public' AND tablename = '%s')`, table)
 }
 
+func (p *PostgresDialect) ColumnExistsSQL(table, column string) string {
+	return fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_schema = 'public' AND table_name = '%s' AND column_name = '%s')`, table, column)
+}
+
+func (p *PostgresDialect) IndexExistsSQL(table, index string) string {
+	return fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM pg_catalog.pg_indexes WHERE schemaname = 'public' AND tablename = '%s' AND indexname = '%s')`, table, index)
+}
+
+// TableRowsEstimateSQL reads the planner's row estimate, which is -1 for
+// tables that were never analyzed.
+func (p *PostgresDialect) TableRowsEstimateSQL(table string) string {
+	return fmt.Sprintf(`SELECT GREATEST(reltuples, 0)::bigint FROM pg_catalog.pg_class WHERE oid = 'public.%s'::regclass`, table)
+}
+
+// DependentObjectsSQL lists the views (through pg_depend), foreign keys and
+// triggers that depend on table.
+func (p *PostgresDialect) DependentObjectsSQL(table string) string {
+	return fmt.Sprintf(`SELECT DISTINCT 'view' AS kind, v.relname AS name, '' AS tbl, '' AS definition
+FROM pg_catalog.pg_depend d
+JOIN pg_catalog.pg_rewrite r ON r.oid = d.objid
+JOIN pg_catalog.pg_class v ON v.oid = r.ev_class
+WHERE d.classid = 'pg_catalog.pg_rewrite'::regclass AND d.refobjid = to_regclass('public.%[1]s') AND v.oid <> d.refobjid
+UNION ALL
+SELECT 'foreign_key', c.conname, c.conrelid::regclass::text, ''
+FROM pg_catalog.pg_constraint c
+WHERE c.contype = 'f' AND c.confrelid = to_regclass('public.%[1]s') AND c.conrelid <> c.confrelid
+UNION ALL
+SELECT 'trigger', t.tgname, '%[1]s', ''
+FROM pg_catalog.pg_trigger t
+WHERE t.tgrelid = to_regclass('public.%[1]s') AND NOT t.tgisinternal`, table)
+}
+
+// SchemaSnapshotSQL lists the columns, indexes, constraints, views, triggers,
+// functions and enum types of the public schema.
+func (p *PostgresDialect) SchemaSnapshotSQL() string {
+	return `SELECT 'column' AS kind, table_name || '.' || column_name AS name, table_name AS tbl,
+	data_type || CASE WHEN is_nullable = 'NO' THEN ' NOT NULL' ELSE '' END || COALESCE(' DEFAULT ' || column_default, '') AS definition
+FROM information_schema.columns
+WHERE table_schema = 'public'
+UNION ALL
+SELECT 'index', indexname, tablename, indexdef
+FROM pg_catalog.pg_indexes
+WHERE schemaname = 'public'
+UNION ALL
+SELECT 'constraint', c.conname, c.conrelid::regclass::text, pg_catalog.pg_get_constraintdef(c.oid)
+FROM pg_catalog.pg_constraint c
+WHERE c.connamespace = 'public'::regnamespace AND c.conrelid <> 0
+UNION ALL
+SELECT 'view', viewname, viewname, definition
+FROM pg_catalog.pg_views
+WHERE schemaname = 'public'
+UNION ALL
+SELECT 'trigger', t.tgname, t.tgrelid::regclass::text, pg_catalog.pg_get_triggerdef(t.oid)
+FROM pg_catalog.pg_trigger t JOIN pg_catalog.pg_class r ON r.oid = t.tgrelid
+WHERE r.relnamespace = 'public'::regnamespace AND NOT t.tgisinternal
+UNION ALL
+SELECT 'function', p.proname, '', pg_catalog.pg_get_function_identity_arguments(p.oid)
+FROM pg_catalog.pg_proc p
+WHERE p.pronamespace = 'public'::regnamespace
+UNION ALL
+SELECT 'type', t.typname, '', ''
+FROM pg_catalog.pg_type t
+WHERE t.typnamespace = 'public'::regnamespace AND t.typtype = 'e'
+ORDER BY 1, 2`
+}
+
 func (p *PostgresDialect) CreateTableSQL(ct CreateTable, up bool) (string, error) {
 	if err := requireFields(ct.Name); err != nil {
 		return "", fmt.Errorf("PostgresDialect.CreateTableSQL: %w", err)
@@ -27,7 +94,7 @@ func (p *PostgresDialect) CreateTableSQL(ct CreateTable, up bool) (string, error
 		var cols []string
 		var pkCols []string
 		for _, col := range ct.AddFields {
-			colDef := fmt.Sprintf("%s %s", p.quoteIdentifier(col.Name), p.MapDataType(col.Type, col.Size, col.Scale, col.AutoIncrement))
+			colDef := fmt.Sprintf("%s %s", p.quoteIdentifier(col.Name), columnType(p, col))
 			if !col.Nullable {
 				colDef += " NOT NULL"
 			}
@@ -64,9 +131,9 @@ func (p *PostgresDialect) CreateTableSQL(ct CreateTable, up bool) (string, error
 		var extra []string
 		for _, col := range ct.AddFields {
 			if col.Unique {
-				extra = append(extra, fmt.Sprintf("CREATE UNIQUE INDEX uniq_%s_%s ON %s (%s);", ct.Name, col.Name, p.quoteIdentifier(ct.Name), p.quoteIdentifier(col.Name)))
+				extra = append(extra, fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s (%s);", p.quoteIdentifier(uniqueIndexName(DialectPostgres, ct.Name, col)), p.quoteIdentifier(ct.Name), p.quoteIdentifier(col.Name)))
 			} else if col.Index {
-				extra = append(extra, fmt.Sprintf("CREATE INDEX idx_%s_%s ON %s (%s);", ct.Name, col.Name, p.quoteIdentifier(ct.Name), p.quoteIdentifier(col.Name)))
+				extra = append(extra, fmt.Sprintf("CREATE INDEX %s ON %s (%s);", p.quoteIdentifier(indexName(DialectPostgres, ct.Name, col)), p.quoteIdentifier(ct.Name), p.quoteIdentifier(col.Name)))
 			}
 		}
 		if len(extra) > 0 {
@@ -97,9 +164,9 @@ func (p *PostgresDialect) DropEnumTypeSQL(de DropEnumType) (string, error) {
 
 func (p *PostgresDialect) DropRowPolicySQL(drp DropRowPolicy) (string, error) {
 	if drp.IfExists {
-		return fmt.Sprintf("DROP POLICY IF EXISTS %s ON %s;", drp.Name, p.quoteIdentifier(drp.Table)), nil
+		return fmt.Sprintf("DROP POLICY IF EXISTS %s ON %s;", p.quoteIdentifier(drp.Name), p.quoteIdentifier(drp.Table)), nil
 	}
-	return fmt.Sprintf("DROP POLICY %s ON %s;", drp.Name, p.quoteIdentifier(drp.Table)), nil
+	return fmt.Sprintf("DROP POLICY %s ON %s;", p.quoteIdentifier(drp.Name), p.quoteIdentifier(drp.Table)), nil
 }
 
 func (p *PostgresDialect) DropMaterializedViewSQL(dmv DropMaterializedView) (string, error) {
@@ -140,7 +207,7 @@ func (p *PostgresDialect) AddFieldSQL(ac AddField, tableName string) ([]string,
 	var queries []string
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s ", p.quoteIdentifier(tableName), p.quoteIdentifier(ac.Name)))
-	sb.WriteString(p.MapDataType(ac.Type, ac.Size, ac.Scale, ac.AutoIncrement))
+	sb.WriteString(columnType(p, ac))
 	if !ac.Nullable {
 		sb.WriteString(" NOT NULL")
 	}
@@ -160,14 +227,16 @@ func (p *PostgresDialect) AddFieldSQL(ac AddField, tableName string) ([]string,
 	sb.WriteString(";")
 	queries = append(queries, sb.String())
 	if ac.Unique {
-		queries = append(queries, fmt.Sprintf("CREATE UNIQUE INDEX uniq_%s_%s ON %s (%s);", tableName, ac.Name, tableName, ac.Name))
+		queries = append(queries, fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s (%s);", p.quoteIdentifier(uniqueIndexName(DialectPostgres, tableName, ac)), p.quoteIdentifier(tableName), p.quoteIdentifier(ac.Name)))
 	}
 	if ac.Index {
-		queries = append(queries, fmt.Sprintf("CREATE INDEX idx_%s_%s ON %s (%s);", tableName, ac.Name, tableName, ac.Name))
+		queries = append(queries, fmt.Sprintf("CREATE INDEX %s ON %s (%s);", p.quoteIdentifier(indexName(DialectPostgres, tableName, ac)), p.quoteIdentifier(tableName), p.quoteIdentifier(ac.Name)))
 	}
 	if ac.ForeignKey != nil {
 		fk := ac.ForeignKey
-		sql := fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT fk_%s FOREIGN KEY (%s) REFERENCES %s(%s)", tableName, ac.Name, ac.Name, fk.ReferenceTable, fk.ReferenceField)
+		sql := fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
+			p.quoteIdentifier(tableName), p.quoteIdentifier(foreignKeyName(DialectPostgres, tableName, ac)), p.quoteIdentifier(ac.Name),
+			p.quoteIdentifier(fk.ReferenceTable), p.quoteIdentifier(fk.ReferenceField))
 		if fk.OnDelete != "" {
 			sql += fmt.Sprintf(" ON DELETE %s", fk.OnDelete)
 		}
@@ -179,6 +248,27 @@ func (p *PostgresDialect) AddFieldSQL(ac AddField, tableName string) ([]string,
 	return queries, nil
 }
 
+func (p *PostgresDialect) SetNotNullSQL(ac AddField, tableName string) (string, error) {
+	if err := requireFields(ac.Name, tableName); err != nil {
+		return "", fmt.Errorf("PostgresDialect.SetNotNullSQL: %w", err)
+	}
+	return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;", p.quoteIdentifier(tableName), p.quoteIdentifier(ac.Name)), nil
+}
+
+func (p *PostgresDialect) AddPrimaryKeySQL(pk AddPrimaryKey, tableName string) (string, error) {
+	cols := make([]string, len(pk.Fields))
+	for i, f := range pk.Fields {
+		cols[i] = p.quoteIdentifier(f)
+	}
+	return fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s PRIMARY KEY (%s);", p.quoteIdentifier(tableName),
+		p.quoteIdentifier(primaryKeyName(DialectPostgres, tableName, pk.Name)), strings.Join(cols, ", ")), nil
+}
+
+func (p *PostgresDialect) DropPrimaryKeySQL(pk DropPrimaryKey, tableName string) (string, error) {
+	return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;", p.quoteIdentifier(tableName),
+		p.quoteIdentifier(primaryKeyName(DialectPostgres, tableName, pk.Name))), nil
+}
+
 func (p *PostgresDialect) DropFieldSQL(dc DropField, tableName string) (string, error) {
 	if err := requireFields(dc.Name, tableName); err != nil {
 		return "", fmt.Errorf("PostgresDialect.DropFieldSQL: %w", err)
@@ -200,7 +290,168 @@ func (p *PostgresDialect) RenameFieldSQL(rc RenameField, tableName string) (stri
 	if rc.To == "" {
 		return "", errors.New("postgres requires new field name for renaming field")
 	}
-	return fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;", p.quoteIdentifier(tableName), p.quoteIdentifier(from), p.quoteIdentifier(rc.To)), nil
+	sql := fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;", p.quoteIdentifier(tableName), p.quoteIdentifier(from), p.quoteIdentifier(rc.To))
+	if rc.Type != "" {
+		dataType := p.MapDataType(rc.Type, 0, 0, false)
+		sql += fmt.Sprintf(" ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s::%s;", p.quoteIdentifier(tableName), p.quoteIdentifier(rc.To), dataType, p.quoteIdentifier(rc.To), dataType)
+	}
+	return sql, nil
+}
+
+func (p *PostgresDialect) RebuildIndexSQL(ri RebuildIndex) (string, error) {
+	return fmt.Sprintf("REINDEX INDEX CONCURRENTLY %s;", p.quoteIdentifier(ri.Name)), nil
+}
+
+func (p *PostgresDialect) ReindexTableSQL(rt ReindexTable) (string, error) {
+	return fmt.Sprintf("REINDEX TABLE CONCURRENTLY %s;", p.quoteIdentifier(rt.Name)), nil
+}
+
+func (p *PostgresDialect) quoteIdentifiers(ids []string) string {
+	quoted := make([]string, len(ids))
+	for i, id := range ids {
+		quoted[i] = p.quoteIdentifier(id)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func (p *PostgresDialect) CreatePublicationSQL(cp CreatePublication) (string, error) {
+	q := "CREATE PUBLICATION " + p.quoteIdentifier(cp.Name)
+	switch {
+	case cp.AllTables:
+		q += " FOR ALL TABLES"
+	case len(cp.Tables) > 0:
+		q += " FOR TABLE " + p.quoteIdentifiers(cp.Tables)
+	}
+	if cp.Publish != "" {
+		q += fmt.Sprintf(" WITH (publish = '%s')", cp.Publish)
+	}
+	return q + ";", nil
+}
+
+// AlterPublicationSQL emits one ALTER PUBLICATION statement per change.
+func (p *PostgresDialect) AlterPublicationSQL(ap AlterPublication) (string, error) {
+	name := p.quoteIdentifier(ap.Name)
+	var stmts []string
+	if len(ap.SetTables) > 0 {
+		stmts = append(stmts, fmt.Sprintf("ALTER PUBLICATION %s SET TABLE %s;", name, p.quoteIdentifiers(ap.SetTables)))
+	}
+	if len(ap.AddTables) > 0 {
+		stmts = append(stmts, fmt.Sprintf("ALTER PUBLICATION %s ADD TABLE %s;", name, p.quoteIdentifiers(ap.AddTables)))
+	}
+	if len(ap.DropTables) > 0 {
+		stmts = append(stmts, fmt.Sprintf("ALTER PUBLICATION %s DROP TABLE %s;", name, p.quoteIdentifiers(ap.DropTables)))
+	}
+	if ap.Publish != "" {
+		stmts = append(stmts, fmt.Sprintf("ALTER PUBLICATION %s SET (publish = '%s');", name, ap.Publish))
+	}
+	return strings.Join(stmts, " "), nil
+}
+
+func (p *PostgresDialect) DropPublicationSQL(dp DropPublication) (string, error) {
+	if dp.IfExists {
+		return fmt.Sprintf("DROP PUBLICATION IF EXISTS %s;", p.quoteIdentifier(dp.Name)), nil
+	}
+	return fmt.Sprintf("DROP PUBLICATION %s;", p.quoteIdentifier(dp.Name)), nil
+}
+
+func (p *PostgresDialect) CreateRoleSQL(cr CreateRole) (string, error) {
+	opts := []string{"NOLOGIN"}
+	if cr.Login {
+		opts[0] = "LOGIN"
+	}
+	if cr.Password != "" {
+		opts = append(opts, "PASSWORD "+quoteStringLiteral(cr.Password))
+	}
+	opts = append(opts, cr.Options...)
+	q := fmt.Sprintf("CREATE ROLE %s WITH %s", p.quoteIdentifier(cr.Name), strings.Join(opts, " "))
+	if len(cr.InRoles) > 0 {
+		q += " IN ROLE " + p.quoteIdentifiers(cr.InRoles)
+	}
+	return q + ";", nil
+}
+
+// AlterRoleSQL applies option and password changes before a rename.
+func (p *PostgresDialect) AlterRoleSQL(ar AlterRole) (string, error) {
+	name := p.quoteIdentifier(ar.Name)
+	var stmts []string
+	opts := append([]string(nil), ar.Options...)
+	if ar.Password != "" {
+		opts = append(opts, "PASSWORD "+quoteStringLiteral(ar.Password))
+	}
+	if len(opts) > 0 {
+		stmts = append(stmts, fmt.Sprintf("ALTER ROLE %s WITH %s;", name, strings.Join(opts, " ")))
+	}
+	if ar.RenameTo != "" {
+		stmts = append(stmts, fmt.Sprintf("ALTER ROLE %s RENAME TO %s;", name, p.quoteIdentifier(ar.RenameTo)))
+	}
+	return strings.Join(stmts, " "), nil
+}
+
+func (p *PostgresDialect) DropRoleSQL(dr DropRole) (string, error) {
+	if dr.IfExists {
+		return fmt.Sprintf("DROP ROLE IF EXISTS %s;", p.quoteIdentifier(dr.Name)), nil
+	}
+	return fmt.Sprintf("DROP ROLE %s;", p.quoteIdentifier(dr.Name)), nil
+}
+
+func (p *PostgresDialect) CreateDatabaseSQL(cd CreateDatabase) (string, error) {
+	if cd.IfNotExists {
+		return "", errors.New("CREATE DATABASE IF NOT EXISTS is not supported in PostgreSQL")
+	}
+	var opts []string
+	if cd.Owner != "" {
+		opts = append(opts, "OWNER "+p.quoteIdentifier(cd.Owner))
+	}
+	if cd.Template != "" {
+		opts = append(opts, "TEMPLATE "+p.quoteIdentifier(cd.Template))
+	}
+	if cd.Encoding != "" {
+		opts = append(opts, "ENCODING "+quoteStringLiteral(cd.Encoding))
+	}
+	if cd.Collation != "" {
+		opts = append(opts, "LC_COLLATE "+quoteStringLiteral(cd.Collation))
+	}
+	if len(opts) == 0 {
+		return fmt.Sprintf("CREATE DATABASE %s;", p.quoteIdentifier(cd.Name)), nil
+	}
+	return fmt.Sprintf("CREATE DATABASE %s WITH %s;", p.quoteIdentifier(cd.Name), strings.Join(opts, " ")), nil
+}
+
+func (p *PostgresDialect) DropDatabaseSQL(dd DropDatabase) (string, error) {
+	q := "DROP DATABASE "
+	if dd.IfExists {
+		q += "IF EXISTS "
+	}
+	q += p.quoteIdentifier(dd.Name)
+	if dd.Force {
+		q += " WITH (FORCE)"
+	}
+	return q + ";", nil
+}
+
+func (p *PostgresDialect) VacuumSQL(v Vacuum) (string, error) {
+	var opts []string
+	if v.Full {
+		opts = append(opts, "FULL")
+	}
+	if v.Analyze {
+		opts = append(opts, "ANALYZE")
+	}
+	q := "VACUUM"
+	if len(opts) > 0 {
+		q += " (" + strings.Join(opts, ", ") + ")"
+	}
+	if v.Name != "" {
+		q += " " + p.quoteIdentifier(v.Name)
+	}
+	return q + ";", nil
+}
+
+func (p *PostgresDialect) AnalyzeSQL(a Analyze) (string, error) {
+	if a.Name == "" {
+		return "ANALYZE;", nil
+	}
+	return fmt.Sprintf("ANALYZE %s;", p.quoteIdentifier(a.Name)), nil
 }
 
 func (p *PostgresDialect) MapDataType(genericType string, size, scale int, autoIncrement bool) string {
@@ -8,71 +8,136 @@ import (
 
 type SQLiteDialect struct{}
 
+// quoteIdentifier quotes id, doubling the double quotes it contains.
 func (s *SQLiteDialect) quoteIdentifier(id string) string {
-	return fmt.Sprintf("\"%s\"", id)
+	return `"` + strings.ReplaceAll(id, `"`, `""`) + `"`
 }
 
 func (s *SQLiteDialect) TableExistsSQL(table string) string {
 	return fmt.Sprintf(`SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = '%s'`, table)
 }
 
+func (s *SQLiteDialect) ColumnExistsSQL(table, column string) string {
+	return fmt.Sprintf(`SELECT COUNT(*) > 0 FROM pragma_table_info('%s') WHERE name = '%s'`, table, column)
+}
+
+func (s *SQLiteDialect) IndexExistsSQL(table, index string) string {
+	return fmt.Sprintf(`SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'index' AND tbl_name = '%s' AND name = '%s'`, table, index)
+}
+
+// TableRowsEstimateSQL counts the rows; SQLite keeps no row statistics
+// unless ANALYZE has been run.
+func (s *SQLiteDialect) TableRowsEstimateSQL(table string) string {
+	return fmt.Sprintf(`SELECT COUNT(*) FROM "%s"`, table)
+}
+
+// DependentObjectsSQL lists the views, foreign keys and triggers that
+// depend on table. Views are matched on their definition text.
+func (s *SQLiteDialect) DependentObjectsSQL(table string) string {
+	return fmt.Sprintf(`SELECT 'view' AS kind, name, '' AS tbl, sql AS definition
+FROM sqlite_master
+WHERE type = 'view' AND sql LIKE '%%%[1]s%%'
+UNION ALL
+SELECT DISTINCT 'foreign_key', '', m.name, ''
+FROM sqlite_master m JOIN pragma_foreign_key_list(m.name) f
+WHERE m.type = 'table' AND f."table" = '%[1]s' AND m.name <> '%[1]s'
+UNION ALL
+SELECT 'trigger', name, tbl_name, ''
+FROM sqlite_master
+WHERE type = 'trigger' AND tbl_name = '%[1]s'`, table)
+}
+
+// SchemaSnapshotSQL lists every table, index, view and trigger with the SQL
+// that created it.
+func (s *SQLiteDialect) SchemaSnapshotSQL() string {
+	return `SELECT type AS kind, name, tbl_name AS tbl, COALESCE(sql, '') AS definition
+FROM sqlite_master
+WHERE name NOT LIKE 'sqlite_%'
+ORDER BY type, name`
+}
+
 func (s *SQLiteDialect) CreateTableSQL(ct CreateTable, up bool) (string, error) {
 	if err := requireFields(ct.Name); err != nil {
 		return "", fmt.Errorf("SQLiteDialect.CreateTableSQL: %w", err)
 	}
 	if up {
-		var sb strings.Builder
-		sb.WriteString(fmt.Sprintf("CREATE TABLE %s (", s.quoteIdentifier(ct.Name)))
-		var cols []string
-		var pkCols []string
-		for _, col := range ct.AddFields {
-			colDef := fmt.Sprintf("%s %s", s.quoteIdentifier(col.Name), s.MapDataType(col.Type, col.Size, col.Scale, col.AutoIncrement))
-			if !col.Nullable {
-				colDef += " NOT NULL"
-			}
-			if col.Default != "" {
-				def := ConvertDefault(col.Default, col.Type)
-				if strings.Contains(colDef, "NOT NULL") {
-					if def != "NULL" {
-						colDef += fmt.Sprintf(" DEFAULT %s", def)
-					}
-				} else {
+		stmts := append([]string{s.createTableStatement(ct, ct.Name)}, s.tableIndexStatements(ct)...)
+		return strings.Join(stmts, "\n"), nil
+	}
+	return fmt.Sprintf("DROP TABLE IF EXISTS %s;", s.quoteIdentifier(ct.Name)), nil
+}
+
+// createTableStatement returns the CREATE TABLE statement of ct, creating it
+// as name. Foreign keys are table constraints, as SQLite cannot add them
+// later.
+func (s *SQLiteDialect) createTableStatement(ct CreateTable, name string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("CREATE TABLE %s (", s.quoteIdentifier(name)))
+	var cols []string
+	var pkCols []string
+	var fks []string
+	for _, col := range ct.AddFields {
+		colDef := fmt.Sprintf("%s %s", s.quoteIdentifier(col.Name), columnType(s, col))
+		if !col.Nullable {
+			colDef += " NOT NULL"
+		}
+		if col.Default != "" {
+			def := ConvertDefault(col.Default, col.Type)
+			if strings.Contains(colDef, "NOT NULL") {
+				if def != "NULL" {
 					colDef += fmt.Sprintf(" DEFAULT %s", def)
 				}
-			}
-			if col.Check != "" {
-				colDef += fmt.Sprintf(" CHECK (%s)", col.Check)
-			}
-			cols = append(cols, colDef)
-			if len(ct.PrimaryKey) == 0 && col.PrimaryKey {
-				pkCols = append(pkCols, s.quoteIdentifier(col.Name))
+			} else {
+				colDef += fmt.Sprintf(" DEFAULT %s", def)
 			}
 		}
-		if len(ct.PrimaryKey) > 0 {
-			var pkQuoted []string
-			for _, col := range ct.PrimaryKey {
-				pkQuoted = append(pkQuoted, s.quoteIdentifier(col))
-			}
-			cols = append(cols, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(pkQuoted, ", ")))
-		} else if len(pkCols) > 0 {
-			cols = append(cols, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(pkCols, ", ")))
+		if col.Check != "" {
+			colDef += fmt.Sprintf(" CHECK (%s)", col.Check)
 		}
-		sb.WriteString(strings.Join(cols, ", "))
-		sb.WriteString(");")
-		var extra []string
-		for _, col := range ct.AddFields {
-			if col.Unique {
-				extra = append(extra, fmt.Sprintf("CREATE UNIQUE INDEX uniq_%s_%s ON %s (%s);", ct.Name, col.Name, s.quoteIdentifier(ct.Name), s.quoteIdentifier(col.Name)))
-			} else if col.Index {
-				extra = append(extra, fmt.Sprintf("CREATE INDEX idx_%s_%s ON %s (%s);", ct.Name, col.Name, s.quoteIdentifier(ct.Name), s.quoteIdentifier(col.Name)))
+		cols = append(cols, colDef)
+		if len(ct.PrimaryKey) == 0 && col.PrimaryKey {
+			pkCols = append(pkCols, s.quoteIdentifier(col.Name))
+		}
+		if fk := col.ForeignKey; fk != nil {
+			def := fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
+				s.quoteIdentifier(foreignKeyName(DialectSQLite, ct.Name, col)), s.quoteIdentifier(col.Name),
+				s.quoteIdentifier(fk.ReferenceTable), s.quoteIdentifier(fk.ReferenceField))
+			if fk.OnDelete != "" {
+				def += fmt.Sprintf(" ON DELETE %s", fk.OnDelete)
+			}
+			if fk.OnUpdate != "" {
+				def += fmt.Sprintf(" ON UPDATE %s", fk.OnUpdate)
 			}
+			fks = append(fks, def)
 		}
-		if len(extra) > 0 {
-			sb.WriteString("\n" + strings.Join(extra, "\n"))
+	}
+	if len(ct.PrimaryKey) > 0 {
+		var pkQuoted []string
+		for _, col := range ct.PrimaryKey {
+			pkQuoted = append(pkQuoted, s.quoteIdentifier(col))
 		}
-		return sb.String(), nil
+		cols = append(cols, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(pkQuoted, ", ")))
+	} else if len(pkCols) > 0 {
+		cols = append(cols, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(pkCols, ", ")))
 	}
-	return fmt.Sprintf("DROP TABLE IF EXISTS %s;", s.quoteIdentifier(ct.Name)), nil
+	cols = append(cols, fks...)
+	sb.WriteString(strings.Join(cols, ", "))
+	sb.WriteString(");")
+	return sb.String()
+}
+
+// tableIndexStatements returns the statements creating the unique and plain
+// indexes of the fields of ct.
+func (s *SQLiteDialect) tableIndexStatements(ct CreateTable) []string {
+	var stmts []string
+	for _, col := range ct.AddFields {
+		if col.Unique {
+			stmts = append(stmts, fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s (%s);", s.quoteIdentifier(uniqueIndexName(DialectSQLite, ct.Name, col)), s.quoteIdentifier(ct.Name), s.quoteIdentifier(col.Name)))
+		} else if col.Index {
+			stmts = append(stmts, fmt.Sprintf("CREATE INDEX %s ON %s (%s);", s.quoteIdentifier(indexName(DialectSQLite, ct.Name, col)), s.quoteIdentifier(ct.Name), s.quoteIdentifier(col.Name)))
+		}
+	}
+	return stmts
 }
 
 func (s *SQLiteDialect) RenameTableSQL(rt RenameTable) (string, error) {
@@ -113,7 +178,7 @@ func (s *SQLiteDialect) AddFieldSQL(ac AddField, tableName string) ([]string, er
 	var queries []string
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s ", s.quoteIdentifier(tableName), s.quoteIdentifier(ac.Name)))
-	sb.WriteString(s.MapDataType(ac.Type, ac.Size, ac.Scale, ac.AutoIncrement))
+	sb.WriteString(columnType(s, ac))
 	if !ac.Nullable {
 		sb.WriteString(" NOT NULL")
 	}
@@ -133,10 +198,10 @@ func (s *SQLiteDialect) AddFieldSQL(ac AddField, tableName string) ([]string, er
 	sb.WriteString(";")
 	queries = append(queries, sb.String())
 	if ac.Unique {
-		queries = append(queries, fmt.Sprintf("CREATE UNIQUE INDEX uniq_%s_%s ON %s (%s);", tableName, ac.Name, tableName, ac.Name))
+		queries = append(queries, fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s (%s);", s.quoteIdentifier(uniqueIndexName(DialectSQLite, tableName, ac)), s.quoteIdentifier(tableName), s.quoteIdentifier(ac.Name)))
 	}
 	if ac.Index {
-		queries = append(queries, fmt.Sprintf("CREATE INDEX idx_%s_%s ON %s (%s);", tableName, ac.Name, tableName, ac.Name))
+		queries = append(queries, fmt.Sprintf("CREATE INDEX %s ON %s (%s);", s.quoteIdentifier(indexName(DialectSQLite, tableName, ac)), s.quoteIdentifier(tableName), s.quoteIdentifier(ac.Name)))
 	}
 	if ac.ForeignKey != nil {
 		return nil, errors.New("SQLite foreign keys must be defined at table creation")
@@ -151,6 +216,21 @@ func (s *SQLiteDialect) DropFieldSQL(dc DropField, tableName string) (string, er
 	return "", errors.New("SQLite DROP field must use table recreation")
 }
 
+func (s *SQLiteDialect) SetNotNullSQL(ac AddField, tableName string) (string, error) {
+	if err := requireFields(ac.Name, tableName); err != nil {
+		return "", fmt.Errorf("SQLiteDialect.SetNotNullSQL: %w", err)
+	}
+	return "", errors.New("SQLite SET NOT NULL must use table recreation")
+}
+
+func (s *SQLiteDialect) AddPrimaryKeySQL(pk AddPrimaryKey, tableName string) (string, error) {
+	return "", errors.New("SQLite ADD PRIMARY KEY must use table recreation")
+}
+
+func (s *SQLiteDialect) DropPrimaryKeySQL(pk DropPrimaryKey, tableName string) (string, error) {
+	return "", errors.New("SQLite DROP PRIMARY KEY must use table recreation")
+}
+
 func (s *SQLiteDialect) RenameFieldSQL(rc RenameField, tableName string) (string, error) {
 	if err := requireFields(tableName); err != nil {
 		return "", fmt.Errorf("SQLiteDialect.RenameFieldSQL: %w", err)
@@ -158,6 +238,65 @@ func (s *SQLiteDialect) RenameFieldSQL(rc RenameField, tableName string) (string
 	return "", errors.New("SQLite RENAME field must use table recreation")
 }
 
+func (s *SQLiteDialect) RebuildIndexSQL(ri RebuildIndex) (string, error) {
+	return fmt.Sprintf("REINDEX %s;", s.quoteIdentifier(ri.Name)), nil
+}
+
+func (s *SQLiteDialect) ReindexTableSQL(rt ReindexTable) (string, error) {
+	return fmt.Sprintf("REINDEX %s;", s.quoteIdentifier(rt.Name)), nil
+}
+
+func (s *SQLiteDialect) CreatePublicationSQL(cp CreatePublication) (string, error) {
+	return "", errors.New("publications are not supported in SQLite")
+}
+
+func (s *SQLiteDialect) AlterPublicationSQL(ap AlterPublication) (string, error) {
+	return "", errors.New("publications are not supported in SQLite")
+}
+
+func (s *SQLiteDialect) DropPublicationSQL(dp DropPublication) (string, error) {
+	return "", errors.New("publications are not supported in SQLite")
+}
+
+func (s *SQLiteDialect) CreateRoleSQL(cr CreateRole) (string, error) {
+	return "", errors.New("roles are not supported in SQLite")
+}
+
+func (s *SQLiteDialect) AlterRoleSQL(ar AlterRole) (string, error) {
+	return "", errors.New("roles are not supported in SQLite")
+}
+
+func (s *SQLiteDialect) DropRoleSQL(dr DropRole) (string, error) {
+	return "", errors.New("roles are not supported in SQLite")
+}
+
+func (s *SQLiteDialect) CreateDatabaseSQL(cd CreateDatabase) (string, error) {
+	return "", errors.New("database operations are not supported in SQLite")
+}
+
+func (s *SQLiteDialect) DropDatabaseSQL(dd DropDatabase) (string, error) {
+	return "", errors.New("database operations are not supported in SQLite")
+}
+
+// VacuumSQL vacuums the whole database; SQLite cannot vacuum a single table.
+func (s *SQLiteDialect) VacuumSQL(v Vacuum) (string, error) {
+	if !v.Analyze {
+		return "VACUUM;", nil
+	}
+	a, err := s.AnalyzeSQL(Analyze{Name: v.Name})
+	if err != nil {
+		return "", err
+	}
+	return "VACUUM; " + a, nil
+}
+
+func (s *SQLiteDialect) AnalyzeSQL(a Analyze) (string, error) {
+	if a.Name == "" {
+		return "ANALYZE;", nil
+	}
+	return fmt.Sprintf("ANALYZE %s;", s.quoteIdentifier(a.Name)), nil
+}
+
 func (s *SQLiteDialect) MapDataType(genericType string, size, scale int, autoIncrement bool) string {
 	return ConvertType(strings.ToLower(genericType), "sqlite", size, scale, autoIncrement)
 }
@@ -175,7 +314,7 @@ func (s *SQLiteDialect) WrapInTransactionWithConfig(queries []string, trans Tran
 
 func (s *SQLiteDialect) CreateViewSQL(cv CreateView) (string, error) {
 	if cv.OrReplace {
-		return fmt.Sprintf("CREATE VIEW IF NOT EXISTS %s AS %s;", s.quoteIdentifier(cv.Name), cv.Definition), nil
+		return fmt.Sprintf("DROP VIEW IF EXISTS %s; CREATE VIEW %s AS %s;", s.quoteIdentifier(cv.Name), s.quoteIdentifier(cv.Name), cv.Definition), nil
 	}
 	return fmt.Sprintf("CREATE VIEW %s AS %s;", s.quoteIdentifier(cv.Name), cv.Definition), nil
 }
@@ -233,10 +372,19 @@ func (s *SQLiteDialect) RenameTriggerSQL(rt RenameTrigger) (string, error) {
 	return "", errors.New("RENAME TRIGGER is not supported in SQLite")
 }
 
+// RecreateTableForAlter returns the statements replacing tableName by a
+// table with newSchema, copying its rows; renameMap maps the new names of
+// renamed fields to their old ones. The new table is created under a
+// temporary name and renamed once the old one is dropped, so foreign keys of
+// other tables keep referencing tableName, and the foreign keys, unique
+// constraints and indexes of newSchema are created again.
 func (s *SQLiteDialect) RecreateTableForAlter(tableName string, newSchema CreateTable, renameMap map[string]string) ([]string, error) {
+	if len(newSchema.AddFields) == 0 {
+		return nil, fmt.Errorf("failed to generate new schema for table %s: no columns", tableName)
+	}
 	var newCols, selectCols []string
 	for _, col := range newSchema.AddFields {
-		newCols = append(newCols, col.Name)
+		newCols = append(newCols, s.quoteIdentifier(col.Name))
 		orig := col.Name
 		for old, newName := range renameMap {
 			if newName == col.Name {
@@ -244,20 +392,19 @@ func (s *SQLiteDialect) RecreateTableForAlter(tableName string, newSchema Create
 				break
 			}
 		}
-		selectCols = append(selectCols, orig)
+		selectCols = append(selectCols, s.quoteIdentifier(orig))
 	}
+	newSchema.Name = tableName
+	staging := sqliteStagingTable(tableName)
 	queries := []string{
 		"PRAGMA foreign_keys=off;",
-		fmt.Sprintf("ALTER TABLE %s RENAME TO %s_backup;", s.quoteIdentifier(tableName), s.quoteIdentifier(tableName)),
-	}
-	ctSQL, err := newSchema.ToSQL(DialectSQLite, true)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate new schema for table %s: %w", tableName, err)
+		s.createTableStatement(newSchema, staging),
+		fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s;", s.quoteIdentifier(staging), strings.Join(newCols, ", "), strings.Join(selectCols, ", "), s.quoteIdentifier(tableName)),
+		fmt.Sprintf("DROP TABLE %s;", s.quoteIdentifier(tableName)),
+		sqliteSwapSQL(tableName),
 	}
-	queries = append(queries, ctSQL)
-	queries = append(queries, fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s_backup;", s.quoteIdentifier(tableName), strings.Join(newCols, ", "), strings.Join(selectCols, ", "), s.quoteIdentifier(tableName)))
-	queries = append(queries, fmt.Sprintf("DROP TABLE %s_backup;", s.quoteIdentifier(tableName)))
-	queries = append(queries, "PRAGMA foreign_keys=on;")
+	queries = append(queries, s.tableIndexStatements(newSchema)...)
+	queries = append(queries, sqliteForeignKeysOn)
 	return queries, nil
 }
 
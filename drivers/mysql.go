@@ -1,16 +1,44 @@
 package drivers
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
 	"github.com/oarkflow/squealx"
-	"github.com/oarkflow/squealx/drivers/mysql"
+	_ "github.com/oarkflow/squealx/drivers/mysql"
 )
 
 type MySQLDriver struct {
 	db    *squealx.DB
 	Force bool
+	// stmts caches prepared statements for parameterised queries
+	stmts stmtCache
+	// session statements run on each connection before applying SQL
+	session sessionSetup
+	// observer is told about every statement applied
+	observer StatementObserver
+}
+
+// Close releases cached prepared statements and closes the database.
+func (m *MySQLDriver) Close() error {
+	if err := m.stmts.close(); err != nil {
+		_ = m.db.Close()
+		return err
+	}
+	return m.db.Close()
+}
+
+// SetSessionSetup sets statements run once on every connection before it is
+// used, such as lock timeouts or search paths.
+func (m *MySQLDriver) SetSessionSetup(statements ...string) {
+	m.session.set(statements)
+}
+
+// SetStatementObserver sets fn to be called after every statement applied
+// through ApplyBatch or ApplySQL.
+func (m *MySQLDriver) SetStatementObserver(fn StatementObserver) {
+	m.observer = fn
 }
 
 func (m *MySQLDriver) SetForce(force bool) {
@@ -22,115 +50,85 @@ func NewMySQLDriverFromDB(db *squealx.DB) *MySQLDriver {
 }
 
 func NewMySQLDriver(dsn string) (*MySQLDriver, error) {
-	db, err := mysql.Open(dsn, "mysql")
+	m := &MySQLDriver{}
+	db, err := openWithSession("mysql", dsn, "mysql", &m.session)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open connection: %w", err)
 	}
 	if err := db.Ping(); err != nil {
+		_ = db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
-	return &MySQLDriver{db: db}, nil
+	m.db = db
+	return m, nil
 }
 
+// ApplySQL applies migrations in one transaction, binding args[0] to every
+// statement when given. It is shorthand for ApplyBatch.
 func (m *MySQLDriver) ApplySQL(migrations []string, args ...any) error {
-	// Flatten statements
-	var stmts []string
-	for _, query := range migrations {
-		parts := splitSQLStatements(query)
-		for _, q := range parts {
-			if strings.TrimSpace(q) != "" {
-				stmts = append(stmts, q)
-			}
-		}
-	}
+	return m.ApplyBatch(statementsFromSQL(migrations, args))
+}
+
+// ApplyBatch applies statements in order, each with its own arguments and
+// options. Statements share one transaction unless marked NoTransaction.
+func (m *MySQLDriver) ApplyBatch(statements []Statement) error {
+	stmts := flattenStatements(statements)
 	if len(stmts) == 0 {
 		return nil
 	}
 
 	// Force mode: execute each statement individually without transaction, log errors and continue
 	if m.Force {
-		for _, q := range stmts {
-			q = strings.TrimSpace(q)
-			if q == "" {
-				continue
-			}
-			var err error
-			if len(args) > 0 {
-				_, err = m.db.NamedExec(q, args[0])
-			} else {
-				_, err = m.db.Exec(q)
-			}
-			if err != nil {
-				fmt.Printf("[force] warning: statement failed: %s: %v\n", q, err)
-			}
-		}
-		return nil
+		return applyEach(m.db, &m.session, stmts, true, m.observer)
 	}
 
 	// Check if this is a rollback operation (contains DROP statements)
 	isRollback := false
-	for _, q := range stmts {
-		l := strings.ToLower(strings.TrimSpace(q))
+	for _, st := range stmts {
+		l := strings.ToLower(strings.TrimSpace(st.SQL))
 		if strings.HasPrefix(l, "drop table") || strings.HasPrefix(l, "drop view") || strings.HasPrefix(l, "drop function") {
 			isRollback = true
 			break
 		}
 	}
 
-	// Start transaction
-	if _, err := m.db.Exec("START TRANSACTION;"); err != nil {
-		return fmt.Errorf("failed to start transaction: %w", err)
-	}
-
-	// Disable foreign key checks for rollback operations
+	// MySQL rolls back only the failed statement and DDL commits implicitly,
+	// which would discard any savepoint, so skipped statements need none.
+	plan := txPlan{session: &m.session, observe: m.observer}
 	if isRollback {
-		if _, err := m.db.Exec("SET FOREIGN_KEY_CHECKS = 0;"); err != nil {
-			_, _ = m.db.Exec("ROLLBACK;")
-			return fmt.Errorf("failed to disable foreign key checks: %w", err)
-		}
+		// Disable foreign key checks and tolerate missing objects for rollback operations
+		plan.txSetup = []string{"SET FOREIGN_KEY_CHECKS = 0;"}
+		plan.txReset = []string{"SET FOREIGN_KEY_CHECKS = 1;"}
+		plan.ignorable = m.isIgnorableError
 	}
+	return applyBatch(m.db, &m.stmts, stmts, plan)
+}
 
-	for _, q := range stmts {
-		q = strings.TrimSpace(q)
-		if q == "" {
-			continue
-		}
-		if len(args) > 0 {
-			if _, err := m.db.NamedExec(q, args[0]); err != nil {
-				if isRollback && m.isIgnorableError(err) {
-					continue // Skip errors for non-existent objects during rollback
-				}
-				_, _ = m.db.Exec("ROLLBACK;")
-				return fmt.Errorf("failed to execute query [%s]: %w", q, err)
-			}
-		} else {
-			if _, err := m.db.Exec(q); err != nil {
-				if isRollback && m.isIgnorableError(err) {
-					continue // Skip errors for non-existent objects during rollback
-				}
-				_, _ = m.db.Exec("ROLLBACK;")
-				return fmt.Errorf("failed to execute query [%s]: %w", q, err)
-			}
-		}
-	}
+func (m *MySQLDriver) DB() *squealx.DB {
+	return m.db
+}
 
-	// Re-enable foreign key checks if they were disabled
-	if isRollback {
-		if _, err := m.db.Exec("SET FOREIGN_KEY_CHECKS = 1;"); err != nil {
-			_, _ = m.db.Exec("ROLLBACK;")
-			return fmt.Errorf("failed to re-enable foreign key checks: %w", err)
-		}
-	}
+// QueryRow scans the first row of query into dest (a struct, map or scalar
+// pointer). It returns sql.ErrNoRows when the query yields no row.
+func (m *MySQLDriver) QueryRow(ctx context.Context, dest any, query string, args ...any) error {
+	return queryRow(ctx, m.db, &m.session, dest, query, args...)
+}
 
-	if _, err := m.db.Exec("COMMIT;"); err != nil {
-		_, _ = m.db.Exec("ROLLBACK;")
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
-	return nil
+// QueryRowReadOnly scans the first row of query into dest like QueryRow,
+// in a START TRANSACTION READ ONLY transaction, so the query cannot change
+// data.
+func (m *MySQLDriver) QueryRowReadOnly(ctx context.Context, dest any, query string, args ...any) error {
+	return queryRowReadOnly(ctx, m.db, &m.session, "", dest, query, args...)
 }
 
-func (m *MySQLDriver) DB() *squealx.DB {
-	return m.db
+// Query scans every row of query into dest, a pointer to a slice.
+func (m *MySQLDriver) Query(ctx context.Context, dest any, query string, args ...any) error {
+	return queryRows(ctx, m.db, &m.session, dest, query, args...)
+}
+
+// Ping verifies that the database is reachable.
+func (m *MySQLDriver) Ping(ctx context.Context) error {
+	return ping(ctx, m.db)
 }
 
 // isIgnorableError checks if an error can be safely ignored during rollback operations
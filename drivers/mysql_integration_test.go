@@ -1,7 +1,9 @@
 package drivers
 
 import (
+	"context"
 	"os"
+	"sync"
 	"testing"
 )
 
@@ -54,3 +56,63 @@ INSERT INTO tx_mysql_test (note) VALUES ('ok');`
 	// cleanup
 	_, _ = m.DB().Exec("DROP TABLE IF EXISTS tx_mysql_test;")
 }
+
+// Statements of one ApplySQL call must share a session; see the Postgres
+// counterpart for the rationale.
+func TestMySQLApplySQL_UsesSingleSession(t *testing.T) {
+	dsn := os.Getenv("TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("skipping mysql integration test; set TEST_MYSQL_DSN to run")
+	}
+	m, err := NewMySQLDriver(dsn)
+	if err != nil {
+		t.Fatalf("failed to create mysql driver: %v", err)
+	}
+	defer func() { _ = m.Close() }()
+	m.DB().SetMaxOpenConns(4)
+
+	session := `CREATE TEMPORARY TABLE tx_mysql_session (id INT);
+INSERT INTO tx_mysql_session (id) VALUES (1);
+DROP TEMPORARY TABLE tx_mysql_session;`
+	errs := make(chan error, 8)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- m.ApplySQL([]string{session})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("expected statements to share one session, got %v", err)
+		}
+	}
+}
+
+func TestMySQLQueryRowReadOnly_RejectsWrites(t *testing.T) {
+	dsn := os.Getenv("TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("skipping mysql integration test; set TEST_MYSQL_DSN to run")
+	}
+	m, err := NewMySQLDriver(dsn)
+	if err != nil {
+		t.Fatalf("failed to create mysql driver: %v", err)
+	}
+	defer func() { _ = m.Close() }()
+	_, _ = m.DB().Exec("DROP TABLE IF EXISTS ro_mysql_test;")
+	if _, err := m.DB().Exec("CREATE TABLE ro_mysql_test (id INT);"); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	defer func() { _, _ = m.DB().Exec("DROP TABLE IF EXISTS ro_mysql_test;") }()
+
+	var v int64
+	if err := m.QueryRowReadOnly(context.Background(), &v, "INSERT INTO ro_mysql_test (id) VALUES (1)"); err == nil {
+		t.Fatal("expected an insert to fail in a read-only lookup")
+	}
+	if err := m.QueryRow(context.Background(), &v, "SELECT COUNT(*) FROM ro_mysql_test"); err != nil || v != 0 {
+		t.Fatalf("rows after read-only lookup = %d, %v; want 0", v, err)
+	}
+}
@@ -1,16 +1,44 @@
 package drivers
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
 	"github.com/oarkflow/squealx"
-	"github.com/oarkflow/squealx/drivers/postgres"
+	_ "github.com/oarkflow/squealx/drivers/postgres"
 )
 
 type PostgresDriver struct {
 	db    *squealx.DB
 	Force bool
+	// stmts caches prepared statements for parameterised queries
+	stmts stmtCache
+	// session statements run on each connection before applying SQL
+	session sessionSetup
+	// observer is told about every statement applied
+	observer StatementObserver
+}
+
+// Close releases cached prepared statements and closes the database.
+func (p *PostgresDriver) Close() error {
+	if err := p.stmts.close(); err != nil {
+		_ = p.db.Close()
+		return err
+	}
+	return p.db.Close()
+}
+
+// SetSessionSetup sets statements run once on every connection before it is
+// used, such as lock timeouts or search paths.
+func (p *PostgresDriver) SetSessionSetup(statements ...string) {
+	p.session.set(statements)
+}
+
+// SetStatementObserver sets fn to be called after every statement applied
+// through ApplyBatch or ApplySQL.
+func (p *PostgresDriver) SetStatementObserver(fn StatementObserver) {
+	p.observer = fn
 }
 
 func (p *PostgresDriver) SetForce(force bool) {
@@ -22,144 +50,69 @@ func NewPostgresDriverFromDB(db *squealx.DB) *PostgresDriver {
 }
 
 func NewPostgresDriver(dsn string) (*PostgresDriver, error) {
-	db, err := postgres.Open(dsn, "postgres")
+	p := &PostgresDriver{}
+	db, err := openWithSession("pgx", dsn, "postgres", &p.session)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open connection: %w", err)
 	}
 	if err := db.Ping(); err != nil {
+		_ = db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
-	return &PostgresDriver{db: db}, nil
+	p.db = db
+	return p, nil
 }
 
+// ApplySQL applies migrations in one transaction, binding args[0] to every
+// statement when given. It is shorthand for ApplyBatch.
 func (p *PostgresDriver) ApplySQL(migrations []string, args ...any) error {
-	// Flatten statements
-	var stmts []string
-	for _, query := range migrations {
-		parts := splitSQLStatements(query)
-		for _, q := range parts {
-			if strings.TrimSpace(q) != "" {
-				stmts = append(stmts, q)
-			}
-		}
-	}
+	return p.ApplyBatch(statementsFromSQL(migrations, args))
+}
+
+// ApplyBatch applies statements in order, each with its own arguments and
+// options. Statements share one transaction unless marked NoTransaction.
+func (p *PostgresDriver) ApplyBatch(statements []Statement) error {
+	stmts := flattenStatements(statements)
 	if len(stmts) == 0 {
 		return nil
 	}
 
 	// Check if this is a rollback operation (contains DROP statements)
 	isRollback := false
-	for _, q := range stmts {
-		l := strings.ToLower(strings.TrimSpace(q))
+	for _, st := range stmts {
+		l := strings.ToLower(strings.TrimSpace(st.SQL))
 		if strings.HasPrefix(l, "drop table") || strings.HasPrefix(l, "drop view") || strings.HasPrefix(l, "drop function") {
 			isRollback = true
 			break
 		}
 	}
 
-	// If the set of statements includes database-level operations (CREATE/DROP/ALTER DATABASE)
-	// they cannot be executed inside a transaction in Postgres. Execute all statements
-	// individually (without BEGIN/COMMIT) when any such statement is present.
-	hasDBStmt := false
-	for _, q := range stmts {
-		l := strings.ToLower(strings.TrimSpace(q))
+	// Database-level operations (CREATE/DROP/ALTER DATABASE) cannot be
+	// executed inside a transaction in Postgres; run them on their own so
+	// the statements around them stay transactional.
+	for i, st := range stmts {
+		l := strings.ToLower(strings.TrimSpace(st.SQL))
 		if strings.HasPrefix(l, "drop database") || strings.HasPrefix(l, "create database") || strings.HasPrefix(l, "alter database") {
-			hasDBStmt = true
-			break
-		}
-	}
-
-	if hasDBStmt {
-		for _, q := range stmts {
-			q = strings.TrimSpace(q)
-			if q == "" {
-				continue
-			}
-			if len(args) > 0 {
-				if _, err := p.db.NamedExec(q, args[0]); err != nil {
-					return fmt.Errorf("failed to execute query [%s]: %w", q, err)
-				}
-			} else {
-				if _, err := p.db.Exec(q); err != nil {
-					return fmt.Errorf("failed to execute query [%s]: %w", q, err)
-				}
-			}
+			stmts[i].Options.NoTransaction = true
 		}
-		return nil
 	}
 
 	// Force mode: execute each statement individually without transaction, log errors and continue
 	if p.Force {
-		for _, q := range stmts {
-			q = strings.TrimSpace(q)
-			if q == "" {
-				continue
-			}
-			var err error
-			if len(args) > 0 {
-				_, err = p.db.NamedExec(q, args[0])
-			} else {
-				_, err = p.db.Exec(q)
-			}
-			if err != nil {
-				fmt.Printf("[force] warning: statement failed: %s: %v\n", q, err)
-			}
-		}
-		return nil
-	}
-
-	// Begin transaction
-	if _, err := p.db.Exec("BEGIN;"); err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return applyEach(p.db, &p.session, stmts, true, p.observer)
 	}
 
-	// Disable foreign key checks for rollback operations
+	// A failed statement aborts a PostgreSQL transaction, so statements that
+	// may be skipped run inside a savepoint.
+	plan := txPlan{session: &p.session, observe: p.observer, savepoints: true}
 	if isRollback {
-		if _, err := p.db.Exec("SET session_replication_role = replica;"); err != nil {
-			_, _ = p.db.Exec("ROLLBACK;")
-			return fmt.Errorf("failed to disable foreign key constraints: %w", err)
-		}
-	}
-
-	// Execute statements with error tolerance for rollback operations
-	for _, q := range stmts {
-		q = strings.TrimSpace(q)
-		if q == "" {
-			continue
-		}
-		if len(args) > 0 {
-			if _, err := p.db.NamedExec(q, args[0]); err != nil {
-				if isRollback && p.isIgnorableError(err) {
-					continue // Skip errors for non-existent objects during rollback
-				}
-				_, _ = p.db.Exec("ROLLBACK;")
-				return fmt.Errorf("failed to execute query [%s]: %w", q, err)
-			}
-		} else {
-			if _, err := p.db.Exec(q); err != nil {
-				if isRollback && p.isIgnorableError(err) {
-					continue // Skip errors for non-existent objects during rollback
-				}
-				_, _ = p.db.Exec("ROLLBACK;")
-				return fmt.Errorf("failed to execute query [%s]: %w", q, err)
-			}
-		}
+		// Disable foreign key checks and tolerate missing objects for rollback
+		// operations.
+		plan.txSetup = []string{"SET session_replication_role = replica;"}
+		plan.txReset = []string{"SET session_replication_role = DEFAULT;"}
+		plan.ignorable = p.isIgnorableError
 	}
-
-	// Re-enable foreign key checks if they were disabled
-	if isRollback {
-		if _, err := p.db.Exec("SET session_replication_role = DEFAULT;"); err != nil {
-			_, _ = p.db.Exec("ROLLBACK;")
-			return fmt.Errorf("failed to re-enable foreign key constraints: %w", err)
-		}
-	}
-
-	// Commit
-	if _, err := p.db.Exec("COMMIT;"); err != nil {
-		_, _ = p.db.Exec("ROLLBACK;")
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
-	return nil
+	return applyBatch(p.db, &p.stmts, stmts, plan)
 }
 
 // isIgnorableError checks if an error can be safely ignored during rollback operations
@@ -177,3 +130,25 @@ func (p *PostgresDriver) isIgnorableError(err error) bool {
 func (p *PostgresDriver) DB() *squealx.DB {
 	return p.db
 }
+
+// QueryRow scans the first row of query into dest (a struct, map or scalar
+// pointer). It returns sql.ErrNoRows when the query yields no row.
+func (p *PostgresDriver) QueryRow(ctx context.Context, dest any, query string, args ...any) error {
+	return queryRow(ctx, p.db, &p.session, dest, query, args...)
+}
+
+// QueryRowReadOnly scans the first row of query into dest like QueryRow,
+// in a READ ONLY transaction, so the query cannot change data.
+func (p *PostgresDriver) QueryRowReadOnly(ctx context.Context, dest any, query string, args ...any) error {
+	return queryRowReadOnly(ctx, p.db, &p.session, "SET TRANSACTION READ ONLY", dest, query, args...)
+}
+
+// Query scans every row of query into dest, a pointer to a slice.
+func (p *PostgresDriver) Query(ctx context.Context, dest any, query string, args ...any) error {
+	return queryRows(ctx, p.db, &p.session, dest, query, args...)
+}
+
+// Ping verifies that the database is reachable.
+func (p *PostgresDriver) Ping(ctx context.Context) error {
+	return ping(ctx, p.db)
+}
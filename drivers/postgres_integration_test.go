@@ -1,7 +1,9 @@
 package drivers
 
 import (
+	"context"
 	"os"
+	"sync"
 	"testing"
 )
 
@@ -81,3 +83,68 @@ INSERT INTO tx_postgres_test (v) VALUES (5);`
 	_, _ = p.DB().Exec("DROP TABLE IF EXISTS tx_postgres_test;")
 	_, _ = p.DB().Exec("DROP FUNCTION IF EXISTS test_fn_increment(integer);")
 }
+
+// Statements of one ApplySQL call must share a session: temporary tables are
+// only visible to the connection that created them, so concurrent callers on
+// a pool would fail if BEGIN, the statements and COMMIT were spread across
+// connections.
+func TestPostgresApplySQL_UsesSingleSession(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("skipping postgres integration test; set TEST_POSTGRES_DSN to run")
+	}
+	p, err := NewPostgresDriver(dsn)
+	if err != nil {
+		t.Fatalf("failed to create postgres driver: %v", err)
+	}
+	defer func() { _ = p.Close() }()
+	p.DB().SetMaxOpenConns(4)
+
+	session := `CREATE TEMP TABLE tx_pg_session (id integer);
+INSERT INTO tx_pg_session (id) VALUES (1);
+DROP TABLE tx_pg_session;`
+	errs := make(chan error, 8)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- p.ApplySQL([]string{session})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("expected statements to share one session, got %v", err)
+		}
+	}
+}
+
+func TestPostgresQueryRowReadOnly_RejectsSideEffects(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("skipping postgres integration test; set TEST_POSTGRES_DSN to run")
+	}
+	p, err := NewPostgresDriver(dsn)
+	if err != nil {
+		t.Fatalf("failed to create postgres driver: %v", err)
+	}
+	defer func() { _ = p.Close() }()
+	_, _ = p.DB().Exec("DROP SEQUENCE IF EXISTS ro_postgres_seq;")
+	if _, err := p.DB().Exec("CREATE SEQUENCE ro_postgres_seq;"); err != nil {
+		t.Fatalf("create sequence: %v", err)
+	}
+	defer func() { _, _ = p.DB().Exec("DROP SEQUENCE IF EXISTS ro_postgres_seq;") }()
+
+	var v int64
+	if err := p.QueryRowReadOnly(context.Background(), &v, "SELECT setval('ro_postgres_seq', 42)"); err == nil {
+		t.Fatal("expected setval to fail in a read-only lookup")
+	}
+	if err := p.QueryRowReadOnly(context.Background(), &v, "SELECT 7"); err != nil || v != 7 {
+		t.Fatalf("read-only select = %d, %v", v, err)
+	}
+	if err := p.QueryRow(context.Background(), &v, "SELECT nextval('ro_postgres_seq')"); err != nil || v != 1 {
+		t.Fatalf("nextval after read-only lookup = %d, %v; want 1", v, err)
+	}
+}
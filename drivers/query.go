@@ -0,0 +1,87 @@
+package drivers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/oarkflow/squealx"
+)
+
+var errNoDatabase = errors.New("no database connection")
+
+// queryRow scans the first row of query into dest on a connection prepared
+// with session, so reads see the same search path and settings as migrations.
+func queryRow(ctx context.Context, db *squealx.DB, session *sessionSetup, dest any, query string, args ...any) error {
+	return withSession(ctx, db, session, func(conn *squealx.Conn) error {
+		return conn.GetContext(ctx, dest, query, args...)
+	})
+}
+
+// queryRows scans every row of query into dest, a pointer to a slice.
+func queryRows(ctx context.Context, db *squealx.DB, session *sessionSetup, dest any, query string, args ...any) error {
+	return withSession(ctx, db, session, func(conn *squealx.Conn) error {
+		return conn.SelectContext(ctx, dest, query, args...)
+	})
+}
+
+// queryRowReadOnly scans the first row of query into dest inside a read-only
+// transaction, which is rolled back, so not even a function with side
+// effects called by query can change data. setup runs first in the
+// transaction, e.g. SET TRANSACTION READ ONLY for drivers that ignore
+// sql.TxOptions.ReadOnly.
+func queryRowReadOnly(ctx context.Context, db *squealx.DB, session *sessionSetup, setup string, dest any, query string, args ...any) error {
+	return withSession(ctx, db, session, func(conn *squealx.Conn) error {
+		tx, err := conn.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		if setup != "" {
+			if _, err := tx.ExecContext(ctx, setup); err != nil {
+				return err
+			}
+		}
+		return tx.GetContext(ctx, dest, query, args...)
+	})
+}
+
+// queryRowQueryOnly scans the first row of query into dest on a connection
+// held in SQLite's query_only mode for the duration of the query. The mode
+// is turned off again before the connection returns to the pool, even when
+// ctx is done.
+func queryRowQueryOnly(ctx context.Context, db *squealx.DB, session *sessionSetup, dest any, query string, args ...any) error {
+	return withSession(ctx, db, session, func(conn *squealx.Conn) (err error) {
+		if _, err := conn.ExecContext(ctx, "PRAGMA query_only = ON"); err != nil {
+			return err
+		}
+		defer func() {
+			if _, resetErr := conn.ExecContext(context.WithoutCancel(ctx), "PRAGMA query_only = OFF"); resetErr != nil {
+				err = errors.Join(err, resetErr)
+			}
+		}()
+		return conn.GetContext(ctx, dest, query, args...)
+	})
+}
+
+func withSession(ctx context.Context, db *squealx.DB, session *sessionSetup, fn func(conn *squealx.Conn) error) error {
+	if db == nil {
+		return errNoDatabase
+	}
+	conn, err := db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := setupSession(ctx, conn, session); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func ping(ctx context.Context, db *squealx.DB) error {
+	if db == nil {
+		return errNoDatabase
+	}
+	return db.PingContext(ctx)
+}
@@ -0,0 +1,12 @@
+package drivers
+
+import "regexp"
+
+// passwordLiteral matches the password literals of CREATE/ALTER ROLE and
+// CREATE/ALTER USER statements.
+var passwordLiteral = regexp.MustCompile(`(?i)\b(PASSWORD|IDENTIFIED BY)\s+'(?:[^'\\]|''|\\.)*'`)
+
+// RedactPasswords masks the password literals in sql.
+func RedactPasswords(sql string) string {
+	return passwordLiteral.ReplaceAllString(sql, "$1 '[REDACTED]'")
+}
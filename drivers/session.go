@@ -0,0 +1,218 @@
+package drivers
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/oarkflow/squealx"
+)
+
+// sessionSetup holds the statements configured with SetSessionSetup. A
+// driver opened from a DSN runs them once on every new connection of its pool
+// (see sessionConnector) and again on a pooled connection after they change,
+// so every user of DB() sees the same settings. A driver built around an
+// existing database cannot hook its connections and runs them each time it
+// takes a connection from the pool. The zero value has no statements.
+type sessionSetup struct {
+	mu         sync.RWMutex
+	statements []string
+	generation uint64
+	// hooked is set when the pool's connections are prepared by a
+	// sessionConnector.
+	hooked bool
+}
+
+func (s *sessionSetup) set(statements []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statements = statements
+	s.generation++
+}
+
+func (s *sessionSetup) current() ([]string, uint64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.statements, s.generation
+}
+
+// setupSession runs the configured session statements on conn, e.g.
+// "SET lock_timeout = '5s'" or "SET search_path = app", unless the pool
+// already prepared it.
+func setupSession(ctx context.Context, conn *squealx.Conn, session *sessionSetup) error {
+	if session.hooked {
+		return nil
+	}
+	statements, _ := session.current()
+	for _, q := range statements {
+		if _, err := conn.ExecContext(ctx, q); err != nil {
+			return fmt.Errorf("failed to run session setup [%s]: %w", q, err)
+		}
+	}
+	return nil
+}
+
+// openWithSession opens a database whose connections run the statements of
+// session when they are created.
+func openWithSession(driverName, dsn, id string, session *sessionSetup) (*squealx.DB, error) {
+	probe, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	drv := probe.Driver()
+	_ = probe.Close()
+	var connector driver.Connector = dsnConnector{dsn: dsn, driver: drv}
+	if dc, ok := drv.(driver.DriverContext); ok {
+		if connector, err = dc.OpenConnector(dsn); err != nil {
+			return nil, err
+		}
+	}
+	session.hooked = true
+	db := sql.OpenDB(&sessionConnector{Connector: connector, session: session})
+	return squealx.NewDb(db, driverName, id), nil
+}
+
+// dsnConnector is the driver.Connector of a driver that has no
+// OpenConnector.
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// sessionConnector runs the session statements on every connection it
+// creates.
+type sessionConnector struct {
+	driver.Connector
+	session *sessionSetup
+}
+
+func (c *sessionConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statements, generation := c.session.current()
+	if err := execSession(ctx, conn, statements); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return &sessionConn{Conn: conn, session: c.session, generation: generation}, nil
+}
+
+// sessionConn is a pooled connection prepared with the session statements
+// current when generation was recorded. database/sql calls ResetSession
+// before reusing it, which brings it up to date after SetSessionSetup.
+// The other methods delegate to the driver's connection.
+type sessionConn struct {
+	driver.Conn
+	session    *sessionSetup
+	generation uint64
+}
+
+func (c *sessionConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		if err := resetter.ResetSession(ctx); err != nil {
+			return err
+		}
+	}
+	statements, generation := c.session.current()
+	if generation == c.generation {
+		return nil
+	}
+	if err := execSession(ctx, c.Conn, statements); err != nil {
+		// Discard the connection; the one opened instead reports the error.
+		return driver.ErrBadConn
+	}
+	c.generation = generation
+	return nil
+}
+
+func (c *sessionConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *sessionConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *sessionConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *sessionConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin() //nolint:staticcheck // fallback for drivers without BeginTx
+}
+
+func (c *sessionConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if execer, ok := c.Conn.(driver.ExecerContext); ok {
+		return execer.ExecContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *sessionConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if queryer, ok := c.Conn.(driver.QueryerContext); ok {
+		return queryer.QueryContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *sessionConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// execSession runs statements directly on a driver connection.
+func execSession(ctx context.Context, conn driver.Conn, statements []string) error {
+	for _, q := range statements {
+		if err := execDriverConn(ctx, conn, q); err != nil {
+			return fmt.Errorf("failed to run session setup [%s]: %w", q, err)
+		}
+	}
+	return nil
+}
+
+func execDriverConn(ctx context.Context, conn driver.Conn, query string) error {
+	if execer, ok := conn.(driver.ExecerContext); ok {
+		_, err := execer.ExecContext(ctx, query, nil)
+		if !errors.Is(err, driver.ErrSkip) {
+			return err
+		}
+	}
+	stmt, err := conn.Prepare(query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	if execer, ok := stmt.(driver.StmtExecContext); ok {
+		_, err = execer.ExecContext(ctx, nil)
+		return err
+	}
+	_, err = stmt.Exec(nil) //nolint:staticcheck // fallback for drivers without StmtExecContext
+	return err
+}
@@ -1,11 +1,20 @@
 package drivers
 
-import "strings"
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	triggerStatement = regexp.MustCompile(`(?is)^\s*CREATE\s+(?:TEMP\s+|TEMPORARY\s+)?TRIGGER\b.*\bBEGIN\b`)
+	triggerBodyEnd   = regexp.MustCompile(`(?i)\bEND\s*$`)
+)
 
 // splitSQLStatements splits SQL into statements while respecting quoted strings,
-// dollar-quoted string tags (e.g., $$ ... $$ or $tag$ ... $tag$), and SQL
-// comments ("--", "#", and block comments "/* ... */"). It returns trimmed
-// non-empty statements without the trailing semicolons.
+// dollar-quoted string tags (e.g., $$ ... $$ or $tag$ ... $tag$), SQL
+// comments ("--", "#", and block comments "/* ... */") and the BEGIN ... END
+// body of SQLite and MySQL triggers. It returns trimmed non-empty statements
+// without the trailing semicolons.
 func splitSQLStatements(query string) []string {
 	var stmts []string
 	s := query
@@ -98,6 +107,9 @@ func splitSQLStatements(query string) []string {
 		}
 		if ch == ';' {
 			stmt := strings.TrimSpace(s[start:i])
+			if triggerStatement.MatchString(stmt) && !triggerBodyEnd.MatchString(stmt) {
+				continue
+			}
 			if stmt != "" {
 				stmts = append(stmts, stmt)
 			}
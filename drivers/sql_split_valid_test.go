@@ -5,6 +5,14 @@ import (
 	"testing"
 )
 
+func TestSplitKeepsTriggerBody_Valid(t *testing.T) {
+	sql := "CREATE TRIGGER touch AFTER UPDATE ON notes BEGIN UPDATE notes SET n = 1; SELECT 'end;'; END; SELECT 1;"
+	stmts := splitSQLStatements(sql)
+	if len(stmts) != 2 || !strings.HasSuffix(stmts[0], "END") {
+		t.Fatalf("expected the trigger and a select, got %d: %v", len(stmts), stmts)
+	}
+}
+
 func TestSplitDollarQuotedFunction_Valid(t *testing.T) {
 	sql := `
 CREATE FUNCTION example() RETURNS void AS $$
@@ -1,9 +1,12 @@
 package drivers
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestSQLiteTransactionalRollbackOnFailure(t *testing.T) {
@@ -49,3 +52,202 @@ func TestSQLiteTransactionalRollbackOnFailure(t *testing.T) {
 		t.Fatalf("expected 1 row in tx_ok after commit, got %d", count)
 	}
 }
+
+func TestSQLiteTransactionUsesSingleConnection(t *testing.T) {
+	drv, err := NewSQLiteDriver(filepath.Join(t.TempDir(), "migrate_tx_session.db"))
+	if err != nil {
+		t.Fatalf("failed to create sqlite driver: %v", err)
+	}
+	defer drv.Close()
+	drv.DB().SetMaxOpenConns(4)
+
+	// Temporary tables are visible only to the session that created them, so
+	// this fails whenever statements of one ApplySQL call use different
+	// pooled connections.
+	session := "CREATE TEMP TABLE tx_session (id INTEGER); INSERT INTO tx_session (id) VALUES (1); INSERT INTO tx_session (id) SELECT id + 1 FROM tx_session;"
+	for i := 0; i < 8; i++ {
+		if err := drv.ApplySQL([]string{session + " DROP TABLE tx_session;"}); err != nil {
+			t.Fatalf("run %d: expected statements to share one session, got %v", i, err)
+		}
+	}
+
+	if err := drv.ApplySQL([]string{"CREATE TABLE tx_atomic (id INTEGER PRIMARY KEY);"}); err != nil {
+		t.Fatalf("create tx_atomic: %v", err)
+	}
+	bad := "INSERT INTO tx_atomic (id) VALUES (1); INSERT INTO tx_atomic (id) VALUES (2); INSERT INTO tx_atomic (id) VALUES (1);"
+	if err := drv.ApplySQL([]string{bad}); err == nil {
+		t.Fatal("expected duplicate key error")
+	}
+	var count int
+	if err := drv.DB().QueryRow("SELECT count(*) FROM tx_atomic").Scan(&count); err != nil {
+		t.Fatalf("count tx_atomic: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("expected rollback to discard every row of the failed batch, found %d", count)
+	}
+
+	// Rollback batches run on the pinned connection with foreign keys off and
+	// skip statements for objects that no longer exist.
+	if err := drv.ApplySQL([]string{"DROP TABLE tx_missing; DROP TABLE tx_atomic;"}); err != nil {
+		t.Fatalf("rollback batch: %v", err)
+	}
+	if err := drv.DB().QueryRow("SELECT count(*) FROM sqlite_master WHERE type='table' AND name='tx_atomic'").Scan(&count); err != nil {
+		t.Fatalf("query sqlite_master: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("expected tx_atomic to be dropped, found %d", count)
+	}
+}
+
+func TestSQLiteSessionSetupRunsOnEachConnection(t *testing.T) {
+	drv, err := NewSQLiteDriver(filepath.Join(t.TempDir(), "migrate_session_setup.db"))
+	if err != nil {
+		t.Fatalf("failed to create sqlite driver: %v", err)
+	}
+	defer drv.Close()
+	drv.DB().SetMaxOpenConns(4)
+	drv.SetSessionSetup("CREATE TEMP TABLE IF NOT EXISTS session_marker (id INTEGER)")
+
+	// The temporary table exists only if the setup ran on the connection that
+	// executes the statements.
+	for i := 0; i < 8; i++ {
+		if err := drv.ApplySQL([]string{"INSERT INTO session_marker (id) VALUES (1);"}); err != nil {
+			t.Fatalf("run %d: expected session setup before statements, got %v", i, err)
+		}
+	}
+
+	// The setup runs once per connection, not before every batch or query,
+	// and other users of DB() get prepared connections too.
+	if err := drv.ApplySQL([]string{"CREATE TABLE session_runs (id INTEGER PRIMARY KEY);"}); err != nil {
+		t.Fatalf("create session_runs: %v", err)
+	}
+	drv.SetSessionSetup("CREATE TEMP TABLE IF NOT EXISTS session_marker (id INTEGER)", "INSERT INTO session_runs DEFAULT VALUES")
+	for i := 0; i < 8; i++ {
+		if err := drv.ApplySQL([]string{"INSERT INTO session_marker (id) VALUES (1);"}); err != nil {
+			t.Fatalf("run %d after changing the setup: %v", i, err)
+		}
+		var marked int
+		if err := drv.QueryRow(context.Background(), &marked, "SELECT count(*) FROM session_marker"); err != nil {
+			t.Fatalf("query %d: %v", i, err)
+		}
+	}
+	var runs int
+	if err := drv.DB().QueryRow("SELECT count(*) FROM session_runs").Scan(&runs); err != nil {
+		t.Fatalf("count session_runs through DB(): %v", err)
+	}
+	if open := drv.DB().Stats().OpenConnections; runs == 0 || runs > open {
+		t.Fatalf("session setup ran %d times on %d connections, want once per connection", runs, open)
+	}
+
+	drv.SetSessionSetup("SELECT * FROM session_missing")
+	if err := drv.ApplySQL([]string{"SELECT 1;"}); err == nil {
+		t.Fatal("expected failing session setup to abort ApplySQL")
+	}
+}
+
+func TestSQLiteSessionSetupOnExistingDatabase(t *testing.T) {
+	opened, err := NewSQLiteDriver(filepath.Join(t.TempDir(), "migrate_session_fromdb.db"))
+	if err != nil {
+		t.Fatalf("failed to create sqlite driver: %v", err)
+	}
+	defer opened.Close()
+	// A driver around a database it did not open cannot hook new
+	// connections, so it prepares each connection it takes.
+	drv := NewSQLiteDriverFromDB(opened.DB())
+	drv.SetSessionSetup("CREATE TEMP TABLE IF NOT EXISTS session_marker (id INTEGER)")
+	if err := drv.ApplySQL([]string{"INSERT INTO session_marker (id) VALUES (1);"}); err != nil {
+		t.Fatalf("expected session setup before statements, got %v", err)
+	}
+}
+
+func TestSQLiteContinueOnErrorSkipsFailedStatement(t *testing.T) {
+	drv, err := NewSQLiteDriver(filepath.Join(t.TempDir(), "migrate_continue.db"))
+	if err != nil {
+		t.Fatalf("failed to create sqlite driver: %v", err)
+	}
+	defer drv.Close()
+
+	batch := func(continueOnError bool) []Statement {
+		return []Statement{
+			{SQL: "CREATE TABLE optional_items (id INTEGER PRIMARY KEY);"},
+			{SQL: "INSERT INTO optional_missing (id) VALUES (1);", Options: StatementOptions{ContinueOnError: continueOnError}},
+			{SQL: "INSERT INTO optional_items (id) VALUES (1);"},
+		}
+	}
+	if err := drv.ApplyBatch(batch(false)); err == nil {
+		t.Fatal("expected failure without continue_on_error")
+	}
+	if err := drv.ApplyBatch(batch(true)); err != nil {
+		t.Fatalf("expected optional statement to be skipped, got %v", err)
+	}
+	var count int
+	if err := drv.DB().QueryRow("SELECT count(*) FROM optional_items").Scan(&count); err != nil {
+		t.Fatalf("count optional_items: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("expected statements after the skipped one to commit, found %d rows", count)
+	}
+}
+
+func TestSQLiteApplyBatchBindsArgsPerStatement(t *testing.T) {
+	drv, err := NewSQLiteDriver(filepath.Join(t.TempDir(), "migrate_batch.db"))
+	if err != nil {
+		t.Fatalf("failed to create sqlite driver: %v", err)
+	}
+	defer drv.Close()
+
+	err = drv.ApplyBatch([]Statement{
+		{SQL: "CREATE TABLE batch_items (id INTEGER PRIMARY KEY, name TEXT NOT NULL);"},
+		{SQL: "INSERT INTO batch_items (id, name) VALUES (:id, :name);", Args: map[string]any{"id": 1, "name": "first"}},
+		{SQL: "INSERT INTO batch_items (id, name) VALUES (:id, :name);", Args: map[string]any{"id": 2, "name": "second"}},
+		{SQL: "VACUUM;", Options: StatementOptions{NoTransaction: true, Timeout: time.Minute}},
+		{SQL: "UPDATE batch_items SET name = :name WHERE id = 1;", Args: map[string]any{"name": "renamed"}},
+	})
+	if err != nil {
+		t.Fatalf("ApplyBatch: %v", err)
+	}
+	var names []string
+	if err := drv.DB().Select(&names, "SELECT name FROM batch_items ORDER BY id"); err != nil {
+		t.Fatalf("select names: %v", err)
+	}
+	if len(names) != 2 || names[0] != "renamed" || names[1] != "second" {
+		t.Fatalf("names = %q, want [renamed second]", names)
+	}
+
+	// A statement that outlives its timeout fails the batch.
+	err = drv.ApplyBatch([]Statement{{
+		SQL:     "WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n) SELECT count(*) FROM n;",
+		Options: StatementOptions{Timeout: 50 * time.Millisecond},
+	}})
+	if err == nil {
+		t.Fatal("expected timeout error")
+	}
+}
+
+func TestSQLiteStatementErrorsRedactPasswords(t *testing.T) {
+	drv, err := NewSQLiteDriver(filepath.Join(t.TempDir(), "migrate_redact.db"))
+	if err != nil {
+		t.Fatalf("failed to create sqlite driver: %v", err)
+	}
+	defer drv.Close()
+	var observed []string
+	drv.SetStatementObserver(func(sql string, _ time.Duration, _ error) {
+		observed = append(observed, sql)
+	})
+
+	err = drv.ApplySQL([]string{"CREATE ROLE billing WITH LOGIN PASSWORD 'hunter2';"})
+	if err == nil {
+		t.Fatal("expected CREATE ROLE to fail on sqlite")
+	}
+	if strings.Contains(err.Error(), "hunter2") || !strings.Contains(err.Error(), "PASSWORD '[REDACTED]'") {
+		t.Fatalf("error does not redact the password: %v", err)
+	}
+	if len(observed) == 0 {
+		t.Fatal("expected the observer to see the statement")
+	}
+	for _, sql := range observed {
+		if strings.Contains(sql, "hunter2") {
+			t.Fatalf("observer saw the password in %q", sql)
+		}
+	}
+}
@@ -1,16 +1,44 @@
 package drivers
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
 	"github.com/oarkflow/squealx"
-	"github.com/oarkflow/squealx/drivers/sqlite"
+	_ "github.com/oarkflow/squealx/drivers/sqlite"
 )
 
 type SQLiteDriver struct {
 	db    *squealx.DB
 	Force bool
+	// stmts caches prepared statements for parameterised queries
+	stmts stmtCache
+	// session statements run on each connection before applying SQL
+	session sessionSetup
+	// observer is told about every statement applied
+	observer StatementObserver
+}
+
+// Close releases cached prepared statements and closes the database.
+func (s *SQLiteDriver) Close() error {
+	if err := s.stmts.close(); err != nil {
+		_ = s.db.Close()
+		return err
+	}
+	return s.db.Close()
+}
+
+// SetSessionSetup sets statements run once on every connection before it is
+// used, such as lock timeouts or search paths.
+func (s *SQLiteDriver) SetSessionSetup(statements ...string) {
+	s.session.set(statements)
+}
+
+// SetStatementObserver sets fn to be called after every statement applied
+// through ApplyBatch or ApplySQL.
+func (s *SQLiteDriver) SetStatementObserver(fn StatementObserver) {
+	s.observer = fn
 }
 
 func (s *SQLiteDriver) SetForce(force bool) {
@@ -22,116 +50,86 @@ func NewSQLiteDriverFromDB(db *squealx.DB) *SQLiteDriver {
 }
 
 func NewSQLiteDriver(dbPath string) (*SQLiteDriver, error) {
-	db, err := sqlite.Open(dbPath, "sqlite3")
+	s := &SQLiteDriver{}
+	db, err := openWithSession("sqlite", dbPath, "sqlite3", &s.session)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
 	}
 	if err := db.Ping(); err != nil {
+		_ = db.Close()
 		return nil, fmt.Errorf("failed to ping sqlite database: %w", err)
 	}
-	return &SQLiteDriver{db: db}, nil
+	s.db = db
+	return s, nil
 }
 
+// ApplySQL applies migrations in one transaction, binding args[0] to every
+// statement when given. It is shorthand for ApplyBatch.
 func (s *SQLiteDriver) ApplySQL(migrations []string, args ...any) error {
-	// Flatten statements
-	var stmts []string
-	for _, query := range migrations {
-		parts := splitSQLStatements(query)
-		for _, q := range parts {
-			if strings.TrimSpace(q) != "" {
-				stmts = append(stmts, q)
-			}
-		}
-	}
+	return s.ApplyBatch(statementsFromSQL(migrations, args))
+}
+
+// ApplyBatch applies statements in order, each with its own arguments and
+// options. Statements share one transaction unless marked NoTransaction.
+func (s *SQLiteDriver) ApplyBatch(statements []Statement) error {
+	stmts := flattenStatements(statements)
 	if len(stmts) == 0 {
 		return nil
 	}
 
 	// Force mode: execute each statement individually without transaction, log errors and continue
 	if s.Force {
-		for _, q := range stmts {
-			q = strings.TrimSpace(q)
-			if q == "" {
-				continue
-			}
-			var err error
-			if len(args) > 0 {
-				_, err = s.db.NamedExec(q, args[0])
-			} else {
-				_, err = s.db.Exec(q)
-			}
-			if err != nil {
-				fmt.Printf("[force] warning: statement failed: %s: %v\n", q, err)
-			}
-		}
-		return nil
+		return applyEach(s.db, &s.session, stmts, true, s.observer)
 	}
 
 	// Check if this is a rollback operation (contains DROP statements)
 	isRollback := false
-	for _, q := range stmts {
-		l := strings.ToLower(strings.TrimSpace(q))
+	for _, st := range stmts {
+		l := strings.ToLower(strings.TrimSpace(st.SQL))
 		if strings.HasPrefix(l, "drop table") || strings.HasPrefix(l, "drop view") || strings.HasPrefix(l, "drop function") {
 			isRollback = true
 			break
 		}
 	}
 
-	// Begin transaction
-	if _, err := s.db.Exec("BEGIN;"); err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-
-	// Disable foreign key checks for rollback operations
+	plan := txPlan{session: &s.session, observe: s.observer, savepoints: true}
 	if isRollback {
-		if _, err := s.db.Exec("PRAGMA foreign_keys = OFF;"); err != nil {
-			_, _ = s.db.Exec("ROLLBACK;")
-			return fmt.Errorf("failed to disable foreign key checks: %w", err)
-		}
+		// Disable foreign key checks and tolerate missing objects for rollback
+		// operations. SQLite ignores foreign_keys changes inside a transaction,
+		// so the PRAGMA runs on the pinned connection around it.
+		plan.connSetup = []string{"PRAGMA foreign_keys = OFF;"}
+		plan.connReset = []string{"PRAGMA foreign_keys = ON;"}
+		plan.ignorable = s.isIgnorableError
 	}
+	return applyBatch(s.db, &s.stmts, stmts, plan)
+}
 
-	for _, q := range stmts {
-		q = strings.TrimSpace(q)
-		if q == "" {
-			continue
-		}
-		if len(args) > 0 {
-			if _, err := s.db.NamedExec(q, args[0]); err != nil {
-				if isRollback && s.isIgnorableError(err) {
-					continue // Skip errors for non-existent objects during rollback
-				}
-				_, _ = s.db.Exec("ROLLBACK;")
-				return fmt.Errorf("failed to execute query [%s]: %w", q, err)
-			}
-		} else {
-			if _, err := s.db.Exec(q); err != nil {
-				if isRollback && s.isIgnorableError(err) {
-					continue // Skip errors for non-existent objects during rollback
-				}
-				_, _ = s.db.Exec("ROLLBACK;")
-				return fmt.Errorf("failed to execute query [%s]: %w", q, err)
-			}
-		}
-	}
+func (m *SQLiteDriver) DB() *squealx.DB {
+	return m.db
+}
 
-	// Re-enable foreign key checks if they were disabled
-	if isRollback {
-		if _, err := s.db.Exec("PRAGMA foreign_keys = ON;"); err != nil {
-			_, _ = s.db.Exec("ROLLBACK;")
-			return fmt.Errorf("failed to re-enable foreign key checks: %w", err)
-		}
-	}
+// QueryRow scans the first row of query into dest (a struct, map or scalar
+// pointer). It returns sql.ErrNoRows when the query yields no row.
+func (s *SQLiteDriver) QueryRow(ctx context.Context, dest any, query string, args ...any) error {
+	return queryRow(ctx, s.db, &s.session, dest, query, args...)
+}
 
-	if _, err := s.db.Exec("COMMIT;"); err != nil {
-		_, _ = s.db.Exec("ROLLBACK;")
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
-	return nil
+// QueryRowReadOnly scans the first row of query into dest like QueryRow,
+// on a connection in query_only mode, so the query cannot change data.
+func (s *SQLiteDriver) QueryRowReadOnly(ctx context.Context, dest any, query string, args ...any) error {
+	return queryRowQueryOnly(ctx, s.db, &s.session, dest, query, args...)
 }
 
-func (m *SQLiteDriver) DB() *squealx.DB {
-	return m.db
+// Query scans every row of query into dest, a pointer to a slice.
+func (s *SQLiteDriver) Query(ctx context.Context, dest any, query string, args ...any) error {
+	return queryRows(ctx, s.db, &s.session, dest, query, args...)
 }
+
+// Ping verifies that the database is reachable.
+func (s *SQLiteDriver) Ping(ctx context.Context) error {
+	return ping(ctx, s.db)
+}
+
 // isIgnorableError checks if an error can be safely ignored during rollback operations
 func (s *SQLiteDriver) isIgnorableError(err error) bool {
 	errStr := strings.ToLower(err.Error())
@@ -140,4 +138,4 @@ func (s *SQLiteDriver) isIgnorableError(err error) bool {
 		strings.Contains(errStr, "no such column") ||
 		strings.Contains(errStr, "no such index") ||
 		strings.Contains(errStr, "no such trigger")
-}
\ No newline at end of file
+}
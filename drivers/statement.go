@@ -0,0 +1,89 @@
+package drivers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Statement is a single unit of SQL handed to a driver's ApplyBatch,
+// carrying its own arguments and execution options.
+type Statement struct {
+	SQL string
+	// Args is bound to the named parameters of SQL (a map or struct); nil
+	// executes SQL as is.
+	Args    any
+	Options StatementOptions
+}
+
+// StatementOptions control how a Statement is executed.
+type StatementOptions struct {
+	// Timeout cancels the statement when it runs longer; zero means no limit.
+	Timeout time.Duration
+	// NoTransaction runs the statement on its own outside the batch
+	// transaction, for statements such as CREATE INDEX CONCURRENTLY.
+	NoTransaction bool
+	// ContinueOnError reports and skips a failure instead of aborting the
+	// batch (continue_on_error in BCL).
+	ContinueOnError bool
+}
+
+// StatementObserver is called after each statement a driver executes with
+// its SQL, how long it ran and its error, if any.
+type StatementObserver func(sql string, elapsed time.Duration, err error)
+
+// observe reports st to fn when set.
+func (fn StatementObserver) observe(st Statement, started time.Time, err error) {
+	if fn != nil {
+		fn(RedactPasswords(st.SQL), time.Since(started), err)
+	}
+}
+
+// StatementError reports the statement of a batch that failed.
+type StatementError struct {
+	// Statement is the SQL that failed.
+	Statement string
+	// Position is the 1-based position of Statement among the statements of
+	// the batch after splitting.
+	Position int
+	Err      error
+}
+
+func (e *StatementError) Error() string {
+	return fmt.Sprintf("failed to execute query [%s]: %v", RedactPasswords(e.Statement), e.Err)
+}
+
+func (e *StatementError) Unwrap() error {
+	return e.Err
+}
+
+// statementsFromSQL converts the arguments of ApplySQL into statements,
+// binding args[0] to every query when given.
+func statementsFromSQL(queries []string, args []any) []Statement {
+	var arg any
+	if len(args) > 0 {
+		arg = args[0]
+	}
+	stmts := make([]Statement, len(queries))
+	for i, q := range queries {
+		stmts[i] = Statement{SQL: q, Args: arg}
+	}
+	return stmts
+}
+
+// flattenStatements splits every statement into single SQL statements. Each
+// part keeps the arguments and options of the statement it came from.
+func flattenStatements(statements []Statement) []Statement {
+	var stmts []Statement
+	for _, st := range statements {
+		for _, q := range splitSQLStatements(st.SQL) {
+			if strings.TrimSpace(q) == "" {
+				continue
+			}
+			part := st
+			part.SQL = q
+			stmts = append(stmts, part)
+		}
+	}
+	return stmts
+}
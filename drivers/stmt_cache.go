@@ -0,0 +1,149 @@
+package drivers
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/oarkflow/squealx"
+)
+
+// maxCachedStatements bounds the number of prepared statements kept per driver.
+const maxCachedStatements = 128
+
+// stmtCache keeps prepared named statements so that repeated identical SQL
+// with different arguments (typically seed INSERTs) is parsed by the server
+// once instead of on every execution. The zero value is ready to use.
+type stmtCache struct {
+	mu    sync.Mutex
+	stmts map[string]*cachedStmt
+	order []string
+}
+
+// cachedStmt counts the executions using a prepared statement, so a
+// statement dropped from the cache while seeds run in parallel is closed
+// only after its last user is done with it.
+type cachedStmt struct {
+	stmt    *squealx.NamedStmt
+	users   int
+	dropped bool
+}
+
+// namedExec executes query with arg through a cached prepared statement.
+// A statement that fails is evicted so a later call prepares it afresh, for
+// example after the underlying table was altered.
+func (c *stmtCache) namedExec(db *squealx.DB, query string, arg any) (sql.Result, error) {
+	entry, err := c.acquire(db, query)
+	if err != nil {
+		return nil, err
+	}
+	res, err := entry.stmt.Exec(arg)
+	c.release(query, entry, err != nil)
+	return res, err
+}
+
+// namedExecTx is namedExec bound to tx, reusing the cached statement on the
+// transaction's connection. The transaction-bound copy is released when tx
+// ends; closing it explicitly would close the cached statement. A query that
+// cannot be prepared outside tx, e.g. because it uses a table created earlier
+// in the same transaction, is executed directly.
+func (c *stmtCache) namedExecTx(ctx context.Context, db *squealx.DB, tx *squealx.Tx, query string, arg any) error {
+	entry, err := c.acquire(db, query)
+	if err != nil {
+		_, err = tx.NamedExecContext(ctx, query, arg)
+		return err
+	}
+	// tx.NamedStmt would leave the statement on its own connection, outside
+	// tx; NamedStmtContext binds it to tx.
+	_, err = tx.NamedStmtContext(ctx, entry.stmt).ExecContext(ctx, arg)
+	c.release(query, entry, err != nil)
+	return err
+}
+
+// acquire returns the cached statement for query, preparing it when needed,
+// and registers the caller as a user until release. Preparing happens
+// outside the lock so statements for different queries are prepared
+// concurrently.
+func (c *stmtCache) acquire(db *squealx.DB, query string) (*cachedStmt, error) {
+	c.mu.Lock()
+	if entry, ok := c.stmts[query]; ok {
+		entry.users++
+		c.mu.Unlock()
+		return entry, nil
+	}
+	c.mu.Unlock()
+
+	stmt, err := db.PrepareNamed(query)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.stmts[query]; ok {
+		// Another caller prepared the same query meanwhile.
+		_ = stmt.Close()
+		entry.users++
+		return entry, nil
+	}
+	if c.stmts == nil {
+		c.stmts = make(map[string]*cachedStmt)
+	}
+	if len(c.order) >= maxCachedStatements {
+		c.drop(c.order[0])
+	}
+	entry := &cachedStmt{stmt: stmt, users: 1}
+	c.stmts[query] = entry
+	c.order = append(c.order, query)
+	return entry, nil
+}
+
+// release ends a use of entry. A failed execution evicts the statement so it
+// is prepared again next time.
+func (c *stmtCache) release(query string, entry *cachedStmt, failed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.users--
+	if failed && c.stmts[query] == entry {
+		c.drop(query)
+		return
+	}
+	if entry.dropped && entry.users == 0 {
+		_ = entry.stmt.Close()
+	}
+}
+
+// drop removes query from the cache, closing its statement unless it is
+// still in use; the last user closes it then. c.mu must be held.
+func (c *stmtCache) drop(query string) {
+	entry, ok := c.stmts[query]
+	if !ok {
+		return
+	}
+	delete(c.stmts, query)
+	for i, q := range c.order {
+		if q == query {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+	entry.dropped = true
+	if entry.users == 0 {
+		_ = entry.stmt.Close()
+	}
+}
+
+// close releases every cached statement.
+func (c *stmtCache) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var firstErr error
+	for _, entry := range c.stmts {
+		if err := entry.stmt.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	c.stmts = nil
+	c.order = nil
+	return firstErr
+}
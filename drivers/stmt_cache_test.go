@@ -0,0 +1,111 @@
+package drivers
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestSQLitePreparedStatementReuse(t *testing.T) {
+	drv, err := NewSQLiteDriver(filepath.Join(t.TempDir(), "stmt_cache.db"))
+	if err != nil {
+		t.Fatalf("failed to create sqlite driver: %v", err)
+	}
+	defer drv.Close()
+
+	if err := drv.ApplySQL([]string{"CREATE TABLE stmt_items (id INTEGER PRIMARY KEY, name TEXT NOT NULL);"}); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	insert := "INSERT INTO stmt_items (id, name) VALUES (:id, :name);"
+	for i := 1; i <= 5; i++ {
+		if err := drv.ApplySQL([]string{insert}, map[string]any{"id": i, "name": "item"}); err != nil {
+			t.Fatalf("insert %d: %v", i, err)
+		}
+	}
+	if got := len(drv.stmts.stmts); got != 1 {
+		t.Fatalf("cached statements = %d, want 1", got)
+	}
+	var count int
+	if err := drv.DB().QueryRow("SELECT count(*) FROM stmt_items").Scan(&count); err != nil {
+		t.Fatalf("count rows: %v", err)
+	}
+	if count != 5 {
+		t.Fatalf("rows = %d, want 5", count)
+	}
+
+	// A failing execution evicts the statement so it is prepared again next time.
+	if err := drv.ApplySQL([]string{insert}, map[string]any{"id": 1, "name": "duplicate"}); err == nil {
+		t.Fatal("expected duplicate key error")
+	}
+	if got := len(drv.stmts.stmts); got != 0 {
+		t.Fatalf("cached statements after failure = %d, want 0", got)
+	}
+	if err := drv.ApplySQL([]string{insert}, map[string]any{"id": 6, "name": "item"}); err != nil {
+		t.Fatalf("insert after eviction: %v", err)
+	}
+}
+
+func TestSQLiteCachedStatementsJoinTheTransaction(t *testing.T) {
+	drv, err := NewSQLiteDriver(filepath.Join(t.TempDir(), "stmt_tx.db"))
+	if err != nil {
+		t.Fatalf("failed to create sqlite driver: %v", err)
+	}
+	defer drv.Close()
+
+	if err := drv.ApplySQL([]string{"CREATE TABLE stmt_items (id INTEGER PRIMARY KEY, name TEXT NOT NULL);"}); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	// The second insert repeats the key, so the cached first insert must be
+	// rolled back with it.
+	insert := "INSERT INTO stmt_items (id, name) VALUES (:id, :name);"
+	if err := drv.ApplySQL([]string{insert + " " + insert}, map[string]any{"id": 1, "name": "item"}); err == nil {
+		t.Fatal("expected duplicate key error")
+	}
+	var count int
+	if err := drv.DB().QueryRow("SELECT count(*) FROM stmt_items").Scan(&count); err != nil {
+		t.Fatalf("count rows: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("rows = %d, want 0", count)
+	}
+}
+
+func TestStmtCacheEvictionWaitsForRunningStatements(t *testing.T) {
+	drv, err := NewSQLiteDriver(filepath.Join(t.TempDir(), "stmt_evict.db"))
+	if err != nil {
+		t.Fatalf("failed to create sqlite driver: %v", err)
+	}
+	defer drv.Close()
+	drv.DB().SetMaxOpenConns(8)
+
+	// Twice as many distinct queries as the cache holds, run from several
+	// goroutines, so statements are evicted while others still use them.
+	var cache stmtCache
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 2*maxCachedStatements; i++ {
+				query := fmt.Sprintf("SELECT :id + %d;", (i+w*31)%(2*maxCachedStatements))
+				if _, err := cache.namedExec(drv.DB(), query, map[string]any{"id": i}); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("namedExec: %v", err)
+	}
+	if got := len(cache.stmts); got > maxCachedStatements {
+		t.Fatalf("cached statements = %d, want at most %d", got, maxCachedStatements)
+	}
+	if err := cache.close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+}
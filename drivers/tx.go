@@ -0,0 +1,217 @@
+package drivers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/oarkflow/squealx"
+)
+
+// txPlan describes how a driver runs a group of statements atomically.
+type txPlan struct {
+	// session holds the configured per-connection setup statements.
+	session *sessionSetup
+	// connSetup and connReset run on the pinned connection outside the
+	// transaction, for settings that cannot change inside one (SQLite PRAGMAs).
+	connSetup, connReset []string
+	// txSetup and txReset run inside the transaction around the statements.
+	txSetup, txReset []string
+	// ignorable reports statement errors that are skipped instead of failing.
+	ignorable func(error) bool
+	// savepoints wraps each statement that may be skipped in a SAVEPOINT so a
+	// skipped failure is undone without aborting the transaction.
+	savepoints bool
+	// observe is told about every statement of the batch.
+	observe StatementObserver
+}
+
+// applyBatch runs stmts in order. Consecutive transactional statements share
+// one transaction (see applyInTx); a NoTransaction statement commits the
+// statements before it and then runs on its own.
+func applyBatch(db *squealx.DB, cache *stmtCache, stmts []Statement, plan txPlan) error {
+	start := 0
+	for i, st := range stmts {
+		if !st.Options.NoTransaction {
+			continue
+		}
+		if i > start {
+			if err := applyInTx(db, cache, stmts[start:i], plan); err != nil {
+				return offsetStatementError(err, start)
+			}
+		}
+		if err := applyEach(db, plan.session, stmts[i:i+1], false, plan.observe); err != nil {
+			return offsetStatementError(err, i)
+		}
+		start = i + 1
+	}
+	if start < len(stmts) {
+		return offsetStatementError(applyInTx(db, cache, stmts[start:], plan), start)
+	}
+	return nil
+}
+
+// offsetStatementError shifts the Position of a StatementError from a
+// sub-batch starting at offset to the whole batch.
+func offsetStatementError(err error, offset int) error {
+	var stErr *StatementError
+	if errors.As(err, &stErr) {
+		stErr.Position += offset
+	}
+	return err
+}
+
+// applyInTx runs stmts in one transaction on a single pinned connection, so
+// setup, statements and COMMIT/ROLLBACK cannot land on different sessions of
+// the pool. Statements with Args are executed through the prepared statement
+// cache.
+func applyInTx(db *squealx.DB, cache *stmtCache, stmts []Statement, plan txPlan) (err error) {
+	ctx := context.Background()
+	conn, err := db.Connx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	if err := setupSession(ctx, conn, plan.session); err != nil {
+		return err
+	}
+	for _, q := range plan.connSetup {
+		if _, err := conn.ExecContext(ctx, q); err != nil {
+			return fmt.Errorf("failed to prepare connection [%s]: %w", q, err)
+		}
+	}
+	defer func() {
+		for _, q := range plan.connReset {
+			if _, resetErr := conn.ExecContext(ctx, q); resetErr != nil && err == nil {
+				err = fmt.Errorf("failed to reset connection [%s]: %w", q, resetErr)
+			}
+		}
+	}()
+
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	for _, q := range plan.txSetup {
+		if _, err := tx.Exec(q); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("failed to prepare transaction [%s]: %w", q, err)
+		}
+	}
+	for i, st := range stmts {
+		optional := st.Options.ContinueOnError
+		useSavepoints := plan.savepoints && (plan.ignorable != nil || optional)
+		if useSavepoints {
+			if _, err := tx.Exec("SAVEPOINT migrate_stmt"); err != nil {
+				_ = tx.Rollback()
+				return fmt.Errorf("failed to create savepoint: %w", err)
+			}
+		}
+		started := time.Now()
+		execErr := execInTx(ctx, db, tx, cache, st)
+		plan.observe.observe(st, started, execErr)
+		if execErr != nil {
+			if optional || (plan.ignorable != nil && plan.ignorable(execErr)) {
+				if useSavepoints {
+					if _, err := tx.Exec("ROLLBACK TO SAVEPOINT migrate_stmt"); err != nil {
+						_ = tx.Rollback()
+						return fmt.Errorf("failed to roll back to savepoint: %w", err)
+					}
+				}
+				if optional {
+					fmt.Printf("warning: continue_on_error statement failed: %s: %v\n", RedactPasswords(st.SQL), execErr)
+				}
+				continue // Skip errors for non-existent objects during rollback
+			}
+			_ = tx.Rollback()
+			return &StatementError{Statement: st.SQL, Position: i + 1, Err: execErr}
+		}
+		if useSavepoints {
+			if _, err := tx.Exec("RELEASE SAVEPOINT migrate_stmt"); err != nil {
+				_ = tx.Rollback()
+				return fmt.Errorf("failed to release savepoint: %w", err)
+			}
+		}
+	}
+	for _, q := range plan.txReset {
+		if _, err := tx.Exec(q); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("failed to reset transaction [%s]: %w", q, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// execInTx executes st inside tx within its timeout.
+func execInTx(ctx context.Context, db *squealx.DB, tx *squealx.Tx, cache *stmtCache, st Statement) error {
+	ctx, cancel := statementContext(ctx, st)
+	defer cancel()
+	if st.Args != nil {
+		return cache.namedExecTx(ctx, db, tx, st.SQL, st.Args)
+	}
+	_, err := tx.ExecContext(ctx, st.SQL)
+	return err
+}
+
+// applyEach runs stmts one by one outside a transaction on a single pinned
+// connection prepared with session. With keepGoing set, failures are reported
+// and skipped (force mode); otherwise the first failure of a statement without
+// ContinueOnError is returned. Each statement is reported to observe.
+func applyEach(db *squealx.DB, session *sessionSetup, stmts []Statement, keepGoing bool, observe StatementObserver) error {
+	ctx := context.Background()
+	conn, err := db.Connx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+	if err := setupSession(ctx, conn, session); err != nil {
+		return err
+	}
+	for i, st := range stmts {
+		started := time.Now()
+		err := execOnConn(ctx, db, conn, st)
+		observe.observe(st, started, err)
+		if err != nil {
+			if keepGoing {
+				fmt.Printf("[force] warning: statement failed: %s: %v\n", RedactPasswords(st.SQL), err)
+				continue
+			}
+			if st.Options.ContinueOnError {
+				fmt.Printf("warning: continue_on_error statement failed: %s: %v\n", RedactPasswords(st.SQL), err)
+				continue
+			}
+			return &StatementError{Statement: st.SQL, Position: i + 1, Err: err}
+		}
+	}
+	return nil
+}
+
+// execOnConn executes st on conn within its timeout, binding its Args.
+func execOnConn(ctx context.Context, db *squealx.DB, conn *squealx.Conn, st Statement) error {
+	query, bound := st.SQL, []any(nil)
+	if st.Args != nil {
+		var err error
+		query, bound, err = db.BindNamed(st.SQL, st.Args)
+		if err != nil {
+			return err
+		}
+	}
+	ctx, cancel := statementContext(ctx, st)
+	defer cancel()
+	_, err := conn.ExecContext(ctx, query, bound...)
+	return err
+}
+
+// statementContext derives the execution context of st from ctx.
+func statementContext(ctx context.Context, st Statement) (context.Context, context.CancelFunc) {
+	if st.Options.Timeout > 0 {
+		return context.WithTimeout(ctx, st.Options.Timeout)
+	}
+	return context.WithCancel(ctx)
+}
@@ -0,0 +1,169 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// DSLVersion is the version of the migration DSL written and understood by
+// this build. Version 1 covers files written before migrations recorded a
+// version; version 2 records DSLVersion and ToolVersion in every Migration
+// block and spells Disable as Disabled.
+const DSLVersion = 2
+
+// AuthoredDSLVersion returns the DSL version m was written for. Migrations
+// without DSLVersion are version 1.
+func (m Migration) AuthoredDSLVersion() int {
+	if m.DSLVersion == 0 {
+		return 1
+	}
+	return m.DSLVersion
+}
+
+// dslVersionWarning returns a warning when m was written for a newer DSL
+// than this build understands, or "" when it was not.
+func dslVersionWarning(m Migration) string {
+	if m.AuthoredDSLVersion() <= DSLVersion {
+		return ""
+	}
+	by := ""
+	if m.ToolVersion != "" {
+		by = " by migrate " + m.ToolVersion
+	}
+	return fmt.Sprintf("Migration %s was written%s for DSL version %d, but migrate %s supports up to version %d; settings it relies on may be ignored. Upgrade migrate before applying it", m.Name, by, m.AuthoredDSLVersion(), Version, DSLVersion)
+}
+
+var (
+	migrationHeaderPattern = regexp.MustCompile(`(?m)^([ \t]*)Migration\s+"[^"]*"\s*\{[ \t]*\r?\n`)
+	dslVersionPattern      = regexp.MustCompile(`(?m)^([ \t]*)DSLVersion\s*=\s*\d+`)
+	toolVersionPattern     = regexp.MustCompile(`(?m)^([ \t]*)ToolVersion\s*=\s*"[^"]*"`)
+	legacyDisablePattern   = regexp.MustCompile(`(?m)^([ \t]*)Disable(\s*=)`)
+)
+
+// upgradeMigrationFormat rewrites the BCL migration file data to the current
+// DSL version, keeping its layout and comments: every Migration block gets
+// DSLVersion and ToolVersion, and Disable becomes Disabled.
+func upgradeMigrationFormat(data []byte) []byte {
+	dslLine := fmt.Sprintf("DSLVersion = %d", DSLVersion)
+	toolLine := fmt.Sprintf("ToolVersion = %q", Version)
+	src := legacyDisablePattern.ReplaceAllString(string(data), "${1}Disabled${2}")
+	src = dslVersionPattern.ReplaceAllString(src, "${1}"+dslLine)
+	src = toolVersionPattern.ReplaceAllString(src, "${1}"+toolLine)
+	headers := migrationHeaderPattern.FindAllStringSubmatchIndex(src, -1)
+	var out strings.Builder
+	last := 0
+	for i, h := range headers {
+		bodyEnd := len(src)
+		if i+1 < len(headers) {
+			bodyEnd = headers[i+1][0]
+		}
+		body := src[h[1]:bodyEnd]
+		indent := src[h[2]:h[3]] + "  "
+		out.WriteString(src[last:h[1]])
+		if !dslVersionPattern.MatchString(body) {
+			out.WriteString(indent + dslLine + "\n")
+		}
+		if !toolVersionPattern.MatchString(body) {
+			out.WriteString(indent + toolLine + "\n")
+		}
+		last = h[1]
+	}
+	out.WriteString(src[last:])
+	return []byte(out.String())
+}
+
+// FormatUpgrade is a migration file rewritten by UpgradeFormat.
+type FormatUpgrade struct {
+	Path string
+	// Migrations lists the migrations of the file written for an older DSL.
+	Migrations []string
+	// Rechecksummed lists the applied migrations whose history checksum was
+	// updated to match the rewritten file.
+	Rechecksummed []string
+}
+
+// UpgradeFormat rewrites every BCL migration file written for an older DSL
+// version with upgradeMigrationFormat. The history checksums of applied
+// migrations in those files are updated so the rewrite is not reported as a
+// modification; migrations edited since they were applied keep their old
+// checksum. With dryRun nothing is written.
+func (d *Manager) UpgradeFormat(dryRun bool) ([]FormatUpgrade, error) {
+	if d.assets != nil {
+		return nil, fmt.Errorf("cannot rewrite embedded migration files")
+	}
+	_, bclPaths, err := d.migrationFilePaths()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migration files: %w", err)
+	}
+	histories, err := d.historyDriver.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migration history: %w", err)
+	}
+	applied := make(map[string]int, len(histories))
+	for i, h := range histories {
+		applied[h.Name] = i
+	}
+	var upgrades []FormatUpgrade
+	historyChanged := false
+	for _, path := range bclPaths {
+		cached, err := d.readMigrationsBCL(path)
+		if err != nil {
+			return upgrades, err
+		}
+		upgrade := FormatUpgrade{Path: path}
+		for _, m := range cached.migrations {
+			if m.AuthoredDSLVersion() < DSLVersion {
+				upgrade.Migrations = append(upgrade.Migrations, m.Name)
+			}
+		}
+		if len(upgrade.Migrations) == 0 {
+			continue
+		}
+		data := upgradeMigrationFormat(cached.data)
+		rewritten, err := ParseMigrationsBCL(data)
+		if err != nil {
+			return upgrades, fmt.Errorf("rewriting %s produced an invalid file: %w", path, withParseErrorFile(err, path))
+		}
+		for _, m := range cached.migrations {
+			i, ok := applied[m.Name]
+			if !ok {
+				continue
+			}
+			normalized, err := normalizedMigrationChecksum(m)
+			if err != nil {
+				return upgrades, err
+			}
+			if !checksumMatches(histories[i].Checksum, cached.checksum, normalized) {
+				continue
+			}
+			next, ok := findMigrationByName(rewritten, m.Name)
+			if !ok {
+				continue
+			}
+			if strings.HasPrefix(histories[i].Checksum, normalizedChecksumPrefix) {
+				if histories[i].Checksum, err = normalizedMigrationChecksum(next); err != nil {
+					return upgrades, err
+				}
+			} else {
+				histories[i].Checksum = computeChecksum(data)
+			}
+			upgrade.Rechecksummed = append(upgrade.Rechecksummed, m.Name)
+			historyChanged = true
+		}
+		upgrades = append(upgrades, upgrade)
+		if dryRun {
+			continue
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return upgrades, fmt.Errorf("failed to rewrite %s: %w", path, err)
+		}
+	}
+	if historyChanged && !dryRun {
+		if err := d.historyDriver.Rollback(histories...); err != nil {
+			return upgrades, fmt.Errorf("failed to update migration history: %w", err)
+		}
+	}
+	return upgrades, nil
+}
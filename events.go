@@ -0,0 +1,184 @@
+package migrate
+
+import (
+	"sync"
+	"time"
+
+	"github.com/oarkflow/migrate/drivers"
+)
+
+// Event is emitted to the sinks registered with WithEventSink while
+// migrations and seeds run. It is one of *MigrationStarted,
+// *StatementExecuted, *MigrationApplied, *SeedRowBatch or *LockAcquired.
+type Event interface {
+	// EventTime returns when the event happened.
+	EventTime() time.Time
+}
+
+// MigrationStarted is emitted before the statements of a migration run.
+type MigrationStarted struct {
+	Migration string
+	Time      time.Time
+}
+
+// StatementExecuted is emitted after each statement the database driver
+// executes. Migration is empty for statements outside a migration, such as
+// seeds.
+type StatementExecuted struct {
+	Migration string
+	SQL       string
+	Duration  time.Duration
+	Err       error
+	Time      time.Time
+}
+
+// MigrationApplied is emitted once the statements of a migration have run,
+// before it is recorded in the history.
+type MigrationApplied struct {
+	Migration string
+	Duration  time.Duration
+	Time      time.Time
+}
+
+// SeedRowBatch is emitted after a batch of seed rows is committed. Table is
+// empty for raw SQL seed files, whose Rows count statements, and File is
+// empty for the rows migrate --seed generates.
+type SeedRowBatch struct {
+	File  string
+	Table string
+	Rows  int64
+	Time  time.Time
+}
+
+// LockAcquired is emitted when the migration lock is taken.
+type LockAcquired struct {
+	Lock LockInfo
+	Time time.Time
+}
+
+func (e *MigrationStarted) EventTime() time.Time  { return e.Time }
+func (e *StatementExecuted) EventTime() time.Time { return e.Time }
+func (e *MigrationApplied) EventTime() time.Time  { return e.Time }
+func (e *SeedRowBatch) EventTime() time.Time      { return e.Time }
+func (e *LockAcquired) EventTime() time.Time      { return e.Time }
+
+// EventSink receives events. It is called synchronously from the goroutine
+// running the migration, so it should return quickly.
+type EventSink func(Event)
+
+// WithEventSink registers sink to receive the events of the manager, for
+// progress UIs and integrations that should not parse the log. It may be
+// given more than once.
+func WithEventSink(sink EventSink) ManagerOption {
+	return func(m *Manager) {
+		m.eventSinks = append(m.eventSinks, sink)
+	}
+}
+
+// ChannelSink returns an EventSink sending events to ch. Events are dropped
+// while ch is full so a slow reader cannot stall a migration.
+func ChannelSink(ch chan<- Event) EventSink {
+	return func(e Event) {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// statementObserver is implemented by drivers that report each statement
+// they execute.
+type statementObserver interface {
+	SetStatementObserver(fn drivers.StatementObserver)
+}
+
+// eventScope holds the migration whose statements are running, so
+// StatementExecuted events can name it.
+type eventScope struct {
+	mu        sync.Mutex
+	migration string
+}
+
+func (s *eventScope) set(migration string) {
+	s.mu.Lock()
+	s.migration = migration
+	s.mu.Unlock()
+}
+
+func (s *eventScope) get() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.migration
+}
+
+func (d *Manager) emit(e Event) {
+	for _, sink := range d.eventSinks {
+		sink(e)
+	}
+}
+
+// observeStatements installs a statement observer emitting
+// StatementExecuted on the database driver when sinks are registered.
+func (d *Manager) observeStatements() {
+	if len(d.eventSinks) == 0 || d.dbDriver == nil {
+		return
+	}
+	driver, ok := d.dbDriver.(statementObserver)
+	if !ok {
+		logger.Warn().Msg("Database driver does not report statements; StatementExecuted events are not emitted")
+		return
+	}
+	driver.SetStatementObserver(func(sql string, elapsed time.Duration, err error) {
+		d.emit(&StatementExecuted{Migration: d.events.get(), SQL: sql, Duration: elapsed, Err: err, Time: time.Now()})
+	})
+}
+
+// startMigrationEvents emits MigrationStarted for name and returns the
+// function to call with the result of its statements, which emits
+// MigrationApplied when err is nil.
+func (d *Manager) startMigrationEvents(name string) (finish func(err error)) {
+	if len(d.eventSinks) == 0 {
+		return func(error) {}
+	}
+	started := time.Now()
+	d.events.set(name)
+	d.emit(&MigrationStarted{Migration: name, Time: started})
+	return func(err error) {
+		d.events.set("")
+		if err == nil {
+			d.emit(&MigrationApplied{Migration: name, Duration: time.Since(started), Time: time.Now()})
+		}
+	}
+}
+
+// emitSeedRows emits SeedRowBatch for rows committed from file into table.
+func (d *Manager) emitSeedRows(file, table string, rows int64) {
+	if len(d.eventSinks) == 0 || rows == 0 {
+		return
+	}
+	d.emit(&SeedRowBatch{File: file, Table: table, Rows: rows, Time: time.Now()})
+}
+
+// acquireLock takes the migration lock and emits LockAcquired.
+func (d *Manager) acquireLock() (*migrationLock, error) {
+	lock, err := acquireLock()
+	if err != nil {
+		return nil, err
+	}
+	if len(d.eventSinks) > 0 {
+		lock.mu.Lock()
+		info := lock.info
+		lock.mu.Unlock()
+		d.emit(&LockAcquired{Lock: info, Time: time.Now()})
+	}
+	return lock, nil
+}
+
+// acquireCommandLock takes the migration lock for a command, through the
+// manager when driver is one so LockAcquired is emitted.
+func acquireCommandLock(driver IManager) (*migrationLock, error) {
+	if mgr, ok := driver.(*Manager); ok {
+		return mgr.acquireLock()
+	}
+	return acquireLock()
+}
@@ -0,0 +1,158 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/oarkflow/cli/contracts"
+	"github.com/oarkflow/json"
+)
+
+// Process exit codes returned by the CLI.
+const (
+	ExitOK      = 0
+	ExitError   = 1
+	ExitPending = 2
+	ExitDrift   = 3
+)
+
+var (
+	// ErrPendingMigrations reports that migrations exist which have not been applied.
+	ErrPendingMigrations = errors.New("pending migrations")
+	// ErrChecksumMismatch reports that an applied migration was modified afterwards.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+)
+
+// ExitCode maps an error returned by a command to the process exit code.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, ErrPendingMigrations):
+		return ExitPending
+	case errors.Is(err, ErrChecksumMismatch):
+		return ExitDrift
+	default:
+		return ExitError
+	}
+}
+
+func errorKind(err error) string {
+	switch ExitCode(err) {
+	case ExitPending:
+		return "pending_migrations"
+	case ExitDrift:
+		return "checksum_mismatch"
+	default:
+		return "error"
+	}
+}
+
+// ErrorEnvelope is the machine-readable error written with --format=json.
+type ErrorEnvelope struct {
+	Status   string `json:"status"`
+	Command  string `json:"command"`
+	ExitCode int    `json:"exit_code"`
+	Kind     string `json:"kind"`
+	Message  string `json:"message"`
+	// Migration, Statement and Position identify the failed statement when
+	// err is a MigrationError.
+	Migration string `json:"migration,omitempty"`
+	Statement string `json:"statement,omitempty"`
+	Position  int    `json:"position,omitempty"`
+	// Hint suggests how to recover from the failure, see RecoveryHint.
+	Hint string `json:"hint,omitempty"`
+}
+
+// NewErrorEnvelope builds the envelope describing err for command.
+func NewErrorEnvelope(command string, err error) ErrorEnvelope {
+	envelope := ErrorEnvelope{
+		Status:   "error",
+		Command:  command,
+		ExitCode: ExitCode(err),
+		Kind:     errorKind(err),
+		Message:  err.Error(),
+	}
+	var migErr *MigrationError
+	if errors.As(err, &migErr) {
+		envelope.Migration = migErr.Migration
+		envelope.Statement = migErr.Statement
+		envelope.Position = migErr.Position
+		envelope.Hint = migErr.Hint
+	}
+	return envelope
+}
+
+// exitError carries the exit code of a failed command to the CLI runner. When
+// quiet is set the error has already been reported and prints nothing.
+type exitError struct {
+	err   error
+	code  int
+	quiet bool
+}
+
+func (e *exitError) Error() string {
+	if e.quiet {
+		return ""
+	}
+	var migErr *MigrationError
+	if errors.As(e.err, &migErr) && migErr.Hint != "" {
+		return e.err.Error() + "\nhint: " + migErr.Hint
+	}
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+func (e *exitError) ExitCode() int {
+	return e.code
+}
+
+// exitCodeCommand wraps a command so failures terminate the process with the
+// standard exit codes and can be reported as JSON via --format=json.
+type exitCodeCommand struct {
+	contracts.Command
+}
+
+func withExitCodes(commands []contracts.Command) []contracts.Command {
+	wrapped := make([]contracts.Command, 0, len(commands))
+	for _, cmd := range commands {
+		wrapped = append(wrapped, &exitCodeCommand{Command: cmd})
+	}
+	return wrapped
+}
+
+func (c *exitCodeCommand) Extend() contracts.Extend {
+	extend := c.Command.Extend()
+	for _, flag := range extend.Flags {
+		if flag.Name == "format" {
+			return extend
+		}
+	}
+	extend.Flags = append(extend.Flags, contracts.Flag{
+		Name:  "format",
+		Usage: "Output format for errors (text|json)",
+		Value: "text",
+	})
+	return extend
+}
+
+func (c *exitCodeCommand) Handle(ctx contracts.Context) error {
+	err := c.Command.Handle(ctx)
+	if err == nil {
+		return nil
+	}
+	code := ExitCode(err)
+	if ctx.Option("format") != "json" {
+		return &exitError{err: err, code: code}
+	}
+	data, marshalErr := json.Marshal(NewErrorEnvelope(c.Signature(), err))
+	if marshalErr != nil {
+		return &exitError{err: err, code: code}
+	}
+	fmt.Fprintln(os.Stdout, string(data))
+	return &exitError{err: err, code: code, quiet: true}
+}
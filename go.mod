@@ -4,6 +4,7 @@ go 1.26.2
 
 require (
 	github.com/brianvoe/gofakeit/v6 v6.28.0
+	github.com/mattn/go-isatty v0.0.20
 	github.com/oarkflow/bcl v0.0.25
 	github.com/oarkflow/cli v0.0.3
 	github.com/oarkflow/expr v0.0.11
@@ -23,7 +24,6 @@ require (
 	github.com/jackc/pgservicefile v0.0.0-20240606120523-5a60cdf6a761 // indirect
 	github.com/jackc/pgx/v5 v5.9.2 // indirect
 	github.com/jackc/puddle/v2 v2.2.2 // indirect
-	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/ncruces/go-strftime v1.0.0 // indirect
 	github.com/oarkflow/date v0.0.4 // indirect
 	github.com/oarkflow/jet v0.0.4 // indirect
@@ -0,0 +1,115 @@
+package migrate
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/oarkflow/cli/contracts"
+)
+
+// yesProductionFlag is the flag name that acknowledges destructive commands
+// against a protected environment.
+const yesProductionFlag = "yes-production"
+
+// ErrNonInteractive is returned when a command needs a typed confirmation but
+// no interactive input is available.
+var ErrNonInteractive = errors.New("confirmation required but input is not interactive")
+
+// stdinIsTerminal reports whether stdin is attached to a terminal. It is a
+// variable so tests can simulate interactive sessions.
+var stdinIsTerminal = func() bool {
+	fd := os.Stdin.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+func yesProductionFlagDefinition() contracts.Flag {
+	return contracts.Flag{
+		Name:  yesProductionFlag,
+		Usage: "Confirm destructive operations against a protected environment",
+		Value: "false",
+	}
+}
+
+func yesFlagDefinition() contracts.Flag {
+	return contracts.Flag{
+		Name:    "yes",
+		Aliases: []string{"y"},
+		Usage:   "Answer yes to confirmation prompts",
+		Value:   "false",
+	}
+}
+
+func noInputFlagDefinition() contracts.Flag {
+	return contracts.Flag{
+		Name:  "no-input",
+		Usage: "Never prompt; fail when a confirmation would be required",
+		Value: "false",
+	}
+}
+
+func optionEnabled(ctx contracts.Context, name string) bool {
+	v := ctx.Option(name)
+	return v == "true" || v == "1"
+}
+
+// inputAllowed reports whether the command may prompt the user. Prompts are
+// disabled by --no-input, MIGRATE_NO_INPUT, or a non-terminal stdin.
+func inputAllowed(ctx contracts.Context) bool {
+	if optionEnabled(ctx, "no-input") {
+		return false
+	}
+	if v := os.Getenv("MIGRATE_NO_INPUT"); v == "true" || v == "1" {
+		return false
+	}
+	return stdinIsTerminal()
+}
+
+// promptConfirmation asks the user to type expected and reports whether they did.
+// When prompting is not possible it fails fast with ErrNonInteractive and hint.
+func promptConfirmation(ctx contracts.Context, prompt, expected, hint string) (bool, error) {
+	if !inputAllowed(ctx) {
+		return false, fmt.Errorf("%w; %s", ErrNonInteractive, hint)
+	}
+	fmt.Print(prompt)
+	r := bufio.NewReader(os.Stdin)
+	resp, _ := r.ReadString('\n')
+	return strings.EqualFold(strings.TrimSpace(resp), expected), nil
+}
+
+// confirmProtectedEnvironment guards destructive operations on a protected
+// environment. The operation proceeds when --yes-production is set or the user
+// types the environment name at the prompt.
+func confirmProtectedEnvironment(ctx contracts.Context, env EnvironmentConfig, operation string) error {
+	if !env.Protected {
+		return nil
+	}
+	if optionEnabled(ctx, yesProductionFlag) {
+		return nil
+	}
+	name := env.Name
+	if name == "" {
+		name = "production"
+	}
+	logger.Warn().Msgf("WARNING: Environment '%s' is protected. %s is destructive.", name, operation)
+	hint := fmt.Sprintf("pass --%s to confirm", yesProductionFlag)
+	ok, err := promptConfirmation(ctx, fmt.Sprintf("Type the environment name '%s' to continue: ", name), name, hint)
+	if err != nil {
+		return fmt.Errorf("%s aborted: environment '%s' is protected: %w", operation, name, err)
+	}
+	if !ok {
+		return fmt.Errorf("%s aborted: environment '%s' is protected (%s)", operation, name, hint)
+	}
+	return nil
+}
+
+// managerEnvironment returns the environment of driver when it is a *Manager.
+func managerEnvironment(driver IManager) EnvironmentConfig {
+	if mgr, ok := driver.(*Manager); ok {
+		return mgr.Environment()
+	}
+	return EnvironmentConfig{}
+}
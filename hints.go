@@ -0,0 +1,54 @@
+package migrate
+
+import (
+	"regexp"
+	"strings"
+)
+
+// recoveryHints maps patterns of database error messages, matched in lower
+// case, to a suggestion. The first matching entry wins, so specific entries
+// come before generic ones.
+var recoveryHints = []struct {
+	pattern *regexp.Regexp
+	hint    string
+}{
+	{
+		pattern: regexp.MustCompile(`duplicate column|column .*already exists`),
+		hint:    "the column already exists; it may have been added outside migrations or by an earlier migration. Remove the AddField or set continue_on_error = true",
+	},
+	{
+		pattern: regexp.MustCompile(`already exists`),
+		hint:    "the object already exists; it may have been created outside migrations. Drop it, or set continue_on_error = true on the operation",
+	},
+	{
+		pattern: regexp.MustCompile(`permission denied|access denied|command denied|must be owner|insufficient privilege`),
+		hint:    "the database user lacks a privilege this statement needs; grant it or run migrations as the schema owner (doctor checks DDL permissions)",
+	},
+	{
+		pattern: regexp.MustCompile(`lock timeout|lock wait timeout|database is locked|deadlock`),
+		hint:    "the statement waited too long for a lock held by another session; retry when traffic is lower or raise lock_timeout in database.session",
+	},
+}
+
+// RecoveryHint suggests how to recover from err, or returns "" when err is
+// not a known class of database error.
+func RecoveryHint(err error) string {
+	if err == nil {
+		return ""
+	}
+	msg := strings.ToLower(err.Error())
+	for _, entry := range recoveryHints {
+		if entry.pattern.MatchString(msg) {
+			return entry.hint
+		}
+	}
+	return ""
+}
+
+// WithErrorHints turns the recovery hints attached to MigrationError on or
+// off. They are on by default.
+func WithErrorHints(enabled bool) ManagerOption {
+	return func(m *Manager) {
+		m.errorHints = enabled
+	}
+}
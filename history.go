@@ -1,10 +1,10 @@
 package migrate
 
 import (
+	"database/sql"
 	"fmt"
 	"os"
 	"regexp"
-	"strings"
 	"time"
 
 	"github.com/oarkflow/json"
@@ -32,6 +32,24 @@ type MigrationHistory struct {
 	Description string    `json:"description" db:"description"`
 	Checksum    string    `json:"checksum" db:"checksum"`
 	AppliedAt   time.Time `json:"applied_at" db:"applied_at"`
+	// DSLVersion and ToolVersion record the DSL version and migrate version
+	// the migration file was written with; they are zero for .sql
+	// migrations and entries recorded before they were tracked.
+	DSLVersion  int    `json:"dsl_version,omitempty" db:"dsl_version"`
+	ToolVersion string `json:"tool_version,omitempty" db:"tool_version"`
+	// DownSQL is the Down SQL generated when the migration was applied, so
+	// it can be rolled back as applied after its file is edited; it is empty
+	// for entries recorded before it was stored.
+	DownSQL []string `json:"down_sql,omitempty" db:"-"`
+	// Archived marks entries moved to the archive table by PruneHistory.
+	Archived bool `json:"archived,omitempty" db:"-"`
+}
+
+// historyRow is a row of the database history table, which stores DownSQL
+// as a JSON array.
+type historyRow struct {
+	MigrationHistory
+	DownSQL string `db:"down_sql"`
 }
 
 // HistoryDriver defines an interface to store migration history.
@@ -115,7 +133,10 @@ func NewDB(dialect, dsn string) (*squealx.DB, error) {
 }
 
 func SetupMigrationHistoryTable(dialect string, db *squealx.DB, table string) error {
-	dial := GetDialect(dialect)
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return err
+	}
 	stmt := CreateTable{
 		Name: table,
 		AddFields: []AddField{
@@ -125,11 +146,14 @@ func SetupMigrationHistoryTable(dialect string, db *squealx.DB, table string) er
 			{Name: "description", Type: "string", Size: 500},
 			{Name: "checksum", Type: "string", Size: 100},
 			{Name: "applied_at", Type: "datetime"},
+			{Name: "dsl_version", Type: "number", Nullable: true},
+			{Name: "tool_version", Type: "string", Size: 50, Nullable: true},
+			{Name: "down_sql", Type: "text", Nullable: true},
 		},
 	}
 	existsQuery := dial.TableExistsSQL(table)
 	var exists bool
-	err := db.Select(&exists, existsQuery)
+	err = db.Select(&exists, existsQuery)
 	if err != nil {
 		return err
 	}
@@ -139,18 +163,11 @@ func SetupMigrationHistoryTable(dialect string, db *squealx.DB, table string) er
 		if err != nil {
 			return err
 		}
-		queries := strings.Split(query, dial.EOS())
-		for _, q := range queries {
-			q = strings.TrimSpace(q)
-			if q == "" {
-				continue
-			}
-			if _, err := db.Exec(q); err != nil {
-				return err
-			}
+		if err := execDialectSQL(db, dial, query); err != nil {
+			return err
 		}
 	}
-	return nil
+	return upgradeHistorySchema(dialect, db, table, !exists)
 }
 
 func NewDatabaseHistoryDriverFromDB(db *squealx.DB, dialect, table string) (HistoryDriver, error) {
@@ -181,32 +198,78 @@ func NewDatabaseHistoryDriver(dialect, dsn string, tables ...string) (HistoryDri
 }
 
 func (d *DatabaseHistoryDriver) Save(history MigrationHistory) error {
-	dial := GetDialect(d.dialect)
-	cols := []string{"name", "version", "description", "checksum", "applied_at"}
-	vals := []any{history.Name, history.Version, history.Description, history.Checksum, history.AppliedAt.Format(time.RFC3339)}
-	query, args, err := dial.InsertSQL(d.table, cols, vals)
+	return d.insert(d.db, d.table, history)
+}
+
+// namedExecer runs named queries on a database or in a transaction.
+type namedExecer interface {
+	NamedExec(query string, arg any) (sql.Result, error)
+}
+
+// insert adds history to table.
+func (d *DatabaseHistoryDriver) insert(db namedExecer, table string, history MigrationHistory) error {
+	dial, err := GetDialect(d.dialect)
 	if err != nil {
 		return err
 	}
-	_, err = d.db.NamedExec(query, args)
+	var downSQL any
+	if len(history.DownSQL) > 0 {
+		data, err := json.Marshal(history.DownSQL)
+		if err != nil {
+			return fmt.Errorf("failed to encode down SQL of %s: %w", history.Name, err)
+		}
+		downSQL = string(data)
+	}
+	cols := []string{"name", "version", "description", "checksum", "applied_at", "dsl_version", "tool_version", "down_sql"}
+	vals := []any{history.Name, history.Version, history.Description, history.Checksum, history.AppliedAt.Format(time.RFC3339), history.DSLVersion, history.ToolVersion, downSQL}
+	query, args, err := dial.InsertSQL(table, cols, vals)
+	if err != nil {
+		return err
+	}
+	_, err = db.NamedExec(query, args)
 	return err
 }
 
+// Load returns the entries archived by PruneHistory followed by the entries
+// of the history table, oldest first.
 func (d *DatabaseHistoryDriver) Load() ([]MigrationHistory, error) {
-	var histories []MigrationHistory
+	histories, err := d.loadTable(d.table)
+	if err != nil {
+		return nil, err
+	}
+	archived, err := d.loadArchive()
+	if err != nil || len(archived) == 0 {
+		return histories, err
+	}
+	return append(archived, histories...), nil
+}
+
+func (d *DatabaseHistoryDriver) loadTable(table string) ([]MigrationHistory, error) {
+	var rows []historyRow
 	// Use parameterized query to prevent SQL injection
-	query := `SELECT id, name, version, description, checksum, applied_at FROM migrations ORDER BY applied_at ASC`
-	if d.table != "migrations" {
+	columns := `id, name, version, description, checksum, applied_at, COALESCE(dsl_version, 0) AS dsl_version, COALESCE(tool_version, '') AS tool_version, COALESCE(down_sql, '') AS down_sql`
+	query := `SELECT ` + columns + ` FROM migrations ORDER BY applied_at ASC`
+	if table != "migrations" {
 		// Validate table name to prevent SQL injection
-		if !isValidIdentifier(d.table) {
-			return nil, fmt.Errorf("invalid table name: %s", d.table)
+		if !isValidIdentifier(table) {
+			return nil, fmt.Errorf("invalid table name: %s", table)
 		}
-		query = fmt.Sprintf(`SELECT id, name, version, description, checksum, applied_at FROM "%s" ORDER BY applied_at ASC`, d.table)
+		query = fmt.Sprintf(`SELECT %s FROM "%s" ORDER BY applied_at ASC`, columns, table)
 	}
-	err := d.db.Select(&histories, query)
+	err := d.db.Select(&rows, query)
 	if err != nil {
 		return nil, err
 	}
+	histories := make([]MigrationHistory, len(rows))
+	for i, row := range rows {
+		histories[i] = row.MigrationHistory
+		if row.DownSQL == "" {
+			continue
+		}
+		if err := json.Unmarshal([]byte(row.DownSQL), &histories[i].DownSQL); err != nil {
+			return nil, fmt.Errorf("failed to decode down SQL of %s: %w", row.Name, err)
+		}
+	}
 	return histories, nil
 }
 
@@ -224,21 +287,43 @@ func (f *FileHistoryDriver) Rollback(histories ...MigrationHistory) error {
 }
 
 // DatabaseHistoryDriver: implement Rollback by executing a DELETE query.
+// Archived entries are kept in the archive table.
 func (d *DatabaseHistoryDriver) Rollback(histories ...MigrationHistory) error {
 	// Validate table name to prevent SQL injection
 	if !isValidIdentifier(d.table) {
 		return fmt.Errorf("invalid table name: %s", d.table)
 	}
+	var live, archived []MigrationHistory
+	for _, h := range histories {
+		if h.Archived {
+			archived = append(archived, h)
+		} else {
+			live = append(live, h)
+		}
+	}
+	hasArchive, err := d.hasArchive()
+	if err != nil {
+		return err
+	}
+	if hasArchive {
+		if err := d.rewrite(historyArchiveTable(d.table), archived); err != nil {
+			return err
+		}
+	}
+	return d.rewrite(d.table, live)
+}
 
+// rewrite replaces the entries of table with histories.
+func (d *DatabaseHistoryDriver) rewrite(table string, histories []MigrationHistory) error {
 	// Simpler and portable approach: delete all rows and re-insert the remaining
-	// histories using the existing Save method which handles parameterization
-	query := fmt.Sprintf(`DELETE FROM "%s"`, d.table)
+	// histories using insert which handles parameterization
+	query := "DELETE FROM " + quoteDialectIdentifier(d.dialect, table)
 	if _, err := d.db.Exec(query); err != nil {
 		return err
 	}
 
 	for _, h := range histories {
-		if err := d.Save(h); err != nil {
+		if err := d.insert(d.db, table, h); err != nil {
 			return err
 		}
 	}
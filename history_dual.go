@@ -0,0 +1,107 @@
+package migrate
+
+import (
+	"fmt"
+	"time"
+)
+
+// HistoryMirrorFile as migration.history_mirror also writes every history
+// change to the file history, see WithHistoryFile, while the history is
+// still read from the database.
+const HistoryMirrorFile = "file"
+
+// DualHistoryDriver writes the history to Primary and Secondary and reads it
+// from Primary only. It keeps a file copy of a database history while moving
+// between them, or as a local audit copy. Failures writing Secondary are
+// logged and do not fail the migration.
+type DualHistoryDriver struct {
+	Primary   HistoryDriver
+	Secondary HistoryDriver
+}
+
+// NewDualHistoryDriver returns a history driver writing through primary to
+// secondary and reading from primary.
+func NewDualHistoryDriver(primary, secondary HistoryDriver) *DualHistoryDriver {
+	return &DualHistoryDriver{Primary: primary, Secondary: secondary}
+}
+
+// WithHistoryMirror sets the history mirror policy: empty for none, or
+// HistoryMirrorFile.
+func WithHistoryMirror(mirror string) ManagerOption {
+	return func(m *Manager) {
+		m.historyMirror = mirror
+	}
+}
+
+func (d *DualHistoryDriver) Save(history MigrationHistory) error {
+	if err := d.Primary.Save(history); err != nil {
+		return err
+	}
+	d.mirrored("save", d.Secondary.Save(history))
+	return nil
+}
+
+func (d *DualHistoryDriver) Load() ([]MigrationHistory, error) {
+	return d.Primary.Load()
+}
+
+func (d *DualHistoryDriver) ValidateStorage() error {
+	if err := d.Primary.ValidateStorage(); err != nil {
+		return err
+	}
+	d.mirrored("prepare", d.Secondary.ValidateStorage())
+	return nil
+}
+
+func (d *DualHistoryDriver) Rollback(histories ...MigrationHistory) error {
+	if err := d.Primary.Rollback(histories...); err != nil {
+		return err
+	}
+	d.mirrored("rewrite", d.Secondary.Rollback(histories...))
+	return nil
+}
+
+// Prune prunes the primary history. The secondary keeps every entry.
+func (d *DualHistoryDriver) Prune(keep int, before time.Time) ([]MigrationHistory, error) {
+	pruner, ok := d.Primary.(historyPruner)
+	if !ok {
+		return nil, fmt.Errorf("history:prune requires a database history")
+	}
+	return pruner.Prune(keep, before)
+}
+
+func (d *DualHistoryDriver) mirrored(op string, err error) {
+	if err != nil {
+		logger.Warn().Msgf("Failed to %s the mirrored migration history: %v", op, err)
+	}
+}
+
+// databaseHistory returns the database history behind h, if any.
+func databaseHistory(h HistoryDriver) (*DatabaseHistoryDriver, bool) {
+	if dual, ok := h.(*DualHistoryDriver); ok {
+		h = dual.Primary
+	}
+	db, ok := h.(*DatabaseHistoryDriver)
+	return db, ok
+}
+
+// mirrorHistory applies migration.history_mirror once the history driver is
+// known. A history already kept in the file is not mirrored to itself.
+func (m *Manager) mirrorHistory() error {
+	if m.historyMirror == "" {
+		return nil
+	}
+	if m.historyMirror != HistoryMirrorFile {
+		return fmt.Errorf("unsupported history mirror %q", m.historyMirror)
+	}
+	switch m.historyDriver.(type) {
+	case *FileHistoryDriver, *DualHistoryDriver, unavailableHistoryDriver:
+		return nil
+	}
+	path, err := m.fileHistoryPath()
+	if err != nil {
+		return err
+	}
+	m.historyDriver = NewDualHistoryDriver(m.historyDriver, NewFileHistoryDriver(path))
+	return nil
+}
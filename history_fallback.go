@@ -0,0 +1,54 @@
+package migrate
+
+import "fmt"
+
+// History fallback policies decide what happens when the migration history
+// table of the configured database cannot be reached.
+const (
+	// HistoryFallbackFail makes every history read and write fail with the
+	// connection error, and NewManagerFromConfig return it.
+	HistoryFallbackFail = "fail"
+	// HistoryFallbackFile keeps the history in the file history instead,
+	// with a warning, see WithHistoryFile. Migrations applied meanwhile are not recorded in the
+	// database, so use it only where the database history is expendable.
+	HistoryFallbackFile = "file"
+)
+
+// WithHistoryFallback sets the policy applied when the database history is
+// unavailable to HistoryFallbackFail, the default, or HistoryFallbackFile.
+func WithHistoryFallback(policy string) ManagerOption {
+	return func(m *Manager) {
+		m.historyFallback = policy
+	}
+}
+
+// unavailableHistoryDriver is the history driver of a manager whose history
+// cannot be used; every call fails with err.
+type unavailableHistoryDriver struct {
+	err error
+}
+
+func (u unavailableHistoryDriver) Save(MigrationHistory) error        { return u.err }
+func (u unavailableHistoryDriver) Load() ([]MigrationHistory, error)  { return nil, u.err }
+func (u unavailableHistoryDriver) ValidateStorage() error             { return u.err }
+func (u unavailableHistoryDriver) Rollback(...MigrationHistory) error { return u.err }
+
+// fallBackToFileHistory applies HistoryFallbackFile after connecting to the
+// configured database failed with err.
+func (m *Manager) fallBackToFileHistory(err error) error {
+	path, pathErr := m.fileHistoryPath()
+	if pathErr != nil {
+		return pathErr
+	}
+	logger.Warn().Msgf("WARNING: the database migration history is unavailable (%v). Falling back to %s as migration.history_fallback is %q; migrations applied now are NOT recorded in the database", err, path, HistoryFallbackFile)
+	if m.historyDriver == nil {
+		m.historyDriver = NewFileHistoryDriver(path)
+	}
+	return nil
+}
+
+// historyUnavailableError wraps the connection error reported by the history
+// of a manager whose database could not be reached.
+func historyUnavailableError(err error) error {
+	return fmt.Errorf("migration history is unavailable: %w (set migration.history_fallback to %q to use a local file instead)", err, HistoryFallbackFile)
+}
@@ -0,0 +1,94 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// historyFileName is the name of the file history.
+const historyFileName = "migration_history.txt"
+
+// HistoryFileState as migration.history_file keeps the file history in the
+// user's state directory ($XDG_STATE_HOME, ~/.local/state, or the local
+// application data directory on Windows), under a directory of its own for
+// each project.
+const HistoryFileState = "state"
+
+// WithHistoryFile sets the file keeping the history of a manager without a
+// database history, or with the "file" history fallback. Empty means
+// migration_history.txt in the migration directory, and HistoryFileState
+// the user's state directory.
+func WithHistoryFile(path string) ManagerOption {
+	return func(m *Manager) {
+		m.historyFile = path
+	}
+}
+
+// fileHistoryPath resolves the file history of the manager. Without a
+// configured path a migration_history.txt left in the working directory by
+// earlier versions is kept, with a warning, so the migrations it recorded
+// are not applied again.
+func (m *Manager) fileHistoryPath() (string, error) {
+	switch m.historyFile {
+	case HistoryFileState:
+		return stateHistoryPath(m.migrationDir)
+	case "":
+		path := filepath.Join(m.migrationDir, historyFileName)
+		if fileExists(path) || !fileExists(historyFileName) {
+			return path, nil
+		}
+		logger.Warn().Msgf("Using %s from the working directory; move it to %s or set migration.history_file to keep using it", historyFileName, path)
+		return historyFileName, nil
+	}
+	return m.historyFile, nil
+}
+
+// stateHistoryPath returns the file history of the project whose migrations
+// are in migrationDir inside the user's state directory. Projects are told
+// apart by the absolute path of their migration directory.
+func stateHistoryPath(migrationDir string) (string, error) {
+	dir, err := userStateDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the state directory for the history file: %w", err)
+	}
+	abs, err := filepath.Abs(migrationDir)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(abs))
+	project := filepath.Base(filepath.Dir(abs)) + "-" + hex.EncodeToString(sum[:4])
+	path := filepath.Join(dir, "migrate", project, historyFileName)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create history file directory: %w", err)
+	}
+	return path, nil
+}
+
+// userStateDir returns $XDG_STATE_HOME, or its default ~/.local/state; on
+// Windows it returns the local application data directory.
+func userStateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		if !filepath.IsAbs(dir) {
+			return "", errors.New("XDG_STATE_HOME must be an absolute path")
+		}
+		return dir, nil
+	}
+	if runtime.GOOS == "windows" {
+		return os.UserCacheDir()
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state"), nil
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
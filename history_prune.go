@@ -0,0 +1,153 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oarkflow/json"
+)
+
+// historyArchiveTable returns the table keeping the entries pruned from the
+// history table.
+func historyArchiveTable(table string) string {
+	return table + "_archive"
+}
+
+// historyPruner is implemented by history drivers that can move old entries
+// out of the history.
+type historyPruner interface {
+	Prune(keep int, before time.Time) ([]MigrationHistory, error)
+}
+
+// hasArchive reports whether entries were archived from the history table.
+func (d *DatabaseHistoryDriver) hasArchive() (bool, error) {
+	dial, err := GetDialect(d.dialect)
+	if err != nil {
+		return false, err
+	}
+	var exists bool
+	if err := d.db.Select(&exists, dial.TableExistsSQL(historyArchiveTable(d.table))); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+// loadArchive returns the archived entries, oldest first.
+func (d *DatabaseHistoryDriver) loadArchive() ([]MigrationHistory, error) {
+	exists, err := d.hasArchive()
+	if err != nil || !exists {
+		return nil, err
+	}
+	histories, err := d.loadTable(historyArchiveTable(d.table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load archived history: %w", err)
+	}
+	for i := range histories {
+		histories[i].Archived = true
+	}
+	return histories, nil
+}
+
+// Prune moves the entries of the history table older than the last keep
+// entries and applied before before to the archive table, in one
+// transaction, and returns them. A negative keep or a zero before does not
+// restrict the entries pruned. Archived entries are still loaded so their
+// migrations stay applied.
+func (d *DatabaseHistoryDriver) Prune(keep int, before time.Time) ([]MigrationHistory, error) {
+	histories, err := d.loadTable(d.table)
+	if err != nil {
+		return nil, err
+	}
+	var pruned, kept []MigrationHistory
+	for i, h := range histories {
+		if (keep < 0 || i < len(histories)-keep) && (before.IsZero() || h.AppliedAt.Before(before)) {
+			pruned = append(pruned, h)
+		} else {
+			kept = append(kept, h)
+		}
+	}
+	if len(pruned) == 0 {
+		return nil, nil
+	}
+	archive := historyArchiveTable(d.table)
+	if err := SetupMigrationHistoryTable(d.dialect, d.db, archive); err != nil {
+		return nil, fmt.Errorf("failed to set up history archive table %s: %w", archive, err)
+	}
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	for _, h := range pruned {
+		if err := d.insert(tx, archive, h); err != nil {
+			return nil, fmt.Errorf("failed to archive history of %s: %w", h.Name, err)
+		}
+	}
+	if _, err := tx.Exec("DELETE FROM " + quoteDialectIdentifier(d.dialect, d.table)); err != nil {
+		return nil, err
+	}
+	for _, h := range kept {
+		if err := d.insert(tx, d.table, h); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return pruned, nil
+}
+
+// PruneHistory archives the history entries older than the last keep and
+// than olderThan; a negative keep or a zero olderThan does not restrict
+// them. It requires a database history.
+func (d *Manager) PruneHistory(keep int, olderThan time.Duration) ([]MigrationHistory, error) {
+	pruner, ok := d.historyDriver.(historyPruner)
+	if !ok {
+		return nil, fmt.Errorf("history:prune requires a database history")
+	}
+	var before time.Time
+	if olderThan > 0 {
+		before = time.Now().Add(-olderThan)
+	}
+	return pruner.Prune(keep, before)
+}
+
+// parseHistoryAge parses a duration as time.ParseDuration does, also
+// accepting whole days such as "90d".
+func parseHistoryAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	age, err := time.ParseDuration(s)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return age, nil
+}
+
+// appendHistoryArchive adds histories to the JSON array in path, creating
+// the file when missing.
+func appendHistoryArchive(path string, histories []MigrationHistory) error {
+	var archived []MigrationHistory
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &archived); err != nil {
+			return fmt.Errorf("failed to read history archive %s: %w", path, err)
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+	data, err = json.MarshalIndent(append(archived, histories...), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
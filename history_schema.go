@@ -0,0 +1,199 @@
+package migrate
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/oarkflow/squealx"
+)
+
+// HistorySchemaVersion is the layout version of the database history table
+// understood by this build. Bump it together with a new entry in
+// historySchemaSteps whenever the history table changes.
+const HistorySchemaVersion = 3
+
+// historySchemaStep upgrades the history table to Version.
+type historySchemaStep struct {
+	Version     int
+	Description string
+	Apply       func(db *squealx.DB, dialect, table string) error
+}
+
+// historySchemaSteps lists the self-migrations for the history table in
+// ascending version order. Version 1 is the original layout created by
+// SetupMigrationHistoryTable and has nothing to apply.
+var historySchemaSteps = []historySchemaStep{
+	{Version: 1, Description: "baseline history table"},
+	{Version: 2, Description: "record DSL and tool version", Apply: addHistoryVersionColumns},
+	{Version: 3, Description: "record down SQL", Apply: addHistoryDownSQLColumn},
+}
+
+// addHistoryVersionColumns adds the dsl_version and tool_version columns.
+func addHistoryVersionColumns(db *squealx.DB, dialect, table string) error {
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return err
+	}
+	for _, field := range []AddField{
+		{Name: "dsl_version", Type: "number", Nullable: true},
+		{Name: "tool_version", Type: "string", Size: 50, Nullable: true},
+	} {
+		queries, err := dial.AddFieldSQL(field, table)
+		if err != nil {
+			return err
+		}
+		for _, q := range queries {
+			if err := execDialectSQL(db, dial, q); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// addHistoryDownSQLColumn adds the down_sql column.
+func addHistoryDownSQLColumn(db *squealx.DB, dialect, table string) error {
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return err
+	}
+	queries, err := dial.AddFieldSQL(AddField{Name: "down_sql", Type: "text", Nullable: true}, table)
+	if err != nil {
+		return err
+	}
+	for _, q := range queries {
+		if err := execDialectSQL(db, dial, q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// historySchemaTable returns the name of the table storing the schema_version
+// marker for the given history table.
+func historySchemaTable(table string) string {
+	return table + "_schema"
+}
+
+// quoteDialectIdentifier quotes an identifier used in hand written queries.
+func quoteDialectIdentifier(dialect, id string) string {
+	if dialect == DialectMySQL {
+		return "`" + strings.ReplaceAll(id, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(id, `"`, `""`) + `"`
+}
+
+// execDialectSQL runs every statement contained in query, split on the dialect's EOS.
+func execDialectSQL(db *squealx.DB, dial Dialect, query string) error {
+	for _, q := range strings.Split(query, dial.EOS()) {
+		q = strings.TrimSpace(q)
+		if q == "" {
+			continue
+		}
+		if _, err := db.Exec(q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setupHistorySchemaTable(dialect string, db *squealx.DB, table string) error {
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return err
+	}
+	var exists bool
+	if err := db.Select(&exists, dial.TableExistsSQL(table)); err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	query, err := dial.CreateTableSQL(CreateTable{
+		Name: table,
+		AddFields: []AddField{
+			{Name: "schema_version", Type: "number"},
+			{Name: "description", Type: "string", Size: 200},
+			{Name: "applied_at", Type: "datetime"},
+		},
+	}, true)
+	if err != nil {
+		return err
+	}
+	return execDialectSQL(db, dial, query)
+}
+
+// loadHistorySchemaVersion returns the recorded schema version of the history
+// table, or 0 when no marker has been written yet.
+func loadHistorySchemaVersion(dialect string, db *squealx.DB, table string) (int, error) {
+	var version int
+	query := fmt.Sprintf("SELECT COALESCE(MAX(schema_version), 0) FROM %s", quoteDialectIdentifier(dialect, historySchemaTable(table)))
+	if err := db.Select(&version, query); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+func recordHistorySchemaVersion(dialect string, db *squealx.DB, table string, step historySchemaStep) error {
+	cols := []string{"schema_version", "description", "applied_at"}
+	vals := []any{step.Version, step.Description, time.Now().Format(time.RFC3339)}
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return err
+	}
+	query, args, err := dial.InsertSQL(historySchemaTable(table), cols, vals)
+	if err != nil {
+		return err
+	}
+	_, err = db.NamedExec(query, args)
+	return err
+}
+
+// upgradeHistorySchema brings the history table up to HistorySchemaVersion by
+// applying every pending self-migration. A freshly created table already has the
+// latest layout, so its steps are only recorded. Tables created before versioning
+// was introduced carry no marker and are treated as version 1.
+func upgradeHistorySchema(dialect string, db *squealx.DB, table string, created bool) error {
+	schemaTable := historySchemaTable(table)
+	if !isValidIdentifier(schemaTable) {
+		return fmt.Errorf("invalid history schema table name: %s", schemaTable)
+	}
+	if err := setupHistorySchemaTable(dialect, db, schemaTable); err != nil {
+		return fmt.Errorf("failed to set up history schema table: %w", err)
+	}
+	current, err := loadHistorySchemaVersion(dialect, db, table)
+	if err != nil {
+		return fmt.Errorf("failed to read history schema version: %w", err)
+	}
+	if current > HistorySchemaVersion {
+		return fmt.Errorf("history table %s has schema version %d, newer than the supported version %d; upgrade %s", table, current, HistorySchemaVersion, Name)
+	}
+	if current == 0 && !created {
+		if err := recordHistorySchemaVersion(dialect, db, table, historySchemaSteps[0]); err != nil {
+			return fmt.Errorf("failed to record history schema version %d: %w", historySchemaSteps[0].Version, err)
+		}
+		current = historySchemaSteps[0].Version
+	}
+	for _, step := range historySchemaSteps {
+		if step.Version <= current {
+			continue
+		}
+		if step.Apply != nil && !created {
+			logger.Info().Msgf("Upgrading migration history table to schema version %d: %s", step.Version, step.Description)
+			if err := step.Apply(db, dialect, table); err != nil {
+				return fmt.Errorf("failed to upgrade history schema to version %d: %w", step.Version, err)
+			}
+		}
+		if err := recordHistorySchemaVersion(dialect, db, table, step); err != nil {
+			return fmt.Errorf("failed to record history schema version %d: %w", step.Version, err)
+		}
+		current = step.Version
+	}
+	return nil
+}
+
+// SchemaVersion reports the recorded schema version of the history table.
+func (d *DatabaseHistoryDriver) SchemaVersion() (int, error) {
+	return loadHistorySchemaVersion(d.dialect, d.db, d.table)
+}
@@ -0,0 +1,68 @@
+package migrate
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistorySchemaVersionUpgradesLegacyTableSQLite(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "history.db")
+	db, err := NewDB(DialectSQLite, dbPath)
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	defer db.Close()
+
+	// Simulate a history table created before schema versioning existed.
+	if _, err := db.Exec(`CREATE TABLE "migrations" (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL, version TEXT NOT NULL, description TEXT NOT NULL, checksum TEXT NOT NULL, applied_at DATETIME NOT NULL)`); err != nil {
+		t.Fatalf("create legacy table: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := SetupMigrationHistoryTable(DialectSQLite, db, "migrations"); err != nil {
+			t.Fatalf("SetupMigrationHistoryTable run %d: %v", i+1, err)
+		}
+	}
+	version, err := loadHistorySchemaVersion(DialectSQLite, db, "migrations")
+	if err != nil {
+		t.Fatalf("loadHistorySchemaVersion: %v", err)
+	}
+	if version != HistorySchemaVersion {
+		t.Fatalf("schema version = %d, want %d", version, HistorySchemaVersion)
+	}
+	var markers int
+	if err := db.Select(&markers, `SELECT COUNT(*) FROM "migrations_schema"`); err != nil {
+		t.Fatalf("count markers: %v", err)
+	}
+	if markers != len(historySchemaSteps) {
+		t.Fatalf("markers = %d, want %d", markers, len(historySchemaSteps))
+	}
+
+	driver, err := NewDatabaseHistoryDriverFromDB(db, DialectSQLite, "migrations")
+	if err != nil {
+		t.Fatalf("NewDatabaseHistoryDriverFromDB: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO "migrations" (name, version, description, checksum, applied_at) VALUES ('001_legacy', '1.0.0', '', 'abc', '2024-01-01T00:00:00Z')`); err != nil {
+		t.Fatalf("insert legacy entry: %v", err)
+	}
+	if err := driver.Save(MigrationHistory{Name: "002_current", AppliedAt: time.Now(), DSLVersion: DSLVersion, ToolVersion: "v1.2.3"}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	histories, err := driver.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(histories) != 2 || histories[0].DSLVersion != 0 || histories[1].DSLVersion != DSLVersion || histories[1].ToolVersion != "v1.2.3" {
+		t.Fatalf("histories = %+v", histories)
+	}
+
+	if _, err := db.Exec(`INSERT INTO "migrations_schema" (schema_version, description, applied_at) VALUES (?, 'future', '2030-01-01T00:00:00Z')`, HistorySchemaVersion+1); err != nil {
+		t.Fatalf("insert future marker: %v", err)
+	}
+	err = SetupMigrationHistoryTable(DialectSQLite, db, "migrations")
+	if err == nil || !strings.Contains(err.Error(), "newer than the supported version") {
+		t.Fatalf("SetupMigrationHistoryTable error = %v, want newer schema version error", err)
+	}
+}
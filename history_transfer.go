@@ -0,0 +1,89 @@
+package migrate
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/oarkflow/json"
+)
+
+// ExportHistory writes the migration history to w as a JSON array, the
+// layout of the file history, so it can be imported into another history
+// driver with ImportHistory.
+func (d *Manager) ExportHistory(w io.Writer) (int, error) {
+	histories, err := d.historyDriver.Load()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load migration history: %w", err)
+	}
+	data, err := json.MarshalIndent(histories, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode migration history: %w", err)
+	}
+	if _, err := fmt.Fprintln(w, string(data)); err != nil {
+		return 0, err
+	}
+	return len(histories), nil
+}
+
+// ReadHistoryExport reads a history written by ExportHistory, or a file
+// history.
+func ReadHistoryExport(path string) ([]MigrationHistory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var histories []MigrationHistory
+	if err := json.Unmarshal(data, &histories); err != nil {
+		return nil, fmt.Errorf("failed to decode history export %s: %w", path, err)
+	}
+	return histories, nil
+}
+
+// ImportHistory adds histories to the migration history, ordered by the
+// time they were applied, and returns how many were added. Entries of
+// migrations already in the history are skipped; an entry whose checksum
+// differs from the recorded one is an error, as the two histories disagree.
+// With replace the history is replaced by histories instead.
+func (d *Manager) ImportHistory(histories []MigrationHistory, replace bool) (int, error) {
+	var merged []MigrationHistory
+	if !replace {
+		existing, err := d.historyDriver.Load()
+		if err != nil {
+			return 0, fmt.Errorf("failed to load migration history: %w", err)
+		}
+		merged = existing
+	}
+	recorded := make(map[string]MigrationHistory, len(merged))
+	for _, h := range merged {
+		recorded[h.Name] = h
+	}
+	imported := 0
+	for _, h := range histories {
+		if h.Name == "" {
+			return 0, fmt.Errorf("history export has an entry without a migration name")
+		}
+		if prev, ok := recorded[h.Name]; ok {
+			if prev.Checksum != h.Checksum {
+				return 0, fmt.Errorf("migration %s is recorded with checksum %s but imported with %s; import with --replace=true to overwrite the history", h.Name, prev.Checksum, h.Checksum)
+			}
+			continue
+		}
+		// Imported entries go to the history itself, not its archive.
+		h.Archived = false
+		recorded[h.Name] = h
+		merged = append(merged, h)
+		imported++
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].AppliedAt.Before(merged[j].AppliedAt)
+	})
+	if imported == 0 && !replace {
+		return 0, nil
+	}
+	if err := d.historyDriver.Rollback(merged...); err != nil {
+		return 0, fmt.Errorf("failed to write migration history: %w", err)
+	}
+	return imported, nil
+}
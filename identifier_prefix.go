@@ -0,0 +1,145 @@
+package migrate
+
+import "fmt"
+
+// WithIdentifierPrefix prefixes the tables of BCL migrations and seeds with
+// prefix, e.g. "app1_", so several applications can share a database. The
+// indexes generated for a table are named after the prefixed table, and
+// foreign key constraints get the prefix too. Raw SQL migrations and seeds,
+// and the definitions of views, functions and triggers, are used as written.
+func WithIdentifierPrefix(prefix string) ManagerOption {
+	return func(m *Manager) {
+		m.identifierPrefix = prefix
+	}
+}
+
+// validIdentifierPrefix reports whether prefix is empty or can start an SQL
+// identifier.
+func validIdentifierPrefix(prefix string) error {
+	if prefix != "" && !isValidIdentifier(prefix) {
+		return fmt.Errorf("invalid identifier prefix %q: use letters, digits and underscores, starting with a letter or underscore", prefix)
+	}
+	return nil
+}
+
+// WithPrefix returns m with the tables of its operations prefixed, see
+// WithIdentifierPrefix. m itself is not modified.
+func (m Migration) WithPrefix(prefix string) Migration {
+	if prefix == "" {
+		return m
+	}
+	m.Up = m.Up.withPrefix(prefix)
+	m.Down = m.Down.withPrefix(prefix)
+	m.UpSteps = prefixSteps(m.UpSteps, prefix)
+	m.DownSteps = prefixSteps(m.DownSteps, prefix)
+	return m
+}
+
+func prefixSteps(steps []OperationStep, prefix string) []OperationStep {
+	if steps == nil {
+		return nil
+	}
+	prefixed := make([]OperationStep, len(steps))
+	for i, step := range steps {
+		step.Operation = step.Operation.withPrefix(prefix)
+		prefixed[i] = step
+	}
+	return prefixed
+}
+
+func (op Operation) withPrefix(prefix string) Operation {
+	op.CreateTable = prefixEach(op.CreateTable, func(ct *CreateTable) {
+		ct.AddFields = prefixFields(ct.AddFields, ct.Name, prefix)
+		ct.Name = prefix + ct.Name
+	})
+	op.AlterTable = prefixEach(op.AlterTable, func(at *AlterTable) {
+		at.AddFields = prefixFields(at.AddFields, at.Name, prefix)
+		at.Name = prefix + at.Name
+	})
+	op.DropTable = prefixEach(op.DropTable, func(dt *DropTable) { dt.Name = prefix + dt.Name })
+	op.DeleteData = prefixEach(op.DeleteData, func(dd *DeleteData) { dd.Name = prefix + dd.Name })
+	op.RenameTable = prefixEach(op.RenameTable, func(rt *RenameTable) {
+		rt.OldName = prefix + rt.OldName
+		rt.NewName = prefix + rt.NewName
+	})
+	op.DropRowPolicy = prefixEach(op.DropRowPolicy, func(drp *DropRowPolicy) { drp.Table = prefix + drp.Table })
+	op.RebuildIndex = prefixEach(op.RebuildIndex, func(ri *RebuildIndex) {
+		if ri.Table != "" {
+			ri.Table = prefix + ri.Table
+		}
+	})
+	op.ReindexTable = prefixEach(op.ReindexTable, func(rt *ReindexTable) { rt.Name = prefix + rt.Name })
+	op.Vacuum = prefixEach(op.Vacuum, func(v *Vacuum) {
+		if v.Name != "" {
+			v.Name = prefix + v.Name
+		}
+	})
+	op.Analyze = prefixEach(op.Analyze, func(a *Analyze) {
+		if a.Name != "" {
+			a.Name = prefix + a.Name
+		}
+	})
+	return op
+}
+
+// prefixFields prefixes the tables referenced by the foreign keys of fields
+// added to table, and names their constraints after it.
+func prefixFields(fields []AddField, table, prefix string) []AddField {
+	return prefixEach(fields, func(f *AddField) {
+		if f.ForeignKey == nil {
+			return
+		}
+		fk := *f.ForeignKey
+		fk.ReferenceTable = prefix + fk.ReferenceTable
+		if fk.Name == "" {
+			fk.Name = prefix + expandNamingTemplate(f.namingConvention().ForeignKey, table, f.Name)
+		}
+		f.ForeignKey = &fk
+	})
+}
+
+// prefixEach returns a copy of items with fn applied to each, leaving items
+// untouched.
+func prefixEach[T any](items []T, fn func(*T)) []T {
+	if items == nil {
+		return nil
+	}
+	prefixed := make([]T, len(items))
+	copy(prefixed, items)
+	for i := range prefixed {
+		fn(&prefixed[i])
+	}
+	return prefixed
+}
+
+// WithPrefix returns s with its table prefixed, see WithIdentifierPrefix.
+func (s SeedDefinition) WithPrefix(prefix string) SeedDefinition {
+	if prefix != "" && s.Table != "" {
+		s.Table = prefix + s.Table
+	}
+	return s
+}
+
+// prefixedMigrations returns migrations with the identifier prefix applied.
+func (d *Manager) prefixedMigrations(migrations []Migration) []Migration {
+	if d.identifierPrefix == "" {
+		return migrations
+	}
+	prefixed := make([]Migration, len(migrations))
+	for i, m := range migrations {
+		prefixed[i] = m.WithPrefix(d.identifierPrefix)
+	}
+	return prefixed
+}
+
+// prefixedSeeds returns seeds with the identifier prefix applied.
+func (d *Manager) prefixedSeeds(seeds []SeedDefinition) []SeedDefinition {
+	if d.identifierPrefix == "" {
+		return seeds
+	}
+	prefixed := make([]SeedDefinition, len(seeds))
+	for i, s := range seeds {
+		prefixed[i] = s.WithPrefix(d.identifierPrefix)
+	}
+	return prefixed
+}
@@ -0,0 +1,100 @@
+package migrate
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// IdentifierRules describe the table and column names the Validator accepts.
+type IdentifierRules struct {
+	// Unicode allows letters and digits outside ASCII.
+	Unicode bool `json:"unicode"`
+	// Dollar allows $ after the first character, as PostgreSQL, MySQL and
+	// Oracle do.
+	Dollar bool `json:"dollar"`
+	// MaxLength is the maximum length in characters, or in bytes with
+	// LengthInBytes; 0 means no limit.
+	MaxLength     int  `json:"max_length"`
+	LengthInBytes bool `json:"length_in_bytes,omitempty"`
+	// FoldsCase is set for databases folding unquoted identifiers to lower
+	// case. Generated SQL quotes identifiers, so a mixed-case name is case
+	// sensitive there and hand-written SQL must quote it too; the Validator
+	// warns about them.
+	FoldsCase bool `json:"folds_case,omitempty"`
+}
+
+// DefaultIdentifierRules are the rules of NewValidator: ASCII letters,
+// digits and underscores, up to 64 characters.
+var DefaultIdentifierRules = IdentifierRules{MaxLength: 64}
+
+// IdentifierRulesFor returns the identifier rules of dialect, or
+// DefaultIdentifierRules for an unknown dialect.
+func IdentifierRulesFor(dialect string) IdentifierRules {
+	if normalized, err := NormalizeDriver(dialect); err == nil {
+		dialect = normalized
+	}
+	switch dialect {
+	case DialectPostgres:
+		return IdentifierRules{Unicode: true, Dollar: true, MaxLength: 63, LengthInBytes: true, FoldsCase: true}
+	case DialectMySQL:
+		return IdentifierRules{Unicode: true, Dollar: true, MaxLength: 64}
+	case DialectSQLite:
+		return IdentifierRules{Unicode: true}
+	}
+	return DefaultIdentifierRules
+}
+
+// withMaxLength lowers the maximum length of r to max when it is stricter.
+func (r IdentifierRules) withMaxLength(max int) IdentifierRules {
+	if max > 0 && (r.MaxLength == 0 || max < r.MaxLength) {
+		r.MaxLength = max
+	}
+	return r
+}
+
+// check returns why value breaks the rules, or "" when it does not.
+func (r IdentifierRules) check(value string) string {
+	length, unit := utf8.RuneCountInString(value), "characters"
+	if r.LengthInBytes {
+		length, unit = len(value), "bytes"
+	}
+	if r.MaxLength > 0 && length > r.MaxLength {
+		return fmt.Sprintf("identifier too long (max %d %s)", r.MaxLength, unit)
+	}
+	for i, c := range value {
+		ok := c == '_' || c < utf8.RuneSelf && unicode.IsLetter(c) || r.Unicode && unicode.IsLetter(c)
+		if i > 0 {
+			ok = ok || c < utf8.RuneSelf && unicode.IsDigit(c) || r.Unicode && unicode.IsDigit(c) || r.Dollar && c == '$'
+		}
+		if !ok {
+			return r.describe()
+		}
+	}
+	return ""
+}
+
+func (r IdentifierRules) describe() string {
+	if r.Dollar {
+		return "identifier must start with letter or underscore and contain only letters, digits, underscores and dollar signs"
+	}
+	return "identifier must start with letter or underscore and contain only alphanumeric characters and underscores"
+}
+
+// caseSensitive reports whether value is case sensitive under r, as it
+// holds upper case letters on a database folding names to lower case.
+func (r IdentifierRules) caseSensitive(value string) bool {
+	return r.FoldsCase && strings.ToLower(value) != value
+}
+
+// identifierRules returns the identifier rules of the manager: the rules
+// of validation.identifiers, or else those of its dialect, limited to
+// validation.max_identifier_length.
+func (d *Manager) identifierRules() IdentifierRules {
+	rules := IdentifierRulesFor(d.dialect)
+	if d.customIdentifierRules != nil {
+		rules = *d.customIdentifierRules
+	}
+	return rules.withMaxLength(d.maxIdentifierLen)
+}
@@ -0,0 +1,206 @@
+// Package inspect reads the catalog of a live database: its tables and
+// views, and the columns, indexes and foreign keys of each table. It works
+// on any database/sql handle of PostgreSQL, MySQL or SQLite, so tools such
+// as documentation generators and admin UIs can describe a schema without
+// the migration manager.
+//
+//	in, err := inspect.New(db, "postgres")
+//	tables, err := in.ListTables(ctx)
+//	columns, err := in.TableColumns(ctx, "users")
+//
+// PostgreSQL is inspected in the current schema and MySQL in the current
+// database.
+package inspect
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Querier runs catalog queries; *sql.DB, *sql.Conn and *sql.Tx implement it.
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// Table is a table or view.
+type Table struct {
+	Name string
+	// Kind is "table" or "view".
+	Kind string
+}
+
+// Column is a column of a table, in the order of the table.
+type Column struct {
+	Name string
+	// Type is the type as the database reports it, e.g. "character
+	// varying(255)" on PostgreSQL or "varchar(255)" on MySQL.
+	Type     string
+	Nullable bool
+	// Default is the default expression, nil when there is none.
+	Default    *string
+	PrimaryKey bool
+	// Position is the 1-based position of the column in the table.
+	Position int
+}
+
+// Index is an index of a table. SQLite does not list the primary key of a
+// rowid table as an index; see Column.PrimaryKey.
+type Index struct {
+	Name string
+	// Columns lists the indexed columns in index order; expressions are
+	// left out.
+	Columns []string
+	Unique  bool
+	Primary bool
+}
+
+// ForeignKey is a foreign key of a table. SQLite foreign keys have no name.
+type ForeignKey struct {
+	Name       string
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+	// OnDelete and OnUpdate are the referential actions, such as "CASCADE"
+	// or "NO ACTION".
+	OnDelete string
+	OnUpdate string
+}
+
+// catalog holds the queries of a dialect. Each query returns the fields of
+// its type in declaration order, lists of names joined with commas; the
+// table queries take the table name as their only argument.
+type catalog struct {
+	tables      string
+	columns     string
+	indexes     string
+	foreignKeys string
+}
+
+// catalogs maps dialect names and their aliases to their queries.
+var catalogs = map[string]catalog{
+	"postgres":   postgresCatalog,
+	"postgresql": postgresCatalog,
+	"pgx":        postgresCatalog,
+	"mysql":      mysqlCatalog,
+	"mariadb":    mysqlCatalog,
+	"sqlite":     sqliteCatalog,
+	"sqlite3":    sqliteCatalog,
+}
+
+// Inspector reads the catalog of one database.
+type Inspector struct {
+	db      Querier
+	catalog catalog
+}
+
+// New returns an Inspector of db, a database of dialect: "postgres",
+// "mysql" or "sqlite", or an alias such as "postgresql", "mariadb" or
+// "sqlite3".
+func New(db Querier, dialect string) (*Inspector, error) {
+	c, ok := catalogs[strings.ToLower(strings.TrimSpace(dialect))]
+	if !ok {
+		return nil, fmt.Errorf("inspect: unsupported dialect %q", dialect)
+	}
+	return &Inspector{db: db, catalog: c}, nil
+}
+
+// query runs query and calls scan for each row.
+func (i *Inspector) query(ctx context.Context, query string, scan func(*sql.Rows) error, args ...any) error {
+	rows, err := i.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ListTables returns the tables and views, ordered by name. Tables the
+// database keeps for itself are left out.
+func (i *Inspector) ListTables(ctx context.Context) ([]Table, error) {
+	var tables []Table
+	err := i.query(ctx, i.catalog.tables, func(rows *sql.Rows) error {
+		var t Table
+		if err := rows.Scan(&t.Name, &t.Kind); err != nil {
+			return err
+		}
+		tables = append(tables, t)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("inspect: failed to list tables: %w", err)
+	}
+	return tables, nil
+}
+
+// TableColumns returns the columns of table in table order, none when the
+// table does not exist.
+func (i *Inspector) TableColumns(ctx context.Context, table string) ([]Column, error) {
+	var columns []Column
+	err := i.query(ctx, i.catalog.columns, func(rows *sql.Rows) error {
+		var c Column
+		var primary int
+		if err := rows.Scan(&c.Name, &c.Type, &c.Nullable, &c.Default, &primary, &c.Position); err != nil {
+			return err
+		}
+		c.PrimaryKey = primary > 0
+		columns = append(columns, c)
+		return nil
+	}, table)
+	if err != nil {
+		return nil, fmt.Errorf("inspect: failed to list the columns of %s: %w", table, err)
+	}
+	return columns, nil
+}
+
+// Indexes returns the indexes of table, ordered by name.
+func (i *Inspector) Indexes(ctx context.Context, table string) ([]Index, error) {
+	var indexes []Index
+	err := i.query(ctx, i.catalog.indexes, func(rows *sql.Rows) error {
+		var x Index
+		var columns sql.NullString
+		if err := rows.Scan(&x.Name, &x.Unique, &x.Primary, &columns); err != nil {
+			return err
+		}
+		x.Columns = splitNames(columns.String)
+		indexes = append(indexes, x)
+		return nil
+	}, table)
+	if err != nil {
+		return nil, fmt.Errorf("inspect: failed to list the indexes of %s: %w", table, err)
+	}
+	return indexes, nil
+}
+
+// ForeignKeys returns the foreign keys of table, ordered by name, or on
+// SQLite in the order of PRAGMA foreign_key_list.
+func (i *Inspector) ForeignKeys(ctx context.Context, table string) ([]ForeignKey, error) {
+	var keys []ForeignKey
+	err := i.query(ctx, i.catalog.foreignKeys, func(rows *sql.Rows) error {
+		var fk ForeignKey
+		var columns, refColumns sql.NullString
+		if err := rows.Scan(&fk.Name, &fk.RefTable, &columns, &refColumns, &fk.OnDelete, &fk.OnUpdate); err != nil {
+			return err
+		}
+		fk.Columns, fk.RefColumns = splitNames(columns.String), splitNames(refColumns.String)
+		keys = append(keys, fk)
+		return nil
+	}, table)
+	if err != nil {
+		return nil, fmt.Errorf("inspect: failed to list the foreign keys of %s: %w", table, err)
+	}
+	return keys, nil
+}
+
+func splitNames(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
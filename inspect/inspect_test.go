@@ -0,0 +1,87 @@
+package inspect
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/oarkflow/squealx/drivers/sqlite"
+)
+
+func TestInspectSQLiteCatalog(t *testing.T) {
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "inspect.db"), "inspect")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+	for _, statement := range []string{
+		`CREATE TABLE teams (id INTEGER PRIMARY KEY, name TEXT NOT NULL UNIQUE)`,
+		`CREATE TABLE users (
+			id INTEGER PRIMARY KEY,
+			email VARCHAR(255) NOT NULL,
+			team_id INTEGER REFERENCES teams (id) ON DELETE CASCADE,
+			status TEXT DEFAULT 'active'
+		)`,
+		`CREATE INDEX idx_users_team_status ON users (team_id, status)`,
+		`CREATE VIEW active_users AS SELECT id FROM users WHERE status = 'active'`,
+	} {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("exec %q: %v", statement, err)
+		}
+	}
+	in, err := New(db.DB(), "sqlite3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	tables, err := in.ListTables(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Table{{"active_users", "view"}, {"teams", "table"}, {"users", "table"}}
+	if !reflect.DeepEqual(tables, want) {
+		t.Fatalf("ListTables = %+v, want %+v", tables, want)
+	}
+
+	columns, err := in.TableColumns(ctx, "users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(columns) != 4 {
+		t.Fatalf("expected 4 columns, got %+v", columns)
+	}
+	id, email, status := columns[0], columns[1], columns[3]
+	if !id.PrimaryKey || email.Type != "VARCHAR(255)" || email.Nullable || email.Position != 2 || email.Default != nil {
+		t.Fatalf("unexpected columns %+v %+v", id, email)
+	}
+	if !status.Nullable || status.Default == nil || *status.Default != "'active'" {
+		t.Fatalf("expected status to default to 'active', got %+v", status)
+	}
+
+	indexes, err := in.Indexes(ctx, "users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(indexes) != 1 || indexes[0].Name != "idx_users_team_status" || indexes[0].Unique ||
+		!reflect.DeepEqual(indexes[0].Columns, []string{"team_id", "status"}) {
+		t.Fatalf("unexpected indexes %+v", indexes)
+	}
+	if indexes, err := in.Indexes(ctx, "teams"); err != nil || len(indexes) != 1 || !indexes[0].Unique || indexes[0].Columns[0] != "name" {
+		t.Fatalf("expected the unique index of teams.name, got %+v, %v", indexes, err)
+	}
+
+	keys, err := in.ForeignKeys(ctx, "users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantKeys := []ForeignKey{{Columns: []string{"team_id"}, RefTable: "teams", RefColumns: []string{"id"}, OnDelete: "CASCADE", OnUpdate: "NO ACTION"}}
+	if !reflect.DeepEqual(keys, wantKeys) {
+		t.Fatalf("ForeignKeys = %+v, want %+v", keys, wantKeys)
+	}
+
+	if _, err := New(db.DB(), "oracle"); err == nil {
+		t.Fatal("expected an unsupported dialect error")
+	}
+}
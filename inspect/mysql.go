@@ -0,0 +1,29 @@
+package inspect
+
+// mysqlCatalog reads the information_schema of the current database.
+var mysqlCatalog = catalog{
+	tables: `SELECT table_name, IF(table_type = 'VIEW', 'view', 'table')
+FROM information_schema.tables
+WHERE table_schema = DATABASE()
+ORDER BY table_name`,
+	columns: `SELECT column_name, column_type, is_nullable = 'YES', column_default, column_key = 'PRI', ordinal_position
+FROM information_schema.columns
+WHERE table_schema = DATABASE() AND table_name = ?
+ORDER BY ordinal_position`,
+	indexes: `SELECT index_name, MIN(non_unique) = 0, index_name = 'PRIMARY',
+	GROUP_CONCAT(column_name ORDER BY seq_in_index)
+FROM information_schema.statistics
+WHERE table_schema = DATABASE() AND table_name = ?
+GROUP BY index_name
+ORDER BY index_name`,
+	foreignKeys: `SELECT k.constraint_name, k.referenced_table_name,
+	GROUP_CONCAT(k.column_name ORDER BY k.ordinal_position),
+	GROUP_CONCAT(k.referenced_column_name ORDER BY k.ordinal_position),
+	r.delete_rule, r.update_rule
+FROM information_schema.key_column_usage k
+JOIN information_schema.referential_constraints r
+	ON r.constraint_schema = k.constraint_schema AND r.constraint_name = k.constraint_name AND r.table_name = k.table_name
+WHERE k.table_schema = DATABASE() AND k.table_name = ? AND k.referenced_table_name IS NOT NULL
+GROUP BY k.constraint_name, k.referenced_table_name, r.delete_rule, r.update_rule
+ORDER BY k.constraint_name`,
+}
@@ -0,0 +1,42 @@
+package inspect
+
+// postgresCatalog reads the pg_catalog of the current schema. Tables are
+// resolved through the search path.
+var postgresCatalog = catalog{
+	tables: `SELECT table_name, CASE table_type WHEN 'VIEW' THEN 'view' ELSE 'table' END
+FROM information_schema.tables
+WHERE table_schema = current_schema()
+ORDER BY table_name`,
+	columns: `SELECT a.attname, pg_catalog.format_type(a.atttypid, a.atttypmod), NOT a.attnotnull,
+	pg_catalog.pg_get_expr(d.adbin, d.adrelid),
+	CASE WHEN EXISTS (SELECT 1 FROM pg_catalog.pg_index i
+		WHERE i.indrelid = a.attrelid AND i.indisprimary AND a.attnum = ANY(i.indkey)) THEN 1 ELSE 0 END,
+	a.attnum
+FROM pg_catalog.pg_attribute a
+LEFT JOIN pg_catalog.pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+WHERE a.attrelid = to_regclass(quote_ident($1)) AND a.attnum > 0 AND NOT a.attisdropped
+ORDER BY a.attnum`,
+	indexes: `SELECT c.relname, i.indisunique, i.indisprimary,
+	array_to_string(ARRAY(SELECT a.attname FROM unnest(i.indkey) WITH ORDINALITY k(attnum, n)
+		JOIN pg_catalog.pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = k.attnum ORDER BY k.n), ',')
+FROM pg_catalog.pg_index i
+JOIN pg_catalog.pg_class c ON c.oid = i.indexrelid
+WHERE i.indrelid = to_regclass(quote_ident($1))
+ORDER BY c.relname`,
+	foreignKeys: `SELECT c.conname, r.relname,
+	array_to_string(ARRAY(SELECT a.attname FROM unnest(c.conkey) WITH ORDINALITY k(attnum, n)
+		JOIN pg_catalog.pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum ORDER BY k.n), ','),
+	array_to_string(ARRAY(SELECT a.attname FROM unnest(c.confkey) WITH ORDINALITY k(attnum, n)
+		JOIN pg_catalog.pg_attribute a ON a.attrelid = c.confrelid AND a.attnum = k.attnum ORDER BY k.n), ','),
+	` + postgresAction("c.confdeltype") + `, ` + postgresAction("c.confupdtype") + `
+FROM pg_catalog.pg_constraint c
+JOIN pg_catalog.pg_class r ON r.oid = c.confrelid
+WHERE c.contype = 'f' AND c.conrelid = to_regclass(quote_ident($1))
+ORDER BY c.conname`,
+}
+
+// postgresAction spells out the referential action code in column.
+func postgresAction(column string) string {
+	return `CASE ` + column + ` WHEN 'c' THEN 'CASCADE' WHEN 'n' THEN 'SET NULL' WHEN 'd' THEN 'SET DEFAULT'
+		WHEN 'r' THEN 'RESTRICT' ELSE 'NO ACTION' END`
+}
@@ -0,0 +1,21 @@
+package inspect
+
+// sqliteCatalog reads sqlite_master and the table-valued PRAGMA functions of
+// the main database.
+var sqliteCatalog = catalog{
+	tables: `SELECT name, type
+FROM sqlite_master
+WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%'
+ORDER BY name`,
+	columns: `SELECT name, type, "notnull" = 0, dflt_value, pk, cid + 1
+FROM pragma_table_info(?)
+ORDER BY cid`,
+	indexes: `SELECT l.name, l."unique", l.origin = 'pk',
+	(SELECT group_concat(name, ',') FROM (SELECT name FROM pragma_index_info(l.name) WHERE name IS NOT NULL ORDER BY seqno))
+FROM pragma_index_list(?) l
+ORDER BY l.name`,
+	foreignKeys: `SELECT '', "table", group_concat("from", ','), group_concat("to", ','), on_delete, on_update
+FROM (SELECT * FROM pragma_foreign_key_list(?) ORDER BY id, seq)
+GROUP BY id
+ORDER BY id`,
+}
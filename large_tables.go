@@ -0,0 +1,157 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultLargeTableRows is the estimated row count above which plan and lint
+// warn about ALTERs on a table.
+const DefaultLargeTableRows int64 = 1_000_000
+
+// WithLargeTableRows sets the estimated row count above which ALTERs are
+// reported as long-running by migrate --check and migration:validate. Zero
+// disables the check.
+func WithLargeTableRows(rows int64) ManagerOption {
+	return func(m *Manager) {
+		m.largeTableRows = rows
+	}
+}
+
+// alterImpact describes how an AlterTable touches the existing rows.
+type alterImpact int
+
+const (
+	// alterMetadata only changes the catalog.
+	alterMetadata alterImpact = iota
+	// alterScan reads every row, e.g. to build an index or validate a
+	// constraint, while holding a lock.
+	alterScan
+	// alterRewrite copies every row into a new table.
+	alterRewrite
+)
+
+// classifyAlter reports the most expensive effect of at on dialect.
+func classifyAlter(dialect string, at AlterTable) alterImpact {
+	switch dialect {
+	case DialectSQLite:
+		// Dropping or renaming a field, or changing the primary key,
+		// recreates the table.
+		if len(at.DropFields) > 0 || len(at.RenameFields) > 0 || len(at.DropPrimaryKey) > 0 || len(at.AddPrimaryKey) > 0 {
+			return alterRewrite
+		}
+	case DialectPostgres:
+		for _, rf := range at.RenameFields {
+			// ALTER COLUMN TYPE rewrites the table for most type changes.
+			if rf.Type != "" {
+				return alterRewrite
+			}
+		}
+	case DialectMySQL:
+		// InnoDB clusters rows by primary key, so changing it copies them.
+		if len(at.DropFields) > 0 || len(at.DropPrimaryKey) > 0 || len(at.AddPrimaryKey) > 0 {
+			return alterRewrite
+		}
+		for _, rf := range at.RenameFields {
+			// CHANGE COLUMN always restates the type; a changed type copies the table.
+			if rf.Type != "" {
+				return alterRewrite
+			}
+		}
+	}
+	if len(at.AddPrimaryKey) > 0 {
+		return alterScan
+	}
+	for _, af := range at.AddFields {
+		if af.PrimaryKey || af.Unique || af.Index || af.ForeignKey != nil || af.Check != "" {
+			return alterScan
+		}
+	}
+	return alterMetadata
+}
+
+// EstimateTableRows returns the row estimate the database keeps for table,
+// or 0 when the table does not exist yet.
+func (d *Manager) EstimateTableRows(table string) (int64, error) {
+	exists, err := d.TableExists(table)
+	if err != nil || !exists {
+		return 0, err
+	}
+	dial, err := GetDialect(d.dialect)
+	if err != nil {
+		return 0, err
+	}
+	var rows int64
+	if err := d.dbDriver.QueryRow(context.Background(), &rows, dial.TableRowsEstimateSQL(table)); err != nil {
+		return 0, fmt.Errorf("failed to estimate rows of %s: %w", table, err)
+	}
+	return rows, nil
+}
+
+// LargeTableWarnings lists the ALTERs in the Up block of m that touch tables
+// with more estimated rows than the configured threshold, e.g. "ALTER on
+// table orders with ~40000000 rows will rewrite the table".
+func (d *Manager) LargeTableWarnings(m Migration) ([]string, error) {
+	if d.largeTableRows <= 0 {
+		return nil, nil
+	}
+	dialect := d.dialect
+	if m.Driver != "" {
+		normalized, err := NormalizeDriver(m.Driver)
+		if err != nil {
+			return nil, fmt.Errorf("invalid driver in migration %s: %w", m.Name, err)
+		}
+		dialect = normalized
+	}
+	var warnings []string
+	for _, at := range m.Up.AlterTable {
+		impact := classifyAlter(dialect, at)
+		if impact == alterMetadata {
+			continue
+		}
+		rows, err := d.EstimateTableRows(at.Name)
+		if err != nil {
+			return warnings, err
+		}
+		if rows < d.largeTableRows {
+			continue
+		}
+		effect := "will rewrite the table"
+		if impact == alterScan {
+			effect = "will scan the table while holding a lock"
+		}
+		warnings = append(warnings, fmt.Sprintf("ALTER on table %s with ~%d rows %s", at.Name, rows, effect))
+	}
+	return warnings, nil
+}
+
+// warnLargeTables logs LargeTableWarnings for the named BCL migrations.
+// Failures to estimate are logged too; they never fail plan or lint.
+func (d *Manager) warnLargeTables(migrationMap map[string]string, names []string) {
+	if d.largeTableRows <= 0 || d.dbDriver == nil {
+		return
+	}
+	for _, name := range names {
+		path, ok := migrationMap[name]
+		if !ok || strings.EqualFold(filepath.Ext(path), ".sql") {
+			continue
+		}
+		cached, err := d.readMigrationsBCL(path)
+		if err != nil {
+			continue
+		}
+		migration, ok := findMigrationByName(cached.migrations, name)
+		if !ok {
+			continue
+		}
+		warnings, err := d.LargeTableWarnings(migration)
+		for _, w := range warnings {
+			logger.Warn().Msgf("Migration %s: %s", name, w)
+		}
+		if err != nil {
+			logger.Warn().Err(err).Msgf("Migration %s: could not estimate table sizes", name)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oarkflow/json"
+)
+
+// defaultLockLease is how long a lock stays valid without a heartbeat. The
+// holder renews it every third of the lease.
+const defaultLockLease = 30 * time.Second
+
+// LockInfo describes the holder of the migration lock.
+type LockInfo struct {
+	Holder       string    `json:"holder"`
+	PID          int       `json:"pid"`
+	Command      string    `json:"command"`
+	AcquiredAt   time.Time `json:"acquired_at"`
+	RenewedAt    time.Time `json:"renewed_at"`
+	LeaseSeconds int       `json:"lease_seconds"`
+}
+
+// ExpiresAt returns when the lease ends unless renewed.
+func (l LockInfo) ExpiresAt() time.Time {
+	return l.RenewedAt.Add(time.Duration(l.LeaseSeconds) * time.Second)
+}
+
+// Stale reports whether the holder stopped renewing the lease.
+func (l LockInfo) Stale(now time.Time) bool {
+	return now.After(l.ExpiresAt())
+}
+
+// errLockLost reports that another process took the migration lock over
+// while it was held, e.g. after a heartbeat was delayed past the lease.
+var errLockLost = errors.New("migration lock lost")
+
+// migrationLock is a held lock whose lease is renewed in the background
+// until Release is called.
+type migrationLock struct {
+	path  string
+	lease time.Duration
+	mu    sync.Mutex
+	info  LockInfo
+	// lost is set once the lock file is found to belong to another holder;
+	// the heartbeat stops then.
+	lost error
+	stop chan struct{}
+	done chan struct{}
+}
+
+func acquireLock() (*migrationLock, error) {
+	return acquireLockAt(lockFileName, defaultLockLease)
+}
+
+// acquireLockAt creates the lock file at path exclusively. A lock whose lease
+// has expired is taken over with a warning; a live lock is reported with its
+// holder so operators can tell a long migration from a dead one.
+func acquireLockAt(path string, lease time.Duration) (*migrationLock, error) {
+	hostname, _ := os.Hostname()
+	now := time.Now()
+	l := &migrationLock{
+		path:  path,
+		lease: lease,
+		info: LockInfo{
+			Holder:       hostname,
+			PID:          os.Getpid(),
+			Command:      strings.Join(os.Args, " "),
+			AcquiredAt:   now,
+			RenewedAt:    now,
+			LeaseSeconds: int(lease.Round(time.Second) / time.Second),
+		},
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	if l.info.LeaseSeconds < 1 {
+		l.info.LeaseSeconds = 1
+	}
+	for attempt := 0; attempt < 3; attempt++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			if err := l.write(); err != nil {
+				os.Remove(path)
+				return nil, err
+			}
+			go l.heartbeat()
+			return l, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create lock file: %w", err)
+		}
+		seen, err := os.Stat(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to inspect lock file: %w", err)
+		}
+		holder, err := readLockInfo(path)
+		if err != nil {
+			return nil, err
+		}
+		if holder == nil {
+			continue
+		}
+		if !holder.Stale(time.Now()) {
+			return nil, fmt.Errorf("migration lock already acquired by %s (pid %d) since %s, lease expires %s",
+				holder.Holder, holder.PID, holder.AcquiredAt.Format(time.DateTime), holder.ExpiresAt().Format(time.DateTime))
+		}
+		logger.Warn().Msgf("Taking over stale migration lock held by %s (pid %d), last renewed %s",
+			holder.Holder, holder.PID, holder.RenewedAt.Format(time.DateTime))
+		if err := takeOverStaleLock(path, seen); err != nil {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("failed to acquire migration lock at %s", path)
+}
+
+// takeOverStaleLock moves the stale lock file seen at path out of the way.
+// Removing it by name would race with other processes taking it over: one
+// of them could delete the fresh lock another has just created. Renaming is
+// atomic, so only one process gets the stale file; a process that got a
+// different file instead puts it back and finds the lock held on its next
+// attempt.
+func takeOverStaleLock(path string, seen os.FileInfo) error {
+	moved := fmt.Sprintf("%s.stale-%d-%d", path, os.Getpid(), time.Now().UnixNano())
+	if err := os.Rename(path, moved); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to remove stale lock file: %w", err)
+	}
+	defer os.Remove(moved)
+	got, err := os.Stat(moved)
+	if err != nil {
+		return fmt.Errorf("failed to inspect stale lock file: %w", err)
+	}
+	if os.SameFile(got, seen) && got.ModTime().Equal(seen.ModTime()) {
+		return nil
+	}
+	if err := os.Link(moved, path); err != nil && !os.IsExist(err) {
+		return fmt.Errorf("failed to restore migration lock taken over concurrently: %w", err)
+	}
+	return nil
+}
+
+// write replaces the lock file with the holder information through a
+// temporary file, so readers never see a partly written file.
+func (l *migrationLock) write() error {
+	l.mu.Lock()
+	data, err := json.Marshal(l.info)
+	l.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	tmp := fmt.Sprintf("%s.tmp-%d", l.path, os.Getpid())
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write lock file: %w", err)
+	}
+	if err := os.Rename(tmp, l.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write lock file: %w", err)
+	}
+	return nil
+}
+
+// checkOwner reports errLockLost, recording it, when the lock file no
+// longer holds the holder, process and acquisition time recorded when the
+// lock was acquired.
+func (l *migrationLock) checkOwner() error {
+	current, err := readLockInfo(l.path)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.lost != nil {
+		return l.lost
+	}
+	switch {
+	case current == nil:
+		l.lost = fmt.Errorf("%w: the lock file %s was removed", errLockLost, l.path)
+	case current.Holder != l.info.Holder || current.PID != l.info.PID || !current.AcquiredAt.Equal(l.info.AcquiredAt):
+		l.lost = fmt.Errorf("%w: now held by %s (pid %d) since %s",
+			errLockLost, current.Holder, current.PID, current.AcquiredAt.Format(time.DateTime))
+	}
+	return l.lost
+}
+
+// Err returns the error telling why the lock was lost, or nil while it is
+// held. Runs check it between migrations so they stop once another
+// process holds the lock.
+func (l *migrationLock) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lost
+}
+
+func (l *migrationLock) heartbeat() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.lease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			if err := l.checkOwner(); err != nil {
+				if errors.Is(err, errLockLost) {
+					logger.Error().Msgf("Stopped renewing the migration lock: %v", err)
+					return
+				}
+				logger.Warn().Msgf("Failed to renew migration lock: %v", err)
+				continue
+			}
+			l.mu.Lock()
+			l.info.RenewedAt = time.Now()
+			l.mu.Unlock()
+			if err := l.write(); err != nil {
+				logger.Warn().Msgf("Failed to renew migration lock: %v", err)
+			}
+		}
+	}
+}
+
+// Release stops the heartbeat and removes the lock file. A lock file that
+// now belongs to another holder is left in place and errLockLost returned.
+func (l *migrationLock) Release() error {
+	close(l.stop)
+	<-l.done
+	if err := l.checkOwner(); err != nil {
+		return err
+	}
+	if err := os.Remove(l.path); err != nil {
+		return fmt.Errorf("failed to remove lock file: %w", err)
+	}
+	return nil
+}
+
+// readLockInfo returns the holder recorded at path, or nil when no lock file
+// exists. Empty lock files written by older versions are reported using the
+// file modification time and the default lease.
+func readLockInfo(path string) (*LockInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read lock file: %w", err)
+	}
+	var info LockInfo
+	if len(strings.TrimSpace(string(data))) == 0 || json.Unmarshal(data, &info) != nil || info.RenewedAt.IsZero() {
+		stat, err := os.Stat(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to inspect lock file: %w", err)
+		}
+		info = LockInfo{
+			Holder:       "unknown",
+			AcquiredAt:   stat.ModTime(),
+			RenewedAt:    stat.ModTime(),
+			LeaseSeconds: int(defaultLockLease / time.Second),
+		}
+	}
+	return &info, nil
+}
+
+// ReadLockStatus returns the current holder of the migration lock, or nil when
+// the lock is free.
+func ReadLockStatus() (*LockInfo, error) {
+	return readLockInfo(lockFileName)
+}
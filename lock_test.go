@@ -0,0 +1,125 @@
+package migrate
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oarkflow/json"
+)
+
+func TestMigrationLockRenewsLeaseAndTakesOverStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), lockFileName)
+
+	lock, err := acquireLockAt(path, 300*time.Millisecond)
+	if err != nil {
+		t.Fatalf("acquireLockAt: %v", err)
+	}
+	if _, err := acquireLockAt(path, time.Second); err == nil || !strings.Contains(err.Error(), "already acquired by") {
+		t.Fatalf("second acquire error = %v, want held error", err)
+	}
+	first, err := readLockInfo(path)
+	if err != nil || first == nil {
+		t.Fatalf("readLockInfo: %v, %v", first, err)
+	}
+	time.Sleep(400 * time.Millisecond)
+	renewed, err := readLockInfo(path)
+	if err != nil || renewed == nil {
+		t.Fatalf("readLockInfo after heartbeat: %v, %v", renewed, err)
+	}
+	if !renewed.RenewedAt.After(first.RenewedAt) {
+		t.Fatalf("lease not renewed: first %s, now %s", first.RenewedAt, renewed.RenewedAt)
+	}
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if info, err := readLockInfo(path); err != nil || info != nil {
+		t.Fatalf("readLockInfo after release = %v, %v", info, err)
+	}
+
+	stale := LockInfo{Holder: "dead-host", PID: 1, AcquiredAt: time.Now().Add(-time.Hour), RenewedAt: time.Now().Add(-time.Hour), LeaseSeconds: 30}
+	data, _ := json.Marshal(stale)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("write stale lock: %v", err)
+	}
+	lock, err = acquireLockAt(path, time.Second)
+	if err != nil {
+		t.Fatalf("acquire over stale lock: %v", err)
+	}
+	defer lock.Release()
+	info, err := readLockInfo(path)
+	if err != nil || info == nil || info.Holder == "dead-host" {
+		t.Fatalf("lock holder after takeover = %+v, %v", info, err)
+	}
+}
+
+func TestMigrationLockStaleTakeoverKeepsFreshLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), lockFileName)
+	stale := LockInfo{Holder: "dead-host", PID: 1, AcquiredAt: time.Now().Add(-time.Hour), RenewedAt: time.Now().Add(-time.Hour), LeaseSeconds: 30}
+	data, _ := json.Marshal(stale)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("write stale lock: %v", err)
+	}
+	seen, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat stale lock: %v", err)
+	}
+	lock, err := acquireLockAt(path, time.Second)
+	if err != nil {
+		t.Fatalf("acquire over stale lock: %v", err)
+	}
+	defer lock.Release()
+
+	// A second process that saw the same stale lock takes over only after
+	// the first one already holds the lock.
+	if err := takeOverStaleLock(path, seen); err != nil {
+		t.Fatalf("takeOverStaleLock: %v", err)
+	}
+	info, err := readLockInfo(path)
+	if err != nil || info == nil || info.PID != lock.info.PID || !info.AcquiredAt.Equal(lock.info.AcquiredAt) {
+		t.Fatalf("lock holder after late takeover = %+v, %v", info, err)
+	}
+	if _, err := acquireLockAt(path, time.Second); err == nil || !strings.Contains(err.Error(), "already acquired by") {
+		t.Fatalf("acquire after late takeover error = %v, want held error", err)
+	}
+	matches, _ := filepath.Glob(path + ".stale-*")
+	if len(matches) != 0 {
+		t.Fatalf("stale lock files left behind: %v", matches)
+	}
+}
+
+func TestMigrationLockStopsWhenTakenOver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), lockFileName)
+	lock, err := acquireLockAt(path, 300*time.Millisecond)
+	if err != nil {
+		t.Fatalf("acquireLockAt: %v", err)
+	}
+	if err := lock.Err(); err != nil {
+		t.Fatalf("Err of a held lock = %v", err)
+	}
+
+	// Another process took the lock over, e.g. while this one was paused.
+	other := LockInfo{Holder: "other-host", PID: 42, AcquiredAt: time.Now(), RenewedAt: time.Now(), LeaseSeconds: 30}
+	data, _ := json.Marshal(other)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("write other lock: %v", err)
+	}
+	time.Sleep(250 * time.Millisecond)
+	if err := lock.Err(); !errors.Is(err, errLockLost) || !strings.Contains(err.Error(), "other-host") {
+		t.Fatalf("Err after takeover = %v, want errLockLost naming the new holder", err)
+	}
+	if err := lock.Release(); !errors.Is(err, errLockLost) {
+		t.Fatalf("Release after takeover = %v, want errLockLost", err)
+	}
+	info, err := readLockInfo(path)
+	if err != nil || info == nil || info.Holder != "other-host" {
+		t.Fatalf("lock of the new holder after release = %+v, %v", info, err)
+	}
+	matches, _ := filepath.Glob(path + ".tmp-*")
+	if len(matches) != 0 {
+		t.Fatalf("temporary lock files left behind: %v", matches)
+	}
+}
@@ -0,0 +1,105 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/oarkflow/json"
+	"github.com/oarkflow/log"
+)
+
+// SetLogLevel sets the level of the package logger to "debug", "info",
+// "warn" or "error". Debug adds the generated SQL and other details to the
+// progress logged at info; warn and error only report problems. The logger
+// is shared by every manager.
+func SetLogLevel(level string) error {
+	switch level {
+	case "debug":
+		logger.Level = log.DebugLevel
+	case "", "info":
+		logger.Level = log.InfoLevel
+	case "warn":
+		logger.Level = log.WarnLevel
+	case "error":
+		logger.Level = log.ErrorLevel
+	default:
+		return fmt.Errorf("invalid log level %q: must be debug, info, warn or error", level)
+	}
+	return nil
+}
+
+// WithLogLevel sets the log level, see SetLogLevel.
+func WithLogLevel(level string) ManagerOption {
+	return func(m *Manager) {
+		if err := SetLogLevel(level); err != nil {
+			logger.Warn().Msg(err.Error())
+		}
+	}
+}
+
+// WithSlogHandler sends the package log to handler instead of the console,
+// so applications embedding the manager route migration logs through
+// log/slog. Each entry becomes a record with its message and level, and its
+// other fields, such as error and caller, as attributes. The level set by
+// SetLogLevel still applies first.
+func WithSlogHandler(handler slog.Handler) ManagerOption {
+	return func(m *Manager) {
+		logger.Writer = slogWriter{handler: handler}
+	}
+}
+
+// slogWriter is a log.Writer passing entries to a slog.Handler.
+type slogWriter struct {
+	handler slog.Handler
+}
+
+func (w slogWriter) WriteEntry(e *log.Entry) (int, error) {
+	data := e.Value()
+	level := slogLevel(e.Level)
+	ctx := context.Background()
+	if !w.handler.Enabled(ctx, level) {
+		return len(data), nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return 0, fmt.Errorf("failed to decode log entry: %w", err)
+	}
+	message, _ := fields["message"].(string)
+	delete(fields, "message")
+	delete(fields, "level")
+	delete(fields, "time")
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	record := slog.NewRecord(time.Now(), level, message, 0)
+	for _, key := range keys {
+		record.AddAttrs(slog.Any(key, fields[key]))
+	}
+	return len(data), w.handler.Handle(ctx, record)
+}
+
+func slogLevel(level log.Level) slog.Level {
+	switch {
+	case level <= log.DebugLevel:
+		return slog.LevelDebug
+	case level == log.InfoLevel:
+		return slog.LevelInfo
+	case level == log.WarnLevel:
+		return slog.LevelWarn
+	}
+	return slog.LevelError
+}
+
+// detailLog returns the entry for details such as generated SQL: info when
+// the manager is verbose, debug otherwise.
+func (d *Manager) detailLog() *log.Entry {
+	if d.Verbose {
+		return logger.Info()
+	}
+	return logger.Debug()
+}
@@ -1,11 +1,16 @@
 package migrate
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -14,6 +19,7 @@ import (
 	"github.com/oarkflow/cli"
 	"github.com/oarkflow/cli/contracts"
 	"github.com/oarkflow/log"
+	"github.com/oarkflow/migrate/inspect"
 	"github.com/oarkflow/squealx"
 )
 
@@ -23,6 +29,7 @@ var (
 )
 
 var logger = log.Logger{
+	Level:      log.InfoLevel,
 	TimeFormat: "15:04:05",
 	Caller:     1,
 	Writer: &log.ConsoleWriter{
@@ -33,11 +40,26 @@ var logger = log.Logger{
 }
 
 type IDatabaseDriver interface {
+	// ApplySQL applies queries in one transaction, binding args[0] to each.
 	ApplySQL(queries []string, args ...any) error
+	// ApplyBatch applies statements in order, each with its own arguments
+	// and options (timeout, no-transaction, continue-on-error).
+	ApplyBatch(statements []Statement) error
+	// QueryRow scans the first row of query into dest.
+	QueryRow(ctx context.Context, dest any, query string, args ...any) error
+	// Query scans every row of query into dest, a pointer to a slice.
+	Query(ctx context.Context, dest any, query string, args ...any) error
+	Ping(ctx context.Context) error
 	DB() *squealx.DB
 	SetForce(force bool)
 }
 
+// sessionConfigurer is implemented by drivers that run setup statements on
+// each connection they use.
+type sessionConfigurer interface {
+	SetSessionSetup(statements ...string)
+}
+
 type IManager interface {
 	MigrationDir() string
 	SeedDir() string
@@ -62,23 +84,112 @@ type Manager struct {
 	historyDriver HistoryDriver
 	Verbose       bool
 	Force         bool
-	command       []contracts.Command
+	// AllowModified rolls back migrations modified since they were applied
+	// with the Down of the current file
+	AllowModified bool
+	// FromHistory rolls back migrations with the Down SQL stored when they
+	// were applied instead of the Down of their files
+	FromHistory bool
+	// CascadeDependencies drops the views depending on a table before a
+	// DropTable removes it
+	CascadeDependencies bool
+	command             []contracts.Command
 	// configPath stores the path to the config file that was loaded
 	configPath string
+	// environment describes the target environment and whether destructive
+	// commands against it need explicit confirmation
+	environment EnvironmentConfig
+	// redactor masks bound parameter values in verbose statement logs
+	redactor *Redactor
+	// jobs bounds the number of files parsed concurrently; 0 means one per CPU
+	jobs int
+	// seedJobs bounds the number of seed files run concurrently; 0 runs
+	// them one after another
+	seedJobs int
+	// seedStream controls streaming of large raw seed files
+	seedStream SeedStreamOptions
+	// seedFilter restricts seed runs to some tables, see db:seed --table
+	seedFilter SeedFilter
+	// seedKeepGoing runs the other seed files when one fails, see
+	// WithSeedKeepGoing
+	seedKeepGoing bool
+	// seedKey is the secret reference holding the key of encrypted seeds
+	seedKey string
+	// seedLookupTables lists the tables the sql() helper of seed
+	// expressions may read
+	seedLookupTables []string
+	// sessionSetup holds statements run on every driver connection
+	sessionSetup []string
+	// backupDir and dumper take table dumps before destructive operations;
+	// a nil dumper disables backups
+	backupDir string
+	dumper    TableDumper
+	// snapshotDir and snapshotter save and restore database snapshots, see
+	// db:snapshot; a nil snapshotter uses SQLiteSnapshotter on SQLite
+	snapshotDir string
+	snapshotter Snapshotter
+	// auditLog is the JSON lines file receiving audit entries
+	auditLog string
+	// largeTableRows is the estimated row count above which ALTERs are
+	// reported as long-running; 0 disables the check
+	largeTableRows int64
+	// checksumMode is ChecksumRaw or ChecksumNormalized
+	checksumMode string
+	// errorHints attaches RecoveryHint suggestions to MigrationError.
+	errorHints bool
+	// metadataPolicy lists the ownership fields migrations must declare
+	metadataPolicy MetadataPolicy
+	// config is the configuration given by WithConfig; its database is
+	// connected once all options are applied
+	config *MigrateConfig
+	// offline keeps the manager from connecting to the configured database
+	offline bool
+	// historyFallback is HistoryFallbackFail or HistoryFallbackFile
+	historyFallback string
+	// historyFile is the file history, see WithHistoryFile
+	historyFile string
+	// historyMirror is empty or HistoryMirrorFile, see WithHistoryMirror
+	historyMirror string
+	// identifierPrefix prefixes the tables of migrations and seeds, see
+	// WithIdentifierPrefix
+	identifierPrefix string
+	// strictIdentifiers makes reserved words used as identifiers plan
+	// errors instead of warnings
+	strictIdentifiers bool
+	// maxIdentifierLen and customIdentifierRules adjust the identifier rules
+	// of the dialect, see identifierRules.
+	maxIdentifierLen      int
+	customIdentifierRules *IdentifierRules
+	// notNullStrategy handles NOT NULL fields without a default added to
+	// existing tables, see NotNullBackfill.
+	notNullStrategy string
+	// naming is the naming convention of generated index and constraint
+	// names, see WithNamingConvention
+	naming NamingConvention
+	// eventSinks receive the events of WithEventSink; events tracks the
+	// migration their statements belong to
+	eventSinks []EventSink
+	events     eventScope
 	// assets holds an optional embedded filesystem (using //go:embed from the
 	// application that embeds migrations/seeds/templates). When set, file
 	// reads and directory walks will prefer this FS over the OS filesystem.
 	assets fs.FS
 
+	// parse caches are keyed by file checksum so edited files are re-parsed
+	// and identical content is only parsed once per process
 	parseCacheMu sync.RWMutex
 	migrationBCL map[string]cachedMigrationsBCL
 	seedBCL      map[string]cachedSeedsBCL
+	generatedSQL map[string][]Statement
 }
 
 type cachedMigrationsBCL struct {
 	data       []byte
 	checksum   string
 	migrations []Migration
+	// written holds the migrations before the identifier prefix is applied,
+	// for normalized checksums
+	written []Migration
 }
 
 type cachedSeedsBCL struct {
@@ -138,37 +249,102 @@ func WithConfig(config *MigrateConfig) ManagerOption {
 		if config.Database.Driver != "" {
 			if drv, err := NormalizeDriver(config.Database.Driver); err == nil {
 				normalizedDriver = drv
-			} else {
-				logger.Error().Err(err).Msgf("Invalid database driver in config: %s", config.Database.Driver)
 			}
 		}
 
 		// Apply configuration settings
 		m.migrationDir = config.Migration.Directory
 		m.seedDir = config.Seed.Directory
+		m.seedKey = config.Seed.EncryptionKey
+		m.seedLookupTables = config.Seed.LookupTables
 		m.dialect = normalizedDriver
 		m.Verbose = config.Logging.Verbose
-
-		// Set up database driver if configuration is complete
-		if normalizedDriver != "" && config.Database.Database != "" {
-			dsn := config.GetDSN()
-			if dsn != "" {
-				driver, err := NewDriver(normalizedDriver, dsn)
-				if err == nil {
-					m.dbDriver = driver
-
-					// Set up history driver
-					historyDriver, err := NewHistoryDriver("db", normalizedDriver, dsn, config.Migration.TableName)
-					if err == nil {
-						m.historyDriver = historyDriver
-					} else {
-						logger.Error().Err(err).Msg("Failed to initialize history driver from config")
-					}
-				} else {
-					logger.Error().Err(err).Msg("Failed to initialize database driver from config")
-				}
+		if err := SetLogLevel(config.Logging.Level); err != nil {
+			logger.Warn().Msg(err.Error())
+		}
+		m.environment = config.Environment
+		m.sessionSetup = config.Database.Session
+		m.largeTableRows = config.Validation.LargeTableRows
+		m.strictIdentifiers = config.Validation.StrictMode
+		m.maxIdentifierLen = config.Validation.MaxIdentifierLen
+		m.customIdentifierRules = config.Validation.Identifiers
+		m.checksumMode = config.Migration.Checksum
+		if config.Migration.HistoryFallback != "" {
+			m.historyFallback = config.Migration.HistoryFallback
+		}
+		m.historyFile = config.Migration.HistoryFile
+		m.historyMirror = config.Migration.HistoryMirror
+		m.identifierPrefix = config.Migration.IdentifierPrefix
+		m.notNullStrategy = config.Migration.NotNullStrategy
+		m.naming = config.Migration.Naming
+		m.errorHints = config.Logging.ErrorHints
+		m.metadataPolicy = MetadataPolicy{
+			RequireAuthor: config.Validation.RequireAuthor,
+			RequireTicket: config.Validation.RequireTicket,
+			RequireReview: config.Validation.RequireReview,
+		}
+		m.auditLog = config.Logging.AuditLog
+		if config.Backup.Enabled {
+			if dumper, err := NewCommandDumper(config.Database, config.Backup.Command); err == nil {
+				m.backupDir = config.Backup.Directory
+				m.dumper = dumper
+			} else {
+				logger.Error().Err(err).Msg("Failed to configure table backups")
 			}
 		}
+		m.snapshotDir = config.Snapshot.Directory
+		if driver, _ := NormalizeDriver(config.Database.Driver); driver != DialectSQLite {
+			if snapshotter, err := NewCommandSnapshotter(config.Database, config.Snapshot); err == nil {
+				m.snapshotter = snapshotter
+			}
+		}
+		if config.Logging.Redact != nil {
+			m.redactor = NewRedactor(config.Logging.Redact...)
+		}
+
+		// The database is connected once all options are applied
+		m.config = config
+	}
+}
+
+// WithRedaction sets the column name patterns whose bound values are masked in
+// verbose statement logs. Without it DefaultRedactPatterns apply.
+func WithRedaction(patterns ...string) ManagerOption {
+	return func(m *Manager) {
+		m.redactor = NewRedactor(patterns...)
+	}
+}
+
+// WithSessionSetup sets statements the database driver runs on every
+// connection before applying migrations or seeds, such as
+// "SET lock_timeout = '5s'", "SET search_path = app" or "SET sql_mode = ...".
+func WithSessionSetup(statements ...string) ManagerOption {
+	return func(m *Manager) {
+		m.sessionSetup = statements
+	}
+}
+
+// WithJobs sets how many migration files are read, parsed and checksummed
+// concurrently when listing migrations. Values below one use one per CPU.
+func WithJobs(n int) ManagerOption {
+	return func(m *Manager) {
+		m.jobs = n
+	}
+}
+
+// WithSeedStreaming configures how CSV and large raw SQL seed files are
+// streamed: batch size per commit, resuming and forced streaming.
+func WithSeedStreaming(opts SeedStreamOptions) ManagerOption {
+	return func(m *Manager) {
+		m.seedStream = opts
+	}
+}
+
+// WithProtectedEnvironment marks the target environment as protected so that
+// rollback and reset commands require --yes-production or a typed confirmation.
+func WithProtectedEnvironment(name string) ManagerOption {
+	return func(m *Manager) {
+		m.environment = EnvironmentConfig{Name: name, Protected: true}
 	}
 }
 
@@ -189,31 +365,137 @@ func WithClient(client contracts.Cli) ManagerOption {
 
 func defaultManager() *Manager {
 	return &Manager{
-		migrationDir:  "migrations",
-		seedDir:       "migrations/seeds",
-		dialect:       "postgres",
-		historyDriver: NewFileHistoryDriver("migration_history.txt"),
+		migrationDir:    "migrations",
+		seedDir:         "migrations/seeds",
+		dialect:         "postgres",
+		largeTableRows:  DefaultLargeTableRows,
+		checksumMode:    ChecksumRaw,
+		historyFallback: HistoryFallbackFail,
+		errorHints:      true,
+		snapshotDir:     "snapshots",
 	}
 }
 
+// NewManager creates a manager from opts. Errors are logged and the manager
+// continues without what failed: a database configured by WithConfig that
+// cannot be connected leaves the history to the history fallback policy, and
+// missing migration or seed directories are reported when used. Use
+// NewManagerE to handle the errors instead.
 func NewManager(opts ...ManagerOption) *Manager {
+	m, err := NewManagerE(opts...)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to set up the migration manager")
+	}
+	return m
+}
+
+// NewManagerE is NewManager returning its errors: connecting to the database
+// configured by WithConfig, and creating the migration and seed directories.
+// The manager is returned even then, set up as NewManager would.
+func NewManagerE(opts ...ManagerOption) (*Manager, error) {
 	m := defaultManager()
 	for _, opt := range opts {
 		opt(m)
 	}
+	return m, errors.Join(m.connect(), m.prepare())
+}
+
+// connect opens the database and history drivers of the configuration given
+// by WithConfig that were not supplied by other options, unless the manager
+// is offline. When connecting fails the history fallback policy applies. A
+// manager without a configured database keeps its history in the file of
+// WithHistoryFile.
+func (m *Manager) connect() error {
+	var err error
+	if m.config != nil && !m.offline {
+		err = m.connectConfig()
+	}
+	if err != nil && m.historyFallback == HistoryFallbackFile {
+		err = m.fallBackToFileHistory(err)
+	}
+	if m.historyDriver == nil {
+		switch {
+		case m.offline:
+			m.historyDriver = unavailableHistoryDriver{err: ErrOffline}
+		case err != nil:
+			m.historyDriver = unavailableHistoryDriver{err: historyUnavailableError(err)}
+		default:
+			path, pathErr := m.fileHistoryPath()
+			if pathErr != nil {
+				m.historyDriver = unavailableHistoryDriver{err: pathErr}
+				return pathErr
+			}
+			m.historyDriver = NewFileHistoryDriver(path)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return m.mirrorHistory()
+}
+
+func (m *Manager) connectConfig() error {
+	config := *m.config
+	dialect, err := NormalizeDriver(config.Database.Driver)
+	if err != nil {
+		return fmt.Errorf("invalid database driver: %w", err)
+	}
+	if config.Database.Database == "" {
+		return nil
+	}
+	config.Database.Driver = dialect
+	dsn := config.GetDSN()
+	if m.dbDriver == nil {
+		driver, err := NewDriver(dialect, dsn)
+		if err != nil {
+			return fmt.Errorf("failed to initialize database driver: %w", err)
+		}
+		m.dbDriver = driver
+	}
+	if m.historyDriver == nil {
+		historyDriver, err := NewHistoryDriver("db", dialect, dsn, config.Migration.TableName)
+		if err != nil {
+			return fmt.Errorf("failed to initialize history driver: %w", err)
+		}
+		m.historyDriver = historyDriver
+	}
+	return nil
+}
+
+// prepare applies the session setup to the database driver and creates the
+// migration and seed directories once options have been applied.
+func (m *Manager) prepare() error {
+	if err := validIdentifierPrefix(m.identifierPrefix); err != nil {
+		return err
+	}
+	if err := validNotNullStrategy(m.notNullStrategy); err != nil {
+		return err
+	}
+	if err := m.naming.Validate(); err != nil {
+		return err
+	}
+	if len(m.sessionSetup) > 0 {
+		if driver, ok := m.dbDriver.(sessionConfigurer); ok {
+			driver.SetSessionSetup(m.sessionSetup...)
+		} else {
+			logger.Warn().Msg("Database driver does not support session setup statements; ignoring them")
+		}
+	}
+	m.observeStatements()
 	if err := os.MkdirAll(m.migrationDir, fs.ModePerm); err != nil {
-		logger.Fatal().Msgf("Failed to create migration directory: %v", err)
+		return fmt.Errorf("failed to create migration directory: %w", err)
 	}
 	if err := os.MkdirAll(m.seedDir, fs.ModePerm); err != nil {
-		logger.Fatal().Msgf("Failed to create migration directory: %v", err)
+		return fmt.Errorf("failed to create seed directory: %w", err)
 	}
-	return m
+	return nil
 }
 
 func GetCommands(m *Manager) []contracts.Command {
 	return []contracts.Command{
 		&MakeMigrationCommand{Driver: m},
 		&MigrateCommand{Driver: m},
+		&UpCommand{MigrateCommand{Driver: m}},
 		&RollbackCommand{Driver: m},
 		&ResetCommand{Driver: m},
 		&ResetDatabaseCommand{Driver: m},
@@ -225,12 +507,82 @@ func GetCommands(m *Manager) []contracts.Command {
 		&ConfigInitCommand{Driver: m},
 		&ConfigValidateCommand{Driver: m},
 		&ConfigShowCommand{Driver: m},
+		&ConfigGetCommand{Driver: m},
+		&ConfigSetCommand{Driver: m},
 		&StatusCommand{Driver: m},
+		&CompletionCommand{Driver: m},
+		&DoctorCommand{Driver: m},
+		&ServeHealthCommand{Driver: m},
+		&LockStatusCommand{Driver: m},
+		&SQLCommand{Driver: m},
+		&ChecksumUpgradeCommand{Driver: m},
+		&HistoryPruneCommand{Driver: m},
+		&HistoryExportCommand{Driver: m},
+		&HistoryImportCommand{Driver: m},
+		&RenameMigrationCommand{Driver: m},
+		&TestReversibilityCommand{Driver: m},
+		&ParseCommand{Driver: m},
+		&TUICommand{Driver: m},
+		&PlanCommand{Driver: m},
+		&SeedEncryptCommand{Driver: m},
+		&SeedDecryptCommand{Driver: m},
+		&BundleCommand{Driver: m},
+		&ApplyBundleCommand{MigrateCommand{Driver: m}},
+		&UpgradeFormatCommand{Driver: m},
+		&ProfileCommand{Driver: m},
+		&BenchDataCommand{Driver: m},
+		&SnapshotCommand{Driver: m},
+		&SchemaDiffCommand{Driver: m},
+		&SchemaExportCommand{Driver: m},
+		&GenerateModelsCommand{Driver: m},
+		&GenerateTypesCommand{Driver: m},
+		&SchemaDocsCommand{Driver: m},
+		&GrepCommand{Driver: m},
 	}
 }
 
-// NewManagerFromConfig creates a new manager from configuration file
+// NewManagerFromConfig creates a new manager from configuration file. It
+// fails when the configured database cannot be connected, unless opts
+// include WithOffline.
 func NewManagerFromConfig(configPath string, opts ...ManagerOption) (*Manager, error) {
+	allOpts, err := configFileOptions(configPath)
+	if err != nil {
+		return nil, err
+	}
+	allOpts = append(allOpts, opts...)
+
+	manager, err := NewManagerE(allOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return manager, nil
+}
+
+// UseConfigFile reconfigures the manager from the configuration file at
+// configPath as NewManagerFromConfig would, connecting to its database.
+// Settings the file does not cover, such as embedded files and custom
+// commands, are kept. It does nothing when configPath is already loaded.
+func (d *Manager) UseConfigFile(configPath string) error {
+	if configPath == d.configPath {
+		return nil
+	}
+	opts, err := configFileOptions(configPath)
+	if err != nil {
+		return err
+	}
+	d.dbDriver, d.historyDriver = nil, nil
+	for _, opt := range opts {
+		opt(d)
+	}
+	if err := d.connect(); err != nil {
+		return err
+	}
+	return d.prepare()
+}
+
+// configFileOptions loads the configuration file at configPath and returns
+// the options that configure a manager from it.
+func configFileOptions(configPath string) ([]ManagerOption, error) {
 	// Load configuration
 	config, err := LoadConfig(configPath)
 	if err != nil {
@@ -250,32 +602,14 @@ func NewManagerFromConfig(configPath string, opts ...ManagerOption) (*Manager, e
 	}
 	config.Database.Driver = normalizedDriver
 
-	dsn := config.GetDSN()
-	if dsn == "" {
+	if config.GetDSN() == "" {
 		return nil, fmt.Errorf("failed to build DSN for driver %s", config.Database.Driver)
 	}
 
-	driver, err := NewDriver(config.Database.Driver, dsn)
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize database driver: %w", err)
-	}
-	historyDriver, err := NewHistoryDriver("db", config.Database.Driver, dsn, config.Migration.TableName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize history driver: %w", err)
-	}
-
-	// Create manager with configuration
-	allOpts := []ManagerOption{
+	return []ManagerOption{
 		WithConfig(config),
 		WithConfigPath(configPath),
-		WithDriver(driver),
-		WithHistoryDriver(historyDriver),
-		WithDialect(config.Database.Driver),
-	}
-	allOpts = append(allOpts, opts...)
-
-	manager := NewManager(allOpts...)
-	return manager, nil
+	}, nil
 }
 
 func (d *Manager) Run(clients ...contracts.Cli) {
@@ -290,9 +624,18 @@ func (d *Manager) Run(clients ...contracts.Cli) {
 		app := cli.New()
 		client = app.Instance.Client()
 	}
+	client.Register(d.registeredCommands())
+	if err := client.Run(os.Args, true); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(ExitCode(err))
+	}
+}
+
+// registeredCommands returns the built-in and custom commands as registered
+// with the CLI client.
+func (d *Manager) registeredCommands() []contracts.Command {
 	cmds := append(GetCommands(d), d.command...)
-	client.Register(cmds)
-	client.Run(os.Args, true)
+	return withExitCodes(withConfigFlag(d, cmds))
 }
 
 func (d *Manager) SetDialect(dialect string) {
@@ -316,6 +659,36 @@ func (d *Manager) ConfigPath() string {
 	return d.configPath
 }
 
+// Environment returns the environment settings the manager was configured with
+func (d *Manager) Environment() EnvironmentConfig {
+	return d.environment
+}
+
+// PendingMigrations returns the names of migrations that have no history
+// record, sorted by name. It does not modify the database.
+func (d *Manager) PendingMigrations() ([]string, error) {
+	migrationMap, err := d.ListMigrationMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migration files: %w", err)
+	}
+	histories, err := d.historyDriver.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migration history: %w", err)
+	}
+	applied := make(map[string]bool, len(histories))
+	for _, h := range histories {
+		applied[h.Name] = true
+	}
+	var pending []string
+	for name := range migrationMap {
+		if !applied[name] {
+			pending = append(pending, name)
+		}
+	}
+	sort.Strings(pending)
+	return pending, nil
+}
+
 func (d *Manager) ValidateHistoryStorage() error {
 	return d.historyDriver.ValidateStorage()
 }
@@ -329,70 +702,101 @@ func (d *Manager) readFile(path string) ([]byte, error) {
 	return os.ReadFile(path)
 }
 
+// readMigrationsBCL reads and parses the migration file at path. The file is
+// always read so edits are noticed, but parsing is skipped when a file with
+// the same checksum has already been parsed.
 func (d *Manager) readMigrationsBCL(path string) (cachedMigrationsBCL, error) {
-	d.parseCacheMu.RLock()
-	if d.migrationBCL != nil {
-		if cached, ok := d.migrationBCL[path]; ok {
-			d.parseCacheMu.RUnlock()
-			return cached, nil
-		}
-	}
-	d.parseCacheMu.RUnlock()
-
 	data, err := d.readFile(path)
 	if err != nil {
 		return cachedMigrationsBCL{}, err
 	}
+	checksum := computeChecksum(data)
+	d.parseCacheMu.RLock()
+	cached, ok := d.migrationBCL[checksum]
+	d.parseCacheMu.RUnlock()
+	if ok {
+		return cached, nil
+	}
+
 	migrations, err := ParseMigrationsBCL(data)
 	if err != nil {
-		return cachedMigrationsBCL{}, err
+		return cachedMigrationsBCL{}, withParseErrorFile(err, path)
 	}
-	cached := cachedMigrationsBCL{
+	cached = cachedMigrationsBCL{
 		data:       data,
-		checksum:   computeChecksum(data),
-		migrations: migrations,
+		checksum:   checksum,
+		migrations: d.backfilledMigrations(d.prefixedMigrations(d.namedMigrations(migrations))),
+		written:    migrations,
 	}
 	d.parseCacheMu.Lock()
 	if d.migrationBCL == nil {
 		d.migrationBCL = make(map[string]cachedMigrationsBCL)
 	}
-	d.migrationBCL[path] = cached
+	d.migrationBCL[checksum] = cached
 	d.parseCacheMu.Unlock()
 	return cached, nil
 }
 
+// readSeedsBCL is the seed file counterpart of readMigrationsBCL.
 func (d *Manager) readSeedsBCL(path string) (cachedSeedsBCL, error) {
-	d.parseCacheMu.RLock()
-	if d.seedBCL != nil {
-		if cached, ok := d.seedBCL[path]; ok {
-			d.parseCacheMu.RUnlock()
-			return cached, nil
-		}
-	}
-	d.parseCacheMu.RUnlock()
-
-	data, err := d.readFile(path)
+	data, err := d.readSeedFile(path)
 	if err != nil {
 		return cachedSeedsBCL{}, err
 	}
+	checksum := computeChecksum(data)
+	d.parseCacheMu.RLock()
+	cached, ok := d.seedBCL[checksum]
+	d.parseCacheMu.RUnlock()
+	if ok {
+		return cached, nil
+	}
+
 	seeds, err := ParseSeedsBCL(data)
 	if err != nil {
-		return cachedSeedsBCL{}, err
+		return cachedSeedsBCL{}, withParseErrorFile(err, path)
 	}
-	cached := cachedSeedsBCL{
+	cached = cachedSeedsBCL{
 		data:     data,
-		checksum: computeChecksum(data),
-		seeds:    seeds,
+		checksum: checksum,
+		seeds:    d.prefixedSeeds(seeds),
 	}
 	d.parseCacheMu.Lock()
 	if d.seedBCL == nil {
 		d.seedBCL = make(map[string]cachedSeedsBCL)
 	}
-	d.seedBCL[path] = cached
+	d.seedBCL[checksum] = cached
 	d.parseCacheMu.Unlock()
 	return cached, nil
 }
 
+// migrationSQL returns the generated SQL for migration in the given direction,
+// reusing earlier output for the same file checksum. SQLite is never cached:
+// its ALTER TABLE emulation depends on table schemas recorded while generating
+// earlier migrations.
+func (d *Manager) migrationSQL(checksum string, migration Migration, dialect string, up bool) ([]Statement, error) {
+	if checksum == "" || dialect == DialectSQLite {
+		return migration.Plan(dialect, up)
+	}
+	key := fmt.Sprintf("%s|%s|%s|%t", checksum, migration.Name, dialect, up)
+	d.parseCacheMu.RLock()
+	queries, ok := d.generatedSQL[key]
+	d.parseCacheMu.RUnlock()
+	if ok {
+		return queries, nil
+	}
+	queries, err := migration.Plan(dialect, up)
+	if err != nil {
+		return nil, err
+	}
+	d.parseCacheMu.Lock()
+	if d.generatedSQL == nil {
+		d.generatedSQL = make(map[string][]Statement)
+	}
+	d.generatedSQL[key] = queries
+	d.parseCacheMu.Unlock()
+	return queries, nil
+}
+
 func findMigrationByName(migrations []Migration, name string) (Migration, bool) {
 	for _, migration := range migrations {
 		if migration.Name == name {
@@ -404,6 +808,8 @@ func findMigrationByName(migrations []Migration, name string) (Migration, bool)
 
 // ListMigrationMap returns a map of migration name -> path. When using an
 // embedded filesystem the returned paths are the paths inside the embedded FS.
+// BCL files are parsed concurrently (see WithJobs) and then registered in walk
+// order so duplicate detection stays deterministic.
 func (d *Manager) ListMigrationMap() (map[string]string, error) {
 	migrationMap := make(map[string]string)
 	addMigration := func(name, path string) error {
@@ -413,84 +819,91 @@ func (d *Manager) ListMigrationMap() (map[string]string, error) {
 		migrationMap[name] = path
 		return nil
 	}
+	paths, bclPaths, err := d.migrationFilePaths()
+	if err != nil {
+		return migrationMap, err
+	}
+	preloaded := d.preloadMigrations(bclPaths)
+	for _, path := range paths {
+		ext := strings.ToLower(filepath.Ext(path))
+		if ext == ".sql" {
+			if err := addMigration(strings.TrimSuffix(filepath.Base(path), ext), path); err != nil {
+				return migrationMap, err
+			}
+			continue
+		}
+		result, ok := preloaded[path]
+		if !ok {
+			result.cached, result.err = d.readMigrationsBCL(path)
+		}
+		cached, err := result.cached, result.err
+		if err != nil {
+			return migrationMap, fmt.Errorf("failed to parse migration file %s: %w", path, err)
+		}
+		if len(cached.migrations) == 0 {
+			return migrationMap, fmt.Errorf("migration file %s contains no Migration blocks", path)
+		}
+		for _, migration := range cached.migrations {
+			if err := addMigration(migration.Name, path); err != nil {
+				return migrationMap, err
+			}
+		}
+	}
+	return migrationMap, nil
+}
+
+// migrationFilePaths walks the migration directory, skipping the seed
+// directory, and returns the .bcl and .sql files in walk order along with
+// the .bcl files alone.
+func (d *Manager) migrationFilePaths() (paths, bclPaths []string, err error) {
 	seedDir := d.SeedDir()
+	collect := func(path, name string) {
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".bcl":
+			paths = append(paths, path)
+			bclPaths = append(bclPaths, path)
+		case ".sql":
+			paths = append(paths, path)
+		}
+	}
 	if d.assets != nil {
-		err := fs.WalkDir(d.assets, d.migrationDir, func(p string, de fs.DirEntry, err error) error {
+		err = fs.WalkDir(d.assets, d.migrationDir, func(p string, de fs.DirEntry, err error) error {
 			if err != nil {
 				return err
 			}
 			if de.IsDir() {
 				return nil
 			}
-			ext := strings.ToLower(filepath.Ext(de.Name()))
 			// Skip seeds
 			if seedDir != "" && strings.HasPrefix(p, seedDir) {
 				return nil
 			}
-			switch ext {
-			case ".bcl":
-				cached, err := d.readMigrationsBCL(p)
-				if err != nil {
-					return fmt.Errorf("failed to parse migration file %s: %w", p, err)
-				}
-				if len(cached.migrations) == 0 {
-					return fmt.Errorf("migration file %s contains no Migration blocks", p)
-				}
-				for _, migration := range cached.migrations {
-					if err := addMigration(migration.Name, p); err != nil {
-						return err
-					}
-				}
-			case ".sql":
-				name := strings.TrimSuffix(de.Name(), ext)
-				if err := addMigration(name, p); err != nil {
-					return err
-				}
-			}
+			collect(p, de.Name())
 			return nil
 		})
-		return migrationMap, err
-	}
-	// Fallback to OS filesystem walking
-	err := filepath.Walk(d.migrationDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if seedDir != "" && strings.HasPrefix(path, seedDir) {
-			if info.IsDir() {
-				return filepath.SkipDir
+	} else {
+		// Fallback to OS filesystem walking
+		err = filepath.Walk(d.migrationDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
 			}
-			return nil
-		}
-		if !info.IsDir() {
-			ext := strings.ToLower(filepath.Ext(info.Name()))
-			switch ext {
-			case ".bcl":
-				cached, err := d.readMigrationsBCL(path)
-				if err != nil {
-					return fmt.Errorf("failed to parse migration file %s: %w", path, err)
-				}
-				if len(cached.migrations) == 0 {
-					return fmt.Errorf("migration file %s contains no Migration blocks", path)
-				}
-				for _, migration := range cached.migrations {
-					if err := addMigration(migration.Name, path); err != nil {
-						return err
-					}
-				}
-			case ".sql":
-				name := strings.TrimSuffix(info.Name(), ext)
-				if err := addMigration(name, path); err != nil {
-					return err
+			if seedDir != "" && strings.HasPrefix(path, seedDir) {
+				if info.IsDir() {
+					return filepath.SkipDir
 				}
+				return nil
 			}
-		}
-		return nil
-	})
-	return migrationMap, err
+			if !info.IsDir() {
+				collect(path, info.Name())
+			}
+			return nil
+		})
+	}
+	return paths, bclPaths, err
 }
 
-// ListSeedFiles returns seed files (.bcl and optionally .sql) inside the
+// ListSeedFiles returns seed files (.bcl and optionally .sql and .csv, each
+// possibly encrypted with an EncryptedSeedExt suffix) inside the
 // configured seed directory. The returned paths are either OS paths or paths
 // inside the embedded FS depending on configuration.
 func (d *Manager) ListSeedFiles(includeRaw bool) ([]string, error) {
@@ -503,11 +916,11 @@ func (d *Manager) ListSeedFiles(includeRaw bool) ([]string, error) {
 			if de.IsDir() {
 				return nil
 			}
-			ext := strings.ToLower(filepath.Ext(de.Name()))
+			ext := seedFileExt(de.Name())
 			switch ext {
 			case ".bcl":
 				files = append(files, p)
-			case ".sql":
+			case ".sql", ".csv":
 				if includeRaw {
 					files = append(files, p)
 				}
@@ -524,11 +937,11 @@ func (d *Manager) ListSeedFiles(includeRaw bool) ([]string, error) {
 		if file.IsDir() {
 			continue
 		}
-		ext := strings.ToLower(filepath.Ext(file.Name()))
+		ext := seedFileExt(file.Name())
 		switch ext {
 		case ".bcl":
 			files = append(files, filepath.Join(d.SeedDir(), file.Name()))
-		case ".sql":
+		case ".sql", ".csv":
 			if includeRaw {
 				files = append(files, filepath.Join(d.SeedDir(), file.Name()))
 			}
@@ -542,7 +955,7 @@ func (d *Manager) ApplyMigration(m Migration) error {
 	if err := requireFields(m.Name); err != nil {
 		return fmt.Errorf("ApplyMigration: invalid migration name: %w", err)
 	}
-	if m.Disable {
+	if m.isDisabled() {
 		logger.Warn().Msgf("Migration '%s' is disabled and will not be applied.", m.Name)
 		return nil
 	}
@@ -562,32 +975,49 @@ func (d *Manager) ApplyMigration(m Migration) error {
 	}
 
 	checksum := cached.checksum
+	migration, ok := findMigrationByName(cached.migrations, m.Name)
+	if !ok {
+		return fmt.Errorf("migration %q not found in BCL document", m.Name)
+	}
+	if err := d.metadataPolicy.Check(migration); err != nil {
+		return err
+	}
+	if warning := dslVersionWarning(migration); warning != "" {
+		logger.Warn().Msg(warning)
+	}
+	if stem := strings.TrimSuffix(filepath.Base(migrationPath), filepath.Ext(migrationPath)); len(cached.migrations) == 1 && stem != m.Name {
+		logger.Warn().Msgf("Migration %s is declared in %s; history records the declared name. Run migration:rename %s %s to align them", m.Name, filepath.Base(migrationPath), m.Name, stem)
+	}
+	normalized, err := normalizedMigrationChecksum(migration)
+	if err != nil {
+		return err
+	}
 	histories, err := d.historyDriver.Load()
 	if err != nil {
 		return fmt.Errorf("failed to load migration history: %w", err)
 	}
 
 	// Check if migration already applied
+	reapply := false
 	for _, h := range histories {
 		if h.Name == m.Name {
-			if h.Checksum == checksum {
-				if d.Verbose {
-					logger.Info().Msgf("Migration '%s' already applied, skipping", m.Name)
-				}
+			if checksumMatches(h.Checksum, checksum, normalized) {
+				d.detailLog().Msgf("Migration '%s' already applied, skipping", m.Name)
 				return nil
 			}
+			if migration.Repeatable {
+				logger.Info().Msgf("Repeatable migration '%s' changed, re-applying", m.Name)
+				reapply = true
+				break
+			}
 			if d.Force {
 				logger.Warn().Msgf("Checksum mismatch for '%s', force-applying", m.Name)
 				d.historyDriver.Rollback(h)
 				break
 			}
-			return fmt.Errorf("migration '%s' has been modified after being applied (checksum mismatch)", m.Name)
+			return fmt.Errorf("migration '%s' has been modified after being applied: %w", m.Name, ErrChecksumMismatch)
 		}
 	}
-	migration, ok := findMigrationByName(cached.migrations, m.Name)
-	if !ok {
-		return fmt.Errorf("migration %q not found in BCL document", m.Name)
-	}
 	if err := requireFields(migration.Name); err != nil {
 		return fmt.Errorf("ApplyMigration: %w", err)
 	}
@@ -608,16 +1038,24 @@ func (d *Manager) ApplyMigration(m Migration) error {
 			return fmt.Errorf("migration %s has Driver set but no Connection", migration.Name)
 		}
 	}
-	queries, err := migration.ToSQL(dialect, true)
+	queries, err := d.migrationSQL(checksum, migration, dialect, true)
 	if err != nil {
 		return fmt.Errorf("failed to generate SQL: %w", err)
 	}
-	if d.Verbose {
-		logger.Info().Msgf("Migration '%s' details:", m.Name)
-		for _, q := range queries {
-			logger.Info().Msg(q)
+	if dialect == DialectSQLite {
+		if queries, err = d.preserveSQLiteObjects(dbDriver, migration.Up, queries); err != nil {
+			return fmt.Errorf("migration %s: %w", m.Name, err)
 		}
 	}
+	cascade, err := d.checkDependencies(migration, dialect, true)
+	if err != nil {
+		return fmt.Errorf("migration %s: %w", m.Name, err)
+	}
+	queries = append(cascade, queries...)
+	d.detailLog().Msgf("Migration '%s' details:", m.Name)
+	for _, q := range queries {
+		d.detailLog().Msg(RedactPasswords(q.SQL))
+	}
 	if dbDriver == nil {
 		return fmt.Errorf("no database driver configured for migration '%s'", m.Name)
 	}
@@ -626,26 +1064,58 @@ func (d *Manager) ApplyMigration(m Migration) error {
 		return nil
 	}
 	for _, val := range migration.Validate {
-		if err := runPreUpChecks(val.PreUpChecks); err != nil {
+		if err := runPreUpChecks(dbDriver, val.PreUpChecks); err != nil {
 			return fmt.Errorf("pre-up validation failed for migration %s: %w", migration.Name, err)
 		}
 	}
-	if err := dbDriver.ApplySQL(queries); err != nil {
-		return fmt.Errorf("failed to apply migration %s: %w", m.Name, err)
+	if err := d.backupTables(migration, true); err != nil {
+		return fmt.Errorf("failed to back up tables for migration %s: %w", m.Name, err)
+	}
+	finish := d.startMigrationEvents(m.Name)
+	err = dbDriver.ApplyBatch(queries)
+	finish(err)
+	if err != nil {
+		return d.newMigrationError("apply", m.Name, err)
 	}
 	for _, val := range migration.Validate {
-		if err := runPostUpChecks(val.PostUpChecks); err != nil {
+		if err := runPostUpChecks(dbDriver, val.PostUpChecks); err != nil {
 			return fmt.Errorf("post-up validation failed for migration %s: %w", migration.Name, err)
 		}
 	}
 	now := time.Now()
 	logger.Info().Msgf("Applied migration: %s at %v", m.Name, now.Format(time.DateTime))
+	downSQL := d.recordedDownSQL(checksum, migration, dialect)
+	if err := d.recordAudit(AuditEntry{
+		Time:       now.UTC(),
+		Event:      AuditEventApply,
+		Migration:  m.Name,
+		Direction:  "up",
+		Author:     migration.Author,
+		Ticket:     migration.Ticket,
+		ReviewedBy: migration.ReviewedBy,
+		DownSQL:    downSQL,
+	}); err != nil {
+		return err
+	}
 	history := MigrationHistory{
 		Name:        m.Name,
 		Version:     m.Version,
 		Description: m.Description,
-		Checksum:    checksum,
+		Checksum:    d.recordedChecksum(checksum, normalized),
 		AppliedAt:   now,
+		DSLVersion:  migration.AuthoredDSLVersion(),
+		ToolVersion: migration.ToolVersion,
+		DownSQL:     downSQL,
+	}
+	if reapply {
+		// Move the entry to the end so rollbacks follow the latest apply.
+		var kept []MigrationHistory
+		for _, h := range histories {
+			if h.Name != m.Name {
+				kept = append(kept, h)
+			}
+		}
+		return d.historyDriver.Rollback(append(kept, history)...)
 	}
 	return d.historyDriver.Save(history)
 }
@@ -696,8 +1166,18 @@ func (d *Manager) RollbackMigration(step int) error {
 		last := histories[len(histories)-1]
 		name := last.Name
 		path, ok := migrationMap[name]
+		if !ok || d.FromHistory {
+			rolledBack, err := d.rollbackFromHistory(last, !ok)
+			if err != nil {
+				return err
+			}
+			if rolledBack {
+				histories = histories[:len(histories)-1]
+				continue
+			}
+		}
 		if !ok {
-			logger.Warn().Msgf("Migration file for %s not found; removing history entry and continuing", name)
+			logger.Warn().Msgf("Migration file for %s not found and no Down SQL was stored; removing history entry and continuing", name)
 			histories = histories[:len(histories)-1]
 			continue
 		}
@@ -709,8 +1189,15 @@ func (d *Manager) RollbackMigration(step int) error {
 				histories = histories[:len(histories)-1]
 				continue
 			}
+			stored, err := d.modifiedRollbackSQL(last, path)
+			if err != nil {
+				return err
+			}
 			// Raw SQL rollback
 			_, down := parseSQLMigration(data)
+			if stored != nil {
+				down = strings.Join(stored, "\n")
+			}
 			if down == "" {
 				if !d.Force {
 					return fmt.Errorf("raw migration %s has no down SQL", name)
@@ -722,12 +1209,10 @@ func (d *Manager) RollbackMigration(step int) error {
 			if d.dbDriver == nil {
 				return fmt.Errorf("no database driver configured for rollback of %s", name)
 			}
-			if d.Verbose {
-				logger.Info().Msgf("Rollback raw SQL for '%s': %s", name, down)
-			}
+			d.detailLog().Msgf("Rollback raw SQL for '%s': %s", name, down)
 			if err := d.dbDriver.ApplySQL([]string{down}); err != nil {
 				if !d.Force {
-					return fmt.Errorf("failed to rollback raw migration %s: %w", name, err)
+					return d.newMigrationError("rollback", name, err)
 				}
 				logger.Warn().Msgf("Failed to rollback raw migration %s (continuing): %v", name, err)
 			} else {
@@ -753,7 +1238,7 @@ func (d *Manager) RollbackMigration(step int) error {
 			histories = histories[:len(histories)-1]
 			continue
 		}
-		if migration.Disable {
+		if migration.isDisabled() {
 			logger.Warn().Msgf("Migration '%s' is disabled, skipping rollback.", migration.Name)
 			// Still remove from history since user requested rollback
 			histories = histories[:len(histories)-1]
@@ -776,22 +1261,40 @@ func (d *Manager) RollbackMigration(step int) error {
 				return fmt.Errorf("migration %s has Driver set but no Connection", migration.Name)
 			}
 		}
-		downQueries, err := migration.ToSQL(dialect, false)
+		stored, err := d.modifiedRollbackSQL(last, path)
 		if err != nil {
-			return fmt.Errorf("failed to generate rollback SQL for migration %s: %w", name, err)
+			return err
+		}
+		downQueries := storedStatements(stored)
+		if stored == nil {
+			downQueries, err = d.migrationSQL(cached.checksum, migration, dialect, false)
+			if err != nil {
+				return fmt.Errorf("failed to generate rollback SQL for migration %s: %w", name, err)
+			}
 		}
 		if len(downQueries) == 0 {
 			return fmt.Errorf("no rollback SQL found for migration %s; aborting", name)
 		}
-		if d.Verbose {
-			logger.Info().Msgf("Rollback of migration '%s' details:", name)
-			for _, q := range downQueries {
-				logger.Info().Msg(q)
+		if dialect == DialectSQLite {
+			if downQueries, err = d.preserveSQLiteObjects(dbDriver, migration.Down, downQueries); err != nil {
+				return fmt.Errorf("failed to rollback migration %s: %w", name, err)
 			}
 		}
-		if err := dbDriver.ApplySQL(downQueries); err != nil {
+		cascade, err := d.checkDependencies(migration, dialect, false)
+		if err != nil {
+			return fmt.Errorf("failed to rollback migration %s: %w", name, err)
+		}
+		downQueries = append(cascade, downQueries...)
+		d.detailLog().Msgf("Rollback of migration '%s' details:", name)
+		for _, q := range downQueries {
+			d.detailLog().Msg(RedactPasswords(q.SQL))
+		}
+		if err := d.backupTables(migration, false); err != nil {
+			return fmt.Errorf("failed to back up tables for migration %s: %w", name, err)
+		}
+		if err := dbDriver.ApplyBatch(downQueries); err != nil {
 			if !d.Force {
-				return fmt.Errorf("failed to rollback migration %s: %w", name, err)
+				return d.newMigrationError("rollback", name, err)
 			}
 			logger.Warn().Msgf("Failed to rollback migration %s (continuing): %v", name, err)
 			histories = histories[:len(histories)-1]
@@ -834,8 +1337,18 @@ func (d *Manager) ResetMigrations() error {
 		last := histories[len(histories)-1]
 		name := last.Name
 		path, ok := migrationMap[name]
+		if !ok || d.FromHistory {
+			rolledBack, err := d.rollbackFromHistory(last, !ok)
+			if err != nil {
+				return err
+			}
+			if rolledBack {
+				histories = histories[:len(histories)-1]
+				continue
+			}
+		}
 		if !ok {
-			logger.Warn().Msgf("Migration file for %s not found; removing history entry and continuing", name)
+			logger.Warn().Msgf("Migration file for %s not found and no Down SQL was stored; removing history entry and continuing", name)
 			histories = histories[:len(histories)-1]
 			continue
 		}
@@ -847,7 +1360,14 @@ func (d *Manager) ResetMigrations() error {
 				histories = histories[:len(histories)-1]
 				continue
 			}
+			stored, err := d.modifiedRollbackSQL(last, path)
+			if err != nil {
+				return err
+			}
 			_, down := parseSQLMigration(data)
+			if stored != nil {
+				down = strings.Join(stored, "\n")
+			}
 			if down == "" {
 				if !d.Force {
 					return fmt.Errorf("raw migration %s has no down SQL", name)
@@ -859,12 +1379,10 @@ func (d *Manager) ResetMigrations() error {
 			if d.dbDriver == nil {
 				return fmt.Errorf("no database driver configured for rollback of %s", name)
 			}
-			if d.Verbose {
-				logger.Info().Msgf("Rollback raw SQL for '%s': %s", name, down)
-			}
+			d.detailLog().Msgf("Rollback raw SQL for '%s': %s", name, down)
 			if err := d.dbDriver.ApplySQL([]string{down}); err != nil {
 				if !d.Force {
-					return fmt.Errorf("failed to rollback raw migration %s: %w", name, err)
+					return d.newMigrationError("rollback", name, err)
 				}
 				logger.Warn().Msgf("Failed to rollback raw migration %s (continuing): %v", name, err)
 			} else {
@@ -890,7 +1408,7 @@ func (d *Manager) ResetMigrations() error {
 			histories = histories[:len(histories)-1]
 			continue
 		}
-		if migration.Disable {
+		if migration.isDisabled() {
 			logger.Warn().Msgf("Migration '%s' is disabled, skipping reset.", migration.Name)
 			// Still remove from history since user requested reset
 			histories = histories[:len(histories)-1]
@@ -913,22 +1431,40 @@ func (d *Manager) ResetMigrations() error {
 				return fmt.Errorf("migration %s has Driver set but no Connection", migration.Name)
 			}
 		}
-		downQueries, err := migration.ToSQL(dialect, false)
+		stored, err := d.modifiedRollbackSQL(last, path)
 		if err != nil {
-			return fmt.Errorf("failed to generate rollback SQL for migration %s: %w", name, err)
+			return err
+		}
+		downQueries := storedStatements(stored)
+		if stored == nil {
+			downQueries, err = d.migrationSQL(cached.checksum, migration, dialect, false)
+			if err != nil {
+				return fmt.Errorf("failed to generate rollback SQL for migration %s: %w", name, err)
+			}
 		}
 		if len(downQueries) == 0 {
 			return fmt.Errorf("no rollback SQL found for migration %s; aborting", name)
 		}
-		if d.Verbose {
-			logger.Info().Msgf("Rollback of migration '%s' details:", name)
-			for _, q := range downQueries {
-				logger.Info().Msg(q)
+		if dialect == DialectSQLite {
+			if downQueries, err = d.preserveSQLiteObjects(dbDriver, migration.Down, downQueries); err != nil {
+				return fmt.Errorf("failed to rollback migration %s: %w", name, err)
 			}
 		}
-		if err := dbDriver.ApplySQL(downQueries); err != nil {
+		cascade, err := d.checkDependencies(migration, dialect, false)
+		if err != nil {
+			return fmt.Errorf("failed to rollback migration %s: %w", name, err)
+		}
+		downQueries = append(cascade, downQueries...)
+		d.detailLog().Msgf("Rollback of migration '%s' details:", name)
+		for _, q := range downQueries {
+			d.detailLog().Msg(RedactPasswords(q.SQL))
+		}
+		if err := d.backupTables(migration, false); err != nil {
+			return fmt.Errorf("failed to back up tables for migration %s: %w", name, err)
+		}
+		if err := dbDriver.ApplyBatch(downQueries); err != nil {
 			if !d.Force {
-				return fmt.Errorf("failed to rollback migration %s: %w", name, err)
+				return d.newMigrationError("rollback", name, err)
 			}
 			histories = histories[:len(histories)-1]
 			logger.Warn().Msgf("Failed to rollback migration %s (continuing): %v", name, err)
@@ -973,8 +1509,13 @@ func (d *Manager) ValidateMigrations() error {
 			missing = append(missing, name)
 		}
 	}
+	if err := reportMigrationIssues(migrationIssues(migrationMap, histories)); err != nil {
+		return err
+	}
 	toApply := len(missing)
 	if toApply > 0 {
+		sort.Strings(missing)
+		d.warnLargeTables(migrationMap, missing)
 		logger.Info().Msgf("Migration initiated for: %v", toApply)
 		return nil
 	}
@@ -1308,6 +1849,7 @@ func (d *Manager) CreateMigrationFile(name string, raw bool) error {
 			template = defaultTemplate(name)
 		}
 	}
+	template = string(upgradeMigrationFormat([]byte(template)))
 	if err := os.WriteFile(filename, []byte(template), 0644); err != nil {
 		return fmt.Errorf("failed to create migration file: %w", err)
 	}
@@ -1405,7 +1947,7 @@ func (d *Manager) ApplySQLMigration(path string) error {
 	if err != nil {
 		return fmt.Errorf("failed to read migration file %s: %w", path, err)
 	}
-	checksum := computeChecksum(data)
+	checksum, normalized := computeChecksum(data), normalizedSQLChecksum(data)
 	histories, err := d.historyDriver.Load()
 	if err != nil {
 		return fmt.Errorf("failed to load migration history: %w", err)
@@ -1413,10 +1955,8 @@ func (d *Manager) ApplySQLMigration(path string) error {
 	// Check if already applied
 	for _, h := range histories {
 		if h.Name == name {
-			if h.Checksum == checksum {
-				if d.Verbose {
-					logger.Info().Msgf("Migration '%s' already applied, skipping", name)
-				}
+			if checksumMatches(h.Checksum, checksum, normalized) {
+				d.detailLog().Msgf("Migration '%s' already applied, skipping", name)
 				return nil
 			}
 			if d.Force {
@@ -1424,75 +1964,132 @@ func (d *Manager) ApplySQLMigration(path string) error {
 				d.historyDriver.Rollback(h)
 				break
 			}
-			return fmt.Errorf("migration '%s' has been modified after being applied (checksum mismatch)", name)
+			return fmt.Errorf("migration '%s' has been modified after being applied: %w", name, ErrChecksumMismatch)
 		}
 	}
-	up, _ := parseSQLMigration(data)
+	up, down := parseSQLMigration(data)
 	if up == "" {
 		return fmt.Errorf("no up SQL found in %s", path)
 	}
 	if d.dbDriver == nil {
 		return fmt.Errorf("no database driver configured for migration '%s'", name)
 	}
-	if d.Verbose {
-		logger.Info().Msgf("Applying raw SQL migration '%s' details:", name)
-		logger.Info().Msg(up)
-	}
-	if err := d.dbDriver.ApplySQL([]string{up}); err != nil {
-		return fmt.Errorf("failed to apply raw migration %s: %w", name, err)
+	d.detailLog().Msgf("Applying raw SQL migration '%s' details:", name)
+	d.detailLog().Msg(up)
+	finish := d.startMigrationEvents(name)
+	err = d.dbDriver.ApplySQL([]string{up})
+	finish(err)
+	if err != nil {
+		return d.newMigrationError("apply", name, err)
 	}
 	now := time.Now()
 	history := MigrationHistory{
 		Name:        name,
 		Version:     deriveVersionFromFilename(name),
 		Description: deriveDescriptionFromFilename(name),
-		Checksum:    checksum,
+		Checksum:    d.recordedChecksum(checksum, normalized),
 		AppliedAt:   now,
 	}
+	if down != "" {
+		history.DownSQL = []string{down}
+	}
 	return d.historyDriver.Save(history)
 }
 
-func acquireLock() error {
-	if _, err := os.Stat(lockFileName); err == nil {
-		return fmt.Errorf("migration lock already acquired")
-	}
-	f, err := os.Create(lockFileName)
-	if err != nil {
-		return fmt.Errorf("failed to create lock file: %w", err)
+func runPreUpChecks(driver IDatabaseDriver, checks []string) error {
+	for _, check := range checks {
+		logger.Printf("Executing PreUpCheck: %s", check)
+		if err := runValidationCheck(driver, check); err != nil {
+			return fmt.Errorf("PreUp check failed: %s: %w", check, err)
+		}
 	}
-	f.Close()
+	logger.Info().Msg("All PreUpChecks passed.")
 	return nil
 }
 
-func releaseLock() error {
-	if err := os.Remove(lockFileName); err != nil {
-		return fmt.Errorf("failed to remove lock file: %w", err)
+func runPostUpChecks(driver IDatabaseDriver, checks []string) error {
+	for _, check := range checks {
+		logger.Printf("Executing PostUpCheck: %s", check)
+		if err := runValidationCheck(driver, check); err != nil {
+			return fmt.Errorf("PostUp check failed: %s: %w", check, err)
+		}
 	}
+	logger.Info().Msg("All PostUpChecks passed.")
 	return nil
 }
 
-func runPreUpChecks(checks []string) error {
-	for _, check := range checks {
-		logger.Printf("Executing PreUpCheck: %s", check)
-
-		if strings.Contains(strings.ToLower(check), "fail") {
-			return fmt.Errorf("PreUp check failed: %s", check)
+// runValidationCheck runs a PreUpCheck or PostUpCheck query. The check fails
+// when the query errors, returns no row, or its first column is false.
+func runValidationCheck(driver IDatabaseDriver, check string) error {
+	var result any
+	if err := driver.QueryRow(context.Background(), &result, check); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("query returned no rows")
 		}
+		return err
+	}
+	if ok, isBool := result.(bool); isBool && !ok {
+		return fmt.Errorf("query returned false")
 	}
-	logger.Info().Msg("All PreUpChecks passed.")
 	return nil
 }
 
-func runPostUpChecks(checks []string) error {
-	for _, check := range checks {
-		logger.Printf("Executing PostUpCheck: %s", check)
+// Inspector returns an inspect.Inspector of the managed database, listing
+// its tables, columns, indexes and foreign keys.
+func (d *Manager) Inspector() (*inspect.Inspector, error) {
+	if d.dbDriver == nil {
+		return nil, fmt.Errorf("no database driver configured")
+	}
+	return inspect.New(d.dbDriver.DB().DB(), d.dialect)
+}
 
-		if strings.Contains(strings.ToLower(check), "fail") {
-			return fmt.Errorf("PostUp check failed: %s", check)
-		}
+// TableExists reports whether table exists in the managed database.
+func (d *Manager) TableExists(table string) (bool, error) {
+	if d.dbDriver == nil {
+		return false, fmt.Errorf("no database driver configured")
 	}
-	logger.Info().Msg("All PostUpChecks passed.")
-	return nil
+	dial, err := GetDialect(d.dialect)
+	if err != nil {
+		return false, err
+	}
+	var exists bool
+	if err := d.dbDriver.QueryRow(context.Background(), &exists, dial.TableExistsSQL(table)); err != nil {
+		return false, fmt.Errorf("failed to check table %s: %w", table, err)
+	}
+	return exists, nil
+}
+
+// ColumnExists reports whether table has column in the managed database.
+func (d *Manager) ColumnExists(table, column string) (bool, error) {
+	if d.dbDriver == nil {
+		return false, fmt.Errorf("no database driver configured")
+	}
+	dial, err := GetDialect(d.dialect)
+	if err != nil {
+		return false, err
+	}
+	var exists bool
+	if err := d.dbDriver.QueryRow(context.Background(), &exists, dial.ColumnExistsSQL(table, column)); err != nil {
+		return false, fmt.Errorf("failed to check column %s.%s: %w", table, column, err)
+	}
+	return exists, nil
+}
+
+// IndexExists reports whether table has the index named index in the managed
+// database.
+func (d *Manager) IndexExists(table, index string) (bool, error) {
+	if d.dbDriver == nil {
+		return false, fmt.Errorf("no database driver configured")
+	}
+	dial, err := GetDialect(d.dialect)
+	if err != nil {
+		return false, err
+	}
+	var exists bool
+	if err := d.dbDriver.QueryRow(context.Background(), &exists, dial.IndexExistsSQL(table, index)); err != nil {
+		return false, fmt.Errorf("failed to check index %s on %s: %w", index, table, err)
+	}
+	return exists, nil
 }
 
 func (d *Manager) RunSeeds(truncate bool, includeRaw bool, seedFiles ...string) error {
@@ -1505,133 +2102,250 @@ func (d *Manager) RunSeeds(truncate bool, includeRaw bool, seedFiles ...string)
 		return nil
 	}
 
-	for _, seedFile := range seedFiles {
-		if seedFile == "" {
-			logger.Warn().Msg("Empty seed file path, skipping")
-			continue
+	lookup := d.newSeedLookup(truncate)
+	summary := newSeedSummary(seedFiles)
+	defer d.collectSeedSummary(summary)()
+	// seeded lists the tables targeted by the files run, for the seed filter.
+	var seeded []string
+	if d.seedJobs > 1 {
+		tables, err := d.runSeedsParallel(truncate, includeRaw, lookup, seedFiles, summary)
+		if err != nil {
+			return err
 		}
+		seeded = tables
+	} else {
+		for _, seedFile := range seedFiles {
+			started := time.Now()
+			tables, err := d.runSeedFile(seedFile, truncate, includeRaw, lookup)
+			seeded = append(seeded, tables...)
+			summary.finish(seedFile, started, err)
+			if err != nil && !d.seedKeepGoing {
+				return err
+			}
+		}
+	}
+	if d.seedKeepGoing {
+		if err := summary.write(os.Stdout); err != nil {
+			return err
+		}
+		if err := summary.Err(); err != nil {
+			return err
+		}
+	}
+	return d.unseededTables(seeded)
+}
 
-		ext := strings.ToLower(filepath.Ext(seedFile))
-		switch ext {
-		case ".sql":
-			if !includeRaw {
-				logger.Info().Msgf("Skipping raw seed file (enable with --include-raw): %s", seedFile)
-				continue
+// runSeedFile runs one seed file and returns the tables it targets. Errors
+// are logged and only returned without Force.
+func (d *Manager) runSeedFile(seedFile string, truncate, includeRaw bool, lookup *seedLookup) ([]string, error) {
+	var seeded []string
+	if seedFile == "" {
+		logger.Warn().Msg("Empty seed file path, skipping")
+		return seeded, nil
+	}
+
+	ext := seedFileExt(seedFile)
+	switch ext {
+	case ".csv":
+		if !includeRaw {
+			logger.Info().Msgf("Skipping raw seed file (enable with --include-raw): %s", seedFile)
+			return seeded, nil
+		}
+		table := d.identifierPrefix + csvSeedTable(seedFile)
+		if !d.seedSelected(table) {
+			return seeded, nil
+		}
+		seeded = append(seeded, table)
+		if err := d.streamCSVSeed(seedFile, truncate); err != nil {
+			logger.Error().Msgf("Failed to apply CSV seed file '%s': %v", seedFile, err)
+			if !d.Force {
+				return seeded, fmt.Errorf("failed to apply CSV seed file %s: %w", seedFile, err)
 			}
-			data, err := d.readFile(seedFile)
-			if err != nil {
-				logger.Error().Msgf("Failed to read seed file '%s': %v", seedFile, err)
+			return seeded, nil
+		}
+	case ".sql":
+		if !includeRaw {
+			logger.Info().Msgf("Skipping raw seed file (enable with --include-raw): %s", seedFile)
+			return seeded, nil
+		}
+		if len(d.seedFilter.Tables) > 0 {
+			logger.Info().Msgf("Skipping raw seed file, its tables cannot be filtered: %s", seedFile)
+			return seeded, nil
+		}
+		if d.shouldStreamSQLSeed(seedFile) {
+			if truncate {
+				logger.Warn().Msgf("Truncate flag ignored for raw seed file: %s", seedFile)
+			}
+			if err := d.streamSQLSeed(seedFile); err != nil {
+				logger.Error().Msgf("Failed to apply raw seed file '%s': %v", seedFile, err)
 				if !d.Force {
-					return fmt.Errorf("failed to read seed file %s: %w", seedFile, err)
+					return seeded, fmt.Errorf("failed to apply raw seed file %s: %w", seedFile, err)
 				}
-				continue
 			}
-			sql := strings.TrimSpace(string(data))
-			if sql == "" {
-				logger.Info().Msgf("Raw seed file '%s' is empty, skipping", seedFile)
-				continue
+			return seeded, nil
+		}
+		data, err := d.readSeedFile(seedFile)
+		if err != nil {
+			logger.Error().Msgf("Failed to read seed file '%s': %v", seedFile, err)
+			if !d.Force {
+				return seeded, fmt.Errorf("failed to read seed file %s: %w", seedFile, err)
 			}
-			if d.Verbose {
-				logger.Info().Msgf("Raw seed SQL (%d bytes)", len(sql))
+			return seeded, nil
+		}
+		sql := strings.TrimSpace(string(data))
+		if sql == "" {
+			logger.Info().Msgf("Raw seed file '%s' is empty, skipping", seedFile)
+			return seeded, nil
+		}
+		d.detailLog().Msgf("Raw seed SQL (%d bytes)", len(sql))
+		if truncate {
+			logger.Warn().Msgf("Truncate flag ignored for raw seed file: %s", seedFile)
+		}
+		logger.Info().Msgf("Applying raw seed file: %s", seedFile)
+		if err := d.dbDriver.ApplySQL([]string{sql}); err != nil {
+			logger.Error().Msgf("Failed to apply raw seed file '%s': %v", seedFile, err)
+			if !d.Force {
+				return seeded, fmt.Errorf("failed to apply raw seed file %s: %w", seedFile, err)
 			}
-			if truncate {
-				logger.Warn().Msgf("Truncate flag ignored for raw seed file: %s", seedFile)
+			return seeded, nil
+		}
+	case ".bcl":
+		cached, err := d.readSeedsBCL(seedFile)
+		if err != nil {
+			logger.Error().Msgf("Failed to parse seed file '%s': %v", seedFile, err)
+			if !d.Force {
+				return seeded, fmt.Errorf("failed to parse seed file %s: %w", seedFile, err)
 			}
-			logger.Info().Msgf("Applying raw seed file: %s", seedFile)
-			if err := d.dbDriver.ApplySQL([]string{sql}); err != nil {
-				logger.Error().Msgf("Failed to apply raw seed file '%s': %v", seedFile, err)
+			return seeded, nil
+		}
+		if len(cached.seeds) == 0 {
+			logger.Info().Msgf("Seed file '%s' contains no Seed blocks, skipping", seedFile)
+			return seeded, nil
+		}
+
+		// An atomic file collects its statements and applies them in
+		// one batch transaction after the loop.
+		atomic := atomicEnabled(cached.seeds[0].Atomic)
+		seeds := d.filterSeeds(cached.seeds)
+		var batch []Statement
+		// batchRows counts the rows of batch per table, in batchTables order.
+		var batchTables []string
+		batchRows := make(map[string]int64)
+		// generated holds the rows of each seed, generated before any
+		// table is truncated.
+		type generatedSeed struct {
+			seed    SeedDefinition
+			queries []InsertQuery
+		}
+		var generated []generatedSeed
+		for _, seed := range seeds {
+			seeded = append(seeded, seed.Table)
+			if err := requireFields(seed.Name, seed.Table); err != nil {
+				logger.Error().Msgf("Invalid seed configuration in '%s': %v", seedFile, err)
 				if !d.Force {
-					return fmt.Errorf("failed to apply raw seed file %s: %w", seedFile, err)
+					return seeded, fmt.Errorf("invalid seed configuration in %s: %w", seedFile, err)
 				}
 				continue
 			}
-		case ".bcl":
-			cached, err := d.readSeedsBCL(seedFile)
+
+			queries, err := seed.toSQL(d.dialect, lookup)
 			if err != nil {
-				logger.Error().Msgf("Failed to parse seed file '%s': %v", seedFile, err)
+				logger.Error().Msgf("Failed to generate seed SQL for '%s': %v", seedFile, err)
 				if !d.Force {
-					return fmt.Errorf("failed to parse seed file %s: %w", seedFile, err)
+					return seeded, fmt.Errorf("failed to generate seed SQL for %s: %w", seedFile, err)
 				}
 				continue
 			}
-			if len(cached.seeds) == 0 {
-				logger.Info().Msgf("Seed file '%s' contains no Seed blocks, skipping", seedFile)
+
+			if len(queries) == 0 {
+				logger.Info().Msgf("Seed '%s' in file '%s' generated no queries, skipping", seed.Name, seedFile)
 				continue
 			}
-
-			for _, seed := range cached.seeds {
-				if err := requireFields(seed.Name, seed.Table); err != nil {
-					logger.Error().Msgf("Invalid seed configuration in '%s': %v", seedFile, err)
-					if !d.Force {
-						return fmt.Errorf("invalid seed configuration in %s: %w", seedFile, err)
-					}
-					continue
+			generated = append(generated, generatedSeed{seed, queries})
+		}
+		// The tables are emptied once every row is generated, in an
+		// order their foreign keys allow.
+		if truncate && len(generated) > 0 {
+			var tables []string
+			for _, g := range generated {
+				if !slices.Contains(tables, g.seed.Table) {
+					tables = append(tables, g.seed.Table)
 				}
-
-				queries, err := seed.ToSQL(d.dialect)
-				if err != nil {
-					logger.Error().Msgf("Failed to generate seed SQL for '%s': %v", seedFile, err)
+			}
+			var queries []string
+			err := d.backupTruncatedTables(seedFile, tables)
+			if err == nil {
+				queries, err = d.truncateStatements(tables)
+			}
+			if err == nil && atomic {
+				for _, q := range queries {
+					batch = append(batch, Statement{SQL: q})
+				}
+			} else if err == nil {
+				d.detailLog().Msg("Executing truncate SQL")
+				err = d.dbDriver.ApplySQL(queries)
+			}
+			if err != nil {
+				logger.Error().Msgf("Failed to truncate tables %s: %v", strings.Join(tables, ", "), err)
+				if !d.Force {
+					return seeded, fmt.Errorf("failed to truncate tables %s: %w", strings.Join(tables, ", "), err)
+				}
+			}
+		}
+		for _, g := range generated {
+			seed, queries := g.seed, g.queries
+			logger.Info().Msgf("Seeding table: %s", seed.Table)
+			if atomic {
+				for _, q := range queries {
+					d.logStatement(q.SQL, q.Args)
+					batch = append(batch, Statement{SQL: q.SQL, Args: q.Args})
+				}
+				if _, ok := batchRows[seed.Table]; !ok {
+					batchTables = append(batchTables, seed.Table)
+				}
+				batchRows[seed.Table] += int64(len(queries))
+				continue
+			}
+			var rows int64
+			for _, q := range queries {
+				d.logStatement(q.SQL, q.Args)
+				if err := d.dbDriver.ApplySQL([]string{q.SQL}, q.Args); err != nil {
+					logger.Error().Msgf("Seed failed (%s): %v", seedFile, err)
 					if !d.Force {
-						return fmt.Errorf("failed to generate seed SQL for %s: %w", seedFile, err)
+						return seeded, fmt.Errorf("seed failed for %s: %w", seedFile, err)
 					}
 					continue
 				}
-
-				if len(queries) == 0 {
-					logger.Info().Msgf("Seed '%s' in file '%s' generated no queries, skipping", seed.Name, seedFile)
-					continue
-				}
-				if truncate {
-					query := getTruncateSQL(d.dialect, seed.Table)
-					if query != "" {
-						logger.Info().Msgf("Truncating table: %s", seed.Table)
-						if d.Verbose {
-							logger.Info().Msg("Executing truncate SQL")
-						}
-						if err := d.dbDriver.ApplySQL([]string{query}); err != nil {
-							logger.Error().Msgf("Failed to truncate table '%s': %v", seed.Table, err)
-							if !d.Force {
-								return fmt.Errorf("failed to truncate table %s: %w", seed.Table, err)
-							}
-							continue
-						}
-					} else {
-						logger.Error().Msgf("Unsupported dialect for truncation: %s", d.dialect)
-						if !d.Force {
-							return fmt.Errorf("unsupported dialect for truncation: %s", d.dialect)
-						}
-						continue
-					}
+				rows++
+			}
+			d.emitSeedRows(seedFile, seed.Table, rows)
+		}
+		if len(batch) > 0 {
+			if err := d.dbDriver.ApplyBatch(batch); err != nil {
+				logger.Error().Msgf("Seed failed (%s): %v", seedFile, err)
+				if !d.Force {
+					return seeded, fmt.Errorf("seed failed for %s: %w", seedFile, err)
 				}
-				logger.Info().Msgf("Seeding table: %s", seed.Table)
-				for _, q := range queries {
-					if d.Verbose {
-						logger.Info().Msg("Executing seed SQL")
-					}
-					if err := d.dbDriver.ApplySQL([]string{q.SQL}, q.Args); err != nil {
-						logger.Error().Msgf("Seed failed (%s): %v", seedFile, err)
-						if !d.Force {
-							return fmt.Errorf("seed failed for %s: %w", seedFile, err)
-						}
-						continue
-					}
+			} else {
+				for _, table := range batchTables {
+					d.emitSeedRows(seedFile, table, batchRows[table])
 				}
 			}
-		default:
-			logger.Warn().Msgf("Unsupported seed file type, skipping: %s", seedFile)
 		}
+	default:
+		logger.Warn().Msgf("Unsupported seed file type, skipping: %s", seedFile)
 	}
-	return nil
+	return seeded, nil
 }
 
 func getTruncateSQL(dialect string, table string) string {
 	switch dialect {
 	case "mysql", "mariadb":
-		return fmt.Sprintf("TRUNCATE TABLE `%s`;", table)
+		return fmt.Sprintf("TRUNCATE TABLE %s;", quoteDialectIdentifier(DialectMySQL, table))
 	case "postgres", "cockroachdb", "postgresql", "pgx":
-		return fmt.Sprintf("TRUNCATE TABLE \"%s\" RESTART IDENTITY CASCADE;", table)
+		return fmt.Sprintf("TRUNCATE TABLE %s RESTART IDENTITY CASCADE;", quoteDialectIdentifier(DialectPostgres, table))
 	case "sqlite", "sqlite3":
-		return fmt.Sprintf("DELETE FROM `%s`;", table)
+		return fmt.Sprintf("DELETE FROM %s;", quoteDialectIdentifier(DialectSQLite, table))
 	}
 	return ""
 }
@@ -1,10 +1,31 @@
 package migrate
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/oarkflow/cli/contracts"
 )
 
 func newSQLiteWorkflowManager(t *testing.T) *Manager {
@@ -279,6 +300,103 @@ DROP TABLE IF EXISTS raw_command_items;
 	assertSQLiteTableExists(t, manager, "raw_command_items", true)
 }
 
+func TestMigrateCommandCheckReportsPendingWithoutApplying(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_multi.bcl"), testMultiRootMigrationBCL())
+
+	cmd := &MigrateCommand{Driver: manager}
+	err := cmd.Handle(testContext{options: map[string]string{"check": "true"}})
+	if err == nil || !strings.Contains(err.Error(), "2 pending migration(s)") {
+		t.Fatalf("Handle --check error = %v, want pending error", err)
+	}
+	if code := ExitCode(err); code != ExitPending {
+		t.Fatalf("ExitCode = %d, want %d", code, ExitPending)
+	}
+	assertSQLiteTableExists(t, manager, "accounts", false)
+
+	if err := cmd.Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if err := cmd.Handle(testContext{options: map[string]string{"check": "true"}}); err != nil {
+		t.Fatalf("Handle --check after migrate: %v", err)
+	}
+}
+
+func TestExitCodeCommandWrapsErrors(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_multi.bcl"), testMultiRootMigrationBCL())
+
+	cmd := withExitCodes([]contracts.Command{&MigrateCommand{Driver: manager}})[0]
+	hasFormat := false
+	for _, flag := range cmd.Extend().Flags {
+		if flag.Name == "format" {
+			hasFormat = true
+		}
+	}
+	if !hasFormat {
+		t.Fatal("wrapped command has no --format flag")
+	}
+
+	err := cmd.Handle(testContext{options: map[string]string{"check": "true", "format": "json"}})
+	var exitErr *exitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("Handle error = %T %v, want *exitError", err, err)
+	}
+	if exitErr.ExitCode() != ExitPending || exitErr.Error() != "" {
+		t.Fatalf("exit code = %d, message = %q", exitErr.ExitCode(), exitErr.Error())
+	}
+	envelope := NewErrorEnvelope("migrate", exitErr)
+	if envelope.Kind != "pending_migrations" || envelope.ExitCode != ExitPending {
+		t.Fatalf("envelope = %+v", envelope)
+	}
+	if ExitCode(fmt.Errorf("apply: %w", ErrChecksumMismatch)) != ExitDrift {
+		t.Fatal("checksum mismatch should map to ExitDrift")
+	}
+}
+
+func TestRollbackRequiresConfirmationOnProtectedEnvironment(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	WithProtectedEnvironment("production")(manager)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_multi.bcl"), testMultiRootMigrationBCL())
+	if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	cmd := &RollbackCommand{Driver: manager}
+	err := cmd.Handle(testContext{options: map[string]string{"step": "1"}})
+	if err == nil || !strings.Contains(err.Error(), "--yes-production") {
+		t.Fatalf("Handle without confirmation error = %v", err)
+	}
+	assertSQLiteTableExists(t, manager, "projects", true)
+
+	if err := cmd.Handle(testContext{options: map[string]string{"step": "1", "yes-production": "true"}}); err != nil {
+		t.Fatalf("Handle with --yes-production: %v", err)
+	}
+	assertSQLiteTableExists(t, manager, "projects", false)
+}
+
+func TestResetDatabaseCommandFailsFastWithoutInteractiveInput(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "reset.db")
+	writeTestFile(t, dbPath, "")
+	cfg := DefaultConfig()
+	cfg.Database.Driver = DialectSQLite
+	cfg.Database.Database = dbPath
+	configPath := filepath.Join(dir, "migrate.json")
+	if err := cfg.SaveConfig(configPath); err != nil {
+		t.Fatalf("SaveConfig: %v", err)
+	}
+
+	cmd := &ResetDatabaseCommand{Driver: newSQLiteWorkflowManager(t)}
+	err := cmd.Handle(testContext{options: map[string]string{"config": configPath}})
+	if !errors.Is(err, ErrNonInteractive) {
+		t.Fatalf("Handle without --yes error = %v, want ErrNonInteractive", err)
+	}
+	if err := cmd.Handle(testContext{options: map[string]string{"config": configPath, "yes": "true"}}); err != nil {
+		t.Fatalf("Handle with --yes: %v", err)
+	}
+}
+
 func TestValidateMigrationsRejectsRawSQLWithoutUpSection(t *testing.T) {
 	manager := newSQLiteWorkflowManager(t)
 	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_bad.sql"), `CREATE TABLE bad_raw (id INTEGER);`)
@@ -389,6 +507,101 @@ func TestHistoryReportIncludesMultiRootMigrations(t *testing.T) {
 	}
 }
 
+func TestHistoryReportShowsColumnTimeline(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	create := filepath.Join(manager.MigrationDir(), "1700000000_create_items.bcl")
+	writeTestFile(t, create, `
+Migration "1700000000_create_items" {
+  Up {
+    CreateTable "items" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+      Field "name" {
+        type = "string"
+        size = 50
+      }
+      Field "price" {
+        type = "integer"
+        default = "0"
+      }
+    }
+  }
+}
+`)
+	alter := filepath.Join(manager.MigrationDir(), "1700000100_reshape_items.bcl")
+	writeTestFile(t, alter, `
+Migration "1700000100_reshape_items" {
+  Up {
+    AlterTable "items" {
+      RenameField "name" {
+        from = "name"
+        to = "title"
+        type = "text"
+      }
+      DropField "price" {}
+    }
+  }
+}
+Migration "1700000200_reprice_items" {
+  Up {
+    AlterTable "items" {
+      AddField "price" {
+        type = "decimal"
+        nullable = true
+        default = "1.5"
+      }
+    }
+  }
+}
+`)
+	readMigrations := func(path string) ([]Migration, error) {
+		cached, err := manager.readMigrationsBCL(path)
+		if err != nil {
+			return nil, err
+		}
+		return cached.migrations, nil
+	}
+	objects := []objectInfo{{Name: "items", Type: "table"}}
+	reports := objectHistoryReports(objects, []string{create, alter}, readMigrations)
+	timeline := map[string][]string{}
+	for _, col := range reports["items"].Columns {
+		for _, change := range col.Changes {
+			timeline[col.Name] = append(timeline[col.Name], change.MigrationName+": "+change.Describe())
+		}
+	}
+	want := map[string][]string{
+		"id": {"1700000000_create_items: Added as integer NOT NULL PRIMARY KEY"},
+		"title": {
+			"1700000000_create_items: Added as string(50) NOT NULL",
+			"1700000100_reshape_items: Renamed from name to title",
+			"1700000100_reshape_items: Type changed from string(50) to text",
+		},
+		"price": {
+			"1700000000_create_items: Added as integer NOT NULL DEFAULT 0",
+			"1700000100_reshape_items: Dropped",
+			"1700000200_reprice_items: Added as decimal NULL DEFAULT 1.5",
+			"1700000200_reprice_items: Type changed from integer to decimal",
+			"1700000200_reprice_items: Default changed from 0 to 1.5",
+			"1700000200_reprice_items: Nullability changed from NOT NULL to NULL",
+		},
+	}
+	if !reflect.DeepEqual(timeline, want) {
+		t.Fatalf("column timeline = %#v, want %#v", timeline, want)
+	}
+
+	report, err := generateFallbackHTMLReport(objects, reports)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`id="tab-columns-btn"`, `columns: `, `Type changed from string(50) to text`} {
+		if !strings.Contains(report, want) {
+			t.Fatalf("history report missing %q", want)
+		}
+	}
+}
+
 func TestManagerRejectsDuplicateMigrationNamesAcrossFiles(t *testing.T) {
 	manager := newSQLiteWorkflowManager(t)
 	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_a.bcl"), `Migration "dup" {}`)
@@ -424,6 +637,51 @@ func TestManagerMigrationParseCache(t *testing.T) {
 	}
 }
 
+func TestManagerMigrationParseCacheSeesEdits(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	migrationFile := filepath.Join(manager.MigrationDir(), "001_multi.bcl")
+	writeTestFile(t, migrationFile, testMultiRootMigrationBCL())
+
+	first, err := manager.readMigrationsBCL(migrationFile)
+	if err != nil {
+		t.Fatalf("first readMigrationsBCL: %v", err)
+	}
+	edited := strings.Replace(testMultiRootMigrationBCL(), "001_create_accounts", "001_create_members", 1)
+	writeTestFile(t, migrationFile, edited)
+	second, err := manager.readMigrationsBCL(migrationFile)
+	if err != nil {
+		t.Fatalf("second readMigrationsBCL: %v", err)
+	}
+	if second.checksum == first.checksum {
+		t.Fatalf("checksum unchanged after edit: %q", second.checksum)
+	}
+	if _, ok := findMigrationByName(second.migrations, "001_create_members"); !ok {
+		t.Fatalf("edited migration not re-parsed: %+v", second.migrations)
+	}
+
+	copyFile := filepath.Join(manager.MigrationDir(), "002_copy.bcl")
+	writeTestFile(t, copyFile, edited)
+	if _, err := manager.readMigrationsBCL(copyFile); err != nil {
+		t.Fatalf("readMigrationsBCL copy: %v", err)
+	}
+	if len(manager.migrationBCL) != 2 {
+		t.Fatalf("len(migrationBCL cache) = %d, want 2", len(manager.migrationBCL))
+	}
+
+	migration, _ := findMigrationByName(second.migrations, "001_create_members")
+	up, err := manager.migrationSQL(second.checksum, migration, DialectPostgres, true)
+	if err != nil {
+		t.Fatalf("migrationSQL: %v", err)
+	}
+	again, err := manager.migrationSQL(second.checksum, migration, DialectPostgres, true)
+	if err != nil {
+		t.Fatalf("migrationSQL again: %v", err)
+	}
+	if len(up) == 0 || len(again) != len(up) || len(manager.generatedSQL) != 1 {
+		t.Fatalf("generated SQL not cached: %d vs %d queries, %d entries", len(up), len(again), len(manager.generatedSQL))
+	}
+}
+
 func TestParsedMigrationGeneratesMySQLAndPostgresSQL(t *testing.T) {
 	src := []byte(`
 Migration "001_create_accounts" {
@@ -467,6 +725,199 @@ Migration "001_create_accounts" {
 	}
 }
 
+func TestGenerateCompletionCoversRegisteredCommands(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	commands := manager.registeredCommands()
+	for _, shell := range []string{"bash", "zsh", "fish", "markdown"} {
+		script, err := GenerateCompletion(shell, "migrator", commands)
+		if err != nil {
+			t.Fatalf("GenerateCompletion(%s): %v", shell, err)
+		}
+		for _, want := range []string{"migration:rollback", "completion", "step", "format"} {
+			if !strings.Contains(script, want) {
+				t.Fatalf("%s completion missing %q:\n%s", shell, want, script)
+			}
+		}
+	}
+	if _, err := GenerateCompletion("powershell", "migrator", commands); err == nil {
+		t.Fatal("expected unsupported shell error")
+	}
+}
+
+func TestManagerDiagnoseReportsChecksumDrift(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	migrationFile := filepath.Join(manager.MigrationDir(), "001_multi.bcl")
+	writeTestFile(t, migrationFile, testMultiRootMigrationBCL())
+	if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	for _, f := range manager.Diagnose() {
+		if f.Status == DoctorFail {
+			t.Fatalf("unexpected failure before drift: %+v", f)
+		}
+	}
+	assertSQLiteTableExists(t, manager, doctorProbeTable, false)
+
+	writeTestFile(t, migrationFile, testMultiRootMigrationBCL()+"\n# edited\n")
+	drift := false
+	for _, f := range manager.Diagnose() {
+		if f.Check == "checksums" && f.Status == DoctorFail {
+			drift = true
+		}
+	}
+	if !drift {
+		t.Fatal("expected checksum drift finding")
+	}
+	err := (&DoctorCommand{Driver: manager}).Handle(testContext{options: map[string]string{"format": "json"}})
+	if !errors.Is(err, ErrChecksumMismatch) || ExitCode(err) != 3 {
+		t.Fatalf("doctor error = %v (exit %d), want a checksum mismatch exiting 3", err, ExitCode(err))
+	}
+}
+
+func TestHealthHandlerReportsPendingMigrations(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_multi.bcl"), testMultiRootMigrationBCL())
+	handler := manager.HealthHandler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("/healthz code = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/migrations", nil))
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), `"pending":2`) {
+		t.Fatalf("/migrations before migrate code = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/migrations", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"last_applied":"002_create_projects"`) {
+		t.Fatalf("/migrations after migrate code = %d, body = %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestParseWaitTimeout(t *testing.T) {
+	cases := map[string]time.Duration{
+		"":     defaultWaitTimeout,
+		"120":  120 * time.Second,
+		"120s": 120 * time.Second,
+		"2m":   2 * time.Minute,
+	}
+	for value, want := range cases {
+		got, err := ParseWaitTimeout(value)
+		if err != nil || got != want {
+			t.Fatalf("ParseWaitTimeout(%q) = %s, %v; want %s", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"0", "-5s", "soon"} {
+		if _, err := ParseWaitTimeout(value); err == nil {
+			t.Fatalf("ParseWaitTimeout(%q) expected error", value)
+		}
+	}
+}
+
+func TestUpCommandWaitsForDatabaseAndMigrates(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_multi.bcl"), testMultiRootMigrationBCL())
+	cmd := &UpCommand{MigrateCommand{Driver: manager}}
+	if err := cmd.Handle(testContext{options: map[string]string{"wait-for-db": "true", "timeout": "5s"}}); err != nil {
+		t.Fatalf("up --wait-for-db: %v", err)
+	}
+	assertSQLiteTableExists(t, manager, "projects", true)
+}
+
+func TestListMigrationMapParsesConcurrently(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	const files = 24
+	for i := 0; i < files; i++ {
+		body := fmt.Sprintf(`
+Migration "%03d_create_t%d" {
+  Version = "1.0.0"
+  Description = "Create t%d."
+  Up {
+    CreateTable "t%d" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+    }
+  }
+  Down {
+    DropTable "t%d" {}
+  }
+}
+`, i, i, i, i, i)
+		writeTestFile(t, filepath.Join(manager.MigrationDir(), fmt.Sprintf("%03d_create_t%d.bcl", i, i)), body)
+	}
+	if err := applyJobsOption(testContext{options: map[string]string{"jobs": "4"}}, manager); err != nil {
+		t.Fatalf("applyJobsOption: %v", err)
+	}
+	if manager.parseJobs() != 4 {
+		t.Fatalf("parseJobs = %d, want 4", manager.parseJobs())
+	}
+	migrationMap, err := manager.ListMigrationMap()
+	if err != nil {
+		t.Fatalf("ListMigrationMap: %v", err)
+	}
+	if len(migrationMap) != files || len(manager.migrationBCL) != files {
+		t.Fatalf("migrations = %d, cached = %d, want %d", len(migrationMap), len(manager.migrationBCL), files)
+	}
+
+	// The ordered pass reuses what the workers read instead of reading each
+	// file again.
+	dir := manager.migrationDir
+	reads := &openCountingFS{FS: os.DirFS(dir)}
+	manager.assets, manager.migrationDir = reads, "."
+	if _, err := manager.ListMigrationMap(); err != nil {
+		t.Fatalf("ListMigrationMap from assets: %v", err)
+	}
+	manager.assets, manager.migrationDir = nil, dir
+	if got := reads.count(".bcl"); got != files {
+		t.Fatalf("migration files opened %d times, want %d", got, files)
+	}
+
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "999_dup.bcl"), strings.Replace(testMultiRootMigrationBCL(), "001_create_accounts", "003_create_t3", 1))
+	if _, err := manager.ListMigrationMap(); err == nil || !strings.Contains(err.Error(), "duplicate migration name") {
+		t.Fatalf("ListMigrationMap error = %v, want duplicate migration name", err)
+	}
+	if err := applyJobsOption(testContext{options: map[string]string{"jobs": "0"}}, manager); err == nil {
+		t.Fatal("applyJobsOption accepted --jobs 0")
+	}
+}
+
+// openCountingFS counts the files opened through it.
+type openCountingFS struct {
+	fs.FS
+	mu     sync.Mutex
+	opened []string
+}
+
+func (c *openCountingFS) Open(name string) (fs.File, error) {
+	c.mu.Lock()
+	c.opened = append(c.opened, name)
+	c.mu.Unlock()
+	return c.FS.Open(name)
+}
+
+// count returns how many opened names end in ext.
+func (c *openCountingFS) count(ext string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, name := range c.opened {
+		if strings.HasSuffix(name, ext) {
+			n++
+		}
+	}
+	return n
+}
+
 type testContext struct {
 	args    []string
 	options map[string]string
@@ -489,12 +940,4398 @@ func (c testContext) Option(key string) string {
 
 func assertSQLiteTableExists(t *testing.T, manager *Manager, table string, want bool) {
 	t.Helper()
-	var exists bool
-	query := GetDialect(DialectSQLite).TableExistsSQL(table)
-	if err := manager.dbDriver.DB().Select(&exists, query); err != nil {
-		t.Fatalf("table exists query for %s: %v", table, err)
+	exists, err := manager.TableExists(table)
+	if err != nil {
+		t.Fatalf("TableExists(%s): %v", table, err)
 	}
 	if exists != want {
 		t.Fatalf("table %s exists = %t, want %t", table, exists, want)
 	}
 }
+
+func TestApplyMigrationSkipsContinueOnErrorStatements(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	body := `
+Migration "001_create_gadgets" {
+  Version = "1.0.0"
+  Up {
+    CreateTable "gadgets" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+    }
+    DeleteData "legacy_gadgets" {
+      Where = "1 = 1"
+      continue_on_error = %t
+    }
+  }
+  Down {
+    DropTable "gadgets" {}
+  }
+}
+`
+	migrationFile := filepath.Join(manager.MigrationDir(), "001_gadgets.bcl")
+	writeTestFile(t, migrationFile, fmt.Sprintf(body, false))
+	migration, err := ParseMigrationBCL([]byte(fmt.Sprintf(body, false)))
+	if err != nil {
+		t.Fatalf("ParseMigrationBCL: %v", err)
+	}
+	if err := manager.ApplyMigration(migration); err == nil {
+		t.Fatal("ApplyMigration succeeded although DeleteData targets a missing table")
+	}
+	assertSQLiteTableExists(t, manager, "gadgets", false)
+
+	writeTestFile(t, migrationFile, fmt.Sprintf(body, true))
+	migration, err = ParseMigrationBCL([]byte(fmt.Sprintf(body, true)))
+	if err != nil {
+		t.Fatalf("ParseMigrationBCL: %v", err)
+	}
+	if !migration.Up.DeleteData[0].ContinueOnError {
+		t.Fatal("continue_on_error not parsed from BCL")
+	}
+	if err := manager.ApplyMigration(migration); err != nil {
+		t.Fatalf("ApplyMigration with continue_on_error: %v", err)
+	}
+	assertSQLiteTableExists(t, manager, "gadgets", true)
+}
+
+func TestApplyMigrationRunsValidationQueries(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	body := `
+Migration "001_create_gauges" {
+  Version = "1.0.0"
+  Up {
+    CreateTable "gauges" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+    }
+  }
+  Down {
+    DropTable "gauges" {}
+  }
+  Validate "checks" {
+    PreUpChecks = ["SELECT 1"]
+    PostUpChecks = ["%s"]
+  }
+}
+`
+	migrationFile := filepath.Join(manager.MigrationDir(), "001_gauges.bcl")
+	failing := fmt.Sprintf(body, "SELECT COUNT(*) FROM gauges_missing")
+	writeTestFile(t, migrationFile, failing)
+	migration, err := ParseMigrationBCL([]byte(failing))
+	if err != nil {
+		t.Fatalf("ParseMigrationBCL: %v", err)
+	}
+	if err := manager.ApplyMigration(migration); err == nil || !strings.Contains(err.Error(), "post-up validation failed") {
+		t.Fatalf("ApplyMigration error = %v, want post-up validation failure", err)
+	}
+
+	passing := fmt.Sprintf(body, "SELECT COUNT(*) FROM gauges")
+	writeTestFile(t, migrationFile, passing)
+	migration, err = ParseMigrationBCL([]byte(passing))
+	if err != nil {
+		t.Fatalf("ParseMigrationBCL: %v", err)
+	}
+	if err := manager.dbDriver.ApplySQL([]string{"DROP TABLE gauges;"}); err != nil {
+		t.Fatalf("drop gauges: %v", err)
+	}
+	if err := manager.ApplyMigration(migration); err != nil {
+		t.Fatalf("ApplyMigration: %v", err)
+	}
+	assertSQLiteTableExists(t, manager, "gauges", true)
+}
+
+func TestWriteMigrationSQLCoversPendingAndApplied(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_multi.bcl"), testMultiRootMigrationBCL())
+	migrations, err := ParseMigrationsBCL([]byte(testMultiRootMigrationBCL()))
+	if err != nil {
+		t.Fatalf("ParseMigrationsBCL: %v", err)
+	}
+	if err := manager.ApplyMigration(migrations[0]); err != nil {
+		t.Fatalf("ApplyMigration: %v", err)
+	}
+
+	var up strings.Builder
+	if err := manager.WriteMigrationSQL(&up, OfflineSQLOptions{Dialect: "postgres"}); err != nil {
+		t.Fatalf("WriteMigrationSQL up: %v", err)
+	}
+	if strings.Contains(up.String(), "001_create_accounts") || !strings.Contains(up.String(), "-- Migration: 002_create_projects (up)") {
+		t.Fatalf("up SQL should cover only the pending migration:\n%s", up.String())
+	}
+	if !strings.Contains(up.String(), `CREATE TABLE "projects"`) {
+		t.Fatalf("up SQL not generated for postgres:\n%s", up.String())
+	}
+
+	var down strings.Builder
+	if err := manager.WriteMigrationSQL(&down, OfflineSQLOptions{Down: true}); err != nil {
+		t.Fatalf("WriteMigrationSQL down: %v", err)
+	}
+	if !strings.Contains(down.String(), "-- Migration: 001_create_accounts (down)") || strings.Contains(down.String(), "002_create_projects") {
+		t.Fatalf("down SQL should cover only the applied migration:\n%s", down.String())
+	}
+
+	var all strings.Builder
+	if err := manager.WriteMigrationSQL(&all, OfflineSQLOptions{Dialect: "mysql", All: true}); err != nil {
+		t.Fatalf("WriteMigrationSQL all: %v", err)
+	}
+	first := strings.Index(all.String(), "001_create_accounts")
+	second := strings.Index(all.String(), "002_create_projects")
+	if first < 0 || second < first {
+		t.Fatalf("all SQL should list every migration in apply order:\n%s", all.String())
+	}
+}
+
+func TestRolePasswordsStayOutOfOfflineSQLAndErrors(t *testing.T) {
+	t.Setenv("BILLING_DB_PASSWORD", "it's-secret")
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_billing_role.bcl"), `
+Migration "001_billing_role" {
+  Version = "1.0.0"
+  Description = "Provision the billing application role."
+  Up {
+    CreateRole "billing" {
+      login = true
+      password = "env:BILLING_DB_PASSWORD"
+    }
+    AlterRole "reporting" {
+      password = "env:BILLING_DB_PASSWORD"
+    }
+  }
+  Down {
+    DropRole "billing" {
+      if_exists = true
+    }
+  }
+}
+`)
+	var out strings.Builder
+	if err := manager.WriteMigrationSQL(&out, OfflineSQLOptions{Dialect: "postgres"}); err != nil {
+		t.Fatalf("WriteMigrationSQL: %v", err)
+	}
+	if strings.Contains(out.String(), "secret") {
+		t.Fatalf("offline SQL contains the resolved password:\n%s", out.String())
+	}
+	if strings.Count(out.String(), "PASSWORD 'env:BILLING_DB_PASSWORD'") != 2 {
+		t.Fatalf("offline SQL should show the secret reference:\n%s", out.String())
+	}
+	migration, err := ParseMigrationBCL([]byte(`
+Migration "002_role" {
+  Up {
+    CreateRole "billing" {
+      password = "env:BILLING_DB_PASSWORD"
+    }
+  }
+}
+`))
+	if err != nil {
+		t.Fatalf("ParseMigrationBCL: %v", err)
+	}
+	if up, err := migration.ToSQL(DialectPostgres, true); err != nil || len(up) != 1 || !strings.Contains(up[0], "it''s-secret") {
+		t.Fatalf("ToSQL after offline SQL = %q, %v, want the resolved password", up, err)
+	}
+
+	stErr := &StatementError{Statement: `CREATE ROLE "billing" WITH LOGIN PASSWORD 'it''s-secret';`, Position: 1, Err: errors.New("permission denied")}
+	migErr := manager.newMigrationError("apply", "001_billing_role", stErr)
+	if strings.Contains(migErr.Error(), "secret") || strings.Contains(migErr.Statement, "secret") {
+		t.Fatalf("MigrationError leaks the password: %v (statement %q)", migErr, migErr.Statement)
+	}
+	data, err := json.Marshal(NewErrorEnvelope("migrate", migErr))
+	if err != nil {
+		t.Fatalf("marshal envelope: %v", err)
+	}
+	if strings.Contains(string(data), "secret") || !strings.Contains(string(data), "[REDACTED]") {
+		t.Fatalf("error envelope does not redact the password: %s", data)
+	}
+}
+
+func TestMigrateCommandKeepGoingAttemptsEveryMigration(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_broken.sql"), `
+-- migration-up
+INSERT INTO keep_going_missing (id) VALUES (1);
+`)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "002_items.sql"), `
+-- migration-up
+CREATE TABLE keep_going_items (id INTEGER PRIMARY KEY);
+`)
+	options := map[string]string{"force": "false", "seed": "false", "include-raw": "true"}
+
+	cmd := &MigrateCommand{Driver: manager}
+	if err := cmd.Handle(testContext{options: options}); err == nil {
+		t.Fatal("Handle succeeded despite the broken migration")
+	}
+	assertSQLiteTableExists(t, manager, "keep_going_items", false)
+
+	options["keep-going"] = "true"
+	err := cmd.Handle(testContext{options: options})
+	if err == nil || !strings.Contains(err.Error(), "1 migration(s) failed (001_broken)") {
+		t.Fatalf("Handle --keep-going error = %v, want summary naming 001_broken", err)
+	}
+	assertSQLiteTableExists(t, manager, "keep_going_items", true)
+}
+
+func TestMigrationScheduleHold(t *testing.T) {
+	now := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name      string
+		migration Migration
+		held      bool
+		wantErr   bool
+	}{
+		{name: "unscheduled", migration: Migration{Name: "a"}},
+		{name: "future", migration: Migration{Name: "a", RunAfter: "2025-07-01T02:00:00Z"}, held: true},
+		{name: "past", migration: Migration{Name: "a", RunAfter: "2025-06-30T02:00:00Z", RequiresWindow: true}},
+		{name: "window", migration: Migration{Name: "a", RequiresWindow: true}, held: true},
+		{name: "invalid", migration: Migration{Name: "a", RunAfter: "tomorrow"}, wantErr: true},
+	}
+	for _, tc := range cases {
+		hold, err := tc.migration.ScheduleHold(now)
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("%s: err = %v, wantErr %t", tc.name, err, tc.wantErr)
+		}
+		if (hold != "") != tc.held {
+			t.Fatalf("%s: hold = %q, want held %t", tc.name, hold, tc.held)
+		}
+	}
+}
+
+func TestMigrateCommandSkipsScheduledMigrations(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_heavy.bcl"), `
+Migration "001_heavy" {
+  Version = "1.0.0"
+  Description = "Heavy DDL."
+  RequiresWindow = true
+  Up {
+    CreateTable "heavy_items" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+    }
+  }
+}
+`)
+	options := map[string]string{"force": "false", "seed": "false", "include-raw": "false"}
+	cmd := &MigrateCommand{Driver: manager}
+	if err := cmd.Handle(testContext{options: options}); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	assertSQLiteTableExists(t, manager, "heavy_items", false)
+
+	options["include-scheduled"] = "true"
+	if err := cmd.Handle(testContext{options: options}); err != nil {
+		t.Fatalf("Handle --include-scheduled: %v", err)
+	}
+	assertSQLiteTableExists(t, manager, "heavy_items", true)
+
+	// An applied migration is not reported as held on later runs.
+	writer, level := logger.Writer, logger.Level
+	t.Cleanup(func() { logger.Writer, logger.Level = writer, level })
+	var out bytes.Buffer
+	WithSlogHandler(slog.NewTextHandler(&out, nil))(manager)
+	delete(options, "include-scheduled")
+	if err := cmd.Handle(testContext{options: options}); err != nil {
+		t.Fatalf("Handle after apply: %v", err)
+	}
+	if strings.Contains(out.String(), "Skipping migration 001_heavy") {
+		t.Fatalf("applied migration reported as held:\n%s", out.String())
+	}
+}
+
+func TestLargeTableWarningsUseRowEstimates(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	if err := manager.dbDriver.ApplySQL([]string{
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, sku TEXT);",
+		"INSERT INTO orders (sku) VALUES ('a'), ('b'), ('c');",
+	}); err != nil {
+		t.Fatalf("seed orders: %v", err)
+	}
+	migration := Migration{
+		Name: "002_index_orders",
+		Up: Operation{AlterTable: []AlterTable{
+			{Name: "orders", AddFields: []AddField{{Name: "code", Type: "string", Nullable: true, Unique: true}}},
+			{Name: "orders", AddFields: []AddField{{Name: "note", Type: "string", Nullable: true}}},
+			{Name: "shipments", AddFields: []AddField{{Name: "code", Type: "string", Nullable: true, Index: true}}},
+		}},
+	}
+
+	manager.largeTableRows = 3
+	warnings, err := manager.LargeTableWarnings(migration)
+	if err != nil {
+		t.Fatalf("LargeTableWarnings: %v", err)
+	}
+	want := "ALTER on table orders with ~3 rows will scan the table while holding a lock"
+	if len(warnings) != 1 || warnings[0] != want {
+		t.Fatalf("warnings = %q, want [%q]", warnings, want)
+	}
+
+	manager.largeTableRows = 4
+	if warnings, err = manager.LargeTableWarnings(migration); err != nil || len(warnings) != 0 {
+		t.Fatalf("below threshold: warnings = %q, err = %v", warnings, err)
+	}
+}
+
+type recordingDumper struct {
+	tables []string
+}
+
+func (r *recordingDumper) DumpTable(ctx context.Context, table, path string) error {
+	r.tables = append(r.tables, table)
+	return os.WriteFile(path, []byte("-- dump of "+table+"\n"), 0644)
+}
+
+func TestApplyMigrationBacksUpDroppedTables(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	dumper := &recordingDumper{}
+	auditLog := filepath.Join(t.TempDir(), "audit.log")
+	WithBackups(filepath.Join(t.TempDir(), "backups"), dumper)(manager)
+	WithAuditLog(auditLog)(manager)
+	if err := manager.dbDriver.ApplySQL([]string{
+		"CREATE TABLE legacy_orders (id INTEGER PRIMARY KEY);",
+		"CREATE TABLE legacy_events (id INTEGER PRIMARY KEY);",
+	}); err != nil {
+		t.Fatalf("create tables: %v", err)
+	}
+	body := `
+Migration "001_drop_legacy" {
+  Version = "1.0.0"
+  Description = "Drop legacy tables."
+  Up {
+    DropTable "legacy_orders" {}
+    DropTable "legacy_events" {}
+  }
+}
+`
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_drop_legacy.bcl"), body)
+	migration, err := ParseMigrationBCL([]byte(body))
+	if err != nil {
+		t.Fatalf("ParseMigrationBCL: %v", err)
+	}
+	if err := manager.ApplyMigration(migration); err != nil {
+		t.Fatalf("ApplyMigration: %v", err)
+	}
+	if got := strings.Join(dumper.tables, ","); got != "legacy_orders,legacy_events" {
+		t.Fatalf("dumped tables = %s, want legacy_orders,legacy_events", got)
+	}
+	entries, err := ReadAuditLog(auditLog)
+	if err != nil {
+		t.Fatalf("ReadAuditLog: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("audit entries = %d, want 2 backups and the apply", len(entries))
+	}
+	if e := entries[2]; e.Event != AuditEventApply || e.Migration != "001_drop_legacy" {
+		t.Fatalf("unexpected audit entry %+v", e)
+	}
+	for i, e := range entries[:2] {
+		if e.Event != AuditEventBackup || e.Migration != "001_drop_legacy" || e.Table != dumper.tables[i] || e.Direction != "up" {
+			t.Fatalf("unexpected audit entry %+v", e)
+		}
+		if _, err := os.Stat(e.Path); err != nil {
+			t.Fatalf("backup %s: %v", e.Path, err)
+		}
+	}
+	assertSQLiteTableExists(t, manager, "legacy_orders", false)
+}
+
+func TestTruncatingSeedsBacksUpTables(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	dumper := &recordingDumper{}
+	auditLog := filepath.Join(t.TempDir(), "audit.log")
+	WithBackups(filepath.Join(t.TempDir(), "backups"), dumper)(manager)
+	WithAuditLog(auditLog)(manager)
+	if err := manager.dbDriver.ApplySQL([]string{"CREATE TABLE teams (id INTEGER PRIMARY KEY, name TEXT NOT NULL);"}); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	seedFile := filepath.Join(manager.SeedDir(), "teams.bcl")
+	writeTestFile(t, seedFile, `
+Seed "teams" {
+  table = "teams"
+  Field "name" {
+    value = "fake_company"
+  }
+  rows = 1
+}
+`)
+	if err := manager.RunSeeds(false, false, seedFile); err != nil {
+		t.Fatalf("RunSeeds: %v", err)
+	}
+	if len(dumper.tables) != 0 {
+		t.Fatalf("tables dumped without truncation: %v", dumper.tables)
+	}
+	if err := manager.RunSeeds(true, false, seedFile); err != nil {
+		t.Fatalf("truncating RunSeeds: %v", err)
+	}
+	if got := strings.Join(dumper.tables, ","); got != "teams" {
+		t.Fatalf("dumped tables = %s, want teams", got)
+	}
+	entries, err := ReadAuditLog(auditLog)
+	if err != nil {
+		t.Fatalf("ReadAuditLog: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Event != AuditEventBackup || entries[0].Table != "teams" || entries[0].Seed != seedFile {
+		t.Fatalf("audit entries = %+v, want one backup of teams", entries)
+	}
+	if _, err := os.Stat(entries[0].Path); err != nil {
+		t.Fatalf("backup %s: %v", entries[0].Path, err)
+	}
+}
+
+func TestDropTableChecksDependentObjects(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	if err := manager.dbDriver.ApplySQL([]string{
+		"CREATE TABLE parents (id INTEGER PRIMARY KEY, name TEXT);",
+		"CREATE TABLE parents_archive (id INTEGER PRIMARY KEY);",
+		"CREATE TABLE children (id INTEGER PRIMARY KEY, parent_id INTEGER REFERENCES parents(id));",
+		"CREATE VIEW parent_names AS SELECT name FROM parents;",
+		"CREATE VIEW archived_ids AS SELECT id FROM parents_archive;",
+	}); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	if _, err := manager.dbDriver.DB().Exec("CREATE TRIGGER parents_touch AFTER UPDATE ON parents BEGIN SELECT 1; END"); err != nil {
+		t.Fatalf("create trigger: %v", err)
+	}
+	objects, err := manager.DependentObjects("parents")
+	if err != nil {
+		t.Fatalf("DependentObjects: %v", err)
+	}
+	if got := joinObjects(objects); got != "view parent_names, foreign key from children, trigger parents_touch" {
+		t.Fatalf("dependent objects = %s", got)
+	}
+
+	drop := func(name, up string) error {
+		body := fmt.Sprintf(`
+Migration "%s" {
+  Version = "1.0.0"
+  Description = "Drop parents."
+  Up {
+%s
+  }
+}
+`, name, up)
+		writeTestFile(t, filepath.Join(manager.MigrationDir(), name+".bcl"), body)
+		migration, err := ParseMigrationBCL([]byte(body))
+		if err != nil {
+			t.Fatalf("ParseMigrationBCL: %v", err)
+		}
+		return manager.ApplyMigration(migration)
+	}
+	err = drop("001_drop_parents", `    DropTable "parents" {}`)
+	if !errors.Is(err, ErrDependentObjects) || !strings.Contains(err.Error(), "view parent_names, foreign key from children") {
+		t.Fatalf("ApplyMigration error = %v, want ErrDependentObjects naming the view and foreign key", err)
+	}
+	assertSQLiteTableExists(t, manager, "parents", true)
+
+	manager.CascadeDependencies = true
+	if err := drop("002_drop_family", "    DropTable \"children\" {}\n    DropTable \"parents\" {}"); err != nil {
+		t.Fatalf("ApplyMigration with cascade: %v", err)
+	}
+	assertSQLiteTableExists(t, manager, "parents", false)
+	var views int
+	if err := manager.dbDriver.QueryRow(context.Background(), &views, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'view'"); err != nil {
+		t.Fatalf("count views: %v", err)
+	}
+	if views != 1 {
+		t.Fatalf("views left = %d, want only archived_ids", views)
+	}
+}
+
+func TestApplyMigrationRunsVacuumAndAnalyze(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	body := `
+Migration "001_backfill_metrics" {
+  Version = "1.0.0"
+  Description = "Backfill metrics and refresh statistics."
+  Up {
+    CreateTable "metrics" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+      Field "value" {
+        type = "integer"
+        index = true
+      }
+    }
+    Vacuum {}
+    Analyze "metrics" {}
+  }
+}
+`
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_backfill_metrics.bcl"), body)
+	migration, err := ParseMigrationBCL([]byte(body))
+	if err != nil {
+		t.Fatalf("ParseMigrationBCL: %v", err)
+	}
+	planned, err := migration.Plan(DialectSQLite, true)
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	var vacuum, analyze *Statement
+	for i := range planned {
+		switch planned[i].SQL {
+		case "VACUUM;":
+			vacuum = &planned[i]
+		case `ANALYZE "metrics";`:
+			analyze = &planned[i]
+		}
+	}
+	if vacuum == nil || !vacuum.Options.NoTransaction {
+		t.Fatalf("VACUUM should be planned outside the transaction: %+v", planned)
+	}
+	if analyze == nil || analyze.Options.NoTransaction {
+		t.Fatalf("ANALYZE should be planned inside the transaction: %+v", planned)
+	}
+	if err := manager.ApplyMigration(migration); err != nil {
+		t.Fatalf("ApplyMigration: %v", err)
+	}
+	assertSQLiteTableExists(t, manager, "sqlite_stat1", true)
+
+	postgres, err := (Vacuum{Name: "metrics", Full: true, Analyze: true}).ToSQL(DialectPostgres)
+	if err != nil || postgres != `VACUUM (FULL, ANALYZE) "metrics";` {
+		t.Fatalf("postgres Vacuum = %q, %v", postgres, err)
+	}
+}
+
+func TestNormalizedChecksumsIgnoreFormattingEdits(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	migrationFile := filepath.Join(manager.MigrationDir(), "001_multi.bcl")
+	writeTestFile(t, migrationFile, testMultiRootMigrationBCL())
+	migrate := &MigrateCommand{Driver: manager}
+	if err := migrate.Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	upgraded, skipped, err := manager.UpgradeChecksums()
+	if err != nil || upgraded == 0 || len(skipped) > 0 {
+		t.Fatalf("UpgradeChecksums = %d, %v, %v", upgraded, skipped, err)
+	}
+	histories, err := manager.historyDriver.Load()
+	if err != nil {
+		t.Fatalf("load history: %v", err)
+	}
+	for _, h := range histories {
+		if !strings.HasPrefix(h.Checksum, normalizedChecksumPrefix) {
+			t.Fatalf("history %s kept raw checksum %q", h.Name, h.Checksum)
+		}
+	}
+
+	manager.checksumMode = ChecksumNormalized
+	writeTestFile(t, migrationFile, "# reformatted\n"+testMultiRootMigrationBCL()+"\n\n")
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "002_raw.sql"), "-- migration-up\nCREATE TABLE notes (id INTEGER);\n-- migration-down\nDROP TABLE notes;\n")
+	if err := migrate.Handle(testContext{options: map[string]string{"include-raw": "true"}}); err != nil {
+		t.Fatalf("migrate after formatting edit: %v", err)
+	}
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "002_raw.sql"), "-- migration-up\n-- keeps notes\nCREATE TABLE   notes\n  (id INTEGER);\n-- migration-down\nDROP TABLE notes;\n")
+	for _, f := range manager.Diagnose() {
+		if f.Check == "checksums" && f.Status == DoctorFail {
+			t.Fatalf("formatting edit reported as drift: %+v", f)
+		}
+	}
+}
+
+func TestRollbackPreviewsFlagIrreversibleOperations(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_multi.bcl"), testMultiRootMigrationBCL())
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "002_drop_accounts.bcl"), `
+Migration "003_drop_accounts" {
+  Version = "1.0.0"
+  Description = "Drop accounts."
+  Up {
+    DropTable "accounts" {}
+  }
+}
+`)
+	if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	previews, err := manager.RollbackPreviews()
+	if err != nil {
+		t.Fatalf("RollbackPreviews: %v", err)
+	}
+	if len(previews) != 3 || previews[0].Migration != "003_drop_accounts" {
+		t.Fatalf("previews = %+v, want the applied migrations newest first", previews)
+	}
+	if previews[0].AppliedAt.IsZero() || len(previews[0].Statements) != 0 {
+		t.Fatalf("drop preview = %+v", previews[0])
+	}
+	notes := strings.Join(previews[0].Irreversible, "; ")
+	if !strings.Contains(notes, "no Down operations") || !strings.Contains(notes, "Up dropped table accounts") {
+		t.Fatalf("irreversible = %q", notes)
+	}
+	if len(previews[1].Statements) == 0 || !strings.Contains(strings.Join(previews[1].Irreversible, "; "), "Down drops table projects") {
+		t.Fatalf("projects preview = %+v", previews[1])
+	}
+
+	section, err := rollbackPreviewHTML(previews)
+	if err != nil {
+		t.Fatalf("rollbackPreviewHTML: %v", err)
+	}
+	if !strings.Contains(section, "Rollback Preview") || !strings.Contains(section, "003_drop_accounts") {
+		t.Fatalf("section missing previews: %s", section)
+	}
+}
+
+func TestValidateMigrationsReportsHistoryInconsistencies(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "1700000000_create_users.bcl"), `
+Migration "1700000000_create_users" {
+  Version = "1.0.0"
+  Description = "Create users."
+  Up {
+    CreateTable "users" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+    }
+  }
+}
+`)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "1800000000_create_users.sql"), "-- migration-up\nSELECT 1;\n")
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "1900000000_add_email.bcl"), `
+Migration "add_email" {
+  Version = "1.0.0"
+  Description = "Add email."
+  Up {
+    AlterTable "users" {
+      AddField "email" {
+        type = "string"
+      }
+    }
+  }
+}
+`)
+	now := time.Now()
+	for _, h := range []MigrationHistory{
+		{Name: "1600000000_create_accounts", AppliedAt: now},
+		{Name: "1700000000_create_users", AppliedAt: now},
+		{Name: "1700000000_create_users", AppliedAt: now.Add(time.Minute)},
+	} {
+		if err := manager.historyDriver.Save(h); err != nil {
+			t.Fatalf("save history: %v", err)
+		}
+	}
+
+	issues, err := manager.MigrationIssues()
+	if err != nil {
+		t.Fatalf("MigrationIssues: %v", err)
+	}
+	if len(issues.OrphanedHistory) != 1 || issues.OrphanedHistory[0] != "1600000000_create_accounts" {
+		t.Fatalf("orphaned = %v", issues.OrphanedHistory)
+	}
+	if len(issues.Duplicates) != 2 {
+		t.Fatalf("duplicates = %v, want the repeated history entry and the reused name", issues.Duplicates)
+	}
+	if len(issues.NameMismatches) != 1 || !strings.Contains(issues.NameMismatches[0], "add_email") {
+		t.Fatalf("name mismatches = %v", issues.NameMismatches)
+	}
+	if err := manager.ValidateMigrations(); !errors.Is(err, ErrInconsistentMigrations) {
+		t.Fatalf("ValidateMigrations error = %v, want ErrInconsistentMigrations", err)
+	}
+}
+
+func TestRenameMigrationUpdatesFileAndHistory(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	oldPath := filepath.Join(manager.MigrationDir(), "1700000000_create_users.bcl")
+	writeTestFile(t, oldPath, `
+Migration "1700000000_create_users" {
+  Version = "1.0.0"
+  Description = "Create users."
+  Up {
+    CreateTable "users" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+    }
+  }
+}
+`)
+	migrate := &MigrateCommand{Driver: manager}
+	if err := migrate.Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	rename := &RenameMigrationCommand{Driver: manager}
+	if err := rename.Handle(testContext{args: []string{"1700000000_create_users", "1700000000_create_accounts"}}); err != nil {
+		t.Fatalf("migration:rename: %v", err)
+	}
+	if _, err := os.Stat(oldPath); !os.IsNotExist(err) {
+		t.Fatalf("old file still exists: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(manager.MigrationDir(), "1700000000_create_accounts.bcl"))
+	if err != nil || !strings.Contains(string(data), `Migration "1700000000_create_accounts"`) {
+		t.Fatalf("renamed file = %q, %v", data, err)
+	}
+	histories, err := manager.historyDriver.Load()
+	if err != nil || len(histories) != 1 || histories[0].Name != "1700000000_create_accounts" {
+		t.Fatalf("history = %+v, %v", histories, err)
+	}
+	// The rewritten checksum keeps the migration applied.
+	if err := migrate.Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate after rename: %v", err)
+	}
+	if err := manager.RenameMigration("missing", "other"); err == nil {
+		t.Fatal("expected renaming an unknown migration to fail")
+	}
+}
+
+func TestMigrateTagsFilterAndStatusShowsDisabled(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_tagged.bcl"), `
+Migration "001_create_users" {
+  Tags = ["core"]
+  Up {
+    CreateTable "users" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+    }
+  }
+}
+
+Migration "002_create_reports" {
+  Tags = ["analytics"]
+  Up {
+    CreateTable "reports" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+    }
+  }
+}
+
+Migration "003_create_legacy" {
+  Disable = true
+  Up {
+    CreateTable "legacy" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+    }
+  }
+}
+`)
+	migrate := &MigrateCommand{Driver: manager}
+	if err := migrate.Handle(testContext{options: map[string]string{"tags": "core"}}); err != nil {
+		t.Fatalf("migrate --tags=core: %v", err)
+	}
+	statuses, err := manager.MigrationStatuses()
+	if err != nil {
+		t.Fatalf("MigrationStatuses: %v", err)
+	}
+	var states []string
+	for _, status := range statuses {
+		states = append(states, status.Name+"="+status.State()+fmt.Sprint(status.Tags))
+	}
+	want := "001_create_users=applied[core] 002_create_reports=pending[analytics] 003_create_legacy=disabled[]"
+	if got := strings.Join(states, " "); got != want {
+		t.Fatalf("statuses = %s, want %s", got, want)
+	}
+	if err := migrate.Handle(testContext{options: map[string]string{"check": "true", "tags": "core"}}); err != nil {
+		t.Fatalf("migrate --check --tags=core: %v", err)
+	}
+	// The disabled migration is not pending, only the analytics one is.
+	err = migrate.Handle(testContext{options: map[string]string{"check": "true"}})
+	if !errors.Is(err, ErrPendingMigrations) || !strings.Contains(err.Error(), "1 pending") {
+		t.Fatalf("migrate --check = %v, want one pending migration", err)
+	}
+}
+
+func TestDeprecatedDisableFieldIsHonoured(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	var migration Migration
+	if err := json.Unmarshal([]byte(`{"name": "001_create_legacy", "Disable": true, "Up": {"CreateTable": [{"name": "legacy", "Field": [{"name": "id", "type": "integer", "primary_key": true}]}]}}`), &migration); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if len(migration.Up.CreateTable) != 1 || len(migration.Up.CreateTable[0].AddFields) != 1 || !migration.Disable || migration.Disabled {
+		t.Fatalf("decoded migration = %+v, want one table and the deprecated Disable set", migration)
+	}
+	if err := manager.ApplyMigration(migration); err != nil {
+		t.Fatalf("ApplyMigration: %v", err)
+	}
+	assertSQLiteTableExists(t, manager, "legacy", false)
+	if histories, err := manager.historyDriver.Load(); err != nil || len(histories) != 0 {
+		t.Fatalf("history after applying a disabled migration = %+v, %v", histories, err)
+	}
+}
+
+func TestRepeatableMigrationReappliesWhenChanged(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	path := filepath.Join(manager.MigrationDir(), "001_active_users.bcl")
+	view := func(definition string) string {
+		return `
+Migration "001_active_users" {
+  Repeatable = true
+  Up {
+    CreateView "active_users" {
+      definition = "` + definition + `"
+      or_replace = true
+    }
+  }
+}
+`
+	}
+	writeTestFile(t, path, view("SELECT 1 AS id"))
+	migrate := &MigrateCommand{Driver: manager}
+	if err := migrate.Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	writeTestFile(t, path, view("SELECT 2 AS id"))
+	statuses, err := manager.MigrationStatuses()
+	if err != nil || len(statuses) != 1 || statuses[0].State() != "changed" {
+		t.Fatalf("statuses = %+v, %v", statuses, err)
+	}
+	if err := migrate.Handle(testContext{options: map[string]string{"check": "true"}}); !errors.Is(err, ErrPendingMigrations) {
+		t.Fatalf("migrate --check = %v, want the changed migration pending", err)
+	}
+	if err := migrate.Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate after edit: %v", err)
+	}
+	var id int
+	if err := manager.dbDriver.QueryRow(context.Background(), &id, "SELECT id FROM active_users"); err != nil || id != 2 {
+		t.Fatalf("active_users id = %d, %v; want the new definition", id, err)
+	}
+	histories, err := manager.historyDriver.Load()
+	if err != nil || len(histories) != 1 {
+		t.Fatalf("history = %+v, %v", histories, err)
+	}
+	statuses, err = manager.MigrationStatuses()
+	if err != nil || statuses[0].State() != "applied" {
+		t.Fatalf("statuses after re-apply = %+v, %v", statuses, err)
+	}
+}
+
+func TestMigratePhaseSeparatesPostDeployMigrations(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_phases.bcl"), `
+Migration "001_add_users" {
+  Up {
+    CreateTable "users" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+    }
+  }
+}
+
+Migration "002_backfill_users" {
+  Phase = "post-deploy"
+  Up {
+    CreateTable "user_backfills" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+    }
+  }
+}
+`)
+	migrate := &MigrateCommand{Driver: manager}
+	if err := migrate.Handle(testContext{options: map[string]string{"phase": PhasePreDeploy}}); err != nil {
+		t.Fatalf("migrate --phase=pre-deploy: %v", err)
+	}
+	if err := migrate.Handle(testContext{options: map[string]string{"check": "true", "phase": PhasePreDeploy}}); err != nil {
+		t.Fatalf("pre-deploy migrations still pending: %v", err)
+	}
+	err := migrate.Handle(testContext{options: map[string]string{"check": "true", "phase": PhasePostDeploy}})
+	if !errors.Is(err, ErrPendingMigrations) {
+		t.Fatalf("migrate --check --phase=post-deploy = %v, want the backfill pending", err)
+	}
+	if err := migrate.Handle(testContext{options: map[string]string{"phase": PhasePostDeploy}}); err != nil {
+		t.Fatalf("migrate --phase=post-deploy: %v", err)
+	}
+	statuses, err := manager.MigrationStatuses()
+	if err != nil || len(statuses) != 2 || !statuses[1].Applied || statuses[1].Phase != PhasePostDeploy {
+		t.Fatalf("statuses = %+v, %v", statuses, err)
+	}
+	if err := migrate.Handle(testContext{options: map[string]string{"phase": "during"}}); err == nil {
+		t.Fatal("expected an unknown phase to be rejected")
+	}
+}
+
+func TestAtomicFalseRunsStatementsOutsideTransaction(t *testing.T) {
+	migration := func(header string) string {
+		return header + `
+Migration "001_partial" {
+  Up {
+    CreateTable "kept" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+    }
+    CreateView "broken" {
+      definition = "SELEC nonsense"
+    }
+  }
+}
+`
+	}
+	for _, tc := range []struct {
+		header   string
+		wantKept int
+	}{
+		{header: "", wantKept: 0},
+		{header: "atomic = false\n", wantKept: 1},
+	} {
+		manager := newSQLiteWorkflowManager(t)
+		writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_partial.bcl"), migration(tc.header))
+		migrate := &MigrateCommand{Driver: manager}
+		if err := migrate.Handle(testContext{options: map[string]string{}}); err == nil {
+			t.Fatalf("%q: expected the broken view to fail the migration", tc.header)
+		}
+		var kept int
+		if err := manager.dbDriver.QueryRow(context.Background(), &kept, "SELECT COUNT(*) FROM sqlite_master WHERE name = 'kept'"); err != nil {
+			t.Fatalf("count tables: %v", err)
+		}
+		if kept != tc.wantKept {
+			t.Fatalf("%q: kept tables = %d, want %d", tc.header, kept, tc.wantKept)
+		}
+	}
+}
+
+func TestOperationStepsRunInDeclarationOrder(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_report.bcl"), `
+Migration "001_report" {
+  Up {
+    CreateView "report" {
+      definition = "SELECT 1 AS id"
+    }
+  }
+}
+`)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "002_replace_report.bcl"), `
+Migration "002_replace_report" {
+  Up "drop_old" {
+    DropView "report" {}
+  }
+  Up "create_new" {
+    CreateView "report" {
+      definition = "SELECT 2 AS id"
+    }
+  }
+  Down {
+    DropView "report" {}
+  }
+  Down {
+    CreateView "report" {
+      definition = "SELECT 1 AS id"
+    }
+  }
+}
+`)
+	plan, err := manager.Plan()
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	var planOut strings.Builder
+	WritePlan(&planOut, plan)
+	if !strings.Contains(planOut.String(), "    Up steps: drop_old, create_new\n    Down steps: step 1, step 2\n") {
+		t.Fatalf("plan does not list the steps of 002_replace_report:\n%s", planOut.String())
+	}
+	if _, ok := plan.Steps["001_report"]; ok {
+		t.Fatalf("plan lists steps for a single-block migration: %+v", plan.Steps)
+	}
+
+	var out strings.Builder
+	if err := manager.WriteMigrationSQL(&out, OfflineSQLOptions{Names: []string{"002_replace_report"}}); err != nil {
+		t.Fatalf("WriteMigrationSQL: %v", err)
+	}
+	sql := out.String()
+	dropAt, createAt := strings.Index(sql, "DROP VIEW"), strings.Index(sql, "CREATE VIEW")
+	if !strings.Contains(sql, "-- Step 1: drop_old") || !strings.Contains(sql, "-- Step 2: create_new") || dropAt < 0 || dropAt > createAt {
+		t.Fatalf("SQL does not follow the step order:\n%s", sql)
+	}
+
+	migrate := &MigrateCommand{Driver: manager}
+	if err := migrate.Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	var id int
+	if err := manager.dbDriver.QueryRow(context.Background(), &id, "SELECT id FROM report"); err != nil || id != 2 {
+		t.Fatalf("report id = %d, %v; want the recreated view", id, err)
+	}
+}
+
+func TestApplyMigrationReturnsMigrationError(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_broken_view.bcl"), `
+Migration "001_broken_view" {
+  Up {
+    CreateTable "kept" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+    }
+    CreateView "broken" {
+      definition = "SELEC nonsense"
+    }
+  }
+}
+`)
+	migrate := &MigrateCommand{Driver: manager}
+	err := migrate.Handle(testContext{options: map[string]string{}})
+	var migErr *MigrationError
+	if !errors.As(err, &migErr) {
+		t.Fatalf("migrate error = %v, want a *MigrationError", err)
+	}
+	if migErr.Op != "apply" || migErr.Migration != "001_broken_view" || migErr.Position != 2 || !strings.Contains(migErr.Statement, "SELEC nonsense") {
+		t.Fatalf("MigrationError = %+v", migErr)
+	}
+	var stErr *StatementError
+	if !errors.As(err, &stErr) || stErr.Statement != migErr.Statement {
+		t.Fatalf("StatementError = %+v, want the failed statement", stErr)
+	}
+}
+
+func TestMigrationErrorCarriesRecoveryHint(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		manager := newSQLiteWorkflowManager(t)
+		WithErrorHints(enabled)(manager)
+		if _, err := manager.dbDriver.DB().Exec(`CREATE TABLE "customers" ("id" INTEGER)`); err != nil {
+			t.Fatalf("create customers: %v", err)
+		}
+		writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_customers.bcl"), `
+Migration "001_customers" {
+  Up {
+    CreateTable "customers" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+    }
+  }
+}
+`)
+		cmd := withExitCodes([]contracts.Command{&MigrateCommand{Driver: manager}})[0]
+		err := cmd.Handle(testContext{options: map[string]string{}})
+		var migErr *MigrationError
+		if !errors.As(err, &migErr) {
+			t.Fatalf("migrate error = %v, want a *MigrationError", err)
+		}
+		hasHint := strings.Contains(err.Error(), "hint: the object already exists")
+		if enabled != (migErr.Hint != "") || enabled != hasHint {
+			t.Fatalf("hints %t: Hint = %q, message = %q", enabled, migErr.Hint, err.Error())
+		}
+	}
+	if hint := RecoveryHint(errors.New(`pq: column "email" of relation "users" already exists`)); !strings.Contains(hint, "column already exists") {
+		t.Fatalf("duplicate column hint = %q", hint)
+	}
+	if hint := RecoveryHint(errors.New("Error 1205: Lock wait timeout exceeded")); !strings.Contains(hint, "lock") {
+		t.Fatalf("lock timeout hint = %q", hint)
+	}
+}
+
+func TestCheckReversibilityReportsLeftoverObjects(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_create_notes.bcl"), `
+Migration "001_create_notes" {
+  Version = "1.0.0"
+  Up {
+    CreateTable "notes" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+    }
+  }
+  Down {
+    DropTable "notes" {}
+  }
+}
+`)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "002_create_note_ids.bcl"), `
+Migration "002_create_note_ids" {
+  Version = "1.0.0"
+  Up {
+    CreateView "note_ids" {
+      definition = "SELECT id FROM notes"
+      or_replace = true
+    }
+  }
+  Down {
+    DropView "missing_view" {
+      if_exists = true
+    }
+  }
+}
+`)
+
+	results, err := manager.CheckReversibility()
+	if err != nil {
+		t.Fatalf("CheckReversibility: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("results = %+v, want 2", results)
+	}
+	if !results[0].Reversible() {
+		t.Fatalf("001 should be reversible: %+v", results[0])
+	}
+	if results[1].Reversible() || strings.Join(results[1].Differences, "; ") != "view note_ids was left behind" {
+		t.Fatalf("002 differences = %q, err = %v", results[1].Differences, results[1].Err)
+	}
+	pending, err := manager.PendingMigrations()
+	if err != nil {
+		t.Fatalf("PendingMigrations: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Fatalf("pending after check = %v, want every migration re-applied", pending)
+	}
+
+	var report bytes.Buffer
+	if failed := writeReversibilityReport(&report, results); failed != 1 {
+		t.Fatalf("failed = %d, want 1", failed)
+	}
+	if !strings.Contains(report.String(), "FAIL 002_create_note_ids") || !strings.Contains(report.String(), "ok   001_create_notes") {
+		t.Fatalf("report = %q", report.String())
+	}
+}
+
+func TestCheckFilesReportsEveryBrokenFile(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_create_notes.bcl"), `
+Migration "001_create_notes" {
+  Version = "1.0.0"
+  Up {
+    CreateTable "notes" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+    }
+  }
+  Down {
+    DropTable "notes" {}
+  }
+}
+`)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "002_broken.bcl"), "Migration \"002_broken\" {\n  Version = \"1.0.0\n}\n")
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "003_bad_phase.bcl"), `
+Migration "003_bad_phase" {
+  Version = "1.0.0"
+  Phase = "mid-deploy"
+  Up {
+    DropTable "notes" {}
+  }
+}
+`)
+	writeTestFile(t, filepath.Join(manager.SeedDir(), "notes.bcl"), "Seed \"notes\" {\n  table = \"notes\n}\n")
+
+	checks, err := manager.CheckFiles()
+	if err != nil {
+		t.Fatalf("CheckFiles: %v", err)
+	}
+	var out bytes.Buffer
+	if failed := writeFileChecks(&out, checks); failed != 3 {
+		t.Fatalf("failed = %d, want 3:\n%s", failed, out.String())
+	}
+	report := out.String()
+	for _, want := range []string{
+		"ok   " + filepath.Join(manager.MigrationDir(), "001_create_notes.bcl"),
+		"FAIL " + filepath.Join(manager.MigrationDir(), "002_broken.bcl"),
+		filepath.Join(manager.MigrationDir(), "002_broken.bcl") + ":2:13: unterminated string",
+		`invalid Phase "mid-deploy"`,
+		"FAIL " + filepath.Join(manager.SeedDir(), "notes.bcl"),
+	} {
+		if !strings.Contains(report, want) {
+			t.Fatalf("report missing %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestMigrationBrowserAppliesAndRollsBackSelectedMigrations(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	for i, table := range []string{"notes", "tags"} {
+		name := fmt.Sprintf("%03d_create_%s", i+1, table)
+		writeTestFile(t, filepath.Join(manager.MigrationDir(), name+".bcl"), fmt.Sprintf(`
+Migration "%s" {
+  Version = "1.0.0"
+  Description = "Create %s."
+  Up {
+    CreateTable "%s" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+    }
+  }
+  Down {
+    DropTable "%s" {}
+  }
+}
+`, name, table, table, table))
+	}
+
+	script := strings.Join([]string{
+		"sql 1",
+		"up 2", "n",
+		"up 1", "y",
+		"up 2", "yes",
+		"history",
+		"down 1", "y",
+		"bogus",
+		"quit",
+	}, "\n") + "\n"
+	var out bytes.Buffer
+	if err := newMigrationBrowser(manager, strings.NewReader(script), &out).run(); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	session := out.String()
+	for _, want := range []string{
+		"1  pending  001_create_notes",
+		`CREATE TABLE "notes"`,
+		"Cancelled.",
+		"Applied 001_create_notes.",
+		"Applied 002_create_tags.",
+		"001_create_notes  Create notes.",
+		"Rolling back 001_create_notes also rolls back, newest first:\n  002_create_tags",
+		"Rolled back 2 migration(s).",
+		`error: unknown command "bogus"`,
+	} {
+		if !strings.Contains(session, want) {
+			t.Fatalf("session missing %q:\n%s", want, session)
+		}
+	}
+	assertSQLiteTableExists(t, manager, "notes", false)
+	assertSQLiteTableExists(t, manager, "tags", false)
+}
+
+func TestPlanAnnotatesDestructiveOperationsAndLintErrors(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	path := filepath.Join(manager.MigrationDir(), "001_drop_legacy.bcl")
+	writeTestFile(t, path, `
+Migration "001_drop_legacy" {
+  Version = "1.0.0"
+  Up {
+    DropTable "legacy" {}
+  }
+}
+`)
+	plan, err := manager.Plan()
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if len(plan.Pending) != 1 || plan.ErrorCount() != 1 {
+		t.Fatalf("plan = %+v", plan)
+	}
+
+	var out bytes.Buffer
+	WritePlanGitHub(&out, plan)
+	file := escapeGitHubProperty(filepath.ToSlash(path))
+	for _, want := range []string{
+		"::warning file=" + file + ",line=5,title=migration 001_drop_legacy::drops table legacy and every row in it\n",
+		"::error file=" + file + ",line=2,title=migration 001_drop_legacy::validation error for field 'migration.description'",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("annotations missing %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := WritePlanGitLab(&out, plan); err != nil {
+		t.Fatalf("WritePlanGitLab: %v", err)
+	}
+	var issues []struct {
+		Severity string `json:"severity"`
+		Location struct {
+			Path  string `json:"path"`
+			Lines struct {
+				Begin int `json:"begin"`
+			} `json:"lines"`
+		} `json:"location"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &issues); err != nil {
+		t.Fatalf("code quality report: %v\n%s", err, out.String())
+	}
+	if len(issues) != 2 || issues[0].Severity != "minor" || issues[0].Location.Lines.Begin != 5 || issues[1].Severity != "major" {
+		t.Fatalf("issues = %+v", issues)
+	}
+
+	writeTestFile(t, path, "Migration \"001_drop_legacy\" {\n  Version = \"1.0.0\n}\n")
+	plan, err = manager.Plan()
+	if err != nil {
+		t.Fatalf("Plan after breaking the file: %v", err)
+	}
+	out.Reset()
+	WritePlanGitHub(&out, plan)
+	if want := "::error file=" + file + ",line=2,title=migration::unterminated string\n"; out.String() != want {
+		t.Fatalf("annotations = %q, want %q", out.String(), want)
+	}
+}
+
+func TestReservedWordIdentifiersWarnUnlessStrict(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_create_order.bcl"), `
+Migration "001_create_order" {
+  Version = "1.0.0"
+  Description = "Create order."
+  Up {
+    CreateTable "order" {
+      Field "user" {
+        type = "string"
+        size = 64
+      }
+    }
+  }
+  Down {
+    DropTable "order" {}
+  }
+}
+`)
+	plan, err := manager.Plan()
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if plan.ErrorCount() != 0 || len(plan.Findings) != 3 || !strings.Contains(plan.Findings[0].Message, "reserved SQL keyword") {
+		t.Fatalf("expected reserved word warnings only, got %+v", plan.Findings)
+	}
+
+	manager.strictIdentifiers = true
+	if plan, err = manager.Plan(); err != nil || plan.ErrorCount() != 3 {
+		t.Fatalf("expected reserved word errors in strict mode, got %+v (%v)", plan.Findings, err)
+	}
+
+	manager.strictIdentifiers = false
+	if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate reserved word table: %v", err)
+	}
+	assertSQLiteTableExists(t, manager, "order", true)
+}
+
+func TestIdentifierRulesFollowDialect(t *testing.T) {
+	cases := []struct {
+		dialect, value string
+		valid          bool
+	}{
+		{DialectPostgres, "données", true},
+		{DialectPostgres, "price$usd", true},
+		{DialectPostgres, "$price", false},
+		{DialectPostgres, strings.Repeat("é", 32), false},
+		{DialectMySQL, strings.Repeat("é", 64), true},
+		{DialectSQLite, strings.Repeat("a", 100), true},
+		{DialectSQLite, "price$usd", false},
+		{"", "données", false},
+	}
+	for _, c := range cases {
+		v := NewValidator()
+		v.Rules = IdentifierRulesFor(c.dialect)
+		v.ValidateIdentifier("table", c.value)
+		if v.HasErrors() == c.valid {
+			t.Errorf("%s %q: expected valid=%t, got %v", c.dialect, c.value, c.valid, v.Errors())
+		}
+	}
+
+	v := NewValidator()
+	v.Rules = IdentifierRulesFor(DialectPostgres)
+	v.ValidateIdentifier("table", "UserAccounts")
+	if v.HasErrors() || len(v.Warnings()) != 1 || !strings.Contains(v.Warnings()[0].Message, "case sensitive") {
+		t.Fatalf("expected a case sensitivity warning, got %v %v", v.Errors(), v.Warnings())
+	}
+
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_create_donnees.bcl"), `
+Migration "001_create_donnees" {
+  Version = "1.0.0"
+  Description = "Create données."
+  Up {
+    CreateTable "données" {
+      Field "prix" {
+        type = "integer"
+      }
+    }
+  }
+  Down {
+    DropTable "données" {}
+  }
+}
+`)
+	plan, err := manager.Plan()
+	if err != nil || plan.ErrorCount() != 0 {
+		t.Fatalf("expected unicode identifiers to plan on sqlite, got %+v (%v)", plan, err)
+	}
+	manager.maxIdentifierLen = 5
+	if plan, err = manager.Plan(); err != nil || plan.ErrorCount() != 2 {
+		t.Fatalf("expected max_identifier_length to reject the table name, got %+v (%v)", plan, err)
+	}
+}
+
+func TestNotNullFieldOnPopulatedTableIsBackfilled(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_create_wallets.bcl"), `
+Migration "001_create_wallets" {
+  Version = "1.0.0"
+  Description = "Create wallets."
+  Up {
+    CreateTable "wallets" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+    }
+  }
+  Down {
+    DropTable "wallets" {}
+  }
+}
+`)
+	if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate wallets: %v", err)
+	}
+	if _, err := manager.dbDriver.DB().Exec(`INSERT INTO wallets (id) VALUES (1), (2)`); err != nil {
+		t.Fatalf("insert wallets: %v", err)
+	}
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "002_add_wallet_credits.bcl"), `
+Migration "002_add_wallet_credits" {
+  Version = "1.0.0"
+  Description = "Add credits to wallets."
+  Up {
+    AlterTable "wallets" {
+      AddField "credits" {
+        type = "integer"
+      }
+    }
+  }
+}
+`)
+	WithNotNullStrategy(NotNullError)(manager)
+	plan, err := manager.Plan()
+	if err != nil || plan.ErrorCount() != 1 || !strings.Contains(plan.Findings[0].Message, "NOT NULL column wallets.credits") {
+		t.Fatalf("expected the NOT NULL field to fail plan, got %+v (%v)", plan.Findings, err)
+	}
+
+	WithNotNullStrategy(NotNullBackfill)(manager)
+	manager.migrationBCL = nil
+	if plan, err = manager.Plan(); err != nil || len(plan.Findings) != 0 {
+		t.Fatalf("expected no findings with backfill, got %+v (%v)", plan.Findings, err)
+	}
+	if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate backfilled credits: %v", err)
+	}
+	var credits []int
+	if err := manager.dbDriver.DB().Select(&credits, `SELECT credits FROM wallets ORDER BY id`); err != nil || len(credits) != 2 || credits[0] != 0 {
+		t.Fatalf("expected backfilled credits, got %v (%v)", credits, err)
+	}
+	if _, err := manager.dbDriver.DB().Exec(`INSERT INTO wallets (id) VALUES (3)`); err == nil {
+		t.Fatal("expected credits to be NOT NULL after the backfill")
+	}
+}
+
+func TestNotNullBackfillZeroValuesFollowTheColumnType(t *testing.T) {
+	for _, tc := range []struct {
+		dialect string
+		field   AddField
+		want    string
+	}{
+		{DialectPostgres, AddField{Type: "integer"}, "0"},
+		{DialectPostgres, AddField{Type: "string"}, "''"},
+		{DialectPostgres, AddField{Type: "jsonb"}, "'{}'"},
+		{DialectSQLite, AddField{Type: "json"}, "'{}'"},
+		{DialectMySQL, AddField{Type: "boolean"}, "0"},
+		{DialectPostgres, AddField{Type: "boolean"}, "false"},
+		{DialectPostgres, AddField{Type: "date"}, "CURRENT_DATE"},
+		{DialectMySQL, AddField{Type: "datetime"}, "CURRENT_TIMESTAMP"},
+		{DialectMySQL, AddField{RawType: "int unsigned"}, "0"},
+		{DialectPostgres, AddField{Type: "uuid"}, ""},
+		{DialectSQLite, AddField{Type: "uuid"}, ""},
+		{DialectPostgres, AddField{Type: "enum"}, ""},
+		{DialectPostgres, AddField{RawType: "interval"}, ""},
+		{DialectPostgres, AddField{RawType: "point"}, ""},
+		{DialectPostgres, AddField{RawType: "bytea"}, ""},
+		{DialectPostgres, AddField{RawType: "mood"}, ""},
+	} {
+		got, ok := zeroBackfill(tc.dialect, tc.field)
+		if got != tc.want || ok != (tc.want != "") {
+			t.Errorf("zeroBackfill(%s, %+v) = %q, %t; want %q", tc.dialect, tc.field, got, ok, tc.want)
+		}
+	}
+
+	manager := newSQLiteWorkflowManager(t)
+	WithNotNullStrategy(NotNullBackfill)(manager)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_create_devices.bcl"), `
+Migration "001_create_devices" {
+  Version = "1.0.0"
+  Description = "Create devices."
+  Up {
+    CreateTable "devices" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+    }
+  }
+}
+`)
+	if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate devices: %v", err)
+	}
+	if _, err := manager.dbDriver.DB().Exec(`INSERT INTO devices (id) VALUES (1)`); err != nil {
+		t.Fatalf("insert devices: %v", err)
+	}
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "002_add_device_fields.bcl"), `
+Migration "002_add_device_fields" {
+  Version = "1.0.0"
+  Description = "Add device settings."
+  Up {
+    AlterTable "devices" {
+      AddField "settings" {
+        type = "json"
+      }
+      AddField "serial" {
+        raw_type = "uuid"
+      }
+    }
+  }
+}
+`)
+	plan, err := manager.Plan()
+	if err != nil || plan.ErrorCount() != 1 || !strings.Contains(plan.Findings[0].Message, "devices.serial of type uuid, which has no zero value") {
+		t.Fatalf("expected the uuid field to fail plan, got %+v (%v)", plan.Findings, err)
+	}
+
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "002_add_device_fields.bcl"), `
+Migration "002_add_device_fields" {
+  Version = "1.0.0"
+  Description = "Add device settings."
+  Up {
+    AlterTable "devices" {
+      AddField "settings" {
+        type = "json"
+      }
+    }
+  }
+}
+`)
+	manager.migrationBCL = nil
+	if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate backfilled settings: %v", err)
+	}
+	var settings string
+	if err := manager.dbDriver.QueryRow(context.Background(), &settings, `SELECT settings FROM devices WHERE id = 1`); err != nil || settings != "{}" {
+		t.Fatalf("settings = %q (%v), want {}", settings, err)
+	}
+}
+
+func TestSQLiteTableRecreationKeepsForeignKeysAndIndexes(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_create_pets.bcl"), `
+Migration "001_create_pets" {
+  Version = "1.0.0"
+  Description = "Create owners and pets."
+  Up {
+    CreateTable "owners" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+    }
+    CreateTable "pets" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+      Field "tag" {
+        type = "string"
+        size = 32
+        unique = true
+      }
+      Field "nickname" {
+        type = "string"
+        size = 32
+        nullable = true
+      }
+      Field "owner_id" {
+        type = "integer"
+        foreign_key = {
+          reference_table = "owners"
+          reference_field = "id"
+          on_delete = "CASCADE"
+        }
+      }
+    }
+  }
+  Down {
+    DropTable "pets" {}
+    DropTable "owners" {}
+  }
+}
+`)
+	if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate pets: %v", err)
+	}
+	db := manager.dbDriver.DB()
+	if _, err := db.Exec(`INSERT INTO owners (id) VALUES (1); INSERT INTO pets (id, tag, nickname, owner_id) VALUES (1, 'a1', 'rex', 1)`); err != nil {
+		t.Fatalf("insert pets: %v", err)
+	}
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "002_alter_pets.bcl"), `
+Migration "002_alter_pets" {
+  Version = "1.0.0"
+  Description = "Drop nicknames and add a breeder."
+  Up {
+    AlterTable "pets" {
+      DropField "nickname" {}
+    }
+    AlterTable "pets" {
+      AddField "breeder_id" {
+        type = "integer"
+        nullable = true
+        foreign_key = {
+          reference_table = "owners"
+          reference_field = "id"
+        }
+      }
+    }
+  }
+}
+`)
+	if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate altered pets: %v", err)
+	}
+	var fkColumns []string
+	if err := db.Select(&fkColumns, `SELECT "from" FROM pragma_foreign_key_list('pets') ORDER BY "from"`); err != nil || strings.Join(fkColumns, ",") != "breeder_id,owner_id" {
+		t.Fatalf("expected both foreign keys after recreation, got %v (%v)", fkColumns, err)
+	}
+	var unique int
+	if err := db.Select(&unique, `SELECT COUNT(*) FROM pragma_index_list('pets') WHERE name = 'uniq_pets_tag' AND "unique" = 1`); err != nil || unique != 1 {
+		t.Fatalf("expected the unique index after recreation, got %d (%v)", unique, err)
+	}
+	var tags []string
+	if err := db.Select(&tags, `SELECT tag FROM pets`); err != nil || len(tags) != 1 || tags[0] != "a1" {
+		t.Fatalf("expected the rows to be copied, got %v (%v)", tags, err)
+	}
+}
+
+func TestSQLiteTableRecreationKeepsIndexesAndTriggers(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_create_notes.bcl"), `
+Migration "001_create_notes" {
+  Version = "1.0.0"
+  Description = "Create notes."
+  Up {
+    CreateTable "notes" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+      Field "title" {
+        type = "string"
+        size = 64
+      }
+      Field "draft" {
+        type = "string"
+        size = 64
+        nullable = true
+      }
+      Field "updated" {
+        type = "integer"
+        nullable = true
+      }
+    }
+  }
+  Down {
+    DropTable "notes" {}
+  }
+}
+`)
+	if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate notes: %v", err)
+	}
+	db := manager.dbDriver.DB()
+	for _, q := range []string{
+		`CREATE INDEX notes_title_lower ON notes (lower(title))`,
+		`CREATE INDEX notes_draft ON notes (draft)`,
+		`CREATE TRIGGER notes_touch AFTER UPDATE OF title ON notes BEGIN UPDATE notes SET updated = 1 WHERE id = NEW.id; END`,
+	} {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatalf("%s: %v", q, err)
+		}
+	}
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "002_drop_note_drafts.bcl"), `
+Migration "002_drop_note_drafts" {
+  Version = "1.0.0"
+  Description = "Drop drafts."
+  Up {
+    AlterTable "notes" {
+      DropField "draft" {}
+    }
+  }
+}
+`)
+	if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate dropped drafts: %v", err)
+	}
+	var names []string
+	if err := db.Select(&names, `SELECT name FROM sqlite_master WHERE tbl_name = 'notes' AND type IN ('index', 'trigger') ORDER BY name`); err != nil {
+		t.Fatalf("list notes objects: %v", err)
+	}
+	if got := strings.Join(names, ","); got != "notes_title_lower,notes_touch" {
+		t.Fatalf("expected the index and trigger to survive recreation, got %s", got)
+	}
+	if _, err := db.Exec(`INSERT INTO notes (id, title) VALUES (1, 'a'); UPDATE notes SET title = 'b' WHERE id = 1`); err != nil {
+		t.Fatalf("update notes: %v", err)
+	}
+	var updated int
+	if err := db.Select(&updated, `SELECT updated FROM notes WHERE id = 1`); err != nil || updated != 1 {
+		t.Fatalf("expected the trigger to fire, got %d (%v)", updated, err)
+	}
+}
+
+func TestAlterTableChangesPrimaryKey(t *testing.T) {
+	at := AlterTable{
+		Name:           "memberships",
+		DropPrimaryKey: []DropPrimaryKey{{}},
+		AddPrimaryKey:  []AddPrimaryKey{{Fields: []string{"user_id", "group_id"}}},
+	}
+	for dialect, want := range map[string][]string{
+		DialectPostgres: {
+			`ALTER TABLE "memberships" DROP CONSTRAINT "memberships_pkey";`,
+			`ALTER TABLE "memberships" ADD CONSTRAINT "memberships_pkey" PRIMARY KEY ("user_id", "group_id");`,
+		},
+		DialectMySQL: {
+			"ALTER TABLE `memberships` DROP PRIMARY KEY;",
+			"ALTER TABLE `memberships` ADD PRIMARY KEY (`user_id`, `group_id`);",
+		},
+	} {
+		got, err := at.ToSQL(dialect)
+		if err != nil || strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Fatalf("%s: got %q (%v), want %q", dialect, got, err, want)
+		}
+	}
+
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_create_memberships.bcl"), `
+Migration "001_create_memberships" {
+  Version = "1.0.0"
+  Description = "Create memberships."
+  Up {
+    CreateTable "memberships" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+      Field "user_id" {
+        type = "integer"
+      }
+      Field "group_id" {
+        type = "integer"
+      }
+    }
+  }
+  Down {
+    DropTable "memberships" {}
+  }
+}
+`)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "002_key_memberships.bcl"), `
+Migration "002_key_memberships" {
+  Version = "1.0.0"
+  Description = "Key memberships by user and group."
+  Up {
+    AlterTable "memberships" {
+      DropPrimaryKey {}
+      AddPrimaryKey {
+        fields = ["user_id", "group_id"]
+      }
+    }
+  }
+}
+`)
+	if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate memberships: %v", err)
+	}
+	var keys []string
+	if err := manager.dbDriver.DB().Select(&keys, `SELECT name FROM pragma_table_info('memberships') WHERE pk > 0 ORDER BY pk`); err != nil || strings.Join(keys, ",") != "user_id,group_id" {
+		t.Fatalf("expected the new primary key, got %v (%v)", keys, err)
+	}
+}
+
+func TestRenameFieldChangesType(t *testing.T) {
+	rename := RenameField{From: "qty", To: "quantity", Type: "bigint"}
+	got, err := rename.ToSQL(DialectPostgres, "items")
+	want := `ALTER TABLE "items" RENAME COLUMN "qty" TO "quantity"; ALTER TABLE "items" ALTER COLUMN "quantity" TYPE BIGINT USING "quantity"::BIGINT;`
+	if err != nil || got != want {
+		t.Fatalf("postgres: got %q (%v), want %q", got, err, want)
+	}
+
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_create_items.bcl"), `
+Migration "001_create_items" {
+  Version = "1.0.0"
+  Description = "Create items."
+  Up {
+    CreateTable "items" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+      Field "qty" {
+        type = "integer"
+      }
+    }
+  }
+  Down {
+    DropTable "items" {}
+  }
+}
+`)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "002_rename_qty.bcl"), `
+Migration "002_rename_qty" {
+  Version = "1.0.0"
+  Description = "Rename qty."
+  Up {
+    AlterTable "items" {
+      RenameField "qty" {
+        from = "qty"
+        to = "quantity"
+      }
+    }
+  }
+}
+`)
+	manager.dialect = DialectMySQL
+	plan, err := manager.Plan()
+	if err != nil || plan.ErrorCount() != 1 || !strings.Contains(plan.Findings[len(plan.Findings)-1].Message, "without a type") {
+		t.Fatalf("expected the untyped MySQL rename to fail plan, got %+v (%v)", plan.Findings, err)
+	}
+	manager.dialect = DialectSQLite
+
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "002_rename_qty.bcl"), `
+Migration "002_rename_qty" {
+  Version = "1.0.0"
+  Description = "Rename qty."
+  Up {
+    AlterTable "items" {
+      RenameField "qty" {
+        from = "qty"
+        to = "quantity"
+        type = "text"
+      }
+    }
+  }
+}
+`)
+	if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate items: %v", err)
+	}
+	var columnType string
+	if err := manager.dbDriver.DB().Select(&columnType, `SELECT type FROM pragma_table_info('items') WHERE name = 'quantity'`); err != nil || columnType != "TEXT" {
+		t.Fatalf("expected quantity to become TEXT, got %q (%v)", columnType, err)
+	}
+}
+
+func TestPlanWarnsAboutTypesDegradedByDialect(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_create_events.bcl"), `
+Migration "001_create_events" {
+  Version = "1.0.0"
+  Description = "Create events."
+  Up {
+    CreateTable "events" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+      Field "payload" {
+        type = "json"
+      }
+      Field "kind" {
+        type = "enum"
+      }
+    }
+  }
+  Down {
+    DropTable "events" {}
+  }
+}
+`)
+	plan, err := manager.Plan()
+	if err != nil || plan.ErrorCount() != 0 || len(plan.Findings) != 2 {
+		t.Fatalf("expected two type warnings, got %+v (%v)", plan.Findings, err)
+	}
+	if f := plan.Findings[0]; !strings.Contains(f.Message, "events.payload of type json is stored as TEXT on SQLite") || f.Line != 11 {
+		t.Fatalf("expected the json field warning at its block, got %+v", f)
+	}
+
+	manager.dialect = DialectPostgres
+	if plan, err = manager.Plan(); err != nil || len(plan.Findings) != 1 || !strings.Contains(plan.Findings[0].Message, "enum is stored as TEXT on PostgreSQL") {
+		t.Fatalf("expected only the enum warning on postgres, got %+v (%v)", plan.Findings, err)
+	}
+}
+
+func TestRawTypeIsEmittedVerbatim(t *testing.T) {
+	field := AddField{Name: "search", RawType: "tsvector", Nullable: true}
+	got, err := field.ToSQL(DialectPostgres, "docs")
+	if err != nil || len(got) != 1 || got[0] != `ALTER TABLE "docs" ADD COLUMN "search" tsvector DEFAULT NULL;` {
+		t.Fatalf("postgres: got %q (%v)", got, err)
+	}
+
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_create_places.bcl"), `
+Migration "001_create_places" {
+  Version = "1.0.0"
+  Description = "Create places."
+  Up {
+    CreateTable "places" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+      Field "label" {
+        raw_type = "citext"
+        nullable = true
+      }
+    }
+  }
+  Down {
+    DropTable "places" {}
+  }
+}
+`)
+	if plan, err := manager.Plan(); err != nil || len(plan.Findings) != 0 {
+		t.Fatalf("expected a raw type to pass plan, got %+v (%v)", plan.Findings, err)
+	}
+	if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate places: %v", err)
+	}
+	var columnType string
+	if err := manager.dbDriver.DB().Select(&columnType, `SELECT type FROM pragma_table_info('places') WHERE name = 'label'`); err != nil || columnType != "citext" {
+		t.Fatalf("expected label to keep its raw type, got %q (%v)", columnType, err)
+	}
+}
+
+func TestSeedExpressionsLookUpExistingRows(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	if err := manager.dbDriver.ApplySQL([]string{
+		`CREATE TABLE teams (id INTEGER PRIMARY KEY, name TEXT NOT NULL);`,
+		`CREATE TABLE players (name TEXT NOT NULL, team_id INTEGER);`,
+		`INSERT INTO teams (id, name) VALUES (3, 'red'), (7, 'blue');`,
+	}); err != nil {
+		t.Fatalf("create tables: %v", err)
+	}
+	seedFile := filepath.Join(manager.SeedDir(), "players.bcl")
+	writeTestFile(t, seedFile, `
+Seed "players" {
+  table = "players"
+  Field "name" {
+    value = "fake_name"
+  }
+  Field "team_id" {
+    value = "expr: sql('SELECT MAX(id) FROM teams')"
+  }
+  rows = 2
+}
+`)
+	if err := manager.RunSeeds(false, false, seedFile); err == nil || !strings.Contains(err.Error(), "seed.lookup_tables") {
+		t.Fatalf("expected sql() to be disabled without lookup tables, got %v", err)
+	}
+
+	WithSeedLookupTables("teams")(manager)
+	if err := manager.RunSeeds(false, false, seedFile); err != nil {
+		t.Fatalf("RunSeeds: %v", err)
+	}
+	var teams []int64
+	if err := manager.dbDriver.DB().Select(&teams, `SELECT team_id FROM players`); err != nil || len(teams) != 2 || teams[0] != 7 || teams[1] != 7 {
+		t.Fatalf("expected both players in team 7, got %v (%v)", teams, err)
+	}
+
+	for _, query := range []string{
+		"SELECT name FROM players",
+		"SELECT t.id FROM teams t JOIN players p ON p.team_id = t.id",
+		"SELECT (TABLE players LIMIT 1)",
+		"SELECT id FROM other.teams",
+		`SELECT id FROM "other"."teams"`,
+	} {
+		if err := checkLookupSQL(query, []string{"teams"}); err == nil {
+			t.Errorf("checkLookupSQL accepted %q", query)
+		}
+	}
+	for _, query := range []string{
+		"WITH top AS (SELECT id FROM teams) SELECT id FROM top",
+		"SELECT id FROM main.teams",
+	} {
+		if err := checkLookupSQL(query, []string{"teams", "main.teams"}); err != nil {
+			t.Errorf("checkLookupSQL rejected %q: %v", query, err)
+		}
+	}
+
+	// Statements that change data only pass the table check; the lookup
+	// runs read-only and fails.
+	lookup := manager.newSeedLookup(false)
+	for _, query := range []string{
+		"DELETE FROM teams RETURNING id",
+		"UPDATE teams SET name = 'green' RETURNING id",
+		"INSERT INTO teams (id, name) VALUES (9, 'green') RETURNING id",
+	} {
+		if _, err := lookup.call(query); err == nil {
+			t.Errorf("sql(%q) succeeded", query)
+		}
+	}
+	var count int
+	if err := manager.dbDriver.QueryRow(context.Background(), &count, "SELECT COUNT(*) FROM teams WHERE name IN ('red', 'blue')"); err != nil || count != 2 {
+		t.Fatalf("teams changed by lookups: %d rows left (%v)", count, err)
+	}
+	// The connection used by the lookups is writable again.
+	if err := manager.dbDriver.ApplySQL([]string{"INSERT INTO teams (id, name) VALUES (9, 'green');"}); err != nil {
+		t.Fatalf("write after lookups: %v", err)
+	}
+}
+
+func TestSeedValuesBindNullsAndDates(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	if err := manager.dbDriver.ApplySQL([]string{
+		`CREATE TABLE visits (name TEXT NOT NULL, born DATE, seen_at DATETIME, left_at DATETIME);`,
+	}); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	seedFile := filepath.Join(manager.SeedDir(), "visits.bcl")
+	writeTestFile(t, seedFile, `
+Seed "visits" {
+  table = "visits"
+  Field "name" {
+    value = "fake_name"
+  }
+  Field "born" {
+    value = "fake_date"
+    data_type = "date"
+  }
+  Field "seen_at" {
+    value = "2024-05-06T07:08:09Z"
+    data_type = "datetime"
+  }
+  Field "left_at" {
+    value = "expr: name.value == '' ? seen_at.value : nil"
+    data_type = "datetime"
+  }
+  rows = 1
+}
+`)
+	if err := manager.RunSeeds(false, false, seedFile); err != nil {
+		t.Fatalf("RunSeeds: %v", err)
+	}
+	var row struct {
+		Born   string  `db:"born"`
+		SeenAt string  `db:"seen_at"`
+		LeftAt *string `db:"left_at"`
+	}
+	if err := manager.dbDriver.DB().Get(&row, `SELECT born || '' AS born, seen_at || '' AS seen_at, left_at FROM visits`); err != nil {
+		t.Fatalf("read visit: %v", err)
+	}
+	if _, err := time.Parse(time.DateOnly, row.Born); err != nil {
+		t.Errorf("born = %q, want a date", row.Born)
+	}
+	if row.SeenAt != "2024-05-06 07:08:09" {
+		t.Errorf("seen_at = %q, want 2024-05-06 07:08:09", row.SeenAt)
+	}
+	if row.LeftAt != nil {
+		t.Errorf("left_at = %q, want NULL", *row.LeftAt)
+	}
+
+	if got := convertSeedValue("2024-05-06", "date", DialectPostgres); got != time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC) {
+		t.Errorf("postgres date = %#v, want a time.Time", got)
+	}
+	if got := convertSeedValue(nil, "int", DialectMySQL); got != nil {
+		t.Errorf("nil = %#v, want nil", got)
+	}
+}
+
+func TestUniqueSeedFieldsSkipExistingValues(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	if err := manager.dbDriver.ApplySQL([]string{
+		`CREATE TABLE flags (v TEXT NOT NULL UNIQUE);`,
+		`INSERT INTO flags (v) VALUES ('true');`,
+	}); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	seedFile := filepath.Join(manager.SeedDir(), "flags.bcl")
+	writeTestFile(t, seedFile, `
+Seed "flags" {
+  table = "flags"
+  Field "v" {
+    value = "fake_bool"
+    unique = true
+  }
+  rows = 1
+}
+`)
+	if err := manager.RunSeeds(false, false, seedFile); err != nil {
+		t.Fatalf("RunSeeds: %v", err)
+	}
+	var values []string
+	if err := manager.dbDriver.DB().Select(&values, `SELECT v FROM flags ORDER BY v`); err != nil || len(values) != 2 || values[0] != "false" {
+		t.Fatalf("expected the seed to add false, got %v (%v)", values, err)
+	}
+	if err := manager.RunSeeds(false, false, seedFile); err == nil || !strings.Contains(err.Error(), "could not generate unique value") {
+		t.Fatalf("expected no unique value to be left, got %v", err)
+	}
+}
+
+func TestTruncatingSeedsEmptiesReferencingTablesFirst(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	if err := manager.dbDriver.ApplySQL([]string{
+		`CREATE TABLE teams (id INTEGER PRIMARY KEY, name TEXT NOT NULL);`,
+		`CREATE TABLE players (name TEXT NOT NULL, team_id INTEGER NOT NULL REFERENCES teams (id));`,
+	}); err != nil {
+		t.Fatalf("create tables: %v", err)
+	}
+	order, err := manager.truncateOrder([]string{"teams", "players"})
+	if err != nil || !slices.Equal(order, []string{"players", "teams"}) {
+		t.Fatalf("truncate order = %v (%v), want players before teams", order, err)
+	}
+
+	seedFile := filepath.Join(manager.SeedDir(), "league.bcl")
+	writeTestFile(t, seedFile, `
+Seed "teams" {
+  table = "teams"
+  Field "id" {
+    value = 1
+  }
+  Field "name" {
+    value = "fake_company"
+  }
+  rows = 1
+}
+Seed "players" {
+  table = "players"
+  Field "name" {
+    value = "fake_name"
+  }
+  Field "team_id" {
+    value = 1
+  }
+  rows = 2
+}
+`)
+	for run := 1; run <= 2; run++ {
+		if err := manager.RunSeeds(true, false, seedFile); err != nil {
+			t.Fatalf("truncating RunSeeds #%d: %v", run, err)
+		}
+	}
+	var players int
+	if err := manager.dbDriver.DB().Get(&players, `SELECT COUNT(*) FROM players`); err != nil || players != 2 {
+		t.Fatalf("players = %d (%v), want 2", players, err)
+	}
+}
+
+func TestSeedCommandFiltersTablesAndOverridesRows(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	if err := manager.dbDriver.ApplySQL([]string{
+		`CREATE TABLE teams (name TEXT NOT NULL);`,
+		`CREATE TABLE players (name TEXT NOT NULL);`,
+	}); err != nil {
+		t.Fatalf("create tables: %v", err)
+	}
+	for _, table := range []string{"teams", "players"} {
+		writeTestFile(t, filepath.Join(manager.SeedDir(), table+".bcl"), fmt.Sprintf(`
+Seed "%[1]s" {
+  table = "%[1]s"
+  Field "name" {
+    value = "fake_name"
+  }
+  rows = 2
+}
+`, table))
+	}
+	seed := &SeedCommand{Driver: manager}
+	if err := seed.Handle(testContext{options: map[string]string{"table": "players", "rows": "5"}}); err != nil {
+		t.Fatalf("db:seed --table=players --rows=5: %v", err)
+	}
+	var teams, players int
+	if err := manager.dbDriver.DB().Get(&teams, `SELECT COUNT(*) FROM teams`); err != nil || teams != 0 {
+		t.Fatalf("teams = %d (%v), want 0", teams, err)
+	}
+	if err := manager.dbDriver.DB().Get(&players, `SELECT COUNT(*) FROM players`); err != nil || players != 5 {
+		t.Fatalf("players = %d (%v), want 5", players, err)
+	}
+	if err := seed.Handle(testContext{options: map[string]string{"table": "coaches"}}); err == nil || !strings.Contains(err.Error(), "no seed targets table coaches") {
+		t.Fatalf("expected an unknown table to fail, got %v", err)
+	}
+	if err := seed.Handle(testContext{options: map[string]string{"rows": "0"}}); err == nil {
+		t.Fatal("expected --rows=0 to be rejected")
+	}
+}
+
+func TestMakeSeedFromTableMasksSampledRows(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	if err := manager.dbDriver.ApplySQL([]string{
+		`CREATE TABLE members (id INTEGER PRIMARY KEY, name TEXT, email TEXT, nickname TEXT, score REAL, note TEXT);`,
+		`INSERT INTO members (id, name, email, nickname, score, note) VALUES
+			(1, 'Ann "A" O''Hara', 'ann@example.com', 'annie', 1.5, NULL),
+			(2, 'Bob', 'bob@example.com', 'bobby', 2, 'line
+break'),
+			(3, 'Cy', 'cy@example.com', 'cy', 3, NULL);`,
+	}); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	err := (&MakeSeedCommand{Driver: manager}).Handle(testContext{options: map[string]string{"from-db": "members", "limit": "2", "mask": "nickname"}})
+	if err != nil {
+		t.Fatalf("make:seed --from-db: %v", err)
+	}
+	files, err := filepath.Glob(filepath.Join(manager.SeedDir(), "*_members.bcl"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one sampled seed file, got %v (%v)", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, leaked := range []string{"ann@example.com", "annie"} {
+		if strings.Contains(string(data), leaked) {
+			t.Fatalf("seed file leaks %q:\n%s", leaked, data)
+		}
+	}
+	seeds, err := ParseSeedsBCL(data)
+	if err != nil || len(seeds) != 2 {
+		t.Fatalf("expected two parsable seeds, got %d (%v):\n%s", len(seeds), err, data)
+	}
+
+	if err := manager.dbDriver.ApplySQL([]string{`DELETE FROM members;`}); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.RunSeeds(false, false, files[0]); err != nil {
+		t.Fatalf("RunSeeds: %v", err)
+	}
+	var names []string
+	if err := manager.dbDriver.DB().Select(&names, `SELECT name FROM members ORDER BY id`); err != nil || !slices.Equal(names, []string{`Ann "A" O'Hara`, "Bob"}) {
+		t.Fatalf("names = %q (%v)", names, err)
+	}
+	var note *string
+	if err := manager.dbDriver.DB().Get(&note, `SELECT note FROM members WHERE id = 1`); err != nil || note != nil {
+		t.Fatalf("expected the NULL note to be kept, got %v (%v)", note, err)
+	}
+	var email string
+	if err := manager.dbDriver.DB().Get(&email, `SELECT email FROM members WHERE id = 1`); err != nil || email == "ann@example.com" || !strings.Contains(email, "@") {
+		t.Fatalf("expected a fake email, got %q (%v)", email, err)
+	}
+}
+
+func TestSeedsDrawValuesFromTableProfile(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	queries := []string{`CREATE TABLE orders (id INTEGER PRIMARY KEY, customer TEXT, status TEXT, amount INTEGER, note TEXT);`}
+	for i := 1; i <= 20; i++ {
+		status, note := "paid", "NULL"
+		if i%4 == 0 {
+			status = "refunded"
+		}
+		if i%2 == 0 {
+			note = fmt.Sprintf("'note %d'", i)
+		}
+		queries = append(queries, fmt.Sprintf(`INSERT INTO orders (id, customer, status, amount, note) VALUES (%d, 'customer_%02d', '%s', %d, %s);`, i, i, status, 9+i, note))
+	}
+	if err := manager.dbDriver.ApplySQL(queries); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	if err := (&ProfileCommand{Driver: manager}).Handle(testContext{args: []string{"orders"}}); err != nil {
+		t.Fatalf("db:profile: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(manager.SeedDir(), "profiles", "orders.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "customer_") || strings.Contains(string(data), "note 2") {
+		t.Fatalf("profile leaks column values:\n%s", data)
+	}
+	if !strings.Contains(string(data), `"refunded"`) {
+		t.Fatalf("expected the status distribution in the profile:\n%s", data)
+	}
+
+	if err := manager.dbDriver.ApplySQL([]string{`DELETE FROM orders;`}); err != nil {
+		t.Fatal(err)
+	}
+	seed := filepath.Join(manager.SeedDir(), "orders.bcl")
+	writeTestFile(t, seed, `
+Seed "orders" {
+    table = "orders"
+    Field "customer" {
+        value = "profile"
+    }
+    Field "status" {
+        value = "profile"
+    }
+    Field "amount" {
+        value = "profile"
+    }
+    Field "note" {
+        value = "profile"
+    }
+    rows = 50
+}
+`)
+	if err := manager.RunSeeds(false, false, seed); err != nil {
+		t.Fatalf("RunSeeds: %v", err)
+	}
+	var statuses []string
+	if err := manager.dbDriver.DB().Select(&statuses, `SELECT DISTINCT status FROM orders ORDER BY status`); err != nil || !slices.Equal(statuses, []string{"paid", "refunded"}) {
+		t.Fatalf("statuses = %q (%v)", statuses, err)
+	}
+	var low, high, nulls, leaked int
+	if err := manager.dbDriver.DB().Get(&low, `SELECT MIN(amount) FROM orders`); err != nil || low < 10 {
+		t.Fatalf("min amount = %d (%v)", low, err)
+	}
+	if err := manager.dbDriver.DB().Get(&high, `SELECT MAX(amount) FROM orders`); err != nil || high > 29 {
+		t.Fatalf("max amount = %d (%v)", high, err)
+	}
+	if err := manager.dbDriver.DB().Get(&nulls, `SELECT COUNT(*) FROM orders WHERE note IS NULL`); err != nil || nulls == 0 || nulls == 50 {
+		t.Fatalf("expected some NULL notes, got %d of 50 (%v)", nulls, err)
+	}
+	if err := manager.dbDriver.DB().Get(&leaked, `SELECT COUNT(*) FROM orders WHERE customer LIKE 'customer_%' OR note LIKE 'note %'`); err != nil || leaked != 0 {
+		t.Fatalf("expected no profiled values, got %d (%v)", leaked, err)
+	}
+}
+
+func TestBenchDataFillsMigrationSetInForeignKeyOrder(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "shop", "001_create_shop.bcl"), `
+Migration "001_create_shop" {
+  Version = "1.0.0"
+  Description = "Create orders before the customers they reference."
+  Up {
+    CreateTable "orders" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+      Field "customer_id" {
+        type = "integer"
+        foreign_key = {
+          reference_table = "customers"
+          reference_field = "id"
+        }
+      }
+      Field "total" {
+        type = "decimal"
+      }
+      Field "placed_at" {
+        type = "datetime"
+      }
+    }
+    CreateTable "customers" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+      Field "email" {
+        type = "string"
+        unique = true
+      }
+    }
+  }
+  Down {
+    DropTable "orders" {}
+    DropTable "customers" {}
+  }
+}
+`)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "shop", "002_alter_customers.bcl"), `
+Migration "002_alter_customers" {
+  Version = "1.0.0"
+  Description = "Add a nickname to customers."
+  Up {
+    AlterTable "customers" {
+      AddField "nickname" {
+        type = "string"
+        nullable = true
+      }
+    }
+  }
+  Down {
+    AlterTable "customers" {
+      DropField "nickname" {}
+    }
+  }
+}
+`)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "003_create_audit.bcl"), `
+Migration "003_create_audit" {
+  Version = "1.0.0"
+  Description = "Create the audit table outside the shop set."
+  Up {
+    CreateTable "audit" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+      Field "event" {
+        type = "string"
+      }
+    }
+  }
+  Down {
+    DropTable "audit" {}
+  }
+}
+`)
+	if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := (&BenchDataCommand{Driver: manager}).Handle(testContext{options: map[string]string{"schema": "shop", "scale": "7", "workers": "3"}}); err != nil {
+		t.Fatalf("db:bench-data: %v", err)
+	}
+	db := manager.dbDriver.DB()
+	for query, want := range map[string]int{
+		`SELECT COUNT(*) FROM customers`:                                                  700,
+		`SELECT COUNT(DISTINCT email) FROM customers`:                                     700,
+		`SELECT COUNT(*) FROM customers WHERE nickname IS NOT NULL`:                       700,
+		`SELECT COUNT(*) FROM orders`:                                                     700,
+		`SELECT COUNT(*) FROM orders WHERE customer_id NOT IN (SELECT id FROM customers)`: 0,
+		`SELECT COUNT(*) FROM audit`:                                                      0,
+	} {
+		var got int
+		if err := db.Get(&got, query); err != nil || got != want {
+			t.Fatalf("%s = %d, want %d (%v)", query, got, want, err)
+		}
+	}
+	err := (&BenchDataCommand{Driver: manager}).Handle(testContext{options: map[string]string{"schema": "missing"}})
+	if err == nil || !strings.Contains(err.Error(), "migration set missing") {
+		t.Fatalf("expected an unknown migration set to fail, got %v", err)
+	}
+}
+
+func TestParallelSeedsKeepRelatedTablesOrdered(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	if err := manager.dbDriver.ApplySQL([]string{
+		`CREATE TABLE teams (id INTEGER PRIMARY KEY, name TEXT);`,
+		`CREATE TABLE players (id INTEGER PRIMARY KEY, team_id INTEGER NOT NULL REFERENCES teams(id), name TEXT);`,
+		`CREATE TABLE tags (id INTEGER PRIMARY KEY, label TEXT);`,
+	}); err != nil {
+		t.Fatalf("create tables: %v", err)
+	}
+	files := map[string]string{
+		"001_teams.bcl": `
+Seed "teams" {
+    table = "teams"
+    Field "name" {
+        value = "fake_name"
+    }
+    rows = 5
+}
+`,
+		"002_players.bcl": `
+Seed "players" {
+    table = "players"
+    Field "team_id" {
+        value = "ref:teams.id"
+    }
+    Field "name" {
+        value = "fake_name"
+    }
+    rows = 40
+}
+`,
+		"003_tags.bcl": `
+Seed "tags" {
+    table = "tags"
+    Field "label" {
+        value = "fake_string"
+    }
+    rows = 10
+}
+`,
+		"004_players_fk.bcl": `
+Seed "more_players" {
+    table = "players"
+    Field "team_id" {
+        value = 1
+    }
+    rows = 2
+}
+`,
+	}
+	var paths []string
+	for _, name := range []string{"001_teams.bcl", "002_players.bcl", "003_tags.bcl", "004_players_fk.bcl"} {
+		path := filepath.Join(manager.SeedDir(), name)
+		writeTestFile(t, path, files[name])
+		paths = append(paths, path)
+	}
+	var tables []seedFileTables
+	for _, path := range paths {
+		tables = append(tables, manager.seedTables(path, false))
+	}
+	deps, err := manager.seedDependencies(tables)
+	if err != nil {
+		t.Fatalf("seedDependencies: %v", err)
+	}
+	if !slices.Equal(deps[1], []int{0}) || len(deps[2]) != 0 || !slices.Equal(deps[3], []int{0, 1}) {
+		t.Fatalf("dependencies = %v, want players after teams, tags alone, and the second players file after both", deps)
+	}
+
+	if err := (&SeedCommand{Driver: manager}).Handle(testContext{options: map[string]string{"jobs": "3"}}); err != nil {
+		t.Fatalf("db:seed --jobs=3: %v", err)
+	}
+	db := manager.dbDriver.DB()
+	for query, want := range map[string]int{
+		`SELECT COUNT(*) FROM teams`:   5,
+		`SELECT COUNT(*) FROM players`: 42,
+		`SELECT COUNT(*) FROM tags`:    10,
+		`SELECT COUNT(*) FROM players WHERE team_id NOT IN (SELECT id FROM teams)`: 0,
+	} {
+		var got int
+		if err := db.Get(&got, query); err != nil || got != want {
+			t.Fatalf("%s = %d, want %d (%v)", query, got, want, err)
+		}
+	}
+}
+
+func TestSeedKeepGoingIsolatesFailedFiles(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	if err := manager.dbDriver.ApplySQL([]string{`CREATE TABLE tags (id INTEGER PRIMARY KEY, label TEXT);`}); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	seed := func(name, table string, rows int) string {
+		return fmt.Sprintf(`
+Seed %q {
+    table = %q
+    Field "label" {
+        value = "fake_string"
+    }
+    rows = %d
+}
+`, name, table, rows)
+	}
+	var paths []string
+	for name, body := range map[string]string{
+		"001_tags.bcl":   seed("first", "tags", 2),
+		"002_broken.bcl": seed("kept_back", "tags", 5) + seed("missing", "no_such_table", 1),
+		"003_tags.bcl":   seed("last", "tags", 3),
+	} {
+		path := filepath.Join(manager.SeedDir(), name)
+		writeTestFile(t, path, body)
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	count := func() int {
+		var n int
+		if err := manager.dbDriver.DB().Get(&n, `SELECT COUNT(*) FROM tags`); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	if err := manager.RunSeeds(false, false, paths...); err == nil || count() != 2 {
+		t.Fatalf("expected the run to stop at the broken file, got %v with %d tag(s)", err, count())
+	}
+	if err := manager.dbDriver.ApplySQL([]string{`DELETE FROM tags;`}); err != nil {
+		t.Fatal(err)
+	}
+	err := (&SeedCommand{Driver: manager}).Handle(testContext{options: map[string]string{"keep-going": "true"}})
+	if err == nil || !strings.Contains(err.Error(), "1 of 3 seed file(s) failed") || !strings.Contains(err.Error(), "002_broken.bcl") {
+		t.Fatalf("expected the broken file to be reported, got %v", err)
+	}
+	// The rows of the broken file roll back with it; the other files run.
+	if got := count(); got != 5 {
+		t.Fatalf("tags = %d, want 5", got)
+	}
+}
+
+func TestSnapshotRestoresSavedData(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	WithSnapshots(filepath.Join(t.TempDir(), "snapshots"), nil)(manager)
+	if err := manager.dbDriver.ApplySQL([]string{
+		`CREATE TABLE tags (id INTEGER PRIMARY KEY AUTOINCREMENT, label TEXT UNIQUE);`,
+		`INSERT INTO tags (label) VALUES ('kept'), ('also kept');`,
+	}); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	command := &SnapshotCommand{Driver: manager}
+	if err := command.Handle(testContext{args: []string{"save", "seeded"}, options: map[string]string{}}); err != nil {
+		t.Fatalf("save snapshot: %v", err)
+	}
+	if err := manager.dbDriver.ApplySQL([]string{
+		`DELETE FROM tags;`,
+		`CREATE TABLE scratch (id INTEGER PRIMARY KEY);`,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := command.Handle(testContext{args: []string{"restore", "seeded"}, options: map[string]string{"no-input": "true"}}); err == nil {
+		t.Fatal("expected restore to require confirmation without input")
+	}
+	if err := command.Handle(testContext{args: []string{"restore", "seeded"}, options: map[string]string{"yes": "true"}}); err != nil {
+		t.Fatalf("restore snapshot: %v", err)
+	}
+	var labels []string
+	if err := manager.dbDriver.DB().Select(&labels, `SELECT label FROM tags ORDER BY id`); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(labels, []string{"kept", "also kept"}) {
+		t.Fatalf("expected the saved rows back, got %v", labels)
+	}
+	if exists, err := manager.TableExists("scratch"); err != nil || exists {
+		t.Fatalf("expected the table created after the snapshot to be gone, got %v, %v", exists, err)
+	}
+
+	if _, err := manager.SaveSnapshot("../escape"); err == nil {
+		t.Fatal("expected an invalid snapshot name to be rejected")
+	}
+	if err := manager.RestoreSnapshot("missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected a missing snapshot error, got %v", err)
+	}
+	snapshots, err := manager.ListSnapshots()
+	if err != nil || len(snapshots) != 1 || snapshots[0].Name != "seeded" || snapshots[0].Dialect != DialectSQLite {
+		t.Fatalf("expected the seeded snapshot to be listed, got %+v, %v", snapshots, err)
+	}
+}
+
+func TestSchemaDiffReconcilesLiveDatabases(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	dir := t.TempDir()
+	open := func(name string, statements ...string) (string, IDatabaseDriver) {
+		path := filepath.Join(dir, name)
+		driver, err := NewDriver(DialectSQLite, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { driver.DB().Close() })
+		if err := driver.ApplySQL(statements); err != nil {
+			t.Fatalf("set up %s: %v", name, err)
+		}
+		return "sqlite://" + path, driver
+	}
+	source, _ := open("staging.db",
+		`CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT);`,
+		`CREATE INDEX idx_users_email ON users (email);`,
+		`CREATE VIEW user_ids AS SELECT id FROM users;`,
+	)
+	target, targetDriver := open("prod.db",
+		`CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT);`,
+		`CREATE TABLE hotfix_audit (id INTEGER PRIMARY KEY);`,
+		`CREATE VIEW user_ids AS SELECT email FROM users;`,
+	)
+	output := filepath.Join(dir, "reconcile.sql")
+	command := &SchemaDiffCommand{Driver: manager}
+	if err := command.Handle(testContext{options: map[string]string{"source": source, "target": target, "output": output}}); err != nil {
+		t.Fatalf("schema:diff: %v", err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	up, down := parseSQLMigration(data)
+	apply := func(section string) {
+		for _, statement := range strings.Split(section, ";\n") {
+			if statement = strings.TrimSpace(statement); statement != "" {
+				if err := targetDriver.ApplySQL([]string{statement}); err != nil {
+					t.Fatalf("apply %q: %v\n%s", statement, err, data)
+				}
+			}
+		}
+	}
+
+	apply(up)
+	diff, err := manager.DiffDatabases(source, target)
+	if err != nil || !diff.Empty() {
+		t.Fatalf("expected the schemas to match after up, got %+v, %v\n%s", diff, err, data)
+	}
+	apply(down)
+	diff, err = manager.DiffDatabases(source, target)
+	if err != nil || len(diff.Missing) != 1 || len(diff.Extra) != 1 || len(diff.Changed) != 1 {
+		t.Fatalf("expected down to restore the drift, got %+v, %v", diff, err)
+	}
+
+	// Columns of a table missing on one side become a CREATE TABLE, and
+	// changed PostgreSQL columns are altered in place.
+	diff = compareSchemas([]SchemaObject{
+		{Kind: "column", Name: "orders.id", Table: "orders", Definition: "integer NOT NULL"},
+		{Kind: "column", Name: "users.email", Table: "users", Definition: "text NOT NULL"},
+	}, []SchemaObject{
+		{Kind: "column", Name: "users.email", Table: "users", Definition: "text"},
+	})
+	diff.Dialect = DialectPostgres
+	migration := diff.Migration("reconcile")
+	for _, want := range []string{
+		"CREATE TABLE \"orders\" (\n  \"id\" integer NOT NULL\n);",
+		`ALTER TABLE "users" ALTER COLUMN "email" SET NOT NULL;`,
+		`DROP TABLE IF EXISTS "orders";`,
+		`ALTER TABLE "users" ALTER COLUMN "email" DROP NOT NULL;`,
+	} {
+		if !strings.Contains(migration, want) {
+			t.Fatalf("expected %q in the migration:\n%s", want, migration)
+		}
+	}
+}
+
+func TestSchemaExportDescribesMigratedTables(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_create_users.bcl"), `
+Migration "001_create_users" {
+  Version = "1.0.0"
+  Description = "Create users."
+  Up {
+    CreateTable "users" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+        auto_increment = true
+      }
+      Field "email" {
+        type = "string"
+        size = 255
+      }
+      Field "nickname" {
+        type = "string"
+        nullable = true
+      }
+      Field "active" {
+        type = "boolean"
+        default = "true"
+      }
+      Field "created_at" {
+        type = "datetime"
+        default = "CURRENT_TIMESTAMP"
+      }
+    }
+  }
+  Down {
+    DropTable "users" {}
+  }
+}
+`)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "002_alter_users.bcl"), `
+Migration "002_alter_users" {
+  Version = "1.0.0"
+  Description = "Rename nickname and add a score."
+  Up {
+    AlterTable "users" {
+      RenameField "nickname" {
+        to = "handle"
+      }
+      AddField "score" {
+        type = "integer"
+      }
+    }
+  }
+  Down {
+    AlterTable "users" {
+      DropField "score" {}
+      RenameField "handle" {
+        to = "nickname"
+      }
+    }
+  }
+}
+`)
+	output := filepath.Join(t.TempDir(), "schema.json")
+	command := &SchemaExportCommand{Driver: manager}
+	if err := command.Handle(testContext{options: map[string]string{"format": "jsonschema", "output": output}}); err != nil {
+		t.Fatalf("schema:export: %v", err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Defs map[string]struct {
+			Properties map[string]map[string]any `json:"properties"`
+			Required   []string                  `json:"required"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("decode %s: %v", data, err)
+	}
+	users := doc.Defs["users"]
+	if !slices.Equal(users.Required, []string{"email", "score"}) {
+		t.Fatalf("expected email and score to be required, got %v", users.Required)
+	}
+	if _, ok := users.Properties["nickname"]; ok || len(users.Properties) != 6 {
+		t.Fatalf("expected the renamed field set, got %v", users.Properties)
+	}
+	for field, want := range map[string]string{
+		"id":         `{"readOnly":true,"type":"integer"}`,
+		"email":      `{"maxLength":255,"type":"string"}`,
+		"handle":     `{"type":["string","null"]}`,
+		"active":     `{"default":true,"type":"boolean"}`,
+		"created_at": `{"format":"date-time","type":"string"}`,
+	} {
+		got, _ := json.Marshal(users.Properties[field])
+		if string(got) != want {
+			t.Fatalf("%s: expected %s, got %s", field, want, got)
+		}
+	}
+
+	openapi, err := manager.ExportSchema(SchemaFormatOpenAPI, "")
+	if err != nil || !strings.Contains(string(openapi), `"version": "002_alter_users"`) || !strings.Contains(string(openapi), `"schemas": {`) {
+		t.Fatalf("expected OpenAPI components, got %s, %v", openapi, err)
+	}
+	if _, err := manager.ExportSchema("xml", ""); err == nil {
+		t.Fatal("expected an unsupported format error")
+	}
+}
+
+func TestGenerateModelsFromMigrations(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_create_catalog.bcl"), `
+Migration "001_create_catalog" {
+  Version = "1.0.0"
+  Description = "Create categories and products."
+  Up {
+    CreateTable "categories" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+        auto_increment = true
+      }
+      Field "name" {
+        type = "string"
+      }
+    }
+    CreateTable "product_items" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+      Field "category_id" {
+        type = "integer"
+        nullable = true
+        foreign_key = {
+          reference_table = "categories"
+          reference_field = "id"
+        }
+      }
+      Field "price" {
+        type = "decimal"
+      }
+      Field "attributes" {
+        type = "json"
+        nullable = true
+      }
+      Field "published_at" {
+        type = "datetime"
+        nullable = true
+      }
+    }
+  }
+  Down {
+    DropTable "product_items" {}
+    DropTable "categories" {}
+  }
+}
+`)
+	output := filepath.Join(t.TempDir(), "models", "models.go")
+	command := &GenerateModelsCommand{Driver: manager}
+	if err := command.Handle(testContext{options: map[string]string{"package": "models", "output": output}}); err != nil {
+		t.Fatalf("generate:models: %v", err)
+	}
+	src, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), output, src, 0); err != nil {
+		t.Fatalf("generated models do not parse: %v\n%s", err, src)
+	}
+	for _, want := range []string{
+		"package models",
+		"\"encoding/json\"\n\t\"time\"",
+		"type Category struct {",
+		"ID   int64  `db:\"id\" json:\"id\"`",
+		"type ProductItem struct {",
+		"// CategoryID references categories.id.\n\tCategoryID  *int64",
+		"Price       float64",
+		"Attributes  json.RawMessage",
+		"PublishedAt *time.Time",
+		"func (ProductItem) TableName() string {\n\treturn \"product_items\"",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Fatalf("expected %q in the models:\n%s", want, src)
+		}
+	}
+	if _, err := manager.GenerateModels("not-a-package", ""); err == nil {
+		t.Fatal("expected an invalid package name error")
+	}
+}
+
+func TestGenerateTypeScriptTypesFromMigrations(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_create_orders.bcl"), `
+Migration "001_create_orders" {
+  Version = "1.0.0"
+  Description = "Create orders."
+  Up {
+    CreateTable "orders" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+      Field "customer_id" {
+        type = "integer"
+        foreign_key = {
+          reference_table = "customers"
+          reference_field = "id"
+        }
+      }
+      Field "paid" {
+        type = "boolean"
+      }
+      Field "notes" {
+        type = "text"
+        nullable = true
+      }
+      Field "metadata" {
+        type = "jsonb"
+        nullable = true
+      }
+      Field "placed-at" {
+        type = "datetime"
+      }
+    }
+  }
+  Down {
+    DropTable "orders" {}
+  }
+}
+`)
+	output := filepath.Join(t.TempDir(), "web", "schema.ts")
+	command := &GenerateTypesCommand{Driver: manager}
+	if err := command.Handle(testContext{options: map[string]string{"lang": "ts", "output": output}}); err != nil {
+		t.Fatalf("generate:types: %v", err)
+	}
+	src, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `
+/** A row of the orders table. */
+export interface Order {
+  id: number;
+  /** References customers.id. */
+  customer_id: number;
+  paid: boolean;
+  notes: string | null;
+  metadata: unknown;
+  "placed-at": string;
+}
+`
+	if !strings.HasSuffix(string(src), want) {
+		t.Fatalf("expected the Order interface, got:\n%s", src)
+	}
+	if _, err := manager.GenerateTypes("swift", ""); err == nil {
+		t.Fatal("expected an unsupported language error")
+	}
+}
+
+func TestSchemaDocsDocumentsTablesAndHistory(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "1700000000_create_catalog.bcl"), `
+Migration "1700000000_create_catalog" {
+  Version = "1.0.0"
+  Description = "Create categories and products."
+  Up {
+    CreateTable "categories" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+      Field "name" {
+        type = "string"
+        size = 100
+      }
+    }
+    CreateTable "products" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+      Field "category_id" {
+        type = "integer"
+        foreign_key = {
+          reference_table = "categories"
+          reference_field = "id"
+          on_delete = "CASCADE"
+        }
+      }
+    }
+  }
+  Down {
+    DropTable "products" {}
+    DropTable "categories" {}
+  }
+}
+`)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "1700000100_add_price.bcl"), `
+Migration "1700000100_add_price" {
+  Version = "1.0.0"
+  Description = "Price products."
+  Up {
+    AlterTable "products" {
+      AddField "price" {
+        type = "decimal"
+        nullable = true
+      }
+    }
+  }
+  Down {
+    AlterTable "products" {
+      DropField "price" {}
+    }
+  }
+}
+`)
+	output := t.TempDir()
+	command := &SchemaDocsCommand{Driver: manager}
+	if err := command.Handle(testContext{options: map[string]string{"output": output}}); err != nil {
+		t.Fatalf("schema:docs: %v", err)
+	}
+	for _, name := range []string{"index.html", ".nojekyll", "tables/categories.html"} {
+		if _, err := os.Stat(filepath.Join(output, name)); err != nil {
+			t.Fatalf("expected %s: %v", name, err)
+		}
+	}
+	index, err := os.ReadFile(filepath.Join(output, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(index), `<a href="tables/products.html">products</a> — Create categories and products.`) {
+		t.Fatalf("index does not link the tables:\n%s", index)
+	}
+	page, err := os.ReadFile(filepath.Join(output, "tables", "products.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<td>price</td><td><code>decimal</code></td><td>nullable</td>`,
+		`references <a href="../tables/categories.html">categories</a>.<code>id</code> (on delete cascade)`,
+		`<h3>1700000100_add_price</h3>`,
+		`Price products.`,
+		`<li>Add field price decimal</li>`,
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Fatalf("products page lacks %q:\n%s", want, page)
+		}
+	}
+
+	pages, err := manager.SchemaDocs(SchemaDocsMarkdown, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	categories := string(pages["tables/categories.md"])
+	if !strings.Contains(categories, "- [products](../tables/products.md).`category_id` references `id` (on delete cascade)") ||
+		!strings.Contains(categories, "| name | `string(100)` | not null |  |") {
+		t.Fatalf("unexpected categories page:\n%s", categories)
+	}
+	if _, ok := pages[".nojekyll"]; ok {
+		t.Fatal("markdown site must be rendered by Jekyll")
+	}
+	if _, err := manager.SchemaDocs("pdf", ""); err == nil {
+		t.Fatal("expected an unsupported format error")
+	}
+}
+
+func TestSearchMigrationsFindsIntroducingMigration(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	users := filepath.Join(manager.MigrationDir(), "1700000000_users.bcl")
+	writeTestFile(t, users, `# Users and their contact details.
+Migration "1700000000_create_users" {
+  Up {
+    CreateTable "users" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+    }
+  }
+}
+
+Migration "1700000100_add_email" {
+  Up {
+    AlterTable "users" {
+      AddField "email" {
+        type = "string"
+      }
+    }
+  }
+}
+`)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "1700000200_index_email.sql"), `-- migration-up
+CREATE UNIQUE INDEX users_Email_idx ON users (email);
+-- migration-down
+DROP INDEX users_Email_idx;
+`)
+
+	matches, err := manager.SearchMigrations(`"email"`, false)
+	if err != nil {
+		t.Fatalf("SearchMigrations: %v", err)
+	}
+	want := []MigrationMatch{{Migration: "1700000100_add_email", File: users, Line: 16, Text: `      AddField "email" {`}}
+	if !reflect.DeepEqual(matches, want) {
+		t.Fatalf("matches = %#v, want %#v", matches, want)
+	}
+	matches, err = manager.SearchMigrations(`users_email_idx`, true)
+	if err != nil {
+		t.Fatalf("SearchMigrations: %v", err)
+	}
+	if len(matches) != 2 || matches[0].Migration != "1700000200_index_email" || matches[0].Line != 2 || matches[1].Line != 4 {
+		t.Fatalf("case-insensitive matches = %#v", matches)
+	}
+	if matches, err := manager.SearchMigrations(`Users and their`, false); err != nil || len(matches) != 1 || matches[0].Migration != "1700000000_create_users" || matches[0].Line != 1 {
+		t.Fatalf("leading comment matches = %#v, %v", matches, err)
+	}
+	if _, err := manager.SearchMigrations(`(`, false); err == nil {
+		t.Fatal("expected an invalid pattern error")
+	}
+
+	sources, err := migrationSources([]string{users}, manager.readFile, func(path string) ([]Migration, error) {
+		cached, err := manager.readMigrationsBCL(path)
+		return cached.migrations, err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := migrationSearchScript(sources)
+	if !strings.Contains(script, `{name: "1700000100_add_email", file: "`) || !strings.Contains(script, `AddField \"email\" {\u000A`) {
+		t.Fatalf("unexpected search script:\n%s", script)
+	}
+}
+
+func TestMetadataPolicyRequiresOwnershipFields(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	auditLog := filepath.Join(t.TempDir(), "audit.log")
+	WithAuditLog(auditLog)(manager)
+	WithMetadataPolicy(MetadataPolicy{RequireTicket: true, RequireReview: true})(manager)
+	path := filepath.Join(manager.MigrationDir(), "001_create_accounts.bcl")
+	body := `
+Migration "001_create_accounts" {
+  Version = "1.0.0"
+  Description = "Create accounts."
+  Author = "alice"
+  Up {
+    CreateTable "accounts" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+    }
+  }
+}
+`
+	writeTestFile(t, path, body)
+	migration, err := ParseMigrationBCL([]byte(body))
+	if err != nil {
+		t.Fatalf("ParseMigrationBCL: %v", err)
+	}
+	err = manager.ApplyMigration(migration)
+	if err == nil || !strings.Contains(err.Error(), "missing Ticket, ReviewedBy") {
+		t.Fatalf("ApplyMigration error = %v, want missing Ticket, ReviewedBy", err)
+	}
+	assertSQLiteTableExists(t, manager, "accounts", false)
+
+	body = strings.Replace(body, `Author = "alice"`, "Author = \"alice\"\n  Ticket = \"OPS-12\"\n  ReviewedBy = \"bob\"", 1)
+	writeTestFile(t, path, body)
+	if migration, err = ParseMigrationBCL([]byte(body)); err != nil {
+		t.Fatalf("ParseMigrationBCL: %v", err)
+	}
+	if got, want := migration.Ownership(), "by alice, OPS-12, reviewed by bob"; got != want {
+		t.Fatalf("Ownership() = %q, want %q", got, want)
+	}
+	if err := manager.ApplyMigration(migration); err != nil {
+		t.Fatalf("ApplyMigration: %v", err)
+	}
+	statuses, err := manager.MigrationStatuses()
+	if err != nil {
+		t.Fatalf("MigrationStatuses: %v", err)
+	}
+	if len(statuses) != 1 || statuses[0].Ownership() != migration.Ownership() {
+		t.Fatalf("statuses = %+v", statuses)
+	}
+	entries, err := ReadAuditLog(auditLog)
+	if err != nil {
+		t.Fatalf("ReadAuditLog: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Author != "alice" || entries[0].Ticket != "OPS-12" || entries[0].ReviewedBy != "bob" {
+		t.Fatalf("audit entries = %+v", entries)
+	}
+}
+
+func TestApplyBundleAppliesOnlyVerifiedBundleContents(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_multi.bcl"), testMultiRootMigrationBCL())
+	private, public, err := GenerateBundleKey()
+	if err != nil {
+		t.Fatalf("GenerateBundleKey: %v", err)
+	}
+	t.Setenv("TEST_BUNDLE_KEY", private)
+	t.Setenv("TEST_BUNDLE_PUBLIC_KEY", public)
+	signKey, err := ResolveBundleSigningKey("env:TEST_BUNDLE_KEY")
+	if err != nil {
+		t.Fatalf("ResolveBundleSigningKey: %v", err)
+	}
+	bundleFile := filepath.Join(t.TempDir(), "migrations.tgz")
+	var buf bytes.Buffer
+	manifest, err := manager.WriteBundle(&buf, signKey)
+	if err != nil {
+		t.Fatalf("WriteBundle: %v", err)
+	}
+	if len(manifest.Files) != 1 || strings.Join(manifest.Files[0].Migrations, ",") != "001_create_accounts,002_create_projects" {
+		t.Fatalf("manifest files = %+v", manifest.Files)
+	}
+	writeTestFile(t, bundleFile, buf.String())
+
+	// A migration added after the bundle was reviewed must not be applied.
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "003_unreviewed.sql"), "-- migration-up\nCREATE TABLE unreviewed (id INTEGER);\n")
+	cmd := &ApplyBundleCommand{MigrateCommand{Driver: manager}}
+	for _, flag := range cmd.Extend().Flags {
+		if flag.Name == "seed" || flag.Name == "rows" {
+			t.Fatalf("apply-bundle inherits --%s", flag.Name)
+		}
+	}
+	if err := cmd.Handle(testContext{args: []string{bundleFile}, options: map[string]string{}}); err == nil || !strings.Contains(err.Error(), "--verify-key") {
+		t.Fatalf("apply-bundle without --verify-key error = %v", err)
+	}
+	if err := cmd.Handle(testContext{args: []string{bundleFile}, options: map[string]string{"allow-unsigned": "true", "seed": "true"}}); err == nil || !strings.Contains(err.Error(), "does not seed") {
+		t.Fatalf("apply-bundle --seed error = %v", err)
+	}
+	assertSQLiteTableExists(t, manager, "accounts", false)
+	ctx := testContext{args: []string{bundleFile}, options: map[string]string{"include-raw": "true", "verify-key": "env:TEST_BUNDLE_PUBLIC_KEY"}}
+	if err := cmd.Handle(ctx); err != nil {
+		t.Fatalf("apply-bundle: %v", err)
+	}
+	assertSQLiteTableExists(t, manager, "accounts", true)
+	assertSQLiteTableExists(t, manager, "projects", true)
+	assertSQLiteTableExists(t, manager, "unreviewed", false)
+
+	_, otherPublic, _ := GenerateBundleKey()
+	t.Setenv("TEST_BUNDLE_PUBLIC_KEY", otherPublic)
+	if err := cmd.Handle(ctx); err == nil || !strings.Contains(err.Error(), "signature is invalid") {
+		t.Fatalf("apply-bundle with another key error = %v", err)
+	}
+
+	// Rewrite the bundle with an edited migration file but the original
+	// manifest.
+	tampered := filepath.Join(t.TempDir(), "tampered.tgz")
+	original, err := os.ReadFile(bundleFile)
+	if err != nil {
+		t.Fatalf("read bundle: %v", err)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(original))
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	var out bytes.Buffer
+	gw := gzip.NewWriter(&out)
+	tw := tar.NewWriter(gw)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar: %v", err)
+		}
+		data, _ := io.ReadAll(tr)
+		if strings.HasPrefix(header.Name, "migrations/") {
+			data = bytes.Replace(data, []byte("size = 64"), []byte("size = 65"), 1)
+			header.Size = int64(len(data))
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatalf("tar header: %v", err)
+		}
+		tw.Write(data)
+	}
+	tw.Close()
+	gw.Close()
+	writeTestFile(t, tampered, out.String())
+	if _, err := OpenBundle(tampered, nil); err == nil || !strings.Contains(err.Error(), "checksum mismatch for 001_multi.bcl") {
+		t.Fatalf("OpenBundle tampered error = %v", err)
+	}
+
+	// An unsigned bundle applies only with --allow-unsigned.
+	unsignedManager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(unsignedManager.MigrationDir(), "001_multi.bcl"), testMultiRootMigrationBCL())
+	buf.Reset()
+	if _, err := unsignedManager.WriteBundle(&buf, nil); err != nil {
+		t.Fatalf("WriteBundle unsigned: %v", err)
+	}
+	unsigned := filepath.Join(t.TempDir(), "unsigned.tgz")
+	writeTestFile(t, unsigned, buf.String())
+	cmd = &ApplyBundleCommand{MigrateCommand{Driver: unsignedManager}}
+	if err := cmd.Handle(testContext{args: []string{unsigned}, options: map[string]string{"verify-key": "env:TEST_BUNDLE_PUBLIC_KEY"}}); err == nil || !strings.Contains(err.Error(), "not signed") {
+		t.Fatalf("apply-bundle unsigned with --verify-key error = %v", err)
+	}
+	if err := cmd.Handle(testContext{args: []string{unsigned}, options: map[string]string{"allow-unsigned": "true"}}); err != nil {
+		t.Fatalf("apply-bundle --allow-unsigned: %v", err)
+	}
+	assertSQLiteTableExists(t, unsignedManager, "projects", true)
+}
+
+func TestUpgradeFormatStampsLegacyFilesAndKeepsHistoryValid(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	path := filepath.Join(manager.MigrationDir(), "001_multi.bcl")
+	legacy := strings.Replace(testMultiRootMigrationBCL(), `  Description = "Create projects."`, "  Description = \"Create projects.\"\n  Disable = false", 1)
+	writeTestFile(t, path, legacy)
+	cmd := &MigrateCommand{Driver: manager}
+	if err := cmd.Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	histories, err := manager.historyDriver.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(histories) != 2 || histories[0].DSLVersion != 1 {
+		t.Fatalf("histories = %+v, want DSL version 1", histories)
+	}
+
+	upgrades, err := manager.UpgradeFormat(false)
+	if err != nil {
+		t.Fatalf("UpgradeFormat: %v", err)
+	}
+	if len(upgrades) != 1 || len(upgrades[0].Rechecksummed) != 2 {
+		t.Fatalf("upgrades = %+v", upgrades)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if n := strings.Count(string(data), fmt.Sprintf("  DSLVersion = %d\n  ToolVersion = %q\n", DSLVersion, Version)); n != 2 {
+		t.Fatalf("rewritten file stamps %d blocks, want 2:\n%s", n, data)
+	}
+	if strings.Contains(string(data), "Disable =") {
+		t.Fatalf("rewritten file still uses Disable:\n%s", data)
+	}
+	// The rewrite must not be reported as a modification of applied
+	// migrations.
+	if err := cmd.Handle(testContext{options: map[string]string{"check": "true"}}); err != nil {
+		t.Fatalf("migrate --check after upgrade: %v", err)
+	}
+	if err := manager.ValidateMigrations(); err != nil {
+		t.Fatalf("ValidateMigrations after upgrade: %v", err)
+	}
+	if upgrades, err := manager.UpgradeFormat(false); err != nil || len(upgrades) != 0 {
+		t.Fatalf("second UpgradeFormat = %+v, %v", upgrades, err)
+	}
+
+	if warning := dslVersionWarning(Migration{Name: "003_future", DSLVersion: DSLVersion + 1, ToolVersion: "v9.0.0"}); !strings.Contains(warning, fmt.Sprintf("DSL version %d", DSLVersion+1)) {
+		t.Fatalf("dslVersionWarning = %q", warning)
+	}
+}
+
+func TestSetConfigValuesValidatesAndKeepsOtherSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "migrate.json")
+	if err := CreateSampleConfig(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetConfigValues(path, map[string]string{
+		"database.host":   "db.internal",
+		"seed.batch_size": "500",
+		"logging.redact":  "card_number, ssn",
+	}); err != nil {
+		t.Fatalf("SetConfigValues: %v", err)
+	}
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Database.Host != "db.internal" || config.Seed.BatchSize != 500 {
+		t.Fatalf("values not written: host=%q batch_size=%d", config.Database.Host, config.Seed.BatchSize)
+	}
+	if got, err := GetConfigValue(config, "logging.redact"); err != nil || got != `["card_number","ssn"]` {
+		t.Fatalf("logging.redact = %q, %v", got, err)
+	}
+	if got, err := GetConfigValue(config, "database.username"); err != nil || got != "your_username" {
+		t.Fatalf("database.username = %q, %v", got, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "_comment") {
+		t.Fatalf("settings unknown to MigrateConfig were dropped:\n%s", data)
+	}
+
+	for _, values := range []map[string]string{
+		{"seed.batch_size": "abc"},
+		{"seed.batch_size": "0"},
+		{"database.hostname": "x"},
+		{"database": "x"},
+	} {
+		if err := SetConfigValues(path, values); err == nil {
+			t.Fatalf("SetConfigValues(%v) succeeded", values)
+		}
+	}
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(data) {
+		t.Fatal("rejected values changed the config file")
+	}
+
+	// Placeholders for variables only set where migrate runs do not block
+	// unrelated changes, but the changed settings are still checked.
+	if err := SetConfigValues(path, map[string]string{"database.driver": "${MIGRATE_TEST_UNSET_DRIVER}", "database.host": "${MIGRATE_TEST_UNSET_HOST}"}); err != nil {
+		t.Fatalf("SetConfigValues with placeholders: %v", err)
+	}
+	if err := SetConfigValues(path, map[string]string{"seed.batch_size": "250"}); err != nil {
+		t.Fatalf("SetConfigValues with unset placeholders in the file: %v", err)
+	}
+	if err := SetConfigValues(path, map[string]string{"logging.level": "loud"}); err == nil || !strings.Contains(err.Error(), "logging.level") {
+		t.Fatalf("SetConfigValues(logging.level=loud) error = %v", err)
+	}
+	data, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "${MIGRATE_TEST_UNSET_HOST}") || !strings.Contains(string(data), `"batch_size": 250`) {
+		t.Fatalf("config file after setting values with placeholders:\n%s", data)
+	}
+}
+
+func TestLoadConfigExpandsEnvPlaceholders(t *testing.T) {
+	t.Setenv("APP_DB_USER", "deployer")
+	t.Setenv("APP_DB_PASSWORD", "s3cret")
+	t.Setenv("APP_SESSION_TIMEOUT", "5s")
+	path := filepath.Join(t.TempDir(), "migrate.json")
+	writeTestFile(t, path, `{
+  "database": {
+    "driver": "postgres",
+    "host": "${APP_DB_HOST:-db.internal}",
+    "port": 5432,
+    "username": "${APP_DB_USER}",
+    "password": "pre-${APP_DB_PASSWORD}-$${literal}",
+    "database": "app",
+    "session": ["SET lock_timeout = '${APP_SESSION_TIMEOUT}'"]
+  }
+}`)
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := config.Database
+	if db.Host != "db.internal" || db.Username != "deployer" || db.Password != "pre-s3cret-${literal}" {
+		t.Fatalf("placeholders not expanded: %+v", db)
+	}
+	if len(db.Session) != 1 || db.Session[0] != "SET lock_timeout = '5s'" {
+		t.Fatalf("session = %q", db.Session)
+	}
+
+	writeTestFile(t, path, `{"database": {"driver": "postgres", "host": "localhost", "port": 5432, "database": "app", "password": "${APP_MISSING_PASSWORD}"}}`)
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "database.password") || !strings.Contains(err.Error(), "APP_MISSING_PASSWORD") {
+		t.Fatalf("LoadConfig with unset variable: %v", err)
+	}
+}
+
+func TestConfigWizardRetriesFailedConnection(t *testing.T) {
+	t.Setenv("APP_DB_PASSWORD", "s3cret")
+	answers := strings.Join([]string{
+		"oracle", // unsupported driver, asked again
+		"mariadb",
+		"db.internal",
+		"abc", // invalid port, asked again
+		"",    // keep the MySQL default port
+		"app",
+		"${APP_DB_PASSWORD}",
+		"", // database name is required
+		"shop",
+		"y",
+		"", // change the settings after the failed test
+		"",
+		"db2.internal",
+		"3307",
+		"",
+		"",
+		"shop",
+		"",
+		"db/migrations",
+		"",
+	}, "\n") + "\n"
+	var tested []string
+	test := func(config *MigrateConfig) error {
+		tested = append(tested, config.Database.Host+":"+config.Database.Password)
+		if config.Database.Host == "db.internal" {
+			return errors.New("connection refused")
+		}
+		return nil
+	}
+	var out strings.Builder
+	config, err := runConfigWizard(strings.NewReader(answers), &out, test)
+	if err != nil {
+		t.Fatalf("runConfigWizard: %v\n%s", err, out.String())
+	}
+	db := config.Database
+	if db.Driver != "mysql" || db.Host != "db2.internal" || db.Port != 3307 || db.Username != "app" || db.Database != "shop" {
+		t.Fatalf("database = %+v", db)
+	}
+	if db.Password != "${APP_DB_PASSWORD}" {
+		t.Fatalf("password placeholder was not kept: %q", db.Password)
+	}
+	if strings.Join(tested, ",") != "db.internal:s3cret,db2.internal:s3cret" {
+		t.Fatalf("tested connections = %v", tested)
+	}
+	if config.Migration.Directory != "db/migrations" || config.Seed.Directory != "migrations/seeds" {
+		t.Fatalf("directories = %q, %q", config.Migration.Directory, config.Seed.Directory)
+	}
+	for _, want := range []string{"unsupported driver: oracle", `"abc" is not a valid port`, "Connection failed: connection refused", "Connection succeeded."} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("output lacks %q:\n%s", want, out.String())
+		}
+	}
+
+	if _, err := runConfigWizard(strings.NewReader("sqlite\n"), io.Discard, test); err == nil {
+		t.Fatal("runConfigWizard succeeded on truncated input")
+	}
+}
+
+func TestConfigFlagReconfiguresManagerForEveryCommand(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	dir := t.TempDir()
+	migrationDir := filepath.Join(dir, "other-migrations")
+	configPath := filepath.Join(dir, "other.json")
+	writeTestFile(t, configPath, fmt.Sprintf(`{
+  "database": {"driver": "sqlite", "database": %q},
+  "migration": {"directory": %q, "table_name": "migrations", "lock_timeout": 30, "batch_size": 10, "checksum": "raw"},
+  "seed": {"directory": %q, "batch_size": 10}
+}`, filepath.Join(dir, "other.db"), migrationDir, filepath.Join(migrationDir, "seeds")))
+	writeTestFile(t, filepath.Join(migrationDir, "001_multi.bcl"), testMultiRootMigrationBCL())
+
+	commands := make(map[string]contracts.Command)
+	for _, cmd := range manager.registeredCommands() {
+		commands[cmd.Signature()] = cmd
+	}
+	for name, cmd := range commands {
+		flags := 0
+		for _, flag := range cmd.Extend().Flags {
+			if flag.Name == "config" {
+				flags++
+			}
+		}
+		if flags != 1 {
+			t.Fatalf("%s declares --config %d times", name, flags)
+		}
+		for _, global := range []string{"yes", "no-input"} {
+			count := 0
+			for _, flag := range cmd.Extend().Flags {
+				if flag.Name == global {
+					count++
+				}
+			}
+			if count != 1 {
+				t.Fatalf("%s declares --%s %d times", name, global, count)
+			}
+		}
+	}
+
+	if err := commands["migrate"].Handle(testContext{options: map[string]string{"config": configPath}}); err != nil {
+		t.Fatalf("migrate --config: %v", err)
+	}
+	if manager.ConfigPath() != configPath || manager.MigrationDir() != migrationDir {
+		t.Fatalf("manager not reconfigured: config %q, migrations %q", manager.ConfigPath(), manager.MigrationDir())
+	}
+	assertSQLiteTableExists(t, manager, "accounts", true)
+
+	ctx := testContext{options: map[string]string{"config": filepath.Join(dir, "missing.json")}}
+	if err := commands["status"].Handle(ctx); err == nil || !strings.Contains(err.Error(), "missing.json") {
+		t.Fatalf("status with missing --config: %v", err)
+	}
+	if got := configFileOption(testContext{options: map[string]string{}}, manager); got != configPath {
+		t.Fatalf("config commands use %q, want the loaded %q", got, configPath)
+	}
+	if got := configFileOption(ctx, manager); got != ctx.options["config"] {
+		t.Fatalf("config commands ignore --config: %q", got)
+	}
+}
+
+func TestNewManagerFromConfigReportsUnreachableDatabase(t *testing.T) {
+	dir := t.TempDir()
+	migrationDir := filepath.Join(dir, "migrations")
+	configPath := filepath.Join(dir, "migrate.json")
+	writeTestFile(t, configPath, fmt.Sprintf(`{
+  "database": {"driver": "postgres", "host": "127.0.0.1", "port": 1, "username": "app", "database": "app", "timeout": 1},
+  "migration": {"directory": %q, "table_name": "schema_history", "lock_timeout": 30, "batch_size": 10, "checksum": "raw"},
+  "seed": {"directory": %q, "batch_size": 10}
+}`, migrationDir, filepath.Join(migrationDir, "seeds")))
+
+	if _, err := NewManagerFromConfig(configPath); err == nil || !strings.Contains(err.Error(), "database driver") {
+		t.Fatalf("NewManagerFromConfig with unreachable database: %v", err)
+	}
+
+	manager, err := NewManagerFromConfig(configPath, WithOffline())
+	if err != nil {
+		t.Fatalf("NewManagerFromConfig offline: %v", err)
+	}
+	if manager.MigrationDir() != migrationDir || manager.dbDriver != nil {
+		t.Fatalf("offline manager: migrations %q, driver %v", manager.MigrationDir(), manager.dbDriver)
+	}
+	if _, err := manager.historyDriver.Load(); !errors.Is(err, ErrOffline) {
+		t.Fatalf("offline history Load: %v", err)
+	}
+	writeTestFile(t, filepath.Join(migrationDir, "001_multi.bcl"), testMultiRootMigrationBCL())
+	if err := (&ParseCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migration:parse offline: %v", err)
+	}
+}
+
+func TestWithConfigUsesConfiguredHistoryTable(t *testing.T) {
+	dir := t.TempDir()
+	config := DefaultConfig()
+	config.Database = DatabaseConfig{Driver: "sqlite3", Database: filepath.Join(dir, "app.db")}
+	config.Migration.Directory = filepath.Join(dir, "migrations")
+	config.Migration.TableName = "schema_history"
+	config.Seed.Directory = filepath.Join(dir, "seeds")
+	manager := NewManager(WithConfig(config))
+	if manager.dbDriver == nil || manager.GetDialect() != DialectSQLite {
+		t.Fatalf("WithConfig did not connect: dialect %q", manager.GetDialect())
+	}
+	writeTestFile(t, filepath.Join(config.Migration.Directory, "001_multi.bcl"), testMultiRootMigrationBCL())
+	if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	assertSQLiteTableExists(t, manager, "schema_history", true)
+}
+
+func TestHistoryMirrorWritesThroughToFile(t *testing.T) {
+	dir := t.TempDir()
+	config := DefaultConfig()
+	config.Database = DatabaseConfig{Driver: "sqlite3", Database: filepath.Join(dir, "app.db")}
+	config.Migration.Directory = filepath.Join(dir, "migrations")
+	config.Migration.HistoryMirror = HistoryMirrorFile
+	config.Seed.Directory = filepath.Join(dir, "seeds")
+	manager := NewManager(WithConfig(config))
+	dual, ok := manager.historyDriver.(*DualHistoryDriver)
+	if !ok {
+		t.Fatalf("expected a dual history driver, got %T", manager.historyDriver)
+	}
+	writeTestFile(t, filepath.Join(config.Migration.Directory, "001_multi.bcl"), testMultiRootMigrationBCL())
+	if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	mirror := NewFileHistoryDriver(filepath.Join(config.Migration.Directory, historyFileName))
+	mirrored, err := mirror.Load()
+	if err != nil || len(mirrored) != 2 {
+		t.Fatalf("expected both migrations in the mirrored file, got %+v (%v)", mirrored, err)
+	}
+	if err := manager.RollbackMigration(1); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if mirrored, err = mirror.Load(); err != nil || len(mirrored) != 1 {
+		t.Fatalf("expected the rollback mirrored to the file, got %+v (%v)", mirrored, err)
+	}
+
+	// Reads come from the database only.
+	if err := mirror.Rollback(); err != nil {
+		t.Fatalf("clear mirror: %v", err)
+	}
+	histories, err := dual.Load()
+	if err != nil || len(histories) != 1 || histories[0].Name != "001_create_accounts" {
+		t.Fatalf("expected the history read from the database, got %+v (%v)", histories, err)
+	}
+}
+
+func TestHistoryFallbackPolicy(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	config := DefaultConfig()
+	config.Database = DatabaseConfig{Driver: "postgres", Host: "127.0.0.1", Port: 1, Username: "app", Database: "app", Timeout: 1}
+	config.Migration.Directory = filepath.Join(dir, "migrations")
+	config.Seed.Directory = filepath.Join(dir, "seeds")
+
+	manager := NewManager(WithConfig(config))
+	if _, err := manager.historyDriver.Load(); err == nil || !strings.Contains(err.Error(), "history_fallback") {
+		t.Fatalf("history of unreachable database with fail policy: %v", err)
+	}
+	if _, ok := manager.historyDriver.(*FileHistoryDriver); ok {
+		t.Fatal("fail policy fell back to the file history")
+	}
+
+	config.Migration.HistoryFallback = HistoryFallbackFile
+	configPath := filepath.Join(dir, "migrate.json")
+	if err := config.SaveConfig(configPath); err != nil {
+		t.Fatal(err)
+	}
+	manager, err := NewManagerFromConfig(configPath)
+	if err != nil {
+		t.Fatalf("NewManagerFromConfig with file fallback: %v", err)
+	}
+	if _, ok := manager.historyDriver.(*FileHistoryDriver); !ok {
+		t.Fatalf("history driver = %T, want the file fallback", manager.historyDriver)
+	}
+
+	config.Migration.HistoryFallback = "memory"
+	if err := config.Validate(); err == nil {
+		t.Fatal("Validate accepted an unknown history fallback")
+	}
+}
+
+func TestNewManagerEReturnsDirectoryErrors(t *testing.T) {
+	dir := t.TempDir()
+	blocker := filepath.Join(dir, "file")
+	writeTestFile(t, blocker, "not a directory")
+
+	manager, err := NewManagerE(WithMigrationDir(filepath.Join(blocker, "migrations")), WithSeedDir(filepath.Join(dir, "seeds")))
+	if err == nil || !strings.Contains(err.Error(), "migration directory") {
+		t.Fatalf("NewManagerE with unusable migration directory: %v", err)
+	}
+	if manager == nil {
+		t.Fatal("NewManagerE returned no manager with its error")
+	}
+	if NewManager(WithMigrationDir(filepath.Join(dir, "migrations")), WithSeedDir(filepath.Join(blocker, "seeds"))) == nil {
+		t.Fatal("NewManager returned nil")
+	}
+
+	manager, err = NewManagerE(WithMigrationDir(filepath.Join(dir, "migrations")), WithSeedDir(filepath.Join(dir, "seeds")))
+	if err != nil {
+		t.Fatalf("NewManagerE: %v", err)
+	}
+	if _, err := os.Stat(manager.SeedDir()); err != nil {
+		t.Fatalf("seed directory not created: %v", err)
+	}
+}
+
+func TestSlogHandlerReceivesLogAtConfiguredLevel(t *testing.T) {
+	writer, level := logger.Writer, logger.Level
+	t.Cleanup(func() { logger.Writer, logger.Level = writer, level })
+	var out bytes.Buffer
+	manager := newSQLiteWorkflowManager(t)
+	WithSlogHandler(slog.NewJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))(manager)
+	WithLogLevel("info")(manager)
+
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_multi.bcl"), testMultiRootMigrationBCL())
+	if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	info := out.String()
+	if !strings.Contains(info, `"level":"INFO"`) || strings.Contains(info, "CREATE TABLE") {
+		t.Fatalf("info level log:\n%s", info)
+	}
+
+	out.Reset()
+	if err := SetLogLevel("debug"); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.RollbackMigration(1); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if !strings.Contains(out.String(), `"level":"DEBUG"`) || !strings.Contains(out.String(), "DROP TABLE") {
+		t.Fatalf("debug level log lacks the generated SQL:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := SetLogLevel("warn"); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("warn level logged progress:\n%s", out.String())
+	}
+	if err := SetLogLevel("loud"); err == nil {
+		t.Fatal("SetLogLevel accepted an unknown level")
+	}
+}
+
+func TestEventSinkReceivesMigrationAndSeedEvents(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	var events []Event
+	WithEventSink(func(e Event) { events = append(events, e) })(manager)
+	if err := manager.prepare(); err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_multi.bcl"), testMultiRootMigrationBCL())
+	if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	seedFile := filepath.Join(manager.SeedDir(), "accounts.bcl")
+	writeTestFile(t, seedFile, `
+Seed "accounts" {
+  table = "accounts"
+  Field "name" {
+    value = "fake_name"
+  }
+  rows = 3
+}
+`)
+	if err := manager.RunSeeds(false, false, seedFile); err != nil {
+		t.Fatalf("RunSeeds: %v", err)
+	}
+
+	if _, ok := events[0].(*LockAcquired); !ok {
+		t.Fatalf("first event = %T, want *LockAcquired", events[0])
+	}
+	var started, applied, statements int
+	var seeded int64
+	for _, e := range events {
+		switch e := e.(type) {
+		case *MigrationStarted:
+			started++
+		case *MigrationApplied:
+			applied++
+		case *StatementExecuted:
+			if e.Err != nil {
+				t.Fatalf("statement %q failed: %v", e.SQL, e.Err)
+			}
+			if e.Migration == "001_create_accounts" {
+				statements++
+			}
+		case *SeedRowBatch:
+			if e.Table != "accounts" || e.File != seedFile {
+				t.Fatalf("unexpected seed batch %+v", e)
+			}
+			seeded += e.Rows
+		}
+	}
+	if started != 2 || applied != 2 {
+		t.Fatalf("started %d and applied %d migrations, want 2 each", started, applied)
+	}
+	if statements == 0 {
+		t.Fatal("expected StatementExecuted events for 001_create_accounts")
+	}
+	if seeded != 3 {
+		t.Fatalf("seeded %d rows, want 3", seeded)
+	}
+}
+
+func TestMigrateSummaryReportsAppliedMigrationsAndSeededRows(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_multi.bcl"), testMultiRootMigrationBCL())
+	summary := newMigrateSummary()
+	stop := manager.collectSummary(summary)
+	err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{"seed": "true", "rows": "4", "format": "json"}})
+	stop()
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if len(manager.eventSinks) != 0 {
+		t.Fatalf("expected the summary sinks to be removed, got %d", len(manager.eventSinks))
+	}
+
+	var buf bytes.Buffer
+	if err := summary.write(&buf, "json"); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	var got struct {
+		Applied    int `json:"applied"`
+		Migrations []struct {
+			Name string `json:"name"`
+		} `json:"migrations"`
+		RowsSeeded int64   `json:"rows_seeded"`
+		TotalMS    float64 `json:"total_ms"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("decode %s: %v", buf.String(), err)
+	}
+	if got.Applied != 2 || len(got.Migrations) != 2 || got.Migrations[0].Name != "001_create_accounts" {
+		t.Fatalf("unexpected migrations in %s", buf.String())
+	}
+	if got.RowsSeeded != 8 || got.TotalMS <= 0 {
+		t.Fatalf("unexpected totals in %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := summary.write(&buf, "text"); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Applied 2 migration(s), seeded 8 row(s)") {
+		t.Fatalf("unexpected text summary:\n%s", buf.String())
+	}
+}
+
+func TestRollbackRefusesModifiedMigration(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	migrationFile := filepath.Join(manager.MigrationDir(), "001_multi.bcl")
+	writeTestFile(t, migrationFile, testMultiRootMigrationBCL())
+	migrate := func() {
+		t.Helper()
+		if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+			t.Fatalf("migrate: %v", err)
+		}
+	}
+	// History entries recorded before the Down SQL was stored leave only
+	// the audit log, if any, to roll back with.
+	forgetStoredDownSQL := func() {
+		t.Helper()
+		histories, err := manager.historyDriver.Load()
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		for i := range histories {
+			histories[i].DownSQL = nil
+		}
+		if err := manager.historyDriver.Rollback(histories...); err != nil {
+			t.Fatalf("rewrite history: %v", err)
+		}
+	}
+	migrate()
+	forgetStoredDownSQL()
+	edited := strings.Replace(testMultiRootMigrationBCL(), `DropTable "projects" {}`, `DropTable "projects_renamed" {}`, 1)
+	if edited == testMultiRootMigrationBCL() {
+		t.Fatal("expected the Down of 002_create_projects to change")
+	}
+	writeTestFile(t, migrationFile, edited)
+
+	err := manager.RollbackMigration(1)
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("expected a modified migration to be refused, got %v", err)
+	}
+	assertSQLiteTableExists(t, manager, "projects", true)
+
+	// --allow-modified rolls back with the Down of the current file, which
+	// tolerates the missing table and leaves projects behind.
+	manager.AllowModified = true
+	if err := manager.RollbackMigration(1); err != nil {
+		t.Fatalf("rollback with AllowModified: %v", err)
+	}
+	assertSQLiteTableExists(t, manager, "projects", true)
+	manager.AllowModified = false
+
+	// With an audit log the Down recorded at apply time is used instead.
+	if _, err := manager.dbDriver.DB().Exec(`DROP TABLE projects`); err != nil {
+		t.Fatalf("drop projects: %v", err)
+	}
+	writeTestFile(t, migrationFile, testMultiRootMigrationBCL())
+	WithAuditLog(filepath.Join(t.TempDir(), "audit.log"))(manager)
+	migrate()
+	forgetStoredDownSQL()
+	writeTestFile(t, migrationFile, edited)
+	if err := manager.RollbackMigration(1); err != nil {
+		t.Fatalf("rollback with audited Down: %v", err)
+	}
+	assertSQLiteTableExists(t, manager, "projects", false)
+}
+
+func TestRollbackUsesDownSQLStoredInHistory(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	migrationFile := filepath.Join(manager.MigrationDir(), "001_multi.bcl")
+	writeTestFile(t, migrationFile, testMultiRootMigrationBCL())
+	if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	histories, err := manager.historyDriver.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	last := histories[len(histories)-1]
+	if last.Name != "002_create_projects" || len(last.DownSQL) == 0 || !strings.Contains(strings.Join(last.DownSQL, " "), "projects") {
+		t.Fatalf("expected the Down SQL of 002_create_projects in the history, got %+v", last)
+	}
+
+	writeTestFile(t, migrationFile, strings.Replace(testMultiRootMigrationBCL(), `DropTable "projects" {}`, `DropTable "projects_renamed" {}`, 1))
+	if err := manager.RollbackMigration(1); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	assertSQLiteTableExists(t, manager, "projects", false)
+}
+
+func TestRecordedDownSQLSkipsResolvedSecrets(t *testing.T) {
+	t.Setenv("BILLING_DB_PASSWORD", "it's-secret")
+	manager := newSQLiteWorkflowManager(t)
+	migration, err := ParseMigrationBCL([]byte(`
+Migration "001_rotate_billing" {
+  Up {
+    AlterRole "billing" {
+      password = "env:BILLING_DB_PASSWORD"
+    }
+  }
+  Down {
+    AlterRole "billing" {
+      password = "env:BILLING_DB_PASSWORD"
+    }
+    DropRole "reporting" {}
+  }
+}
+`))
+	if err != nil {
+		t.Fatalf("ParseMigrationBCL: %v", err)
+	}
+	if down := manager.recordedDownSQL("", migration, DialectPostgres); down != nil {
+		t.Fatalf("recorded Down SQL with a resolved secret: %q", down)
+	}
+	migration.Down.AlterRole = nil
+	if down := manager.recordedDownSQL("", migration, DialectPostgres); len(down) != 1 || !strings.Contains(down[0], "DROP ROLE") {
+		t.Fatalf("recorded Down SQL = %q, want the DROP ROLE", down)
+	}
+}
+
+func TestRollbackOrphanedMigrationFromStoredDownSQL(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	migrationFile := filepath.Join(manager.MigrationDir(), "001_multi.bcl")
+	writeTestFile(t, migrationFile, testMultiRootMigrationBCL())
+	if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := os.Remove(migrationFile); err != nil {
+		t.Fatalf("remove migration file: %v", err)
+	}
+
+	if err := manager.RollbackMigration(1); err != nil {
+		t.Fatalf("rollback orphaned migration: %v", err)
+	}
+	assertSQLiteTableExists(t, manager, "projects", false)
+	assertSQLiteTableExists(t, manager, "accounts", true)
+	histories, err := manager.historyDriver.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(histories) != 1 || histories[0].Name != "001_create_accounts" {
+		t.Fatalf("unexpected history after rollback: %+v", histories)
+	}
+
+	// --from-history needs stored SQL even when the file is present.
+	writeTestFile(t, migrationFile, testMultiRootMigrationBCL())
+	histories[0].DownSQL = nil
+	if err := manager.historyDriver.Rollback(histories...); err != nil {
+		t.Fatalf("rewrite history: %v", err)
+	}
+	manager.FromHistory = true
+	if err := manager.RollbackMigration(1); err == nil {
+		t.Fatal("expected --from-history to fail without stored Down SQL")
+	}
+	assertSQLiteTableExists(t, manager, "accounts", true)
+}
+
+func TestHistoryPruneArchivesOldEntries(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	migrationFile := filepath.Join(manager.MigrationDir(), "001_multi.bcl")
+	writeTestFile(t, migrationFile, testMultiRootMigrationBCL())
+	if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	out := filepath.Join(t.TempDir(), "history.json")
+	if err := (&HistoryPruneCommand{Driver: manager}).Handle(testContext{options: map[string]string{"keep": "1", "out": out}}); err != nil {
+		t.Fatalf("history:prune: %v", err)
+	}
+
+	var live int
+	if err := manager.dbDriver.QueryRow(context.Background(), &live, `SELECT COUNT(*) FROM migrations`); err != nil {
+		t.Fatalf("count history rows: %v", err)
+	}
+	if live != 1 {
+		t.Fatalf("expected 1 history row left after pruning, got %d", live)
+	}
+	histories, err := manager.historyDriver.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(histories) != 2 || !histories[0].Archived || histories[0].Name != "001_create_accounts" || histories[1].Archived {
+		t.Fatalf("expected the archived entry to be loaded before the kept one, got %+v", histories)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil || !strings.Contains(string(data), "001_create_accounts") {
+		t.Fatalf("expected the pruned entry in %s, got %q (%v)", out, data, err)
+	}
+
+	// Archived migrations can still be rolled back.
+	if err := manager.RollbackMigration(2); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	assertSQLiteTableExists(t, manager, "accounts", false)
+	if histories, err = manager.historyDriver.Load(); err != nil || len(histories) != 0 {
+		t.Fatalf("expected an empty history after rollback, got %+v (%v)", histories, err)
+	}
+}
+
+func TestHistoryExportImportBetweenDrivers(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_multi.bcl"), testMultiRootMigrationBCL())
+	if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	export := filepath.Join(t.TempDir(), "history.json")
+	if err := (&HistoryExportCommand{Driver: manager}).Handle(testContext{options: map[string]string{"out": export}}); err != nil {
+		t.Fatalf("history:export: %v", err)
+	}
+
+	manager.historyDriver = NewFileHistoryDriver(filepath.Join(t.TempDir(), "migration_history.txt"))
+	importCmd := &HistoryImportCommand{Driver: manager}
+	for i := 0; i < 2; i++ {
+		if err := importCmd.Handle(testContext{options: map[string]string{"in": export}}); err != nil {
+			t.Fatalf("history:import: %v", err)
+		}
+	}
+	histories, err := manager.historyDriver.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(histories) != 2 || histories[0].Name != "001_create_accounts" || histories[1].Name != "002_create_projects" || len(histories[1].DownSQL) == 0 {
+		t.Fatalf("expected both migrations imported once with their Down SQL, got %+v", histories)
+	}
+
+	exported, err := ReadHistoryExport(export)
+	if err != nil {
+		t.Fatalf("ReadHistoryExport: %v", err)
+	}
+	exported[0].Checksum = "changed"
+	if _, err := manager.ImportHistory(exported, false); err == nil {
+		t.Fatal("expected importing a conflicting checksum to fail")
+	}
+	if _, err := manager.ImportHistory(exported[:1], true); err != nil {
+		t.Fatalf("ImportHistory replace: %v", err)
+	}
+	if histories, err = manager.historyDriver.Load(); err != nil || len(histories) != 1 || histories[0].Checksum != "changed" {
+		t.Fatalf("expected the history replaced by the imported entry, got %+v (%v)", histories, err)
+	}
+}
+
+func TestIdentifierPrefixAppliesToTablesSeedsAndConstraints(t *testing.T) {
+	manager := newSQLiteWorkflowManager(t)
+	manager.identifierPrefix = "app1_"
+	migrationFile := filepath.Join(manager.MigrationDir(), "001_multi.bcl")
+	writeTestFile(t, migrationFile, testMultiRootMigrationBCL())
+	if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	assertSQLiteTableExists(t, manager, "app1_accounts", true)
+	assertSQLiteTableExists(t, manager, "accounts", false)
+
+	seedFile := filepath.Join(manager.SeedDir(), "accounts.bcl")
+	writeTestFile(t, seedFile, `
+Seed "accounts" {
+  table = "accounts"
+  Field "name" {
+    value = "acme"
+  }
+  rows = 1
+}
+`)
+	if err := manager.RunSeeds(false, false, seedFile); err != nil {
+		t.Fatalf("RunSeeds: %v", err)
+	}
+	var count int
+	if err := manager.dbDriver.DB().Select(&count, `SELECT COUNT(*) FROM app1_accounts`); err != nil || count != 1 {
+		t.Fatalf("expected the seed in app1_accounts, got %d (%v)", count, err)
+	}
+
+	if err := manager.RollbackMigration(1); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	assertSQLiteTableExists(t, manager, "app1_projects", false)
+
+	migration := Migration{Name: "003_link", Up: Operation{AlterTable: []AlterTable{{
+		Name:      "projects",
+		AddFields: []AddField{{Name: "account_id", Type: "integer", ForeignKey: &ForeignKey{ReferenceTable: "accounts", ReferenceField: "id"}}},
+	}}}}
+	stmts, err := migration.WithPrefix("app1_").Plan(DialectPostgres, true)
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	var sqls []string
+	for _, st := range stmts {
+		sqls = append(sqls, st.SQL)
+	}
+	joined := strings.Join(sqls, "\n")
+	if !strings.Contains(joined, `CONSTRAINT "app1_fk_account_id"`) || !strings.Contains(joined, `REFERENCES "app1_accounts" ("id")`) {
+		t.Fatalf("expected a prefixed foreign key, got:\n%s", joined)
+	}
+	if migration.Up.AlterTable[0].AddFields[0].ForeignKey.Name != "" {
+		t.Fatal("WithPrefix modified the original migration")
+	}
+}
+
+func TestNamingConventionTemplatesAndTruncation(t *testing.T) {
+	if err := (NamingConvention{Index: "{table}_{col}_idx"}).Validate(); err == nil {
+		t.Fatal("expected an unknown placeholder to be rejected")
+	}
+	dir := t.TempDir()
+	if _, err := NewManagerE(WithMigrationDir(filepath.Join(dir, "migrations")), WithSeedDir(filepath.Join(dir, "seeds")),
+		WithNamingConvention(NamingConvention{Index: "{table}_{col}_idx"})); err == nil {
+		t.Fatal("expected NewManagerE to reject an invalid naming convention")
+	}
+	nc := NamingConvention{Index: "{table}_{column}_idx", ForeignKey: "{table}_{column}_fkey"}
+	table := strings.Repeat("very_long_table_name_", 3)
+	migration := Migration{Up: Operation{AlterTable: []AlterTable{{Name: table, AddFields: []AddField{{
+		Name: "owner_account_identifier", Type: "integer", Index: true, Unique: true,
+		ForeignKey: &ForeignKey{ReferenceTable: "accounts", ReferenceField: "id"},
+	}}}}}}
+	field := migration.WithNaming(nc).Up.AlterTable[0].AddFields[0]
+	queries, err := field.ToSQL(DialectPostgres, table)
+	if err != nil {
+		t.Fatalf("AddField.ToSQL: %v", err)
+	}
+	joined := strings.Join(queries, "\n")
+	name := indexName(DialectPostgres, table, field)
+	if len(name) != 63 || !strings.HasPrefix(name, "very_long_table_name_") || !strings.Contains(joined, `CREATE INDEX "`+name+`" `) {
+		t.Fatalf("expected a 63 character hashed index name, got %q in:\n%s", name, joined)
+	}
+	if name == indexName(DialectPostgres, table+"x", field) {
+		t.Fatal("truncated names of different tables collide")
+	}
+	if !strings.Contains(joined, `"uniq_`+table[:20]) {
+		t.Fatalf("expected the default unique template to be kept, got:\n%s", joined)
+	}
+	if got := indexName(DialectSQLite, table, field); got != table+"_"+field.Name+"_idx" {
+		t.Fatalf("SQLite names should not be truncated, got %q", got)
+	}
+	if got := foreignKeyName(DialectMySQL, "projects", field); got != "projects_owner_account_identifier_fkey" {
+		t.Fatalf("foreign key name = %q", got)
+	}
+	if got := indexName(DialectPostgres, "projects", migration.Up.AlterTable[0].AddFields[0]); got != "idx_projects_owner_account_identifier" {
+		t.Fatalf("WithNaming modified the original migration, index name = %q", got)
+	}
+
+	accents := strings.Repeat("é", 40)
+	if got := fitIdentifier(DialectPostgres, accents, 0); len(got) > 63 || !utf8.ValidString(got) {
+		t.Fatalf("PostgreSQL name %q is %d bytes or splits a character", got, len(got))
+	}
+	if got := fitIdentifier(DialectMySQL, strings.Repeat("é", 60), 0); got != strings.Repeat("é", 60) {
+		t.Fatalf("MySQL counts characters, yet a 60 character name became %q", got)
+	}
+	if got := fitIdentifier(DialectMySQL, strings.Repeat("é", 70), 0); utf8.RuneCountInString(got) != 64 || !strings.HasPrefix(got, strings.Repeat("é", 55)+"_") {
+		t.Fatalf("MySQL name = %q, want 55 characters and a hash", got)
+	}
+}
+
+func TestNamingConventionIsPerManager(t *testing.T) {
+	body := `Migration "001_posts" {
+  Version = "1.0.0"
+  Description = "Posts."
+  Connection = "default"
+  Up {
+    CreateTable "posts" {
+      Field "author" {
+        type = "string"
+        size = 64
+        index = true
+      }
+    }
+  }
+  Down {
+    DropTable "posts" {}
+  }
+}
+`
+	newManager := func(nc NamingConvention) *Manager {
+		t.Helper()
+		dir := t.TempDir()
+		manager, err := NewManagerE(WithMigrationDir(filepath.Join(dir, "migrations")), WithSeedDir(filepath.Join(dir, "seeds")),
+			WithDialect(DialectPostgres), WithNamingConvention(nc))
+		if err != nil {
+			t.Fatalf("NewManagerE: %v", err)
+		}
+		writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_posts.bcl"), body)
+		return manager
+	}
+	indexedSQL := func(manager *Manager) string {
+		t.Helper()
+		cached, err := manager.readMigrationsBCL(filepath.Join(manager.MigrationDir(), "001_posts.bcl"))
+		if err != nil {
+			t.Fatalf("readMigrationsBCL: %v", err)
+		}
+		statements, err := manager.migrationSQL(cached.checksum, cached.migrations[0], DialectPostgres, true)
+		if err != nil {
+			t.Fatalf("migrationSQL: %v", err)
+		}
+		return strings.Join(statementSQL(statements), "\n")
+	}
+	first := newManager(NamingConvention{Index: "{table}_{column}_idx"})
+	second := newManager(NamingConvention{Index: "ix_{column}"})
+	if got := indexedSQL(first); !strings.Contains(got, `"posts_author_idx"`) {
+		t.Fatalf("first manager should use its own convention, got:\n%s", got)
+	}
+	if got := indexedSQL(second); !strings.Contains(got, `"ix_author"`) {
+		t.Fatalf("second manager should use its own convention, got:\n%s", got)
+	}
+}
+
+func TestGeneratedStatementsQuoteMixedCaseIdentifiers(t *testing.T) {
+	field := AddField{Name: "OwnerId", Type: "integer", Nullable: true, Index: true, Unique: true,
+		ForeignKey: &ForeignKey{ReferenceTable: "Accounts", ReferenceField: "Id"}}
+	for dialect, quote := range map[string]func(string) string{
+		DialectPostgres: (&PostgresDialect{}).quoteIdentifier,
+		DialectMySQL:    (&MySQLDialect{}).quoteIdentifier,
+	} {
+		queries, err := field.ToSQL(dialect, "Order")
+		if err != nil {
+			t.Fatalf("%s AddField.ToSQL: %v", dialect, err)
+		}
+		joined := strings.Join(queries, "\n")
+		for _, want := range []string{
+			"CREATE UNIQUE INDEX " + quote("uniq_Order_OwnerId") + " ON " + quote("Order") + " (" + quote("OwnerId") + ")",
+			"CREATE INDEX " + quote("idx_Order_OwnerId") + " ON " + quote("Order") + " (" + quote("OwnerId") + ")",
+			"ALTER TABLE " + quote("Order") + " ADD CONSTRAINT " + quote("fk_OwnerId") + " FOREIGN KEY (" + quote("OwnerId") + ") REFERENCES " + quote("Accounts") + " (" + quote("Id") + ")",
+		} {
+			if !strings.Contains(joined, want) {
+				t.Fatalf("%s: expected %q in:\n%s", dialect, want, joined)
+			}
+		}
+	}
+	if got := (&PostgresDialect{}).quoteIdentifier(`a"b`); got != `"a""b"` {
+		t.Fatalf("postgres quoting = %s", got)
+	}
+	if got := (&MySQLDialect{}).quoteIdentifier("a`b"); got != "`a``b`" {
+		t.Fatalf("mysql quoting = %s", got)
+	}
+
+	manager := newSQLiteWorkflowManager(t)
+	writeTestFile(t, filepath.Join(manager.MigrationDir(), "001_mixed_case.bcl"), `
+Migration "001_mixed_case" {
+  Version = "1.0.0"
+  Description = "Mixed-case table."
+  Up {
+    CreateTable "Order" {
+      Field "Id" {
+        type = "integer"
+        primary_key = true
+      }
+    }
+    AlterTable "Order" {
+      AddField "CustomerName" {
+        type = "string"
+        size = 64
+        nullable = true
+        index = true
+        unique = true
+      }
+    }
+  }
+  Down {
+    DropTable "Order" {}
+  }
+}
+`)
+	if err := (&MigrateCommand{Driver: manager}).Handle(testContext{options: map[string]string{}}); err != nil {
+		t.Fatalf("migrate mixed-case table: %v", err)
+	}
+	assertSQLiteTableExists(t, manager, "Order", true)
+}
+
+func TestFileHistoryLocation(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	migrationDir := filepath.Join(dir, "db", "migrations")
+	historyPath := func(opts ...ManagerOption) string {
+		t.Helper()
+		opts = append([]ManagerOption{WithMigrationDir(migrationDir), WithSeedDir(filepath.Join(dir, "seeds"))}, opts...)
+		manager, err := NewManagerE(opts...)
+		if err != nil {
+			t.Fatalf("NewManagerE: %v", err)
+		}
+		driver, ok := manager.historyDriver.(*FileHistoryDriver)
+		if !ok {
+			t.Fatalf("history driver = %T, want *FileHistoryDriver", manager.historyDriver)
+		}
+		return driver.filePath
+	}
+
+	if got, want := historyPath(), filepath.Join(migrationDir, "migration_history.txt"); got != want {
+		t.Fatalf("default history file = %s, want %s", got, want)
+	}
+	if got := historyPath(WithHistoryFile("custom/history.json")); got != "custom/history.json" {
+		t.Fatalf("configured history file = %s", got)
+	}
+
+	state := filepath.Join(dir, "state")
+	t.Setenv("XDG_STATE_HOME", state)
+	got := historyPath(WithHistoryFile(HistoryFileState))
+	if !strings.HasPrefix(got, filepath.Join(state, "migrate", "db-")) || filepath.Base(got) != "migration_history.txt" {
+		t.Fatalf("state history file = %s", got)
+	}
+	if other, err := stateHistoryPath(filepath.Join(dir, "other", "migrations")); err != nil || other == got {
+		t.Fatalf("state history file of another project = %s, %v", other, err)
+	}
+
+	// A history left in the working directory by earlier versions is kept.
+	writeTestFile(t, filepath.Join(dir, "migration_history.txt"), "[]")
+	if got := historyPath(); got != "migration_history.txt" {
+		t.Fatalf("history file with a legacy file present = %s", got)
+	}
+}
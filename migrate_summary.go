@@ -0,0 +1,96 @@
+package migrate
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/oarkflow/json"
+)
+
+// MigrateSummary reports what a migrate run did. migrate prints it when it
+// finishes, as JSON with --format=json so CI jobs can keep it as an artifact.
+type MigrateSummary struct {
+	Migrations []MigrationTiming
+	RowsSeeded int64
+	Total      time.Duration
+	started    time.Time
+}
+
+// MigrationTiming is the time the statements of one migration took.
+type MigrationTiming struct {
+	Name     string
+	Duration time.Duration
+}
+
+func newMigrateSummary() *MigrateSummary {
+	return &MigrateSummary{started: time.Now()}
+}
+
+// collectSummary records the migrations applied and the rows seeded into
+// summary until the returned function is called.
+func (d *Manager) collectSummary(summary *MigrateSummary) (stop func()) {
+	sinks := d.eventSinks
+	d.eventSinks = append(sinks[:len(sinks):len(sinks)], func(e Event) {
+		switch e := e.(type) {
+		case *MigrationApplied:
+			summary.Migrations = append(summary.Migrations, MigrationTiming{Name: e.Migration, Duration: e.Duration})
+		case *SeedRowBatch:
+			summary.RowsSeeded += e.Rows
+		}
+	})
+	return func() {
+		d.eventSinks = sinks
+	}
+}
+
+// MarshalJSON writes durations in milliseconds.
+func (s *MigrateSummary) MarshalJSON() ([]byte, error) {
+	type timing struct {
+		Name       string  `json:"name"`
+		DurationMS float64 `json:"duration_ms"`
+	}
+	migrations := make([]timing, len(s.Migrations))
+	for i, m := range s.Migrations {
+		migrations[i] = timing{Name: m.Name, DurationMS: milliseconds(m.Duration)}
+	}
+	return json.Marshal(struct {
+		Status     string   `json:"status"`
+		Applied    int      `json:"applied"`
+		Migrations []timing `json:"migrations"`
+		RowsSeeded int64    `json:"rows_seeded"`
+		TotalMS    float64  `json:"total_ms"`
+	}{"ok", len(s.Migrations), migrations, s.RowsSeeded, milliseconds(s.Total)})
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// write finishes the summary and prints it to w as a table, or as JSON when
+// format is "json".
+func (s *MigrateSummary) write(w io.Writer, format string) error {
+	s.Total = time.Since(s.started)
+	if format == "json" {
+		data, err := json.Marshal(s)
+		if err != nil {
+			return fmt.Errorf("failed to marshal migrate summary: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+	width := len("Migration")
+	for _, m := range s.Migrations {
+		width = max(width, len(m.Name))
+	}
+	fmt.Fprintln(w)
+	if len(s.Migrations) > 0 {
+		fmt.Fprintf(w, "%-*s  %s\n", width, "Migration", "Duration")
+		for _, m := range s.Migrations {
+			fmt.Fprintf(w, "%-*s  %s\n", width, m.Name, m.Duration.Round(time.Microsecond))
+		}
+	}
+	_, err := fmt.Fprintf(w, "Applied %d migration(s), seeded %d row(s) in %s\n",
+		len(s.Migrations), s.RowsSeeded, s.Total.Round(time.Millisecond))
+	return err
+}
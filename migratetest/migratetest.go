@@ -0,0 +1,168 @@
+// Package migratetest applies a service's migrations to a scratch database
+// inside Go tests and asserts the schema they produce.
+//
+//	func TestMigrations(t *testing.T) {
+//		h := migratetest.NewSQLite(t, "../migrations")
+//		h.RoundTrip()
+//		h.HasTable("users")
+//		h.HasColumn("users", "email")
+//		h.HasIndex("users", "uniq_users_email")
+//	}
+//
+// NewSQLite needs nothing but the migration directory. To test against a
+// real server, start one (for example a Postgres container with dockertest)
+// and pass its DSN to New.
+package migratetest
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/oarkflow/migrate"
+)
+
+// Harness applies the migrations in one directory to one database and fails
+// its test on any error.
+type Harness struct {
+	t testing.TB
+	// Manager is the manager the harness drives, for checks the harness
+	// does not cover.
+	Manager *migrate.Manager
+}
+
+var databaseSeq atomic.Int64
+
+// NewSQLite returns a Harness over a private in-memory SQLite database that is
+// dropped when t ends.
+func NewSQLite(t testing.TB, migrationDir string, opts ...migrate.ManagerOption) *Harness {
+	t.Helper()
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(t.Name())
+	dsn := fmt.Sprintf("file:migratetest_%s_%d?mode=memory&cache=shared", name, databaseSeq.Add(1))
+	return New(t, migrate.DialectSQLite, dsn, migrationDir, opts...)
+}
+
+// New returns a Harness over the database at dsn. The database should be
+// empty; the harness does not drop what the migrations leave behind.
+// Migration history is kept in the database itself. opts are applied after
+// the harness's own options and may override them.
+func New(t testing.TB, dialect, dsn, migrationDir string, opts ...migrate.ManagerOption) *Harness {
+	t.Helper()
+	driver, err := migrate.NewDriver(dialect, dsn)
+	if err != nil {
+		t.Fatalf("migratetest: open %s database: %v", dialect, err)
+	}
+	// An in-memory SQLite database lives as long as its last connection, so
+	// the driver stays open until the test ends.
+	t.Cleanup(func() { driver.DB().Close() })
+	historyDriver, err := migrate.NewHistoryDriver("db", dialect, dsn, "migrations")
+	if err != nil {
+		t.Fatalf("migratetest: open %s history: %v", dialect, err)
+	}
+	all := append([]migrate.ManagerOption{
+		migrate.WithMigrationDir(migrationDir),
+		migrate.WithSeedDir(filepath.Join(migrationDir, "seeds")),
+		migrate.WithDialect(dialect),
+		migrate.WithDriver(driver),
+		migrate.WithHistoryDriver(historyDriver),
+	}, opts...)
+	return &Harness{t: t, Manager: migrate.NewManager(all...)}
+}
+
+// Up applies every pending migration.
+func (h *Harness) Up() {
+	h.t.Helper()
+	cmd := &migrate.MigrateCommand{Driver: h.Manager}
+	if err := cmd.Handle(options{}); err != nil {
+		h.t.Fatalf("migratetest: migrate: %v", err)
+	}
+}
+
+// Down rolls back every applied migration.
+func (h *Harness) Down() {
+	h.t.Helper()
+	if err := h.Manager.ResetMigrations(); err != nil {
+		h.t.Fatalf("migratetest: reset: %v", err)
+	}
+	pending, err := h.Manager.PendingMigrations()
+	if err != nil {
+		h.t.Fatalf("migratetest: list pending migrations: %v", err)
+	}
+	all, err := h.Manager.ListMigrationMap()
+	if err != nil {
+		h.t.Fatalf("migratetest: list migrations: %v", err)
+	}
+	if len(pending) != len(all) {
+		h.t.Fatalf("migratetest: %d of %d migrations still applied after reset", len(all)-len(pending), len(all))
+	}
+}
+
+// RoundTrip applies every migration, rolls them all back and applies them
+// again. It catches Down blocks that leave objects behind or drop too
+// much, which only surface when Up runs a second time.
+func (h *Harness) RoundTrip() {
+	h.t.Helper()
+	h.Up()
+	h.Down()
+	h.Up()
+}
+
+// HasTable fails the test unless table exists.
+func (h *Harness) HasTable(table string) {
+	h.t.Helper()
+	h.expect(h.Manager.TableExists(table))(true, "table %s", table)
+}
+
+// NoTable fails the test if table exists.
+func (h *Harness) NoTable(table string) {
+	h.t.Helper()
+	h.expect(h.Manager.TableExists(table))(false, "table %s", table)
+}
+
+// HasColumn fails the test unless table has column.
+func (h *Harness) HasColumn(table, column string) {
+	h.t.Helper()
+	h.expect(h.Manager.ColumnExists(table, column))(true, "column %s.%s", table, column)
+}
+
+// NoColumn fails the test if table has column.
+func (h *Harness) NoColumn(table, column string) {
+	h.t.Helper()
+	h.expect(h.Manager.ColumnExists(table, column))(false, "column %s.%s", table, column)
+}
+
+// HasIndex fails the test unless table has the index named index.
+func (h *Harness) HasIndex(table, index string) {
+	h.t.Helper()
+	h.expect(h.Manager.IndexExists(table, index))(true, "index %s on %s", index, table)
+}
+
+// NoIndex fails the test if table has the index named index.
+func (h *Harness) NoIndex(table, index string) {
+	h.t.Helper()
+	h.expect(h.Manager.IndexExists(table, index))(false, "index %s on %s", index, table)
+}
+
+// expect returns a check that the result of an existence query is want.
+func (h *Harness) expect(exists bool, err error) func(want bool, format string, args ...any) {
+	return func(want bool, format string, args ...any) {
+		h.t.Helper()
+		what := fmt.Sprintf(format, args...)
+		if err != nil {
+			h.t.Fatalf("migratetest: check %s: %v", what, err)
+		}
+		if exists != want {
+			h.t.Errorf("migratetest: %s exists = %t, want %t", what, exists, want)
+		}
+	}
+}
+
+// options is the command context the harness passes to MigrateCommand: no
+// arguments and every option at its default.
+type options struct{}
+
+func (options) Argument(int) string  { return "" }
+func (options) Arguments() []string  { return nil }
+func (options) Option(string) string { return "" }
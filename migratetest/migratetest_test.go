@@ -0,0 +1,62 @@
+package migratetest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeMigrations(t *testing.T) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "migrations")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	body := `
+Migration "001_create_users" {
+  Version = "1.0.0"
+  Up {
+    CreateTable "users" {
+      Field "id" {
+        type = "integer"
+        primary_key = true
+      }
+      Field "email" {
+        type = "string"
+        size = 255
+        unique = true
+      }
+    }
+  }
+  Down {
+    DropTable "users" {}
+  }
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "001_create_users.bcl"), []byte(body), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return dir
+}
+
+func TestHarnessRoundTripAndSchemaAssertions(t *testing.T) {
+	h := NewSQLite(t, writeMigrations(t))
+	h.RoundTrip()
+	h.HasTable("users")
+	h.HasColumn("users", "email")
+	h.NoColumn("users", "name")
+	h.HasIndex("users", "uniq_users_email")
+	h.NoIndex("users", "idx_users_email")
+
+	h.Down()
+	h.NoTable("users")
+}
+
+func TestNewSQLiteDatabasesAreIsolated(t *testing.T) {
+	dir := writeMigrations(t)
+	first := NewSQLite(t, dir)
+	first.Up()
+	second := NewSQLite(t, dir)
+	second.NoTable("users")
+	first.HasTable("users")
+}
@@ -4,8 +4,10 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/oarkflow/squealx"
 
@@ -36,7 +38,66 @@ type Migration struct {
 	Down        Operation     `json:"Down"`
 	Transaction []Transaction `json:"Transaction"`
 	Validate    []Validation  `json:"Validate"`
-	Disable     bool          `json:"Disable,omitempty"`
+	Disabled    bool          `json:"Disabled,omitempty"`
+	// Disable is the former name of Disabled and is still honoured.
+	//
+	// Deprecated: Use Disabled.
+	Disable bool `json:"Disable,omitempty"`
+	// Tags select the migration with migrate --tags.
+	Tags []string `json:"Tags,omitempty"`
+	// Repeatable migrations are applied again whenever their checksum
+	// changes, so the latest definition of a view or function always wins.
+	Repeatable bool `json:"Repeatable,omitempty"`
+	// Phase is PhasePreDeploy (the default) or PhasePostDeploy.
+	Phase string `json:"Phase,omitempty"`
+	// Atomic is the atomic setting of the file declaring the migration. When
+	// false every statement runs outside the batch transaction; nil means
+	// true.
+	Atomic *bool `json:"Atomic,omitempty"`
+	// UpSteps and DownSteps hold the blocks of a migration declaring several
+	// Up or Down blocks, which run one after another in declaration order.
+	// Up and Down hold the merged operations of all blocks.
+	UpSteps   []OperationStep `json:"UpSteps,omitempty"`
+	DownSteps []OperationStep `json:"DownSteps,omitempty"`
+	// RunAfter holds the migration back until this RFC 3339 time.
+	RunAfter string `json:"RunAfter,omitempty"`
+	// RequiresWindow holds the migration back until a maintenance window is
+	// opened with --include-scheduled, or until RunAfter has passed.
+	RequiresWindow bool `json:"RequiresWindow,omitempty"`
+	// Author, Ticket and ReviewedBy record who wrote the migration, the
+	// change it belongs to and who approved it.
+	Author     string `json:"Author,omitempty"`
+	Ticket     string `json:"Ticket,omitempty"`
+	ReviewedBy string `json:"ReviewedBy,omitempty"`
+	// DSLVersion and ToolVersion are the DSL version and migrate version the
+	// file was written with. DSLVersion is 0 for files older than version 2.
+	DSLVersion  int    `json:"DSLVersion,omitempty"`
+	ToolVersion string `json:"ToolVersion,omitempty"`
+}
+
+// isDisabled reports whether m is disabled under its current or former
+// field name.
+func (m Migration) isDisabled() bool {
+	return m.Disabled || m.Disable
+}
+
+// ScheduleHold reports why migrate should not apply m at now, or "" when it
+// may run. It fails when RunAfter is not an RFC 3339 time.
+func (m Migration) ScheduleHold(now time.Time) (string, error) {
+	if m.RunAfter != "" {
+		runAfter, err := time.Parse(time.RFC3339, m.RunAfter)
+		if err != nil {
+			return "", fmt.Errorf("invalid RunAfter %q for migration %s: %w", m.RunAfter, m.Name, err)
+		}
+		if now.Before(runAfter) {
+			return fmt.Sprintf("scheduled to run after %s", runAfter.Format(time.RFC3339)), nil
+		}
+		return "", nil
+	}
+	if m.RequiresWindow {
+		return "requires a maintenance window", nil
+	}
+	return "", nil
 }
 
 type Operation struct {
@@ -62,6 +123,23 @@ type Operation struct {
 	CreateTrigger        []CreateTrigger        `json:"CreateTrigger,omitempty"`
 	DropTrigger          []DropTrigger          `json:"DropTrigger,omitempty"`
 	RenameTrigger        []RenameTrigger        `json:"RenameTrigger,omitempty"`
+	RebuildIndex         []RebuildIndex         `json:"RebuildIndex,omitempty"`
+	ReindexTable         []ReindexTable         `json:"ReindexTable,omitempty"`
+	CreatePublication    []CreatePublication    `json:"CreatePublication,omitempty"`
+	AlterPublication     []AlterPublication     `json:"AlterPublication,omitempty"`
+	DropPublication      []DropPublication      `json:"DropPublication,omitempty"`
+	CreateRole           []CreateRole           `json:"CreateRole,omitempty"`
+	AlterRole            []AlterRole            `json:"AlterRole,omitempty"`
+	DropRole             []DropRole             `json:"DropRole,omitempty"`
+	CreateDatabase       []CreateDatabase       `json:"CreateDatabase,omitempty"`
+	DropDatabase         []DropDatabase         `json:"DropDatabase,omitempty"`
+	Vacuum               []Vacuum               `json:"Vacuum,omitempty"`
+	Analyze              []Analyze              `json:"Analyze,omitempty"`
+	// Order lists the operation kinds (e.g. "DropView", "CreateTable") in
+	// declaration order. When set, Plan runs the items in that order instead
+	// of grouping them by kind. The BCL parser sets it for blocks with
+	// order = "declared".
+	Order []string `json:"Order,omitempty"`
 }
 
 type AlterTable struct {
@@ -69,12 +147,65 @@ type AlterTable struct {
 	AddFields    []AddField    `json:"AddField"`
 	DropFields   []DropField   `json:"DropField"`
 	RenameFields []RenameField `json:"RenameField"`
+	// DropPrimaryKey and AddPrimaryKey replace the primary key of the table;
+	// a table takes at most one of each.
+	DropPrimaryKey []DropPrimaryKey `json:"DropPrimaryKey,omitempty"`
+	AddPrimaryKey  []AddPrimaryKey  `json:"AddPrimaryKey,omitempty"`
+	Optional
+}
+
+// AddPrimaryKey makes Fields the primary key of a table. Name is the
+// constraint name where the dialect uses one; empty means {table}_pkey.
+type AddPrimaryKey struct {
+	Name   string   `json:"name,omitempty"`
+	Fields []string `json:"fields"`
+}
+
+// DropPrimaryKey drops the primary key of a table. Name is the constraint
+// name where the dialect uses one; empty means {table}_pkey.
+type DropPrimaryKey struct {
+	Name string `json:"name,omitempty"`
+}
+
+// primaryKeyName returns the name of the primary key constraint of table,
+// name when set.
+func primaryKeyName(dialect, table, name string) string {
+	if name != "" {
+		return name
+	}
+	return fitIdentifier(dialect, table+"_pkey", 0)
+}
+
+func (pk AddPrimaryKey) ToSQL(dialect, tableName string) (string, error) {
+	if err := requireFields(tableName); err != nil {
+		return "", fmt.Errorf("AddPrimaryKey: %w", err)
+	}
+	if len(pk.Fields) == 0 {
+		return "", fmt.Errorf("AddPrimaryKey on %s requires fields", tableName)
+	}
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.AddPrimaryKeySQL(pk, tableName)
+}
+
+func (pk DropPrimaryKey) ToSQL(dialect, tableName string) (string, error) {
+	if err := requireFields(tableName); err != nil {
+		return "", fmt.Errorf("DropPrimaryKey: %w", err)
+	}
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.DropPrimaryKeySQL(pk, tableName)
 }
 
 type CreateTable struct {
 	Name       string     `json:"name"`
 	AddFields  []AddField `json:"Field"`
 	PrimaryKey []string   `json:"PrimaryKey,omitempty"`
+	Optional
 }
 
 func (ct CreateTable) ToSQL(dialect string, up bool) (string, error) {
@@ -84,12 +215,20 @@ func (ct CreateTable) ToSQL(dialect string, up bool) (string, error) {
 	if len(ct.AddFields) == 0 {
 		return "", fmt.Errorf("CreateTable requires at least one column")
 	}
-	return GetDialect(dialect).CreateTableSQL(ct, up)
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.CreateTableSQL(ct, up)
 }
 
 type AddField struct {
-	Name          string      `json:"name"`
-	Type          string      `json:"type"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// RawType is the SQL type of the field as written, for types the
+	// generic types do not cover such as tsvector or geography(Point,4326);
+	// it replaces Type and Size.
+	RawType       string      `json:"raw_type,omitempty"`
 	Nullable      bool        `json:"nullable"`
 	Default       any         `json:"default,omitempty"`
 	Check         string      `json:"check,omitempty"`
@@ -100,9 +239,19 @@ type AddField struct {
 	Unique        bool        `json:"unique,omitempty"`
 	Index         bool        `json:"index,omitempty"`
 	ForeignKey    *ForeignKey `json:"foreign_key,omitempty"`
+	// Backfill is the value given to the existing rows of the table when a
+	// NOT NULL field without a default is added to it: the field is added
+	// as nullable, backfilled, then set NOT NULL.
+	Backfill any `json:"backfill,omitempty"`
+	// naming is the convention of the names generated for the field, set
+	// by Migration.WithNaming; nil means DefaultNamingConvention.
+	naming *NamingConvention
 }
 
 type ForeignKey struct {
+	// Name is the constraint name; empty means the name given by the
+	// naming convention, see NamingConvention.
+	Name           string `json:"name,omitempty"`
 	ReferenceTable string `json:"reference_table"`
 	ReferenceField string `json:"reference_field"`
 	OnDelete       string `json:"on_delete,omitempty"`
@@ -117,13 +266,19 @@ func (d DropField) ToSQL(dialect, tableName string) (string, error) {
 	if err := requireFields(tableName, d.Name); err != nil {
 		return "", fmt.Errorf("DropField: %w", err)
 	}
-	return GetDialect(dialect).DropFieldSQL(d, tableName)
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.DropFieldSQL(d, tableName)
 }
 
 type RenameField struct {
 	Name string `json:"name"`
 	From string `json:"from"`
 	To   string `json:"to"`
+	// Type also changes the type of the field. MySQL requires it, as its
+	// CHANGE clause restates the type.
 	Type string `json:"type,omitempty"`
 }
 
@@ -131,93 +286,132 @@ func (r RenameField) ToSQL(dialect, tableName string) (string, error) {
 	if err := requireFields(tableName, r.From, r.To); err != nil {
 		return "", fmt.Errorf("RenameField: %w", err)
 	}
-	return GetDialect(dialect).RenameFieldSQL(r, tableName)
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.RenameFieldSQL(r, tableName)
 }
 
 type RenameTable struct {
 	OldName string `json:"old_name"`
 	NewName string `json:"new_name"`
+	Optional
 }
 
 func (rt RenameTable) ToSQL(dialect string) (string, error) {
 	if err := requireFields(rt.OldName, rt.NewName); err != nil {
 		return "", fmt.Errorf("RenameTable: %w", err)
 	}
-	return GetDialect(dialect).RenameTableSQL(rt)
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.RenameTableSQL(rt)
 }
 
 type DeleteData struct {
 	Name  string `json:"name"`
 	Where string `json:"Where"`
+	Optional
 }
 
 func (d DeleteData) ToSQL(dialect string) (string, error) {
 	if err := requireFields(d.Name); err != nil {
 		return "", fmt.Errorf("DeleteData: %w", err)
 	}
-	return GetDialect(dialect).DeleteDataSQL(d)
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.DeleteDataSQL(d)
 }
 
 type DropEnumType struct {
 	Name     string `json:"name"`
 	IfExists bool   `json:"IfExists"`
+	Optional
 }
 
 func (d DropEnumType) ToSQL(dialect string) (string, error) {
 	if err := requireFields(d.Name); err != nil {
 		return "", fmt.Errorf("DropEnumType: %w", err)
 	}
-	return GetDialect(dialect).DropEnumTypeSQL(d)
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.DropEnumTypeSQL(d)
 }
 
 type DropRowPolicy struct {
 	Name     string `json:"name"`
 	Table    string `json:"Table"`
 	IfExists bool   `json:"if_exists,omitempty"`
+	Optional
 }
 
 func (drp DropRowPolicy) ToSQL(dialect string) (string, error) {
 	if err := requireFields(drp.Name); err != nil {
 		return "", fmt.Errorf("DropRowPolicy: %w", err)
 	}
-	return GetDialect(dialect).DropRowPolicySQL(drp)
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.DropRowPolicySQL(drp)
 }
 
 type DropMaterializedView struct {
 	Name     string `json:"name"`
 	IfExists bool   `json:"if_exists,omitempty"`
+	Optional
 }
 
 func (dmv DropMaterializedView) ToSQL(dialect string) (string, error) {
 	if err := requireFields(dmv.Name); err != nil {
 		return "", fmt.Errorf("DropMaterializedView: %w", err)
 	}
-	return GetDialect(dialect).DropMaterializedViewSQL(dmv)
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.DropMaterializedViewSQL(dmv)
 }
 
 type DropTable struct {
 	Name    string `json:"name"`
 	Cascade bool   `json:"cascade,omitempty"`
+	Optional
 }
 
 func (dt DropTable) ToSQL(dialect string) (string, error) {
 	if err := requireFields(dt.Name); err != nil {
 		return "", fmt.Errorf("DropTable: %w", err)
 	}
-	return GetDialect(dialect).DropTableSQL(dt)
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.DropTableSQL(dt)
 }
 
 type DropSchema struct {
 	Name     string `json:"name"`
 	Cascade  bool   `json:"cascade,omitempty"`
 	IfExists bool   `json:"if_exists,omitempty"`
+	Optional
 }
 
 func (ds DropSchema) ToSQL(dialect string) (string, error) {
 	if err := requireFields(ds.Name); err != nil {
 		return "", fmt.Errorf("DropSchema: %w", err)
 	}
-	return GetDialect(dialect).DropSchemaSQL(ds)
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.DropSchemaSQL(ds)
 }
 
 type Transaction struct {
@@ -236,159 +430,493 @@ func (a AddField) ToSQL(dialect, tableName string) ([]string, error) {
 	if err := requireFields(tableName); err != nil {
 		return nil, fmt.Errorf("AddField: %w", err)
 	}
-	return GetDialect(dialect).AddFieldSQL(a, tableName)
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return nil, err
+	}
+	return dial.AddFieldSQL(a, tableName)
 }
 
 type CreateView struct {
 	Name       string `json:"name"`
 	Definition string `json:"definition"`
 	OrReplace  bool   `json:"or_replace,omitempty"`
+	Optional
 }
 
 func (cv CreateView) ToSQL(dialect string) (string, error) {
 	if err := requireFields(cv.Name); err != nil {
 		return "", fmt.Errorf("CreateView: %w", err)
 	}
-	return GetDialect(dialect).CreateViewSQL(cv)
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.CreateViewSQL(cv)
 }
 
 type DropView struct {
 	Name     string `json:"name"`
 	Cascade  bool   `json:"cascade,omitempty"`
 	IfExists bool   `json:"if_exists,omitempty"`
+	Optional
 }
 
 func (dv DropView) ToSQL(dialect string) (string, error) {
 	if err := requireFields(dv.Name); err != nil {
 		return "", fmt.Errorf("DropView: %w", err)
 	}
-	return GetDialect(dialect).DropViewSQL(dv)
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.DropViewSQL(dv)
 }
 
 type RenameView struct {
 	OldName string `json:"old_name"`
 	NewName string `json:"new_name"`
+	Optional
 }
 
 func (rv RenameView) ToSQL(dialect string) (string, error) {
 	if err := requireFields(rv.OldName); err != nil {
 		return "", fmt.Errorf("RenameView: %w", err)
 	}
-	return GetDialect(dialect).RenameViewSQL(rv)
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.RenameViewSQL(rv)
 }
 
 type CreateFunction struct {
 	Name       string `json:"name"`
 	Definition string `json:"definition"`
 	OrReplace  bool   `json:"or_replace,omitempty"`
+	Optional
 }
 
 func (cf CreateFunction) ToSQL(dialect string) (string, error) {
 	if err := requireFields(cf.Name); err != nil {
 		return "", fmt.Errorf("CreateFunction: %w", err)
 	}
-	return GetDialect(dialect).CreateFunctionSQL(cf)
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.CreateFunctionSQL(cf)
 }
 
 type DropFunction struct {
 	Name     string `json:"name"`
 	Cascade  bool   `json:"cascade,omitempty"`
 	IfExists bool   `json:"if_exists,omitempty"`
+	Optional
 }
 
 func (df DropFunction) ToSQL(dialect string) (string, error) {
 	if err := requireFields(df.Name); err != nil {
 		return "", fmt.Errorf("DropFunction: %w", err)
 	}
-	return GetDialect(dialect).DropFunctionSQL(df)
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.DropFunctionSQL(df)
 }
 
 type RenameFunction struct {
 	OldName string `json:"old_name"`
 	NewName string `json:"new_name"`
+	Optional
 }
 
 func (rf RenameFunction) ToSQL(dialect string) (string, error) {
 	if err := requireFields(rf.OldName, rf.NewName); err != nil {
 		return "", fmt.Errorf("RenameFunction: %w", err)
 	}
-	return GetDialect(dialect).RenameFunctionSQL(rf)
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.RenameFunctionSQL(rf)
 }
 
 type CreateProcedure struct {
 	Name       string `json:"name"`
 	Definition string `json:"definition"`
 	OrReplace  bool   `json:"or_replace,omitempty"`
+	Optional
 }
 
 func (cp CreateProcedure) ToSQL(dialect string) (string, error) {
 	if err := requireFields(cp.Name); err != nil {
 		return "", fmt.Errorf("CreateProcedure: %w", err)
 	}
-	return GetDialect(dialect).CreateProcedureSQL(cp)
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.CreateProcedureSQL(cp)
 }
 
 type DropProcedure struct {
 	Name     string `json:"name"`
 	Cascade  bool   `json:"cascade,omitempty"`
 	IfExists bool   `json:"if_exists,omitempty"`
+	Optional
 }
 
 func (dp DropProcedure) ToSQL(dialect string) (string, error) {
 	if err := requireFields(dp.Name); err != nil {
 		return "", fmt.Errorf("DropProcedure: %w", err)
 	}
-	return GetDialect(dialect).DropProcedureSQL(dp)
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.DropProcedureSQL(dp)
 }
 
 type RenameProcedure struct {
 	OldName string `json:"old_name"`
 	NewName string `json:"new_name"`
+	Optional
 }
 
 func (rp RenameProcedure) ToSQL(dialect string) (string, error) {
 	if err := requireFields(rp.OldName, rp.NewName); err != nil {
 		return "", fmt.Errorf("RenameProcedure: %w", err)
 	}
-	return GetDialect(dialect).RenameProcedureSQL(rp)
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.RenameProcedureSQL(rp)
 }
 
 type CreateTrigger struct {
 	Name       string `json:"name"`
 	Definition string `json:"definition"`
 	OrReplace  bool   `json:"or_replace,omitempty"`
+	Optional
 }
 
 func (ct CreateTrigger) ToSQL(dialect string) (string, error) {
 	if err := requireFields(ct.Name); err != nil {
 		return "", fmt.Errorf("CreateTrigger: %w", err)
 	}
-	return GetDialect(dialect).CreateTriggerSQL(ct)
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.CreateTriggerSQL(ct)
 }
 
 type DropTrigger struct {
 	Name     string `json:"name"`
 	Cascade  bool   `json:"cascade,omitempty"`
 	IfExists bool   `json:"if_exists,omitempty"`
+	Optional
 }
 
 func (dt DropTrigger) ToSQL(dialect string) (string, error) {
 	if err := requireFields(dt.Name); err != nil {
 		return "", fmt.Errorf("DropTrigger: %w", err)
 	}
-	return GetDialect(dialect).DropTriggerSQL(dt)
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.DropTriggerSQL(dt)
 }
 
 type RenameTrigger struct {
 	OldName string `json:"old_name"`
 	NewName string `json:"new_name"`
+	Optional
 }
 
 func (rt RenameTrigger) ToSQL(dialect string) (string, error) {
 	if err := requireFields(rt.OldName, rt.NewName); err != nil {
 		return "", fmt.Errorf("RenameTrigger: %w", err)
 	}
-	return GetDialect(dialect).RenameTriggerSQL(rt)
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.RenameTriggerSQL(rt)
+}
+
+// RebuildIndex rebuilds a single index without blocking writes where the
+// database allows it. MySQL rebuilds the whole Table instead.
+type RebuildIndex struct {
+	Name  string `json:"name"`
+	Table string `json:"table,omitempty"`
+	Optional
+}
+
+func (ri RebuildIndex) ToSQL(dialect string) (string, error) {
+	if err := requireFields(ri.Name); err != nil {
+		return "", fmt.Errorf("RebuildIndex: %w", err)
+	}
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.RebuildIndexSQL(ri)
+}
+
+// ReindexTable rebuilds every index of a table.
+type ReindexTable struct {
+	Name string `json:"name"`
+	Optional
+}
+
+func (rt ReindexTable) ToSQL(dialect string) (string, error) {
+	if err := requireFields(rt.Name); err != nil {
+		return "", fmt.Errorf("ReindexTable: %w", err)
+	}
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.ReindexTableSQL(rt)
+}
+
+// CreatePublication creates a PostgreSQL logical replication publication.
+type CreatePublication struct {
+	Name      string   `json:"name"`
+	Tables    []string `json:"tables,omitempty"`
+	AllTables bool     `json:"all_tables,omitempty"`
+	// Publish limits the replicated operations, e.g. "insert, update".
+	Publish string `json:"publish,omitempty"`
+	Optional
+}
+
+func (cp CreatePublication) ToSQL(dialect string) (string, error) {
+	if err := requireFields(cp.Name); err != nil {
+		return "", fmt.Errorf("CreatePublication: %w", err)
+	}
+	if cp.AllTables && len(cp.Tables) > 0 {
+		return "", fmt.Errorf("CreatePublication %s: tables and all_tables are mutually exclusive", cp.Name)
+	}
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.CreatePublicationSQL(cp)
+}
+
+// AlterPublication changes the tables or operations of a publication, e.g.
+// to publish a table created earlier in the same migration.
+type AlterPublication struct {
+	Name       string   `json:"name"`
+	AddTables  []string `json:"add_tables,omitempty"`
+	DropTables []string `json:"drop_tables,omitempty"`
+	// SetTables replaces the published tables.
+	SetTables []string `json:"set_tables,omitempty"`
+	Publish   string   `json:"publish,omitempty"`
+	Optional
+}
+
+func (ap AlterPublication) ToSQL(dialect string) (string, error) {
+	if err := requireFields(ap.Name); err != nil {
+		return "", fmt.Errorf("AlterPublication: %w", err)
+	}
+	if len(ap.AddTables) == 0 && len(ap.DropTables) == 0 && len(ap.SetTables) == 0 && ap.Publish == "" {
+		return "", fmt.Errorf("AlterPublication %s: nothing to change", ap.Name)
+	}
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.AlterPublicationSQL(ap)
+}
+
+type DropPublication struct {
+	Name     string `json:"name"`
+	IfExists bool   `json:"if_exists,omitempty"`
+	Optional
+}
+
+func (dp DropPublication) ToSQL(dialect string) (string, error) {
+	if err := requireFields(dp.Name); err != nil {
+		return "", fmt.Errorf("DropPublication: %w", err)
+	}
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.DropPublicationSQL(dp)
+}
+
+// CreateRole creates a database role, or a user on MySQL when Login is set.
+// Password is a secret reference such as "env:BILLING_DB_PASSWORD" or
+// "file:/run/secrets/billing"; it is resolved when the SQL is generated.
+type CreateRole struct {
+	Name     string `json:"name"`
+	Login    bool   `json:"login,omitempty"`
+	Password string `json:"password,omitempty"`
+	// InRoles grants the listed roles to the new role.
+	InRoles []string `json:"in_roles,omitempty"`
+	// Options are appended verbatim on PostgreSQL, e.g. "CREATEDB".
+	Options []string `json:"options,omitempty"`
+	Optional
+	// keepSecretRef prints Password as its reference (see
+	// Migration.withSecretReferences).
+	keepSecretRef bool
+}
+
+func (cr CreateRole) ToSQL(dialect string) (string, error) {
+	if err := requireFields(cr.Name); err != nil {
+		return "", fmt.Errorf("CreateRole: %w", err)
+	}
+	if cr.Password != "" {
+		password, err := resolveRolePassword(cr.Password, cr.keepSecretRef)
+		if err != nil {
+			return "", fmt.Errorf("CreateRole %s: %w", cr.Name, err)
+		}
+		cr.Password = password
+	}
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.CreateRoleSQL(cr)
+}
+
+// AlterRole rotates the password of a role, renames it or changes its
+// options. Password is a secret reference, as for CreateRole.
+type AlterRole struct {
+	Name     string   `json:"name"`
+	Password string   `json:"password,omitempty"`
+	RenameTo string   `json:"rename_to,omitempty"`
+	Options  []string `json:"options,omitempty"`
+	Optional
+	// keepSecretRef prints Password as its reference, as for CreateRole.
+	keepSecretRef bool
+}
+
+func (ar AlterRole) ToSQL(dialect string) (string, error) {
+	if err := requireFields(ar.Name); err != nil {
+		return "", fmt.Errorf("AlterRole: %w", err)
+	}
+	if ar.Password == "" && ar.RenameTo == "" && len(ar.Options) == 0 {
+		return "", fmt.Errorf("AlterRole %s: nothing to change", ar.Name)
+	}
+	if ar.Password != "" {
+		password, err := resolveRolePassword(ar.Password, ar.keepSecretRef)
+		if err != nil {
+			return "", fmt.Errorf("AlterRole %s: %w", ar.Name, err)
+		}
+		ar.Password = password
+	}
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.AlterRoleSQL(ar)
+}
+
+type DropRole struct {
+	Name     string `json:"name"`
+	IfExists bool   `json:"if_exists,omitempty"`
+	Optional
+}
+
+func (dr DropRole) ToSQL(dialect string) (string, error) {
+	if err := requireFields(dr.Name); err != nil {
+		return "", fmt.Errorf("DropRole: %w", err)
+	}
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.DropRoleSQL(dr)
+}
+
+// CreateDatabase creates a database, e.g. to bootstrap an environment. It
+// always runs outside the migration transaction. SQLite has no database
+// level operations.
+type CreateDatabase struct {
+	Name string `json:"name"`
+	// IfNotExists is supported by MySQL only.
+	IfNotExists bool `json:"if_not_exists,omitempty"`
+	// Owner and Template are supported by PostgreSQL only.
+	Owner    string `json:"owner,omitempty"`
+	Template string `json:"template,omitempty"`
+	// Encoding is the character set, e.g. "UTF8" or "utf8mb4".
+	Encoding  string `json:"encoding,omitempty"`
+	Collation string `json:"collation,omitempty"`
+	Optional
+}
+
+func (cd CreateDatabase) ToSQL(dialect string) (string, error) {
+	if err := requireFields(cd.Name); err != nil {
+		return "", fmt.Errorf("CreateDatabase: %w", err)
+	}
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.CreateDatabaseSQL(cd)
+}
+
+// DropDatabase drops a database outside the migration transaction.
+type DropDatabase struct {
+	Name     string `json:"name"`
+	IfExists bool   `json:"if_exists,omitempty"`
+	// Force terminates the open connections first (PostgreSQL 13+).
+	Force bool `json:"force,omitempty"`
+	Optional
+}
+
+func (dd DropDatabase) ToSQL(dialect string) (string, error) {
+	if err := requireFields(dd.Name); err != nil {
+		return "", fmt.Errorf("DropDatabase: %w", err)
+	}
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.DropDatabaseSQL(dd)
+}
+
+// Vacuum reclaims the space of a table, or of the whole database when Name
+// is empty. SQLite always vacuums the whole database.
+type Vacuum struct {
+	Name string `json:"name,omitempty"`
+	// Full rewrites the table into a new file (PostgreSQL VACUUM FULL),
+	// holding an exclusive lock.
+	Full bool `json:"full,omitempty"`
+	// Analyze refreshes planner statistics afterwards.
+	Analyze bool `json:"analyze,omitempty"`
+	Optional
+}
+
+func (v Vacuum) ToSQL(dialect string) (string, error) {
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.VacuumSQL(v)
+}
+
+// Analyze refreshes the planner statistics of a table, or of the whole
+// database when Name is empty, e.g. after a backfill.
+type Analyze struct {
+	Name string `json:"name,omitempty"`
+	Optional
+}
+
+func (a Analyze) ToSQL(dialect string) (string, error) {
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	return dial.AnalyzeSQL(a)
 }
 
 func handleSQLiteAlterTable(at AlterTable) ([]string, error) {
@@ -435,6 +963,10 @@ func handleSQLiteAlterTable(at AlterTable) ([]string, error) {
 			for i, col := range newSchema.AddFields {
 				if col.Name == renameCol.From {
 					newSchema.AddFields[i].Name = renameCol.To
+					if renameCol.Type != "" {
+						newSchema.AddFields[i].Type = renameCol.Type
+						newSchema.AddFields[i].Size, newSchema.AddFields[i].Scale = 0, 0
+					}
 					found = true
 					renameMap[renameCol.From] = renameCol.To
 					break
@@ -449,7 +981,10 @@ func handleSQLiteAlterTable(at AlterTable) ([]string, error) {
 				}
 			}
 		}
-		sqliteDialect, _ := GetDialect(DialectSQLite).(*SQLiteDialect)
+		if err := newSchema.changePrimaryKey(at); err != nil {
+			return nil, err
+		}
+		sqliteDialect, _ := MustGetDialect(DialectSQLite).(*SQLiteDialect)
 		queries, err := sqliteDialect.RecreateTableForAlter(at.Name, newSchema, renameMap)
 		if err != nil {
 			return nil, fmt.Errorf("failed to recreate table for SQLite alteration: %w", err)
@@ -458,21 +993,82 @@ func handleSQLiteAlterTable(at AlterTable) ([]string, error) {
 		return queries, nil
 	}
 	var queries []string
+	recreate := len(at.DropPrimaryKey) > 0 || len(at.AddPrimaryKey) > 0
 	for _, addCol := range at.AddFields {
-		qList, err := addCol.ToSQL(DialectSQLite, at.Name)
+		// SQLite cannot add NOT NULL or a foreign key to a column, so the
+		// table is recreated with them once the fields are added and
+		// backfilled.
+		added := addCol
+		added.ForeignKey = nil
+		var qList []string
+		var err error
+		if addCol.backfills() {
+			qList, err = backfillAddFieldSQL(DialectSQLite, at.Name, added)
+			recreate = true
+		} else {
+			qList, err = added.ToSQL(DialectSQLite, at.Name)
+		}
+		recreate = recreate || addCol.ForeignKey != nil
 		if err != nil {
 			return nil, err
 		}
 		queries = append(queries, qList...)
+		// The statements of a migration can be generated more than once,
+		// e.g. for a preview, so the field may be known already.
+		if slices.ContainsFunc(newSchema.AddFields, func(f AddField) bool { return f.Name == addCol.Name }) {
+			continue
+		}
 		newSchema.AddFields = append(newSchema.AddFields, addCol)
 		if addCol.PrimaryKey {
 			newSchema.PrimaryKey = append(newSchema.PrimaryKey, addCol.Name)
 		}
 	}
+	if recreate {
+		if err := newSchema.changePrimaryKey(at); err != nil {
+			return nil, err
+		}
+		sqliteDialect, _ := MustGetDialect(DialectSQLite).(*SQLiteDialect)
+		recreated, err := sqliteDialect.RecreateTableForAlter(at.Name, newSchema, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to recreate table for SQLite alteration: %w", err)
+		}
+		queries = append(queries, recreated...)
+	}
 	tableSchemas[at.Name] = &newSchema
 	return queries, nil
 }
 
+// changePrimaryKey applies the DropPrimaryKey and AddPrimaryKey of at to
+// ct, for SQLite table recreation.
+func (ct *CreateTable) changePrimaryKey(at AlterTable) error {
+	if len(at.DropPrimaryKey) == 0 && len(at.AddPrimaryKey) == 0 {
+		return nil
+	}
+	if len(at.AddPrimaryKey) > 1 || len(at.DropPrimaryKey) > 1 {
+		return fmt.Errorf("table %s takes one AddPrimaryKey and one DropPrimaryKey", at.Name)
+	}
+	var fields []string
+	if len(at.AddPrimaryKey) == 1 {
+		fields = at.AddPrimaryKey[0].Fields
+		if len(fields) == 0 {
+			return fmt.Errorf("AddPrimaryKey on %s requires fields", at.Name)
+		}
+		for _, f := range fields {
+			if !slices.ContainsFunc(ct.AddFields, func(col AddField) bool { return col.Name == f }) {
+				return fmt.Errorf("field %s not found in table %s for primary key", f, at.Name)
+			}
+		}
+	} else if len(ct.PrimaryKey) == 0 && !slices.ContainsFunc(ct.AddFields, func(col AddField) bool { return col.PrimaryKey }) {
+		return fmt.Errorf("table %s has no primary key to drop", at.Name)
+	}
+	fields = slices.Clone(fields)
+	ct.PrimaryKey = fields
+	for i := range ct.AddFields {
+		ct.AddFields[i].PrimaryKey = slices.Contains(fields, ct.AddFields[i].Name)
+	}
+	return nil
+}
+
 type ToSQLWithTable interface {
 	ToSQL(dialect, tableName string) (string, error)
 }
@@ -497,8 +1093,27 @@ func (at AlterTable) ToSQL(dialect string) ([]string, error) {
 	if dialect == DialectSQLite {
 		return handleSQLiteAlterTable(at)
 	}
-	var queries []string
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return nil, err
+	}
+	queries, err := ParseQueriesWithTable(nil, dialect, at.Name, at.DropPrimaryKey...)
+	if err != nil {
+		return nil, fmt.Errorf("error in DropPrimaryKey: %w", err)
+	}
 	for _, addCol := range at.AddFields {
+		if addCol.backfills() {
+			qList, err := backfillAddFieldSQL(dialect, at.Name, addCol)
+			if err != nil {
+				return nil, fmt.Errorf("error in AddField: %w", err)
+			}
+			q, err := dial.SetNotNullSQL(addCol, at.Name)
+			if err != nil {
+				return nil, fmt.Errorf("error in AddField: %w", err)
+			}
+			queries = append(queries, append(qList, q)...)
+			continue
+		}
 		qList, err := addCol.ToSQL(dialect, at.Name)
 		if err != nil {
 			return nil, fmt.Errorf("error in AddField: %w", err)
@@ -507,7 +1122,6 @@ func (at AlterTable) ToSQL(dialect string) ([]string, error) {
 			queries = append(queries, qList...)
 		}
 	}
-	var err error
 	queries, err = ParseQueriesWithTable(queries, dialect, at.Name, at.DropFields...)
 	if err != nil {
 		return nil, fmt.Errorf("error in DropField: %w", err)
@@ -516,6 +1130,10 @@ func (at AlterTable) ToSQL(dialect string) ([]string, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error in RenameField: %w", err)
 	}
+	queries, err = ParseQueriesWithTable(queries, dialect, at.Name, at.AddPrimaryKey...)
+	if err != nil {
+		return nil, fmt.Errorf("error in AddPrimaryKey: %w", err)
+	}
 	return queries, nil
 }
 
@@ -541,15 +1159,113 @@ type toSQLBatch[T ToSQL] struct {
 	items []T
 }
 
+// Optional marks an operation item that may fail without aborting its
+// migration (continue_on_error = true in BCL). Where the database needs it the
+// generated statements run inside a SAVEPOINT so the failure can be undone.
+type Optional struct {
+	ContinueOnError bool `json:"continue_on_error,omitempty"`
+}
+
+func (o Optional) continueOnError() bool {
+	return o.ContinueOnError
+}
+
+// Statement is a SQL statement with its arguments and execution options, as
+// applied by IDatabaseDriver.ApplyBatch.
+type Statement = drivers.Statement
+
+// StatementOptions control how a Statement is executed.
+type StatementOptions = drivers.StatementOptions
+
+// statementSQL returns the SQL text of stmts.
+func statementSQL(stmts []Statement) []string {
+	queries := make([]string, len(stmts))
+	for i, st := range stmts {
+		queries[i] = st.SQL
+	}
+	return queries
+}
+
+// optionalStatement wraps q with the continue_on_error policy of its item.
+func optionalStatement(q string, continueOnError bool) Statement {
+	return Statement{SQL: q, Options: StatementOptions{ContinueOnError: continueOnError}}
+}
+
+type plannableSQL interface {
+	ToSQL
+	continueOnError() bool
+}
+
+func planQueries[T plannableSQL](planned []Statement, dialect string, items ...T) ([]Statement, error) {
+	for _, item := range items {
+		q, err := item.ToSQL(dialect)
+		if err != nil {
+			return nil, fmt.Errorf("error in ToSQL: %w", err)
+		}
+		if q != "" {
+			planned = append(planned, optionalStatement(q, item.continueOnError()))
+		}
+	}
+	return planned, nil
+}
+
+// planOutsideTransaction plans items like planQueries for statements that
+// cannot run inside a transaction, such as REINDEX CONCURRENTLY. SQLite
+// runs them in the migration transaction.
+func planOutsideTransaction[T plannableSQL](planned []Statement, dialect string, items ...T) ([]Statement, error) {
+	if dialect == DialectSQLite {
+		return planQueries(planned, dialect, items...)
+	}
+	return planNoTransaction(planned, dialect, items...)
+}
+
+// planNoTransaction plans items like planQueries for statements that never
+// run inside a transaction on any dialect, such as VACUUM or CREATE DATABASE.
+func planNoTransaction[T plannableSQL](planned []Statement, dialect string, items ...T) ([]Statement, error) {
+	start := len(planned)
+	planned, err := planQueries(planned, dialect, items...)
+	if err != nil {
+		return planned, err
+	}
+	for i := start; i < len(planned); i++ {
+		planned[i].Options.NoTransaction = true
+	}
+	return planned, nil
+}
+
 func (op Operation) ToSQL(dialect string) ([]string, error) {
-	var queries []string
+	planned, err := op.Plan(dialect)
+	if err != nil {
+		return nil, err
+	}
+	return statementSQL(planned), nil
+}
+
+// Plan generates the statements of op in execution order, keeping the
+// continue_on_error setting of the item each statement came from.
+func (op Operation) Plan(dialect string) ([]Statement, error) {
+	if len(op.Order) > 0 {
+		return op.planDeclared(dialect)
+	}
+	return op.planGrouped(dialect)
+}
+
+// planGrouped plans the items of op grouped by kind, in the order listed by
+// operationPlanOrder.
+func (op Operation) planGrouped(dialect string) ([]Statement, error) {
+	// Databases are created first so later statements of a bootstrap
+	// migration can target them.
+	planned, err := planNoTransaction(nil, dialect, op.CreateDatabase...)
+	if err != nil {
+		return nil, fmt.Errorf("error in CreateDatabase: %w", err)
+	}
 	for _, ct := range op.CreateTable {
 		q, err := ct.ToSQL(dialect, true)
 		if err != nil {
 			return nil, fmt.Errorf("error in CreateTable: %w", err)
 		}
 		if q != "" {
-			queries = append(queries, q)
+			planned = append(planned, optionalStatement(q, ct.ContinueOnError))
 		}
 		if dialect == DialectSQLite {
 			schemaMutex.Lock()
@@ -570,104 +1286,208 @@ func (op Operation) ToSQL(dialect string) ([]string, error) {
 		if err != nil {
 			return nil, fmt.Errorf("error in AlterTable: %w", err)
 		}
-		if len(qList) > 0 {
-			queries = append(queries, qList...)
+		for _, q := range qList {
+			planned = append(planned, optionalStatement(q, at.ContinueOnError))
 		}
 	}
-	var err error
-	queries, err = ParseQueries(queries, dialect, op.DeleteData...)
+	// Publications come before drops so they can reference tables created or
+	// altered above; dropping a table removes it from its publications.
+	planned, err = planQueries(planned, dialect, op.CreatePublication...)
+	if err != nil {
+		return nil, fmt.Errorf("error in CreatePublication: %w", err)
+	}
+	planned, err = planQueries(planned, dialect, op.AlterPublication...)
+	if err != nil {
+		return nil, fmt.Errorf("error in AlterPublication: %w", err)
+	}
+	planned, err = planQueries(planned, dialect, op.DropPublication...)
+	if err != nil {
+		return nil, fmt.Errorf("error in DropPublication: %w", err)
+	}
+	planned, err = planQueries(planned, dialect, op.CreateRole...)
+	if err != nil {
+		return nil, fmt.Errorf("error in CreateRole: %w", err)
+	}
+	planned, err = planQueries(planned, dialect, op.AlterRole...)
+	if err != nil {
+		return nil, fmt.Errorf("error in AlterRole: %w", err)
+	}
+	planned, err = planQueries(planned, dialect, op.DropRole...)
+	if err != nil {
+		return nil, fmt.Errorf("error in DropRole: %w", err)
+	}
+	planned, err = planQueries(planned, dialect, op.DeleteData...)
 	if err != nil {
 		return nil, fmt.Errorf("error in DeleteData: %w", err)
 	}
-	queries, err = ParseQueries(queries, dialect, op.DropEnumType...)
+	planned, err = planQueries(planned, dialect, op.DropEnumType...)
 	if err != nil {
 		return nil, fmt.Errorf("error in DropEnumType: %w", err)
 	}
-	queries, err = ParseQueries(queries, dialect, op.DropRowPolicy...)
+	planned, err = planQueries(planned, dialect, op.DropRowPolicy...)
 	if err != nil {
 		return nil, fmt.Errorf("error in DropRowPolicy: %w", err)
 	}
-	queries, err = ParseQueries(queries, dialect, op.DropMaterializedView...)
+	planned, err = planQueries(planned, dialect, op.DropMaterializedView...)
 	if err != nil {
 		return nil, fmt.Errorf("error in DropMaterializedView: %w", err)
 	}
-	queries, err = ParseQueries(queries, dialect, op.DropTable...)
+	planned, err = planQueries(planned, dialect, op.DropTable...)
 	if err != nil {
 		return nil, fmt.Errorf("error in DropTable: %w", err)
 	}
-	queries, err = ParseQueries(queries, dialect, op.DropSchema...)
+	planned, err = planQueries(planned, dialect, op.DropSchema...)
 	if err != nil {
 		return nil, fmt.Errorf("error in DropSchema: %w", err)
 	}
-	queries, err = ParseQueries(queries, dialect, op.RenameTable...)
+	planned, err = planNoTransaction(planned, dialect, op.DropDatabase...)
+	if err != nil {
+		return nil, fmt.Errorf("error in DropDatabase: %w", err)
+	}
+	planned, err = planQueries(planned, dialect, op.RenameTable...)
 	if err != nil {
 		return nil, fmt.Errorf("error in RenameTable: %w", err)
 	}
-	queries, err = ParseQueries(queries, dialect, op.CreateView...)
+	planned, err = planQueries(planned, dialect, op.CreateView...)
 	if err != nil {
 		return nil, fmt.Errorf("error in CreateView: %w", err)
 	}
-	queries, err = ParseQueries(queries, dialect, op.DropView...)
+	planned, err = planQueries(planned, dialect, op.DropView...)
 	if err != nil {
 		return nil, fmt.Errorf("error in DropView: %w", err)
 	}
-	queries, err = ParseQueries(queries, dialect, op.RenameView...)
+	planned, err = planQueries(planned, dialect, op.RenameView...)
 	if err != nil {
 		return nil, fmt.Errorf("error in RenameView: %w", err)
 	}
-	queries, err = ParseQueries(queries, dialect, op.CreateFunction...)
+	planned, err = planQueries(planned, dialect, op.CreateFunction...)
 	if err != nil {
 		return nil, fmt.Errorf("error in CreateFunction: %w", err)
 	}
-	queries, err = ParseQueries(queries, dialect, op.DropFunction...)
+	planned, err = planQueries(planned, dialect, op.DropFunction...)
 	if err != nil {
 		return nil, fmt.Errorf("error in DropFunction: %w", err)
 	}
-	queries, err = ParseQueries(queries, dialect, op.RenameFunction...)
+	planned, err = planQueries(planned, dialect, op.RenameFunction...)
 	if err != nil {
 		return nil, fmt.Errorf("error in RenameFunction: %w", err)
 	}
-	queries, err = ParseQueries(queries, dialect, op.CreateProcedure...)
+	planned, err = planQueries(planned, dialect, op.CreateProcedure...)
 	if err != nil {
 		return nil, fmt.Errorf("error in CreateProcedure: %w", err)
 	}
-	queries, err = ParseQueries(queries, dialect, op.DropProcedure...)
+	planned, err = planQueries(planned, dialect, op.DropProcedure...)
 	if err != nil {
 		return nil, fmt.Errorf("error in DropProcedure: %w", err)
 	}
-	queries, err = ParseQueries(queries, dialect, op.RenameProcedure...)
+	planned, err = planQueries(planned, dialect, op.RenameProcedure...)
 	if err != nil {
 		return nil, fmt.Errorf("error in RenameProcedure: %w", err)
 	}
-	queries, err = ParseQueries(queries, dialect, op.CreateTrigger...)
+	planned, err = planQueries(planned, dialect, op.CreateTrigger...)
 	if err != nil {
 		return nil, fmt.Errorf("error in CreateTrigger: %w", err)
 	}
-	queries, err = ParseQueries(queries, dialect, op.DropTrigger...)
+	planned, err = planQueries(planned, dialect, op.DropTrigger...)
 	if err != nil {
 		return nil, fmt.Errorf("error in DropTrigger: %w", err)
 	}
-	queries, err = ParseQueries(queries, dialect, op.RenameTrigger...)
+	planned, err = planQueries(planned, dialect, op.RenameTrigger...)
 	if err != nil {
 		return nil, fmt.Errorf("error in RenameTrigger: %w", err)
 	}
-	return queries, nil
+	planned, err = planOutsideTransaction(planned, dialect, op.RebuildIndex...)
+	if err != nil {
+		return nil, fmt.Errorf("error in RebuildIndex: %w", err)
+	}
+	planned, err = planOutsideTransaction(planned, dialect, op.ReindexTable...)
+	if err != nil {
+		return nil, fmt.Errorf("error in ReindexTable: %w", err)
+	}
+	// VACUUM cannot run inside a transaction on any dialect.
+	planned, err = planNoTransaction(planned, dialect, op.Vacuum...)
+	if err != nil {
+		return nil, fmt.Errorf("error in Vacuum: %w", err)
+	}
+	planned, err = planQueries(planned, dialect, op.Analyze...)
+	if err != nil {
+		return nil, fmt.Errorf("error in Analyze: %w", err)
+	}
+	return planned, nil
 }
 
 func (m Migration) ToSQL(dialect string, up bool) ([]string, error) {
-	var queries []string
-	var ops Operation
-	if up {
-		ops = m.Up
-	} else {
-		ops = m.Down
+	planned, err := m.Plan(dialect, up)
+	if err != nil {
+		return nil, err
 	}
-	qList, err := ops.ToSQL(dialect)
+	return statementSQL(planned), nil
+}
+
+// Plan generates the statements of the Up or Down operation of m.
+func (m Migration) Plan(dialect string, up bool) ([]Statement, error) {
+	steps, err := m.PlanSteps(dialect, up)
 	if err != nil {
-		return nil, fmt.Errorf("error in migration operation: %w", err)
+		return nil, err
 	}
-	queries = append(queries, qList...)
-	return queries, nil
+	var planned []Statement
+	for _, step := range steps {
+		planned = append(planned, step.Statements...)
+	}
+	return planned, nil
+}
+
+// OperationStep is one of several Up or Down blocks of a migration.
+type OperationStep struct {
+	Name      string    `json:"Name,omitempty"`
+	Operation Operation `json:"Operation"`
+}
+
+// PlannedStep holds the statements generated for one step.
+type PlannedStep struct {
+	// Name is the label of the block, or "step N" for an unlabeled block.
+	Name       string
+	Statements []Statement
+}
+
+// PlanSteps generates the statements of the Up or Down operation of m, one
+// PlannedStep per block in declaration order. A migration with a single
+// block yields one unnamed step.
+func (m Migration) PlanSteps(dialect string, up bool) ([]PlannedStep, error) {
+	ops, steps := m.Down, m.DownSteps
+	if up {
+		ops, steps = m.Up, m.UpSteps
+	}
+	if len(steps) == 0 {
+		steps = []OperationStep{{Operation: ops}}
+	}
+	planned := make([]PlannedStep, 0, len(steps))
+	for i, step := range steps {
+		name := step.Name
+		if name == "" && len(steps) > 1 {
+			name = fmt.Sprintf("step %d", i+1)
+		}
+		statements, err := step.Operation.Plan(dialect)
+		if err != nil {
+			if len(steps) > 1 {
+				return nil, fmt.Errorf("error in migration operation %s: %w", name, err)
+			}
+			return nil, fmt.Errorf("error in migration operation: %w", err)
+		}
+		if !atomicEnabled(m.Atomic) {
+			for j := range statements {
+				statements[j].Options.NoTransaction = true
+			}
+		}
+		planned = append(planned, PlannedStep{Name: name, Statements: statements})
+	}
+	return planned, nil
+}
+
+// atomicEnabled reports whether a file with the given atomic setting runs
+// in one transaction, which is the default.
+func atomicEnabled(atomic *bool) bool {
+	return atomic == nil || *atomic
 }
 
 // RunSeeds executes the seed SQL statements for a given SeedDefinition.
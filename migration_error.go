@@ -0,0 +1,53 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/oarkflow/migrate/drivers"
+)
+
+// StatementError reports the statement of a batch that a driver failed to
+// execute.
+type StatementError = drivers.StatementError
+
+// MigrationError is returned when applying or rolling back a migration
+// fails. Use errors.As to inspect which migration and statement failed.
+type MigrationError struct {
+	// Op is "apply" or "rollback".
+	Op        string
+	Migration string
+	// Statement is the SQL that failed, or "" when the failure did not come
+	// from a statement, e.g. when the connection was lost.
+	Statement string
+	// Position is the 1-based position of Statement among the statements
+	// sent to the driver, after splitting, or 0 when Statement is "".
+	Position int
+	// Hint suggests how to recover, see RecoveryHint. It is "" when no hint
+	// applies or hints are turned off with WithErrorHints(false).
+	Hint string
+	Err  error
+}
+
+func (e *MigrationError) Error() string {
+	return fmt.Sprintf("failed to %s migration %s: %v", e.Op, e.Migration, e.Err)
+}
+
+func (e *MigrationError) Unwrap() error {
+	return e.Err
+}
+
+// newMigrationError wraps err, copying the failed statement from a
+// StatementError in its chain.
+func (d *Manager) newMigrationError(op, migration string, err error) *MigrationError {
+	migErr := &MigrationError{Op: op, Migration: migration, Err: err}
+	if d.errorHints {
+		migErr.Hint = RecoveryHint(err)
+	}
+	var stErr *StatementError
+	if errors.As(err, &stErr) {
+		migErr.Statement = RedactPasswords(stErr.Statement)
+		migErr.Position = stErr.Position
+	}
+	return migErr
+}
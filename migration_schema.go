@@ -0,0 +1,102 @@
+package migrate
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// migrationTable is a table of a migration set with its fields after every
+// migration of the set.
+type migrationTable struct {
+	name   string
+	fields []AddField
+}
+
+// migrationSchema replays the tables created and altered by the migrations
+// of the migration set, a directory of the migration directory or empty for
+// every migration, in migration order. It returns the tables left by the
+// last migration and its name.
+func (d *Manager) migrationSchema(set string) ([]migrationTable, string, error) {
+	dir := d.migrationDir
+	if set != "" {
+		dir = filepath.Join(d.migrationDir, set)
+		if info, err := os.Stat(dir); d.assets == nil && (err != nil || !info.IsDir()) {
+			return nil, "", fmt.Errorf("migration set %s is not a directory of %s", set, d.migrationDir)
+		}
+	}
+	migrationMap, err := d.ListMigrationMap()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list migration files: %w", err)
+	}
+	names := make([]string, 0, len(migrationMap))
+	for name := range migrationMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var tables []migrationTable
+	var last string
+	find := func(name string) int {
+		return slices.IndexFunc(tables, func(t migrationTable) bool { return t.name == name })
+	}
+	for _, name := range names {
+		path := migrationMap[name]
+		if rel, err := filepath.Rel(dir, path); err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		if strings.EqualFold(filepath.Ext(path), ".sql") {
+			logger.Warn().Msgf("Skipping raw SQL migration %s: its tables are not known", name)
+			continue
+		}
+		cached, err := d.readMigrationsBCL(path)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to parse migration file %s: %w", path, err)
+		}
+		for _, m := range cached.migrations {
+			if m.Name != name {
+				continue
+			}
+			last = name
+			for _, ct := range m.Up.CreateTable {
+				if i := find(ct.Name); i >= 0 {
+					tables = slices.Delete(tables, i, i+1)
+				}
+				tables = append(tables, migrationTable{name: ct.Name, fields: slices.Clone(ct.AddFields)})
+			}
+			for _, at := range m.Up.AlterTable {
+				i := find(at.Name)
+				if i < 0 {
+					continue
+				}
+				t := &tables[i]
+				t.fields = append(t.fields, at.AddFields...)
+				for _, drop := range at.DropFields {
+					t.fields = slices.DeleteFunc(t.fields, func(f AddField) bool { return f.Name == drop.Name })
+				}
+				for _, rename := range at.RenameFields {
+					from := cmp.Or(rename.From, rename.Name)
+					for j := range t.fields {
+						if t.fields[j].Name == from {
+							t.fields[j].Name = rename.To
+						}
+					}
+				}
+			}
+			for _, rt := range m.Up.RenameTable {
+				if i := find(rt.OldName); i >= 0 {
+					tables[i].name = rt.NewName
+				}
+			}
+			for _, dt := range m.Up.DropTable {
+				if i := find(dt.Name); i >= 0 {
+					tables = slices.Delete(tables, i, i+1)
+				}
+			}
+		}
+	}
+	return tables, last, nil
+}
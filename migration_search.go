@@ -0,0 +1,136 @@
+package migrate
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MigrationMatch is a line of a migration file matching a search.
+type MigrationMatch struct {
+	Migration string `json:"migration"`
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Text      string `json:"text"`
+}
+
+// migrationSource is the source text of a migration: its block of a BCL
+// file, or the whole of a SQL file. Line is the line of File the text
+// starts at.
+type migrationSource struct {
+	Name string `json:"name"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// SearchMigrations returns the lines of the migration files matching the
+// regular expression pattern, in migration order, so the first match is in
+// the migration that introduced it. A BCL file declaring several
+// migrations is split at their Migration blocks.
+func (d *Manager) SearchMigrations(pattern string, ignoreCase bool) ([]MigrationMatch, error) {
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	migrationMap, err := d.ListMigrationMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migration files: %w", err)
+	}
+	seen := make(map[string]bool, len(migrationMap))
+	var filePaths []string
+	for _, path := range migrationMap {
+		if !seen[path] {
+			seen[path] = true
+			filePaths = append(filePaths, path)
+		}
+	}
+	sources, err := migrationSources(filePaths, d.readFile, func(path string) ([]Migration, error) {
+		cached, err := d.readMigrationsBCL(path)
+		if err != nil {
+			return nil, err
+		}
+		return cached.migrations, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var matches []MigrationMatch
+	for _, src := range sources {
+		for i, line := range strings.Split(src.Text, "\n") {
+			if re.MatchString(line) {
+				matches = append(matches, MigrationMatch{Migration: src.Name, File: src.File, Line: src.Line + i, Text: strings.TrimRight(line, "\r")})
+			}
+		}
+	}
+	return matches, nil
+}
+
+// migrationSources returns the source text of each migration of the files
+// of filePaths, sorted by migration name. The text of a SQL file is its
+// migration, named after the file.
+func migrationSources(filePaths []string, readFile func(string) ([]byte, error), readMigrations func(string) ([]Migration, error)) ([]migrationSource, error) {
+	var sources []migrationSource
+	for _, path := range filePaths {
+		data, err := readFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration file %s: %w", path, err)
+		}
+		ext := filepath.Ext(path)
+		if strings.EqualFold(ext, ".sql") {
+			sources = append(sources, migrationSource{Name: strings.TrimSuffix(filepath.Base(path), ext), File: path, Line: 1, Text: string(data)})
+			continue
+		}
+		migrations, err := readMigrations(path)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, splitMigrationSource(path, data, migrations)...)
+	}
+	sort.SliceStable(sources, func(i, j int) bool { return sources[i].Name < sources[j].Name })
+	return sources, nil
+}
+
+// splitMigrationSource splits data, the BCL file path declaring
+// migrations, at the Migration block of each. Lines before the first block
+// belong to the first migration.
+func splitMigrationSource(path string, data []byte, migrations []Migration) []migrationSource {
+	type start struct {
+		name string
+		line int
+	}
+	var starts []start
+	for _, m := range migrations {
+		if line := declarationLine(data, "Migration", m.Name); line > 0 {
+			starts = append(starts, start{m.Name, line})
+		}
+	}
+	if len(starts) == 0 {
+		if len(migrations) == 0 {
+			return nil
+		}
+		return []migrationSource{{Name: migrations[0].Name, File: path, Line: 1, Text: string(data)}}
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].line < starts[j].line })
+	starts[0].line = 1
+	lines := strings.SplitAfter(string(data), "\n")
+	sources := make([]migrationSource, len(starts))
+	for i, s := range starts {
+		end := len(lines)
+		if i+1 < len(starts) {
+			end = starts[i+1].line - 1
+		}
+		sources[i] = migrationSource{
+			Name: s.name,
+			File: path,
+			Line: s.line,
+			Text: strings.TrimSuffix(strings.Join(lines[s.line-1:end], ""), "\n"),
+		}
+	}
+	return sources
+}
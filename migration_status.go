@@ -0,0 +1,149 @@
+package migrate
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// MigrationStatus describes one migration as the status command shows it.
+type MigrationStatus struct {
+	Name    string
+	Path    string
+	Applied bool
+	// AppliedAt is zero for pending migrations.
+	AppliedAt time.Time
+	Disabled  bool
+	Tags      []string
+	// Phase is PhasePreDeploy or PhasePostDeploy.
+	Phase string
+	// Repeatable migrations are applied again when Changed.
+	Repeatable bool
+	// Changed reports that an applied repeatable migration was edited since
+	// it was applied, so migrate will apply it again.
+	Changed bool
+	// Author, Ticket and ReviewedBy are the ownership fields of the
+	// migration block; raw .sql migrations have none.
+	Author     string
+	Ticket     string
+	ReviewedBy string
+}
+
+// Ownership summarises the Author, Ticket and ReviewedBy of the migration
+// like Migration.Ownership.
+func (s MigrationStatus) Ownership() string {
+	return ownershipSummary(s.Author, s.Ticket, s.ReviewedBy)
+}
+
+// State returns "changed", "applied", "disabled" or "pending".
+func (s MigrationStatus) State() string {
+	switch {
+	case s.Changed:
+		return "changed"
+	case s.Applied:
+		return "applied"
+	case s.Disabled:
+		return "disabled"
+	default:
+		return "pending"
+	}
+}
+
+// MatchesTags reports whether m carries at least one of tags. Every
+// migration matches an empty filter.
+func (m Migration) MatchesTags(tags []string) bool {
+	return tagsMatch(m.Tags, tags)
+}
+
+func tagsMatch(have, want []string) bool {
+	if len(want) == 0 {
+		return true
+	}
+	for _, w := range want {
+		for _, h := range have {
+			if strings.EqualFold(h, w) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseTags splits the comma-separated value of a --tags option.
+func parseTags(value string) []string {
+	var tags []string
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// MigrationStatuses lists every migration in apply order with whether it was
+// applied, is disabled, and its tags. Raw .sql migrations have no tags and
+// are never repeatable.
+func (d *Manager) MigrationStatuses() ([]MigrationStatus, error) {
+	migrationMap, err := d.ListMigrationMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migration files: %w", err)
+	}
+	histories, err := d.historyDriver.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migration history: %w", err)
+	}
+	applied := make(map[string]MigrationHistory, len(histories))
+	for _, h := range histories {
+		applied[h.Name] = h
+	}
+	names, err := d.orderedMigrationNames(migrationMap)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, 0, len(names))
+	for _, name := range names {
+		path := migrationMap[name]
+		status := MigrationStatus{Name: name, Path: path, Phase: PhasePreDeploy}
+		h, ok := applied[name]
+		status.Applied, status.AppliedAt = ok, h.AppliedAt
+		if !strings.EqualFold(filepath.Ext(path), ".sql") {
+			cached, err := d.readMigrationsBCL(path)
+			if err != nil {
+				return nil, err
+			}
+			if migration, ok := findMigrationByName(cached.migrations, name); ok {
+				status.Disabled = migration.isDisabled()
+				status.Tags = migration.Tags
+				status.Repeatable = migration.Repeatable
+				status.Author, status.Ticket, status.ReviewedBy = migration.Author, migration.Ticket, migration.ReviewedBy
+				if status.Phase, err = migration.DeployPhase(); err != nil {
+					return nil, err
+				}
+			}
+			if status.Repeatable && status.Applied {
+				raw, normalized, err := d.migrationChecksums(path, name)
+				if err != nil {
+					return nil, err
+				}
+				status.Changed = !checksumMatches(h.Checksum, raw, normalized)
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// isRepeatable reports whether migration name in the BCL file at path is
+// Repeatable.
+func (d *Manager) isRepeatable(path, name string) bool {
+	if strings.EqualFold(filepath.Ext(path), ".sql") {
+		return false
+	}
+	cached, err := d.readMigrationsBCL(path)
+	if err != nil {
+		return false
+	}
+	migration, ok := findMigrationByName(cached.migrations, name)
+	return ok && migration.Repeatable
+}
@@ -0,0 +1,169 @@
+package migrate
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// modelInitialisms are name parts written in upper case in Go identifiers.
+var modelInitialisms = []string{"id", "url", "uri", "uuid", "api", "http", "https", "json", "sql", "ip", "html", "xml", "sku", "ssn"}
+
+// GenerateModels returns the Go source of package pkg declaring a struct for
+// each table left by the migrations of the migration set, empty meaning
+// every migration. Fields carry db and json tags of their column; nullable
+// columns are pointers. Each struct has a TableName method, unless it has a
+// table_name column.
+func (d *Manager) GenerateModels(pkg, set string) ([]byte, error) {
+	if !token.IsIdentifier(pkg) {
+		return nil, fmt.Errorf("invalid package name %q", pkg)
+	}
+	tables, last, err := d.migrationSchema(set)
+	if err != nil {
+		return nil, err
+	}
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("no tables are created by the migrations of %q", set)
+	}
+	var body bytes.Buffer
+	imports := map[string]bool{}
+	names := map[string]string{}
+	for _, t := range tables {
+		name := modelName(t.name)
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("tables %s and %s both map to the model %s", other, t.name, name)
+		}
+		names[name] = t.name
+		fmt.Fprintf(&body, "\n// %s is a row of the %s table.\n", name, t.name)
+		fmt.Fprintf(&body, "type %s struct {\n", name)
+		fields := map[string]string{}
+		for _, f := range t.fields {
+			field := modelIdentifier(f.Name)
+			if other, ok := fields[field]; ok {
+				return nil, fmt.Errorf("fields %s.%s and %s.%s both map to %s", t.name, other, t.name, f.Name, field)
+			}
+			fields[field] = f.Name
+			typ, pkgPath := modelFieldType(f)
+			if pkgPath != "" {
+				imports[pkgPath] = true
+			}
+			if f.ForeignKey != nil {
+				fmt.Fprintf(&body, "\t// %s references %s.%s.\n", field, f.ForeignKey.ReferenceTable, f.ForeignKey.ReferenceField)
+			}
+			fmt.Fprintf(&body, "\t%s %s `db:%q json:%q`\n", field, typ, f.Name, f.Name)
+		}
+		body.WriteString("}\n")
+		// A table_name column takes the name of the method.
+		if _, ok := fields["TableName"]; !ok {
+			fmt.Fprintf(&body, "\n// TableName returns the name of the table of %s.\n", name)
+			fmt.Fprintf(&body, "func (%s) TableName() string {\n\treturn %q\n}\n", name, t.name)
+		}
+	}
+	var src bytes.Buffer
+	src.WriteString("// Code generated by migrate generate:models; DO NOT EDIT.\n")
+	if last != "" {
+		fmt.Fprintf(&src, "// Schema as of migration %s.\n", last)
+	}
+	fmt.Fprintf(&src, "\npackage %s\n", pkg)
+	if len(imports) > 0 {
+		paths := make([]string, 0, len(imports))
+		for path := range imports {
+			paths = append(paths, path)
+		}
+		slices.Sort(paths)
+		src.WriteString("\nimport (\n")
+		for _, path := range paths {
+			fmt.Fprintf(&src, "\t%q\n", path)
+		}
+		src.WriteString(")\n")
+	}
+	src.Write(body.Bytes())
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format models: %w", err)
+	}
+	return formatted, nil
+}
+
+// modelFieldType returns the Go type of the values of f and the package it
+// needs, if any. Nullable fields are pointers, except for types that are
+// nil already.
+func modelFieldType(f AddField) (string, string) {
+	typ := strings.ToLower(strings.TrimSpace(f.Type))
+	if i := strings.Index(typ, "("); i >= 0 {
+		typ = typ[:i]
+	}
+	goType, pkgPath := "string", ""
+	switch {
+	case f.RawType != "":
+		return "any", ""
+	case slices.Contains([]string{"int", "integer", "bigint", "smallint", "tinyint", "mediumint"}, typ), strings.HasSuffix(typ, "serial"):
+		goType = "int64"
+	case slices.Contains([]string{"float", "double", "decimal", "numeric", "real"}, typ):
+		goType = "float64"
+	case typ == "boolean" || typ == "bool":
+		goType = "bool"
+	case slices.Contains([]string{"date", "datetime", "timestamp", "timestamptz", "time"}, typ):
+		goType, pkgPath = "time.Time", "time"
+	case typ == "json" || typ == "jsonb":
+		return "json.RawMessage", "encoding/json"
+	case slices.Contains([]string{"binary", "blob", "bytea", "varbinary"}, typ):
+		return "[]byte", ""
+	}
+	if f.Nullable {
+		goType = "*" + goType
+	}
+	return goType, pkgPath
+}
+
+// modelName returns the struct name of table: its singular in camel case,
+// such as User for users or Category for categories.
+func modelName(table string) string {
+	parts := modelWords(table)
+	if n := len(parts); n > 0 {
+		last := parts[n-1]
+		switch {
+		case strings.HasSuffix(last, "ies") && len(last) > 3:
+			last = strings.TrimSuffix(last, "ies") + "y"
+		case strings.HasSuffix(last, "sses"), strings.HasSuffix(last, "xes"), strings.HasSuffix(last, "ches"), strings.HasSuffix(last, "shes"):
+			last = strings.TrimSuffix(last, "es")
+		case strings.HasSuffix(last, "s") && !strings.HasSuffix(last, "ss") && !strings.HasSuffix(last, "us") && !strings.HasSuffix(last, "is") && len(last) > 1:
+			last = strings.TrimSuffix(last, "s")
+		}
+		parts[n-1] = last
+	}
+	return modelCamel(parts)
+}
+
+// modelIdentifier returns the exported Go name of column, such as UserID for
+// user_id.
+func modelIdentifier(column string) string {
+	return modelCamel(modelWords(column))
+}
+
+func modelWords(name string) []string {
+	return strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func modelCamel(words []string) string {
+	var sb strings.Builder
+	for _, w := range words {
+		if slices.Contains(modelInitialisms, w) {
+			sb.WriteString(strings.ToUpper(w))
+			continue
+		}
+		r := []rune(w)
+		sb.WriteString(string(unicode.ToUpper(r[0])) + string(r[1:]))
+	}
+	name := sb.String()
+	if name == "" || !unicode.IsLetter(rune(name[0])) {
+		name = "X" + name
+	}
+	return name
+}
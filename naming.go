@@ -0,0 +1,203 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// NamingConvention holds the templates of the index and constraint names
+// generated for fields. {table} and {column} are replaced by the table and
+// the column; an empty template keeps the default. Generated names longer
+// than MaxLength, or the identifier limit of the dialect when it is 0, are
+// truncated and end with a hash of the full name so they stay unique.
+type NamingConvention struct {
+	Index      string `json:"index,omitempty"`
+	Unique     string `json:"unique,omitempty"`
+	ForeignKey string `json:"foreign_key,omitempty"`
+	MaxLength  int    `json:"max_length,omitempty"`
+}
+
+// DefaultNamingConvention is the naming convention used unless
+// WithNamingConvention or migration.naming changes it.
+var DefaultNamingConvention = NamingConvention{
+	Index:      "idx_{table}_{column}",
+	Unique:     "uniq_{table}_{column}",
+	ForeignKey: "fk_{column}",
+}
+
+// dialectIdentifierLimits is the maximum identifier length of each dialect,
+// in bytes on PostgreSQL and in characters on MySQL.
+var dialectIdentifierLimits = map[string]int{
+	DialectPostgres: 63,
+	DialectMySQL:    64,
+}
+
+var namingPlaceholder = regexp.MustCompile(`\{[^}]*\}`)
+
+// WithNamingConvention sets the naming convention of the index and
+// constraint names generated for the fields of the manager's migrations.
+func WithNamingConvention(nc NamingConvention) ManagerOption {
+	return func(m *Manager) {
+		m.naming = nc
+	}
+}
+
+// Validate reports templates using placeholders other than {table} and
+// {column}, and a negative MaxLength.
+func (nc NamingConvention) Validate() error {
+	templates := []struct{ field, template string }{
+		{"index", nc.Index}, {"unique", nc.Unique}, {"foreign_key", nc.ForeignKey},
+	}
+	for _, t := range templates {
+		for _, p := range namingPlaceholder.FindAllString(t.template, -1) {
+			if p != "{table}" && p != "{column}" {
+				return fmt.Errorf("naming template %s %q: unknown placeholder %s", t.field, t.template, p)
+			}
+		}
+	}
+	if nc.MaxLength < 0 {
+		return fmt.Errorf("naming max_length must not be negative")
+	}
+	return nil
+}
+
+func (nc NamingConvention) withDefaults() NamingConvention {
+	if nc.Index == "" {
+		nc.Index = DefaultNamingConvention.Index
+	}
+	if nc.Unique == "" {
+		nc.Unique = DefaultNamingConvention.Unique
+	}
+	if nc.ForeignKey == "" {
+		nc.ForeignKey = DefaultNamingConvention.ForeignKey
+	}
+	return nc
+}
+
+// WithNaming returns m with the names generated for the fields it adds
+// following nc instead of DefaultNamingConvention. m itself is not modified.
+func (m Migration) WithNaming(nc NamingConvention) Migration {
+	nc = nc.withDefaults()
+	m.Up = m.Up.withNaming(nc)
+	m.Down = m.Down.withNaming(nc)
+	m.UpSteps = prefixEach(m.UpSteps, func(s *OperationStep) { s.Operation = s.Operation.withNaming(nc) })
+	m.DownSteps = prefixEach(m.DownSteps, func(s *OperationStep) { s.Operation = s.Operation.withNaming(nc) })
+	return m
+}
+
+func (op Operation) withNaming(nc NamingConvention) Operation {
+	named := func(f *AddField) { f.naming = &nc }
+	op.CreateTable = prefixEach(op.CreateTable, func(ct *CreateTable) {
+		ct.AddFields = prefixEach(ct.AddFields, named)
+	})
+	op.AlterTable = prefixEach(op.AlterTable, func(at *AlterTable) {
+		at.AddFields = prefixEach(at.AddFields, named)
+		if nc.MaxLength == 0 {
+			return
+		}
+		// Primary key names only depend on the convention through its
+		// length limit, so they are given explicitly.
+		pkey := fitIdentifier("", at.Name+"_pkey", nc.MaxLength)
+		at.AddPrimaryKey = prefixEach(at.AddPrimaryKey, func(pk *AddPrimaryKey) {
+			if pk.Name == "" {
+				pk.Name = pkey
+			}
+		})
+		at.DropPrimaryKey = prefixEach(at.DropPrimaryKey, func(pk *DropPrimaryKey) {
+			if pk.Name == "" {
+				pk.Name = pkey
+			}
+		})
+	})
+	return op
+}
+
+// namedMigrations returns migrations with the naming convention of the
+// manager applied.
+func (d *Manager) namedMigrations(migrations []Migration) []Migration {
+	if d.naming == (NamingConvention{}) {
+		return migrations
+	}
+	named := make([]Migration, len(migrations))
+	for i, m := range migrations {
+		named[i] = m.WithNaming(d.naming)
+	}
+	return named
+}
+
+// namingConvention returns the naming convention of the names generated for
+// f, see Migration.WithNaming.
+func (f AddField) namingConvention() NamingConvention {
+	if f.naming != nil {
+		return *f.naming
+	}
+	return DefaultNamingConvention
+}
+
+// expandNamingTemplate replaces the placeholders of template.
+func expandNamingTemplate(template, table, column string) string {
+	return strings.NewReplacer("{table}", table, "{column}", column).Replace(template)
+}
+
+// fitIdentifier truncates a generated name longer than limit, or the
+// identifier limit of dialect when limit is 0, ending it with 8 hex digits
+// of the hash of the full name. The name is cut on a character boundary;
+// MySQL counts the limit in characters, the other dialects in bytes.
+func fitIdentifier(dialect, name string, limit int) string {
+	if limit == 0 {
+		limit = dialectIdentifierLimits[dialect]
+	}
+	length := len(name)
+	if dialect == DialectMySQL {
+		length = utf8.RuneCountInString(name)
+	}
+	if limit == 0 || length <= limit {
+		return name
+	}
+	keep := max(limit-9, 1)
+	cut := keep
+	if dialect == DialectMySQL {
+		cut = len(name)
+		for i := range name {
+			if keep == 0 {
+				cut = i
+				break
+			}
+			keep--
+		}
+	} else {
+		for cut > 0 && !utf8.RuneStart(name[cut]) {
+			cut--
+		}
+	}
+	sum := sha256.Sum256([]byte(name))
+	return strings.TrimRight(name[:cut], "_") + "_" + hex.EncodeToString(sum[:4])
+}
+
+// indexName returns the name of the index generated for f, a field of
+// table.
+func indexName(dialect, table string, f AddField) string {
+	nc := f.namingConvention()
+	return fitIdentifier(dialect, expandNamingTemplate(nc.Index, table, f.Name), nc.MaxLength)
+}
+
+// uniqueIndexName returns the name of the unique index generated for f, a
+// field of table.
+func uniqueIndexName(dialect, table string, f AddField) string {
+	nc := f.namingConvention()
+	return fitIdentifier(dialect, expandNamingTemplate(nc.Unique, table, f.Name), nc.MaxLength)
+}
+
+// foreignKeyName returns the name of the foreign key constraint of f, a
+// field of table.
+func foreignKeyName(dialect, table string, f AddField) string {
+	nc := f.namingConvention()
+	if f.ForeignKey != nil && f.ForeignKey.Name != "" {
+		return fitIdentifier(dialect, f.ForeignKey.Name, nc.MaxLength)
+	}
+	return fitIdentifier(dialect, expandNamingTemplate(nc.ForeignKey, table, f.Name), nc.MaxLength)
+}
@@ -0,0 +1,182 @@
+package migrate
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+)
+
+// Strategies for NOT NULL fields without a default added to existing tables,
+// whose rows have no value for them; see migration.not_null_strategy.
+const (
+	// NotNullAllow generates the field as declared; plan warns about it.
+	NotNullAllow = "allow"
+	// NotNullError makes plan report the field as an error.
+	NotNullError = "error"
+	// NotNullBackfill adds the field as nullable, fills the existing rows
+	// with the zero value of its type and then sets it NOT NULL.
+	NotNullBackfill = "backfill"
+)
+
+// validNotNullStrategy reports whether strategy is empty or a known strategy.
+func validNotNullStrategy(strategy string) error {
+	switch strategy {
+	case "", NotNullAllow, NotNullError, NotNullBackfill:
+		return nil
+	}
+	return fmt.Errorf("invalid not null strategy %q: use %s, %s or %s", strategy, NotNullAllow, NotNullError, NotNullBackfill)
+}
+
+// WithNotNullStrategy sets how NOT NULL fields without a default added to
+// existing tables are handled, one of NotNullAllow, NotNullError and
+// NotNullBackfill.
+func WithNotNullStrategy(strategy string) ManagerOption {
+	return func(m *Manager) {
+		m.notNullStrategy = strategy
+	}
+}
+
+// hasDefault reports whether f declares a default other than NULL.
+func (a AddField) hasDefault() bool {
+	if a.Default == nil || a.Default == "" {
+		return false
+	}
+	return !strings.EqualFold(fmt.Sprint(a.Default), "null")
+}
+
+// needsBackfill reports whether adding f to a populated table fails for
+// lack of a value for the existing rows.
+func (a AddField) needsBackfill() bool {
+	return !a.Nullable && !a.PrimaryKey && !a.AutoIncrement && !a.hasDefault()
+}
+
+// backfills reports whether f is added in three steps: nullable, backfilled
+// with Backfill, then NOT NULL.
+func (a AddField) backfills() bool {
+	return a.Backfill != nil && a.needsBackfill()
+}
+
+// zeroBackfills maps column types, as generated for the dialect and without
+// their size, to the value filled into existing rows under NotNullBackfill.
+// Types missing here, such as uuid, bytea, interval, point or enums, have
+// no safe zero value.
+var zeroBackfills = map[string]string{
+	"smallint": "0", "integer": "0", "int": "0", "bigint": "0", "tinyint": "0", "mediumint": "0",
+	"int2": "0", "int4": "0", "int8": "0", "serial": "0", "smallserial": "0", "bigserial": "0",
+	"decimal": "0", "numeric": "0", "real": "0", "float": "0", "float4": "0", "float8": "0",
+	"double": "0", "double precision": "0",
+	"boolean": "false", "bool": "false",
+	"char": "''", "character": "''", "varchar": "''", "character varying": "''", "nchar": "''",
+	"nvarchar": "''", "text": "''", "tinytext": "''", "mediumtext": "''", "longtext": "''", "citext": "''",
+	"json": "'{}'", "jsonb": "'{}'",
+	"date": "CURRENT_DATE", "time": "CURRENT_TIME", "datetime": "CURRENT_TIMESTAMP",
+	"timestamp": "CURRENT_TIMESTAMP", "timestamptz": "CURRENT_TIMESTAMP",
+	"timestamp with time zone": "CURRENT_TIMESTAMP", "timestamp without time zone": "CURRENT_TIMESTAMP",
+}
+
+// zeroBackfill returns the value filled into the existing rows for f on
+// dialect under NotNullBackfill, and false when its type has no safe zero
+// value.
+func zeroBackfill(dialect string, f AddField) (string, bool) {
+	switch strings.ToLower(f.Type) {
+	case "json", "jsonb":
+		// Stored as text on SQLite, where '' would not be JSON.
+		if f.RawType == "" {
+			return "'{}'", true
+		}
+	case "uuid", "enum":
+		if f.RawType == "" {
+			return "", false
+		}
+	}
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return "", false
+	}
+	t := strings.ToLower(strings.TrimSpace(columnType(dial, f)))
+	if i := strings.IndexByte(t, '('); i >= 0 {
+		t = strings.TrimSpace(t[:i])
+	}
+	t = strings.TrimSpace(strings.TrimSuffix(t, " unsigned"))
+	value, ok := zeroBackfills[t]
+	return value, ok
+}
+
+// backfillSQL returns the statement filling the rows of tableName without a
+// value for f.
+func backfillSQL(dialect, tableName string, f AddField) string {
+	column := quoteDialectIdentifier(dialect, f.Name)
+	return fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s IS NULL;",
+		quoteDialectIdentifier(dialect, tableName), column, ConvertDefault(f.Backfill, f.Type), column)
+}
+
+// backfillAddFieldSQL returns the statements adding f to tableName as
+// nullable and backfilling it; the caller sets it NOT NULL afterwards.
+func backfillAddFieldSQL(dialect, tableName string, f AddField) ([]string, error) {
+	nullable := f
+	nullable.Nullable = true
+	queries, err := nullable.ToSQL(dialect, tableName)
+	if err != nil {
+		return nil, err
+	}
+	return append(queries, backfillSQL(dialect, tableName, f)), nil
+}
+
+// withNotNullBackfill returns op with a zero Backfill given to the NOT NULL
+// fields without a default it adds to existing tables. Fields whose type
+// has no zero value are left without one, for plan to report.
+func (op Operation) withNotNullBackfill(dialect string) Operation {
+	op.AlterTable = prefixEach(op.AlterTable, func(at *AlterTable) {
+		at.AddFields = prefixEach(at.AddFields, func(f *AddField) {
+			if f.Backfill == nil && f.needsBackfill() {
+				if zero, ok := zeroBackfill(dialect, *f); ok {
+					f.Backfill = zero
+				}
+			}
+		})
+	})
+	return op
+}
+
+// backfilledMigrations returns migrations prepared for the NotNullBackfill
+// strategy when it is set.
+func (d *Manager) backfilledMigrations(migrations []Migration) []Migration {
+	if d.notNullStrategy != NotNullBackfill {
+		return migrations
+	}
+	backfilled := make([]Migration, len(migrations))
+	for i, m := range migrations {
+		dialect := d.dialect
+		if m.Driver != "" {
+			if normalized, err := NormalizeDriver(m.Driver); err == nil {
+				dialect = normalized
+			}
+		}
+		m.Up = m.Up.withNotNullBackfill(dialect)
+		m.Down = m.Down.withNotNullBackfill(dialect)
+		m.UpSteps = prefixEach(m.UpSteps, func(s *OperationStep) { s.Operation = s.Operation.withNotNullBackfill(dialect) })
+		m.DownSteps = prefixEach(m.DownSteps, func(s *OperationStep) { s.Operation = s.Operation.withNotNullBackfill(dialect) })
+		backfilled[i] = m
+	}
+	return backfilled
+}
+
+// unsafeNotNullFields lists the NOT NULL fields without a default or a
+// backfill that op adds to existing tables. Under NotNullBackfill these are
+// the fields whose type has no zero value.
+func unsafeNotNullFields(op Operation, strategy string) []destructiveOperation {
+	var ops []destructiveOperation
+	for _, at := range op.AlterTable {
+		for _, f := range at.AddFields {
+			if !f.needsBackfill() || f.Backfill != nil {
+				continue
+			}
+			message := fmt.Sprintf("adds NOT NULL column %s.%s without a default, which fails on tables with rows; set a default or backfill, or migration.not_null_strategy = %q", at.Name, f.Name, NotNullBackfill)
+			if strategy == NotNullBackfill {
+				message = fmt.Sprintf("adds NOT NULL column %s.%s of type %s, which has no zero value to backfill the existing rows with; set a default or backfill", at.Name, f.Name, cmp.Or(f.RawType, f.Type))
+			}
+			ops = append(ops, destructiveOperation{"AddField", f.Name, message})
+		}
+	}
+	return ops
+}
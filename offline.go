@@ -0,0 +1,29 @@
+package migrate
+
+import (
+	"errors"
+
+	"github.com/oarkflow/cli/contracts"
+)
+
+// ErrOffline is returned when an offline manager needs the migration
+// history, which lives in the database.
+var ErrOffline = errors.New("migration history is not available offline; run without --offline")
+
+// WithOffline keeps the manager from connecting to the database of its
+// configuration, for work on files alone such as make:migration or
+// migration:parse. Reading or writing the migration history fails with
+// ErrOffline.
+func WithOffline() ManagerOption {
+	return func(m *Manager) {
+		m.offline = true
+	}
+}
+
+func offlineFlagDefinition() contracts.Flag {
+	return contracts.Flag{
+		Name:  "offline",
+		Usage: "Do not connect to the configured database; for commands that only work on files",
+		Value: "false",
+	}
+}
@@ -0,0 +1,149 @@
+package migrate
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/oarkflow/bcl"
+)
+
+// operationPlanOrder lists the operation kinds in the order planGrouped
+// plans them.
+var operationPlanOrder = []string{
+	"CreateDatabase", "CreateTable", "AlterTable",
+	"CreatePublication", "AlterPublication", "DropPublication",
+	"CreateRole", "AlterRole", "DropRole",
+	"DeleteData", "DropEnumType", "DropRowPolicy", "DropMaterializedView",
+	"DropTable", "DropSchema", "DropDatabase", "RenameTable",
+	"CreateView", "DropView", "RenameView",
+	"CreateFunction", "DropFunction", "RenameFunction",
+	"CreateProcedure", "DropProcedure", "RenameProcedure",
+	"CreateTrigger", "DropTrigger", "RenameTrigger",
+	"RebuildIndex", "ReindexTable", "Vacuum", "Analyze",
+}
+
+// planDeclared plans the items of op one at a time in the order given by
+// op.Order.
+func (op Operation) planDeclared(dialect string) ([]Statement, error) {
+	if n := op.itemCount(); n != len(op.Order) {
+		return nil, fmt.Errorf("operation order lists %d items but the operation has %d", len(op.Order), n)
+	}
+	src := reflect.ValueOf(op)
+	next := make(map[string]int, len(op.Order))
+	var planned []Statement
+	for _, kind := range op.Order {
+		items := src.FieldByName(kind)
+		if !items.IsValid() || items.Kind() != reflect.Slice || next[kind] >= items.Len() {
+			return nil, fmt.Errorf("operation order references missing %s item %d", kind, next[kind]+1)
+		}
+		var single Operation
+		reflect.ValueOf(&single).Elem().FieldByName(kind).Set(items.Slice(next[kind], next[kind]+1))
+		next[kind]++
+		statements, err := single.planGrouped(dialect)
+		if err != nil {
+			return nil, err
+		}
+		planned = append(planned, statements...)
+	}
+	return planned, nil
+}
+
+// itemCount returns the number of items of every kind in op.
+func (op Operation) itemCount() int {
+	count := 0
+	for _, kind := range operationPlanOrder {
+		count += reflect.ValueOf(op).FieldByName(kind).Len()
+	}
+	return count
+}
+
+// declaredOperationOrders reads the operation kinds of every Up and Down
+// block from the syntax tree of a BCL document, keyed by
+// operationBlockKey. It returns nil when the document cannot be parsed.
+func declaredOperationOrders(data []byte) map[string][]string {
+	doc, err := bcl.Parse(data)
+	if err != nil {
+		return nil
+	}
+	orders := make(map[string][]string)
+	for _, item := range doc.Items {
+		migration, ok := item.(*bcl.Block)
+		if !ok || migration.Type != "Migration" {
+			continue
+		}
+		index := map[string]int{}
+		for _, child := range migration.Body {
+			block, ok := child.(*bcl.Block)
+			if !ok || (block.Type != "Up" && block.Type != "Down") {
+				continue
+			}
+			var kinds []string
+			for _, op := range block.Body {
+				if op, ok := op.(*bcl.Block); ok && slices.Contains(operationPlanOrder, op.Type) {
+					kinds = append(kinds, op.Type)
+				}
+			}
+			orders[operationBlockKey(migration.ID, block.Type, index[block.Type])] = kinds
+			index[block.Type]++
+		}
+	}
+	return orders
+}
+
+func operationBlockKey(migration, direction string, block int) string {
+	return fmt.Sprintf("%s/%s/%d", migration, direction, block)
+}
+
+// Values of the order attribute of an Up or Down block.
+const (
+	// OperationOrderGrouped plans operations grouped by kind, in the order of
+	// operationPlanOrder. It is the default.
+	OperationOrderGrouped = "grouped"
+	// OperationOrderDeclared plans operations in declaration order.
+	OperationOrderDeclared = "declared"
+)
+
+// applyDeclaredOrder sets the Order of the operations of m whose block has
+// order = "declared", using the kinds read by declaredOperationOrders.
+func applyDeclaredOrder(m *Migration, item bclMigration, orders map[string][]string) error {
+	set := func(op *Operation, blocks []bclOperation, direction string, block int) error {
+		switch blocks[block].Order {
+		case "", OperationOrderGrouped:
+			return nil
+		case OperationOrderDeclared:
+		default:
+			return fmt.Errorf("invalid order %q in %s block of migration %s: must be %q or %q", blocks[block].Order, direction, m.Name, OperationOrderGrouped, OperationOrderDeclared)
+		}
+		kinds := orders[operationBlockKey(m.Name, direction, block)]
+		if len(kinds) != op.itemCount() {
+			return fmt.Errorf("cannot determine the declaration order of the %s block of migration %s", direction, m.Name)
+		}
+		op.Order = kinds
+		return nil
+	}
+	for _, d := range []struct {
+		direction string
+		blocks    []bclOperation
+		merged    *Operation
+		steps     []OperationStep
+	}{
+		{"Up", item.Up, &m.Up, m.UpSteps},
+		{"Down", item.Down, &m.Down, m.DownSteps},
+	} {
+		if len(d.steps) == 0 {
+			if len(d.blocks) == 1 {
+				if err := set(d.merged, d.blocks, d.direction, 0); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		for i := range d.steps {
+			if err := set(&d.steps[i].Operation, d.blocks, d.direction, i); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package migrate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MetadataPolicy lists the ownership fields every migration must declare
+// before it may be applied.
+type MetadataPolicy struct {
+	RequireAuthor bool
+	RequireTicket bool
+	RequireReview bool
+}
+
+// WithMetadataPolicy refuses to apply migrations missing the fields policy
+// requires.
+func WithMetadataPolicy(policy MetadataPolicy) ManagerOption {
+	return func(m *Manager) {
+		m.metadataPolicy = policy
+	}
+}
+
+// Check returns an error naming the required fields m does not declare.
+func (p MetadataPolicy) Check(m Migration) error {
+	var missing []string
+	if p.RequireAuthor && strings.TrimSpace(m.Author) == "" {
+		missing = append(missing, "Author")
+	}
+	if p.RequireTicket && strings.TrimSpace(m.Ticket) == "" {
+		missing = append(missing, "Ticket")
+	}
+	if p.RequireReview && strings.TrimSpace(m.ReviewedBy) == "" {
+		missing = append(missing, "ReviewedBy")
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("migration %s is missing %s required by the validation policy", m.Name, strings.Join(missing, ", "))
+}
+
+// Ownership summarises the Author, Ticket and ReviewedBy of m, such as
+// "by alice, OPS-12, reviewed by bob", or returns "" when none is set.
+func (m Migration) Ownership() string {
+	return ownershipSummary(m.Author, m.Ticket, m.ReviewedBy)
+}
+
+func ownershipSummary(author, ticket, reviewedBy string) string {
+	var parts []string
+	if author != "" {
+		parts = append(parts, "by "+author)
+	}
+	if ticket != "" {
+		parts = append(parts, ticket)
+	}
+	if reviewedBy != "" {
+		parts = append(parts, "reviewed by "+reviewedBy)
+	}
+	return strings.Join(parts, ", ")
+}
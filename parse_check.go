@@ -0,0 +1,94 @@
+package migrate
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// FileCheck is the outcome of checking one migration or seed file.
+type FileCheck struct {
+	Path string
+	// Errs is empty when the file parses and, for migrations, every
+	// migration in it generates Up and Down SQL for the configured dialect.
+	Errs []error
+}
+
+// CheckFiles parses every migration and BCL seed file and generates the SQL
+// of each migration, without connecting to the database. Unlike
+// ListMigrationMap it carries on past a broken file so every problem is
+// reported at once.
+func (d *Manager) CheckFiles() ([]FileCheck, error) {
+	paths, _, err := d.migrationFilePaths()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migration files: %w", err)
+	}
+	checks := make([]FileCheck, 0, len(paths))
+	for _, path := range paths {
+		checks = append(checks, FileCheck{Path: path, Errs: d.checkMigrationFile(path)})
+	}
+	seedFiles, err := d.ListSeedFiles(false)
+	if err != nil {
+		return checks, fmt.Errorf("failed to list seed files: %w", err)
+	}
+	for _, path := range seedFiles {
+		check := FileCheck{Path: path}
+		if _, err := d.readSeedsBCL(path); err != nil {
+			check.Errs = append(check.Errs, err)
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+func (d *Manager) checkMigrationFile(path string) []error {
+	if strings.EqualFold(filepath.Ext(path), ".sql") {
+		data, err := d.readFile(path)
+		if err != nil {
+			return []error{err}
+		}
+		if up, _ := parseSQLMigration(data); up == "" {
+			return []error{fmt.Errorf("raw SQL migration must include a non-empty -- migration-up section")}
+		}
+		return nil
+	}
+	cached, err := d.readMigrationsBCL(path)
+	if err != nil {
+		return []error{err}
+	}
+	if len(cached.migrations) == 0 {
+		return []error{fmt.Errorf("no Migration blocks found")}
+	}
+	var errs []error
+	for _, migration := range cached.migrations {
+		if _, err := migration.DeployPhase(); err != nil {
+			errs = append(errs, err)
+		}
+		for _, up := range []bool{true, false} {
+			op := migration.Down
+			if up {
+				op = migration.Up
+			}
+			// Report what the dialect refuses at its block rather than as a
+			// failure to generate SQL.
+			if renames := untypedRenames(d.dialect, op); len(renames) > 0 {
+				for _, r := range renames {
+					line := declarationLine(cached.data, r.blockType, r.name)
+					if line == 0 {
+						line = declarationLine(cached.data, "Migration", migration.Name)
+					}
+					errs = append(errs, &ParseError{File: path, Line: line, Message: fmt.Sprintf("migration %s: %s", migration.Name, r.message)})
+				}
+				continue
+			}
+			if _, err := d.offlineMigrationSteps(migration.Name, path, d.dialect, up); err != nil {
+				direction := "Down"
+				if up {
+					direction = "Up"
+				}
+				errs = append(errs, fmt.Errorf("migration %s: %s: %w", migration.Name, direction, err))
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,105 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/oarkflow/bcl"
+)
+
+// ParseError is a BCL syntax error located in its source.
+type ParseError struct {
+	// File is empty when the source was parsed from memory.
+	File   string
+	Line   int
+	Column int
+	// Message is the parser's description of the error.
+	Message string
+	// Snippet shows the offending line, the line around it and a caret under
+	// Column.
+	Snippet string
+}
+
+func (e *ParseError) Error() string {
+	location := fmt.Sprintf("line %d, column %d", e.Line, e.Column)
+	if e.File != "" {
+		location = fmt.Sprintf("%s:%d:%d", e.File, e.Line, e.Column)
+	}
+	if e.Snippet == "" {
+		return fmt.Sprintf("%s: %s", location, e.Message)
+	}
+	return fmt.Sprintf("%s: %s\n%s", location, e.Message, e.Snippet)
+}
+
+// newParseError turns the first located diagnostic of a bcl error into a
+// ParseError with a snippet of data. Other errors are returned unchanged.
+func newParseError(data []byte, err error) error {
+	var diags bcl.ErrorList
+	if !errors.As(err, &diags) {
+		return err
+	}
+	for _, d := range diags {
+		if d.Span.Start.Line <= 0 {
+			continue
+		}
+		return &ParseError{
+			Line:    d.Span.Start.Line,
+			Column:  d.Span.Start.Column,
+			Message: d.Message,
+			Snippet: sourceSnippet(data, d.Span.Start.Line, d.Span.Start.Column),
+		}
+	}
+	return err
+}
+
+// withParseErrorFile records path as the file of a ParseError in err.
+func withParseErrorFile(err error, path string) error {
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) && parseErr.File == "" {
+		parseErr.File = path
+	}
+	return err
+}
+
+// sourceSnippet renders line of data with one line of context on either side
+// and a caret under column.
+func sourceSnippet(data []byte, line, column int) string {
+	lines := strings.Split(string(data), "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	first, last := max(line-1, 1), min(line+1, len(lines))
+	if last == len(lines) && lines[last-1] == "" && last > line {
+		last--
+	}
+	width := len(strconv.Itoa(last))
+	var b strings.Builder
+	for n := first; n <= last; n++ {
+		fmt.Fprintf(&b, "%*d | %s\n", width, n, lines[n-1])
+		if n == line {
+			fmt.Fprintf(&b, "%*s | %s^\n", width, "", caretIndent(lines[n-1], column))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// caretIndent returns the whitespace that puts a caret under column of text,
+// keeping tabs so the caret lines up however tabs are displayed.
+func caretIndent(text string, column int) string {
+	var b strings.Builder
+	for i, r := range []rune(text) {
+		if i >= column-1 {
+			break
+		}
+		if r != '\t' {
+			r = ' '
+		}
+		b.WriteRune(r)
+	}
+	if pad := column - 1 - len([]rune(text)); pad > 0 {
+		b.WriteString(strings.Repeat(" ", pad))
+	}
+	return b.String()
+}
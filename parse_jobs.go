@@ -0,0 +1,86 @@
+package migrate
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync"
+
+	"github.com/oarkflow/cli/contracts"
+)
+
+// jobsFlag bounds concurrent file parsing for commands that list migrations.
+const jobsFlag = "jobs"
+
+func jobsFlagDefinition() contracts.Flag {
+	return contracts.Flag{
+		Name:    jobsFlag,
+		Aliases: []string{"j"},
+		Usage:   "Number of migration files to parse concurrently (default: number of CPUs)",
+		Value:   "",
+	}
+}
+
+// applyJobsOption sets the manager's parse concurrency from --jobs when given.
+func applyJobsOption(ctx contracts.Context, driver IManager) error {
+	value := ctx.Option(jobsFlag)
+	if value == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return fmt.Errorf("invalid --jobs value: %s (expected a positive integer)", value)
+	}
+	if mgr, ok := driver.(*Manager); ok {
+		mgr.jobs = n
+	}
+	return nil
+}
+
+// parseJobs returns the number of parse workers to use.
+func (d *Manager) parseJobs() int {
+	if d.jobs > 0 {
+		return d.jobs
+	}
+	return runtime.NumCPU()
+}
+
+// preloadedMigration is the outcome of reading one migration file during
+// preloadMigrations.
+type preloadedMigration struct {
+	cached cachedMigrationsBCL
+	err    error
+}
+
+// preloadMigrations reads, checksums and parses paths on a bounded worker pool
+// and returns the result for each path, so callers can walk the files in order
+// without reading them again. It returns nil when a single worker would be
+// used; errors are left for the caller to report with its usual context.
+func (d *Manager) preloadMigrations(paths []string) map[string]preloadedMigration {
+	workers := min(d.parseJobs(), len(paths))
+	if workers <= 1 {
+		return nil
+	}
+	results := make(map[string]preloadedMigration, len(paths))
+	var mu sync.Mutex
+	work := make(chan string)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range work {
+				cached, err := d.readMigrationsBCL(path)
+				mu.Lock()
+				results[path] = preloadedMigration{cached: cached, err: err}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, path := range paths {
+		work <- path
+	}
+	close(work)
+	wg.Wait()
+	return results
+}
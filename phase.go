@@ -0,0 +1,37 @@
+package migrate
+
+import "fmt"
+
+// Deploy phases order migrations around an application rollout.
+const (
+	// PhasePreDeploy migrations expand the schema before the new application
+	// version is rolled out. Migrations without a Phase run pre-deploy.
+	PhasePreDeploy = "pre-deploy"
+	// PhasePostDeploy migrations, such as data backfills and removing
+	// columns the old version still reads, run after the rollout.
+	PhasePostDeploy = "post-deploy"
+)
+
+// DeployPhase returns the phase of m, PhasePreDeploy when unset. It fails for
+// unknown phases.
+func (m Migration) DeployPhase() (string, error) {
+	switch m.Phase {
+	case "", PhasePreDeploy:
+		return PhasePreDeploy, nil
+	case PhasePostDeploy:
+		return PhasePostDeploy, nil
+	default:
+		return "", fmt.Errorf("invalid Phase %q for migration %s: must be %q or %q", m.Phase, m.Name, PhasePreDeploy, PhasePostDeploy)
+	}
+}
+
+// parsePhase validates the value of a --phase option. An empty value selects
+// every phase.
+func parsePhase(value string) (string, error) {
+	switch value {
+	case "", PhasePreDeploy, PhasePostDeploy:
+		return value, nil
+	default:
+		return "", fmt.Errorf("invalid --phase %q: must be %q or %q", value, PhasePreDeploy, PhasePostDeploy)
+	}
+}
@@ -0,0 +1,335 @@
+package migrate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Plan finding severities.
+const (
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// PlanFinding is a review note on a pending migration.
+type PlanFinding struct {
+	// Severity is SeverityWarning for destructive operations, reserved
+	// words used as identifiers and migrations written for a newer DSL, and
+	// SeverityError for lint violations and files that do not parse.
+	Severity  string
+	Migration string
+	File      string
+	// Line is 0 when the finding cannot be tied to a line.
+	Line    int
+	Message string
+}
+
+// MigrationPlan is what migrate would apply next and what a reviewer should
+// know about it.
+type MigrationPlan struct {
+	Pending []MigrationStatus
+	// Steps holds the step names of the pending migrations declaring
+	// several Up or Down blocks, keyed by migration name.
+	Steps    map[string]PlanSteps
+	Findings []PlanFinding
+}
+
+// PlanSteps lists the Up and Down blocks of a migration in the order they
+// run. A direction with a single block has no names.
+type PlanSteps struct {
+	Up   []string
+	Down []string
+}
+
+// ErrorCount returns the number of findings that are errors.
+func (p MigrationPlan) ErrorCount() int {
+	n := 0
+	for _, f := range p.Findings {
+		if f.Severity == SeverityError {
+			n++
+		}
+	}
+	return n
+}
+
+// Plan lists the pending migrations with a warning for each operation that
+// destroys data and an error for each Validator violation. When a file does
+// not parse, the plan holds only the parse errors.
+func (d *Manager) Plan() (MigrationPlan, error) {
+	var plan MigrationPlan
+	checks, err := d.CheckFiles()
+	if err != nil {
+		return plan, err
+	}
+	for _, check := range checks {
+		for _, checkErr := range check.Errs {
+			finding := PlanFinding{Severity: SeverityError, File: check.Path, Message: checkErr.Error()}
+			var parseErr *ParseError
+			if errors.As(checkErr, &parseErr) {
+				finding.Line, finding.Message = parseErr.Line, parseErr.Message
+			}
+			plan.Findings = append(plan.Findings, finding)
+		}
+	}
+	if plan.ErrorCount() > 0 {
+		return plan, nil
+	}
+	statuses, err := d.MigrationStatuses()
+	if err != nil {
+		return plan, err
+	}
+	for _, status := range statuses {
+		if status.Disabled || (status.Applied && !status.Changed) {
+			continue
+		}
+		plan.Pending = append(plan.Pending, status)
+		if strings.EqualFold(filepath.Ext(status.Path), ".sql") {
+			continue
+		}
+		cached, err := d.readMigrationsBCL(status.Path)
+		if err != nil {
+			return plan, err
+		}
+		migration, ok := findMigrationByName(cached.migrations, status.Name)
+		if !ok {
+			continue
+		}
+		finding := func(severity, blockType, id, message string) PlanFinding {
+			line := declarationLine(cached.data, blockType, id)
+			if line == 0 {
+				line = declarationLine(cached.data, "Migration", migration.Name)
+			}
+			return PlanFinding{Severity: severity, Migration: migration.Name, File: status.Path, Line: line, Message: message}
+		}
+		if len(migration.UpSteps) > 0 || len(migration.DownSteps) > 0 {
+			if plan.Steps == nil {
+				plan.Steps = make(map[string]PlanSteps)
+			}
+			plan.Steps[migration.Name] = PlanSteps{Up: stepNames(migration.UpSteps), Down: stepNames(migration.DownSteps)}
+		}
+		if warning := dslVersionWarning(migration); warning != "" {
+			plan.Findings = append(plan.Findings, finding(SeverityWarning, "Migration", migration.Name, warning))
+		}
+		for _, op := range destructiveOperations(migration.Up) {
+			plan.Findings = append(plan.Findings, finding(SeverityWarning, op.blockType, op.name, op.message))
+		}
+		severity := SeverityWarning
+		if d.notNullStrategy == NotNullError || d.notNullStrategy == NotNullBackfill {
+			severity = SeverityError
+		}
+		for _, op := range unsafeNotNullFields(migration.Up, d.notNullStrategy) {
+			plan.Findings = append(plan.Findings, finding(severity, op.blockType, op.name, op.message))
+		}
+		dialect := d.dialect
+		if migration.Driver != "" {
+			if normalized, err := NormalizeDriver(migration.Driver); err == nil {
+				dialect = normalized
+			}
+		}
+		for _, op := range degradedFieldTypes(dialect, migration.Up) {
+			plan.Findings = append(plan.Findings, finding(SeverityWarning, op.blockType, op.name, op.message))
+		}
+		v := NewValidator()
+		v.Strict = d.strictIdentifiers
+		v.Rules = d.identifierRules()
+		v.ValidateMigration(migration)
+		for _, verr := range v.Errors() {
+			// Migration names carry a timestamp prefix; they are not SQL
+			// identifiers.
+			if verr.Field == "migration.name" {
+				continue
+			}
+			plan.Findings = append(plan.Findings, finding(SeverityError, "Migration", migration.Name, verr.Error()))
+		}
+		for _, vwarn := range v.Warnings() {
+			if vwarn.Field == "migration.name" {
+				continue
+			}
+			plan.Findings = append(plan.Findings, finding(SeverityWarning, "Migration", migration.Name, fmt.Sprintf("field '%s' (value: '%s'): %s", vwarn.Field, vwarn.Value, vwarn.Message)))
+		}
+	}
+	return plan, nil
+}
+
+// stepNames returns the labels of steps as Migration.PlanSteps names them,
+// with "step N" for an unlabeled block.
+func stepNames(steps []OperationStep) []string {
+	if len(steps) < 2 {
+		return nil
+	}
+	names := make([]string, len(steps))
+	for i, step := range steps {
+		names[i] = step.Name
+		if names[i] == "" {
+			names[i] = fmt.Sprintf("step %d", i+1)
+		}
+	}
+	return names
+}
+
+type destructiveOperation struct {
+	blockType, name, message string
+}
+
+// destructiveOperations lists the operations of op that lose data.
+func destructiveOperations(op Operation) []destructiveOperation {
+	var ops []destructiveOperation
+	for _, dt := range op.DropTable {
+		ops = append(ops, destructiveOperation{"DropTable", dt.Name, fmt.Sprintf("drops table %s and every row in it", dt.Name)})
+	}
+	for _, at := range op.AlterTable {
+		for _, df := range at.DropFields {
+			ops = append(ops, destructiveOperation{"DropField", df.Name, fmt.Sprintf("drops column %s.%s and its values", at.Name, df.Name)})
+		}
+	}
+	for _, dd := range op.DeleteData {
+		ops = append(ops, destructiveOperation{"DeleteData", dd.Name, fmt.Sprintf("deletes rows from %s where %s", dd.Name, dd.Where)})
+	}
+	for _, ds := range op.DropSchema {
+		ops = append(ops, destructiveOperation{"DropSchema", ds.Name, fmt.Sprintf("drops schema %s", ds.Name)})
+	}
+	for _, dd := range op.DropDatabase {
+		ops = append(ops, destructiveOperation{"DropDatabase", dd.Name, fmt.Sprintf("drops database %s", dd.Name)})
+	}
+	return ops
+}
+
+// untypedRenames lists the RenameField blocks of op without a type on
+// MySQL, which restates the column type when renaming.
+func untypedRenames(dialect string, op Operation) []destructiveOperation {
+	if dialect != DialectMySQL {
+		return nil
+	}
+	var ops []destructiveOperation
+	for _, at := range op.AlterTable {
+		for _, rf := range at.RenameFields {
+			if rf.Type == "" {
+				ops = append(ops, destructiveOperation{"RenameField", rf.Name, fmt.Sprintf("renames column %s.%s to %s without a type, which MySQL requires; set type", at.Name, rf.From, rf.To)})
+			}
+		}
+	}
+	return ops
+}
+
+// declarationLine returns the 1-based line of the first `blockType "id"`
+// block in data, or 0 when there is none.
+func declarationLine(data []byte, blockType, id string) int {
+	re := regexp.MustCompile(`(?m)^[ \t]*` + regexp.QuoteMeta(blockType) + `\s+"` + regexp.QuoteMeta(id) + `"`)
+	loc := re.FindIndex(data)
+	if loc == nil {
+		return 0
+	}
+	return strings.Count(string(data[:loc[0]]), "\n") + 1
+}
+
+// WritePlan writes plan for people: the pending migrations, then every
+// finding with its location.
+func WritePlan(w io.Writer, plan MigrationPlan) {
+	if len(plan.Pending) == 0 {
+		fmt.Fprintln(w, "No pending migrations.")
+	} else {
+		fmt.Fprintf(w, "Pending migrations: %d\n", len(plan.Pending))
+		for _, status := range plan.Pending {
+			fmt.Fprintf(w, "  %s (%s)\n", status.Name, status.Path)
+			steps := plan.Steps[status.Name]
+			if len(steps.Up) > 0 {
+				fmt.Fprintf(w, "    Up steps: %s\n", strings.Join(steps.Up, ", "))
+			}
+			if len(steps.Down) > 0 {
+				fmt.Fprintf(w, "    Down steps: %s\n", strings.Join(steps.Down, ", "))
+			}
+		}
+	}
+	for _, f := range plan.Findings {
+		location := f.File
+		if f.Line > 0 {
+			location = fmt.Sprintf("%s:%d", f.File, f.Line)
+		}
+		fmt.Fprintf(w, "%s: %s: %s\n", f.Severity, location, f.Message)
+	}
+}
+
+// WritePlanGitLab writes the findings of plan as a GitLab Code Quality
+// report, which GitLab shows on the merge request when the job publishes it
+// as a codequality artifact.
+func WritePlanGitLab(w io.Writer, plan MigrationPlan) error {
+	type lines struct {
+		Begin int `json:"begin"`
+	}
+	type location struct {
+		Path  string `json:"path"`
+		Lines lines  `json:"lines"`
+	}
+	type issue struct {
+		Description string   `json:"description"`
+		CheckName   string   `json:"check_name"`
+		Fingerprint string   `json:"fingerprint"`
+		Severity    string   `json:"severity"`
+		Location    location `json:"location"`
+	}
+	issues := make([]issue, 0, len(plan.Findings))
+	for _, f := range plan.Findings {
+		severity := "minor"
+		if f.Severity == SeverityError {
+			severity = "major"
+		}
+		file := relativeToWorkingDir(f.File)
+		issues = append(issues, issue{
+			Description: f.Message,
+			CheckName:   "migrate-" + f.Severity,
+			Fingerprint: computeChecksum([]byte(file + "\x00" + f.Migration + "\x00" + f.Message)),
+			Severity:    severity,
+			Location:    location{Path: file, Lines: lines{Begin: max(f.Line, 1)}},
+		})
+	}
+	data, err := json.MarshalIndent(issues, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// relativeToWorkingDir returns path relative to the working directory, which
+// in CI is the repository root, using forward slashes.
+func relativeToWorkingDir(path string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+	}
+	return filepath.ToSlash(path)
+}
+
+// WritePlanGitHub writes the findings of plan as GitHub Actions workflow
+// commands, which GitHub shows as annotations on the pull request diff.
+func WritePlanGitHub(w io.Writer, plan MigrationPlan) {
+	for _, f := range plan.Findings {
+		props := "file=" + escapeGitHubProperty(relativeToWorkingDir(f.File))
+		if f.Line > 0 {
+			props += fmt.Sprintf(",line=%d", f.Line)
+		}
+		title := "migration"
+		if f.Migration != "" {
+			title = "migration " + f.Migration
+		}
+		props += ",title=" + escapeGitHubProperty(title)
+		fmt.Fprintf(w, "::%s %s::%s\n", f.Severity, props, escapeGitHubData(f.Message))
+	}
+}
+
+// escapeGitHubData escapes a workflow command message.
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeGitHubProperty escapes a workflow command property value.
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
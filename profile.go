@@ -0,0 +1,329 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/oarkflow/json"
+)
+
+const (
+	// profileDirName is the directory of the seed directory holding the
+	// profiles written by db:profile.
+	profileDirName = "profiles"
+	// defaultProfileSample is the number of rows db:profile reads when no
+	// sample size is given.
+	defaultProfileSample = 10000
+	// maxProfileValues is the most distinct values a column may have for
+	// its value distribution to be kept.
+	maxProfileValues = 20
+)
+
+// Kinds of profiled columns.
+const (
+	ProfileNumber = "number"
+	ProfileText   = "text"
+	ProfileTime   = "time"
+	ProfileBool   = "bool"
+)
+
+// TableProfile holds the statistics of the columns of a table that seeds
+// reference with value = "profile", see db:profile.
+type TableProfile struct {
+	Table string `json:"table"`
+	// Rows is the number of rows profiled.
+	Rows    int                       `json:"rows"`
+	Columns map[string]*ColumnProfile `json:"columns"`
+}
+
+// ColumnProfile describes the shape of the values of a column without
+// holding them, except for low-cardinality columns whose distribution is
+// kept in Values.
+type ColumnProfile struct {
+	Kind      string  `json:"kind"`
+	NullRatio float64 `json:"null_ratio"`
+	Distinct  int     `json:"distinct"`
+	// Values is the distribution of a column with at most maxProfileValues
+	// distinct values that repeat; it is never kept for redacted columns.
+	Values []ProfileValue `json:"values,omitempty"`
+	// Min, Max, Mean and StdDev describe numbers; Integer is set when all
+	// of them are whole.
+	Min     float64 `json:"min,omitempty"`
+	Max     float64 `json:"max,omitempty"`
+	Mean    float64 `json:"mean,omitempty"`
+	StdDev  float64 `json:"stddev,omitempty"`
+	Integer bool    `json:"integer,omitempty"`
+	// MinLength and MaxLength bound the length of texts.
+	MinLength int `json:"min_length,omitempty"`
+	MaxLength int `json:"max_length,omitempty"`
+	// From and To bound times.
+	From time.Time `json:"from,omitzero"`
+	To   time.Time `json:"to,omitzero"`
+}
+
+// ProfileValue is a value of a column with its share of the non-null rows.
+type ProfileValue struct {
+	Value  any     `json:"value"`
+	Weight float64 `json:"weight"`
+}
+
+// profilePath returns the file of the profile of table in the seed
+// directory seedDir.
+func profilePath(seedDir, table string) string {
+	return filepath.Join(seedDir, profileDirName, table+".json")
+}
+
+// ProfileTable reads up to sample rows of table, 0 meaning
+// defaultProfileSample, and writes the profile of its columns to the
+// profiles directory of the seed directory, returning the file written.
+func (d *Manager) ProfileTable(table string, sample int) (string, error) {
+	if d.dbDriver == nil {
+		return "", fmt.Errorf("no database driver configured")
+	}
+	if err := requireFields(table); err != nil {
+		return "", fmt.Errorf("profile: %w", err)
+	}
+	if sample <= 0 {
+		sample = defaultProfileSample
+	}
+	var rows []map[string]any
+	query := fmt.Sprintf("SELECT * FROM %s LIMIT %d", quoteDialectIdentifier(d.dialect, table), sample)
+	if err := d.dbDriver.Query(context.Background(), &rows, query); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", table, err)
+	}
+	if len(rows) == 0 {
+		return "", fmt.Errorf("table %s has no rows to profile", table)
+	}
+	redactor := d.redactor
+	if redactor == nil {
+		redactor = NewRedactor(DefaultRedactPatterns...)
+	}
+	profile := TableProfile{Table: table, Rows: len(rows), Columns: make(map[string]*ColumnProfile)}
+	for column := range rows[0] {
+		values := make([]any, len(rows))
+		for i, row := range rows {
+			values[i] = row[column]
+		}
+		profile.Columns[column] = profileColumn(values, !redactor.Matches(column))
+	}
+	data, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode profile of %s: %w", table, err)
+	}
+	path := profilePath(d.seedDir, table)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create profile directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write profile of %s: %w", table, err)
+	}
+	logger.Printf("Profile of %s written from %d row(s): %s", table, len(rows), path)
+	return path, nil
+}
+
+// profileColumn computes the profile of the values of a column; keepValues
+// allows its value distribution to be kept.
+func profileColumn(values []any, keepValues bool) *ColumnProfile {
+	p := &ColumnProfile{Integer: true}
+	counts := make(map[string]int)
+	var order []string
+	var present []any
+	nulls := 0
+	for _, v := range values {
+		if b, ok := v.([]byte); ok {
+			v = string(b)
+		}
+		if v == nil {
+			nulls++
+			continue
+		}
+		key := uniqueKey(v)
+		if counts[key] == 0 {
+			order = append(order, key)
+		}
+		counts[key]++
+		present = append(present, v)
+	}
+	p.NullRatio = float64(nulls) / float64(len(values))
+	p.Distinct = len(counts)
+	if len(present) == 0 {
+		p.Kind, p.Integer = ProfileText, false
+		return p
+	}
+	var sum, sumSquares float64
+	p.Min, p.Max = math.Inf(1), math.Inf(-1)
+	p.MinLength = math.MaxInt
+	for _, v := range present {
+		switch v := v.(type) {
+		case bool:
+			p.Kind = ProfileBool
+		case time.Time:
+			p.Kind = ProfileTime
+			if p.From.IsZero() || v.Before(p.From) {
+				p.From = v
+			}
+			if v.After(p.To) {
+				p.To = v
+			}
+		case string:
+			p.Kind = ProfileText
+			n := len([]rune(v))
+			p.MinLength, p.MaxLength = min(p.MinLength, n), max(p.MaxLength, n)
+		default:
+			f, ok := profileNumber(v)
+			if !ok {
+				p.Kind = ProfileText
+				continue
+			}
+			if p.Kind == "" {
+				p.Kind = ProfileNumber
+			}
+			p.Min, p.Max = math.Min(p.Min, f), math.Max(p.Max, f)
+			p.Integer = p.Integer && f == math.Trunc(f)
+			sum += f
+			sumSquares += f * f
+		}
+	}
+	if p.Kind == ProfileNumber {
+		n := float64(len(present))
+		p.Mean = sum / n
+		p.StdDev = math.Sqrt(math.Max(sumSquares/n-p.Mean*p.Mean, 0))
+	} else {
+		p.Min, p.Max, p.Integer = 0, 0, false
+	}
+	if p.Kind != ProfileText {
+		p.MinLength = 0
+	}
+	// A distribution is only kept for values that repeat, such as statuses,
+	// never for identifiers or free text.
+	if keepValues && p.Distinct <= maxProfileValues && p.Distinct*2 <= len(present) {
+		first := make(map[string]any, len(order))
+		for _, v := range present {
+			if _, ok := first[uniqueKey(v)]; !ok {
+				first[uniqueKey(v)] = v
+			}
+		}
+		for _, key := range order {
+			p.Values = append(p.Values, ProfileValue{Value: first[key], Weight: float64(counts[key]) / float64(len(present))})
+		}
+	}
+	return p
+}
+
+func profileNumber(v any) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// generate returns a value drawn from the profile.
+func (p *ColumnProfile) generate() any {
+	if rand.Float64() < p.NullRatio {
+		return nil
+	}
+	if len(p.Values) > 0 {
+		pick := rand.Float64()
+		for _, v := range p.Values {
+			if pick -= v.Weight; pick < 0 {
+				return p.value(v.Value)
+			}
+		}
+		return p.value(p.Values[len(p.Values)-1].Value)
+	}
+	switch p.Kind {
+	case ProfileBool:
+		return rand.Intn(2) == 1
+	case ProfileNumber:
+		f := p.Mean
+		if p.StdDev > 0 {
+			f = rand.NormFloat64()*p.StdDev + p.Mean
+		}
+		f = math.Min(math.Max(f, p.Min), p.Max)
+		if p.Integer {
+			return int64(math.Round(f))
+		}
+		return f
+	case ProfileTime:
+		span := p.To.Sub(p.From)
+		if span <= 0 {
+			return p.From
+		}
+		return p.From.Add(time.Duration(rand.Int63n(int64(span))))
+	}
+	n := p.MinLength
+	if p.MaxLength > p.MinLength {
+		n += rand.Intn(p.MaxLength - p.MinLength + 1)
+	}
+	return randomString(n)
+}
+
+// value returns a value of the distribution, whole numbers read back from
+// the profile as float64 becoming integers again.
+func (p *ColumnProfile) value(v any) any {
+	if f, ok := v.(float64); ok && p.Integer {
+		return int64(f)
+	}
+	return v
+}
+
+// profileField returns the column a seed field value draws from its
+// table's profile: "profile" for the column of the field, or
+// "profile:<column>".
+func profileField(value, field string) (string, bool) {
+	if value == "profile" {
+		return field, true
+	}
+	column, ok := strings.CutPrefix(value, "profile:")
+	return strings.TrimSpace(column), ok
+}
+
+// profile returns the profile of column in the profile named table, read
+// once per run from the profiles directory.
+func (l *seedLookup) profile(table, column string) (*ColumnProfile, error) {
+	if l.seedDir == "" {
+		return nil, fmt.Errorf("profile values need the seed directory of a seed run")
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	profile, ok := l.profiles[table]
+	if !ok {
+		data, err := os.ReadFile(profilePath(l.seedDir, table))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read profile of %s (run db:profile %s): %w", table, table, err)
+		}
+		profile = &TableProfile{}
+		if err := json.Unmarshal(data, profile); err != nil {
+			return nil, fmt.Errorf("failed to parse profile of %s: %w", table, err)
+		}
+		l.profiles[table] = profile
+	}
+	p, ok := profile.Columns[column]
+	if !ok {
+		var columns []string
+		for name := range profile.Columns {
+			columns = append(columns, name)
+		}
+		slices.Sort(columns)
+		return nil, fmt.Errorf("profile of %s has no column %s (columns: %s)", table, column, strings.Join(columns, ", "))
+	}
+	return p, nil
+}
@@ -0,0 +1,110 @@
+package migrate
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/oarkflow/migrate/drivers"
+)
+
+// redactedValue replaces masked parameter values in logs.
+const redactedValue = "[REDACTED]"
+
+// RedactPasswords masks the password literals of CREATE/ALTER ROLE and
+// CREATE/ALTER USER statements in sql.
+func RedactPasswords(sql string) string {
+	return drivers.RedactPasswords(sql)
+}
+
+// DefaultRedactPatterns are the column name patterns masked when no patterns
+// are configured.
+var DefaultRedactPatterns = []string{"*password*", "*passwd*", "*secret*", "*token*", "*api_key*", "*email*", "*phone*", "ssn"}
+
+// Redactor masks bound parameter values whose column name matches one of its
+// glob patterns (see path.Match). Matching is case-insensitive; "*" masks all.
+type Redactor struct {
+	patterns []string
+}
+
+// NewRedactor creates a redactor for the given column name patterns.
+func NewRedactor(patterns ...string) *Redactor {
+	r := &Redactor{}
+	for _, p := range patterns {
+		if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+			r.patterns = append(r.patterns, p)
+		}
+	}
+	return r
+}
+
+// ValidateRedactPatterns reports the first malformed pattern.
+func ValidateRedactPatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(strings.ToLower(p), ""); err != nil {
+			return fmt.Errorf("invalid redact pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether values for column should be masked.
+func (r *Redactor) Matches(column string) bool {
+	if r == nil {
+		return false
+	}
+	column = strings.ToLower(column)
+	for _, p := range r.patterns {
+		if ok, _ := path.Match(p, column); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Args returns a copy of args with matching values masked.
+func (r *Redactor) Args(args map[string]any) map[string]any {
+	out := make(map[string]any, len(args))
+	for k, v := range args {
+		if r.Matches(k) {
+			out[k] = redactedValue
+		} else {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// FormatStatement renders sql and its redacted named arguments for logging,
+// with arguments sorted by name.
+func (r *Redactor) FormatStatement(sql string, args map[string]any) string {
+	if len(args) == 0 {
+		return sql
+	}
+	keys := make([]string, 0, len(args))
+	for k := range args {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	redacted := r.Args(args)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%v", k, redacted[k]))
+	}
+	return fmt.Sprintf("%s [%s]", sql, strings.Join(parts, ", "))
+}
+
+// logStatement logs a statement with redacted arguments when Verbose is on
+// or the log level is debug.
+func (d *Manager) logStatement(sql string, args map[string]any) {
+	entry := d.detailLog()
+	if entry == nil {
+		return
+	}
+	redactor := d.redactor
+	if redactor == nil {
+		redactor = NewRedactor(DefaultRedactPatterns...)
+	}
+	entry.Msgf("SQL: %s", redactor.FormatStatement(RedactPasswords(sql), args))
+}
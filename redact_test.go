@@ -0,0 +1,27 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactorMasksMatchingColumns(t *testing.T) {
+	r := NewRedactor("*password*", "Email")
+	got := r.FormatStatement(`INSERT INTO "users" ("name", "email", "password_hash") VALUES (:name, :email, :password_hash)`, map[string]any{
+		"name":          "alice",
+		"email":         "alice@example.com",
+		"password_hash": "secret",
+	})
+	if strings.Contains(got, "alice@example.com") || strings.Contains(got, "secret") {
+		t.Fatalf("sensitive values not redacted: %s", got)
+	}
+	if !strings.Contains(got, "name=alice") || !strings.Contains(got, "email="+redactedValue) {
+		t.Fatalf("unexpected statement: %s", got)
+	}
+	if !NewRedactor("*").Matches("anything") {
+		t.Fatal("* should match every column")
+	}
+	if err := ValidateRedactPatterns([]string{"[bad"}); err == nil {
+		t.Fatal("expected invalid pattern error")
+	}
+}
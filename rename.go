@@ -0,0 +1,112 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// RenameMigration renames migration oldName to newName. The Migration block
+// of a BCL file is renamed, and so is the file when its name matches
+// oldName. History entries of an applied migration are renamed too, and the
+// checksums of every applied migration in the rewritten file are updated.
+// Applied migrations that were modified since they were applied are not
+// renamed.
+func (d *Manager) RenameMigration(oldName, newName string) error {
+	if err := requireFields(oldName, newName); err != nil {
+		return fmt.Errorf("RenameMigration: %w", err)
+	}
+	if strings.ContainsAny(newName, `/\"`) {
+		return fmt.Errorf("invalid migration name %q", newName)
+	}
+	if d.assets != nil {
+		return fmt.Errorf("cannot rename migrations in an embedded filesystem")
+	}
+	migrationMap, err := d.ListMigrationMap()
+	if err != nil {
+		return fmt.Errorf("failed to list migrations: %w", err)
+	}
+	path, ok := migrationMap[oldName]
+	if !ok {
+		return fmt.Errorf("migration %q not found in '%s'", oldName, d.migrationDir)
+	}
+	if existing, ok := migrationMap[newName]; ok {
+		return fmt.Errorf("migration %q already exists in %s", newName, existing)
+	}
+	histories, err := d.historyDriver.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load migration history: %w", err)
+	}
+	// Every applied migration stored in the file must still match its
+	// checksum, because rewriting the file changes all of them.
+	affected := make(map[int]bool)
+	for i, h := range histories {
+		if migrationMap[h.Name] != path {
+			continue
+		}
+		raw, normalized, err := d.migrationChecksums(path, h.Name)
+		if err != nil {
+			return err
+		}
+		if !checksumMatches(h.Checksum, raw, normalized) {
+			return fmt.Errorf("migration '%s' has been modified after being applied: %w", h.Name, ErrChecksumMismatch)
+		}
+		affected[i] = true
+	}
+
+	data, err := d.readFile(path)
+	if err != nil {
+		return err
+	}
+	ext := filepath.Ext(path)
+	if !strings.EqualFold(ext, ".sql") {
+		block := regexp.MustCompile(`(?m)^(\s*Migration\s+)"` + regexp.QuoteMeta(oldName) + `"`)
+		if n := len(block.FindAllIndex(data, -1)); n != 1 {
+			return fmt.Errorf("expected one Migration %q block in %s, found %d", oldName, path, n)
+		}
+		data = block.ReplaceAll(data, []byte(`${1}"`+newName+`"`))
+	}
+	newPath := path
+	if strings.TrimSuffix(filepath.Base(path), ext) == oldName {
+		newPath = filepath.Join(filepath.Dir(path), newName+ext)
+	}
+	if newPath != path {
+		if _, err := os.Stat(newPath); err == nil {
+			return fmt.Errorf("file %s already exists", newPath)
+		}
+	}
+	if err := os.WriteFile(newPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", newPath, err)
+	}
+	if newPath != path {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+	logger.Info().Msgf("Renamed migration %s to %s in %s", oldName, newName, newPath)
+
+	if len(affected) == 0 {
+		return nil
+	}
+	for i := range affected {
+		h := &histories[i]
+		if h.Name == oldName {
+			h.Name = newName
+		}
+		raw, normalized, err := d.migrationChecksums(newPath, h.Name)
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(h.Checksum, normalizedChecksumPrefix) {
+			h.Checksum = normalized
+		} else {
+			h.Checksum = raw
+		}
+	}
+	if err := d.historyDriver.Rollback(histories...); err != nil {
+		return fmt.Errorf("failed to update migration history: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package migrate
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SchemaObject is one object in a schema snapshot.
+type SchemaObject struct {
+	// Kind is dialect specific, such as "table", "column" or "index".
+	Kind string `db:"kind"`
+	Name string `db:"name"`
+	// Table is the table the object belongs to, if any.
+	Table      string `db:"tbl"`
+	Definition string `db:"definition"`
+}
+
+func (o SchemaObject) String() string {
+	return o.Kind + " " + o.Name
+}
+
+// SchemaSnapshot lists the objects in the managed database, leaving out the
+// migration history table.
+func (d *Manager) SchemaSnapshot() ([]SchemaObject, error) {
+	if d.dbDriver == nil {
+		return nil, fmt.Errorf("no database driver configured")
+	}
+	var historyTable string
+	if history, ok := databaseHistory(d.historyDriver); ok {
+		historyTable = history.table
+	}
+	return schemaObjects(d.dbDriver, d.dialect, historyTable)
+}
+
+// diffSchemaSnapshots describes how after differs from before, one line per
+// object added, removed or redefined.
+func diffSchemaSnapshots(before, after []SchemaObject) []string {
+	old := make(map[string]SchemaObject, len(before))
+	for _, o := range before {
+		old[o.String()] = o
+	}
+	var diffs []string
+	for _, o := range after {
+		prev, ok := old[o.String()]
+		delete(old, o.String())
+		switch {
+		case !ok:
+			diffs = append(diffs, o.String()+" was left behind")
+		case prev.Definition != o.Definition:
+			diffs = append(diffs, fmt.Sprintf("%s changed from %q to %q", o, prev.Definition, o.Definition))
+		}
+	}
+	for key := range old {
+		diffs = append(diffs, key+" was not restored")
+	}
+	sort.Strings(diffs)
+	return diffs
+}
+
+// ReversibilityResult reports whether rolling back a migration restored the
+// schema it started from.
+type ReversibilityResult struct {
+	Migration string
+	// Differences between the schema before Up and the schema after Down.
+	Differences []string
+	// Err is set when Up or Down failed.
+	Err error
+}
+
+// Reversible reports whether Up and Down ran and left no differences.
+func (r ReversibilityResult) Reversible() bool {
+	return r.Err == nil && len(r.Differences) == 0
+}
+
+// CheckReversibility applies each pending migration in order, rolls it back
+// and compares schema snapshots taken before Up and after Down. Each
+// migration is then applied again so the next builds on it. Checking stops
+// at the first migration whose Up or Down fails. It changes the database and
+// is meant for a scratch copy.
+func (d *Manager) CheckReversibility() ([]ReversibilityResult, error) {
+	statuses, err := d.MigrationStatuses()
+	if err != nil {
+		return nil, err
+	}
+	var results []ReversibilityResult
+	for _, status := range statuses {
+		if status.Applied || status.Disabled {
+			continue
+		}
+		apply := func() error { return d.ApplySQLMigration(status.Path) }
+		if !strings.EqualFold(filepath.Ext(status.Path), ".sql") {
+			cached, err := d.readMigrationsBCL(status.Path)
+			if err != nil {
+				return results, err
+			}
+			migration, ok := findMigrationByName(cached.migrations, status.Name)
+			if !ok {
+				return results, fmt.Errorf("migration %s not found in %s", status.Name, status.Path)
+			}
+			apply = func() error { return d.ApplyMigration(migration) }
+		}
+		before, err := d.SchemaSnapshot()
+		if err != nil {
+			return results, err
+		}
+		result := ReversibilityResult{Migration: status.Name}
+		if err := apply(); err != nil {
+			result.Err = fmt.Errorf("up: %w", err)
+			return append(results, result), nil
+		}
+		if err := d.RollbackMigration(1); err != nil {
+			result.Err = fmt.Errorf("down: %w", err)
+			return append(results, result), nil
+		}
+		after, err := d.SchemaSnapshot()
+		if err != nil {
+			return results, err
+		}
+		result.Differences = diffSchemaSnapshots(before, after)
+		results = append(results, result)
+		if err := apply(); err != nil {
+			return results, fmt.Errorf("failed to re-apply %s after rolling it back: %w", status.Name, err)
+		}
+	}
+	return results, nil
+}
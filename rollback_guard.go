@@ -0,0 +1,157 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/oarkflow/cli/contracts"
+)
+
+// modifiedRollbackSQL guards the rollback of h, applied from the file at
+// path. When the file was modified since h was applied its Down may no
+// longer undo what ran, so the rollback is refused unless AllowModified is
+// set, or the Down SQL generated at apply time was stored in the history or
+// the audit log, which is then returned to be run instead of the current
+// Down.
+func (d *Manager) modifiedRollbackSQL(h MigrationHistory, path string) ([]string, error) {
+	if h.Checksum == "" {
+		return nil, nil
+	}
+	raw, normalized, err := d.migrationChecksums(path, h.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to checksum %s: %w", path, err)
+	}
+	if checksumMatches(h.Checksum, raw, normalized) {
+		return nil, nil
+	}
+	if d.AllowModified {
+		logger.Warn().Msgf("Migration '%s' was modified after being applied; rolling back with the Down of the current file", h.Name)
+		return nil, nil
+	}
+	if len(h.DownSQL) > 0 {
+		logger.Warn().Msgf("Migration '%s' was modified after being applied; rolling back with the Down SQL stored in the history", h.Name)
+		return h.DownSQL, nil
+	}
+	down, err := d.auditedDownSQL(h.Name)
+	if err != nil {
+		return nil, err
+	}
+	if len(down) > 0 {
+		logger.Warn().Msgf("Migration '%s' was modified after being applied; rolling back with the Down SQL recorded in the audit log", h.Name)
+		return down, nil
+	}
+	return nil, fmt.Errorf("migration '%s' has been modified after being applied and its Down may not undo it; roll back with --allow-modified=true to use the current file: %w", h.Name, ErrChecksumMismatch)
+}
+
+// auditedDownSQL returns the Down SQL the audit log recorded for the latest
+// apply of migration name, or nil when there is none.
+func (d *Manager) auditedDownSQL(name string) ([]string, error) {
+	if d.auditLog == "" {
+		return nil, nil
+	}
+	entries, err := ReadAuditLog(d.auditLog)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.Event != AuditEventApply || e.Migration != name {
+			continue
+		}
+		return e.DownSQL, nil
+	}
+	return nil, nil
+}
+
+// recordedDownSQL returns the Down SQL of migration to record in the history
+// and the audit log when it is applied, so it can be rolled back as applied
+// after the file changes. Nothing is recorded when a statement has
+// arguments, or when the Down sets a role password, so that resolved
+// secrets are never stored.
+func (d *Manager) recordedDownSQL(checksum string, migration Migration, dialect string) []string {
+	if migration.resolvesSecrets(false) {
+		logger.Warn().Msgf("Not recording the Down SQL of migration %s because it sets a role password; it will roll back with the Down of its file", migration.Name)
+		return nil
+	}
+	down, err := d.migrationSQL(checksum, migration, dialect, false)
+	if err != nil {
+		logger.Warn().Msgf("Failed to generate Down SQL of migration %s to record: %v", migration.Name, err)
+		return nil
+	}
+	sqls := make([]string, len(down))
+	for i, st := range down {
+		if st.Args != nil {
+			return nil
+		}
+		sqls[i] = st.SQL
+	}
+	return sqls
+}
+
+// rollbackFromHistory rolls back h with the Down SQL stored in the history,
+// or else in the audit log, on the default connection. It reports false when
+// nothing was stored for an orphaned migration, one whose file is missing;
+// with FromHistory set a migration whose file exists must have stored SQL.
+func (d *Manager) rollbackFromHistory(h MigrationHistory, orphaned bool) (bool, error) {
+	down := h.DownSQL
+	if len(down) == 0 {
+		var err error
+		if down, err = d.auditedDownSQL(h.Name); err != nil {
+			return false, err
+		}
+	}
+	if len(down) == 0 {
+		if orphaned {
+			return false, nil
+		}
+		return false, fmt.Errorf("no Down SQL was stored when migration %s was applied; roll it back without --from-history", h.Name)
+	}
+	if orphaned {
+		logger.Warn().Msgf("Migration file for %s not found; rolling back the orphaned migration with the Down SQL stored when it was applied", h.Name)
+	}
+	for _, sql := range down {
+		d.detailLog().Msg(RedactPasswords(sql))
+	}
+	if err := d.dbDriver.ApplyBatch(storedStatements(down)); err != nil {
+		if !d.Force {
+			return false, d.newMigrationError("rollback", h.Name, err)
+		}
+		logger.Warn().Msgf("Failed to rollback migration %s (continuing): %v", h.Name, err)
+		return true, nil
+	}
+	if orphaned {
+		logger.Info().Msg("Rolled back orphaned migration: " + h.Name)
+	} else {
+		logger.Info().Msg("Rolled back migration: " + h.Name)
+	}
+	return true, nil
+}
+
+// storedStatements converts recorded Down SQL into statements.
+func storedStatements(sqls []string) []Statement {
+	stmts := make([]Statement, len(sqls))
+	for i, sql := range sqls {
+		stmts[i] = Statement{SQL: sql}
+	}
+	return stmts
+}
+
+func allowModifiedFlagDefinition() contracts.Flag {
+	return contracts.Flag{
+		Name:  "allow-modified",
+		Usage: "Roll back migrations modified since they were applied with the Down of the current file",
+		Value: "false",
+	}
+}
+
+func fromHistoryFlagDefinition() contracts.Flag {
+	return contracts.Flag{
+		Name:  "from-history",
+		Usage: "Roll back with the Down SQL stored in the history when migrations were applied instead of the Down of their files",
+		Value: "false",
+	}
+}
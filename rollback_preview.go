@@ -0,0 +1,127 @@
+package migrate
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// RollbackPreview is the Down SQL a migration would run if it were rolled
+// back now.
+type RollbackPreview struct {
+	Migration string
+	// AppliedAt is zero when the history could not be read.
+	AppliedAt  time.Time
+	Statements []string
+	// Irreversible lists what the rollback cannot undo or destroys, e.g. a
+	// table dropped by Up whose rows are not restored.
+	Irreversible []string
+}
+
+// RollbackPreviews renders the Down SQL of the applied migrations, newest
+// first, in the order migration:rollback would run them. When the history
+// cannot be read every migration is previewed.
+func (d *Manager) RollbackPreviews() ([]RollbackPreview, error) {
+	migrationMap, err := d.ListMigrationMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+	names, err := d.offlineSQLNames(migrationMap, OfflineSQLOptions{Down: true})
+	if err != nil {
+		return nil, err
+	}
+	appliedAt := make(map[string]time.Time)
+	if d.historyDriver != nil {
+		if histories, err := d.historyDriver.Load(); err == nil {
+			for _, h := range histories {
+				appliedAt[h.Name] = h.AppliedAt
+			}
+		}
+	}
+	previews := make([]RollbackPreview, 0, len(names))
+	for _, name := range names {
+		path := migrationMap[name]
+		statements, err := d.offlineMigrationSQL(name, path, d.dialect, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate Down SQL for %s: %w", name, err)
+		}
+		preview := RollbackPreview{Migration: name, AppliedAt: appliedAt[name]}
+		for _, st := range statements {
+			preview.Statements = append(preview.Statements, strings.TrimSpace(st.SQL))
+		}
+		if len(statements) == 0 {
+			preview.Irreversible = append(preview.Irreversible, "no Down operations; the migration cannot be rolled back")
+		}
+		if !strings.EqualFold(filepath.Ext(path), ".sql") {
+			cached, err := d.readMigrationsBCL(path)
+			if err != nil {
+				return nil, err
+			}
+			if migration, ok := findMigrationByName(cached.migrations, name); ok {
+				preview.Irreversible = append(preview.Irreversible, irreversibleOperations(migration)...)
+			}
+		}
+		previews = append(previews, preview)
+	}
+	return previews, nil
+}
+
+// irreversibleOperations lists the data that rolling back m loses: rows
+// removed by Up are not restored, and tables or columns dropped by Down take
+// the rows written since with them.
+func irreversibleOperations(m Migration) []string {
+	var notes []string
+	for _, dt := range m.Up.DropTable {
+		notes = append(notes, fmt.Sprintf("Up dropped table %s; its rows are not restored", dt.Name))
+	}
+	for _, at := range m.Up.AlterTable {
+		for _, df := range at.DropFields {
+			notes = append(notes, fmt.Sprintf("Up dropped column %s.%s; its values are not restored", at.Name, df.Name))
+		}
+	}
+	for _, dd := range m.Up.DeleteData {
+		notes = append(notes, fmt.Sprintf("Up deleted rows from %s; they are not restored", dd.Name))
+	}
+	for _, dt := range m.Down.DropTable {
+		notes = append(notes, fmt.Sprintf("Down drops table %s with every row written since", dt.Name))
+	}
+	for _, at := range m.Down.AlterTable {
+		for _, df := range at.DropFields {
+			notes = append(notes, fmt.Sprintf("Down drops column %s.%s with its values", at.Name, df.Name))
+		}
+	}
+	return notes
+}
+
+var rollbackPreviewTemplate = template.Must(template.New("rollback").Parse(`
+	<section id="rollback-preview" class="px-6 py-4">
+		<h2 class="text-lg font-bold text-blue-700 mb-2">Rollback Preview</h2>
+		{{- if not .}}
+		<p class="text-gray-500">No applied migrations.</p>
+		{{- end}}
+		{{- range .}}
+		<div class="bg-white rounded-lg shadow-sm p-3 mb-3{{if .Irreversible}} border-l-4 border-red-500{{end}}">
+			<div class="font-semibold">{{.Migration}}{{if not .AppliedAt.IsZero}} <span class="text-xs text-gray-500">applied {{.AppliedAt.Format "2006-01-02 15:04:05"}}</span>{{end}}</div>
+			{{- range .Irreversible}}
+			<div class="text-red-600 text-xs">&#9888; {{.}}</div>
+			{{- end}}
+			{{- if .Statements}}
+			<pre class="bg-gray-100 rounded p-2 mt-2 text-xs overflow-x-auto">{{range .Statements}}{{.}}
+{{end}}</pre>
+			{{- end}}
+		</div>
+		{{- end}}
+	</section>
+`))
+
+// rollbackPreviewHTML renders previews as a section of the history report.
+func rollbackPreviewHTML(previews []RollbackPreview) (string, error) {
+	var buf bytes.Buffer
+	if err := rollbackPreviewTemplate.Execute(&buf, previews); err != nil {
+		return "", fmt.Errorf("failed to render rollback preview: %w", err)
+	}
+	return buf.String(), nil
+}
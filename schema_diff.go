@@ -0,0 +1,437 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// SchemaDiff is the difference between the schemas of two live databases,
+// as seen by the target: the objects it lacks, the objects it has in excess,
+// and the objects it defines differently.
+type SchemaDiff struct {
+	Dialect string
+	// Missing objects exist only in the source.
+	Missing []SchemaObject
+	// Extra objects exist only in the target.
+	Extra []SchemaObject
+	// Changed objects are defined differently in the source and the target.
+	Changed []SchemaChange
+	// sourceTables and targetTables are the tables with columns on each
+	// side, see schemaGenerator.groupTables.
+	sourceTables map[string]bool
+	targetTables map[string]bool
+}
+
+// SchemaChange is an object defined differently in two databases.
+type SchemaChange struct {
+	Source SchemaObject
+	Target SchemaObject
+}
+
+// Empty reports whether the two schemas are the same.
+func (s SchemaDiff) Empty() bool {
+	return len(s.Missing) == 0 && len(s.Extra) == 0 && len(s.Changed) == 0
+}
+
+// parseDatabaseURL returns the driver and DSN of url. A url starting with a
+// driver scheme, such as postgres://, mysql:// or sqlite://, names its
+// driver; any other is a DSN of defaultDriver. MySQL and SQLite DSNs are
+// given to the driver without the scheme.
+func parseDatabaseURL(url, defaultDriver string) (string, string, error) {
+	scheme, rest, ok := strings.Cut(url, "://")
+	if !ok {
+		driver, err := NormalizeDriver(defaultDriver)
+		return driver, url, err
+	}
+	driver, err := NormalizeDriver(scheme)
+	if err != nil {
+		return "", "", err
+	}
+	if driver == DialectPostgres {
+		return driver, url, nil
+	}
+	return driver, rest, nil
+}
+
+// schemaObjects lists the objects of the database of driver, leaving out
+// the migration history table.
+func schemaObjects(driver IDatabaseDriver, dialect, historyTable string) ([]SchemaObject, error) {
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return nil, err
+	}
+	var objects []SchemaObject
+	if err := driver.Query(context.Background(), &objects, dial.SchemaSnapshotSQL()); err != nil {
+		return nil, fmt.Errorf("failed to snapshot schema: %w", err)
+	}
+	if historyTable == "" {
+		return objects, nil
+	}
+	kept := objects[:0]
+	for _, o := range objects {
+		if o.Name == historyTable || o.Table == historyTable {
+			continue
+		}
+		kept = append(kept, o)
+	}
+	return kept, nil
+}
+
+// DiffDatabases compares the schemas of the live databases at source and
+// target, both URLs as accepted by schema:diff, which must use the same
+// driver. The migration history table is left out.
+func (d *Manager) DiffDatabases(source, target string) (SchemaDiff, error) {
+	var historyTable string
+	if history, ok := databaseHistory(d.historyDriver); ok {
+		historyTable = history.table
+	}
+	dialect := ""
+	snapshot := func(side, url string) ([]SchemaObject, error) {
+		driver, dsn, err := parseDatabaseURL(url, d.dialect)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s database: %w", side, err)
+		}
+		if dialect != "" && driver != dialect {
+			return nil, fmt.Errorf("cannot compare a %s database with a %s database", dialect, driver)
+		}
+		dialect = driver
+		db, err := NewDriver(driver, dsn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to the %s database: %w", side, err)
+		}
+		defer db.DB().Close()
+		objects, err := schemaObjects(db, driver, historyTable)
+		if err != nil {
+			return nil, fmt.Errorf("%s database: %w", side, err)
+		}
+		return objects, nil
+	}
+	sourceObjects, err := snapshot("source", source)
+	if err != nil {
+		return SchemaDiff{}, err
+	}
+	targetObjects, err := snapshot("target", target)
+	if err != nil {
+		return SchemaDiff{}, err
+	}
+	diff := compareSchemas(sourceObjects, targetObjects)
+	diff.Dialect = dialect
+	return diff, nil
+}
+
+// compareSchemas returns the difference of target from source.
+func compareSchemas(source, target []SchemaObject) SchemaDiff {
+	diff := SchemaDiff{sourceTables: make(map[string]bool), targetTables: make(map[string]bool)}
+	targets := make(map[string]SchemaObject, len(target))
+	for _, o := range target {
+		targets[o.String()] = o
+		if o.Kind == "column" {
+			diff.targetTables[o.Table] = true
+		}
+	}
+	for _, o := range source {
+		if o.Kind == "column" {
+			diff.sourceTables[o.Table] = true
+		}
+		t, ok := targets[o.String()]
+		delete(targets, o.String())
+		switch {
+		case !ok:
+			diff.Missing = append(diff.Missing, o)
+		case t.Definition != o.Definition:
+			diff.Changed = append(diff.Changed, SchemaChange{Source: o, Target: t})
+		}
+	}
+	for _, o := range target {
+		if _, ok := targets[o.String()]; ok {
+			diff.Extra = append(diff.Extra, o)
+		}
+	}
+	return diff
+}
+
+// Write prints the difference to w, one line per object: "+" for objects
+// only in the source, "-" for objects only in the target and "~" for
+// objects defined differently, followed by both definitions.
+func (s SchemaDiff) Write(w io.Writer) error {
+	for _, o := range s.Missing {
+		fmt.Fprintf(w, "+ %-40s %s\n", o, o.Definition)
+	}
+	for _, o := range s.Extra {
+		fmt.Fprintf(w, "- %-40s %s\n", o, o.Definition)
+	}
+	for _, c := range s.Changed {
+		fmt.Fprintf(w, "~ %s\n", c.Source)
+		fmt.Fprintf(w, "    source: %s\n", c.Source.Definition)
+		fmt.Fprintf(w, "    target: %s\n", c.Target.Definition)
+	}
+	_, err := fmt.Fprintf(w, "%d object(s) missing from the target, %d extra, %d changed\n", len(s.Missing), len(s.Extra), len(s.Changed))
+	return err
+}
+
+// schemaStep is a statement of a reconciling migration. Steps run by rank:
+// dependent objects are dropped before what they depend on, and created
+// after it.
+type schemaStep struct {
+	rank int
+	sql  string
+}
+
+// schemaRanks orders the drops (first) and creates (second) of each kind.
+var schemaRanks = map[string][2]int{
+	"view":        {0, 7},
+	"trigger":     {0, 7},
+	"function":    {0, 7},
+	"routine":     {0, 7},
+	"constraint":  {1, 6},
+	"foreign_key": {1, 6},
+	"index":       {1, 6},
+	"column":      {2, 5},
+	"table":       {3, 4},
+	"type":        {3, 4},
+}
+
+// schemaGenerator writes the statements creating, dropping and altering the
+// objects of a schema snapshot of dialect. Objects it cannot create from
+// their snapshot get a TODO comment instead.
+type schemaGenerator struct {
+	dialect string
+}
+
+func (g schemaGenerator) quote(id string) string {
+	return quoteDialectIdentifier(g.dialect, id)
+}
+
+func (g schemaGenerator) todo(o SchemaObject, rank int, action string) []schemaStep {
+	text := fmt.Sprintf("-- TODO: %s %s", action, o)
+	if o.Table != "" && o.Table != o.Name {
+		text += " on " + o.Table
+	}
+	if o.Definition != "" {
+		text += ": " + strings.ReplaceAll(o.Definition, "\n", "\n-- ")
+	}
+	return []schemaStep{{rank, text}}
+}
+
+// column splits the name of a column object into its table and column.
+func (g schemaGenerator) column(o SchemaObject) (string, string) {
+	_, column, _ := strings.Cut(o.Name, ".")
+	return o.Table, column
+}
+
+// index returns the name of an index object, which MySQL prefixes with its
+// table.
+func (g schemaGenerator) index(o SchemaObject) string {
+	if g.dialect == DialectMySQL {
+		_, name, _ := strings.Cut(o.Name, ".")
+		return name
+	}
+	return o.Name
+}
+
+func (g schemaGenerator) create(o SchemaObject) []schemaStep {
+	rank := schemaRanks[o.Kind][1]
+	if g.dialect == DialectSQLite {
+		if o.Definition == "" {
+			return g.todo(o, rank, "create")
+		}
+		return []schemaStep{{rank, strings.TrimSuffix(o.Definition, ";") + ";"}}
+	}
+	switch o.Kind {
+	case "table":
+		return []schemaStep{{rank, fmt.Sprintf("CREATE TABLE %s (\n%s\n);", g.quote(o.Name), o.Definition)}}
+	case "column":
+		table, column := g.column(o)
+		return []schemaStep{{rank, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", g.quote(table), g.quote(column), o.Definition)}}
+	case "view":
+		return []schemaStep{{rank, fmt.Sprintf("CREATE VIEW %s AS %s;", g.quote(o.Name), strings.TrimSuffix(strings.TrimSpace(o.Definition), ";"))}}
+	}
+	switch {
+	case g.dialect == DialectPostgres && (o.Kind == "index" || o.Kind == "trigger"):
+		return []schemaStep{{rank, o.Definition + ";"}}
+	case g.dialect == DialectPostgres && o.Kind == "constraint":
+		return []schemaStep{{rank, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s;", g.quote(o.Table), g.quote(o.Name), o.Definition)}}
+	case g.dialect == DialectMySQL && o.Kind == "index":
+		columns := strings.Split(o.Definition, ",")
+		for i, c := range columns {
+			columns[i] = g.quote(c)
+		}
+		if name := g.index(o); name != "PRIMARY" {
+			return []schemaStep{{rank, fmt.Sprintf("CREATE INDEX %s ON %s (%s);", g.quote(name), g.quote(o.Table), strings.Join(columns, ", "))}}
+		}
+		return []schemaStep{{rank, fmt.Sprintf("ALTER TABLE %s ADD PRIMARY KEY (%s);", g.quote(o.Table), strings.Join(columns, ", "))}}
+	}
+	return g.todo(o, rank, "create")
+}
+
+func (g schemaGenerator) drop(o SchemaObject) []schemaStep {
+	rank := schemaRanks[o.Kind][0]
+	switch o.Kind {
+	case "table", "view":
+		return []schemaStep{{rank, fmt.Sprintf("DROP %s IF EXISTS %s;", strings.ToUpper(o.Kind), g.quote(o.Name))}}
+	case "column":
+		table, column := g.column(o)
+		return []schemaStep{{rank, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", g.quote(table), g.quote(column))}}
+	}
+	switch g.dialect {
+	case DialectSQLite:
+		if o.Kind == "index" || o.Kind == "trigger" {
+			return []schemaStep{{rank, fmt.Sprintf("DROP %s IF EXISTS %s;", strings.ToUpper(o.Kind), g.quote(o.Name))}}
+		}
+	case DialectPostgres:
+		switch o.Kind {
+		case "index":
+			return []schemaStep{{rank, fmt.Sprintf("DROP INDEX IF EXISTS %s;", g.quote(o.Name))}}
+		case "constraint":
+			return []schemaStep{{rank, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;", g.quote(o.Table), g.quote(o.Name))}}
+		case "trigger":
+			return []schemaStep{{rank, fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s;", g.quote(o.Name), g.quote(o.Table))}}
+		}
+	case DialectMySQL:
+		switch o.Kind {
+		case "index":
+			if name := g.index(o); name != "PRIMARY" {
+				return []schemaStep{{rank, fmt.Sprintf("DROP INDEX %s ON %s;", g.quote(name), g.quote(o.Table))}}
+			}
+			return []schemaStep{{rank, fmt.Sprintf("ALTER TABLE %s DROP PRIMARY KEY;", g.quote(o.Table))}}
+		case "foreign_key":
+			return []schemaStep{{rank, fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s;", g.quote(o.Table), g.quote(o.Name))}}
+		case "trigger":
+			return []schemaStep{{rank, fmt.Sprintf("DROP TRIGGER IF EXISTS %s;", g.quote(o.Name))}}
+		}
+	}
+	return g.todo(o, rank, "drop")
+}
+
+// alter changes the definition of an object from that of from to that of to.
+// Columns are altered in place and SQLite tables, which cannot be, get a
+// TODO; other objects are dropped and created again.
+func (g schemaGenerator) alter(from, to SchemaObject) []schemaStep {
+	if to.Kind == "column" {
+		table, column := g.column(to)
+		rank := schemaRanks["column"][1]
+		switch g.dialect {
+		case DialectMySQL:
+			return []schemaStep{{rank, fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s;", g.quote(table), g.quote(column), to.Definition)}}
+		case DialectPostgres:
+			return g.alterPostgresColumn(table, column, from.Definition, to.Definition, rank)
+		}
+	}
+	if g.dialect == DialectSQLite && to.Kind == "table" {
+		return g.todo(to, schemaRanks["table"][1], "recreate")
+	}
+	return append(g.drop(from), g.create(to)...)
+}
+
+// alterPostgresColumn alters a column from the definition from to to, both
+// as listed by PostgresDialect.SchemaSnapshotSQL.
+func (g schemaGenerator) alterPostgresColumn(table, column, from, to string, rank int) []schemaStep {
+	split := func(def string) (typ string, notNull bool, dflt string) {
+		def, dflt, _ = strings.Cut(def, " DEFAULT ")
+		typ, notNull = strings.CutSuffix(def, " NOT NULL")
+		return typ, notNull, dflt
+	}
+	fromType, fromNotNull, fromDefault := split(from)
+	toType, toNotNull, toDefault := split(to)
+	alter := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s", g.quote(table), g.quote(column))
+	var steps []schemaStep
+	if fromType != toType {
+		steps = append(steps, schemaStep{rank, fmt.Sprintf("%s TYPE %s USING %s::%s;", alter, toType, g.quote(column), toType)})
+	}
+	if fromDefault != toDefault {
+		if toDefault == "" {
+			steps = append(steps, schemaStep{rank, alter + " DROP DEFAULT;"})
+		} else {
+			steps = append(steps, schemaStep{rank, fmt.Sprintf("%s SET DEFAULT %s;", alter, toDefault)})
+		}
+	}
+	if fromNotNull != toNotNull {
+		if toNotNull {
+			steps = append(steps, schemaStep{rank, alter + " SET NOT NULL;"})
+		} else {
+			steps = append(steps, schemaStep{rank, alter + " DROP NOT NULL;"})
+		}
+	}
+	return steps
+}
+
+// groupTables replaces the columns of objects whose table is not present on
+// the other side by a table object, so a table missing on one side is
+// created or dropped as a whole. SQLite snapshots list tables, not columns.
+func (g schemaGenerator) groupTables(objects []SchemaObject, present map[string]bool) []SchemaObject {
+	if g.dialect == DialectSQLite {
+		return objects
+	}
+	var grouped []SchemaObject
+	tables := make(map[string]int)
+	for _, o := range objects {
+		if o.Kind != "column" || present[o.Table] {
+			grouped = append(grouped, o)
+			continue
+		}
+		_, column := g.column(o)
+		def := fmt.Sprintf("  %s %s", g.quote(column), o.Definition)
+		if i, ok := tables[o.Table]; ok {
+			grouped[i].Definition += ",\n" + def
+			continue
+		}
+		tables[o.Table] = len(grouped)
+		grouped = append(grouped, SchemaObject{Kind: "table", Name: o.Table, Table: o.Table, Definition: def})
+	}
+	return grouped
+}
+
+// Migration returns a raw SQL migration bringing the target in line with
+// the source, and back in its down section. It is a skeleton to review:
+// statements that cannot be derived from the schemas are TODO comments.
+func (s SchemaDiff) Migration(name string) string {
+	g := schemaGenerator{dialect: s.Dialect}
+	// A table missing from the target is created by up and dropped by down,
+	// and the other way around for a table only in the target.
+	missing := g.groupTables(s.Missing, s.targetTables)
+	extra := g.groupTables(s.Extra, s.sourceTables)
+	// PostgreSQL lists the index of a primary key or unique constraint as
+	// well; it comes and goes with the constraint.
+	constraints := make(map[string]bool)
+	for _, o := range append(append([]SchemaObject(nil), missing...), extra...) {
+		if o.Kind == "constraint" {
+			constraints[o.Name] = true
+		}
+	}
+	owned := func(o SchemaObject) bool { return o.Kind == "index" && constraints[o.Name] }
+	missing = slices.DeleteFunc(missing, owned)
+	extra = slices.DeleteFunc(extra, owned)
+	var up, down []schemaStep
+	for _, o := range missing {
+		up = append(up, g.create(o)...)
+		down = append(down, g.drop(o)...)
+	}
+	for _, o := range extra {
+		up = append(up, g.drop(o)...)
+		down = append(down, g.create(o)...)
+	}
+	for _, c := range s.Changed {
+		up = append(up, g.alter(c.Target, c.Source)...)
+		down = append(down, g.alter(c.Source, c.Target)...)
+	}
+	write := func(sb *strings.Builder, steps []schemaStep) {
+		sort.SliceStable(steps, func(i, j int) bool { return steps[i].rank < steps[j].rank })
+		for _, step := range steps {
+			sb.WriteString(step.sql + "\n")
+		}
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "-- Raw SQL migration: %s\n", name)
+	fmt.Fprintf(&sb, "-- Generated by schema:diff (%s): migration-up brings the target schema in line\n", s.Dialect)
+	sb.WriteString("-- with the source and migration-down restores it. Review it before applying,\n")
+	sb.WriteString("-- and replace the TODO comments with statements.\n\n")
+	sb.WriteString("-- migration-up\n")
+	write(&sb, up)
+	sb.WriteString("\n-- migration-down\n")
+	write(&sb, down)
+	return sb.String()
+}
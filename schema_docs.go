@@ -0,0 +1,488 @@
+package migrate
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Formats of schema:docs.
+const (
+	// SchemaDocsHTML is a static HTML site, served as is by GitHub Pages.
+	SchemaDocsHTML = "html"
+	// SchemaDocsMarkdown is a Markdown site, rendered by GitHub Pages or any
+	// Markdown viewer.
+	SchemaDocsMarkdown = "markdown"
+)
+
+// schemaDocsUnsafe matches the characters of object names left out of page
+// file names.
+var schemaDocsUnsafe = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// schemaDocsObject is a documented object with its page.
+type schemaDocsObject struct {
+	Name string
+	Type string
+	Page string
+	// Description is the description of the migration creating the object.
+	Description  string
+	Fields       []AddField
+	PrimaryKey   []string
+	References   []schemaDocsRelation
+	ReferencedBy []schemaDocsRelation
+	Definition   string
+	History      []MigrationGroup
+}
+
+// schemaDocsRelation is a foreign key from Field of Table to RefField of
+// RefTable; Page is the page of the table at the other end.
+type schemaDocsRelation struct {
+	Table    string
+	Field    string
+	RefTable string
+	RefField string
+	OnDelete string
+	OnUpdate string
+	Page     string
+}
+
+// SchemaDocs returns the pages of a documentation site of the objects left
+// by the migrations of the migration set, empty meaning every migration,
+// keyed by their path in the site. The index lists the objects; each object
+// has a page with its fields, relationships or definition and the history of
+// the migrations changing it. Format is SchemaDocsHTML or SchemaDocsMarkdown.
+func (d *Manager) SchemaDocs(format, set string) (map[string][]byte, error) {
+	ext := ""
+	switch format {
+	case SchemaDocsHTML:
+		ext = ".html"
+	case SchemaDocsMarkdown:
+		ext = ".md"
+	default:
+		return nil, fmt.Errorf("unsupported docs format %q (expected %s or %s)", format, SchemaDocsHTML, SchemaDocsMarkdown)
+	}
+	tables, last, err := d.migrationSchema(set)
+	if err != nil {
+		return nil, err
+	}
+	filePaths, err := d.schemaDocsFiles(set)
+	if err != nil {
+		return nil, err
+	}
+	readMigrations := func(path string) ([]Migration, error) {
+		cached, err := d.readMigrationsBCL(path)
+		if err != nil {
+			return nil, err
+		}
+		return cached.migrations, nil
+	}
+	objectSet := historyObjects(filePaths, readMigrations)
+	infos := make([]objectInfo, 0, len(objectSet)+len(tables))
+	for name, typ := range objectSet {
+		infos = append(infos, objectInfo{Name: name, Type: typ})
+	}
+	// Renamed tables are created under another name.
+	for _, t := range tables {
+		if _, ok := objectSet[strings.ToLower(t.name)]; !ok {
+			infos = append(infos, objectInfo{Name: strings.ToLower(t.name), Type: "table"})
+		}
+	}
+	reports := objectHistoryReports(infos, filePaths, readMigrations)
+
+	page := func(typ, name string) string {
+		return path.Join(typ+"s", schemaDocsUnsafe.ReplaceAllString(strings.ToLower(name), "_")+ext)
+	}
+	var objects []*schemaDocsObject
+	byTable := map[string]*schemaDocsObject{}
+	for _, t := range tables {
+		report := reports[strings.ToLower(t.name)]
+		obj := &schemaDocsObject{
+			Name:    t.name,
+			Type:    "table",
+			Page:    page("table", t.name),
+			Fields:  sortFieldsPriority(slices.Clone(t.fields)),
+			History: report.History,
+		}
+		if report.FinalTable != nil {
+			obj.PrimaryKey = report.FinalTable.PrimaryKey
+		}
+		objects = append(objects, obj)
+		byTable[strings.ToLower(t.name)] = obj
+	}
+	for _, obj := range objects {
+		for _, f := range obj.Fields {
+			if f.ForeignKey == nil {
+				continue
+			}
+			rel := schemaDocsRelation{
+				Table:    obj.Name,
+				Field:    f.Name,
+				RefTable: f.ForeignKey.ReferenceTable,
+				RefField: f.ForeignKey.ReferenceField,
+				OnDelete: f.ForeignKey.OnDelete,
+				OnUpdate: f.ForeignKey.OnUpdate,
+			}
+			target, ok := byTable[strings.ToLower(rel.RefTable)]
+			if ok {
+				rel.Page = target.Page
+			}
+			obj.References = append(obj.References, rel)
+			if ok {
+				rel.Page = obj.Page
+				target.ReferencedBy = append(target.ReferencedBy, rel)
+			}
+		}
+	}
+	for _, info := range infos {
+		report := reports[info.Name]
+		definition := ""
+		switch {
+		case info.Type == "view" && report.FinalView != nil:
+			definition = report.FinalView.Definition
+		case info.Type == "function" && report.FinalFunction != nil:
+			definition = report.FinalFunction.Definition
+		case info.Type == "procedure" && report.FinalProcedure != nil:
+			definition = report.FinalProcedure.Definition
+		case info.Type == "trigger" && report.FinalTrigger != nil:
+			definition = report.FinalTrigger.Definition
+		default:
+			// Tables come from the migration schema; dropped objects are
+			// not documented.
+			continue
+		}
+		objects = append(objects, &schemaDocsObject{
+			Name:       info.Name,
+			Type:       info.Type,
+			Page:       page(info.Type, info.Name),
+			Definition: definition,
+			History:    report.History,
+		})
+	}
+	for _, obj := range objects {
+		if len(obj.History) > 0 {
+			obj.Description = obj.History[0].Description
+		}
+	}
+	sort.SliceStable(objects, func(i, j int) bool {
+		if objects[i].Type != objects[j].Type {
+			return schemaDocsTypeRank(objects[i].Type) < schemaDocsTypeRank(objects[j].Type)
+		}
+		return objects[i].Name < objects[j].Name
+	})
+
+	pages := make(map[string][]byte, len(objects)+2)
+	for _, obj := range objects {
+		var content []byte
+		if format == SchemaDocsHTML {
+			content, err = schemaDocsObjectHTML(obj)
+		} else {
+			content = schemaDocsObjectMarkdown(obj)
+		}
+		if err != nil {
+			return nil, err
+		}
+		pages[obj.Page] = content
+	}
+	if format == SchemaDocsHTML {
+		index, err := schemaDocsIndexHTML(objects, last)
+		if err != nil {
+			return nil, err
+		}
+		pages["index.html"] = index
+		// GitHub Pages publishes the pages as they are, without Jekyll.
+		pages[".nojekyll"] = []byte{}
+	} else {
+		pages["index.md"] = schemaDocsIndexMarkdown(objects, last)
+	}
+	return pages, nil
+}
+
+// schemaDocsFiles returns the migration files of the migration set in
+// migration order.
+func (d *Manager) schemaDocsFiles(set string) ([]string, error) {
+	dir := filepath.Join(d.migrationDir, set)
+	migrationMap, err := d.ListMigrationMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migration files: %w", err)
+	}
+	seen := make(map[string]bool, len(migrationMap))
+	var filePaths []string
+	for _, p := range migrationMap {
+		if rel, err := filepath.Rel(dir, p); err != nil || strings.HasPrefix(rel, "..") || seen[p] {
+			continue
+		}
+		seen[p] = true
+		filePaths = append(filePaths, p)
+	}
+	sort.Strings(filePaths)
+	return filePaths, nil
+}
+
+func schemaDocsTypeRank(typ string) int {
+	return slices.Index([]string{"table", "view", "function", "procedure", "trigger"}, typ)
+}
+
+// schemaDocsFlags returns the constraints of f as short labels.
+func schemaDocsFlags(f AddField) []string {
+	var flags []string
+	if f.PrimaryKey {
+		flags = append(flags, "PK")
+	}
+	if f.AutoIncrement {
+		flags = append(flags, "auto increment")
+	}
+	if f.Unique {
+		flags = append(flags, "unique")
+	}
+	if f.Index {
+		flags = append(flags, "index")
+	}
+	if f.Nullable {
+		flags = append(flags, "nullable")
+	} else {
+		flags = append(flags, "not null")
+	}
+	if hasDefault(f) {
+		flags = append(flags, fmt.Sprintf("default %v", f.Default))
+	}
+	if f.Check != "" {
+		flags = append(flags, "check "+f.Check)
+	}
+	return flags
+}
+
+// schemaDocsChange describes a change of the history of an object.
+func schemaDocsChange(ch MigrationChange) string {
+	switch {
+	case ch.CreateTable != nil:
+		return fmt.Sprintf("Create table with %d fields", len(ch.CreateTable.AddFields))
+	case ch.Field != nil:
+		return fmt.Sprintf("Add field %s %s", ch.Field.Name, migrationFieldType(*ch.Field))
+	case ch.DropField != nil:
+		return "Drop field " + ch.DropField.Name
+	case ch.RenameField != nil:
+		return fmt.Sprintf("Rename field %s to %s", ch.RenameField.From, ch.RenameField.To)
+	case ch.Details != "":
+		return ch.Operation + " " + ch.Details
+	}
+	return ch.Operation
+}
+
+// schemaDocsLink returns the relative link from the page from to the page
+// to.
+func schemaDocsLink(from, to string) string {
+	return strings.Repeat("../", strings.Count(from, "/")) + to
+}
+
+func schemaDocsMarkdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+func schemaDocsIndexMarkdown(objects []*schemaDocsObject, last string) []byte {
+	var b bytes.Buffer
+	b.WriteString("# Database schema\n\n")
+	if last != "" {
+		fmt.Fprintf(&b, "Schema as of migration `%s`.\n\n", last)
+	}
+	typ := ""
+	for _, obj := range objects {
+		if obj.Type != typ {
+			typ = obj.Type
+			fmt.Fprintf(&b, "\n## %s%ss\n\n", strings.ToUpper(typ[:1]), typ[1:])
+		}
+		fmt.Fprintf(&b, "- [%s](%s)", obj.Name, obj.Page)
+		if obj.Description != "" {
+			fmt.Fprintf(&b, " — %s", obj.Description)
+		}
+		b.WriteString("\n")
+	}
+	return b.Bytes()
+}
+
+func schemaDocsObjectMarkdown(obj *schemaDocsObject) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# %s `%s`\n\n[Index](%s)\n\n", strings.ToUpper(obj.Type[:1])+obj.Type[1:], obj.Name, schemaDocsLink(obj.Page, "index.md"))
+	if obj.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", obj.Description)
+	}
+	if len(obj.Fields) > 0 {
+		b.WriteString("## Fields\n\n| Field | Type | Constraints | References |\n| --- | --- | --- | --- |\n")
+		for _, f := range obj.Fields {
+			ref := ""
+			if f.ForeignKey != nil {
+				ref = f.ForeignKey.ReferenceTable + "." + f.ForeignKey.ReferenceField
+			}
+			fmt.Fprintf(&b, "| %s | `%s` | %s | %s |\n", schemaDocsMarkdownCell(f.Name), schemaDocsMarkdownCell(migrationFieldType(f)),
+				schemaDocsMarkdownCell(strings.Join(schemaDocsFlags(f), ", ")), schemaDocsMarkdownCell(ref))
+		}
+		b.WriteString("\n")
+		if len(obj.PrimaryKey) > 0 {
+			fmt.Fprintf(&b, "Primary key: %s\n\n", strings.Join(obj.PrimaryKey, ", "))
+		}
+	}
+	if len(obj.References) > 0 || len(obj.ReferencedBy) > 0 {
+		b.WriteString("## Relationships\n\n")
+		for _, rel := range obj.References {
+			target := rel.RefTable
+			if rel.Page != "" {
+				target = fmt.Sprintf("[%s](%s)", rel.RefTable, schemaDocsLink(obj.Page, rel.Page))
+			}
+			fmt.Fprintf(&b, "- `%s` references %s.`%s`%s\n", rel.Field, target, rel.RefField, schemaDocsActions(rel))
+		}
+		for _, rel := range obj.ReferencedBy {
+			fmt.Fprintf(&b, "- [%s](%s).`%s` references `%s`%s\n", rel.Table, schemaDocsLink(obj.Page, rel.Page), rel.Field, rel.RefField, schemaDocsActions(rel))
+		}
+		b.WriteString("\n")
+	}
+	if obj.Definition != "" {
+		fmt.Fprintf(&b, "## Definition\n\n```sql\n%s\n```\n\n", strings.TrimSpace(obj.Definition))
+	}
+	if len(obj.History) > 0 {
+		b.WriteString("## History\n")
+		for _, group := range obj.History {
+			fmt.Fprintf(&b, "\n### %s\n\n", group.MigrationName)
+			if meta := schemaDocsGroupMeta(group); meta != "" {
+				fmt.Fprintf(&b, "_%s_\n\n", meta)
+			}
+			for _, ch := range group.Actions {
+				fmt.Fprintf(&b, "- %s\n", schemaDocsChange(ch))
+			}
+		}
+	}
+	return b.Bytes()
+}
+
+// schemaDocsActions returns the referential actions of rel, if any.
+func schemaDocsActions(rel schemaDocsRelation) string {
+	var actions []string
+	if rel.OnDelete != "" {
+		actions = append(actions, "on delete "+strings.ToLower(rel.OnDelete))
+	}
+	if rel.OnUpdate != "" {
+		actions = append(actions, "on update "+strings.ToLower(rel.OnUpdate))
+	}
+	if len(actions) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(actions, ", ") + ")"
+}
+
+// schemaDocsGroupMeta returns the date, description and ownership of a
+// migration of a history.
+func schemaDocsGroupMeta(group MigrationGroup) string {
+	var parts []string
+	if !group.Date.IsZero() {
+		parts = append(parts, group.Date.UTC().Format("2006-01-02 15:04:05"))
+	}
+	if group.Description != "" {
+		parts = append(parts, group.Description)
+	}
+	if group.Ownership != "" {
+		parts = append(parts, group.Ownership)
+	}
+	return strings.Join(parts, " · ")
+}
+
+var schemaDocsTemplate = template.Must(template.New("docs").Funcs(template.FuncMap{
+	"title":   func(s string) string { return strings.ToUpper(s[:1]) + s[1:] },
+	"link":    schemaDocsLink,
+	"typ":     migrationFieldType,
+	"flags":   func(f AddField) string { return strings.Join(schemaDocsFlags(f), ", ") },
+	"actions": schemaDocsActions,
+	"change":  schemaDocsChange,
+	"meta":    schemaDocsGroupMeta,
+	"trim":    strings.TrimSpace,
+}).Parse(`{{define "head"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>{{.}}</title>
+<style>
+body{font-family:system-ui,sans-serif;max-width:960px;margin:2rem auto;padding:0 1rem;color:#1f2937}
+a{color:#2563eb}
+table{border-collapse:collapse;width:100%;margin:1rem 0}
+th,td{border:1px solid #e5e7eb;padding:.4rem .6rem;text-align:left;font-size:.9rem}
+th{background:#f3f4f6}
+pre{background:#f3f4f6;padding:1rem;overflow-x:auto}
+.meta{color:#6b7280;font-size:.9rem}
+</style>
+</head>
+<body>
+{{end}}
+{{define "index"}}{{template "head" "Database schema"}}<h1>Database schema</h1>
+{{if .Last}}<p class="meta">Schema as of migration <code>{{.Last}}</code>.</p>
+{{end}}{{range .Groups}}<h2>{{title .Type}}s</h2>
+<ul>
+{{range .Objects}}<li><a href="{{.Page}}">{{.Name}}</a>{{if .Description}} — {{.Description}}{{end}}</li>
+{{end}}</ul>
+{{end}}</body>
+</html>
+{{end}}
+{{define "object"}}{{template "head" .Name}}<p><a href="{{link .Page "index.html"}}">Index</a></p>
+<h1>{{title .Type}} <code>{{.Name}}</code></h1>
+{{if .Description}}<p>{{.Description}}</p>
+{{end}}{{if .Fields}}<h2>Fields</h2>
+<table>
+<thead><tr><th>Field</th><th>Type</th><th>Constraints</th><th>References</th></tr></thead>
+<tbody>
+{{range .Fields}}<tr><td>{{.Name}}</td><td><code>{{typ .}}</code></td><td>{{flags .}}</td><td>{{with .ForeignKey}}{{.ReferenceTable}}.{{.ReferenceField}}{{end}}</td></tr>
+{{end}}</tbody>
+</table>
+{{if .PrimaryKey}}<p>Primary key: {{range $i, $f := .PrimaryKey}}{{if $i}}, {{end}}{{$f}}{{end}}</p>
+{{end}}{{end}}{{if or .References .ReferencedBy}}<h2>Relationships</h2>
+<ul>
+{{$page := .Page}}{{range .References}}<li><code>{{.Field}}</code> references {{if .Page}}<a href="{{link $page .Page}}">{{.RefTable}}</a>{{else}}{{.RefTable}}{{end}}.<code>{{.RefField}}</code>{{actions .}}</li>
+{{end}}{{range .ReferencedBy}}<li><a href="{{link $page .Page}}">{{.Table}}</a>.<code>{{.Field}}</code> references <code>{{.RefField}}</code>{{actions .}}</li>
+{{end}}</ul>
+{{end}}{{if .Definition}}<h2>Definition</h2>
+<pre>{{trim .Definition}}</pre>
+{{end}}{{if .History}}<h2>History</h2>
+{{range .History}}<h3>{{.MigrationName}}</h3>
+{{with meta .}}<p class="meta">{{.}}</p>
+{{end}}<ul>
+{{range .Actions}}<li>{{change .}}</li>
+{{end}}</ul>
+{{end}}{{end}}</body>
+</html>
+{{end}}`))
+
+// schemaDocsGroup is the objects of a type on the index.
+type schemaDocsGroup struct {
+	Type    string
+	Objects []*schemaDocsObject
+}
+
+func schemaDocsIndexHTML(objects []*schemaDocsObject, last string) ([]byte, error) {
+	var groups []schemaDocsGroup
+	for _, obj := range objects {
+		if n := len(groups); n == 0 || groups[n-1].Type != obj.Type {
+			groups = append(groups, schemaDocsGroup{Type: obj.Type})
+		}
+		groups[len(groups)-1].Objects = append(groups[len(groups)-1].Objects, obj)
+	}
+	var buf bytes.Buffer
+	data := struct {
+		Last   string
+		Groups []schemaDocsGroup
+	}{last, groups}
+	if err := schemaDocsTemplate.ExecuteTemplate(&buf, "index", data); err != nil {
+		return nil, fmt.Errorf("failed to render docs index: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func schemaDocsObjectHTML(obj *schemaDocsObject) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := schemaDocsTemplate.ExecuteTemplate(&buf, "object", obj); err != nil {
+		return nil, fmt.Errorf("failed to render docs of %s: %w", obj.Name, err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,181 @@
+package migrate
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/oarkflow/json"
+)
+
+// Formats of schema:export.
+const (
+	// SchemaFormatJSONSchema is a JSON Schema document with one definition
+	// per table under $defs.
+	SchemaFormatJSONSchema = "jsonschema"
+	// SchemaFormatOpenAPI is an OpenAPI 3.1 document with one schema per
+	// table under components.schemas.
+	SchemaFormatOpenAPI = "openapi"
+)
+
+// sqlDefaultCall matches defaults that are SQL expressions rather than
+// values, such as now() or CURRENT_TIMESTAMP.
+var sqlDefaultCall = regexp.MustCompile(`(?i)^([a-z_][a-z0-9_.]*\(.*\)|current_(date|time|timestamp)|localtime(stamp)?)$`)
+
+// ExportSchema describes the tables left by the migrations of the migration
+// set, empty meaning every migration, in format: SchemaFormatJSONSchema or
+// SchemaFormatOpenAPI. Each table becomes an object schema of its row with
+// one property per field; fields that are NOT NULL, have no default and are
+// not assigned by the database are required.
+func (d *Manager) ExportSchema(format, set string) ([]byte, error) {
+	tables, last, err := d.migrationSchema(set)
+	if err != nil {
+		return nil, err
+	}
+	schemas := make(map[string]any, len(tables))
+	for _, t := range tables {
+		schemas[t.name] = tableJSONSchema(t, d.dialect)
+	}
+	var doc map[string]any
+	switch format {
+	case SchemaFormatJSONSchema:
+		doc = map[string]any{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"title":   "Database schema",
+			"$defs":   schemas,
+		}
+		if last != "" {
+			doc["description"] = "Tables after migration " + last
+		}
+	case SchemaFormatOpenAPI:
+		version := last
+		if version == "" {
+			version = "0"
+		}
+		doc = map[string]any{
+			"openapi":    "3.1.0",
+			"info":       map[string]any{"title": "Database schema", "version": version},
+			"components": map[string]any{"schemas": schemas},
+		}
+	default:
+		return nil, fmt.Errorf("unsupported schema format %q (expected %s or %s)", format, SchemaFormatJSONSchema, SchemaFormatOpenAPI)
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode schema: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// tableJSONSchema returns the JSON Schema of a row of t.
+func tableJSONSchema(t migrationTable, dialect string) map[string]any {
+	properties := make(map[string]any, len(t.fields))
+	required := []string{}
+	for _, f := range t.fields {
+		typ := strings.ToLower(strings.TrimSpace(f.Type))
+		if i := strings.Index(typ, "("); i >= 0 {
+			typ = typ[:i]
+		}
+		properties[f.Name] = fieldJSONSchema(f, typ)
+		if !f.Nullable && !hasDefault(f) && !benchAssigned(f, typ, dialect) {
+			required = append(required, f.Name)
+		}
+	}
+	slices.Sort(required)
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// fieldJSONSchema returns the JSON Schema of the values of f, whose
+// lowercased type without size is typ.
+func fieldJSONSchema(f AddField, typ string) map[string]any {
+	schema := map[string]any{}
+	jsonType := ""
+	switch {
+	case f.RawType != "":
+		schema["$comment"] = "SQL type " + f.RawType
+	case slices.Contains([]string{"int", "integer", "bigint", "smallint", "tinyint", "mediumint"}, typ), strings.HasSuffix(typ, "serial"):
+		jsonType = "integer"
+	case slices.Contains([]string{"float", "double", "decimal", "numeric", "real"}, typ):
+		jsonType = "number"
+	case typ == "boolean" || typ == "bool":
+		jsonType = "boolean"
+	case typ == "date":
+		jsonType, schema["format"] = "string", "date"
+	case typ == "time":
+		jsonType, schema["format"] = "string", "time"
+	case slices.Contains([]string{"datetime", "timestamp", "timestamptz"}, typ):
+		jsonType, schema["format"] = "string", "date-time"
+	case typ == "uuid":
+		jsonType, schema["format"] = "string", "uuid"
+	case typ == "json" || typ == "jsonb":
+		// Any JSON value.
+	case slices.Contains([]string{"binary", "blob", "bytea", "varbinary"}, typ):
+		jsonType, schema["contentEncoding"] = "string", "base64"
+	default:
+		jsonType = "string"
+		if f.Size > 0 {
+			schema["maxLength"] = f.Size
+		}
+	}
+	if jsonType != "" {
+		if f.Nullable {
+			schema["type"] = []string{jsonType, "null"}
+		} else {
+			schema["type"] = jsonType
+		}
+	}
+	if f.AutoIncrement || strings.HasSuffix(typ, "serial") {
+		schema["readOnly"] = true
+	}
+	if value, ok := jsonDefault(f, jsonType); ok {
+		schema["default"] = value
+	}
+	if f.ForeignKey != nil {
+		schema["x-foreign-key"] = f.ForeignKey.ReferenceTable + "." + f.ForeignKey.ReferenceField
+	}
+	return schema
+}
+
+func hasDefault(f AddField) bool {
+	return f.Default != nil && f.Default != ""
+}
+
+// jsonDefault returns the default of f as a value of jsonType. Defaults that
+// are SQL expressions, or do not parse as jsonType, are left out.
+func jsonDefault(f AddField, jsonType string) (any, bool) {
+	if !hasDefault(f) {
+		return nil, false
+	}
+	s, ok := f.Default.(string)
+	if !ok {
+		return f.Default, true
+	}
+	s = strings.Trim(strings.TrimSpace(s), "'")
+	if sqlDefaultCall.MatchString(s) || strings.EqualFold(s, "null") {
+		return nil, false
+	}
+	switch jsonType {
+	case "integer":
+		n, err := strconv.ParseInt(s, 10, 64)
+		return n, err == nil
+	case "number":
+		n, err := strconv.ParseFloat(s, 64)
+		return n, err == nil
+	case "boolean":
+		b, err := strconv.ParseBool(s)
+		return b, err == nil
+	case "":
+		// JSON fields and raw types take the default when it is JSON.
+		var v any
+		err := json.Unmarshal([]byte(s), &v)
+		return v, err == nil
+	}
+	return s, true
+}
@@ -0,0 +1,166 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// SecretProvider returns the secret stored under key.
+type SecretProvider func(key string) (string, error)
+
+var (
+	secretProvidersMu sync.RWMutex
+	secretProviders   = map[string]SecretProvider{
+		"env":  envSecret,
+		"file": fileSecret,
+	}
+)
+
+// RegisterSecretProvider makes references of the form "scheme:key" resolve
+// through provider, e.g. "vault:apps/billing#password". The built-in
+// schemes are "env" and "file".
+func RegisterSecretProvider(scheme string, provider SecretProvider) {
+	secretProvidersMu.Lock()
+	defer secretProvidersMu.Unlock()
+	secretProviders[scheme] = provider
+}
+
+// ResolveSecret resolves a "scheme:key" reference. Plain values are
+// rejected so that secrets are never written into migration files.
+func ResolveSecret(ref string) (string, error) {
+	provider, key, err := secretProvider(ref)
+	if err != nil {
+		return "", err
+	}
+	value, err := provider(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve secret %s: %w", ref, err)
+	}
+	return value, nil
+}
+
+// secretProvider splits a "scheme:key" reference and looks up the provider
+// of its scheme.
+func secretProvider(ref string) (SecretProvider, string, error) {
+	scheme, key, ok := strings.Cut(ref, ":")
+	if !ok || key == "" {
+		return nil, "", fmt.Errorf("secret must be a reference such as env:NAME or file:PATH")
+	}
+	secretProvidersMu.RLock()
+	provider, ok := secretProviders[scheme]
+	secretProvidersMu.RUnlock()
+	if !ok {
+		return nil, "", fmt.Errorf("unknown secret provider %q", scheme)
+	}
+	return provider, key, nil
+}
+
+func envSecret(key string) (string, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", key)
+	}
+	return value, nil
+}
+
+// fileSecret reads a secret file such as a Docker or Kubernetes secret mount,
+// dropping the trailing newline.
+func fileSecret(key string) (string, error) {
+	data, err := os.ReadFile(key)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// resolveRolePassword resolves the password reference of a role, or checks
+// and returns the reference itself when keepRef is set.
+func resolveRolePassword(ref string, keepRef bool) (string, error) {
+	if !keepRef {
+		return ResolveSecret(ref)
+	}
+	if _, _, err := secretProvider(ref); err != nil {
+		return "", err
+	}
+	return ref, nil
+}
+
+// withSecretReferences returns a copy of m whose role passwords generate
+// SQL with their secret reference, e.g. PASSWORD 'env:BILLING_DB_PASSWORD',
+// instead of the resolved value, and reports whether m sets any. Offline
+// SQL is generated from such a copy so secrets never reach its output.
+func (m Migration) withSecretReferences() (Migration, bool) {
+	found := false
+	keep := func(op Operation) Operation {
+		if len(op.CreateRole) > 0 {
+			roles := append([]CreateRole(nil), op.CreateRole...)
+			for i := range roles {
+				if roles[i].Password != "" {
+					roles[i].keepSecretRef = true
+					found = true
+				}
+			}
+			op.CreateRole = roles
+		}
+		if len(op.AlterRole) > 0 {
+			roles := append([]AlterRole(nil), op.AlterRole...)
+			for i := range roles {
+				if roles[i].Password != "" {
+					roles[i].keepSecretRef = true
+					found = true
+				}
+			}
+			op.AlterRole = roles
+		}
+		return op
+	}
+	keepSteps := func(steps []OperationStep) []OperationStep {
+		if len(steps) == 0 {
+			return steps
+		}
+		out := make([]OperationStep, len(steps))
+		for i, step := range steps {
+			out[i] = OperationStep{Name: step.Name, Operation: keep(step.Operation)}
+		}
+		return out
+	}
+	m.Up = keep(m.Up)
+	m.Down = keep(m.Down)
+	m.UpSteps = keepSteps(m.UpSteps)
+	m.DownSteps = keepSteps(m.DownSteps)
+	return m, found
+}
+
+// resolvesSecrets reports whether the Up or Down of m sets a role password,
+// whose generated SQL holds the resolved secret.
+func (m Migration) resolvesSecrets(up bool) bool {
+	ops, steps := m.Down, m.DownSteps
+	if up {
+		ops, steps = m.Up, m.UpSteps
+	}
+	if ops.setsRolePassword() {
+		return true
+	}
+	for _, step := range steps {
+		if step.Operation.setsRolePassword() {
+			return true
+		}
+	}
+	return false
+}
+
+func (op Operation) setsRolePassword() bool {
+	for _, role := range op.CreateRole {
+		if role.Password != "" {
+			return true
+		}
+	}
+	for _, role := range op.AlterRole {
+		if role.Password != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -1,9 +1,11 @@
 package migrate
 
 import (
+	"cmp"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/oarkflow/expr"
 	"github.com/oarkflow/expr/vm"
@@ -20,6 +22,11 @@ type SeedDefinition struct {
 	Combine   []string          `json:"combine"`
 	Condition string            `json:"condition"`
 	Rows      int               `json:"rows"`
+	// Profile names the profile written by db:profile that fields with
+	// value = "profile" draw from; empty means the profile of Table.
+	Profile string `json:"profile,omitempty"`
+	// Atomic is the atomic setting of the seed file; nil means true.
+	Atomic *bool `json:"Atomic,omitempty"`
 }
 
 type FieldDefinition struct {
@@ -41,7 +48,12 @@ type SeedHistory struct {
 	AppliedAt string `json:"applied_at"`
 }
 
-func convertSeedValue(val any, dataType string) any {
+// convertSeedValue converts val to the value bound for a field of dataType
+// on dialect. A nil val stays nil and inserts NULL.
+func convertSeedValue(val any, dataType, dialect string) any {
+	if val == nil {
+		return nil
+	}
 	switch strings.ToLower(dataType) {
 	case "int", "integer", "number":
 		switch v := val.(type) {
@@ -61,16 +73,64 @@ func convertSeedValue(val any, dataType string) any {
 		case bool:
 			return v
 		}
+	case "date", "datetime", "timestamp", "timestamptz", "time":
+		return seedTimeValue(val, strings.ToLower(dataType), dialect)
+	}
+	if t, ok := val.(time.Time); ok {
+		return seedTimeValue(t, "datetime", dialect)
 	}
 	return val
 }
 
+// seedTimeLayouts are the layouts seed values of date and time fields are
+// parsed with, including the one of time.Time.String.
+var seedTimeLayouts = []string{
+	time.RFC3339Nano, "2006-01-02 15:04:05.999999999 -0700 MST", "2006-01-02T15:04:05",
+	time.DateTime, time.DateOnly, time.TimeOnly,
+}
+
+// seedTimeValue returns val, a time.Time or a string in one of
+// seedTimeLayouts, bound for a field of dataType on dialect: SQLite stores
+// dates as text, so it gets the canonical text of the type, while the
+// other drivers bind time.Time. Values that do not parse, such as SQL
+// functions, are returned unchanged.
+func seedTimeValue(val any, dataType, dialect string) any {
+	t, ok := val.(time.Time)
+	if s, isString := val.(string); isString {
+		for _, layout := range seedTimeLayouts {
+			if parsed, err := time.Parse(layout, strings.TrimSpace(s)); err == nil {
+				t, ok = parsed, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return val
+	}
+	switch {
+	case dataType == "time":
+		return t.Format(time.TimeOnly)
+	case dialect != DialectSQLite:
+		return t
+	case dataType == "date":
+		return t.Format(time.DateOnly)
+	}
+	return t.Format(time.DateTime)
+}
+
 func (s SeedDefinition) ToSQL(dialect string) ([]InsertQuery, error) {
+	return s.toSQL(dialect, nil)
+}
+
+// toSQL generates the rows of s, with lookup evaluating the sql() helper of
+// its expressions and providing the values of its unique fields already in
+// the table; without a lookup sql() fails.
+func (s SeedDefinition) toSQL(dialect string, lookup *seedLookup) ([]InsertQuery, error) {
 	// Check required fields for SeedDefinition
 	if err := requireFields(s.Name, s.Table); err != nil {
 		return nil, fmt.Errorf("SeedDefinition.ToSQL: %w", err)
 	}
-	mutate := func(val string) string {
+	mutate := func(val string) any {
 		if strings.HasPrefix(val, "fake_") {
 			fn, ok := lookupSeedFunction(val)
 			if ok {
@@ -79,6 +139,8 @@ func (s SeedDefinition) ToSQL(dialect string) ([]InsertQuery, error) {
 					switch rs := rs.(type) {
 					case string:
 						return rs
+					case time.Time:
+						return rs
 					default:
 						return fmt.Sprintf("%v", rs)
 					}
@@ -87,7 +149,10 @@ func (s SeedDefinition) ToSQL(dialect string) ([]InsertQuery, error) {
 		}
 		return val
 	}
-	dial := GetDialect(dialect)
+	dial, err := GetDialect(dialect)
+	if err != nil {
+		return nil, err
+	}
 	exprMap := make(map[string]*vm.Program)
 	findDeps := func(exprStr string) []string {
 		var deps []string
@@ -104,15 +169,33 @@ func (s SeedDefinition) ToSQL(dialect string) ([]InsertQuery, error) {
 		}
 		return deps
 	}
-	var queries []InsertQuery
-	uniqueSet := make(map[string]map[any]struct{})
-	for _, field := range s.Fields {
-		if field.Unique {
-			if uniqueSet[field.Name] == nil {
-				uniqueSet[field.Name] = make(map[any]struct{})
+	if lookup == nil {
+		lookup = newLocalSeedLookup(dialect)
+	}
+	profile := cmp.Or(s.Profile, s.Table)
+	// generate returns a new value of a field that is not an expression.
+	generate := func(field FieldDefinition) (any, error) {
+		val := fmt.Sprintf("%v", field.Value)
+		if column, ok := profileField(val, field.Name); ok {
+			p, err := lookup.profile(profile, column)
+			if err != nil {
+				return nil, fmt.Errorf("field '%s': %w", field.Name, err)
+			}
+			return p.generate(), nil
+		}
+		if ref, ok := strings.CutPrefix(val, "ref:"); ok {
+			table, column, ok := strings.Cut(strings.TrimSpace(ref), ".")
+			if !ok || table == "" || column == "" {
+				return nil, fmt.Errorf("field '%s': %q is not ref:<table>.<column>", field.Name, val)
 			}
+			return lookup.reference(table, column)
 		}
+		if field.Random {
+			return getRandomValue(val), nil
+		}
+		return mutate(val), nil
 	}
+	var queries []InsertQuery
 	for i := 0; i < s.Rows; i++ {
 		var cols []string
 		valMap := make(map[string]any)
@@ -123,44 +206,45 @@ func (s SeedDefinition) ToSQL(dialect string) ([]InsertQuery, error) {
 			if err := requireFields(field.Name); err != nil {
 				return nil, fmt.Errorf("SeedDefinition.ToSQL (field): %w", err)
 			}
+			if field.Value == nil {
+				rowValues[field.Name] = nil
+				continue
+			}
 			val := fmt.Sprintf("%v", field.Value)
 			if strings.HasPrefix(val, "expr:") {
 				exprFields[field.Name] = &s.Fields[idx]
 				continue
 			}
-			var evaluated string
-			if field.Random {
-				evaluated = getRandomValue(val)
-			} else {
-				evaluated = mutate(val)
+			evaluated, err := generate(field)
+			if err != nil {
+				return nil, err
 			}
-			rowValues[field.Name] = convertSeedValue(evaluated, field.DataType)
+			rowValues[field.Name] = convertSeedValue(evaluated, field.DataType, dialect)
 		}
 		// Improved unique constraint enforcement
 		for _, field := range s.Fields {
-			if field.Unique {
+			if field.Unique && rowValues[field.Name] != nil {
 				val := rowValues[field.Name]
 				maxAttempts := 100
 				attempts := 0
-				origVal := fmt.Sprintf("%v", field.Value)
 				for {
-					if _, exists := uniqueSet[field.Name][val]; !exists {
+					claimed, err := lookup.claim(s.Table, field.Name, val)
+					if err != nil {
+						return nil, err
+					}
+					if claimed {
 						break
 					}
 					attempts++
 					if attempts >= maxAttempts {
 						return nil, fmt.Errorf("could not generate unique value for field '%s' after %d attempts", field.Name, maxAttempts)
 					}
-					// Regenerate value using mutate or getRandomValue as appropriate
-					var newVal string
-					if field.Random {
-						newVal = getRandomValue(origVal)
-					} else {
-						newVal = mutate(origVal)
+					newVal, err := generate(field)
+					if err != nil {
+						return nil, err
 					}
-					val = convertSeedValue(newVal, field.DataType)
+					val = convertSeedValue(newVal, field.DataType, dialect)
 				}
-				uniqueSet[field.Name][val] = struct{}{}
 				rowValues[field.Name] = val
 			}
 		}
@@ -194,7 +278,7 @@ func (s SeedDefinition) ToSQL(dialect string) ([]InsertQuery, error) {
 				program, ok := exprMap[exprStr]
 				if !ok {
 					var err error
-					program, err = expr.Compile(exprStr, expr.Env(ctx))
+					program, err = expr.Compile(exprStr, expr.Env(ctx), expr.Function("sql", lookup.call))
 					if err != nil {
 						return nil, fmt.Errorf("expr compile error for field '%s': %w", field.Name, err)
 					}
@@ -204,7 +288,7 @@ func (s SeedDefinition) ToSQL(dialect string) ([]InsertQuery, error) {
 				if err != nil {
 					return nil, fmt.Errorf("expr eval error for field '%s': %w", field.Name, err)
 				}
-				rowValues[name] = convertSeedValue(result, field.DataType)
+				rowValues[name] = convertSeedValue(result, field.DataType, dialect)
 				delete(exprFields, name)
 				progress = true
 			}
@@ -230,6 +314,15 @@ func (s SeedDefinition) ToSQL(dialect string) ([]InsertQuery, error) {
 	return queries, nil
 }
 
+// uniqueKey returns the key of a unique field value, the same for a
+// generated value and the value read back from the database.
+func uniqueKey(v any) string {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
+
 func colsToArgs(cols []string, valMap map[string]any) []any {
 	args := make([]any, len(cols))
 	for i, col := range cols {
@@ -0,0 +1,171 @@
+package migrate
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// EncryptedSeedExt marks an encrypted seed file: "users.csv.enc" is the
+// encrypted form of "users.csv" and seeds the same table.
+const EncryptedSeedExt = ".enc"
+
+// DefaultSeedKey is the secret reference used for the seed encryption key
+// when none is configured.
+const DefaultSeedKey = "env:MIGRATE_SEED_KEY"
+
+// encryptedSeedMagic starts every encrypted seed file, followed by the
+// AES-GCM nonce and the sealed contents.
+var encryptedSeedMagic = []byte("migrate-seed-aesgcm-v1\n")
+
+// WithSeedEncryptionKey sets the secret reference, such as "env:SEED_KEY"
+// or "file:/run/secrets/seed-key", that holds the key for encrypted seed
+// files. The key is resolved only when an encrypted file is read.
+func WithSeedEncryptionKey(ref string) ManagerOption {
+	return func(m *Manager) {
+		m.seedKey = ref
+	}
+}
+
+// isEncryptedSeed reports whether path names an encrypted seed file.
+func isEncryptedSeed(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), EncryptedSeedExt)
+}
+
+// seedFileExt returns the lower-case extension of the seed file at path,
+// looking through the EncryptedSeedExt suffix.
+func seedFileExt(path string) string {
+	if isEncryptedSeed(path) {
+		path = path[:len(path)-len(EncryptedSeedExt)]
+	}
+	return strings.ToLower(filepath.Ext(path))
+}
+
+// GenerateSeedKey returns a new random key for encrypted seed files,
+// base64 encoded.
+func GenerateSeedKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// ResolveSeedKey resolves the secret reference ref to an AES key. The secret
+// must be 16, 24 or 32 bytes encoded as hex or base64.
+func ResolveSeedKey(ref string) ([]byte, error) {
+	if ref == "" {
+		ref = DefaultSeedKey
+	}
+	secret, err := ResolveSecret(ref)
+	if err != nil {
+		return nil, fmt.Errorf("seed encryption key: %w", err)
+	}
+	secret = strings.TrimSpace(secret)
+	key, err := hex.DecodeString(secret)
+	if err != nil {
+		if key, err = base64.StdEncoding.DecodeString(secret); err != nil {
+			return nil, fmt.Errorf("seed encryption key from %s is neither hex nor base64", ref)
+		}
+	}
+	switch len(key) {
+	case 16, 24, 32:
+		return key, nil
+	}
+	return nil, fmt.Errorf("seed encryption key from %s is %d bytes; want 16, 24 or 32", ref, len(key))
+}
+
+// EncryptSeed seals plaintext with AES-GCM under key.
+func EncryptSeed(plaintext, key []byte) ([]byte, error) {
+	gcm, err := newSeedCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := append([]byte(nil), encryptedSeedMagic...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, plaintext, encryptedSeedMagic), nil
+}
+
+// DecryptSeed opens data written by EncryptSeed.
+func DecryptSeed(data, key []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, encryptedSeedMagic) {
+		return nil, errors.New("not an encrypted seed file")
+	}
+	gcm, err := newSeedCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	data = data[len(encryptedSeedMagic):]
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("encrypted seed file is truncated")
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], encryptedSeedMagic)
+	if err != nil {
+		return nil, errors.New("failed to decrypt seed file: wrong key or corrupted file")
+	}
+	return plaintext, nil
+}
+
+func newSeedCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid seed encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// readSeedFile reads the seed file at path, decrypting it in memory when it
+// is encrypted. Plaintext is never written to disk.
+func (d *Manager) readSeedFile(path string) ([]byte, error) {
+	data, err := d.readFile(path)
+	if err != nil || !isEncryptedSeed(path) {
+		return data, err
+	}
+	key, err := ResolveSeedKey(d.seedKey)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := DecryptSeed(data, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return plaintext, nil
+}
+
+// decryptedSeedFile serves the decrypted contents of an encrypted seed file.
+// Stat reports the encrypted file, so streaming checkpoints notice when it
+// is replaced.
+type decryptedSeedFile struct {
+	*bytes.Reader
+	info fs.FileInfo
+}
+
+func (f *decryptedSeedFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+
+func (f *decryptedSeedFile) Close() error { return nil }
+
+// openDecryptedSeedFile decrypts the seed file f opened at path.
+func (d *Manager) openDecryptedSeedFile(path string, f fs.File) (fs.File, error) {
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := d.readSeedFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptedSeedFile{Reader: bytes.NewReader(plaintext), info: info}, nil
+}
@@ -0,0 +1,71 @@
+package migrate
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SeedFilter narrows a seed run to some tables and overrides the row counts
+// declared by their seeds, for one-off loads.
+type SeedFilter struct {
+	// Tables lists the tables to seed, without the identifier prefix; empty
+	// means every table. Raw .sql seeds are skipped when it is set, as their
+	// tables are not known.
+	Tables []string
+	// Rows replaces the rows of every selected Seed block when positive.
+	Rows int
+}
+
+// WithSeedFilter restricts seed runs to the tables of filter and overrides
+// their row counts.
+func WithSeedFilter(filter SeedFilter) ManagerOption {
+	return func(m *Manager) {
+		m.seedFilter = filter
+	}
+}
+
+// seedSelected reports whether the seed filter keeps the seeds of table.
+func (d *Manager) seedSelected(table string) bool {
+	if len(d.seedFilter.Tables) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(d.seedFilter.Tables, func(name string) bool { return d.isSeedTable(name, table) })
+}
+
+// isSeedTable reports whether name, given with or without the identifier
+// prefix, names table.
+func (d *Manager) isSeedTable(name, table string) bool {
+	return strings.EqualFold(name, table) || strings.EqualFold(name, strings.TrimPrefix(table, d.identifierPrefix))
+}
+
+// filterSeeds returns the seeds of the tables of the seed filter, with its
+// row count applied.
+func (d *Manager) filterSeeds(seeds []SeedDefinition) []SeedDefinition {
+	var kept []SeedDefinition
+	for _, seed := range seeds {
+		if !d.seedSelected(seed.Table) {
+			continue
+		}
+		if d.seedFilter.Rows > 0 {
+			seed.Rows = d.seedFilter.Rows
+		}
+		kept = append(kept, seed)
+	}
+	return kept
+}
+
+// unseededTables returns an error naming the tables of the seed filter that
+// no seed file targeted.
+func (d *Manager) unseededTables(seeded []string) error {
+	var missing []string
+	for _, name := range d.seedFilter.Tables {
+		if !slices.ContainsFunc(seeded, func(table string) bool { return d.isSeedTable(name, table) }) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("no seed targets table %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// seedLookup evaluates the sql("SELECT ...") helper of seed expressions
+// against the database, caching the result of each query for the run, and
+// reads the values unique seed fields must not repeat.
+type seedLookup struct {
+	dbDriver IDatabaseDriver
+	dialect  string
+	// truncate is set when seeded tables are emptied first, so their rows
+	// do not constrain unique fields.
+	truncate bool
+	// tables lists the tables the queries may read, see
+	// seed.lookup_tables; no tables disables lookups.
+	tables []string
+	cache  map[string]any
+	// seedDir holds the profiles read by profile values, cached in
+	// profiles by name.
+	seedDir  string
+	profiles map[string]*TableProfile
+	// references caches the values ref: fields pick from, by table and
+	// column.
+	references map[string][]any
+	// unique holds the values of unique fields already in their table or
+	// generated during the run, by table and field, see claim.
+	unique map[string]map[string]struct{}
+	// mu guards the caches, as seeds may be generated by several workers.
+	mu sync.Mutex
+}
+
+// WithSeedLookupTables sets the tables the sql("SELECT ...") helper of seed
+// expressions may read.
+func WithSeedLookupTables(tables ...string) ManagerOption {
+	return func(m *Manager) {
+		m.seedLookupTables = tables
+	}
+}
+
+// newSeedLookup returns the sql() helper and profiles of the seeds run by
+// the manager.
+func (d *Manager) newSeedLookup(truncate bool) *seedLookup {
+	lookup := newLocalSeedLookup(d.dialect)
+	lookup.dbDriver, lookup.truncate, lookup.tables, lookup.seedDir = d.dbDriver, truncate, d.seedLookupTables, d.seedDir
+	return lookup
+}
+
+// newLocalSeedLookup returns the lookup of seeds generated without a
+// database, such as by SeedDefinition.ToSQL.
+func newLocalSeedLookup(dialect string) *seedLookup {
+	return &seedLookup{
+		dialect:    dialect,
+		cache:      make(map[string]any),
+		profiles:   make(map[string]*TableProfile),
+		references: make(map[string][]any),
+		unique:     make(map[string]map[string]struct{}),
+	}
+}
+
+// claim reports whether v is a new value of the unique field of table and
+// records it, so no other row of the run generates it again.
+func (l *seedLookup) claim(table, field string, v any) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := table + "." + field
+	taken, ok := l.unique[key]
+	if !ok {
+		existing, err := l.existing(table, field)
+		if err != nil {
+			return false, err
+		}
+		taken = make(map[string]struct{}, len(existing))
+		for _, e := range existing {
+			taken[uniqueKey(e)] = struct{}{}
+		}
+		l.unique[key] = taken
+	}
+	if _, exists := taken[uniqueKey(v)]; exists {
+		return false, nil
+	}
+	taken[uniqueKey(v)] = struct{}{}
+	return true, nil
+}
+
+// reference returns a random value of column among the rows of table, for
+// ref:<table>.<column> fields. The values are read once per run, so rows
+// inserted by the same seed file are not referenced.
+func (l *seedLookup) reference(table, column string) (any, error) {
+	if l.dbDriver == nil {
+		return nil, fmt.Errorf("ref:%s.%s needs a database connection", table, column)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := table + "." + column
+	values, ok := l.references[key]
+	if !ok {
+		query := fmt.Sprintf("SELECT DISTINCT %s FROM %s WHERE %s IS NOT NULL",
+			quoteDialectIdentifier(l.dialect, column), quoteDialectIdentifier(l.dialect, table), quoteDialectIdentifier(l.dialect, column))
+		if err := l.dbDriver.Query(context.Background(), &values, query); err != nil {
+			return nil, fmt.Errorf("failed to read %s.%s to reference: %w", table, column, err)
+		}
+		l.references[key] = values
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("ref:%s.%s: table %s has no rows to reference", table, column, table)
+	}
+	value := values[rand.Intn(len(values))]
+	if b, ok := value.([]byte); ok {
+		value = string(b)
+	}
+	return value, nil
+}
+
+// existing returns the values of column in table, which a unique seed field
+// must not generate again; nothing when the table does not exist yet or is
+// truncated first.
+func (l *seedLookup) existing(table, column string) ([]any, error) {
+	if l.dbDriver == nil || l.truncate {
+		return nil, nil
+	}
+	dial, err := GetDialect(l.dialect)
+	if err != nil {
+		return nil, err
+	}
+	var exists bool
+	if err := l.dbDriver.QueryRow(context.Background(), &exists, dial.TableExistsSQL(table)); err != nil {
+		return nil, fmt.Errorf("failed to check table %s: %w", table, err)
+	}
+	if !exists {
+		return nil, nil
+	}
+	var values []any
+	query := fmt.Sprintf("SELECT DISTINCT %s FROM %s WHERE %s IS NOT NULL",
+		quoteDialectIdentifier(l.dialect, column), quoteDialectIdentifier(l.dialect, table), quoteDialectIdentifier(l.dialect, column))
+	if err := l.dbDriver.Query(context.Background(), &values, query); err != nil {
+		return nil, fmt.Errorf("failed to read existing values of %s.%s: %w", table, column, err)
+	}
+	return values, nil
+}
+
+// call runs the query given to sql() and returns the first column of its
+// first row, or nil when it yields no row.
+func (l *seedLookup) call(params ...any) (any, error) {
+	if len(params) != 1 {
+		return nil, fmt.Errorf("sql() takes one query, got %d arguments", len(params))
+	}
+	query, ok := params[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("sql() takes a query string, got %T", params[0])
+	}
+	if l.dbDriver == nil {
+		return nil, fmt.Errorf("sql() needs a database connection")
+	}
+	querier, ok := l.dbDriver.(readOnlyQuerier)
+	if !ok {
+		return nil, fmt.Errorf("sql() needs a database driver that runs read-only queries")
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if value, ok := l.cache[query]; ok {
+		return value, nil
+	}
+	if err := checkLookupSQL(query, l.tables); err != nil {
+		return nil, err
+	}
+	var value any
+	if err := querier.QueryRowReadOnly(context.Background(), &value, query); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("sql(%q): %w", query, err)
+	}
+	if b, ok := value.([]byte); ok {
+		value = string(b)
+	}
+	l.cache[query] = value
+	return value, nil
+}
+
+// readOnlyQuerier is implemented by drivers that can run a query in a
+// read-only scope, which sql() lookups require.
+type readOnlyQuerier interface {
+	QueryRowReadOnly(ctx context.Context, dest any, query string, args ...any) error
+}
+
+var (
+	lookupLiterals = regexp.MustCompile(`'(?:[^']|'')*'`)
+	lookupTokens   = regexp.MustCompile(`[\w."` + "`" + `]+|[(),;]`)
+)
+
+// lookupClauseEnd are the keywords ending the table list of a FROM clause.
+var lookupClauseEnd = []string{
+	"WHERE", "GROUP", "ORDER", "HAVING", "LIMIT", "OFFSET", "UNION", "INTERSECT", "EXCEPT",
+	"ON", "USING", "WINDOW", "FETCH", "FOR", "JOIN", "INNER", "LEFT", "RIGHT", "FULL", "CROSS", "NATURAL",
+}
+
+// checkLookupSQL reports why query may not run as a seed lookup: every
+// table it reads, after FROM, JOIN or TABLE, must be listed in tables. A
+// schema qualified table must be listed with its schema. The lookup itself
+// runs read-only, which is what keeps it from changing data.
+func checkLookupSQL(query string, tables []string) error {
+	if len(tables) == 0 {
+		return fmt.Errorf("sql() is disabled: list the tables seeds may read in seed.lookup_tables")
+	}
+	tokens := lookupTokens.FindAllString(lookupLiterals.ReplaceAllString(query, "''"), -1)
+	// ctes are the names of the common table expressions of the query.
+	var ctes []string
+	inFrom, expectTable := false, false
+	for i, tok := range tokens {
+		upper := strings.ToUpper(tok)
+		switch {
+		case upper == "AS" && i > 0 && i+1 < len(tokens) && tokens[i+1] == "(":
+			ctes = append(ctes, unquoteLookupName(tokens[i-1]))
+		case upper == "FROM" || upper == "JOIN" || upper == "TABLE":
+			inFrom, expectTable = true, true
+		case !inFrom:
+		case tok == "(":
+			// A subquery, whose own FROM clause is checked.
+			inFrom, expectTable = false, false
+		case tok == ",":
+			expectTable = true
+		case tok == ")" || tok == ";" || slices.Contains(lookupClauseEnd, upper):
+			inFrom, expectTable = false, false
+		case expectTable:
+			expectTable = false
+			table := unquoteLookupName(tok)
+			if slices.Contains(ctes, table) {
+				continue
+			}
+			if !slices.ContainsFunc(tables, func(t string) bool { return strings.EqualFold(t, table) }) {
+				return fmt.Errorf("sql(%q): table %s is not in seed.lookup_tables", query, table)
+			}
+		}
+	}
+	return nil
+}
+
+// unquoteLookupName returns a possibly quoted and schema qualified
+// identifier without its quotes, keeping the schema.
+func unquoteLookupName(name string) string {
+	return strings.NewReplacer(`"`, "", "`", "").Replace(name)
+}
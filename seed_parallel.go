@@ -0,0 +1,203 @@
+package migrate
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithSeedJobs runs up to jobs seed files at once, keeping the files that
+// seed related tables in order, see seedDependencies.
+func WithSeedJobs(jobs int) ManagerOption {
+	return func(m *Manager) {
+		m.seedJobs = jobs
+	}
+}
+
+// seedFileTables describes what a seed file writes and reads, for ordering
+// the files of a parallel run.
+type seedFileTables struct {
+	// targets are the tables the file seeds.
+	targets []string
+	// refs are the tables its ref: fields read.
+	refs []string
+	// after is set when the file must wait for every earlier file, as its
+	// sql() lookups may read any table.
+	after bool
+	// barrier is set when the tables of the file are not known, as for raw
+	// SQL: it waits for every earlier file and every later file waits
+	// for it.
+	barrier bool
+}
+
+// seedTables returns the tables of seedFile. Files that cannot be read are
+// barriers, their error being reported when they run.
+func (d *Manager) seedTables(seedFile string, includeRaw bool) seedFileTables {
+	switch seedFileExt(seedFile) {
+	case ".csv":
+		table := d.identifierPrefix + csvSeedTable(seedFile)
+		if includeRaw && d.seedSelected(table) {
+			return seedFileTables{targets: []string{table}}
+		}
+	case ".sql":
+		return seedFileTables{barrier: includeRaw && len(d.seedFilter.Tables) == 0}
+	case ".bcl":
+		cached, err := d.readSeedsBCL(seedFile)
+		if err != nil {
+			return seedFileTables{barrier: true}
+		}
+		var tables seedFileTables
+		for _, seed := range d.filterSeeds(cached.seeds) {
+			tables.targets = append(tables.targets, seed.Table)
+			for _, field := range seed.Fields {
+				val := fmt.Sprintf("%v", field.Value)
+				if ref, ok := strings.CutPrefix(val, "ref:"); ok {
+					table, _, _ := strings.Cut(strings.TrimSpace(ref), ".")
+					tables.refs = append(tables.refs, table)
+				}
+				if strings.HasPrefix(val, "expr:") && strings.Contains(val, "sql(") {
+					tables.after = true
+				}
+			}
+		}
+		return tables
+	}
+	return seedFileTables{}
+}
+
+// seedDependencies returns, for each seed file, the earlier files it waits
+// for: files seeding the same tables, tables related to its tables by a
+// foreign key in either direction, or tables its ref: fields read, as well
+// as barriers. Files with no relation run in parallel.
+func (d *Manager) seedDependencies(files []seedFileTables) ([][]int, error) {
+	// related caches the tables linked to each table by foreign keys.
+	related := make(map[string][]string)
+	relatedTo := func(table string) ([]string, error) {
+		if tables, ok := related[table]; ok {
+			return tables, nil
+		}
+		objects, err := d.DependentObjects(table)
+		if err != nil {
+			return nil, err
+		}
+		tables := []string{table}
+		for _, o := range objects {
+			if o.Kind == "foreign_key" {
+				tables = append(tables, o.Table)
+			}
+		}
+		related[table] = tables
+		return tables, nil
+	}
+	// links reports whether the tables of a and b are linked, looking up
+	// the foreign keys of both sides.
+	links := func(a, b seedFileTables) (bool, error) {
+		for _, x := range a.targets {
+			for _, y := range b.targets {
+				xs, err := relatedTo(x)
+				if err != nil {
+					return false, err
+				}
+				ys, err := relatedTo(y)
+				if err != nil {
+					return false, err
+				}
+				if slices.Contains(xs, y) || slices.Contains(ys, x) {
+					return true, nil
+				}
+			}
+		}
+		reads := func(reader, writer seedFileTables) bool {
+			return slices.ContainsFunc(reader.refs, func(ref string) bool {
+				return slices.ContainsFunc(writer.targets, func(target string) bool { return d.isSeedTable(ref, target) })
+			})
+		}
+		return reads(a, b) || reads(b, a), nil
+	}
+	deps := make([][]int, len(files))
+	for i, file := range files {
+		for j := range i {
+			linked := file.after || file.barrier || files[j].barrier
+			if !linked {
+				var err error
+				if linked, err = links(files[j], file); err != nil {
+					return nil, err
+				}
+			}
+			if linked {
+				deps[i] = append(deps[i], j)
+			}
+		}
+	}
+	return deps, nil
+}
+
+// runSeedsParallel runs seedFiles on up to d.seedJobs workers, each file
+// starting once the earlier files it depends on are done, see
+// seedDependencies, and records them in summary. It returns the tables
+// targeted and the error of the first failed file; files not started by
+// then are skipped, unless the run keeps going.
+func (d *Manager) runSeedsParallel(truncate, includeRaw bool, lookup *seedLookup, seedFiles []string, summary *SeedSummary) ([]string, error) {
+	files := make([]seedFileTables, len(seedFiles))
+	for i, seedFile := range seedFiles {
+		files[i] = d.seedTables(seedFile, includeRaw)
+	}
+	deps, err := d.seedDependencies(files)
+	if err != nil {
+		return nil, fmt.Errorf("failed to order seed files: %w", err)
+	}
+	logger.Info().Msgf("Running %d seed file(s) on up to %d workers", len(seedFiles), d.seedJobs)
+	done := make([]chan struct{}, len(seedFiles))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	workers := make(chan struct{}, d.seedJobs)
+	var (
+		mu sync.Mutex
+		// sqliteMu runs one file at a time on SQLite, which allows a single
+		// writer and fails reads while another connection commits.
+		sqliteMu sync.Mutex
+		seeded   = make([][]string, len(seedFiles))
+		firstErr error
+		failed   = -1
+		wg       sync.WaitGroup
+	)
+	for i, seedFile := range seedFiles {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[i])
+			for _, j := range deps[i] {
+				<-done[j]
+			}
+			workers <- struct{}{}
+			defer func() { <-workers }()
+			mu.Lock()
+			stopped := failed >= 0
+			mu.Unlock()
+			if stopped {
+				return
+			}
+			if d.dialect == DialectSQLite {
+				sqliteMu.Lock()
+				defer sqliteMu.Unlock()
+			}
+			started := time.Now()
+			tables, err := d.runSeedFile(seedFile, truncate, includeRaw, lookup)
+			summary.finish(seedFile, started, err)
+			mu.Lock()
+			defer mu.Unlock()
+			seeded[i] = tables
+			if err != nil && !d.seedKeepGoing && (failed < 0 || i < failed) {
+				firstErr, failed = err, i
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return slices.Concat(seeded...), nil
+}
@@ -0,0 +1,134 @@
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultSeedSampleLimit is the number of rows make:seed --from-db copies
+// when no limit is given.
+const defaultSeedSampleLimit = 100
+
+// SeedSample selects the rows of an existing table that CreateSeedFromTable
+// writes into a seed file.
+type SeedSample struct {
+	Table string
+	// Limit is the number of rows copied, the first ones the database
+	// returns; 0 means defaultSeedSampleLimit.
+	Limit int
+	// Mask lists the columns whose values are not copied, in addition to
+	// those matching the logging.redact patterns.
+	Mask []string
+}
+
+// CreateSeedFromTable writes a seed file holding one Seed block per sampled
+// row of sample.Table and returns its path. Masked text values become the
+// fake_<column> token when one is registered, or else a hash of the value so
+// equal values stay equal; masked numbers, booleans and dates become zero.
+func (d *Manager) CreateSeedFromTable(sample SeedSample) (string, error) {
+	if d.dbDriver == nil {
+		return "", fmt.Errorf("no database driver configured")
+	}
+	if err := requireFields(sample.Table); err != nil {
+		return "", fmt.Errorf("seed sample: %w", err)
+	}
+	limit := sample.Limit
+	if limit <= 0 {
+		limit = defaultSeedSampleLimit
+	}
+	var rows []map[string]any
+	query := fmt.Sprintf("SELECT * FROM %s LIMIT %d", quoteDialectIdentifier(d.dialect, sample.Table), limit)
+	if err := d.dbDriver.Query(context.Background(), &rows, query); err != nil {
+		return "", fmt.Errorf("failed to sample %s: %w", sample.Table, err)
+	}
+	if len(rows) == 0 {
+		return "", fmt.Errorf("table %s has no rows to sample", sample.Table)
+	}
+	redactor := d.redactor
+	if redactor == nil {
+		redactor = NewRedactor(DefaultRedactPatterns...)
+	}
+	masked := func(column string) bool {
+		return redactor.Matches(column) || slices.ContainsFunc(sample.Mask, func(m string) bool { return strings.EqualFold(strings.TrimSpace(m), column) })
+	}
+	var columns []string
+	for column := range rows[0] {
+		columns = append(columns, column)
+	}
+	slices.Sort(columns)
+
+	table := strings.TrimPrefix(sample.Table, d.identifierPrefix)
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Sampled from %s on %s.\n", sample.Table, time.Now().Format(time.DateTime))
+	for i, row := range rows {
+		fmt.Fprintf(&b, "\nSeed %s {\n    table = %s\n", strconv.Quote(fmt.Sprintf("%s_%d", table, i+1)), strconv.Quote(table))
+		for _, column := range columns {
+			value := row[column]
+			if masked(column) {
+				value = maskSeedValue(column, value)
+			}
+			fmt.Fprintf(&b, "    Field %s {\n        value = %s\n", strconv.Quote(column), bclSeedValue(value))
+			if _, ok := value.(time.Time); ok {
+				b.WriteString("        data_type = \"datetime\"\n")
+			}
+			b.WriteString("    }\n")
+		}
+		b.WriteString("    rows = 1\n}\n")
+	}
+	filename := filepath.Join(d.seedDir, fmt.Sprintf("%d_%s.bcl", time.Now().Unix(), table))
+	if err := os.WriteFile(filename, []byte(b.String()), 0644); err != nil {
+		return "", fmt.Errorf("failed to create seed file: %w", err)
+	}
+	logger.Printf("Seed file created from %d row(s) of %s: %s", len(rows), sample.Table, filename)
+	return filename, nil
+}
+
+// maskSeedValue returns the value written instead of value for a masked
+// column, see CreateSeedFromTable.
+func maskSeedValue(column string, value any) any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string, []byte:
+		token := "fake_" + strings.ToLower(column)
+		if _, ok := lookupSeedFunction(token); ok {
+			return token
+		}
+		sum := sha256.Sum256([]byte(uniqueKey(v)))
+		return "masked_" + hex.EncodeToString(sum[:6])
+	case bool:
+		return false
+	case time.Time:
+		return time.Unix(0, 0).UTC()
+	}
+	return 0
+}
+
+// bclSeedValue renders value as a BCL literal.
+func bclSeedValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []byte:
+		return strconv.Quote(string(v))
+	case time.Time:
+		return strconv.Quote(v.Format(time.DateTime))
+	}
+	return strconv.Quote(fmt.Sprint(value))
+}
@@ -0,0 +1,524 @@
+package migrate
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oarkflow/json"
+)
+
+const (
+	// defaultSeedBatchSize is the number of rows (CSV) or statements (SQL)
+	// committed per transaction while streaming a seed file.
+	defaultSeedBatchSize = 1000
+	// streamSeedThreshold is the raw .sql seed size above which the file is
+	// streamed instead of being loaded into memory.
+	streamSeedThreshold = 32 << 20
+	// seedInsertParams bounds the bind parameters of one multi-row INSERT so
+	// every dialect stays below its placeholder limit.
+	seedInsertParams = 900
+	// seedProgressInterval is how often streaming throughput is reported.
+	seedProgressInterval = 5 * time.Second
+	// seedProgressFileName records the last committed offset of each streamed
+	// seed file so an interrupted run can resume.
+	seedProgressFileName = "seed_progress.json"
+)
+
+// SeedStreamOptions controls how large raw seed files are applied.
+type SeedStreamOptions struct {
+	// BatchSize is the number of rows or statements committed per transaction.
+	BatchSize int
+	// Resume continues each file from the offset recorded by a failed run.
+	Resume bool
+	// Always streams raw .sql seeds regardless of their size.
+	Always bool
+}
+
+// SeedProgress is the checkpoint stored for a streamed seed file.
+type SeedProgress struct {
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"mod_time"`
+	Offset    int64     `json:"offset"`
+	Rows      int64     `json:"rows"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+var seedProgressMu sync.Mutex
+
+func loadSeedProgress() (map[string]SeedProgress, error) {
+	progress := make(map[string]SeedProgress)
+	data, err := os.ReadFile(seedProgressFileName)
+	if errors.Is(err, os.ErrNotExist) {
+		return progress, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return progress, nil
+	}
+	if err := json.Unmarshal(data, &progress); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", seedProgressFileName, err)
+	}
+	return progress, nil
+}
+
+// saveSeedProgress stores p for path, or removes the entry when p is nil.
+func saveSeedProgress(path string, p *SeedProgress) error {
+	seedProgressMu.Lock()
+	defer seedProgressMu.Unlock()
+	progress, err := loadSeedProgress()
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		if _, ok := progress[path]; !ok {
+			return nil
+		}
+		delete(progress, path)
+	} else {
+		progress[path] = *p
+	}
+	if len(progress) == 0 {
+		if err := os.Remove(seedProgressFileName); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(progress, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(seedProgressFileName, data, 0644)
+}
+
+// seedStream tracks the position and throughput of one streamed seed file.
+type seedStream struct {
+	path      string
+	unit      string
+	info      fs.FileInfo
+	offset    int64
+	rows      int64
+	startRows int64
+	started   time.Time
+	reported  time.Time
+}
+
+func (d *Manager) openSeedFile(path string) (fs.File, error) {
+	var f fs.File
+	var err error
+	if d.assets != nil {
+		f, err = d.assets.Open(path)
+	} else {
+		f, err = os.Open(path)
+	}
+	if err != nil || !isEncryptedSeed(path) {
+		return f, err
+	}
+	return d.openDecryptedSeedFile(path, f)
+}
+
+// newSeedStream starts tracking f and, when resuming, picks up the last
+// committed offset. A checkpoint for a file that has since changed is
+// discarded.
+func (d *Manager) newSeedStream(path, unit string, f fs.File) (*seedStream, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	stream := &seedStream{path: path, unit: unit, info: info, started: now, reported: now}
+	if !d.seedStream.Resume {
+		return stream, nil
+	}
+	seedProgressMu.Lock()
+	progress, err := loadSeedProgress()
+	seedProgressMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if p, ok := progress[path]; ok {
+		if p.Size == info.Size() && p.ModTime.Equal(info.ModTime()) {
+			stream.offset = p.Offset
+			stream.rows = p.Rows
+			stream.startRows = p.Rows
+			logger.Info().Msgf("Resuming seed file %s at byte %d (%d %s already applied)", path, p.Offset, p.Rows, unit)
+		} else {
+			logger.Warn().Msgf("Seed file %s changed since the interrupted run; starting from the beginning", path)
+		}
+	}
+	return stream, nil
+}
+
+// seek positions r at the stream's offset, discarding input when r cannot seek.
+func (s *seedStream) seek(r io.Reader) error {
+	if s.offset == 0 {
+		return nil
+	}
+	if seeker, ok := r.(io.Seeker); ok {
+		_, err := seeker.Seek(s.offset, io.SeekStart)
+		return err
+	}
+	_, err := io.CopyN(io.Discard, r, s.offset)
+	return err
+}
+
+// commit records a committed batch ending at offset and reports throughput
+// at most every seedProgressInterval.
+func (s *seedStream) commit(offset, rows int64) error {
+	s.offset = offset
+	s.rows += rows
+	if err := saveSeedProgress(s.path, &SeedProgress{
+		Size:      s.info.Size(),
+		ModTime:   s.info.ModTime(),
+		Offset:    s.offset,
+		Rows:      s.rows,
+		UpdatedAt: time.Now(),
+	}); err != nil {
+		return fmt.Errorf("failed to record seed progress: %w", err)
+	}
+	if time.Since(s.reported) >= seedProgressInterval {
+		s.reported = time.Now()
+		s.report("Seeding")
+	}
+	return nil
+}
+
+func (s *seedStream) report(prefix string) {
+	elapsed := time.Since(s.started)
+	rate := float64(s.rows-s.startRows) / max(elapsed.Seconds(), 0.001)
+	pct := 100.0
+	if s.info.Size() > 0 {
+		pct = float64(s.offset) * 100 / float64(s.info.Size())
+	}
+	logger.Info().Msgf("%s %s: %d %s, %.1f%% of %d bytes, %.0f %s/s", prefix, s.path, s.rows, s.unit, pct, s.info.Size(), rate, s.unit)
+}
+
+// finish reports the final throughput and clears the file's checkpoint.
+func (s *seedStream) finish() error {
+	s.report("Seeded")
+	return saveSeedProgress(s.path, nil)
+}
+
+func (d *Manager) seedBatchSize() int {
+	if d.seedStream.BatchSize > 0 {
+		return d.seedStream.BatchSize
+	}
+	return defaultSeedBatchSize
+}
+
+// shouldStreamSQLSeed reports whether a raw .sql seed is streamed.
+func (d *Manager) shouldStreamSQLSeed(path string) bool {
+	if d.seedStream.Always || d.seedStream.Resume {
+		return true
+	}
+	var info fs.FileInfo
+	var err error
+	if d.assets != nil {
+		info, err = fs.Stat(d.assets, path)
+	} else {
+		info, err = os.Stat(path)
+	}
+	return err == nil && info.Size() > streamSeedThreshold
+}
+
+var csvSeedPrefix = regexp.MustCompile(`^\d+_`)
+
+// csvSeedTable derives the target table from a CSV seed file name:
+// "users.csv", "001_users.csv" and "users.csv.enc" all seed "users".
+func csvSeedTable(path string) string {
+	name := filepath.Base(path)
+	if isEncryptedSeed(name) {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	return csvSeedPrefix.ReplaceAllString(name, "")
+}
+
+// streamCSVSeed inserts the rows of a CSV file whose header names the columns.
+// Rows are committed every BatchSize rows so memory stays bounded.
+func (d *Manager) streamCSVSeed(path string, truncate bool) error {
+	f, err := d.openSeedFile(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	stream, err := d.newSeedStream(path, "rows", f)
+	if err != nil {
+		return err
+	}
+	table := d.identifierPrefix + csvSeedTable(path)
+	reader := csv.NewReader(f)
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	header = append([]string(nil), header...)
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	var base int64
+	if stream.offset > 0 {
+		if truncate {
+			logger.Warn().Msgf("Truncate flag ignored while resuming seed file: %s", path)
+		}
+		// The header reader has buffered ahead; continue from a fresh handle.
+		resumed, err := d.openSeedFile(path)
+		if err != nil {
+			return err
+		}
+		defer resumed.Close()
+		if err := stream.seek(resumed); err != nil {
+			return fmt.Errorf("failed to seek to offset %d: %w", stream.offset, err)
+		}
+		reader = csv.NewReader(resumed)
+		base = stream.offset
+	} else if truncate {
+		query := getTruncateSQL(d.dialect, table)
+		if query == "" {
+			return fmt.Errorf("unsupported dialect for truncation: %s", d.dialect)
+		}
+		if err := d.backupTruncatedTables(path, []string{table}); err != nil {
+			return err
+		}
+		logger.Info().Msgf("Truncating table: %s", table)
+		if err := d.dbDriver.ApplySQL([]string{query}); err != nil {
+			return fmt.Errorf("failed to truncate table %s: %w", table, err)
+		}
+	}
+	reader.FieldsPerRecord = len(header)
+	reader.ReuseRecord = true
+	offset := func() int64 { return base + reader.InputOffset() }
+
+	logger.Info().Msgf("Streaming CSV seed %s into table %s", path, table)
+	batchSize := d.seedBatchSize()
+	batch := make([][]any, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		queries, args := d.buildSeedInserts(table, header, batch)
+		if err := d.dbDriver.ApplySQL(queries, args); err != nil {
+			return fmt.Errorf("failed to insert rows ending at byte %d: %w", offset(), err)
+		}
+		if err := stream.commit(offset(), int64(len(batch))); err != nil {
+			return err
+		}
+		d.emitSeedRows(path, table, int64(len(batch)))
+		batch = batch[:0]
+		return nil
+	}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read CSV record: %w", err)
+		}
+		row := make([]any, len(record))
+		for i, v := range record {
+			row[i] = v
+		}
+		batch = append(batch, row)
+		if len(batch) >= batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	return stream.finish()
+}
+
+// buildSeedInserts renders rows as multi-row INSERT statements with named
+// parameters, keeping each statement under seedInsertParams parameters.
+func (d *Manager) buildSeedInserts(table string, columns []string, rows [][]any) ([]string, map[string]any) {
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = quoteDialectIdentifier(d.dialect, strings.TrimSpace(c))
+	}
+	prefix := fmt.Sprintf("INSERT INTO %s (%s) VALUES ", quoteDialectIdentifier(d.dialect, table), strings.Join(quoted, ", "))
+	perStatement := max(1, seedInsertParams/max(1, len(columns)))
+	args := make(map[string]any, len(rows)*len(columns))
+	var queries []string
+	for start := 0; start < len(rows); start += perStatement {
+		end := min(start+perStatement, len(rows))
+		var sb strings.Builder
+		sb.WriteString(prefix)
+		for r := start; r < end; r++ {
+			if r > start {
+				sb.WriteString(", ")
+			}
+			sb.WriteString("(")
+			for c := range columns {
+				if c > 0 {
+					sb.WriteString(", ")
+				}
+				name := fmt.Sprintf("r%d_c%d", r, c)
+				sb.WriteString(":" + name)
+				args[name] = rows[r][c]
+			}
+			sb.WriteString(")")
+		}
+		sb.WriteString(";")
+		queries = append(queries, sb.String())
+	}
+	return queries, args
+}
+
+// streamSQLSeed applies a raw SQL file statement by statement, committing
+// every BatchSize statements.
+func (d *Manager) streamSQLSeed(path string) error {
+	f, err := d.openSeedFile(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	stream, err := d.newSeedStream(path, "statements", f)
+	if err != nil {
+		return err
+	}
+	if err := stream.seek(f); err != nil {
+		return fmt.Errorf("failed to seek to offset %d: %w", stream.offset, err)
+	}
+	logger.Info().Msgf("Streaming raw seed file: %s", path)
+	scanner := newSQLStatementScanner(f, stream.offset, d.dialect)
+	batchSize := d.seedBatchSize()
+	var batch []string
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := d.dbDriver.ApplySQL(batch); err != nil {
+			return fmt.Errorf("failed to apply statements ending at byte %d: %w", scanner.offset, err)
+		}
+		if err := stream.commit(scanner.offset, int64(len(batch))); err != nil {
+			return err
+		}
+		d.emitSeedRows(path, "", int64(len(batch)))
+		batch = batch[:0]
+		return nil
+	}
+	for {
+		stmt, err := scanner.next()
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("failed to read seed SQL: %w", err)
+		}
+		if strings.TrimSpace(stmt) != "" {
+			batch = append(batch, stmt)
+		}
+		if len(batch) >= batchSize || (err == io.EOF && len(batch) > 0) {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	return stream.finish()
+}
+
+// sqlStatementScanner reads semicolon terminated statements from a stream,
+// ignoring semicolons inside quotes, comments and PostgreSQL dollar quotes.
+type sqlStatementScanner struct {
+	r      *bufio.Reader
+	offset int64
+	// backslashEscapes makes a backslash escape the next character inside
+	// '' and "" quotes, as in MySQL dumps ('It\'s').
+	backslashEscapes bool
+}
+
+func newSQLStatementScanner(r io.Reader, offset int64, dialect string) *sqlStatementScanner {
+	return &sqlStatementScanner{r: bufio.NewReaderSize(r, 64<<10), offset: offset, backslashEscapes: dialect == DialectMySQL}
+}
+
+// next returns the next statement including its terminating semicolon. At the
+// end of input it returns any trailing text together with io.EOF.
+func (s *sqlStatementScanner) next() (string, error) {
+	var sb strings.Builder
+	var quote byte
+	var dollarTag string
+	lineComment, blockComment, escaped := false, false, false
+	// opened is the length of the statement when the current comment or
+	// dollar quote was opened, so its closer cannot overlap the opener.
+	opened := 0
+	for {
+		b, err := s.r.ReadByte()
+		if err != nil {
+			return sb.String(), err
+		}
+		s.offset++
+		sb.WriteByte(b)
+		switch {
+		case lineComment:
+			lineComment = b != '\n'
+		case blockComment:
+			if b == '/' && sb.Len() >= opened+2 && strings.HasSuffix(sb.String(), "*/") {
+				blockComment = false
+			}
+		case dollarTag != "":
+			if b == '$' && sb.Len() >= opened+len(dollarTag) && strings.HasSuffix(sb.String(), dollarTag) {
+				dollarTag = ""
+			}
+		case escaped:
+			escaped = false
+		case quote != 0:
+			if b == '\\' && s.backslashEscapes && quote != '`' {
+				escaped = true
+			} else if b == quote {
+				quote = 0
+			}
+		case b == '\'' || b == '"' || b == '`':
+			quote = b
+		case b == '-' && strings.HasSuffix(sb.String(), "--"):
+			lineComment = true
+		case b == '*' && strings.HasSuffix(sb.String(), "/*"):
+			blockComment = true
+			opened = sb.Len()
+		case b == '$':
+			tag, err := s.dollarTag()
+			if err != nil && err != io.EOF {
+				return sb.String(), err
+			}
+			if tag != "" {
+				sb.WriteString(tag)
+				dollarTag = "$" + tag
+				opened = sb.Len()
+			}
+		case b == ';':
+			return sb.String(), nil
+		}
+	}
+}
+
+// dollarTag consumes the rest of a dollar quote opener ("tag$" or "$") that
+// follows an already read '$', returning it or "" when none follows.
+func (s *sqlStatementScanner) dollarTag() (string, error) {
+	for n := 1; ; n++ {
+		peek, err := s.r.Peek(n)
+		if len(peek) < n {
+			return "", err
+		}
+		c := peek[n-1]
+		if c == '$' {
+			s.r.Discard(n)
+			s.offset += int64(n)
+			return string(peek), nil
+		}
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || (n > 1 && c >= '0' && c <= '9')) {
+			return "", nil
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package migrate
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSQLStatementScannerHonoursQuotesAndComments(t *testing.T) {
+	scan := func(src, dialect string) []string {
+		t.Helper()
+		scanner := newSQLStatementScanner(strings.NewReader(src), 0, dialect)
+		var stmts []string
+		for {
+			stmt, err := scanner.next()
+			if strings.TrimSpace(stmt) != "" {
+				stmts = append(stmts, strings.TrimSpace(stmt))
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("next: %v", err)
+			}
+		}
+		if scanner.offset != int64(len(src)) {
+			t.Fatalf("offset = %d, want %d", scanner.offset, len(src))
+		}
+		return stmts
+	}
+	src := "INSERT INTO t VALUES ('a;b');\n" +
+		"-- comment; still comment\n" +
+		"/* block; */ INSERT INTO t VALUES (\"c;d\");\n" +
+		"CREATE FUNCTION f() RETURNS int AS $body$ SELECT 1; $body$ LANGUAGE sql;\n" +
+		"SELECT $$;$$;\n" +
+		"trailing"
+	want := []string{
+		"INSERT INTO t VALUES ('a;b');",
+		"-- comment; still comment\n/* block; */ INSERT INTO t VALUES (\"c;d\");",
+		"CREATE FUNCTION f() RETURNS int AS $body$ SELECT 1; $body$ LANGUAGE sql;",
+		"SELECT $$;$$;",
+		"trailing",
+	}
+	if stmts := scan(src, DialectPostgres); !slices.Equal(stmts, want) {
+		t.Fatalf("statements = %q, want %q", stmts, want)
+	}
+
+	// mysqldump escapes quotes with a backslash; other dialects do not.
+	src = `INSERT INTO t VALUES ('It\'s; ok', "say \"hi\"; bye", 'C:\\');` + "\n" + `INSERT INTO t VALUES ('next');`
+	want = []string{
+		`INSERT INTO t VALUES ('It\'s; ok', "say \"hi\"; bye", 'C:\\');`,
+		`INSERT INTO t VALUES ('next');`,
+	}
+	if stmts := scan(src, DialectMySQL); !slices.Equal(stmts, want) {
+		t.Fatalf("MySQL statements = %q, want %q", stmts, want)
+	}
+	if stmts := scan(`SELECT 'C:\'; SELECT 1;`, DialectPostgres); len(stmts) != 2 {
+		t.Fatalf("PostgreSQL statements = %q, want a backslash to end no quote", stmts)
+	}
+}
+
+func TestStreamCSVSeedResumesAfterFailure(t *testing.T) {
+	t.Chdir(t.TempDir())
+	manager := newSQLiteWorkflowManager(t)
+	manager.seedStream = SeedStreamOptions{BatchSize: 2}
+	if err := manager.dbDriver.ApplySQL([]string{`CREATE TABLE people (id INTEGER PRIMARY KEY, name TEXT NOT NULL);`}); err != nil {
+		t.Fatalf("create people: %v", err)
+	}
+	// Row 3 already exists, so the second batch fails after the first commits.
+	if err := manager.dbDriver.ApplySQL([]string{`INSERT INTO people (id, name) VALUES (3, 'existing');`}); err != nil {
+		t.Fatalf("insert conflicting row: %v", err)
+	}
+	seedFile := filepath.Join(manager.SeedDir(), "001_people.csv")
+	writeTestFile(t, seedFile, "id,name\n1,\"Ada, Countess\"\n2,Grace\n3,Linus\n4,Ken\n5,Barbara\n")
+
+	if err := manager.RunSeeds(false, true, seedFile); err == nil {
+		t.Fatal("RunSeeds succeeded despite conflicting row")
+	}
+	progress, err := loadSeedProgress()
+	if err != nil {
+		t.Fatalf("loadSeedProgress: %v", err)
+	}
+	if p, ok := progress[seedFile]; !ok || p.Rows != 2 {
+		t.Fatalf("progress = %+v, want 2 committed rows", progress)
+	}
+
+	if err := manager.dbDriver.ApplySQL([]string{`DELETE FROM people WHERE id = 3;`}); err != nil {
+		t.Fatalf("delete conflicting row: %v", err)
+	}
+	manager.seedStream.Resume = true
+	if err := manager.RunSeeds(false, true, seedFile); err != nil {
+		t.Fatalf("RunSeeds resume: %v", err)
+	}
+	var count int
+	if err := manager.dbDriver.DB().Select(&count, `SELECT COUNT(*) FROM people`); err != nil {
+		t.Fatalf("count people: %v", err)
+	}
+	if count != 5 {
+		t.Fatalf("people = %d, want 5", count)
+	}
+	var name string
+	if err := manager.dbDriver.DB().Select(&name, `SELECT name FROM people WHERE id = 1`); err != nil {
+		t.Fatalf("select name: %v", err)
+	}
+	if name != "Ada, Countess" {
+		t.Fatalf("name = %q, want %q", name, "Ada, Countess")
+	}
+	if _, err := os.Stat(seedProgressFileName); !os.IsNotExist(err) {
+		t.Fatalf("progress file not removed after completion: %v", err)
+	}
+}
+
+func TestStreamSQLSeedCommitsInBatches(t *testing.T) {
+	t.Chdir(t.TempDir())
+	manager := newSQLiteWorkflowManager(t)
+	manager.seedStream = SeedStreamOptions{BatchSize: 3, Always: true}
+	if err := manager.dbDriver.ApplySQL([]string{`CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT);`}); err != nil {
+		t.Fatalf("create notes: %v", err)
+	}
+	var sb strings.Builder
+	for i := 1; i <= 10; i++ {
+		fmt.Fprintf(&sb, "INSERT INTO notes (id, body) VALUES (%d, 'semi;colon');\n", i)
+	}
+	seedFile := filepath.Join(manager.SeedDir(), "notes.sql")
+	writeTestFile(t, seedFile, sb.String())
+	if err := manager.RunSeeds(false, true, seedFile); err != nil {
+		t.Fatalf("RunSeeds: %v", err)
+	}
+	var count int
+	if err := manager.dbDriver.DB().Select(&count, `SELECT COUNT(*) FROM notes WHERE body = 'semi;colon'`); err != nil {
+		t.Fatalf("count notes: %v", err)
+	}
+	if count != 10 {
+		t.Fatalf("notes = %d, want 10", count)
+	}
+}
+
+func TestRunSeedsDecryptsEncryptedSeedFiles(t *testing.T) {
+	t.Chdir(t.TempDir())
+	manager := newSQLiteWorkflowManager(t)
+	secret, err := GenerateSeedKey()
+	if err != nil {
+		t.Fatalf("GenerateSeedKey: %v", err)
+	}
+	t.Setenv("TEST_SEED_KEY", secret)
+	WithSeedEncryptionKey("env:TEST_SEED_KEY")(manager)
+	key, err := ResolveSeedKey("env:TEST_SEED_KEY")
+	if err != nil {
+		t.Fatalf("ResolveSeedKey: %v", err)
+	}
+	if err := manager.dbDriver.ApplySQL([]string{
+		`CREATE TABLE countries (code TEXT PRIMARY KEY, name TEXT NOT NULL);`,
+		`CREATE TABLE labels (id TEXT PRIMARY KEY);`,
+	}); err != nil {
+		t.Fatalf("create tables: %v", err)
+	}
+	encrypt := func(name, plaintext string) {
+		data, err := EncryptSeed([]byte(plaintext), key)
+		if err != nil {
+			t.Fatalf("EncryptSeed: %v", err)
+		}
+		if strings.Contains(string(data), "Ada") {
+			t.Fatal("encrypted seed contains plaintext")
+		}
+		writeTestFile(t, filepath.Join(manager.SeedDir(), name), string(data))
+	}
+	encrypt("001_countries.csv.enc", "code,name\nAD,Andorra\nBE,Belgium\n")
+	encrypt("labels.bcl.enc", `
+Seed "labels" {
+  table = "labels"
+  Field "id" {
+    value = "Ada"
+  }
+  rows = 1
+}
+`)
+	files, err := manager.ListSeedFiles(true)
+	if err != nil {
+		t.Fatalf("ListSeedFiles: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("seed files = %q, want the two encrypted files", files)
+	}
+	if err := manager.RunSeeds(false, true, files...); err != nil {
+		t.Fatalf("RunSeeds: %v", err)
+	}
+	var count int
+	if err := manager.dbDriver.DB().Select(&count, `SELECT (SELECT COUNT(*) FROM countries) + (SELECT COUNT(*) FROM labels)`); err != nil {
+		t.Fatalf("count rows: %v", err)
+	}
+	if count != 3 {
+		t.Fatalf("seeded rows = %d, want 3", count)
+	}
+
+	other, _ := GenerateSeedKey()
+	t.Setenv("TEST_SEED_KEY", other)
+	if err := manager.RunSeeds(false, true, files[1]); err == nil || !strings.Contains(err.Error(), "wrong key") {
+		t.Fatalf("RunSeeds with wrong key error = %v", err)
+	}
+}
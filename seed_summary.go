@@ -0,0 +1,122 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// WithSeedKeepGoing makes seed runs go on with the other seed files when one
+// fails, as db:seed --keep-going does, and print a summary of every file.
+// Each file still rolls back on its own: atomic seed files and raw SQL seeds
+// run in one transaction, and streamed seeds keep the batches committed
+// before the failure.
+func WithSeedKeepGoing(keepGoing bool) ManagerOption {
+	return func(m *Manager) {
+		m.seedKeepGoing = keepGoing
+	}
+}
+
+// SeedSummary reports the outcome of each seed file of a run.
+type SeedSummary struct {
+	Files   []SeedFileResult
+	Total   time.Duration
+	started time.Time
+	mu      sync.Mutex
+}
+
+// SeedFileResult is the outcome of one seed file; Err is nil when it
+// succeeded.
+type SeedFileResult struct {
+	File     string
+	Rows     int64
+	Duration time.Duration
+	Err      error
+}
+
+func newSeedSummary(files []string) *SeedSummary {
+	s := &SeedSummary{started: time.Now()}
+	for _, file := range files {
+		s.Files = append(s.Files, SeedFileResult{File: file})
+	}
+	return s
+}
+
+// result returns the result of file, nil when it is not part of the run.
+func (s *SeedSummary) result(file string) *SeedFileResult {
+	for i := range s.Files {
+		if s.Files[i].File == file {
+			return &s.Files[i]
+		}
+	}
+	return nil
+}
+
+// collectSeedSummary counts the rows each seed file commits into summary
+// until the returned function is called.
+func (d *Manager) collectSeedSummary(summary *SeedSummary) (stop func()) {
+	sinks := d.eventSinks
+	d.eventSinks = append(sinks[:len(sinks):len(sinks)], func(e Event) {
+		if e, ok := e.(*SeedRowBatch); ok {
+			summary.mu.Lock()
+			defer summary.mu.Unlock()
+			if r := summary.result(e.File); r != nil {
+				r.Rows += e.Rows
+			}
+		}
+	})
+	return func() {
+		d.eventSinks = sinks
+	}
+}
+
+// finish records the duration and error of file, which ran from started.
+func (s *SeedSummary) finish(file string, started time.Time, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r := s.result(file); r != nil {
+		r.Duration, r.Err = time.Since(started), err
+	}
+}
+
+// Err returns an error listing the failed seed files, or nil.
+func (s *SeedSummary) Err() error {
+	var errs []error
+	for _, f := range s.Files {
+		if f.Err != nil {
+			errs = append(errs, f.Err)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d seed file(s) failed: %w", len(errs), len(s.Files), errors.Join(errs...))
+}
+
+// write finishes the summary and prints it to w as a table.
+func (s *SeedSummary) write(w io.Writer) error {
+	s.Total = time.Since(s.started)
+	width := len("Seed file")
+	for _, f := range s.Files {
+		width = max(width, len(filepath.Base(f.File)))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%-*s  %-6s  %8s  %s\n", width, "Seed file", "Status", "Rows", "Duration")
+	failed := 0
+	for _, f := range s.Files {
+		status := "ok"
+		if f.Err != nil {
+			status = "failed"
+			failed++
+		}
+		fmt.Fprintf(w, "%-*s  %-6s  %8d  %s\n", width, filepath.Base(f.File), status, f.Rows, f.Duration.Round(time.Microsecond))
+		if f.Err != nil {
+			fmt.Fprintf(w, "%-*s  %v\n", width, "", f.Err)
+		}
+	}
+	_, err := fmt.Fprintf(w, "Ran %d seed file(s), %d failed, in %s\n", len(s.Files), failed, s.Total.Round(time.Millisecond))
+	return err
+}
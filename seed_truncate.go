@@ -0,0 +1,65 @@
+package migrate
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// truncateOrder returns tables ordered so that every table comes before the
+// tables it references through foreign keys, emptying referencing rows
+// first. Tables in a reference cycle keep their given order.
+func (d *Manager) truncateOrder(tables []string) ([]string, error) {
+	// referencedBy maps each table to the other tables of the list holding
+	// foreign keys to it.
+	referencedBy := make(map[string][]string, len(tables))
+	for _, table := range tables {
+		objects, err := d.DependentObjects(table)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range objects {
+			if o.Kind == "foreign_key" && o.Table != table && slices.Contains(tables, o.Table) {
+				referencedBy[table] = append(referencedBy[table], o.Table)
+			}
+		}
+	}
+	var order []string
+	remaining := slices.Clone(tables)
+	for len(remaining) > 0 {
+		next := 0
+		for i, table := range remaining {
+			if !slices.ContainsFunc(referencedBy[table], func(t string) bool { return slices.Contains(remaining, t) }) {
+				next = i
+				break
+			}
+		}
+		order = append(order, remaining[next])
+		remaining = slices.Delete(remaining, next, next+1)
+	}
+	return order, nil
+}
+
+// truncateStatements returns the statements emptying tables before they are
+// seeded, in the order of truncateOrder. MySQL refuses to truncate a table
+// referenced by any foreign key, so there foreign key checks are disabled
+// around them.
+func (d *Manager) truncateStatements(tables []string) ([]string, error) {
+	order, err := d.truncateOrder(tables)
+	if err != nil {
+		return nil, err
+	}
+	var queries []string
+	for _, table := range order {
+		query := getTruncateSQL(d.dialect, table)
+		if query == "" {
+			return nil, fmt.Errorf("unsupported dialect for truncation: %s", d.dialect)
+		}
+		queries = append(queries, query)
+	}
+	if d.dialect == DialectMySQL {
+		queries = append([]string{"SET FOREIGN_KEY_CHECKS = 0;"}, append(queries, "SET FOREIGN_KEY_CHECKS = 1;")...)
+	}
+	logger.Info().Msgf("Truncating tables: %s", strings.Join(order, ", "))
+	return queries, nil
+}
@@ -0,0 +1,285 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oarkflow/json"
+	"github.com/oarkflow/squealx"
+)
+
+// Snapshotter saves the whole development database to a file and restores
+// it, see db:snapshot.
+type Snapshotter interface {
+	SaveSnapshot(ctx context.Context, path string) error
+	RestoreSnapshot(ctx context.Context, path string) error
+}
+
+// CommandSnapshotter snapshots PostgreSQL with pg_dump and pg_restore, and
+// MySQL with mysqldump and mysql.
+type CommandSnapshotter struct {
+	Database DatabaseConfig
+	// DumpCommand and RestoreCommand override the tools; by default they
+	// are looked up in PATH.
+	DumpCommand    string
+	RestoreCommand string
+}
+
+// NewCommandSnapshotter returns a CommandSnapshotter for the database
+// described by db.
+func NewCommandSnapshotter(db DatabaseConfig, config SnapshotConfig) (*CommandSnapshotter, error) {
+	driver, err := NormalizeDriver(db.Driver)
+	if err != nil {
+		return nil, err
+	}
+	if driver != DialectPostgres && driver != DialectMySQL {
+		return nil, fmt.Errorf("command snapshots are not supported for %s", driver)
+	}
+	db.Driver = driver
+	return &CommandSnapshotter{Database: db, DumpCommand: config.DumpCommand, RestoreCommand: config.RestoreCommand}, nil
+}
+
+// SaveSnapshot dumps the database to path: a custom format archive on
+// PostgreSQL, and on MySQL a script dropping and recreating the database.
+func (c *CommandSnapshotter) SaveSnapshot(ctx context.Context, path string) error {
+	db := c.Database
+	switch db.Driver {
+	case DialectPostgres:
+		return runDumpTool(ctx, db, "pg_dump", c.DumpCommand, nil,
+			postgresToolArgs(db, "--format=custom", "--file="+path, "--dbname="+db.Database)...)
+	case DialectMySQL:
+		return runDumpTool(ctx, db, "mysqldump", c.DumpCommand, nil, mysqlToolArgs(db, "--single-transaction", "--routines",
+			"--triggers", "--add-drop-database", "--result-file="+path, "--databases", db.Database)...)
+	}
+	return fmt.Errorf("command snapshots are not supported for %s", db.Driver)
+}
+
+// RestoreSnapshot loads the dump at path. On PostgreSQL the objects of the
+// dump are dropped and recreated in one transaction; objects created after
+// the snapshot are kept.
+func (c *CommandSnapshotter) RestoreSnapshot(ctx context.Context, path string) error {
+	db := c.Database
+	switch db.Driver {
+	case DialectPostgres:
+		return runDumpTool(ctx, db, "pg_restore", c.RestoreCommand, nil, postgresToolArgs(db, "--clean", "--if-exists",
+			"--no-owner", "--single-transaction", "--dbname="+db.Database, path)...)
+	case DialectMySQL:
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open snapshot: %w", err)
+		}
+		defer f.Close()
+		return runDumpTool(ctx, db, "mysql", c.RestoreCommand, f, mysqlToolArgs(db)...)
+	}
+	return fmt.Errorf("command snapshots are not supported for %s", db.Driver)
+}
+
+// SQLiteSnapshotter snapshots an SQLite database to a database file.
+type SQLiteSnapshotter struct {
+	Driver IDatabaseDriver
+}
+
+// SaveSnapshot writes a consistent copy of the database to path with
+// VACUUM INTO, which is safe while connections are open.
+func (s *SQLiteSnapshotter) SaveSnapshot(ctx context.Context, path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+	// VACUUM cannot run in the transaction of ApplySQL.
+	if _, err := s.Driver.DB().ExecContext(ctx, "VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("failed to copy database: %w", err)
+	}
+	return nil
+}
+
+// RestoreSnapshot replaces the tables, views, indexes and triggers of the
+// database with those of the snapshot at path, in one transaction. The file
+// is not copied over, as connections open on it would see a corrupt
+// database; the snapshot is attached and copied instead.
+func (s *SQLiteSnapshotter) RestoreSnapshot(ctx context.Context, path string) error {
+	conn, err := s.Driver.DB().Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	// ATTACH and the foreign_keys PRAGMA have no effect inside a transaction.
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS snapshot", path); err != nil {
+		return fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "DETACH DATABASE snapshot")
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), sqliteForeignKeysOn)
+	return conn.WithTxx(ctx, nil, func(tx *squealx.Tx) error {
+		var current, saved []sqliteTableObject
+		if err := tx.SelectContext(ctx, &current, `SELECT type, name, '' AS sql FROM main.sqlite_master
+			WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%' ORDER BY type = 'table'`); err != nil {
+			return fmt.Errorf("failed to list database objects: %w", err)
+		}
+		if err := tx.SelectContext(ctx, &saved, `SELECT type, name, sql FROM snapshot.sqlite_master
+			WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%' ORDER BY type <> 'table', rowid`); err != nil {
+			return fmt.Errorf("failed to list snapshot objects: %w", err)
+		}
+		exec := func(query string) error {
+			if _, err := tx.ExecContext(ctx, query); err != nil {
+				return fmt.Errorf("failed to execute query [%s]: %w", query, err)
+			}
+			return nil
+		}
+		// Dropping a table drops its indexes and triggers.
+		for _, o := range current {
+			if err := exec(fmt.Sprintf("DROP %s main.%s", strings.ToUpper(o.Kind), quoteDialectIdentifier(DialectSQLite, o.Name))); err != nil {
+				return err
+			}
+		}
+		// Tables are filled before their triggers exist.
+		for _, o := range saved {
+			if err := exec(o.SQL); err != nil {
+				return err
+			}
+			if o.Kind == "table" {
+				name := quoteDialectIdentifier(DialectSQLite, o.Name)
+				if err := exec(fmt.Sprintf("INSERT INTO main.%s SELECT * FROM snapshot.%s", name, name)); err != nil {
+					return err
+				}
+			}
+		}
+		var sequences int
+		if err := tx.GetContext(ctx, &sequences, `SELECT COUNT(*) FROM snapshot.sqlite_master WHERE name = 'sqlite_sequence'`); err != nil {
+			return err
+		}
+		if sequences > 0 {
+			if err := exec("DELETE FROM main.sqlite_sequence"); err != nil {
+				return err
+			}
+			return exec("INSERT INTO main.sqlite_sequence SELECT * FROM snapshot.sqlite_sequence")
+		}
+		return nil
+	})
+}
+
+// WithSnapshots stores the database snapshots of db:snapshot in dir, taken
+// by snapshotter; a nil snapshotter uses SQLiteSnapshotter on SQLite.
+func WithSnapshots(dir string, snapshotter Snapshotter) ManagerOption {
+	return func(m *Manager) {
+		m.snapshotDir = dir
+		m.snapshotter = snapshotter
+	}
+}
+
+// Snapshot describes a saved snapshot, kept next to it as <name>.json.
+type Snapshot struct {
+	Name      string    `json:"name"`
+	Dialect   string    `json:"dialect"`
+	File      string    `json:"file"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+var snapshotName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// snapshots returns the snapshotter of the manager and checks name.
+func (d *Manager) snapshots(name string) (Snapshotter, error) {
+	if !snapshotName.MatchString(name) {
+		return nil, fmt.Errorf("invalid snapshot name %q: use letters, digits, '_', '.' and '-'", name)
+	}
+	if d.snapshotter != nil {
+		return d.snapshotter, nil
+	}
+	if d.dialect == DialectSQLite && d.dbDriver != nil {
+		return &SQLiteSnapshotter{Driver: d.dbDriver}, nil
+	}
+	return nil, fmt.Errorf("snapshots are not configured for %s", d.dialect)
+}
+
+// SaveSnapshot saves the database as the snapshot name, replacing an older
+// one, and returns it.
+func (d *Manager) SaveSnapshot(name string) (Snapshot, error) {
+	snapshotter, err := d.snapshots(name)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if err := os.MkdirAll(d.snapshotDir, 0755); err != nil {
+		return Snapshot{}, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	ext := ".dump"
+	if d.dialect == DialectSQLite {
+		ext = ".db"
+	} else if d.dialect == DialectMySQL {
+		ext = ".sql"
+	}
+	snapshot := Snapshot{Name: name, Dialect: d.dialect, File: name + ext, CreatedAt: time.Now().UTC()}
+	if err := snapshotter.SaveSnapshot(context.Background(), filepath.Join(d.snapshotDir, snapshot.File)); err != nil {
+		return Snapshot{}, fmt.Errorf("failed to save snapshot %s: %w", name, err)
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("failed to encode snapshot %s: %w", name, err)
+	}
+	if err := os.WriteFile(filepath.Join(d.snapshotDir, name+".json"), data, 0644); err != nil {
+		return Snapshot{}, fmt.Errorf("failed to record snapshot %s: %w", name, err)
+	}
+	logger.Info().Msgf("Saved snapshot %s to %s", name, filepath.Join(d.snapshotDir, snapshot.File))
+	return snapshot, nil
+}
+
+// RestoreSnapshot replaces the database with the snapshot name. The history
+// of a database history driver is part of the snapshot; a file history is
+// not, and is left as it is.
+func (d *Manager) RestoreSnapshot(name string) error {
+	snapshotter, err := d.snapshots(name)
+	if err != nil {
+		return err
+	}
+	snapshot, err := d.readSnapshot(name)
+	if err != nil {
+		return err
+	}
+	if snapshot.Dialect != d.dialect {
+		return fmt.Errorf("snapshot %s was taken from %s, not %s", name, snapshot.Dialect, d.dialect)
+	}
+	if err := snapshotter.RestoreSnapshot(context.Background(), filepath.Join(d.snapshotDir, snapshot.File)); err != nil {
+		return fmt.Errorf("failed to restore snapshot %s: %w", name, err)
+	}
+	logger.Info().Msgf("Restored snapshot %s taken %s", name, snapshot.CreatedAt.Local().Format(time.DateTime))
+	return nil
+}
+
+func (d *Manager) readSnapshot(name string) (Snapshot, error) {
+	data, err := os.ReadFile(filepath.Join(d.snapshotDir, name+".json"))
+	if os.IsNotExist(err) {
+		return Snapshot{}, fmt.Errorf("snapshot %s not found in %s", name, d.snapshotDir)
+	}
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("failed to read snapshot %s: %w", name, err)
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return Snapshot{}, fmt.Errorf("failed to parse snapshot %s: %w", name, err)
+	}
+	return snapshot, nil
+}
+
+// ListSnapshots returns the saved snapshots, newest first.
+func (d *Manager) ListSnapshots() ([]Snapshot, error) {
+	paths, err := filepath.Glob(filepath.Join(d.snapshotDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []Snapshot
+	for _, path := range paths {
+		snapshot, err := d.readSnapshot(strings.TrimSuffix(filepath.Base(path), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt) })
+	return snapshots, nil
+}
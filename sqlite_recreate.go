@@ -0,0 +1,140 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// sqliteStagingTable returns the temporary name of a SQLite table being
+// recreated, see SQLiteDialect.RecreateTableForAlter.
+func sqliteStagingTable(table string) string {
+	return table + "_new"
+}
+
+// sqliteSwapSQL returns the statement giving a recreated SQLite table its
+// name back, after which the objects of the original table are re-created.
+func sqliteSwapSQL(table string) string {
+	return fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", quoteDialectIdentifier(DialectSQLite, sqliteStagingTable(table)), quoteDialectIdentifier(DialectSQLite, table))
+}
+
+// sqliteForeignKeysOn ends the statements recreating a SQLite table.
+const sqliteForeignKeysOn = "PRAGMA foreign_keys=on;"
+
+// sqliteTableObject is an index or trigger of a SQLite table, as stored in
+// sqlite_master.
+type sqliteTableObject struct {
+	Kind string `db:"type"`
+	Name string `db:"name"`
+	SQL  string `db:"sql"`
+}
+
+var sqliteCreateObject = regexp.MustCompile(`(?is)^\s*CREATE\s+((?:UNIQUE\s+)?INDEX|(?:TEMP\s+|TEMPORARY\s+)?TRIGGER)\s+(?:IF\s+NOT\s+EXISTS\s+)?`)
+
+// ifNotExists returns the definition of o creating it only when missing, as
+// the recreated table may already define an index of the same name.
+func (o sqliteTableObject) ifNotExists() string {
+	return sqliteCreateObject.ReplaceAllString(o.SQL, "CREATE $1 IF NOT EXISTS ") + ";"
+}
+
+// preserveSQLiteObjects returns queries with the indexes and triggers of each
+// table recreated by them re-created after its last swap; dropping the
+// original table drops them. Triggers that op drops or creates itself are
+// left out, and so are indexes on columns that op drops or renames, with a
+// warning.
+func (d *Manager) preserveSQLiteObjects(dbDriver IDatabaseDriver, op Operation, queries []Statement) ([]Statement, error) {
+	if dbDriver == nil {
+		return queries, nil
+	}
+	triggers := make(map[string]bool)
+	for _, dt := range op.DropTrigger {
+		triggers[dt.Name] = true
+	}
+	for _, ct := range op.CreateTrigger {
+		triggers[ct.Name] = true
+	}
+	done := make(map[string]bool)
+	for _, at := range op.AlterTable {
+		if done[at.Name] {
+			continue
+		}
+		done[at.Name] = true
+		swap := sqliteSwapSQL(at.Name)
+		last := -1
+		for i, q := range queries {
+			if q.SQL == swap {
+				last = i
+			}
+		}
+		if last < 0 {
+			continue
+		}
+		objects, err := sqliteTableObjects(dbDriver, at.Name)
+		if err != nil {
+			return nil, err
+		}
+		removed := make(map[string]bool)
+		for _, other := range op.AlterTable {
+			if other.Name != at.Name {
+				continue
+			}
+			for _, df := range other.DropFields {
+				removed[df.Name] = true
+			}
+			for _, rf := range other.RenameFields {
+				removed[rf.From] = true
+			}
+		}
+		var restore []Statement
+		for _, o := range objects {
+			if o.Kind == "trigger" && triggers[o.Name] {
+				continue
+			}
+			if o.Kind == "index" {
+				var columns []string
+				if err := dbDriver.Query(context.Background(), &columns, `SELECT COALESCE(name, '') FROM pragma_index_info(?)`, o.Name); err != nil {
+					return nil, fmt.Errorf("failed to read index %s: %w", o.Name, err)
+				}
+				if cols := removedColumns(columns, removed); len(cols) > 0 {
+					logger.Warn().Msgf("Recreating table %s: index %s is not re-created as it uses %s", at.Name, o.Name, strings.Join(cols, ", "))
+					continue
+				}
+			}
+			restore = append(restore, Statement{SQL: o.ifNotExists()})
+		}
+		if len(restore) == 0 {
+			continue
+		}
+		// The objects follow those of newSchema, before foreign keys are
+		// checked again.
+		at := last + 1
+		for at < len(queries) && queries[at].SQL != sqliteForeignKeysOn {
+			at++
+		}
+		queries = append(queries[:at:at], append(restore, queries[at:]...)...)
+	}
+	return queries, nil
+}
+
+// sqliteTableObjects lists the indexes and triggers of table that are not
+// created implicitly by its constraints.
+func sqliteTableObjects(dbDriver IDatabaseDriver, table string) ([]sqliteTableObject, error) {
+	var objects []sqliteTableObject
+	err := dbDriver.Query(context.Background(), &objects,
+		`SELECT type, name, sql FROM sqlite_master WHERE type IN ('index', 'trigger') AND tbl_name = ? AND sql IS NOT NULL ORDER BY type, name`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexes and triggers of %s: %w", table, err)
+	}
+	return objects, nil
+}
+
+func removedColumns(columns []string, removed map[string]bool) []string {
+	var cols []string
+	for _, c := range columns {
+		if removed[c] {
+			cols = append(cols, c)
+		}
+	}
+	return cols
+}
@@ -0,0 +1,267 @@
+package migrate
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const browserHelp = `Commands:
+  list              list migrations with their status
+  sql <n> [down]    show the Up (or Down) SQL of migration n
+  up <n>            apply pending migration n
+  down <n>          roll back migration n and every migration applied after it
+  history           show the migration history
+  help              show this help
+  quit              leave
+`
+
+// migrationBrowser is the interactive session behind the tui command. It
+// reads one command per line from in so it works in any terminal and can be
+// scripted in tests.
+type migrationBrowser struct {
+	mgr      *Manager
+	in       *bufio.Reader
+	out      io.Writer
+	statuses []MigrationStatus
+}
+
+func newMigrationBrowser(mgr *Manager, in io.Reader, out io.Writer) *migrationBrowser {
+	return &migrationBrowser{mgr: mgr, in: bufio.NewReader(in), out: out}
+}
+
+// run lists the migrations and executes commands until quit or end of input.
+// Errors from a command are printed and the session continues.
+func (b *migrationBrowser) run() error {
+	if err := b.list(); err != nil {
+		return err
+	}
+	for {
+		fmt.Fprint(b.out, "migrate> ")
+		line, err := b.in.ReadString('\n')
+		if line == "" && err != nil {
+			fmt.Fprintln(b.out)
+			return nil
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "quit" || fields[0] == "q" || fields[0] == "exit" {
+			return nil
+		}
+		if err := b.dispatch(fields[0], fields[1:]); err != nil {
+			fmt.Fprintf(b.out, "error: %v\n", err)
+		}
+	}
+}
+
+func (b *migrationBrowser) dispatch(command string, args []string) error {
+	switch command {
+	case "list", "l":
+		return b.list()
+	case "sql", "s":
+		status, err := b.selected(args)
+		if err != nil {
+			return err
+		}
+		return b.showSQL(status, len(args) > 1 && args[1] == "down")
+	case "up", "u":
+		status, err := b.selected(args)
+		if err != nil {
+			return err
+		}
+		return b.apply(status)
+	case "down", "d":
+		status, err := b.selected(args)
+		if err != nil {
+			return err
+		}
+		return b.rollback(status)
+	case "history", "h":
+		return b.history()
+	case "help", "?":
+		fmt.Fprint(b.out, browserHelp)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q; type help", command)
+	}
+}
+
+func (b *migrationBrowser) list() error {
+	statuses, err := b.mgr.MigrationStatuses()
+	if err != nil {
+		return err
+	}
+	b.statuses = statuses
+	if len(statuses) == 0 {
+		fmt.Fprintln(b.out, "No migrations found.")
+		return nil
+	}
+	width := len(strconv.Itoa(len(statuses)))
+	for i, status := range statuses {
+		line := fmt.Sprintf("%*d  %-8s %s", width, i+1, status.State(), status.Name)
+		if status.Applied {
+			line += "  " + status.AppliedAt.Format(time.DateTime)
+		}
+		if status.Phase == PhasePostDeploy {
+			line += " (post-deploy)"
+		}
+		fmt.Fprintln(b.out, line)
+	}
+	return nil
+}
+
+// selected returns the migration numbered by args[0] in the last listing.
+func (b *migrationBrowser) selected(args []string) (MigrationStatus, error) {
+	if len(args) == 0 {
+		return MigrationStatus{}, fmt.Errorf("missing migration number")
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 || n > len(b.statuses) {
+		return MigrationStatus{}, fmt.Errorf("no migration numbered %q; type list", args[0])
+	}
+	return b.statuses[n-1], nil
+}
+
+func (b *migrationBrowser) showSQL(status MigrationStatus, down bool) error {
+	steps, err := b.mgr.offlineMigrationSteps(status.Name, status.Path, b.mgr.dialect, !down)
+	if err != nil {
+		return err
+	}
+	if len(steps) == 0 {
+		fmt.Fprintln(b.out, "-- no statements")
+		return nil
+	}
+	for i, step := range steps {
+		if len(steps) > 1 {
+			fmt.Fprintf(b.out, "-- Step %d: %s\n", i+1, step.Name)
+		}
+		for _, st := range step.Statements {
+			fmt.Fprintln(b.out, strings.TrimSpace(st.SQL))
+		}
+	}
+	return nil
+}
+
+func (b *migrationBrowser) apply(status MigrationStatus) error {
+	if status.Applied && !status.Changed {
+		return fmt.Errorf("%s is already applied", status.Name)
+	}
+	if status.Disabled {
+		return fmt.Errorf("%s is disabled", status.Name)
+	}
+	if ok, err := b.confirm(fmt.Sprintf("Apply %s?", status.Name)); err != nil || !ok {
+		return err
+	}
+	err := b.locked(func() error {
+		if strings.EqualFold(filepath.Ext(status.Path), ".sql") {
+			return b.mgr.ApplySQLMigration(status.Path)
+		}
+		cached, err := b.mgr.readMigrationsBCL(status.Path)
+		if err != nil {
+			return err
+		}
+		migration, ok := findMigrationByName(cached.migrations, status.Name)
+		if !ok {
+			return fmt.Errorf("migration %s not found in %s", status.Name, status.Path)
+		}
+		return b.mgr.ApplyMigration(migration)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(b.out, "Applied %s.\n", status.Name)
+	return b.list()
+}
+
+// rollback rolls back the applied migrations from the newest down to and
+// including status, after listing them for confirmation.
+func (b *migrationBrowser) rollback(status MigrationStatus) error {
+	histories, err := b.mgr.historyDriver.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load migration history: %w", err)
+	}
+	index := -1
+	for i, h := range histories {
+		if h.Name == status.Name {
+			index = i
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("%s is not applied", status.Name)
+	}
+	steps := len(histories) - index
+	if steps > 1 {
+		fmt.Fprintf(b.out, "Rolling back %s also rolls back, newest first:\n", status.Name)
+		for i := len(histories) - 1; i > index; i-- {
+			fmt.Fprintf(b.out, "  %s\n", histories[i].Name)
+		}
+	}
+	if ok, err := b.confirm(fmt.Sprintf("Roll back %d migration(s)?", steps)); err != nil || !ok {
+		return err
+	}
+	if err := b.locked(func() error { return b.mgr.RollbackMigration(steps) }); err != nil {
+		return err
+	}
+	fmt.Fprintf(b.out, "Rolled back %d migration(s).\n", steps)
+	return b.list()
+}
+
+func (b *migrationBrowser) history() error {
+	histories, err := b.mgr.historyDriver.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load migration history: %w", err)
+	}
+	if len(histories) == 0 {
+		fmt.Fprintln(b.out, "No migrations applied.")
+		return nil
+	}
+	for _, h := range histories {
+		fmt.Fprintf(b.out, "%s  %s  %s\n", h.AppliedAt.Format(time.DateTime), h.Name, h.Description)
+	}
+	return nil
+}
+
+// confirm asks a yes/no question. In a protected environment the user must
+// type the environment name instead.
+func (b *migrationBrowser) confirm(question string) (bool, error) {
+	expected := "y"
+	prompt := question + " [y/N] "
+	if env := b.mgr.Environment(); env.Protected {
+		expected = env.Name
+		if expected == "" {
+			expected = "production"
+		}
+		prompt = fmt.Sprintf("%s Environment '%s' is protected; type its name to continue: ", question, expected)
+	}
+	fmt.Fprint(b.out, prompt)
+	answer, err := b.in.ReadString('\n')
+	if answer == "" && err != nil {
+		return false, err
+	}
+	answer = strings.TrimSpace(answer)
+	if strings.EqualFold(answer, expected) || (expected == "y" && strings.EqualFold(answer, "yes")) {
+		return true, nil
+	}
+	fmt.Fprintln(b.out, "Cancelled.")
+	return false, nil
+}
+
+// locked runs fn while holding the migration lock.
+func (b *migrationBrowser) locked(fn func() error) error {
+	lock, err := b.mgr.acquireLock()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := lock.Release(); err != nil {
+			logger.Printf("Warning releasing lock: %v", err)
+		}
+	}()
+	return fn()
+}
@@ -0,0 +1,54 @@
+package migrate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// degradedTypes describes, per dialect, the generic data types it stores
+// as a weaker type or cannot create as declared.
+var degradedTypes = map[string]map[string]string{
+	DialectPostgres: {
+		"enum": "is stored as TEXT on PostgreSQL, without a check of the allowed values",
+		"set":  "is stored as TEXT on PostgreSQL, without a check of the allowed values",
+		"year": "is stored as INTEGER on PostgreSQL, without a range check",
+	},
+	DialectMySQL: {
+		"enum":  "maps to ENUM, which MySQL requires values for; the field cannot declare them",
+		"set":   "maps to SET, which MySQL requires values for; the field cannot declare them",
+		"jsonb": "is not a MySQL type; use json",
+	},
+	DialectSQLite: {
+		"enum":    "is stored as TEXT on SQLite, without a check of the allowed values",
+		"set":     "is stored as TEXT on SQLite, without a check of the allowed values",
+		"json":    "is stored as TEXT on SQLite, without JSON validation",
+		"jsonb":   "is stored as TEXT on SQLite, without JSON validation",
+		"decimal": "has NUMERIC affinity on SQLite, which stores fractional values as floating point",
+		"numeric": "has NUMERIC affinity on SQLite, which stores fractional values as floating point",
+		"bit":     "is stored as NUMERIC on SQLite",
+	},
+}
+
+// degradedFieldTypes lists the fields op creates or adds whose type degrades
+// on dialect, see degradedTypes.
+func degradedFieldTypes(dialect string, op Operation) []destructiveOperation {
+	degraded := degradedTypes[dialect]
+	if degraded == nil {
+		return nil
+	}
+	var ops []destructiveOperation
+	check := func(blockType, table string, fields []AddField) {
+		for _, f := range fields {
+			if msg, ok := degraded[strings.ToLower(f.Type)]; ok && f.RawType == "" {
+				ops = append(ops, destructiveOperation{blockType, f.Name, fmt.Sprintf("field %s.%s of type %s %s", table, f.Name, f.Type, msg)})
+			}
+		}
+	}
+	for _, ct := range op.CreateTable {
+		check("Field", ct.Name, ct.AddFields)
+	}
+	for _, at := range op.AlterTable {
+		check("AddField", at.Name, at.AddFields)
+	}
+	return ops
+}
@@ -0,0 +1,86 @@
+package migrate
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Languages of generate:types.
+const (
+	TypesTypeScript = "ts"
+)
+
+// tsIdentifier matches property names TypeScript accepts unquoted.
+var tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// GenerateTypes returns type declarations in lang, TypesTypeScript ("ts" or
+// "typescript"), for each table left by the migrations of the migration
+// set, empty meaning every migration. Each table becomes an exported
+// interface named like its Go model, see GenerateModels, with one property
+// per column; nullable columns also accept null.
+func (d *Manager) GenerateTypes(lang, set string) ([]byte, error) {
+	if lang != TypesTypeScript && lang != "typescript" {
+		return nil, fmt.Errorf("unsupported language %q (expected %s)", lang, TypesTypeScript)
+	}
+	tables, last, err := d.migrationSchema(set)
+	if err != nil {
+		return nil, err
+	}
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("no tables are created by the migrations of %q", set)
+	}
+	var src bytes.Buffer
+	src.WriteString("// Code generated by migrate generate:types; DO NOT EDIT.\n")
+	if last != "" {
+		fmt.Fprintf(&src, "// Schema as of migration %s.\n", last)
+	}
+	names := map[string]string{}
+	for _, t := range tables {
+		name := modelName(t.name)
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("tables %s and %s both map to the type %s", other, t.name, name)
+		}
+		names[name] = t.name
+		fmt.Fprintf(&src, "\n/** A row of the %s table. */\n", t.name)
+		fmt.Fprintf(&src, "export interface %s {\n", name)
+		for _, f := range t.fields {
+			if f.ForeignKey != nil {
+				fmt.Fprintf(&src, "  /** References %s.%s. */\n", f.ForeignKey.ReferenceTable, f.ForeignKey.ReferenceField)
+			}
+			property := f.Name
+			if !tsIdentifier.MatchString(property) {
+				property = fmt.Sprintf("%q", property)
+			}
+			fmt.Fprintf(&src, "  %s: %s;\n", property, tsFieldType(f))
+		}
+		src.WriteString("}\n")
+	}
+	return src.Bytes(), nil
+}
+
+// tsFieldType returns the TypeScript type of the JSON values of f. Dates are
+// ISO 8601 strings and binary values base64 strings, as encoded in JSON.
+func tsFieldType(f AddField) string {
+	typ := strings.ToLower(strings.TrimSpace(f.Type))
+	if i := strings.Index(typ, "("); i >= 0 {
+		typ = typ[:i]
+	}
+	tsType := "string"
+	switch {
+	case f.RawType != "", typ == "json" || typ == "jsonb":
+		return "unknown"
+	case slices.Contains([]string{"int", "integer", "bigint", "smallint", "tinyint", "mediumint"}, typ), strings.HasSuffix(typ, "serial"):
+		tsType = "number"
+	case slices.Contains([]string{"float", "double", "decimal", "numeric", "real"}, typ):
+		tsType = "number"
+	case typ == "boolean" || typ == "bool":
+		tsType = "boolean"
+	}
+	if f.Nullable {
+		tsType += " | null"
+	}
+	return tsType
+}
@@ -190,6 +190,9 @@ func init() {
 	RegisterSeedFunction("fake_int", func(args ...any) (any, error) {
 		return f.Int8(), nil
 	})
+	RegisterSeedFunction("fake_int32", func(args ...any) (any, error) {
+		return f.Int32(), nil
+	})
 	RegisterSeedFunction("fake_uint", func(args ...any) (any, error) {
 		return f.Uint8(), nil
 	})
@@ -0,0 +1,120 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ErrInconsistentMigrations is returned by ValidateMigrations when the
+// migration files and the history disagree.
+var ErrInconsistentMigrations = errors.New("inconsistent migrations")
+
+// timestampPrefix matches the timestamp that make:migration puts in front of
+// migration names, e.g. "1700000000_".
+var timestampPrefix = regexp.MustCompile(`^\d+_`)
+
+// MigrationIssues groups the inconsistencies between migration files and the
+// history, by category.
+type MigrationIssues struct {
+	// OrphanedHistory lists applied migrations whose files are missing.
+	OrphanedHistory []string
+	// Duplicates lists names recorded more than once in the history and
+	// names used by several files with different timestamps.
+	Duplicates []string
+	// NameMismatches lists BCL files holding a single migration whose name
+	// differs from the file name.
+	NameMismatches []string
+}
+
+// Empty reports whether no issues were found.
+func (i MigrationIssues) Empty() bool {
+	return len(i.OrphanedHistory) == 0 && len(i.Duplicates) == 0 && len(i.NameMismatches) == 0
+}
+
+// MigrationIssues compares the migration files with the history.
+func (d *Manager) MigrationIssues() (MigrationIssues, error) {
+	migrationMap, err := d.ListMigrationMap()
+	if err != nil {
+		return MigrationIssues{}, fmt.Errorf("failed to list migration files: %w", err)
+	}
+	histories, err := d.historyDriver.Load()
+	if err != nil {
+		return MigrationIssues{}, fmt.Errorf("failed to load migration history: %w", err)
+	}
+	return migrationIssues(migrationMap, histories), nil
+}
+
+// migrationIssues compares the migration files with histories.
+func migrationIssues(migrationMap map[string]string, histories []MigrationHistory) MigrationIssues {
+	var issues MigrationIssues
+
+	recorded := make(map[string]int, len(histories))
+	for _, h := range histories {
+		recorded[h.Name]++
+	}
+	for name, count := range recorded {
+		if _, ok := migrationMap[name]; !ok {
+			issues.OrphanedHistory = append(issues.OrphanedHistory, name)
+		}
+		if count > 1 {
+			issues.Duplicates = append(issues.Duplicates, fmt.Sprintf("%s is recorded %d times in the history", name, count))
+		}
+	}
+
+	byBase := make(map[string][]string)
+	for name := range migrationMap {
+		base := timestampPrefix.ReplaceAllString(name, "")
+		byBase[base] = append(byBase[base], name)
+	}
+	for base, names := range byBase {
+		if len(names) > 1 {
+			sort.Strings(names)
+			issues.Duplicates = append(issues.Duplicates, fmt.Sprintf("%s is used by %s", base, strings.Join(names, ", ")))
+		}
+	}
+
+	perPath := make(map[string][]string)
+	for name, path := range migrationMap {
+		perPath[path] = append(perPath[path], name)
+	}
+	for path, names := range perPath {
+		if len(names) != 1 || strings.EqualFold(filepath.Ext(path), ".sql") {
+			continue
+		}
+		stem := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		if names[0] != stem {
+			issues.NameMismatches = append(issues.NameMismatches, fmt.Sprintf("%s declares migration %s; run migration:rename %s %s", filepath.Base(path), names[0], names[0], stem))
+		}
+	}
+
+	sort.Strings(issues.OrphanedHistory)
+	sort.Strings(issues.Duplicates)
+	sort.Strings(issues.NameMismatches)
+	return issues
+}
+
+// reportMigrationIssues logs every category of issues and returns
+// ErrInconsistentMigrations with their counts.
+func reportMigrationIssues(issues MigrationIssues) error {
+	if issues.Empty() {
+		return nil
+	}
+	var counts []string
+	report := func(category string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		counts = append(counts, fmt.Sprintf("%d %s", len(items), category))
+		for _, item := range items {
+			logger.Warn().Msgf("%s: %s", category, item)
+		}
+	}
+	report("orphaned history entries", issues.OrphanedHistory)
+	report("duplicates", issues.Duplicates)
+	report("name mismatches", issues.NameMismatches)
+	return fmt.Errorf("%w: %s", ErrInconsistentMigrations, strings.Join(counts, ", "))
+}
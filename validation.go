@@ -2,8 +2,8 @@ package migrate
 
 import (
 	"fmt"
-	"regexp"
 	"strings"
+	"time"
 )
 
 // ValidationError represents a validation error with context
@@ -19,13 +19,20 @@ func (e ValidationError) Error() string {
 
 // Validator provides validation utilities for migration components
 type Validator struct {
-	errors []ValidationError
+	errors   []ValidationError
+	warnings []ValidationError
+	// Strict rejects identifiers that are reserved SQL keywords instead of
+	// warning about them; generated SQL quotes them either way.
+	Strict bool
+	// Rules are the identifier rules of ValidateIdentifier.
+	Rules IdentifierRules
 }
 
 // NewValidator creates a new validator instance
 func NewValidator() *Validator {
 	return &Validator{
 		errors: make([]ValidationError, 0),
+		Rules:  DefaultIdentifierRules,
 	}
 }
 
@@ -38,6 +45,20 @@ func (v *Validator) AddError(field, value, message string) {
 	})
 }
 
+// AddWarning adds a validation warning
+func (v *Validator) AddWarning(field, value, message string) {
+	v.warnings = append(v.warnings, ValidationError{
+		Field:   field,
+		Value:   value,
+		Message: message,
+	})
+}
+
+// Warnings returns all validation warnings
+func (v *Validator) Warnings() []ValidationError {
+	return v.warnings
+}
+
 // HasErrors returns true if there are validation errors
 func (v *Validator) HasErrors() bool {
 	return len(v.errors) > 0
@@ -69,21 +90,23 @@ func (v *Validator) ValidateIdentifier(field, value string) {
 		return
 	}
 
-	if len(value) > 64 {
-		v.AddError(field, value, "identifier too long (max 64 characters)")
+	if msg := v.Rules.check(value); msg != "" {
+		v.AddError(field, value, msg)
 		return
 	}
 
-	// Check for valid SQL identifier pattern
-	matched, _ := regexp.MatchString(`^[a-zA-Z_][a-zA-Z0-9_]*$`, value)
-	if !matched {
-		v.AddError(field, value, "identifier must start with letter or underscore and contain only alphanumeric characters and underscores")
-		return
+	if v.Rules.caseSensitive(value) {
+		v.AddWarning(field, value, "identifier has upper case letters and is case sensitive; hand-written SQL must quote it")
 	}
 
-	// Check for SQL reserved words
+	// Reserved words are quoted in generated SQL, so they are only refused
+	// in strict mode
 	if isReservedWord(value) {
-		v.AddError(field, value, "identifier is a reserved SQL keyword")
+		if v.Strict {
+			v.AddError(field, value, "identifier is a reserved SQL keyword")
+		} else {
+			v.AddWarning(field, value, "identifier is a reserved SQL keyword; generated SQL quotes it, but hand-written SQL must too")
+		}
 	}
 }
 
@@ -125,6 +148,12 @@ func (v *Validator) ValidateMigration(m Migration) {
 		v.AddError("migration.description", m.Description, "description cannot be empty")
 	}
 
+	if m.RunAfter != "" {
+		if _, err := time.Parse(time.RFC3339, m.RunAfter); err != nil {
+			v.AddError("migration.run_after", m.RunAfter, "must be an RFC 3339 time such as 2025-07-01T02:00:00Z")
+		}
+	}
+
 	// Validate Up operations
 	v.validateOperation("up", m.Up)
 
@@ -146,7 +175,9 @@ func (v *Validator) validateOperation(prefix string, op Operation) {
 		for j, col := range ct.AddFields {
 			colField := fmt.Sprintf("%s.fields[%d]", field, j)
 			v.ValidateIdentifier(colField+".name", col.Name)
-			v.ValidateDataType(colField+".type", col.Type)
+			if col.RawType == "" {
+				v.ValidateDataType(colField+".type", col.Type)
+			}
 
 			// Validate size constraints
 			if col.Size < 0 {
@@ -172,7 +203,9 @@ func (v *Validator) validateOperation(prefix string, op Operation) {
 		for j, col := range at.AddFields {
 			colField := fmt.Sprintf("%s.add_field[%d]", field, j)
 			v.ValidateIdentifier(colField+".name", col.Name)
-			v.ValidateDataType(colField+".type", col.Type)
+			if col.RawType == "" {
+				v.ValidateDataType(colField+".type", col.Type)
+			}
 		}
 
 		// Validate DropField operations
@@ -187,6 +220,20 @@ func (v *Validator) validateOperation(prefix string, op Operation) {
 			v.ValidateIdentifier(colField+".from", col.From)
 			v.ValidateIdentifier(colField+".to", col.To)
 		}
+
+		// Validate primary key changes
+		if len(at.AddPrimaryKey) > 1 || len(at.DropPrimaryKey) > 1 {
+			v.AddError(field, at.Name, "a table takes one AddPrimaryKey and one DropPrimaryKey")
+		}
+		for j, pk := range at.AddPrimaryKey {
+			pkField := fmt.Sprintf("%s.add_primary_key[%d]", field, j)
+			if len(pk.Fields) == 0 {
+				v.AddError(pkField+".fields", "", "primary key requires at least one field")
+			}
+			for k, f := range pk.Fields {
+				v.ValidateIdentifier(fmt.Sprintf("%s.fields[%d]", pkField, k), f)
+			}
+		}
 	}
 
 	// Validate DropTable operations
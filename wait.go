@@ -0,0 +1,90 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// defaultWaitTimeout bounds --wait-for-db when no --timeout is given.
+const defaultWaitTimeout = 60 * time.Second
+
+// ParseWaitTimeout parses a timeout given either as a Go duration ("120s",
+// "2m") or as a plain number of seconds. An empty value yields the default.
+func ParseWaitTimeout(value string) (time.Duration, error) {
+	if value == "" {
+		return defaultWaitTimeout, nil
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0, fmt.Errorf("invalid timeout: %s", value)
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid timeout: %s", value)
+	}
+	return d, nil
+}
+
+// retryUntil calls attempt with a growing backoff until it succeeds or
+// timeout elapses, returning the last error on timeout.
+func retryUntil(timeout time.Duration, what string, attempt func() error) error {
+	deadline := time.Now().Add(timeout)
+	backoff := 500 * time.Millisecond
+	for {
+		err := attempt()
+		if err == nil {
+			return nil
+		}
+		if time.Now().Add(backoff).After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for %s: %w", timeout, what, err)
+		}
+		logger.Warn().Msgf("Waiting for %s: %v (retrying in %s)", what, err, backoff)
+		time.Sleep(backoff)
+		if backoff < 5*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+// WaitForDatabase blocks until the database accepts connections or timeout
+// elapses. It is meant for Kubernetes Jobs and initContainers that start
+// before the database is ready.
+func WaitForDatabase(dialect, dsn string, timeout time.Duration) error {
+	return retryUntil(timeout, dialect+" database", func() error {
+		driver, err := NewDriver(dialect, dsn)
+		if err != nil {
+			return err
+		}
+		return driver.DB().Close()
+	})
+}
+
+// WaitForDatabaseFromConfig loads configPath (with environment overrides) and
+// waits for the configured database. See WaitForDatabase.
+func WaitForDatabaseFromConfig(configPath string, timeout time.Duration) error {
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	config.ApplyEnvironmentOverrides()
+	dialect, err := NormalizeDriver(config.Database.Driver)
+	if err != nil {
+		return fmt.Errorf("invalid database driver: %w", err)
+	}
+	config.Database.Driver = dialect
+	return WaitForDatabase(dialect, config.GetDSN(), timeout)
+}
+
+// waitForDatabase waits until the manager's database answers a ping.
+func (d *Manager) waitForDatabase(timeout time.Duration) error {
+	if d.dbDriver == nil {
+		return fmt.Errorf("no database driver configured")
+	}
+	return retryUntil(timeout, d.dialect+" database", func() error {
+		return d.dbDriver.Ping(context.Background())
+	})
+}